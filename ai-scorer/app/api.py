@@ -4,7 +4,7 @@ AI SCORING SERVICE – ADVISORY ML MODELS
 This service provides advisory scoring for:
 - Transaction anomaly detection (IsolationForest)
 - Fee adequacy estimation (simple regression)
-- Peer reliability scoring (future)
+- Peer reliability scoring (heuristic)
 
 Important:
 - This is ADVISORY ONLY
@@ -101,7 +101,9 @@ def score_transaction():
             "fee": 1.0,
             "fee_rate": 0.01,
             "change_ratio": 0.99,
-            "input_diversity": 1
+            "input_diversity": 1,
+            "cluster_size": 3,        # optional, advisory; not used by the model
+            "cluster_tx_count": 2     # optional, advisory; not used by the model
         }
     
     Response:
@@ -117,49 +119,10 @@ def score_transaction():
         if not data:
             return jsonify({"error": "No JSON data provided"}), 400
         
-        # Extract features (in same order as model expects)
-        features = np.array([[
-            data.get("num_inputs", 0),
-            data.get("num_outputs", 0),
-            data.get("total_input", 0.0),
-            data.get("total_output", 0.0),
-            data.get("fee", 0.0),
-            data.get("fee_rate", 0.0),
-            data.get("change_ratio", 0.0),
-            data.get("input_diversity", 0)
-        ]])
+        response = score_features(data)
+        response["message"] = "Transaction scored successfully"
         
-        # Predict anomaly score
-        # IsolationForest returns: -1 (anomaly) or 1 (normal)
-        # We convert to 0.0-1.0 scale where 1.0 = most anomalous
-        prediction = tx_anomaly_model.predict(features)[0]
-        anomaly_score = 1.0 if prediction == -1 else 0.0
-        
-        # Get anomaly score (decision function gives confidence)
-        # Lower values = more anomalous
-        decision_score = tx_anomaly_model.decision_function(features)[0]
-        # Normalize to 0.0-1.0 (inverse: higher = more anomalous)
-        # decision_score is typically in range [-0.5, 0.5]
-        normalized_score = max(0.0, min(1.0, 0.5 - decision_score))
-        anomaly_score = normalized_score
-        
-        # Calculate fee adequacy (simple heuristic)
-        # Higher fee rate = better adequacy
-        fee_rate = data.get("fee_rate", 0.0)
-        fee_adequacy = min(1.0, max(0.0, fee_rate * 100))  # Scale fee_rate
-        
-        # If fee is very low, reduce adequacy
-        fee = data.get("fee", 0.0)
-        if fee < 0.1:
-            fee_adequacy *= 0.5
-        
-        response = {
-            "anomaly_score": float(anomaly_score),
-            "fee_adequacy": float(fee_adequacy),
-            "message": "Transaction scored successfully"
-        }
-        
-        logger.info(f"Scored transaction: anomaly={anomaly_score:.2f}, fee={fee_adequacy:.2f}")
+        logger.info(f"Scored transaction: anomaly={response['anomaly_score']:.2f}, fee={response['fee_adequacy']:.2f}")
         return jsonify(response)
         
     except Exception as e:
@@ -167,17 +130,125 @@ def score_transaction():
         return jsonify({"error": str(e)}), 500
 
 
+@app.route('/score/tx/batch', methods=['POST'])
+def score_transaction_batch():
+    """
+    Score many transactions in one request. The Go node uses this to check
+    the whole mempool before mining a block.
+    
+    Request body:
+        {"transactions": [<features as for /score/tx>, ...]}   # at most 100
+    
+    Response (scores in request order):
+        {"scores": [{"anomaly_score": 0.2, "fee_adequacy": 0.8}, ...]}
+    """
+    try:
+        data = request.get_json()
+        if not data or not isinstance(data.get("transactions"), list):
+            return jsonify({"error": "Expected a transactions list"}), 400
+        
+        scores = [score_features(tx) for tx in data["transactions"]]
+        logger.info(f"Scored batch of {len(scores)} transactions")
+        return jsonify({"scores": scores})
+        
+    except Exception as e:
+        logger.error(f"Error scoring transaction batch: {e}")
+        return jsonify({"error": str(e)}), 500
+
+
+def score_features(data):
+    """
+    Score one transaction's features, as sent to /score/tx.
+    """
+    # Extract features (in same order as model expects)
+    features = np.array([[
+        data.get("num_inputs", 0),
+        data.get("num_outputs", 0),
+        data.get("total_input", 0.0),
+        data.get("total_output", 0.0),
+        data.get("fee", 0.0),
+        data.get("fee_rate", 0.0),
+        data.get("change_ratio", 0.0),
+        data.get("input_diversity", 0)
+    ]])
+    
+    # Get anomaly score (decision function gives confidence)
+    # Lower values = more anomalous
+    decision_score = tx_anomaly_model.decision_function(features)[0]
+    # Normalize to 0.0-1.0 (inverse: higher = more anomalous)
+    # decision_score is typically in range [-0.5, 0.5]
+    anomaly_score = max(0.0, min(1.0, 0.5 - decision_score))
+    
+    # Calculate fee adequacy (simple heuristic)
+    # Higher fee rate = better adequacy
+    fee_rate = data.get("fee_rate", 0.0)
+    fee_adequacy = min(1.0, max(0.0, fee_rate * 100))  # Scale fee_rate
+    
+    # If fee is very low, reduce adequacy
+    fee = data.get("fee", 0.0)
+    if fee < 0.1:
+        fee_adequacy *= 0.5
+    
+    return {
+        "anomaly_score": float(anomaly_score),
+        "fee_adequacy": float(fee_adequacy)
+    }
+
+
 @app.route('/score/peer', methods=['POST'])
 def score_peer():
     """
-    Score a peer for reliability (future implementation).
+    Score a peer for reliability.
+    
+    Request body (sent by the Go node for every connected peer):
+        {
+            "inbound": false,
+            "connected_seconds": 600.0,
+            "ping_ms": 12.5,
+            "block_delivery_ms": 80.0,
+            "blocks_received": 42,
+            "invalid_blocks": 0,     # counts cover every connection from the host
+            "invalid_txs": 1,
+            "disconnects": 2
+        }
+    
+    Response:
+        {
+            "reliability_score": 0.9,  # 0.0 = unreliable, 1.0 = reliable
+            "message": "Peer scored successfully"
+        }
     
-    For now, returns a placeholder response.
+    There is no labelled data to train on yet, so this is a heuristic:
+    invalid blocks weigh most, then churn and invalid transactions, then
+    slow responses. Peers that have delivered blocks earn some credit back.
     """
-    return jsonify({
-        "reliability_score": 0.5,
-        "message": "Peer scoring not yet implemented"
-    })
+    try:
+        data = request.get_json()
+        if not data:
+            return jsonify({"error": "No JSON data provided"}), 400
+        
+        score = 1.0
+        score -= 0.4 * data.get("invalid_blocks", 0)
+        score -= 0.1 * min(data.get("disconnects", 0), 5)
+        score -= 0.02 * min(data.get("invalid_txs", 0), 10)
+        
+        # Latency: no penalty under 200ms, up to 0.2 at 2s and beyond
+        latency = max(data.get("ping_ms", 0.0), data.get("block_delivery_ms", 0.0) / 5)
+        score -= 0.2 * min(1.0, max(0.0, (latency - 200) / 1800))
+        
+        if data.get("blocks_received", 0) > 0 and data.get("invalid_blocks", 0) == 0:
+            score += 0.1
+        
+        score = min(1.0, max(0.0, score))
+        logger.info(f"Scored peer: reliability={score:.2f}")
+        return jsonify({
+            "reliability_score": float(score),
+            "message": "Peer scored successfully"
+        })
+        
+    except Exception as e:
+        logger.error(f"Error scoring peer: {e}")
+        return jsonify({"error": str(e)}), 500
 
 
 if __name__ == '__main__':
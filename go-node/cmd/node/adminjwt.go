@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/api"
+)
+
+// runAdminJWT prints an admin API token signed with the secret a node was
+// started with as -admin-jwt-secret.
+func runAdminJWT(args []string) {
+	fs := flag.NewFlagSet("admin-jwt", flag.ExitOnError)
+	secretEnv := fs.String("secret-env", "ADMIN_JWT_SECRET", "Environment variable holding the node's -admin-jwt-secret")
+	subject := fs.String("subject", "", "Who the token is for; logged with every admin request it makes")
+	ttl := fs.Duration("ttl", time.Hour, "How long the token stays valid")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: node admin-jwt [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	secret := os.Getenv(*secretEnv)
+	if secret == "" {
+		log.Fatalf("admin-jwt: $%s is not set", *secretEnv)
+	}
+	if *ttl <= 0 {
+		log.Fatal("admin-jwt: -ttl must be positive")
+	}
+	token, err := api.NewAdminJWT([]byte(secret), *subject, *ttl)
+	if err != nil {
+		log.Fatalf("admin-jwt: %v", err)
+	}
+	fmt.Println(token)
+}
+
+// parseAdminKeys reads -admin-keys: comma-separated name=token pairs.
+func parseAdminKeys(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	tokens := make(map[string]bool)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(pair, "=")
+		name, token = strings.TrimSpace(name), strings.TrimSpace(token)
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("admin key %q: want name=token", pair)
+		}
+		if _, dup := keys[name]; dup {
+			return nil, fmt.Errorf("admin key %q given twice", name)
+		}
+		if tokens[token] {
+			return nil, fmt.Errorf("admin key %q reuses another key's token", name)
+		}
+		keys[name], tokens[token] = token, true
+	}
+	return keys, nil
+}
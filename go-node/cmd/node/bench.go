@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type benchClient struct {
+	base string
+	http *http.Client
+}
+
+func (c *benchClient) do(method, path string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.base+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("%s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		return resp.StatusCode, json.Unmarshal(data, out)
+	}
+	return resp.StatusCode, nil
+}
+
+type minedBlock struct {
+	Block chain.Block `json:"block"`
+}
+
+func (c *benchClient) mine() (*chain.Block, error) {
+	var resp minedBlock
+	if _, err := c.do(http.MethodPost, "/mine", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Block, nil
+}
+
+func (c *benchClient) richestNodeWallet() (string, float64, error) {
+	var list struct {
+		Addresses []string `json:"addresses"`
+	}
+	if _, err := c.do(http.MethodGet, "/api/wallet/list", nil, &list); err != nil {
+		return "", 0, err
+	}
+
+	var best string
+	var bestBalance float64
+	for _, addr := range list.Addresses {
+		var bal struct {
+			Balance float64 `json:"balance"`
+		}
+		if _, err := c.do(http.MethodGet, "/balance/"+addr, nil, &bal); err != nil {
+			return "", 0, err
+		}
+		if bal.Balance > bestBalance {
+			best, bestBalance = addr, bal.Balance
+		}
+	}
+	if best == "" {
+		return "", 0, fmt.Errorf("node has no funded wallet to draw from")
+	}
+	return best, bestBalance, nil
+}
+
+func signBenchTx(w *wallet.Wallet, inputs []chain.TxIn, outputs []chain.TxOut) (*chain.Transaction, error) {
+	tx, err := chain.NewTransaction(inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := chain.CanonicalTxBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	tx.Signature, err = crypto.SignMessage(w.PrivateKey, canonical)
+	if err != nil {
+		return nil, err
+	}
+	tx.PubKey = crypto.EncodePublicKey(w.PublicKey)
+	return tx, nil
+}
+
+// findOutput locates the output of txid in block that pays address.
+func findOutput(block *chain.Block, txid, address string) (chain.TxIn, bool) {
+	for _, tx := range block.Transactions {
+		if tx.ID != txid {
+			continue
+		}
+		for i, out := range tx.Outputs {
+			if out.Address == address {
+				return chain.TxIn{TxID: txid, Index: i}, true
+			}
+		}
+	}
+	return chain.TxIn{}, false
+}
+
+type benchCoin struct {
+	owner int
+	key   chain.UTXOKey
+	out   chain.TxOut
+}
+
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	target := fs.String("target", "http://localhost:8080", "Base URL of the node under test")
+	tps := fs.Int("tps", 10, "Target transactions per second")
+	duration := fs.Duration("duration", 30*time.Second, "How long to drive load")
+	numWallets := fs.Int("wallets", 10, "Number of bench wallets to generate")
+	mineEvery := fs.Duration("mine-every", 5*time.Second, "Mine a block this often during the run (0 = only at the end)")
+	fs.Parse(args)
+
+	client := &benchClient{base: strings.TrimRight(*target, "/") + "/v1", http: &http.Client{Timeout: 10 * time.Minute}}
+	totalTxs := *tps * int(duration.Seconds())
+	if totalTxs <= 0 {
+		log.Fatalf("Nothing to do: tps=%d duration=%v", *tps, *duration)
+	}
+
+	store := wallet.NewWalletStore()
+	wallets := make([]*wallet.Wallet, *numWallets)
+	for i := range wallets {
+		w, err := store.GenerateWallet()
+		if err != nil {
+			log.Fatalf("Failed to generate wallet: %v", err)
+		}
+		wallets[i] = w
+	}
+	log.Printf("Generated %d bench wallets", len(wallets))
+
+	source, balance, err := client.richestNodeWallet()
+	if err != nil {
+		log.Fatalf("Failed to find funding source: %v", err)
+	}
+	funding := math.Floor(balance/2*1e4) / 1e4
+
+	var transfer struct {
+		TxID string `json:"txid"`
+	}
+	if _, err := client.do(http.MethodPost, "/api/wallet/transfer", map[string]interface{}{
+		"from":   source,
+		"to":     wallets[0].Address,
+		"amount": funding,
+	}, &transfer); err != nil {
+		log.Fatalf("Failed to fund bench wallet: %v", err)
+	}
+	fundingBlock, err := client.mine()
+	if err != nil {
+		log.Fatalf("Failed to mine funding block: %v", err)
+	}
+	fundingInput, ok := findOutput(fundingBlock, transfer.TxID, wallets[0].Address)
+	if !ok {
+		log.Fatalf("Funding transaction %s not found in mined block", transfer.TxID)
+	}
+	log.Printf("Funded bench wallet with %.8f coins from %s", funding, source)
+
+	perCoin := math.Floor(funding/float64(totalTxs)*1e6) / 1e6
+	outputs := make([]chain.TxOut, totalTxs)
+	for i := range outputs {
+		outputs[i] = chain.TxOut{Address: wallets[i%len(wallets)].Address, Amount: perCoin}
+	}
+	fanOut, err := signBenchTx(wallets[0], []chain.TxIn{fundingInput}, outputs)
+	if err != nil {
+		log.Fatalf("Failed to build fan-out transaction: %v", err)
+	}
+	if _, err := client.do(http.MethodPost, "/transactions", fanOut, nil); err != nil {
+		log.Fatalf("Failed to submit fan-out transaction: %v", err)
+	}
+	if _, err := client.mine(); err != nil {
+		log.Fatalf("Failed to mine fan-out block: %v", err)
+	}
+
+	coins := make([]benchCoin, totalTxs)
+	owners := make(map[string]int, len(wallets))
+	for i, w := range wallets {
+		owners[w.Address] = i
+	}
+	// Outputs are in canonical order now, not the order they were built in.
+	for i, out := range fanOut.Outputs {
+		coins[i] = benchCoin{owner: owners[out.Address], key: chain.UTXOKey{TxID: fanOut.ID, Index: i}, out: out}
+	}
+
+	txs := make([]*chain.Transaction, totalTxs)
+	for i, coin := range coins {
+		to := wallets[(coin.owner+1)%len(wallets)].Address
+		txs[i], err = signBenchTx(wallets[coin.owner],
+			[]chain.TxIn{{TxID: coin.key.TxID, Index: coin.key.Index}},
+			[]chain.TxOut{{Address: to, Amount: coin.out.Amount}})
+		if err != nil {
+			log.Fatalf("Failed to sign transaction: %v", err)
+		}
+	}
+	log.Printf("Prepared %d signed transactions; driving %d tx/s for %v", totalTxs, *tps, *duration)
+
+	var (
+		mu         sync.Mutex
+		latencies  []time.Duration
+		submitted  = make(map[string]time.Time)
+		inclusions []time.Duration
+		rejected   int
+		wg         sync.WaitGroup
+	)
+
+	collect := func(block *chain.Block, at time.Time) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, tx := range block.Transactions {
+			if sent, ok := submitted[tx.ID]; ok {
+				inclusions = append(inclusions, at.Sub(sent))
+				delete(submitted, tx.ID)
+			}
+		}
+	}
+
+	stopMining := make(chan struct{})
+	miningDone := make(chan struct{})
+	go func() {
+		defer close(miningDone)
+		if *mineEvery <= 0 {
+			return
+		}
+		ticker := time.NewTicker(*mineEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopMining:
+				return
+			case <-ticker.C:
+				if block, err := client.mine(); err == nil {
+					collect(block, time.Now())
+				}
+			}
+		}
+	}()
+
+	start := time.Now()
+	interval := time.Second / time.Duration(*tps)
+	ticker := time.NewTicker(interval)
+	for _, tx := range txs {
+		<-ticker.C
+		wg.Add(1)
+		go func(tx *chain.Transaction) {
+			defer wg.Done()
+			sent := time.Now()
+			mu.Lock()
+			submitted[tx.ID] = sent
+			mu.Unlock()
+
+			_, err := client.do(http.MethodPost, "/transactions", tx, nil)
+			elapsed := time.Since(sent)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				rejected++
+				delete(submitted, tx.ID)
+				return
+			}
+			latencies = append(latencies, elapsed)
+		}(tx)
+	}
+	ticker.Stop()
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	close(stopMining)
+	<-miningDone
+	if block, err := client.mine(); err == nil {
+		collect(block, time.Now())
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	sort.Slice(inclusions, func(i, j int) bool { return inclusions[i] < inclusions[j] })
+
+	accepted := len(latencies)
+	log.Printf("Bench complete: %d submitted, %d accepted, %d rejected in %v", totalTxs, accepted, rejected, elapsed.Round(time.Millisecond))
+	log.Printf("  achieved TPS:        %.2f (target %d)", float64(accepted)/elapsed.Seconds(), *tps)
+	log.Printf("  admission latency:   p50=%v p90=%v p99=%v", percentile(latencies, 0.50), percentile(latencies, 0.90), percentile(latencies, 0.99))
+	log.Printf("  inclusion delay:     p50=%v p90=%v p99=%v (%d included, %d still pending)",
+		percentile(inclusions, 0.50), percentile(inclusions, 0.90), percentile(inclusions, 0.99), len(inclusions), len(submitted))
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"runtime"
+	"time"
+
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/miner"
+)
+
+// runBenchMining measures proof-of-work hash rate with increasing thread
+// counts, without a running node, to show what -mining-threads buys on this
+// machine.
+func runBenchMining(args []string) {
+	fs := flag.NewFlagSet("bench-mining", flag.ExitOnError)
+	maxThreads := fs.Int("threads", runtime.NumCPU(), "Highest thread count to measure (counts double from 1)")
+	duration := fs.Duration("duration", 3*time.Second, "How long to hash at each thread count")
+	powAlgorithm := fs.String("pow-algorithm", string(consensus.SHA256), "Proof-of-work hash to measure: sha256, sha256d, blake3 or scrypt-lite")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: node bench-mining [flags]\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	algorithm, err := consensus.ParseAlgorithm(*powAlgorithm)
+	if err != nil {
+		log.Fatalf("Bench: %v", err)
+	}
+
+	var counts []int
+	for n := 1; n < *maxThreads; n *= 2 {
+		counts = append(counts, n)
+	}
+	counts = append(counts, *maxThreads)
+
+	fmt.Printf("%-8s %14s %8s\n", "threads", "hashes/s", "speedup")
+	var base float64
+	for _, threads := range counts {
+		rate := miner.MeasureHashRate(context.Background(), algorithm.HeaderName(), threads, *duration)
+		if base == 0 {
+			base = rate
+		}
+		fmt.Printf("%-8d %14.0f %7.2fx\n", threads, rate, rate/base)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/p2p"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// createGenesis starts a new network whose blocks are hashed with algorithm.
+func createGenesis(walletStore *wallet.WalletStore, algorithm consensus.Algorithm) (*chain.Block, *wallet.Wallet) {
+	defaultWallet, err := walletStore.GenerateWallet()
+	if err != nil {
+		log.Fatalf("Failed to create default wallet for genesis: %v", err)
+	}
+	log.Printf("Default wallet created for genesis: %s", defaultWallet.Address)
+
+	genesisOutput := chain.TxOut{
+		Address: defaultWallet.Address,
+		Amount:  1000.0,
+	}
+
+	genesisTx, err := chain.NewTransaction(
+		[]chain.TxIn{}, // No inputs (genesis creates coins)
+		[]chain.TxOut{genesisOutput},
+	)
+	if err != nil {
+		log.Fatalf("Failed to create genesis transaction: %v", err)
+	}
+
+	genesisTx.Signature = "genesis"
+	genesisTx.PubKey = "genesis"
+
+	genesisBlock := chain.NewBlock(
+		0,
+		"0",
+		[]chain.Transaction{*genesisTx},
+	)
+	genesisBlock.PowAlgorithm = algorithm.HeaderName()
+	genesisBlock.Hash = genesisBlock.ComputeHash()
+
+	return genesisBlock, defaultWallet
+}
+
+// fetchPeerGenesis adopts the genesis block and consensus parameters of the
+// first reachable peer so a new node joins the existing network instead of
+// starting its own chain.
+func fetchPeerGenesis(peers []string) (*chain.Block, *p2p.ConsensusParams) {
+	for _, addr := range peers {
+		genesis, params, err := p2p.FetchGenesis(addr)
+		if err != nil {
+			log.Printf("Could not fetch genesis from peer %s: %v", addr, err)
+			continue
+		}
+		log.Printf("Adopted genesis block from peer %s", addr)
+		return genesis, params
+	}
+	log.Println("No peer reachable; creating a new genesis block")
+	return nil, nil
+}
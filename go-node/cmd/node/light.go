@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"ai-blockchain/go-node/internal/api"
+	"ai-blockchain/go-node/internal/light"
+)
+
+// runLight runs the node as a header-only light client of the given full
+// nodes instead of starting a full node.
+func runLight(listen []string, peers []string, interval, shutdownTimeout time.Duration) {
+	client, err := light.New(peers, interval)
+	if err != nil {
+		log.Fatalf("Failed to start light client: %v", err)
+	}
+	params := client.Params()
+	log.Printf("Light client mode: syncing headers from %d peer(s), proof of work %s, initial difficulty %d",
+		len(peers), client.PowAlgorithm(), params.InitialDifficulty)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go client.Run(ctx)
+
+	listeners, err := api.ListenAll(listen)
+	if err != nil {
+		log.Fatalf("Light client API failed: %v", err)
+	}
+	server := &http.Server{Handler: client.Handler()}
+	go func() {
+		if err := api.ServeAll(server, listeners); err != nil {
+			log.Fatalf("Light client API failed: %v", err)
+		}
+	}()
+
+	log.Printf("Light client API listening on %s", strings.Join(listen, ", "))
+	log.Println("Available endpoints:")
+	log.Println("  GET  /light/status    - Header sync status")
+	log.Println("  GET  /headers         - Validated block headers (?from=&limit=)")
+	log.Println("  GET  /light/tx/:txid  - Verify a transaction with a Merkle proof from a peer")
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	<-sigChan
+
+	log.Println("\nShutting down gracefully...")
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("API server did not drain in time: %v", err)
+	}
+	log.Println("Light client stopped")
+}
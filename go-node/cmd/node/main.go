@@ -1,72 +1,277 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
 	"ai-blockchain/go-node/internal/ai"
+	"ai-blockchain/go-node/internal/analytics"
 	"ai-blockchain/go-node/internal/api"
+	"ai-blockchain/go-node/internal/archive"
 	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/cluster"
+	"ai-blockchain/go-node/internal/config"
 	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/follower"
+	"ai-blockchain/go-node/internal/metrics"
+	"ai-blockchain/go-node/internal/miner"
+	"ai-blockchain/go-node/internal/notify"
+	"ai-blockchain/go-node/internal/p2p"
+	"ai-blockchain/go-node/internal/quarantine"
 	"ai-blockchain/go-node/internal/wallet"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		case "bench-mining":
+			runBenchMining(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "admin-jwt":
+			runAdminJWT(os.Args[2:])
+			return
+		}
+	}
+
 	port := flag.String("port", "8080", "API server port")
-	difficulty := flag.Int("difficulty", consensus.DefaultDifficulty, "Mining difficulty")
+	listen := flag.String("listen", "", "Comma-separated addresses to serve the API on instead of every interface on -port: host:port, [ipv6]:port or unix:///path/to/node.sock")
+	difficulty := flag.Int("difficulty", consensus.DefaultDifficulty, "Mining difficulty (the starting difficulty when -retarget-interval is set)")
+	retargetInterval := flag.Int("retarget-interval", 0, "Adjust difficulty every N blocks towards -target-block-time (0 = fixed difficulty; must match across the network)")
+	targetBlockTime := flag.Duration("target-block-time", 30*time.Second, "Block interval difficulty adjustment aims for")
+	powAlgorithm := flag.String("pow-algorithm", string(consensus.SHA256), "Proof-of-work hash for a new network: sha256, sha256d, blake3 or scrypt-lite (networks joined with -peers or -follow use theirs)")
 	aiURL := flag.String("ai-url", "", "AI service URL (empty = disabled)")
 	aiTimeout := flag.Int("ai-timeout", 5, "AI service timeout in seconds")
+	aiPeerInterval := flag.Duration("ai-peer-score-interval", p2p.DefaultPeerScoreInterval, "How often connected peers are scored for reliability by the AI service")
+	aiPeerDeprioritize := flag.Float64("ai-peer-deprioritize-below", 0.3, "Download blocks from peers with a lower AI reliability score only as a last resort")
+	aiPeerBan := flag.Float64("ai-peer-ban-below", 0, "Ban peers whose AI reliability score falls below this (0 = never)")
+	minRelayFee := flag.Float64("min-relay-fee", 0, "Minimum transaction fee accepted into the mempool")
+	maxDescendants := flag.Int("mempool-max-descendants", chain.DefaultMaxDescendants, "Most pending descendants a pending transaction may have, itself included (0 = no limit)")
+	deprioritizeAnomaly := flag.Float64("mempool-deprioritize-anomaly", chain.DefaultDeprioritizeAnomaly, "Mine transactions whose AI anomaly score exceeds this after all others (0 = order by fee rate only)")
+	minFeeBump := flag.Float64("rbf-min-fee-bump", chain.DefaultMinFeeBump, "How much more a replacement transaction must pay than the transactions it evicts")
+	minRelayFeeRate := flag.Float64("min-relay-fee-rate", 0, "Minimum fee per 1000 bytes of binary encoding accepted into the mempool")
+	minerIdentity := flag.String("miner-identity", "", "Wallet address whose key names and signs the blocks this node mines (must be in the wallet store; empty = anonymous blocks)")
+	minerAddress := flag.String("miner-address", "", "Default address paid the block reward and fees by /mine (empty = no coinbase unless the request names one)")
+	blockReward := flag.Float64("block-reward", consensus.DefaultBlockReward, "Block subsidy a coinbase may claim on top of fees (must match across the network)")
+	autoMine := flag.Bool("auto-mine", false, "Mine blocks in the background instead of waiting for POST /mine")
+	autoMineInterval := flag.Duration("auto-mine-interval", 0, "With -auto-mine, mine once per interval (0 = whenever the mempool has transactions)")
+	walletFile := flag.String("wallet-file", "", "Encrypted keystore to load wallets from and save new wallets to (empty = keys in memory only)")
+	passphraseEnv := flag.String("wallet-passphrase-env", "WALLET_PASSPHRASE", "Environment variable holding the keystore passphrase; prompts on the terminal if unset")
+	freshChange := flag.Bool("wallet-fresh-change", false, "Send the change of wallet transfers to a newly generated wallet instead of back to the sender (per request: fresh_change)")
+	requireUnlock := flag.Bool("wallet-require-unlock", false, "Require an unlock session (POST /api/wallet/unlock) for wallet transfers and new schedules")
+	consolidateQuota := flag.Int("wallet-consolidate-quota", wallet.DefaultConsolidationPolicy().MaxSmallOutputs, "Suggest consolidating an address holding more small outputs than this")
+	devSeed := flag.String("dev-seed", "", "Derive wallet keys, HD mnemonics and the P2P node ID from this string so addresses are the same on every run (development only: anyone who knows it has the keys)")
+	consolidateBelow := flag.Float64("wallet-consolidate-below", wallet.DefaultConsolidationPolicy().SmallBelow, "Outputs worth less than this count as small for -wallet-consolidate-quota")
+	maxMempool := flag.Int("mempool-max-txs", chain.DefaultMaxMempoolSize, "Maximum number of pending transactions")
+	maxBlockTxs := flag.Int("max-block-txs", 0, "Maximum mempool transactions per mined block, highest fee rate first (0 = all)")
+	miningThreads := flag.Int("mining-threads", runtime.NumCPU(), "Goroutines searching for a block's nonce in parallel")
+	notifyWebhook := flag.String("notify-webhook", "", "Webhook URL for operator alerts (JSON POST)")
+	notifySlack := flag.String("notify-slack", "", "Slack incoming webhook URL for operator alerts")
+	notifySMTP := flag.String("notify-smtp", "", "SMTP server host:port for email alerts")
+	notifySMTPUser := flag.String("notify-smtp-user", "", "SMTP username")
+	notifySMTPPass := flag.String("notify-smtp-pass", "", "SMTP password")
+	notifyEmailFrom := flag.String("notify-email-from", "", "Sender address for email alerts")
+	notifyEmailTo := flag.String("notify-email-to", "", "Comma-separated recipients for email alerts")
+	stallMinutes := flag.Int("notify-stall-minutes", 30, "Alert when no block is produced for this many minutes (0 = off)")
+	reorgDepth := flag.Int("notify-reorg-depth", 3, "Alert on reorgs deeper than this many blocks (0 = off)")
+	aiDownMinutes := flag.Int("notify-ai-down-minutes", 5, "Alert when the AI service is down for this many minutes (0 = off)")
+	mempoolAlert := flag.Int("notify-mempool-threshold", 1000, "Alert when the mempool exceeds this many transactions (0 = off)")
+	notifyQueueFile := flag.String("notify-queue-file", "", "File to keep undelivered alerts in across restarts while they are retried (empty = retry queue kept in memory)")
+	notifyMaxAttempts := flag.Int("notify-max-attempts", notify.DefaultMaxAttempts, "Delivery attempts per alert and sink before it becomes a dead letter (GET /admin/notifications)")
+	notifyMaxBackoff := flag.Duration("notify-max-backoff", notify.DefaultMaxBackoff, "Longest wait between retries of an undelivered alert; waits double from 10s up to this")
+	adminToken := flag.String("admin-token", "", "Bearer token for /admin endpoints, wallet, mining and peer management (empty = admin API disabled)")
+	adminJWTSecret := flag.String("admin-jwt-secret", "", "Also accept HS256 JWTs signed with this secret as admin credentials (mint with 'node admin-jwt')")
+	adminKeyList := flag.String("admin-keys", "", "Named admin tokens, one per operator, as name=token,name=token")
+	adminApprovals := flag.Int("admin-approvals", 1, "Distinct admin credentials needed to freeze the chain, clear the mempool or reindex; above 1 these become proposals approved via /admin/proposals")
+	maxHeavy := flag.Int("max-heavy-requests", api.DefaultHeavyConcurrency, "Maximum concurrently running expensive API handlers (0 = unlimited)")
+	maxMining := flag.Int("max-concurrent-mine", 1, "Maximum concurrent /mine requests (0 = unlimited)")
+	maxBlocks := flag.Int("max-concurrent-blocks", 2, "Maximum concurrent /blocks requests (0 = unlimited)")
+	rateTransactions := flag.String("rate-limit-transactions", "", "Per-IP limit on POST /transactions, as requests/unit, e.g. 60/m (empty = unlimited)")
+	rateMine := flag.String("rate-limit-mine", "", "Per-IP limit on /mine, e.g. 10/m (empty = unlimited)")
+	rateWallet := flag.String("rate-limit-wallet", "", "Per-IP limit on /api/wallet endpoints, e.g. 120/m (empty = unlimited)")
+	trustedProxies := flag.String("trusted-proxies", "", "Comma-separated IPs or CIDR ranges of reverse proxies whose X-Forwarded-For header names the client for rate limiting")
+	cacheTTL := flag.Duration("query-cache-ttl", api.DefaultQueryCacheTTL, "How long balance, address output and rich-list results are cached; a new block empties the cache (0 = off)")
+	cacheSize := flag.Int("query-cache-size", api.DefaultQueryCacheSize, "Maximum cached query results")
+	refreshFee := flag.Float64("refresh-template-fee", 0, "Restart mining with a fresh template when a transaction paying at least this fee arrives (0 = off)")
+	refreshInterval := flag.Duration("refresh-template-interval", 10*time.Second, "Minimum time between mining template refreshes")
+	refreshMax := flag.Int("refresh-template-max", 3, "Maximum template refreshes per block")
+	clusterNodes := flag.String("cluster-nodes", "", "Comma-separated sibling node URLs for GET /cluster/status")
+	clusterLag := flag.Int("cluster-lag-threshold", cluster.DefaultLagThreshold, "Blocks behind the best sibling before a node is reported as lagging")
+	follow := flag.String("follow", "", "Run as a read replica of the primary node at this URL")
+	followInterval := flag.Duration("follow-interval", 2*time.Second, "How often a read replica polls its primary")
+	lightPeers := flag.String("light", "", "Run as a header-only light client of these comma-separated full-node URLs")
+	lightInterval := flag.Duration("light-interval", 5*time.Second, "How often a light client polls its peers for new headers")
+	p2pListen := flag.String("listen-p2p", "", "TCP address to accept P2P connections on, e.g. :9000 (empty = outbound only)")
+	peerList := flag.String("peers", "", "Comma-separated host:port list of P2P peers to connect to")
+	clockSkewWarn := flag.Duration("clock-skew-warn", chain.DefaultClockSkewWarning, "Warn when the median P2P peer clock differs from the local clock by more than this (0 = never)")
+	syncWindow := flag.Int("sync-window", p2p.DefaultSyncWindow, "Block batches of 100 requested at once when catching up with a peer; bounds sync memory use")
+	txRelayDelay := flag.Duration("tx-relay-delay", 0, "Mean random delay before relaying transactions to each peer, e.g. 2s, to hide which node they came from (0 = relay immediately)")
+	txRelayBatch := flag.Int("tx-relay-batch", p2p.DefaultTxRelayBatch, "With -tx-relay-delay, maximum transactions sent to a peer per flush")
+	dandelion := flag.Bool("dandelion", false, "Relay transactions submitted to this node along a random stem of peers before they are broadcast")
+	dandelionFluff := flag.Float64("dandelion-fluff", p2p.DefaultFluffProbability, "With -dandelion, probability that each relay ends the stem and broadcasts")
+	dandelionEmbargo := flag.Duration("dandelion-embargo", p2p.DefaultStemEmbargo, "With -dandelion, how long a stemmed transaction may go unseen before this node broadcasts it itself")
+	archiveDir := flag.String("archive-dir", "", "Directory to append main-chain blocks to as blocks.dat and blocks.idx, served under /archive (empty = no archive)")
+	banFile := flag.String("ban-file", "", "File to persist P2P peer bans in across restarts (empty = bans kept in memory)")
+	p2pAllow := flag.String("p2p-allow", "", "Comma-separated IPs, CIDR ranges or hostnames; when set only these hosts may connect inbound")
+	recordFile := flag.String("record", "", "Record state-changing API calls to this file for 'node replay'")
+	quarantineDir := flag.String("quarantine-dir", "", "Directory to keep rejected blocks and transactions in for later analysis (empty = off)")
+	quarantineMaxMB := flag.Int64("quarantine-max-mb", quarantine.DefaultMaxBytes>>20, "Size cap for the quarantine directory; oldest entries are deleted first")
+	quarantineOn := flag.Bool("quarantine-enabled", true, "Start with quarantine recording on (toggle at runtime via POST /admin/quarantine)")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 15*time.Second, "How long in-flight API requests may take to finish on shutdown")
+	logLevel := flag.String("log-level", "info", "Minimum level logged: debug, info, warn or error")
+	logFormat := flag.String("log-format", "text", "Log output format: text or json")
+	configFile := flag.String("config", "", "YAML or TOML file of settings named after these flags; flags given on the command line take precedence")
 	flag.Parse()
 
-	log.Println("Starting blockchain node...")
-	log.Printf("Port: %s, Difficulty: %d", *port, *difficulty)
+	var configured []string
+	if *configFile != "" {
+		settings, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		if configured, err = settings.Apply(flag.CommandLine); err != nil {
+			log.Fatalf("Invalid configuration: %v", err)
+		}
+	}
 
-	walletStore := wallet.NewWalletStore()
-	log.Println("Wallet store initialized")
+	if err := setupLogging(*logLevel, *logFormat); err != nil {
+		log.Fatal(err)
+	}
+	if *configFile != "" {
+		log.Printf("Loaded %d settings from %s", len(configured), *configFile)
+	}
 
-	defaultWallet, err := walletStore.GenerateWallet()
+	build := buildInfo()
+	selfTest, err := runSelfTest()
 	if err != nil {
-		log.Fatalf("Failed to create default wallet for genesis: %v", err)
+		log.Fatalf("Startup self-test failed: %v", err)
 	}
-	log.Printf("Default wallet created for genesis: %s", defaultWallet.Address)
+	log.Printf("Self-test passed in %.1fms: %s", selfTest.DurationMs, strings.Join(selfTest.Checks, ", "))
 
-	genesisOutput := chain.TxOut{
-		Address: defaultWallet.Address,
-		Amount:  1000.0,
+	if *lightPeers != "" {
+		if *follow != "" || *peerList != "" {
+			log.Fatal("-light cannot be combined with -follow or -peers")
+		}
+		runLight(api.ListenAddresses(*listen, *port), strings.Split(*lightPeers, ","), *lightInterval, *shutdownTimeout)
+		return
 	}
-	
-	genesisTx, err := chain.NewTransaction(
-		[]chain.TxIn{}, // No inputs (genesis creates coins)
-		[]chain.TxOut{genesisOutput},
-	)
+
+	algorithm, err := consensus.ParseAlgorithm(*powAlgorithm)
 	if err != nil {
-		log.Fatalf("Failed to create genesis transaction: %v", err)
+		log.Fatalf("Invalid -pow-algorithm: %v", err)
 	}
-	
-	genesisTx.Signature = "genesis"
-	genesisTx.PubKey = "genesis"
 
-	genesisBlock := chain.NewBlock(
-		0,
-		"0",
-		[]chain.Transaction{*genesisTx},
-	)
+	log.Printf("Starting blockchain node (version %s)...", build.Version)
+	if build.Commit != "" {
+		log.Printf("Built from commit %s (modified: %v)", build.Commit, build.Modified)
+	}
+	log.Printf("API: %s, Difficulty: %d", strings.Join(api.ListenAddresses(*listen, *port), ", "), *difficulty)
+
+	walletStore := wallet.NewWalletStore()
+	var seed *crypto.DevSeed
+	if *devSeed != "" {
+		seed = crypto.NewDevSeed(*devSeed)
+		walletStore.SetDevSeed(seed)
+		log.Println("WARNING: -dev-seed derives every key from a known string; never use this node's wallets for real funds")
+	}
+	if *walletFile != "" {
+		_, statErr := os.Stat(*walletFile)
+		passphrase, err := readPassphrase(*passphraseEnv, os.IsNotExist(statErr))
+		if err != nil {
+			log.Fatalf("Failed to read keystore passphrase: %v", err)
+		}
+		loaded, err := walletStore.OpenKeystore(*walletFile, passphrase)
+		if err != nil {
+			log.Fatalf("Failed to open keystore %s: %v", *walletFile, err)
+		}
+		log.Printf("Loaded %d wallets from encrypted keystore %s", loaded, *walletFile)
+	}
+	log.Println("Wallet store initialized")
+
+	var genesisBlock *chain.Block
+	var defaultWallet *wallet.Wallet
+	if *follow != "" {
+		genesis, err := follower.FetchGenesis(*follow)
+		if err != nil {
+			log.Fatalf("Failed to fetch genesis from primary %s: %v", *follow, err)
+		}
+		params, err := follower.FetchParams(*follow)
+		if err != nil {
+			log.Fatalf("Failed to fetch consensus parameters from primary %s: %v", *follow, err)
+		}
+		*difficulty = params.InitialDifficulty
+		*retargetInterval = params.RetargetInterval
+		*targetBlockTime = time.Duration(params.TargetBlockTime) * time.Second
+		*blockReward = params.BlockReward
+		genesisBlock = genesis
+		log.Printf("Read replica mode: following %s", *follow)
+	} else if *peerList != "" {
+		var params *p2p.ConsensusParams
+		genesisBlock, params = fetchPeerGenesis(strings.Split(*peerList, ","))
+		if params != nil {
+			// Peers refuse a node whose parameters differ from theirs.
+			*difficulty = params.InitialDifficulty
+			*retargetInterval = params.RetargetInterval
+			*targetBlockTime = time.Duration(params.TargetBlockTime) * time.Second
+			*blockReward = params.BlockReward
+		}
+	}
+	if genesisBlock == nil {
+		genesisBlock, defaultWallet = createGenesis(walletStore, algorithm)
+	}
 
 	blockchain := chain.NewBlockchain(genesisBlock)
-	log.Printf("Genesis block created: %s", genesisBlock.Hash)
+	if network, err := consensus.ParseAlgorithm(blockchain.PowAlgorithm()); err != nil {
+		log.Fatalf("Genesis block: %v", err)
+	} else if network != algorithm {
+		log.Printf("Proof of work: %s (set by the network's genesis block; -pow-algorithm ignored)", network)
+	} else {
+		log.Printf("Proof of work: %s", network)
+	}
+	blockchain.SetBlockReward(*blockReward)
+	blockchain.SetDifficulty(*difficulty)
+	blockchain.SetRetargetPolicy(chain.RetargetPolicy{
+		Interval:        *retargetInterval,
+		TargetBlockTime: *targetBlockTime,
+	})
+	if *retargetInterval > 0 {
+		log.Printf("Difficulty retargets every %d blocks towards %s per block", *retargetInterval, *targetBlockTime)
+	}
+	log.Printf("Genesis block: %s", genesisBlock.Hash)
 
-	genesisBalance := blockchain.UTXO.BalanceOf(defaultWallet.Address)
-	log.Printf("Default wallet (genesis recipient) balance: %.2f coins", genesisBalance)
-	if genesisBalance == 0 {
-		log.Printf("WARNING: Genesis coins not found in UTXO set!")
+	if defaultWallet != nil {
+		genesisBalance := blockchain.BalanceOf(defaultWallet.Address)
+		log.Printf("Default wallet (genesis recipient) balance: %.2f coins", genesisBalance)
+		if genesisBalance == 0 {
+			log.Printf("WARNING: Genesis coins not found in UTXO set!")
+		}
 	}
 
-	mempool := chain.NewMempool()
-	log.Println("Mempool initialized")
+	policy := chain.DefaultMempoolPolicy()
+	policy.MinRelayFee = *minRelayFee
+	policy.MinRelayFeeRate = *minRelayFeeRate
+	policy.MaxSize = *maxMempool
+	policy.MaxDescendants = *maxDescendants
+	policy.MinFeeBump = *minFeeBump
+	policy.DeprioritizeAnomalyAbove = *deprioritizeAnomaly
+	mempool := chain.NewMempoolWithPolicy(policy)
+	log.Printf("Mempool initialized (min relay fee: %.8f, min fee rate: %.8f/kB, max size: %d)", *minRelayFee, *minRelayFeeRate, *maxMempool)
 
 	var aiClient *ai.Client
 	if *aiURL != "" {
@@ -77,8 +282,327 @@ func main() {
 		aiClient = ai.NewClient("", 0, false)
 		log.Println("AI scoring disabled")
 	}
+	clusters := analytics.NewClusterer(blockchain)
+	aiClient.SetClusterSource(clusters)
+	aiClient.SetOutputLookup(func(key chain.UTXOKey) (chain.TxOut, bool) {
+		if out, ok := blockchain.Output(key); ok {
+			return out, true
+		}
+		if parent, ok := mempool.Get(key.TxID); ok && key.Index >= 0 && key.Index < len(parent.Outputs) {
+			return parent.Outputs[key.Index], true
+		}
+		return chain.TxOut{}, false
+	})
+	registry := metrics.NewRegistry()
+	aiClient.SetMetrics(registry)
+
+	var sinks []notify.Sink
+	if *notifyWebhook != "" {
+		sinks = append(sinks, &notify.WebhookSink{URL: *notifyWebhook})
+	}
+	if *notifySlack != "" {
+		sinks = append(sinks, &notify.SlackSink{WebhookURL: *notifySlack})
+	}
+	if *notifySMTP != "" && *notifyEmailTo != "" {
+		sinks = append(sinks, &notify.EmailSink{
+			SMTPAddr: *notifySMTP,
+			Username: *notifySMTPUser,
+			Password: *notifySMTPPass,
+			From:     *notifyEmailFrom,
+			To:       strings.Split(*notifyEmailTo, ","),
+		})
+	}
+	notifier := notify.NewNotifier(15*time.Minute, sinks...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Every block and mempool admission is applied by this one goroutine.
+	blocks := chain.NewBlockPipeline(blockchain, mempool)
+	go blocks.Run(ctx)
+
+	var notifyQueue *notify.DeliveryQueue
+	if notifier.Enabled() {
+		notifyQueue, err = notify.LoadDeliveryQueue(*notifyQueueFile, notify.RetryPolicy{
+			MaxAttempts: *notifyMaxAttempts,
+			MaxBackoff:  *notifyMaxBackoff,
+		})
+		if err != nil {
+			log.Fatalf("Failed to load notification queue: %v", err)
+		}
+		notifier.SetQueue(notifyQueue)
+		go notifyQueue.Run(ctx)
+		if pending := len(notifyQueue.Status().Pending); pending > 0 {
+			log.Printf("Retrying %d undelivered notifications from %s", pending, *notifyQueueFile)
+		}
+	}
+
+	var monitor *notify.Monitor
+	if notifier.Enabled() {
+		probes := notify.Probes{
+			TipTime:     func() time.Time { return time.Unix(blockchain.Tip().Timestamp, 0) },
+			MempoolSize: mempool.Size,
+		}
+		if aiClient.Enabled() {
+			probes.AIHealthy = aiClient.Healthy
+		}
+		if *p2pListen != "" || *peerList != "" {
+			probes.ClockSkew = blockchain.Clock().Skew
+		}
+		monitor = notify.NewMonitor(notifier, notify.Thresholds{
+			ChainStall:  time.Duration(*stallMinutes) * time.Minute,
+			AIDown:      time.Duration(*aiDownMinutes) * time.Minute,
+			MempoolSize: *mempoolAlert,
+			ReorgDepth:  *reorgDepth,
+		}, probes, 30*time.Second)
+		go monitor.Run(ctx)
+		walletStore.SetSaveErrorHandler(notifier.StorageError)
+		log.Printf("Operator notifications enabled (%d sinks)", len(sinks))
+	}
+
+	go watchReorgs(ctx, blockchain, monitor)
+
+	server := api.NewServer(blockchain, mempool, blocks, aiClient, *port, walletStore)
+	server.SetListenAddresses(api.ListenAddresses(*listen, *port))
+	server.SetClusterer(clusters)
+	server.SetMetrics(registry)
+	if notifyQueue != nil {
+		server.SetNotificationQueue(notifyQueue)
+	}
+	if *archiveDir != "" {
+		blockArchive, err := archive.Open(*archiveDir)
+		if err != nil {
+			log.Fatalf("Failed to open block archive: %v", err)
+		}
+		n, err := blockArchive.Sync(blockchain)
+		if err != nil {
+			log.Fatalf("Failed to sync block archive: %v", err)
+		}
+		log.Printf("Block archive %s: %d blocks, %d newly archived", *archiveDir, blockArchive.Stats().Height, n)
+		if notifier.Enabled() {
+			blockArchive.SetErrorHandler(notifier.StorageError)
+		}
+		server.SetArchive(blockArchive)
+		go blockArchive.Run(ctx, blockchain)
+	}
 
-	server := api.NewServer(blockchain, mempool, aiClient, *difficulty, *port, walletStore)
+	server.SetConcurrencyLimits(api.ConcurrencyLimits{
+		Global: *maxHeavy,
+		PerEndpoint: map[string]int{
+			"mine":   *maxMining,
+			"blocks": *maxBlocks,
+		},
+	})
+	rateLimits := make(map[string]api.RateLimit)
+	for class, spec := range map[string]string{"transactions": *rateTransactions, "mine": *rateMine, "wallet": *rateWallet} {
+		limit, err := api.ParseRateLimit(spec)
+		if err != nil {
+			log.Fatalf("Invalid -rate-limit-%s: %v", class, err)
+		}
+		if limit.Requests > 0 {
+			rateLimits[class] = limit
+			log.Printf("Rate limiting %s requests to %s per client IP", class, limit)
+		}
+	}
+	server.SetRateLimits(rateLimits)
+	if *trustedProxies != "" {
+		if err := server.SetTrustedProxies(strings.Split(*trustedProxies, ",")); err != nil {
+			log.Fatalf("Invalid -trusted-proxies: %v", err)
+		}
+		log.Printf("Rate limiting clients named in X-Forwarded-For by %s", *trustedProxies)
+	}
+	if *cacheTTL > 0 {
+		server.SetQueryCache(api.NewQueryCache(*cacheTTL, *cacheSize))
+	}
+
+	if *minerAddress != "" {
+		if err := wallet.ValidateAddress(*minerAddress); err != nil {
+			log.Fatalf("Invalid -miner-address: %v", err)
+		}
+		server.SetMinerAddress(*minerAddress)
+		log.Printf("Block rewards (%.8f + fees) paid to %s", *blockReward, *minerAddress)
+	}
+	if *minerIdentity != "" {
+		identity := walletStore.GetWallet(*minerIdentity)
+		if identity == nil || identity.PrivateKey == nil {
+			log.Fatalf("Invalid -miner-identity: no unlocked wallet %s in the wallet store", *minerIdentity)
+		}
+		server.Miner().SetIdentity(identity.PrivateKey)
+		log.Printf("Mined blocks are signed by %s", *minerIdentity)
+	}
+
+	server.SetMiningRefreshPolicy(miner.RefreshPolicy{
+		MinFee:       *refreshFee,
+		MinInterval:  *refreshInterval,
+		MaxRefreshes: *refreshMax,
+	})
+	server.Miner().SetMaxBlockTxs(*maxBlockTxs)
+	server.Miner().SetThreads(*miningThreads)
+	log.Printf("Mining with %d thread(s)", *miningThreads)
+
+	if *clusterNodes != "" {
+		server.SetClusterMonitor(cluster.NewMonitor(strings.Split(*clusterNodes, ","), 5*time.Second, *clusterLag))
+		log.Printf("Cluster status enabled for %s", *clusterNodes)
+	}
+
+	var quarantineStore *quarantine.Store
+	if *quarantineDir != "" {
+		q, err := quarantine.Open(*quarantineDir, *quarantineMaxMB<<20, *quarantineOn)
+		if err != nil {
+			log.Fatalf("Failed to open quarantine directory: %v", err)
+		}
+		quarantineStore = q
+		server.SetQuarantine(q)
+		log.Printf("Quarantining rejected objects in %s (max %d MB, enabled=%v)", *quarantineDir, *quarantineMaxMB, *quarantineOn)
+	}
+
+	if *follow != "" {
+		f := follower.New(*follow, blockchain, blocks, *followInterval)
+		f.SetQuarantine(quarantineStore)
+		server.SetFollower(f)
+		go f.Run(ctx)
+	}
+
+	if *p2pListen != "" || *peerList != "" {
+		blockchain.Clock().SetWarnThreshold(*clockSkewWarn)
+		var bootstrap []string
+		if *peerList != "" {
+			bootstrap = strings.Split(*peerList, ",")
+		}
+		var allow *p2p.AllowList
+		if *p2pAllow != "" {
+			var err error
+			allow, err = p2p.ParseAllowList(strings.Split(*p2pAllow, ","))
+			if err != nil {
+				log.Fatalf("Invalid -p2p-allow: %v", err)
+			}
+			log.Printf("P2P allow-list mode: only %s may connect", *p2pAllow)
+		}
+		bans, err := p2p.LoadBanList(*banFile)
+		if err != nil {
+			log.Fatalf("Failed to load ban list: %v", err)
+		}
+		if *banFile != "" {
+			log.Printf("Loaded %d peer bans from %s", len(bans.List()), *banFile)
+		}
+		network := p2p.New(p2p.Config{
+			ListenAddr: *p2pListen,
+			Peers:      bootstrap,
+			AllowList:  allow,
+			SyncWindow: *syncWindow,
+			TxRelay: p2p.TxRelayPolicy{
+				MeanDelay: *txRelayDelay,
+				MaxBatch:  *txRelayBatch,
+			},
+			Dandelion: p2p.DandelionPolicy{
+				Enabled:          *dandelion,
+				FluffProbability: *dandelionFluff,
+				Embargo:          *dandelionEmbargo,
+			},
+			DevSeed: seed,
+		}, blockchain, mempool, blocks)
+		network.SetQuarantine(quarantineStore)
+		network.SetBanList(bans)
+		if aiClient.Enabled() {
+			network.SetPeerScorer(aiClient, p2p.PeerScoringPolicy{
+				Interval:          *aiPeerInterval,
+				DeprioritizeBelow: *aiPeerDeprioritize,
+				BanBelow:          *aiPeerBan,
+			})
+		}
+		if err := network.Start(ctx); err != nil {
+			log.Fatalf("Failed to start P2P network: %v", err)
+		}
+		server.SetNetwork(network)
+	}
+
+	if *adminToken != "" {
+		server.SetAdminToken(*adminToken)
+	}
+	if *adminJWTSecret != "" {
+		server.SetAdminJWTSecret([]byte(*adminJWTSecret))
+	}
+	adminKeys, err := parseAdminKeys(*adminKeyList)
+	if err != nil {
+		log.Fatalf("Invalid -admin-keys: %v", err)
+	}
+	if len(adminKeys) > 0 {
+		server.SetAdminKeys(adminKeys)
+	}
+	adminEnabled := *adminToken != "" || *adminJWTSecret != "" || len(adminKeys) > 0
+	if adminEnabled {
+		log.Println("Admin API enabled; wallet, mining and peer management endpoints require admin credentials")
+	} else {
+		log.Println("WARNING: no -admin-token, -admin-keys or -admin-jwt-secret; wallet, mining and peer management endpoints are open to anyone who can reach the API")
+	}
+	if *adminApprovals > 1 {
+		// Whoever holds the JWT secret can sign any subject, so every JWT
+		// counts as one approver.
+		credentials := len(adminKeys)
+		if *adminToken != "" {
+			credentials++
+		}
+		if *adminJWTSecret != "" {
+			credentials++
+		}
+		if credentials < *adminApprovals {
+			log.Fatalf("-admin-approvals %d needs at least %d distinct admin credentials; %d configured", *adminApprovals, *adminApprovals, credentials)
+		}
+		server.SetAdminApprovals(*adminApprovals)
+		log.Printf("Freeze, mempool clear and reindex need %d admin approvals", *adminApprovals)
+	}
+
+	if *recordFile != "" {
+		recorder, err := api.NewRecorder(*recordFile)
+		if err != nil {
+			log.Fatalf("Failed to open recording file: %v", err)
+		}
+		defer recorder.Close()
+		server.SetRecorder(recorder)
+		log.Printf("Recording API calls to %s", *recordFile)
+	}
+
+	if *autoMine {
+		if *follow != "" {
+			log.Fatal("-auto-mine cannot be used with -follow: replicas do not produce blocks")
+		}
+		autoMiner := miner.NewAutoMiner(server.Miner(), *autoMineInterval, *minerAddress)
+		server.SetAutoMiner(autoMiner)
+		go autoMiner.Run(ctx)
+		log.Printf("Auto-mining enabled (%s)", autoMiner.Status().Interval)
+	}
+
+	if *requireUnlock {
+		server.SetRequireUnlock(true)
+		log.Println("Wallet signing requires an unlock session")
+	}
+	if *freshChange {
+		server.SetFreshChange(true)
+		log.Println("Wallet transfers send change to new addresses")
+	}
+	if *consolidateQuota < 0 || *consolidateBelow <= 0 {
+		log.Fatal("-wallet-consolidate-quota must not be negative and -wallet-consolidate-below must be positive")
+	}
+	server.SetConsolidationPolicy(wallet.ConsolidationPolicy{MaxSmallOutputs: *consolidateQuota, SmallBelow: *consolidateBelow})
+
+	mode := "full"
+	if *follow != "" {
+		mode = "replica"
+	}
+	server.SetVersionInfo(build, api.NodeFeatures{
+		Mode:              mode,
+		Indexes:           []string{"tx", "address", "address-cluster", "utxo-hash"},
+		P2P:               *p2pListen != "" || *peerList != "",
+		Dandelion:         *dandelion,
+		AIScoring:         aiClient.Enabled(),
+		EncryptedKeystore: *walletFile != "",
+		AutoMine:          *autoMine,
+		AdminAPI:          adminEnabled,
+	}, selfTest)
+
+	go server.Scheduler().Run(ctx)
+	go server.Sessions().Run(ctx)
+	go server.FeeEstimator().Run(ctx)
 
 	go func() {
 		if err := server.Start(); err != nil {
@@ -87,20 +611,72 @@ func main() {
 	}()
 
 	log.Println("Blockchain node is running!")
-	log.Println("API endpoints:")
+	log.Println("API endpoints (under /v1; the unversioned paths are deprecated aliases):")
+	log.Println("  GET  /v1/openapi.json - OpenAPI 3 description of the API")
 	log.Println("  GET  /health          - Health check")
-	log.Println("  GET  /blocks          - Get all blocks")
+	log.Println("  GET  /blocks          - Get blocks (?offset=&limit=&order=desc to page)")
+	log.Println("  GET  /headers         - Main-chain headers (?from=&limit=)")
+	log.Println("  GET  /blocks/stale    - Blocks that lost the race for the tip")
 	log.Println("  GET  /chain           - Get blockchain info")
-	log.Println("  GET  /mempool         - Get pending transactions")
+	log.Println("  GET  /stats           - Tip, UTXO count and rolling UTXO set hash")
+	log.Println("  GET  /params          - Active consensus and policy parameters")
+	log.Println("  GET  /version         - Build, protocol versions, features and startup self-test")
+	log.Println("  GET  /consensus/simulate?difficulty=N - Expected time to mine a block at this node's hash rate")
+	log.Println("  GET  /mempool         - Get pending transactions (?offset=&limit=&order=desc to page)")
 	log.Println("  GET  /balance/:addr  - Get balance for address")
+	log.Println("  GET  /richlist        - Addresses ranked by confirmed balance (?limit=)")
+	log.Println("  GET  /miners          - Blocks and stale blocks per signing miner (?blocks=)")
+	log.Println("  GET  /address/:addr/history - Confirmed transactions paying or spending from an address")
+	log.Println("  GET  /api/address/validate?address= - Check an address's format before paying it")
 	log.Println("  POST /transactions    - Submit new transaction")
-	log.Println("  POST /mine            - Mine a new block")
+	log.Println("  GET  /transactions/:txid - Look up a confirmed or pending transaction")
+	log.Println("  GET  /transactions/:txid/proof - Merkle proof of a confirmed transaction")
+	log.Println("  GET  /analytics/cluster/:address - Addresses likely sharing an owner (advisory)")
+	log.Println("  POST /graphql        - Explorer queries over blocks, transactions, addresses and mempool")
+	log.Println("  POST /mine            - Mine a new block (optional {\"miner_address\"} for the reward)")
+	log.Println("  POST /mine/cancel     - Abort the block currently being mined")
+	log.Println("  GET  /fees/estimate   - Fee quote for ?target=N confirmations")
+	log.Println("  POST /mining/proposal - Validate a block template (no PoW check)")
+	log.Println("  POST /api/wallet/import - Import a private key (hex, wif or pem); GET /api/wallet/export/:address (admin) exports one")
+	log.Println("  GET /api/wallet/:address/consolidate-suggestion - Propose merging small outputs (POST .../consolidate to do it)")
+	log.Println("  GET/POST /api/wallet/hd - HD wallets from BIP39 mnemonics (/hd/:id/derive for addresses, /hd/:id/mnemonic to reveal once)")
+	log.Println("  GET/POST/DELETE /api/wallet/contacts - Wallet address book")
+	log.Println("  GET/POST /api/wallet/schedules - Recurring payments")
+	log.Println("  POST /api/wallet/build|sign|derive - Build, sign (without submitting) and derive addresses for external wallets")
+	log.Println("  GET/POST /api/wallet/multisig - m-of-n multisig accounts (POST /api/wallet/multisig/sign adds a co-signer's signature)")
+	log.Println("  GET/POST/DELETE /api/wallet/watch - Watch-only addresses, tracked without their keys")
+	log.Println("  GET  /api/wallet/history - Wallet transactions with memos encrypted to it decrypted (?address=)")
+	log.Println("  POST /api/wallet/unlock - Start a time-limited signing session (POST /api/wallet/lock to end it)")
+	log.Println("  POST /api/wallet/store/lock - Drop private keys from memory (POST /api/wallet/store/unlock to reload)")
+	log.Println("  GET  /api/wallet/uri  - Build a coin: payment URI (GET /api/wallet/uri/parse to decode)")
+	log.Println("  GET  /cluster/status  - Aggregate status of sibling nodes")
+	log.Println("  GET  /metrics         - Prometheus metrics (AI scoring outcomes and latency)")
+	log.Println("  GET  /peers           - Connected P2P peers")
+	log.Println("  GET  /archive         - Block archive status; blocks.dat and blocks.idx support range requests")
+	log.Println("  GET  /sync/status     - Sync progress against the best peer height")
+	log.Println("  GET  /ws              - WebSocket stream of block, tx, reorg and wallet events (?events=)")
+	log.Println("  POST /debug/canonicalize - Canonical bytes and txid for a transaction")
+	log.Println("  POST /admin/freeze    - Halt block acceptance and mining (admin, approvals)")
+	log.Println("  POST /admin/unfreeze  - Resume normal operation (admin)")
+	log.Println("  POST /admin/mempool/clear - Drop every pending transaction (admin, approvals)")
+	log.Println("  POST /admin/reindex   - Rebuild the UTXO set and indexes from the main chain (admin, approvals)")
+	log.Println("  GET/POST/DELETE /admin/proposals - Approve or cancel proposed destructive actions (admin)")
+	log.Println("  GET/POST /admin/quarantine - Rejected-object quarantine status and toggle (admin)")
+	log.Println("  GET/POST/DELETE /admin/bans - P2P peer ban list (admin)")
+	log.Println("  GET/POST/DELETE /admin/peers - Connect to and drop P2P peers (admin)")
+	log.Println("  GET/POST/DELETE /admin/notifications - Alert retry queue and dead letters (admin)")
+	log.Println("  GET  /admin/export    - Chain history as CSV or Parquet (?what=blocks|txs|utxos&format=) (admin)")
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
 	log.Println("\nShutting down gracefully...")
+	cancel()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancelShutdown()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("API server did not drain in time: %v", err)
+	}
 	log.Println("Node stopped")
 }
-
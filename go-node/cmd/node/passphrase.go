@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// readPassphrase returns the keystore passphrase from envVar or, failing
+// that, prompts on the terminal. New keystores ask twice.
+func readPassphrase(envVar string, isNew bool) (string, error) {
+	if p := os.Getenv(envVar); p != "" {
+		return p, nil
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	p, err := promptHidden(reader, "Wallet keystore passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if isNew {
+		confirm, err := promptHidden(reader, "Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm != p {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if p == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	return p, nil
+}
+
+// promptHidden turns off terminal echo through stty where available; on other
+// platforms the input is visible.
+func promptHidden(reader *bufio.Reader, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		return cmd.Run()
+	}
+	if stty("-echo") == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(os.Stderr)
+		}()
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/notify"
+)
+
+// watchReorgs logs reorganizations and reports deep ones to the operator
+// monitor, if one is running. The block pipeline has already returned
+// transactions from the abandoned blocks to the mempool.
+func watchReorgs(ctx context.Context, blockchain *chain.Blockchain, monitor *notify.Monitor) {
+	reorgs, cancel := blockchain.SubscribeReorgs()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case reorg := <-reorgs:
+			slog.Warn("Chain reorganized", "fork_height", reorg.ForkHeight, "depth", reorg.Depth(),
+				"old_tip", reorg.OldTip, "new_tip", reorg.NewTip, "restored_txs", reorg.Restored)
+
+			if monitor != nil {
+				monitor.ObserveReorg(reorg.Depth(), reorg.OldTip, reorg.NewTip)
+			}
+		}
+	}
+}
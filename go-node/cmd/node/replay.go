@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/api"
+)
+
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := fs.String("target", "http://localhost:8080", "Base URL of the node to replay against")
+	speed := fs.Float64("speed", 1.0, "Timing multiplier (2 = twice as fast, 0 = no delays)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: node replay [flags] <recording.jsonl>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	calls, err := api.ReadRecording(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Failed to read recording: %v", err)
+	}
+	log.Printf("Replaying %d calls against %s (speed %.2fx)", len(calls), *target, *speed)
+
+	client := &http.Client{Timeout: 10 * time.Minute}
+	base := strings.TrimRight(*target, "/")
+	start := time.Now()
+
+	latencies := make(map[string][]time.Duration)
+	statuses := make(map[int]int)
+	failures := 0
+
+	for i, call := range calls {
+		if *speed > 0 {
+			due := time.Duration(float64(call.OffsetMs)/(*speed)) * time.Millisecond
+			if wait := due - time.Since(start); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+
+		req, err := http.NewRequest(call.Method, base+call.Path, bytes.NewReader(call.Body))
+		if err != nil {
+			log.Fatalf("Call %d: %v", i, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		sent := time.Now()
+		resp, err := client.Do(req)
+		elapsed := time.Since(sent)
+		if err != nil {
+			failures++
+			log.Printf("Call %d %s %s failed: %v", i, call.Method, call.Path, err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		statuses[resp.StatusCode]++
+		latencies[call.Path] = append(latencies[call.Path], elapsed)
+	}
+
+	log.Printf("Replay finished in %v (%d transport failures)", time.Since(start).Round(time.Millisecond), failures)
+	for status, count := range statuses {
+		log.Printf("  HTTP %d: %d", status, count)
+	}
+	for path, durations := range latencies {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		log.Printf("  %-24s n=%d p50=%v p99=%v max=%v", path, len(durations),
+			percentile(durations, 0.50), percentile(durations, 0.99), durations[len(durations)-1])
+	}
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted)-1) * p)
+	return sorted[idx]
+}
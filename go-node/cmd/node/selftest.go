@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"ai-blockchain/go-node/internal/api"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/fixtures"
+)
+
+// version is the release this binary was built as, set with
+// -ldflags "-X main.version=1.2.0".
+var version = "dev"
+
+// buildInfo reads the version control stamp go build embeds in the binary.
+func buildInfo() api.BuildInfo {
+	info := api.BuildInfo{Version: version, GoVersion: runtime.Version()}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.CommitTime = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+// runSelfTest checks the crypto primitives against known vectors, then
+// recomputes the golden vectors shared with the wallet and scorer, so a
+// broken build fails before it validates a single block.
+func runSelfTest() (*api.SelfTestReport, error) {
+	start := time.Now()
+	checks, err := crypto.SelfTest()
+	if err != nil {
+		return nil, err
+	}
+
+	vectors, err := fixtures.Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := vectors.Check(); err != nil {
+		return nil, fmt.Errorf("golden vectors: %w", err)
+	}
+	checks = append(checks, "golden-vectors")
+
+	return &api.SelfTestReport{
+		Checks:     checks,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		RanAt:      start.Unix(),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// MaxScoreBatch caps how many transactions go in one POST /score/tx/batch.
+// Longer lists are sent in several requests.
+const MaxScoreBatch = 100
+
+type batchScoreRequest struct {
+	Transactions []*TxFeatures `json:"transactions"`
+}
+
+type batchScoreResponse struct {
+	Scores []ScoreResponse `json:"scores"`
+}
+
+// features extracts what the AI service scores tx on.
+func (c *Client) features(tx *chain.Transaction) *TxFeatures {
+	features := extractTxFeatures(tx, c.outputs)
+	if c.clusters != nil {
+		features.ClusterSize, features.ClusterTxCount = c.clusters.InputCluster(tx)
+	}
+	return features
+}
+
+// ScoreTransactions scores txs with one request per MaxScoreBatch
+// transactions instead of one each, and returns the scores in the order of
+// txs. Like ScoreTransaction it hands out the default score when the
+// service is disabled or unreachable.
+func (c *Client) ScoreTransactions(txs []*chain.Transaction, endpoint string) ([]*ScoreResponse, error) {
+	scores := make([]*ScoreResponse, 0, len(txs))
+	for start := 0; start < len(txs); start += MaxScoreBatch {
+		end := start + MaxScoreBatch
+		if end > len(txs) {
+			end = len(txs)
+		}
+		batch, err := c.scoreBatch(txs[start:end], endpoint)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, batch...)
+	}
+	return scores, nil
+}
+
+func (c *Client) scoreBatch(txs []*chain.Transaction, endpoint string) ([]*ScoreResponse, error) {
+	if !c.enabled {
+		c.metrics.fallbackBatch(endpoint, fallbackDisabled, len(txs))
+		return defaultScores(len(txs), ""), nil
+	}
+
+	body := batchScoreRequest{Transactions: make([]*TxFeatures, len(txs))}
+	for i, tx := range txs {
+		body.Transactions[i] = c.features(tx)
+	}
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		c.metrics.fallbackBatch(endpoint, fallbackError, len(txs))
+		return nil, fmt.Errorf("failed to marshal features: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Post(c.baseURL+"/score/tx/batch", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		c.metrics.observe(endpoint, start, fallbackUnavailable)
+		c.metrics.fallbackBatch(endpoint, fallbackUnavailable, len(txs))
+		return defaultScores(len(txs), "AI service unavailable"), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		c.metrics.observe(endpoint, start, fallbackError)
+		c.metrics.fallbackBatch(endpoint, fallbackError, len(txs))
+		return nil, fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var decoded batchScoreResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		c.metrics.observe(endpoint, start, fallbackError)
+		c.metrics.fallbackBatch(endpoint, fallbackError, len(txs))
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(decoded.Scores) != len(txs) {
+		c.metrics.observe(endpoint, start, fallbackError)
+		c.metrics.fallbackBatch(endpoint, fallbackError, len(txs))
+		return nil, fmt.Errorf("AI service returned %d scores for %d transactions", len(decoded.Scores), len(txs))
+	}
+	c.metrics.observe(endpoint, start, "ok")
+
+	scores := make([]*ScoreResponse, len(txs))
+	for i := range decoded.Scores {
+		scores[i] = &decoded.Scores[i]
+		c.metrics.scored(endpoint, scores[i])
+	}
+	return scores, nil
+}
+
+func defaultScores(n int, message string) []*ScoreResponse {
+	scores := make([]*ScoreResponse, n)
+	for i := range scores {
+		scores[i] = &ScoreResponse{AnomalyScore: 0.0, FeeAdequacy: 0.5, Message: message, Fallback: true}
+	}
+	return scores
+}
@@ -15,12 +15,25 @@ type Client struct {
 	baseURL    string
 	httpClient *http.Client
 	enabled    bool
+	clusters   ClusterSource
+	outputs    OutputLookup
+	metrics    *clientMetrics
+}
+
+// OutputLookup resolves an outpoint a transaction spends to the output it
+// refers to, whether confirmed or created by a pending transaction.
+type OutputLookup func(key chain.UTXOKey) (chain.TxOut, bool)
+
+// ClusterSource supplies address-clustering features for scoring.
+type ClusterSource interface {
+	InputCluster(tx *chain.Transaction) (size, txCount int)
 }
 
 type ScoreResponse struct {
-	AnomalyScore float64 `json:"anomaly_score"`  // 0.0 = normal, 1.0 = highly anomalous
-	FeeAdequacy  float64 `json:"fee_adequacy"`   // 0.0 = low fee, 1.0 = high fee
+	AnomalyScore float64 `json:"anomaly_score"` // 0.0 = normal, 1.0 = highly anomalous
+	FeeAdequacy  float64 `json:"fee_adequacy"`  // 0.0 = low fee, 1.0 = high fee
 	Message      string  `json:"message,omitempty"`
+	Fallback     bool    `json:"-"` // default score handed out without asking the service
 }
 
 func NewClient(baseURL string, timeout time.Duration, enabled bool) *Client {
@@ -33,67 +46,137 @@ func NewClient(baseURL string, timeout time.Duration, enabled bool) *Client {
 	}
 }
 
-func (c *Client) ScoreTransaction(tx *chain.Transaction) (*ScoreResponse, error) {
+// SetClusterSource adds the size of the address cluster a transaction's
+// inputs belong to to the features sent for scoring.
+func (c *Client) SetClusterSource(src ClusterSource) {
+	c.clusters = src
+}
+
+// SetOutputLookup lets the client resolve inputs, so the features sent for
+// scoring carry real input totals, fees and input addresses. Without it
+// they are zero.
+func (c *Client) SetOutputLookup(lookup OutputLookup) {
+	c.outputs = lookup
+}
+
+func (c *Client) Enabled() bool {
+	return c != nil && c.enabled
+}
+
+func (c *Client) Healthy() bool {
+	if !c.Enabled() {
+		return false
+	}
+
+	resp, err := c.httpClient.Get(c.baseURL + "/health")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	return resp.StatusCode == http.StatusOK
+}
+
+// ScoreTransaction asks the AI service to score tx on behalf of the node
+// endpoint named by endpoint, which labels the scoring metrics.
+func (c *Client) ScoreTransaction(tx *chain.Transaction, endpoint string) (*ScoreResponse, error) {
 	if !c.enabled {
+		c.metrics.fallback(endpoint, fallbackDisabled)
 		return &ScoreResponse{
 			AnomalyScore: 0.0,
 			FeeAdequacy:  0.5,
+			Fallback:     true,
 		}, nil
 	}
 
-	features := extractTxFeatures(tx)
+	features := c.features(tx)
 
 	reqBody, err := json.Marshal(features)
 	if err != nil {
+		c.metrics.fallback(endpoint, fallbackError)
 		return nil, fmt.Errorf("failed to marshal features: %w", err)
 	}
 
 	url := c.baseURL + "/score/tx"
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBody))
 	if err != nil {
+		c.metrics.fallback(endpoint, fallbackError)
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.metrics.observe(endpoint, start, fallbackUnavailable)
+		c.metrics.fallback(endpoint, fallbackUnavailable)
 		return &ScoreResponse{
 			AnomalyScore: 0.0,
 			FeeAdequacy:  0.5,
 			Message:      "AI service unavailable",
+			Fallback:     true,
 		}, nil
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		c.metrics.observe(endpoint, start, fallbackError)
+		c.metrics.fallback(endpoint, fallbackError)
 		return nil, fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
 	}
 
 	var score ScoreResponse
 	if err := json.NewDecoder(resp.Body).Decode(&score); err != nil {
+		c.metrics.observe(endpoint, start, fallbackError)
+		c.metrics.fallback(endpoint, fallbackError)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	c.metrics.observe(endpoint, start, "ok")
+	c.metrics.scored(endpoint, &score)
 
 	return &score, nil
 }
 
 type TxFeatures struct {
-	NumInputs    int     `json:"num_inputs"`
-	NumOutputs   int     `json:"num_outputs"`
-	TotalInput   float64 `json:"total_input"`
-	TotalOutput  float64 `json:"total_output"`
-	Fee          float64 `json:"fee"`
-	FeeRate      float64 `json:"fee_rate"`      // Fee per byte (simplified)
-	ChangeRatio   float64 `json:"change_ratio"` // Output / Input ratio
-	InputDiversity int    `json:"input_diversity"` // Number of unique input addresses
+	NumInputs      int     `json:"num_inputs"`
+	NumOutputs     int     `json:"num_outputs"`
+	TotalInput     float64 `json:"total_input"`
+	TotalOutput    float64 `json:"total_output"`
+	Fee            float64 `json:"fee"`
+	FeeRate        float64 `json:"fee_rate"`         // Fee per byte of the binary encoding
+	ChangeRatio    float64 `json:"change_ratio"`     // Output / Input ratio
+	InputDiversity int     `json:"input_diversity"`  // Number of unique input addresses
+	ClusterSize    int     `json:"cluster_size"`     // Addresses in the inputs' ownership cluster
+	ClusterTxCount int     `json:"cluster_tx_count"` // Transactions that linked that cluster
 }
 
-func extractTxFeatures(tx *chain.Transaction) *TxFeatures {
+// extractTxFeatures derives tx's features, resolving its inputs with
+// lookup. Input totals, and the fee and ratios derived from them, are only
+// filled in when every input resolves: a partial total would understate the
+// fee. Input diversity then falls back to counting funding transactions.
+func extractTxFeatures(tx *chain.Transaction, lookup OutputLookup) *TxFeatures {
 	var totalInput float64
 	inputAddresses := make(map[string]bool)
+	resolved := lookup != nil
 	for _, in := range tx.Inputs {
-		inputAddresses[in.TxID] = true
+		if !resolved {
+			break
+		}
+		out, ok := lookup(chain.UTXOKey{TxID: in.TxID, Index: in.Index})
+		if !ok {
+			resolved = false
+			break
+		}
+		totalInput += out.Amount
+		inputAddresses[out.Address] = true
+	}
+	if !resolved {
+		totalInput = 0
+		inputAddresses = make(map[string]bool)
+		for _, in := range tx.Inputs {
+			inputAddresses[in.TxID] = true
+		}
 	}
 
 	var totalOutput float64
@@ -101,12 +184,13 @@ func extractTxFeatures(tx *chain.Transaction) *TxFeatures {
 		totalOutput += out.Amount
 	}
 
-	fee := totalInput - totalOutput
+	fee := chain.RoundAmount(totalInput - totalOutput)
 	if fee < 0 {
 		fee = 0
 	}
 
-	txSize := len(tx.ID) + len(tx.Signature) + len(tx.PubKey) // Rough estimate
+	data, _ := tx.MarshalBinary()
+	txSize := len(data)
 	feeRate := 0.0
 	if txSize > 0 {
 		feeRate = fee / float64(txSize)
@@ -118,14 +202,13 @@ func extractTxFeatures(tx *chain.Transaction) *TxFeatures {
 	}
 
 	return &TxFeatures{
-		NumInputs:     len(tx.Inputs),
-		NumOutputs:    len(tx.Outputs),
-		TotalInput:    totalInput,
-		TotalOutput:   totalOutput,
-		Fee:           fee,
-		FeeRate:       feeRate,
-		ChangeRatio:   changeRatio,
+		NumInputs:      len(tx.Inputs),
+		NumOutputs:     len(tx.Outputs),
+		TotalInput:     totalInput,
+		TotalOutput:    totalOutput,
+		Fee:            fee,
+		FeeRate:        feeRate,
+		ChangeRatio:    changeRatio,
 		InputDiversity: len(inputAddresses),
 	}
 }
-
@@ -0,0 +1,87 @@
+package ai
+
+import (
+	"time"
+
+	"ai-blockchain/go-node/internal/metrics"
+)
+
+// Reasons a transaction went without a real score. Callers carry on as if
+// the AI layer were absent in every case.
+const (
+	fallbackDisabled    = "disabled"    // no -ai-url configured
+	fallbackUnavailable = "unavailable" // the service could not be reached
+	fallbackError       = "error"       // the service answered with an error or garbage
+)
+
+// scoreBuckets spread the 0..1 scores in tenths.
+var scoreBuckets = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1}
+
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// clientMetrics show whether the advisory layer is doing anything: the
+// scores it hands out, how often the node fell back to the default score,
+// how often a score got a transaction rejected, and how long scoring took.
+// Every series is labelled with the node endpoint that asked for the score.
+type clientMetrics struct {
+	latency     *metrics.Histogram
+	anomaly     *metrics.Histogram
+	feeAdequacy *metrics.Histogram
+	fallbacks   *metrics.Counter
+	rejections  *metrics.Counter
+}
+
+// SetMetrics registers the client's scoring metrics in reg.
+func (c *Client) SetMetrics(reg *metrics.Registry) {
+	c.metrics = &clientMetrics{
+		latency: reg.NewHistogram("ai_score_duration_seconds",
+			"Time spent asking the AI service for a transaction score.", latencyBuckets, "endpoint", "outcome"),
+		anomaly: reg.NewHistogram("ai_anomaly_score",
+			"Anomaly scores returned by the AI service.", scoreBuckets, "endpoint"),
+		feeAdequacy: reg.NewHistogram("ai_fee_adequacy",
+			"Fee adequacy scores returned by the AI service.", scoreBuckets, "endpoint"),
+		fallbacks: reg.NewCounter("ai_score_fallbacks_total",
+			"Transactions that went without an AI score, by reason.", "endpoint", "reason"),
+		rejections: reg.NewCounter("ai_rejections_total",
+			"Transactions rejected because of their AI score.", "endpoint"),
+	}
+}
+
+// RecordRejection counts a transaction the node turned away at endpoint
+// because of its score.
+func (c *Client) RecordRejection(endpoint string) {
+	if c == nil || c.metrics == nil {
+		return
+	}
+	c.metrics.rejections.Inc(endpoint)
+}
+
+func (m *clientMetrics) observe(endpoint string, start time.Time, outcome string) {
+	if m == nil {
+		return
+	}
+	m.latency.Observe(time.Since(start).Seconds(), endpoint, outcome)
+}
+
+func (m *clientMetrics) fallback(endpoint, reason string) {
+	if m == nil {
+		return
+	}
+	m.fallbacks.Inc(endpoint, reason)
+}
+
+// fallbackBatch counts n transactions of one batch that went without a score.
+func (m *clientMetrics) fallbackBatch(endpoint, reason string, n int) {
+	if m == nil {
+		return
+	}
+	m.fallbacks.Add(float64(n), endpoint, reason)
+}
+
+func (m *clientMetrics) scored(endpoint string, score *ScoreResponse) {
+	if m == nil {
+		return
+	}
+	m.anomaly.Observe(score.AnomalyScore, endpoint)
+	m.feeAdequacy.Observe(score.FeeAdequacy, endpoint)
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PeerStats are the features sent to the AI service to rate a peer. The
+// misbehaviour counts cover every connection from the peer's host, not just
+// the current one.
+type PeerStats struct {
+	Inbound          bool    `json:"inbound"`
+	ConnectedSeconds float64 `json:"connected_seconds"`
+	PingMs           float64 `json:"ping_ms"`
+	BlockDeliveryMs  float64 `json:"block_delivery_ms"`
+	BlocksReceived   int     `json:"blocks_received"`
+	InvalidBlocks    int     `json:"invalid_blocks"`
+	InvalidTxs       int     `json:"invalid_txs"`
+	Disconnects      int     `json:"disconnects"`
+}
+
+type PeerScore struct {
+	ReliabilityScore float64 `json:"reliability_score"` // 0.0 = unreliable, 1.0 = reliable
+	Message          string  `json:"message,omitempty"`
+}
+
+var ErrUnavailable = errors.New("AI service unavailable")
+
+// ScorePeer asks the AI service how reliable a peer is. Unlike
+// ScoreTransaction there is no default score: when the service is off or
+// unreachable the caller keeps whatever it knew before.
+func (c *Client) ScorePeer(stats PeerStats) (*PeerScore, error) {
+	if !c.Enabled() {
+		return nil, ErrUnavailable
+	}
+
+	reqBody, err := json.Marshal(stats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal peer stats: %w", err)
+	}
+	resp, err := c.httpClient.Post(c.baseURL+"/score/peer", "application/json", bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, ErrUnavailable
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("AI service returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var score PeerScore
+	if err := json.NewDecoder(resp.Body).Decode(&score); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if score.ReliabilityScore < 0 || score.ReliabilityScore > 1 {
+		return nil, fmt.Errorf("reliability score %v outside 0..1", score.ReliabilityScore)
+	}
+	return &score, nil
+}
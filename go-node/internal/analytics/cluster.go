@@ -0,0 +1,239 @@
+// Package analytics derives explorer-facing insights from the chain. Its
+// output is advisory: nothing in it feeds back into consensus or policy.
+package analytics
+
+import (
+	"sort"
+	"sync"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+const (
+	scanBatch = 500
+	// MaxClusterAddresses caps how many member addresses a Cluster lists.
+	MaxClusterAddresses = 100
+)
+
+// Cluster describes the group of addresses an address probably shares an
+// owner with.
+type Cluster struct {
+	Address         string   `json:"address"`
+	ID              string   `json:"cluster_id"` // lowest address in the cluster, stable as it grows
+	Size            int      `json:"size"`
+	Addresses       []string `json:"addresses"`
+	Truncated       bool     `json:"truncated,omitempty"`
+	TxCount         int      `json:"tx_count"` // transactions that linked the cluster's addresses
+	FirstSeenHeight int      `json:"first_seen_height"`
+	Height          int      `json:"height"` // chain height the clustering covers
+}
+
+// Clusterer groups addresses by the common-input-ownership heuristic: the
+// addresses whose coins a transaction spends, and the address of the key
+// that signed it, are assumed to belong to one owner. Groups are kept in a
+// union-find over every address seen on the main chain. The index is
+// brought up to date lazily when queried, incrementally while the chain
+// only grows and from scratch after a reorganization.
+type Clusterer struct {
+	blockchain *chain.Blockchain
+
+	mu        sync.Mutex
+	height    int                 // blocks scanned
+	tipHash   string              // hash of the last block scanned
+	outputs   map[string][]string // txid → output addresses
+	parent    map[string]string
+	members   map[string][]string // root → addresses in its cluster
+	txCount   map[string]int      // root → linking transactions
+	firstSeen map[string]int      // address → height it first appeared at
+}
+
+func NewClusterer(blockchain *chain.Blockchain) *Clusterer {
+	c := &Clusterer{blockchain: blockchain}
+	c.reset()
+	return c
+}
+
+func (c *Clusterer) reset() {
+	c.height = 0
+	c.tipHash = ""
+	c.outputs = make(map[string][]string)
+	c.parent = make(map[string]string)
+	c.members = make(map[string][]string)
+	c.txCount = make(map[string]int)
+	c.firstSeen = make(map[string]int)
+}
+
+// Lookup returns the cluster containing address, or false if the address
+// has never appeared on the main chain.
+func (c *Clusterer) Lookup(address string) (Cluster, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshLocked()
+
+	if _, ok := c.parent[address]; !ok {
+		return Cluster{}, false
+	}
+	root := c.find(address)
+	members := c.members[root]
+
+	cluster := Cluster{
+		Address: address,
+		ID:      members[0],
+		Size:    len(members),
+		TxCount: c.txCount[root],
+		Height:  c.height,
+	}
+	first := -1
+	for _, a := range members {
+		if a < cluster.ID {
+			cluster.ID = a
+		}
+		if h := c.firstSeen[a]; first < 0 || h < first {
+			first = h
+		}
+	}
+	cluster.FirstSeenHeight = first
+
+	list := append([]string(nil), members...)
+	sort.Strings(list)
+	if len(list) > MaxClusterAddresses {
+		list = list[:MaxClusterAddresses]
+		cluster.Truncated = true
+	}
+	cluster.Addresses = list
+	return cluster, true
+}
+
+// InputCluster sizes the cluster a transaction's inputs and signer would
+// form, as features for transaction scoring: the number of addresses and
+// of linking transactions across every cluster it touches.
+func (c *Clusterer) InputCluster(tx *chain.Transaction) (size, txCount int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshLocked()
+
+	roots := make(map[string]bool)
+	unknown := make(map[string]bool)
+	for _, addr := range c.ownersLocked(tx) {
+		if _, ok := c.parent[addr]; ok {
+			roots[c.find(addr)] = true
+		} else {
+			unknown[addr] = true
+		}
+	}
+	size = len(unknown)
+	for root := range roots {
+		size += len(c.members[root])
+		txCount += c.txCount[root]
+	}
+	return size, txCount
+}
+
+// refreshLocked scans blocks the index has not seen yet, starting over if
+// the block it stopped at has left the main chain.
+func (c *Clusterer) refreshLocked() {
+	if c.height > 0 {
+		if h, ok := c.blockchain.HeaderAt(c.height - 1); !ok || h.Hash != c.tipHash {
+			c.reset()
+		}
+	}
+	for {
+		blocks := c.blockchain.BlocksFrom(c.height, scanBatch)
+		if len(blocks) == 0 {
+			return
+		}
+		for _, block := range blocks {
+			if block.Index != c.height || (c.height > 0 && block.PrevHash != c.tipHash) {
+				// The chain reorganized while we were reading it.
+				c.reset()
+				break
+			}
+			c.addBlock(block)
+		}
+	}
+}
+
+func (c *Clusterer) addBlock(block *chain.Block) {
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if !tx.IsCoinbase() {
+			owners := c.ownersLocked(tx)
+			for _, addr := range owners {
+				c.see(addr, block.Index)
+			}
+			if len(owners) > 1 {
+				root := c.find(owners[0])
+				for _, addr := range owners[1:] {
+					root = c.union(root, c.find(addr))
+				}
+				c.txCount[root]++
+			}
+		}
+
+		addrs := make([]string, len(tx.Outputs))
+		for j, out := range tx.Outputs {
+			addrs[j] = out.Address
+			c.see(out.Address, block.Index)
+		}
+		c.outputs[tx.ID] = addrs
+	}
+	c.height = block.Index + 1
+	c.tipHash = block.Hash
+}
+
+// ownersLocked lists the distinct addresses a transaction spends from plus
+// the address of its signing key.
+func (c *Clusterer) ownersLocked(tx *chain.Transaction) []string {
+	seen := make(map[string]bool)
+	var owners []string
+	add := func(addr string) {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			owners = append(owners, addr)
+		}
+	}
+	for _, in := range tx.Inputs {
+		if outs := c.outputs[in.TxID]; in.Index >= 0 && in.Index < len(outs) {
+			add(outs[in.Index])
+		}
+	}
+	if pub, err := crypto.DecodePublicKey(tx.PubKey); err == nil {
+		add(crypto.AddressFromPublicKey(pub))
+	}
+	return owners
+}
+
+func (c *Clusterer) see(addr string, height int) {
+	if _, ok := c.parent[addr]; ok {
+		return
+	}
+	c.parent[addr] = addr
+	c.members[addr] = []string{addr}
+	c.firstSeen[addr] = height
+}
+
+func (c *Clusterer) find(addr string) string {
+	for c.parent[addr] != addr {
+		c.parent[addr] = c.parent[c.parent[addr]]
+		addr = c.parent[addr]
+	}
+	return addr
+}
+
+// union merges the clusters rooted at a and b, smaller into larger, and
+// returns the surviving root.
+func (c *Clusterer) union(a, b string) string {
+	if a == b {
+		return a
+	}
+	if len(c.members[a]) < len(c.members[b]) {
+		a, b = b, a
+	}
+	c.parent[b] = a
+	c.members[a] = append(c.members[a], c.members[b]...)
+	c.txCount[a] += c.txCount[b]
+	delete(c.members, b)
+	delete(c.txCount, b)
+	return a
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type addressHistoryEntry struct {
+	chain.AddressTx
+	Confirmations int `json:"confirmations"`
+}
+
+type addressHistoryResponse struct {
+	Address      string                `json:"address"`
+	Count        int                   `json:"count"`
+	Offset       int                   `json:"offset"`
+	Total        int                   `json:"total"`
+	Transactions []addressHistoryEntry `json:"transactions"`
+}
+
+// handleAddressHistory serves GET /address/:addr/history: the confirmed
+// transactions that paid or spent from an address, oldest first, paged like
+// /blocks.
+func (s *Server) handleAddressHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/address/")
+	address, ok := strings.CutSuffix(rest, "/history")
+	if !ok || strings.Contains(address, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	address = strings.ToLower(address)
+	if err := wallet.ValidateAddress(address); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history := s.cached("history:"+address, func() interface{} {
+		return s.blockchain.AddressHistory(address)
+	}).([]chain.AddressTx)
+
+	height := s.blockchain.Height()
+	positions := page.indices(len(history))
+	entries := make([]addressHistoryEntry, len(positions))
+	for i, pos := range positions {
+		entries[i] = addressHistoryEntry{AddressTx: history[pos], Confirmations: height - history[pos].Height}
+	}
+
+	writeJSON(w, &addressHistoryResponse{
+		Address:      address,
+		Count:        len(entries),
+		Offset:       page.offset,
+		Total:        len(history),
+		Transactions: entries,
+	})
+}
+
+// handleValidateAddress serves GET /api/address/validate?address=: whether
+// the address is usable on this network and, if not, why. Malformed
+// addresses are a 200 answer, not an error.
+func (s *Server) handleValidateAddress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !r.URL.Query().Has("address") {
+		http.Error(w, "address query parameter required", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, wallet.CheckAddress(r.URL.Query().Get("address")))
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/follower"
+)
+
+// apiKeyHeader carries the admin token for clients that cannot set
+// Authorization.
+const apiKeyHeader = "X-API-Key"
+
+var errNoCredentials = errors.New("missing credentials")
+
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken = token
+}
+
+// SetAdminJWTSecret also accepts HS256 JWTs signed with secret as admin
+// credentials.
+func (s *Server) SetAdminJWTSecret(secret []byte) {
+	s.adminJWTSecret = secret
+}
+
+// SetAdminKeys adds named admin tokens, so several operators can hold
+// credentials of their own. A caller using one is "key:<name>".
+func (s *Server) SetAdminKeys(keys map[string]string) {
+	s.adminKeys = keys
+}
+
+// adminEnabled reports whether admin credentials have been configured.
+func (s *Server) adminEnabled() bool {
+	return s.adminToken != "" || len(s.adminJWTSecret) > 0 || len(s.adminKeys) > 0
+}
+
+// authorizeAdmin checks the credentials of r: the admin token, a named
+// admin key, or a JWT signed with the admin secret, sent as
+// "Authorization: Bearer ..." or in X-API-Key. It returns who the caller
+// is, for the logs and for telling approvers apart.
+func (s *Server) authorizeAdmin(r *http.Request) (string, error) {
+	credential := r.Header.Get(apiKeyHeader)
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		credential = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if credential == "" {
+		return "", errNoCredentials
+	}
+
+	if s.adminToken != "" && subtle.ConstantTimeCompare([]byte(credential), []byte(s.adminToken)) == 1 {
+		return "token", nil
+	}
+	for name, key := range s.adminKeys {
+		if subtle.ConstantTimeCompare([]byte(credential), []byte(key)) == 1 {
+			return "key:" + name, nil
+		}
+	}
+	if len(s.adminJWTSecret) > 0 && strings.Count(credential, ".") == 2 {
+		claims, err := verifyJWT(credential, s.adminJWTSecret, time.Now())
+		if err != nil {
+			return "", err
+		}
+		return "jwt:" + claims.Subject, nil
+	}
+	return "", errors.New("invalid credentials")
+}
+
+func (s *Server) adminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminEnabled() {
+			http.Error(w, "Admin API disabled (start node with -admin-token, -admin-keys or -admin-jwt-secret)", http.StatusForbidden)
+			return
+		}
+
+		caller, err := s.authorizeAdmin(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			requestLogger(r).Info("Admin request", "caller", caller, "method", r.Method, "path", r.URL.Path)
+		}
+
+		next(w, withAdminCaller(r, caller))
+	}
+}
+
+// sensitive guards endpoints that spend, sign with or reveal the node's
+// wallets, mine, or manage peers. Once admin credentials are configured
+// they require them like /admin endpoints do; without any, the node is
+// open as a development node always was.
+func (s *Server) sensitive(next http.HandlerFunc) http.HandlerFunc {
+	guarded := s.adminOnly(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminEnabled() {
+			next(w, r)
+			return
+		}
+		guarded(w, r)
+	}
+}
+
+// whenLive rejects requests while the chain is frozen or the node is a read
+// replica. Use it for endpoints that always change state.
+func (s *Server) whenLive(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || s.requireLive(w) {
+			next(w, r)
+		}
+	}
+}
+
+// writesWhenLive is whenLive for mixed endpoints: reads always pass, other
+// methods are rejected while frozen or in replica mode.
+func (s *Server) writesWhenLive(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next(w, r)
+		default:
+			if s.requireLive(w) {
+				next(w, r)
+			}
+		}
+	}
+}
+
+// requireLive reports whether the node accepts writes, answering 503 when it
+// is a read-only replica or frozen.
+func (s *Server) requireLive(w http.ResponseWriter) bool {
+	if s.follower != nil {
+		http.Error(w, "Read-only replica; send writes to "+s.follower.Primary(), http.StatusServiceUnavailable)
+		return false
+	}
+	if frozen, reason := s.blockchain.Frozen(); frozen {
+		http.Error(w, "Node is frozen: "+reason, http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+type freezeRequest struct {
+	Reason string `json:"reason"` // "emergency halt" when empty
+}
+
+// freezeResponse answers both /admin/freeze and /admin/unfreeze.
+type freezeResponse struct {
+	Height int    `json:"height"`
+	Reason string `json:"reason,omitempty"`
+	Status string `json:"status"` // frozen or live
+}
+
+type clearMempoolResponse struct {
+	Cleared int    `json:"cleared"`
+	Status  string `json:"status"`
+}
+
+func (s *Server) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request freezeRequest
+	json.NewDecoder(r.Body).Decode(&request)
+	if request.Reason == "" {
+		request.Reason = "emergency halt"
+	}
+
+	s.blockchain.Freeze(request.Reason)
+	requestLogger(r).Warn("Chain FROZEN by admin", "reason", request.Reason)
+
+	writeJSON(w, &freezeResponse{
+		Status: "frozen",
+		Reason: request.Reason,
+		Height: s.blockchain.Height(),
+	})
+}
+
+func (s *Server) handleUnfreeze(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.blockchain.Unfreeze()
+	requestLogger(r).Info("Chain unfrozen by admin")
+
+	writeJSON(w, &freezeResponse{
+		Status: "live",
+		Height: s.blockchain.Height(),
+	})
+}
+
+func (s *Server) handleClearMempool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cleared, err := s.blocks.ClearMempool()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	requestLogger(r).Warn("Mempool cleared by admin", "transactions", cleared)
+
+	writeJSON(w, &clearMempoolResponse{Status: "cleared", Cleared: cleared})
+}
+
+// handleReindex rebuilds the UTXO set and the transaction and address
+// indexes from the main chain.
+func (s *Server) handleReindex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start := time.Now()
+	result, err := s.blocks.Reindex()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if s.cache != nil {
+		s.cache.invalidate()
+	}
+	log := requestLogger(r).With("blocks", result.Blocks, "utxos", result.UTXOs, "duration", time.Since(start))
+	if result.Changed {
+		log.Warn("Reindex by admin repaired the UTXO set", "utxo_hash", result.UTXOHash)
+	} else {
+		log.Info("Reindex by admin completed", "utxo_hash", result.UTXOHash)
+	}
+
+	writeJSON(w, result)
+}
+
+func (s *Server) SetFollower(f *follower.Follower) {
+	s.follower = f
+}
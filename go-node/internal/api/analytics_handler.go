@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"ai-blockchain/go-node/internal/analytics"
+)
+
+func (s *Server) SetClusterer(c *analytics.Clusterer) {
+	s.clusters = c
+}
+
+// handleAddressCluster reports which addresses probably share an owner with
+// the given one. The heuristic is easily fooled (coinjoins, shared wallets),
+// so the result is labelled advisory.
+type addressClusterResponse struct {
+	Advisory  bool              `json:"advisory"` // the heuristic can be wrong
+	Cluster   analytics.Cluster `json:"cluster"`
+	Heuristic string            `json:"heuristic"`
+}
+
+func (s *Server) handleAddressCluster(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.clusters == nil {
+		http.Error(w, "Address analytics not enabled", http.StatusConflict)
+		return
+	}
+
+	address := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/analytics/cluster/"))
+	if address == "" {
+		http.Error(w, "Address required", http.StatusBadRequest)
+		return
+	}
+	cluster, ok := s.clusters.Lookup(address)
+	if !ok {
+		http.Error(w, "Address not seen on chain", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&addressClusterResponse{
+		Advisory:  true,
+		Cluster:   cluster,
+		Heuristic: "common-input-ownership",
+	})
+}
@@ -0,0 +1,390 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultProposalTTL is how long a proposed admin action waits for its
+	// approvals before it lapses.
+	DefaultProposalTTL = 15 * time.Minute
+
+	maxProposals     = 100     // proposals kept, settled ones dropped first
+	maxProposalBody  = 1 << 16 // bytes of request body a proposal stores
+	proposalPending  = "pending"
+	proposalExecuted = "executed"
+	proposalCanceled = "cancelled"
+	proposalExpired  = "expired"
+)
+
+var (
+	errUnknownProposal = errors.New("no such proposal")
+	errProposalSettled = errors.New("proposal is no longer pending")
+	errSameApprover    = errors.New("already approved by this credential; another admin must approve")
+)
+
+// Proposal is a destructive admin request that runs only once enough
+// distinct admin credentials have approved it.
+type Proposal struct {
+	ID         string   `json:"id"`
+	Action     string   `json:"action"` // method and path, e.g. "POST /admin/freeze"
+	Body       string   `json:"body,omitempty"`
+	ProposedBy string   `json:"proposed_by"`
+	Approvals  []string `json:"approvals"` // callers who signed off, proposer first
+	Required   int      `json:"required"`
+	Status     string   `json:"status"` // pending, executed, cancelled or expired
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  int64    `json:"expires_at"`
+	SettledBy  string   `json:"settled_by,omitempty"`
+	SettledAt  int64    `json:"settled_at,omitempty"`
+	Result     int      `json:"result_status,omitempty"` // HTTP status the action answered with
+}
+
+type proposalsResponse struct {
+	Proposals []Proposal `json:"proposals"` // newest first
+	Required  int        `json:"required"`
+	TTL       string     `json:"ttl"`
+}
+
+// proposal is a Proposal with what is needed to replay its request.
+type proposal struct {
+	Proposal
+	method      string
+	uri         string
+	contentType string
+	body        []byte
+	handler     http.HandlerFunc
+}
+
+// approvalBook holds the proposals of the admin API's two-person rule.
+type approvalBook struct {
+	required int
+	ttl      time.Duration
+
+	mu        sync.Mutex
+	nextID    uint64
+	proposals []*proposal // oldest first
+}
+
+func newApprovalBook(required int, ttl time.Duration) *approvalBook {
+	return &approvalBook{required: required, ttl: ttl}
+}
+
+func (b *approvalBook) propose(caller string, r *http.Request, body []byte, handler http.HandlerFunc) Proposal {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.expireLocked(now)
+	b.nextID++
+	p := &proposal{
+		Proposal: Proposal{
+			ID:         strconv.FormatUint(b.nextID, 10),
+			Action:     r.Method + " " + r.URL.Path,
+			Body:       string(body),
+			ProposedBy: caller,
+			Approvals:  []string{caller},
+			Required:   b.required,
+			Status:     proposalPending,
+			CreatedAt:  now.Unix(),
+			ExpiresAt:  now.Add(b.ttl).Unix(),
+		},
+		method:      r.Method,
+		uri:         r.URL.RequestURI(),
+		contentType: r.Header.Get("Content-Type"),
+		body:        body,
+		handler:     handler,
+	}
+	b.proposals = append(b.proposals, p)
+	b.trimLocked()
+	return p.snapshot()
+}
+
+// approve adds caller's approval to a pending proposal. When it is the last
+// one needed the proposal is marked executed and returned for the caller to
+// run; otherwise the returned proposal is nil.
+func (b *approvalBook) approve(id, caller string) (Proposal, *proposal, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.expireLocked(now)
+	p := b.findLocked(id)
+	if p == nil {
+		return Proposal{}, nil, errUnknownProposal
+	}
+	if p.Status != proposalPending {
+		return p.snapshot(), nil, errProposalSettled
+	}
+	for _, approver := range p.Approvals {
+		if approverCredential(approver) == approverCredential(caller) {
+			return p.snapshot(), nil, errSameApprover
+		}
+	}
+	p.Approvals = append(p.Approvals, caller)
+	if len(p.Approvals) < p.Required {
+		return p.snapshot(), nil, nil
+	}
+	p.Status, p.SettledBy, p.SettledAt = proposalExecuted, caller, now.Unix()
+	return p.snapshot(), p, nil
+}
+
+// approverCredential is the credential a caller counts as when approvals
+// are told apart. Every JWT is signed with the one shared secret, and its
+// holder picks the subject, so all JWT callers are a single approver.
+func approverCredential(caller string) string {
+	if strings.HasPrefix(caller, "jwt:") {
+		return "jwt"
+	}
+	return caller
+}
+
+// executed records the status the action of an executed proposal answered.
+func (b *approvalBook) executed(p *proposal, status int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p.Result = status
+}
+
+func (b *approvalBook) cancel(id, caller string) (Proposal, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.expireLocked(now)
+	p := b.findLocked(id)
+	if p == nil {
+		return Proposal{}, errUnknownProposal
+	}
+	if p.Status != proposalPending {
+		return p.snapshot(), errProposalSettled
+	}
+	p.Status, p.SettledBy, p.SettledAt = proposalCanceled, caller, now.Unix()
+	return p.snapshot(), nil
+}
+
+func (b *approvalBook) get(id string) (Proposal, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireLocked(time.Now())
+	if p := b.findLocked(id); p != nil {
+		return p.snapshot(), true
+	}
+	return Proposal{}, false
+}
+
+// list returns every proposal kept, newest first.
+func (b *approvalBook) list() []Proposal {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireLocked(time.Now())
+	list := make([]Proposal, 0, len(b.proposals))
+	for i := len(b.proposals) - 1; i >= 0; i-- {
+		list = append(list, b.proposals[i].snapshot())
+	}
+	return list
+}
+
+func (b *approvalBook) findLocked(id string) *proposal {
+	for _, p := range b.proposals {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (b *approvalBook) expireLocked(now time.Time) {
+	for _, p := range b.proposals {
+		if p.Status == proposalPending && now.Unix() >= p.ExpiresAt {
+			p.Status, p.SettledAt = proposalExpired, p.ExpiresAt
+		}
+	}
+}
+
+// trimLocked keeps at most maxProposals, dropping the oldest settled ones
+// and, if every proposal is pending, the oldest pending ones.
+func (b *approvalBook) trimLocked() {
+	for len(b.proposals) > maxProposals {
+		drop := 0
+		for i, p := range b.proposals {
+			if p.Status != proposalPending {
+				drop = i
+				break
+			}
+		}
+		b.proposals = append(b.proposals[:drop], b.proposals[drop+1:]...)
+	}
+}
+
+func (p *proposal) snapshot() Proposal {
+	s := p.Proposal
+	s.Approvals = append([]string(nil), p.Approvals...)
+	return s
+}
+
+// SetAdminApprovals makes destructive admin actions (freezing the chain,
+// clearing the mempool, reindexing) wait for required distinct admin
+// credentials, the proposer's included. 1 or less runs them at once.
+func (s *Server) SetAdminApprovals(required int) {
+	s.approvals = newApprovalBook(required, DefaultProposalTTL)
+}
+
+// twoPerson guards a destructive admin action. Once approvals are required
+// a request does not run: it is stored as a proposal and replayed when
+// other admins approve it through /admin/proposals. Use it inside adminOnly.
+func (s *Server) twoPerson(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.approvals == nil || s.approvals.required <= 1 || r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxProposalBody+1))
+		if err != nil {
+			http.Error(w, "Failed to read request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxProposalBody {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		p := s.approvals.propose(adminCaller(r), r, body, next)
+		requestLogger(r).Warn("Admin action proposed", "proposal", p.ID, "action", p.Action, "caller", p.ProposedBy, "required", p.Required)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+// handleProposals serves /admin/proposals:
+//
+//	GET    /admin/proposals              every proposal, newest first
+//	GET    /admin/proposals/{id}         one proposal
+//	POST   /admin/proposals/{id}/approve approve; the last approval runs it
+//	DELETE /admin/proposals/{id}         cancel a pending proposal
+func (s *Server) handleProposals(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/proposals"), "/")
+	id, verb, _ := strings.Cut(rest, "/")
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		required := 1
+		list := []Proposal{}
+		if s.approvals != nil {
+			required = s.approvals.required
+			list = s.approvals.list()
+		}
+		writeJSON(w, &proposalsResponse{
+			Proposals: list,
+			Required:  required,
+			TTL:       DefaultProposalTTL.String(),
+		})
+
+	case id != "" && verb == "" && r.Method == http.MethodGet:
+		if s.approvals == nil {
+			http.Error(w, errUnknownProposal.Error(), http.StatusNotFound)
+			return
+		}
+		p, ok := s.approvals.get(id)
+		if !ok {
+			http.Error(w, errUnknownProposal.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, p)
+
+	case id != "" && verb == "approve" && r.Method == http.MethodPost:
+		s.approveProposal(w, r, id)
+
+	case id != "" && verb == "" && r.Method == http.MethodDelete:
+		if s.approvals == nil {
+			http.Error(w, errUnknownProposal.Error(), http.StatusNotFound)
+			return
+		}
+		p, err := s.approvals.cancel(id, adminCaller(r))
+		if err != nil {
+			http.Error(w, err.Error(), proposalErrorStatus(err))
+			return
+		}
+		requestLogger(r).Info("Admin proposal cancelled", "proposal", p.ID, "action", p.Action, "caller", p.SettledBy)
+		writeJSON(w, p)
+
+	case id == "" || verb == "" || verb == "approve":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// approveProposal records an approval and, on the last one, replays the
+// proposed request as the approver, answering with the action's response.
+func (s *Server) approveProposal(w http.ResponseWriter, r *http.Request, id string) {
+	if s.approvals == nil {
+		http.Error(w, errUnknownProposal.Error(), http.StatusNotFound)
+		return
+	}
+	caller := adminCaller(r)
+	snapshot, ready, err := s.approvals.approve(id, caller)
+	if err != nil {
+		http.Error(w, err.Error(), proposalErrorStatus(err))
+		return
+	}
+	log := requestLogger(r).With("proposal", snapshot.ID, "action", snapshot.Action, "caller", caller)
+	if ready == nil {
+		log.Info("Admin proposal approved", "approvals", len(snapshot.Approvals), "required", snapshot.Required)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(snapshot)
+		return
+	}
+
+	log.Warn("Admin proposal approved, executing", "approvals", snapshot.Approvals)
+	replay, err := http.NewRequestWithContext(r.Context(), ready.method, ready.uri, bytes.NewReader(ready.body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	replay.RemoteAddr = r.RemoteAddr
+	if ready.contentType != "" {
+		replay.Header.Set("Content-Type", ready.contentType)
+	}
+	w.Header().Set("X-Proposal-ID", snapshot.ID)
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	ready.handler(rec, replay)
+	s.approvals.executed(ready, rec.status)
+}
+
+func proposalErrorStatus(err error) int {
+	switch err {
+	case errUnknownProposal:
+		return http.StatusNotFound
+	case errSameApprover:
+		return http.StatusForbidden
+	default:
+		return http.StatusConflict
+	}
+}
+
+type adminCallerKey struct{}
+
+// adminCaller is who adminOnly authorized r as.
+func adminCaller(r *http.Request) string {
+	caller, _ := r.Context().Value(adminCallerKey{}).(string)
+	return caller
+}
+
+func withAdminCaller(r *http.Request, caller string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), adminCallerKey{}, caller))
+}
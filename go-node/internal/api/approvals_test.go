@@ -0,0 +1,118 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// approvalServer requires two approvals for a counted action, with the
+// named keys alice and bob and a JWT secret configured.
+func approvalServer(t *testing.T, runs *int) (action, proposals http.HandlerFunc, secret []byte) {
+	t.Helper()
+	secret = []byte("approval-test-secret")
+	s := &Server{}
+	s.SetAdminKeys(map[string]string{"alice": "alice-key", "bob": "bob-key"})
+	s.SetAdminJWTSecret(secret)
+	s.SetAdminApprovals(2)
+	action = s.adminOnly(s.twoPerson(func(w http.ResponseWriter, r *http.Request) {
+		*runs++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return action, s.adminOnly(s.handleProposals), secret
+}
+
+func adminRequest(handler http.HandlerFunc, method, path, credential string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	r.Header.Set("Authorization", "Bearer "+credential)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func propose(t *testing.T, action http.HandlerFunc, credential string) string {
+	t.Helper()
+	w := adminRequest(action, http.MethodPost, "/admin/freeze", credential)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("propose: status %d, want %d: %s", w.Code, http.StatusAccepted, w.Body)
+	}
+	var p Proposal
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatal(err)
+	}
+	return p.ID
+}
+
+func mintJWT(t *testing.T, secret []byte, subject string) string {
+	t.Helper()
+	token, err := NewAdminJWT(secret, subject, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestApprovalNeedsAnotherCredential(t *testing.T) {
+	runs := 0
+	action, proposals, _ := approvalServer(t, &runs)
+
+	id := propose(t, action, "alice-key")
+	if runs != 0 {
+		t.Fatal("proposed action ran before approval")
+	}
+	if w := adminRequest(proposals, http.MethodPost, "/admin/proposals/"+id+"/approve", "alice-key"); w.Code != http.StatusForbidden {
+		t.Fatalf("self-approval: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if runs != 0 {
+		t.Fatal("self-approval ran the action")
+	}
+
+	w := adminRequest(proposals, http.MethodPost, "/admin/proposals/"+id+"/approve", "bob-key")
+	if w.Code != http.StatusNoContent || runs != 1 {
+		t.Fatalf("second key: status %d and %d runs, want %d and 1", w.Code, runs, http.StatusNoContent)
+	}
+	if w := adminRequest(proposals, http.MethodPost, "/admin/proposals/"+id+"/approve", "bob-key"); w.Code != http.StatusConflict {
+		t.Errorf("approving an executed proposal: status %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+// Anyone with the shared secret can mint a second subject, so two JWTs are
+// one approver however their subjects differ.
+func TestJWTSubjectsAreOneApprover(t *testing.T) {
+	runs := 0
+	action, proposals, secret := approvalServer(t, &runs)
+
+	id := propose(t, action, mintJWT(t, secret, "alice"))
+	if w := adminRequest(proposals, http.MethodPost, "/admin/proposals/"+id+"/approve", mintJWT(t, secret, "mallory")); w.Code != http.StatusForbidden {
+		t.Fatalf("second JWT subject: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if runs != 0 {
+		t.Fatal("a second JWT subject ran the action")
+	}
+
+	// A named key is a different credential.
+	if w := adminRequest(proposals, http.MethodPost, "/admin/proposals/"+id+"/approve", "bob-key"); w.Code != http.StatusNoContent || runs != 1 {
+		t.Fatalf("named key after JWT: status %d and %d runs, want %d and 1", w.Code, runs, http.StatusNoContent)
+	}
+}
+
+func TestPendingProposalsExpire(t *testing.T) {
+	book := newApprovalBook(2, 0)
+	r := httptest.NewRequest(http.MethodPost, "/admin/freeze", nil)
+	p := book.propose("key:alice", r, nil, func(http.ResponseWriter, *http.Request) {
+		t.Error("expired proposal ran")
+	})
+
+	snapshot, ready, err := book.approve(p.ID, "key:bob")
+	if err != errProposalSettled || ready != nil {
+		t.Fatalf("approve after expiry: err %v, ready %v; want %v", err, ready != nil, errProposalSettled)
+	}
+	if snapshot.Status != proposalExpired {
+		t.Errorf("status %q, want %q", snapshot.Status, proposalExpired)
+	}
+	if _, err := book.cancel(p.ID, "key:bob"); err != errProposalSettled {
+		t.Errorf("cancel after expiry: err %v, want %v", err, errProposalSettled)
+	}
+}
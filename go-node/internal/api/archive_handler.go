@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/archive"
+)
+
+// SetArchive serves the block archive under /archive.
+func (s *Server) SetArchive(a *archive.Archive) {
+	s.archive = a
+}
+
+// handleArchive serves /archive and everything under it:
+//
+//	GET /archive               what the archive holds
+//	GET /archive/blocks.dat    block records, with range requests
+//	GET /archive/blocks.idx    the offset index, with range requests
+//	GET /archive/blocks/{hash} where a block's record is in blocks.dat
+func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.archive == nil {
+		http.Error(w, "Block archive disabled (start node with -archive-dir)", http.StatusNotFound)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/archive")
+	switch {
+	case path == "" || path == "/":
+		writeJSON(w, s.archive.Stats())
+
+	case path == "/"+archive.DataFile:
+		s.serveArchiveFile(w, r, archive.DataFile)
+
+	case path == "/"+archive.IndexFile:
+		s.serveArchiveFile(w, r, archive.IndexFile)
+
+	case strings.HasPrefix(path, "/blocks/"):
+		loc, err := s.archive.Locate(strings.TrimPrefix(path, "/blocks/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeJSON(w, loc)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveArchiveFile serves one of the archive's files as far as it has been
+// written. http.ServeContent answers Range requests. The files only grow,
+// so a byte range, once served, never changes.
+func (s *Server) serveArchiveFile(w http.ResponseWriter, r *http.Request, name string) {
+	reader, index, blocks := s.archive.Files()
+	if name == archive.IndexFile {
+		reader = index
+	}
+	w.Header().Set("Content-Type", binaryContentType)
+	w.Header().Set("X-Archive-Blocks", strconv.Itoa(blocks))
+	w.Header().Set("X-Archive-Entry-Size", strconv.Itoa(archive.EntrySize))
+	http.ServeContent(w, r, name, time.Time{}, reader)
+}
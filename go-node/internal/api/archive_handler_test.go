@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/archive"
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+func archiveServer(t *testing.T, blocks int) *Server {
+	t.Helper()
+	a, err := archive.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	bc := chaintest.NewChain(t, strings.Repeat("a", 64), blocks)
+	if _, err := a.Sync(bc); err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{blockchain: bc}
+	s.SetArchive(a)
+	return s
+}
+
+func getArchive(s *Server, path, ranges string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	if ranges != "" {
+		r.Header.Set("Range", ranges)
+	}
+	w := httptest.NewRecorder()
+	s.handleArchive(w, r)
+	return w
+}
+
+func TestArchiveRanges(t *testing.T) {
+	s := archiveServer(t, 2)
+	full := getArchive(s, "/archive/"+archive.DataFile, "")
+	if full.Code != http.StatusOK || full.Header().Get("Accept-Ranges") != "bytes" {
+		t.Fatalf("whole file: status %d, Accept-Ranges %q", full.Code, full.Header().Get("Accept-Ranges"))
+	}
+	data := full.Body.Bytes()
+	size := len(data)
+	if size < 100 {
+		t.Fatalf("blocks.dat is only %d bytes", size)
+	}
+
+	tests := []struct {
+		name          string
+		ranges        string
+		first, last   int // the bytes expected, inclusive
+		contentLength int
+	}{
+		{"prefix", "bytes=0-9", 0, 9, 10},
+		{"middle", "bytes=20-59", 20, 59, 40},
+		{"suffix", "bytes=-16", size - 16, size - 1, 16},
+		{"open ended", fmt.Sprintf("bytes=%d-", size-30), size - 30, size - 1, 30},
+		{"past the end", fmt.Sprintf("bytes=%d-%d", size-5, size+100), size - 5, size - 1, 5},
+	}
+	for _, tt := range tests {
+		w := getArchive(s, "/archive/"+archive.DataFile, tt.ranges)
+		if w.Code != http.StatusPartialContent {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, http.StatusPartialContent)
+			continue
+		}
+		if want := fmt.Sprintf("bytes %d-%d/%d", tt.first, tt.last, size); w.Header().Get("Content-Range") != want {
+			t.Errorf("%s: Content-Range %q, want %q", tt.name, w.Header().Get("Content-Range"), want)
+		}
+		if w.Body.Len() != tt.contentLength || !bytes.Equal(w.Body.Bytes(), data[tt.first:tt.last+1]) {
+			t.Errorf("%s: got %d bytes, not bytes %d-%d of the file", tt.name, w.Body.Len(), tt.first, tt.last)
+		}
+	}
+
+	for _, ranges := range []string{fmt.Sprintf("bytes=%d-", size), fmt.Sprintf("bytes=%d-%d", size+10, size+20)} {
+		w := getArchive(s, "/archive/"+archive.DataFile, ranges)
+		if w.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("%s: status %d, want %d", ranges, w.Code, http.StatusRequestedRangeNotSatisfiable)
+		}
+		if want := fmt.Sprintf("bytes */%d", size); w.Header().Get("Content-Range") != want {
+			t.Errorf("%s: Content-Range %q, want %q", ranges, w.Header().Get("Content-Range"), want)
+		}
+	}
+
+	for _, ranges := range []string{"bytes=9-2", "lines=0-9"} {
+		if w := getArchive(s, "/archive/"+archive.DataFile, ranges); w.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("%s: status %d, want %d", ranges, w.Code, http.StatusRequestedRangeNotSatisfiable)
+		}
+	}
+}
+
+func TestArchiveMultipleRanges(t *testing.T) {
+	s := archiveServer(t, 2)
+	data := getArchive(s, "/archive/"+archive.DataFile, "").Body.Bytes()
+
+	w := getArchive(s, "/archive/"+archive.DataFile, "bytes=0-3,10-19,-5")
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusPartialContent)
+	}
+	mediaType, params, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Content-Type %q, want multipart/byteranges", w.Header().Get("Content-Type"))
+	}
+	want := []struct{ first, last int }{{0, 3}, {10, 19}, {len(data) - 5, len(data) - 1}}
+	parts := multipart.NewReader(w.Body, params["boundary"])
+	for i := 0; ; i++ {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("%d parts, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Fatalf("more than %d parts", len(want))
+		}
+		body, _ := io.ReadAll(part)
+		r := want[i]
+		if got, want := part.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-%d/%d", r.first, r.last, len(data)); got != want {
+			t.Errorf("part %d: Content-Range %q, want %q", i, got, want)
+		}
+		if !bytes.Equal(body, data[r.first:r.last+1]) {
+			t.Errorf("part %d: wrong bytes", i)
+		}
+	}
+}
+
+// Ranges over the index line up with its fixed-size entries, and a range
+// served once is unchanged after the archive grows.
+func TestArchiveIndexRanges(t *testing.T) {
+	s := archiveServer(t, 2)
+	w := getArchive(s, "/archive/"+archive.IndexFile, fmt.Sprintf("bytes=%d-%d", archive.EntrySize, 2*archive.EntrySize-1))
+	if w.Code != http.StatusPartialContent || w.Body.Len() != archive.EntrySize {
+		t.Fatalf("second entry: status %d with %d bytes", w.Code, w.Body.Len())
+	}
+	if got := w.Header().Get("X-Archive-Blocks"); got != "3" {
+		t.Errorf("X-Archive-Blocks %q, want 3", got)
+	}
+	if w := getArchive(s, "/archive/"+archive.IndexFile, fmt.Sprintf("bytes=%d-", 3*archive.EntrySize)); w.Code != http.StatusRequestedRangeNotSatisfiable {
+		t.Errorf("entry past the end: status %d, want %d", w.Code, http.StatusRequestedRangeNotSatisfiable)
+	}
+
+	before := getArchive(s, "/archive/"+archive.DataFile, "").Body.Bytes()
+	if _, err := s.archive.Sync(chaintest.NewChain(t, strings.Repeat("a", 64), 4)); err != nil {
+		t.Fatal(err)
+	}
+	grown := getArchive(s, "/archive/"+archive.DataFile, "").Body.Bytes()
+	if len(grown) <= len(before) || !bytes.Equal(grown[:len(before)], before) {
+		t.Errorf("blocks.dat went from %d to %d bytes, want it to grow past the bytes already served", len(before), len(grown))
+	}
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	DefaultQueryCacheTTL  = 30 * time.Second
+	DefaultQueryCacheSize = 10000
+)
+
+// QueryCache keeps the results of expensive explorer queries (per-address
+// balances, outputs and history, the rich list) for a short time. Every entry belongs
+// to the tip it was computed at: the first lookup after a new block or a
+// reorg sees a different tip and drops everything, so a cached answer is
+// never older than the chain it is served with.
+type QueryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu            sync.Mutex
+	tip           string
+	entries       map[string]cacheEntry
+	hits          uint64
+	misses        uint64
+	invalidations uint64
+	evictions     uint64
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// CacheStats is reported under "cache" in GET /stats.
+type CacheStats struct {
+	TTL           string  `json:"ttl"`
+	Entries       int     `json:"entries"`
+	MaxEntries    int     `json:"max_entries"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"`
+	Invalidations uint64  `json:"invalidations"` // times a new tip emptied the cache
+	Evictions     uint64  `json:"evictions"`     // entries dropped to stay under max_entries
+}
+
+func NewQueryCache(ttl time.Duration, maxEntries int) *QueryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultQueryCacheSize
+	}
+	return &QueryCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// fetch returns the value cached under key at tip, calling compute on a miss.
+// compute runs without the lock held, so two concurrent misses may both
+// compute; the values are identical and the later one wins. Cached values are
+// shared between requests and must not be modified.
+func (c *QueryCache) fetch(tip, key string, compute func() interface{}) interface{} {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.resetIfMoved(tip)
+	if e, ok := c.entries[key]; ok && now.Before(e.expires) {
+		c.hits++
+		c.mu.Unlock()
+		return e.value
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	value := compute()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tip != tip {
+		// A block landed while computing; don't file the result under it.
+		return value
+	}
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict(now)
+	}
+	c.entries[key] = cacheEntry{value: value, expires: now.Add(c.ttl)}
+	return value
+}
+
+func (c *QueryCache) resetIfMoved(tip string) {
+	if c.tip == tip {
+		return
+	}
+	if len(c.entries) > 0 {
+		c.entries = make(map[string]cacheEntry)
+		c.invalidations++
+	}
+	c.tip = tip
+}
+
+// invalidate drops every entry, for when chain state changed without the
+// tip moving.
+func (c *QueryCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resetIfMoved("")
+}
+
+// evict makes room for one entry, preferring expired ones and otherwise
+// dropping an arbitrary entry.
+func (c *QueryCache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+			c.evictions++
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		c.evictions++
+		return
+	}
+}
+
+func (c *QueryCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{
+		TTL:           c.ttl.String(),
+		Entries:       len(c.entries),
+		MaxEntries:    c.maxEntries,
+		Hits:          c.hits,
+		Misses:        c.misses,
+		Invalidations: c.invalidations,
+		Evictions:     c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// SetQueryCache turns on caching of explorer queries. Without it every query
+// is computed from the UTXO set.
+func (s *Server) SetQueryCache(c *QueryCache) {
+	s.cache = c
+}
+
+// cached returns the value for key at the current tip, from the query cache
+// when one is set.
+func (s *Server) cached(key string, compute func() interface{}) interface{} {
+	if s.cache == nil {
+		return compute()
+	}
+	return s.cache.fetch(s.blockchain.Tip().Hash, key, compute)
+}
+
+// confirmedBalance is the address's balance in the UTXO set, which means
+// scanning the whole set.
+func (s *Server) confirmedBalance(address string) float64 {
+	return s.cached("balance:"+address, func() interface{} {
+		return s.blockchain.BalanceOf(address)
+	}).(float64)
+}
+
+// addressUTXOs returns the address's unspent outputs sorted by outpoint. The
+// slice may be shared with other requests and must not be modified.
+func (s *Server) addressUTXOs(address string) []chain.UTXO {
+	return s.cached("utxos:"+address, func() interface{} {
+		utxos := s.blockchain.UnspentOutputs(address)
+		sort.Slice(utxos, func(i, j int) bool {
+			if utxos[i].Key.TxID != utxos[j].Key.TxID {
+				return utxos[i].Key.TxID < utxos[j].Key.TxID
+			}
+			return utxos[i].Key.Index < utxos[j].Key.Index
+		})
+		return utxos
+	}).([]chain.UTXO)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/cluster"
+)
+
+func (s *Server) SetClusterMonitor(monitor *cluster.Monitor) {
+	s.cluster = monitor
+}
+
+func (s *Server) handleClusterStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !s.cluster.Enabled() {
+		http.Error(w, "Cluster mode not configured (start node with -cluster-nodes)", http.StatusNotFound)
+		return
+	}
+
+	tip := s.blockchain.Tip()
+	local := &cluster.NodeStatus{
+		URL:       "local",
+		Reachable: true,
+		Status:    "healthy",
+		Height:    s.blockchain.Height(),
+		TipHash:   tip.Hash,
+		Mempool:   s.mempool.Size(),
+	}
+	if frozen, _ := s.blockchain.Frozen(); frozen {
+		local.Status = "frozen"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.cluster.Check(local))
+}
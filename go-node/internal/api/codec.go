@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const binaryContentType = "application/octet-stream"
+
+// wantsBinary reports whether the client asked for the binary wire encoding
+// via the Accept header.
+func wantsBinary(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+			if mediaType == binaryContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeBinary(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", binaryContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// consolidationTarget is the confirmation target a consolidation's fee is
+// estimated for unless the request names one. Consolidating is never
+// urgent, so it waits for a cheap fee.
+const consolidationTarget = 12
+
+type consolidationSuggestionResponse struct {
+	Address         string             `json:"address"`
+	Amount          float64            `json:"amount,omitempty"` // of the single output
+	Fee             float64            `json:"fee"`
+	Inputs          int                `json:"inputs"`
+	MaxSmallOutputs int                `json:"max_small_outputs"`
+	Message         string             `json:"message"`
+	SmallBelow      float64            `json:"small_below"`
+	SmallOutputs    int                `json:"small_outputs"` // spendable, not counting outputs pending transactions spend
+	Suggested       bool               `json:"suggested"`
+	Transaction     *chain.Transaction `json:"transaction,omitempty"` // unsigned
+	UTXOs           int                `json:"utxos"`                 // confirmed, of any amount
+}
+
+type consolidateRequest struct {
+	Fee                 *float64 `json:"fee,omitempty"` // overrides the estimate
+	TargetConfirmations int      `json:"target_confirmations,omitempty"`
+}
+
+type consolidateResponse struct {
+	Amount  float64 `json:"amount"`
+	Fee     float64 `json:"fee"`
+	Inputs  int     `json:"inputs"`
+	Message string  `json:"message"`
+	Status  string  `json:"status"`
+	TxID    string  `json:"txid"`
+}
+
+// handleWalletAddress serves /api/wallet/{address}/...: GET
+// consolidate-suggestion and POST consolidate.
+func (s *Server) handleWalletAddress(w http.ResponseWriter, r *http.Request) {
+	address, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/wallet/"), "/")
+	if !ok || strings.Contains(action, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	address = strings.ToLower(address)
+
+	switch action {
+	case "consolidate-suggestion":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := wallet.ValidateAddress(address); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		target := 0
+		if v := r.URL.Query().Get("target_confirmations"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "target_confirmations must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			target = n
+		}
+		s.writeConsolidationSuggestion(w, address, target)
+
+	case "consolidate":
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.handleConsolidate(w, r, address)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// buildConsolidation runs build with fee when it is set, or else with the
+// estimate for target confirmations, consolidationTarget when target is
+// zero, at the size of the transaction built. It returns the fee used, also
+// when build fails.
+func (s *Server) buildConsolidation(fee *float64, target int, build func(fee float64) (*chain.Transaction, error)) (*chain.Transaction, float64, error) {
+	if fee != nil {
+		tx, err := build(*fee)
+		return tx, *fee, err
+	}
+	if target == 0 {
+		target = consolidationTarget
+	}
+	tx, quote, err := s.buildWithQuote(target, build)
+	return tx, quote.Fee, err
+}
+
+// writeConsolidationSuggestion counts the small outputs of address and,
+// when they are over the quota, proposes the transaction merging them.
+func (s *Server) writeConsolidationSuggestion(w http.ResponseWriter, address string, target int) {
+	if target == 0 {
+		target = consolidationTarget
+	}
+	policy := s.consolidation
+	view := s.mempool.SpendableUTXO(s.blockchain)
+	small := len(policy.SmallOutputs(view.UnspentOutputs(address)))
+
+	response := consolidationSuggestionResponse{
+		Address:         address,
+		MaxSmallOutputs: policy.MaxSmallOutputs,
+		SmallBelow:      policy.SmallBelow,
+		SmallOutputs:    small,
+		UTXOs:           s.blockchain.UTXOCountOf(address),
+	}
+	if !policy.OverQuota(small) {
+		response.Fee = s.fees.EstimateFee(target, 0).Fee
+		response.Message = fmt.Sprintf("%d small outputs, within the quota of %d; nothing to consolidate", small, policy.MaxSmallOutputs)
+		writeJSON(w, &response)
+		return
+	}
+
+	// Quoted for the unsigned transaction: the signature adds a little.
+	tx, fee, err := s.buildConsolidation(nil, target, func(fee float64) (*chain.Transaction, error) {
+		return policy.BuildConsolidation(address, fee, view)
+	})
+	response.Fee = fee
+	if err == wallet.ErrInsufficientFunds {
+		response.Message = fmt.Sprintf("%d small outputs are over the quota of %d, but together they do not cover the fee", small, policy.MaxSmallOutputs)
+		writeJSON(w, &response)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to build consolidation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response.Suggested = true
+	response.Amount = tx.Outputs[0].Amount
+	response.Inputs = len(tx.Inputs)
+	response.Transaction = tx
+	response.Message = fmt.Sprintf("%d small outputs are over the quota of %d; POST /api/wallet/%s/consolidate merges %d of them into one",
+		small, policy.MaxSmallOutputs, address, len(tx.Inputs))
+	writeJSON(w, &response)
+}
+
+// handleConsolidate signs and submits the consolidation of address's small
+// outputs. It does not require the address to be over the quota; any two
+// small outputs can be merged.
+func (s *Server) handleConsolidate(w http.ResponseWriter, r *http.Request, address string) {
+	var request consolidateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if request.TargetConfirmations < 0 || (request.Fee != nil && *request.Fee < 0) {
+		http.Error(w, "Invalid request: target_confirmations and fee must not be negative", http.StatusBadRequest)
+		return
+	}
+	if !s.requireUnlocked(w, r, address) {
+		return
+	}
+
+	view := s.mempool.SpendableUTXO(s.blockchain)
+	tx, fee, err := s.buildConsolidation(request.Fee, request.TargetConfirmations, func(fee float64) (*chain.Transaction, error) {
+		return s.walletStore.BuildAndSignConsolidation(address, s.consolidation, fee, view)
+	})
+	switch err {
+	case nil:
+	case wallet.ErrNothingToConsolidate:
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case wallet.ErrInsufficientFunds:
+		http.Error(w, "Small outputs do not cover the fee", http.StatusUnprocessableEntity)
+		return
+	default:
+		writeSigningError(w, err)
+		return
+	}
+
+	if terr := s.admitWalletTransaction(tx, "/api/wallet/consolidate"); terr != nil {
+		terr.write(w)
+		return
+	}
+	requestLogger(r).Info("Outputs consolidated", "address", address, "inputs", len(tx.Inputs), "txid", tx.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&consolidateResponse{
+		Amount:  tx.Outputs[0].Amount,
+		Fee:     fee,
+		Inputs:  len(tx.Inputs),
+		Message: "Consolidation signed and submitted successfully",
+		Status:  "submitted",
+		TxID:    tx.ID,
+	})
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type contactsResponse struct {
+	Contacts []wallet.Contact `json:"contacts"`
+	Count    int              `json:"count"`
+	Wallet   string           `json:"wallet"`
+}
+
+type contactRequest struct {
+	Wallet  string `json:"wallet"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func (s *Server) handleContacts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		owner := r.URL.Query().Get("wallet")
+		if owner == "" {
+			http.Error(w, "wallet query parameter required", http.StatusBadRequest)
+			return
+		}
+
+		contacts := s.walletStore.Contacts(owner)
+
+		response := contactsResponse{
+			Contacts: contacts,
+			Count:    len(contacts),
+			Wallet:   owner,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost, http.MethodPut:
+		var request contactRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		contact, err := s.walletStore.SaveContact(request.Wallet, request.Name, request.Address)
+		if errors.Is(err, wallet.ErrWalletNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid contact: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(contact)
+
+	case http.MethodDelete:
+		owner := r.URL.Query().Get("wallet")
+		name := r.URL.Query().Get("name")
+
+		if err := s.walletStore.DeleteContact(owner, name); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
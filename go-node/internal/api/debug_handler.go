@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+type canonicalizeResponse struct {
+	Canonical    string `json:"canonical"`
+	CanonicalHex string `json:"canonical_hex"`
+	IDMatches    bool   `json:"id_matches"`
+	SubmittedID  string `json:"submitted_id"`
+	TxID         string `json:"txid"` // computed from the canonical bytes
+}
+
+func (s *Server) handleCanonicalize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var tx chain.Transaction
+	if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	canonical, err := chain.CanonicalTxBytes(&tx)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to canonicalize: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	txid, err := chain.ComputeTxID(&tx)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to compute txid: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := canonicalizeResponse{
+		Canonical:    string(canonical),
+		CanonicalHex: hex.EncodeToString(canonical),
+		IDMatches:    tx.ID == txid,
+		SubmittedID:  tx.ID,
+		TxID:         txid,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/export"
+)
+
+// handleExport returns one table of chain history as a flat file for
+// analysis: ?what=blocks|txs|utxos&format=csv|parquet. The file is built
+// in memory first so a failure still produces a proper error status.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	what := r.URL.Query().Get("what")
+	if what == "" {
+		what = export.Blocks
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatCSV
+	}
+	if err := export.Check(what, format); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := export.Write(&buf, s.blockchain, what, format); errors.Is(err, export.ErrReorganized) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, fmt.Sprintf("Export failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	contentType := "text/csv"
+	if format == export.FormatParquet {
+		contentType = "application/vnd.apache.parquet"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", what+"."+format))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Write(buf.Bytes())
+}
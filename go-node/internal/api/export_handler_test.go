@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+func TestHandleExport(t *testing.T) {
+	s := &Server{blockchain: chaintest.NewChain(t, strings.Repeat("a", 64), 0)}
+
+	tests := []struct {
+		query       string
+		status      int
+		contentType string
+		filename    string
+	}{
+		{"", http.StatusOK, "text/csv", "blocks.csv"},
+		{"?what=utxos&format=parquet", http.StatusOK, "application/vnd.apache.parquet", "utxos.parquet"},
+		{"?what=wallets", http.StatusBadRequest, "", ""},
+		{"?format=json", http.StatusBadRequest, "", ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.handleExport(w, httptest.NewRequest(http.MethodGet, "/admin/export"+tt.query, nil))
+		if w.Code != tt.status {
+			t.Errorf("%q: status %d, want %d", tt.query, w.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		if got := w.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("%q: Content-Type %q, want %q", tt.query, got, tt.contentType)
+		}
+		if got := w.Header().Get("Content-Disposition"); !strings.Contains(got, `"`+tt.filename+`"`) {
+			t.Errorf("%q: Content-Disposition %q, want file %s", tt.query, got, tt.filename)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleExport(w, httptest.NewRequest(http.MethodGet, "/admin/export?what=blocks", nil))
+	if lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n"); len(lines) != 2 {
+		t.Errorf("blocks export has %d lines, want a header and the genesis block", len(lines))
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/fees"
+)
+
+// maxRequotes bounds how often buildWithQuote rebuilds a transaction whose
+// size raised the fee it must pay.
+const maxRequotes = 3
+
+func (s *Server) FeeEstimator() *fees.Estimator {
+	return s.fees
+}
+
+func (s *Server) handleFeeEstimate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	target := 1
+	if v := r.URL.Query().Get("target"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > fees.MaxTarget {
+			http.Error(w, "target must be an integer between 1 and 100", http.StatusBadRequest)
+			return
+		}
+		target = n
+	}
+	size := 0
+	if v := r.URL.Query().Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "size must be a non-negative number of bytes", http.StatusBadRequest)
+			return
+		}
+		size = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.fees.EstimateFee(target, size))
+}
+
+// buildWithQuote builds a transaction paying the fee quoted for target,
+// then quotes again for the size of what it built and rebuilds while that
+// comes out higher: the learned fee is absolute, but the relay floor grows
+// with the transaction. It returns the transaction and the quote it pays.
+func (s *Server) buildWithQuote(target int, build func(fee float64) (*chain.Transaction, error)) (*chain.Transaction, fees.Estimate, error) {
+	quote := s.fees.EstimateFee(target, 0)
+	for i := 0; ; i++ {
+		tx, err := build(quote.Fee)
+		if err != nil {
+			return nil, quote, err
+		}
+		data, _ := tx.MarshalBinary()
+		sized := s.fees.EstimateFee(target, len(data))
+		if sized.Fee <= quote.Fee || i == maxRequotes {
+			quote.Size = len(data)
+			return tx, quote, nil
+		}
+		quote = sized
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// blockFields are the names ?fields= accepts on /blocks: the block's JSON
+// keys plus tx_count, which lets summaries skip the transactions entirely.
+var blockFields = map[string]func(b *chain.Block) interface{}{
+	"index":           func(b *chain.Block) interface{} { return b.Index },
+	"timestamp":       func(b *chain.Block) interface{} { return b.Timestamp },
+	"prevHash":        func(b *chain.Block) interface{} { return b.PrevHash },
+	"merkleRoot":      func(b *chain.Block) interface{} { return b.MerkleRoot },
+	"transactions":    func(b *chain.Block) interface{} { return b.Transactions },
+	"hash":            func(b *chain.Block) interface{} { return b.Hash },
+	"nonce":           func(b *chain.Block) interface{} { return b.Nonce },
+	"difficulty":      func(b *chain.Block) interface{} { return b.Difficulty },
+	"pow_algorithm":   func(b *chain.Block) interface{} { return b.PowAlgorithm },
+	"miner_pubkey":    func(b *chain.Block) interface{} { return b.MinerPubKey },
+	"miner_signature": func(b *chain.Block) interface{} { return b.MinerSignature },
+	"tx_count":        func(b *chain.Block) interface{} { return len(b.Transactions) },
+}
+
+// mempoolFields are the names ?fields= accepts on /mempool: the
+// transaction's JSON keys plus the fee data and AI score the pool keeps for
+// it.
+var mempoolFields = map[string]func(e mempoolEntry) interface{}{
+	"id":        func(e mempoolEntry) interface{} { return e.ID },
+	"inputs":    func(e mempoolEntry) interface{} { return e.Inputs },
+	"outputs":   func(e mempoolEntry) interface{} { return e.Outputs },
+	"signature": func(e mempoolEntry) interface{} { return e.Signature },
+	"pubkey":    func(e mempoolEntry) interface{} { return e.PubKey },
+	"timestamp": func(e mempoolEntry) interface{} { return e.Timestamp },
+	"memo":      func(e mempoolEntry) interface{} { return e.Memo },
+	"multisig":  func(e mempoolEntry) interface{} { return e.Multisig },
+	"fee":       func(e mempoolEntry) interface{} { return e.Fee },
+	"fee_rate":  func(e mempoolEntry) interface{} { return e.FeeRate },
+	"size":      func(e mempoolEntry) interface{} { return e.Size },
+	"ai_score":  func(e mempoolEntry) interface{} { return e.AIScore },
+	"in_flight": func(e mempoolEntry) interface{} { return e.InFlight },
+}
+
+var blockFieldNames = func() []string {
+	var names []string
+	for name := range blockFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+var mempoolFieldNames = func() []string {
+	var names []string
+	for name := range mempoolFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// requestedFields parses ?fields=a,b,c against the names an endpoint
+// supports. It returns nil when the parameter is absent, meaning full
+// objects.
+func requestedFields(r *http.Request, available []string) ([]string, error) {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !containsString(available, name) {
+			return nil, fmt.Errorf("unknown field %q (available: %s)", name, strings.Join(available, ", "))
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("fields must name at least one of: %s", strings.Join(available, ", "))
+	}
+	return fields, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func projectBlocks(blocks []*chain.Block, fields []string) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(blocks))
+	for i, b := range blocks {
+		item := make(map[string]interface{}, len(fields))
+		for _, name := range fields {
+			item[name] = blockFields[name](b)
+		}
+		out[i] = item
+	}
+	return out
+}
+
+func (s *Server) projectMempool(txs []*chain.Transaction, fields []string) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(txs))
+	for i, tx := range txs {
+		entry := s.mempoolEntry(tx)
+		item := make(map[string]interface{}, len(fields))
+		for _, name := range fields {
+			item[name] = mempoolFields[name](entry)
+		}
+		out[i] = item
+	}
+	return out
+}
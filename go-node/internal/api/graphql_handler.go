@@ -0,0 +1,524 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/graphql"
+)
+
+const (
+	graphqlMaxPage    = 100
+	graphqlMaxBody    = 64 << 10
+	graphqlMaxDepth   = 12
+	graphqlMaxFields  = 20000
+	graphqlDefaultTop = 10
+)
+
+// handleGraphQL serves read-only explorer queries. Queries arrive as JSON
+// POST bodies or, for simple links, as ?query=&variables= on a GET.
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				http.Error(w, "Invalid variables", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, graphqlMaxBody)).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if req.Query == "" {
+		http.Error(w, "Query required", http.StatusBadRequest)
+		return
+	}
+
+	resp := graphql.Execute(r.Context(), s.gqlSchema, req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeJSONStatus(w, status, resp)
+}
+
+// gqlTx is a transaction together with where it sits, if it is confirmed.
+type gqlTx struct {
+	tx  *chain.Transaction
+	loc *chain.TxLocation
+}
+
+type gqlInput struct {
+	in chain.TxIn
+}
+
+type gqlOutput struct {
+	txid      string
+	index     int
+	out       chain.TxOut
+	confirmed bool
+}
+
+// gqlConnection is the paginated list shape shared by every list field.
+type gqlConnection struct {
+	total    int
+	nodes    interface{}
+	pageInfo gqlPageInfo
+}
+
+type gqlPageInfo struct {
+	next      bool
+	endCursor string // empty on an empty page, sent as null
+}
+
+func newGQLConnection(nodes interface{}, total int, next bool, endCursor string) gqlConnection {
+	return gqlConnection{total: total, nodes: nodes, pageInfo: gqlPageInfo{next: next, endCursor: endCursor}}
+}
+
+// gqlPage turns first/after arguments into a [start, end) window over a list
+// of n items whose cursors are their offsets.
+func gqlPage(p graphql.Params, n int) (int, int, error) {
+	first, err := p.Int("first", graphqlDefaultTop)
+	if err != nil {
+		return 0, 0, err
+	}
+	if first < 0 || first > graphqlMaxPage {
+		return 0, 0, fmt.Errorf("first must be between 0 and %d", graphqlMaxPage)
+	}
+	start := 0
+	if after, ok, err := p.String("after"); err != nil {
+		return 0, 0, err
+	} else if ok {
+		offset, err := strconv.Atoi(after)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid cursor")
+		}
+		start = offset + 1
+	}
+	if start > n {
+		start = n
+	}
+	end := start + first
+	if end > n {
+		end = n
+	}
+	return start, end, nil
+}
+
+func gqlOffsetConnection(nodes interface{}, start, end, total int) gqlConnection {
+	cursor := ""
+	if end > start {
+		cursor = strconv.Itoa(end - 1)
+	}
+	return newGQLConnection(nodes, total, end < total, cursor)
+}
+
+// newGraphQLSchema builds the explorer schema. Types refer to each other
+// (a transaction's block lists transactions), so the objects are declared
+// first and their fields filled in afterwards.
+func (s *Server) newGraphQLSchema() *graphql.Schema {
+	query := &graphql.Object{Name: "Query"}
+	chainType := &graphql.Object{Name: "Chain"}
+	block := &graphql.Object{Name: "Block"}
+	tx := &graphql.Object{Name: "Transaction"}
+	input := &graphql.Object{Name: "Input"}
+	output := &graphql.Object{Name: "Output"}
+	address := &graphql.Object{Name: "Address"}
+	mempool := &graphql.Object{Name: "Mempool"}
+	pageInfo := &graphql.Object{Name: "PageInfo", Fields: map[string]*graphql.FieldDef{
+		"hasNextPage": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(gqlPageInfo).next, nil }},
+		"endCursor": {Resolve: func(p graphql.Params) (interface{}, error) {
+			if cursor := p.Source.(gqlPageInfo).endCursor; cursor != "" {
+				return cursor, nil
+			}
+			return nil, nil
+		}},
+	}}
+	connection := func(name string, node *graphql.Object) *graphql.Object {
+		return &graphql.Object{Name: name, Fields: map[string]*graphql.FieldDef{
+			"totalCount": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(gqlConnection).total, nil }},
+			"nodes":      {Type: node, Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(gqlConnection).nodes, nil }},
+			"pageInfo":   {Type: pageInfo, Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(gqlConnection).pageInfo, nil }},
+		}}
+	}
+	blockConnection := connection("BlockConnection", block)
+	txConnection := connection("TransactionConnection", tx)
+	outputConnection := connection("OutputConnection", output)
+	page := []string{"first", "after"}
+
+	query.Fields = map[string]*graphql.FieldDef{
+		"chain": {Type: chainType, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.blockchain.TipInfo(), nil
+		}},
+		"block":  {Type: block, Args: []string{"height", "hash"}, Resolve: s.gqlBlock},
+		"blocks": {Type: blockConnection, Args: page, Resolve: s.gqlBlocks},
+		"transaction": {Type: tx, Args: []string{"txid"}, Resolve: func(p graphql.Params) (interface{}, error) {
+			txid, ok, err := p.String("txid")
+			if err != nil || !ok {
+				return nil, errors.New("txid required")
+			}
+			return s.gqlFindTx(strings.ToLower(txid)), nil
+		}},
+		"address": {Type: address, Args: []string{"address"}, Resolve: func(p graphql.Params) (interface{}, error) {
+			addr, ok, err := p.String("address")
+			if err != nil || !ok || addr == "" {
+				return nil, errors.New("address required")
+			}
+			return strings.ToLower(addr), nil
+		}},
+		"mempool": {Type: mempool, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.mempool, nil
+		}},
+	}
+
+	chainType.Fields = map[string]*graphql.FieldDef{
+		"height":    {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(chain.TipInfo).Height, nil }},
+		"tipHash":   {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(chain.TipInfo).Hash, nil }},
+		"chainWork": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(chain.TipInfo).ChainWork, nil }},
+		"networkId": {Resolve: func(p graphql.Params) (interface{}, error) { return s.blockchain.NetworkID(), nil }},
+		"difficulty": {Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.blockchain.NextDifficulty(), nil
+		}},
+		"utxoCount": {Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.blockchain.UTXOStats().Count, nil
+		}},
+		"tip": {Type: block, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.blockchain.Tip(), nil
+		}},
+	}
+
+	blockField := func(get func(b *chain.Block) interface{}) *graphql.FieldDef {
+		return &graphql.FieldDef{Resolve: func(p graphql.Params) (interface{}, error) {
+			return get(p.Source.(*chain.Block)), nil
+		}}
+	}
+	block.Fields = map[string]*graphql.FieldDef{
+		"height":      blockField(func(b *chain.Block) interface{} { return b.Index }),
+		"hash":        blockField(func(b *chain.Block) interface{} { return b.Hash }),
+		"prevHash":    blockField(func(b *chain.Block) interface{} { return b.PrevHash }),
+		"timestamp":   blockField(func(b *chain.Block) interface{} { return b.Timestamp }),
+		"nonce":       blockField(func(b *chain.Block) interface{} { return b.Nonce }),
+		"difficulty":  blockField(func(b *chain.Block) interface{} { return b.Difficulty }),
+		"merkleRoot":  blockField(func(b *chain.Block) interface{} { return b.MerkleRoot }),
+		"minerPubKey": blockField(func(b *chain.Block) interface{} { return b.MinerPubKey }),
+		"txCount":     blockField(func(b *chain.Block) interface{} { return len(b.Transactions) }),
+		"confirmations": blockField(func(b *chain.Block) interface{} {
+			return s.blockchain.Height() - b.Index
+		}),
+		"previous": {Type: block, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.gqlBlockAt(p.Source.(*chain.Block).Index - 1), nil
+		}},
+		"next": {Type: block, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.gqlBlockAt(p.Source.(*chain.Block).Index + 1), nil
+		}},
+		"transactions": {Type: txConnection, Args: page, Resolve: func(p graphql.Params) (interface{}, error) {
+			b := p.Source.(*chain.Block)
+			start, end, err := gqlPage(p, len(b.Transactions))
+			if err != nil {
+				return nil, err
+			}
+			nodes := make([]*gqlTx, 0, end-start)
+			for i := start; i < end; i++ {
+				nodes = append(nodes, &gqlTx{
+					tx:  &b.Transactions[i],
+					loc: &chain.TxLocation{BlockHash: b.Hash, Height: b.Index, Index: i},
+				})
+			}
+			return gqlOffsetConnection(nodes, start, end, len(b.Transactions)), nil
+		}},
+	}
+
+	txField := func(get func(t *gqlTx) interface{}) *graphql.FieldDef {
+		return &graphql.FieldDef{Resolve: func(p graphql.Params) (interface{}, error) {
+			return get(p.Source.(*gqlTx)), nil
+		}}
+	}
+	tx.Fields = map[string]*graphql.FieldDef{
+		"txid":      txField(func(t *gqlTx) interface{} { return t.tx.ID }),
+		"timestamp": txField(func(t *gqlTx) interface{} { return t.tx.Timestamp }),
+		"coinbase":  txField(func(t *gqlTx) interface{} { return t.tx.IsCoinbase() }),
+		"memo":      txField(func(t *gqlTx) interface{} { return t.tx.Memo }),
+		"status": txField(func(t *gqlTx) interface{} {
+			if t.loc == nil {
+				return txStatusPending
+			}
+			return txStatusConfirmed
+		}),
+		"confirmations": txField(func(t *gqlTx) interface{} {
+			if t.loc == nil {
+				return 0
+			}
+			return s.blockchain.Height() - t.loc.Height
+		}),
+		"size": txField(func(t *gqlTx) interface{} {
+			data, _ := t.tx.MarshalBinary()
+			return len(data)
+		}),
+		"fee": txField(func(t *gqlTx) interface{} { return s.gqlFee(t) }),
+		"block": {Type: block, Resolve: func(p graphql.Params) (interface{}, error) {
+			t := p.Source.(*gqlTx)
+			if t.loc == nil {
+				return nil, nil
+			}
+			return s.gqlBlockAt(t.loc.Height), nil
+		}},
+		"inputs": {Type: input, Resolve: func(p graphql.Params) (interface{}, error) {
+			t := p.Source.(*gqlTx)
+			if t.tx.IsCoinbase() {
+				return []*gqlInput{}, nil
+			}
+			ins := make([]*gqlInput, len(t.tx.Inputs))
+			for i, in := range t.tx.Inputs {
+				ins[i] = &gqlInput{in: in}
+			}
+			return ins, nil
+		}},
+		"outputs": {Type: output, Resolve: func(p graphql.Params) (interface{}, error) {
+			t := p.Source.(*gqlTx)
+			outs := make([]*gqlOutput, len(t.tx.Outputs))
+			for i, out := range t.tx.Outputs {
+				outs[i] = &gqlOutput{txid: t.tx.ID, index: i, out: out, confirmed: t.loc != nil}
+			}
+			return outs, nil
+		}},
+	}
+
+	input.Fields = map[string]*graphql.FieldDef{
+		"txid": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(*gqlInput).in.TxID, nil }},
+		"index": {Resolve: func(p graphql.Params) (interface{}, error) {
+			return p.Source.(*gqlInput).in.Index, nil
+		}},
+		"previousTransaction": {Type: tx, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.gqlFindTx(p.Source.(*gqlInput).in.TxID), nil
+		}},
+		"previousOutput": {Type: output, Resolve: func(p graphql.Params) (interface{}, error) {
+			in := p.Source.(*gqlInput).in
+			prev := s.gqlFindTx(in.TxID)
+			if prev == nil || in.Index < 0 || in.Index >= len(prev.tx.Outputs) {
+				return nil, nil
+			}
+			return &gqlOutput{txid: in.TxID, index: in.Index, out: prev.tx.Outputs[in.Index], confirmed: prev.loc != nil}, nil
+		}},
+	}
+
+	output.Fields = map[string]*graphql.FieldDef{
+		"index":   {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(*gqlOutput).index, nil }},
+		"address": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(*gqlOutput).out.Address, nil }},
+		"amount":  {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(*gqlOutput).out.Amount, nil }},
+		"spent": {Resolve: func(p graphql.Params) (interface{}, error) {
+			o := p.Source.(*gqlOutput)
+			if !o.confirmed {
+				return false, nil
+			}
+			_, unspent := s.blockchain.Output(chain.UTXOKey{TxID: o.txid, Index: o.index})
+			return !unspent, nil
+		}},
+		"transaction": {Type: tx, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.gqlFindTx(p.Source.(*gqlOutput).txid), nil
+		}},
+		"owner": {Type: address, Resolve: func(p graphql.Params) (interface{}, error) {
+			return p.Source.(*gqlOutput).out.Address, nil
+		}},
+	}
+
+	address.Fields = map[string]*graphql.FieldDef{
+		"address": {Resolve: func(p graphql.Params) (interface{}, error) { return p.Source.(string), nil }},
+		"balance": {Resolve: func(p graphql.Params) (interface{}, error) {
+			return chain.RoundAmount(s.confirmedBalance(p.Source.(string))), nil
+		}},
+		"utxoCount": {Resolve: func(p graphql.Params) (interface{}, error) {
+			return len(s.addressUTXOs(p.Source.(string))), nil
+		}},
+		"utxos": {Type: outputConnection, Args: page, Resolve: func(p graphql.Params) (interface{}, error) {
+			utxos := s.addressUTXOs(p.Source.(string))
+			start, end, err := gqlPage(p, len(utxos))
+			if err != nil {
+				return nil, err
+			}
+			nodes := make([]*gqlOutput, 0, end-start)
+			for _, u := range utxos[start:end] {
+				nodes = append(nodes, &gqlOutput{txid: u.Key.TxID, index: u.Key.Index, out: u.Output, confirmed: true})
+			}
+			return gqlOffsetConnection(nodes, start, end, len(utxos)), nil
+		}},
+		"pendingTransactions": {Type: tx, Resolve: func(p graphql.Params) (interface{}, error) {
+			return s.gqlPendingFor(p.Source.(string)), nil
+		}},
+	}
+
+	mempool.Fields = map[string]*graphql.FieldDef{
+		"size": {Resolve: func(p graphql.Params) (interface{}, error) { return s.mempool.Size(), nil }},
+		"transactions": {Type: txConnection, Args: page, Resolve: func(p graphql.Params) (interface{}, error) {
+			txs := s.mempool.GetTransactionsByFee(0)
+			start, end, err := gqlPage(p, len(txs))
+			if err != nil {
+				return nil, err
+			}
+			nodes := make([]*gqlTx, 0, end-start)
+			for _, t := range txs[start:end] {
+				nodes = append(nodes, &gqlTx{tx: t})
+			}
+			return gqlOffsetConnection(nodes, start, end, len(txs)), nil
+		}},
+	}
+
+	return &graphql.Schema{Query: query, MaxDepth: graphqlMaxDepth, MaxFields: graphqlMaxFields}
+}
+
+func (s *Server) gqlBlockAt(height int) *chain.Block {
+	blocks := s.blockchain.BlocksFrom(height, 1)
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[0]
+}
+
+func (s *Server) gqlBlock(p graphql.Params) (interface{}, error) {
+	if hash, ok, err := p.String("hash"); err != nil {
+		return nil, err
+	} else if ok {
+		header, found := s.blockchain.HeaderByHash(strings.ToLower(hash))
+		if !found {
+			return nil, nil
+		}
+		return s.gqlBlockAt(header.Index), nil
+	}
+	height, err := p.Int("height", -1)
+	if err != nil {
+		return nil, err
+	}
+	if height < 0 {
+		return nil, errors.New("height or hash required")
+	}
+	return s.gqlBlockAt(height), nil
+}
+
+// gqlBlocks pages backwards from the tip, newest first; a cursor is a
+// block height.
+func (s *Server) gqlBlocks(p graphql.Params) (interface{}, error) {
+	first, err := p.Int("first", graphqlDefaultTop)
+	if err != nil {
+		return nil, err
+	}
+	if first < 0 || first > graphqlMaxPage {
+		return nil, fmt.Errorf("first must be between 0 and %d", graphqlMaxPage)
+	}
+	height := s.blockchain.Height()
+	top := height - 1
+	if after, ok, err := p.String("after"); err != nil {
+		return nil, err
+	} else if ok {
+		h, err := strconv.Atoi(after)
+		if err != nil || h < 0 {
+			return nil, errors.New("invalid cursor")
+		}
+		top = h - 1
+	}
+	if top >= height {
+		top = height - 1
+	}
+
+	bottom := top - first + 1
+	if bottom < 0 {
+		bottom = 0
+	}
+	var nodes []*chain.Block
+	if top >= bottom {
+		nodes = s.blockchain.BlocksFrom(bottom, top-bottom+1)
+	}
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	if nodes == nil {
+		nodes = []*chain.Block{}
+	}
+
+	cursor := ""
+	if len(nodes) > 0 {
+		cursor = strconv.Itoa(nodes[len(nodes)-1].Index)
+	}
+	return newGQLConnection(nodes, height, len(nodes) > 0 && nodes[len(nodes)-1].Index > 0, cursor), nil
+}
+
+// gqlFindTx looks a transaction up on chain, then in the mempool.
+func (s *Server) gqlFindTx(txid string) *gqlTx {
+	if tx, loc, _, ok := s.blockchain.FindTransaction(txid); ok {
+		return &gqlTx{tx: tx, loc: &loc}
+	}
+	if tx, ok := s.mempool.Get(txid); ok {
+		return &gqlTx{tx: tx}
+	}
+	return nil
+}
+
+// gqlFee is the fee a transaction paid. Confirmed transactions have had
+// their inputs spent, so the previous outputs are looked up through the
+// transaction index instead of the UTXO set.
+func (s *Server) gqlFee(t *gqlTx) interface{} {
+	if t.tx.IsCoinbase() {
+		return 0.0
+	}
+	if t.loc == nil {
+		if info, ok := s.mempool.FeeInfo(t.tx.ID); ok {
+			return info.Fee
+		}
+		return nil
+	}
+	var in, out float64
+	for _, input := range t.tx.Inputs {
+		prev, _, _, ok := s.blockchain.FindTransaction(input.TxID)
+		if !ok || input.Index < 0 || input.Index >= len(prev.Outputs) {
+			return nil
+		}
+		in += prev.Outputs[input.Index].Amount
+	}
+	for _, o := range t.tx.Outputs {
+		out += o.Amount
+	}
+	return chain.RoundAmount(in - out)
+}
+
+// gqlPendingFor lists mempool transactions that pay to address or spend
+// one of its confirmed outputs.
+func (s *Server) gqlPendingFor(address string) []*gqlTx {
+	var out []*gqlTx
+	for _, tx := range s.mempool.GetTransactionsByFee(0) {
+		if gqlTouches(tx, address, s.blockchain.Output) {
+			out = append(out, &gqlTx{tx: tx})
+		}
+	}
+	if out == nil {
+		out = []*gqlTx{}
+	}
+	return out
+}
+
+// gqlTouches reports whether tx pays to address or spends one of its
+// outputs, looked up with output.
+func gqlTouches(tx *chain.Transaction, address string, output func(chain.UTXOKey) (chain.TxOut, bool)) bool {
+	for _, o := range tx.Outputs {
+		if o.Address == address {
+			return true
+		}
+	}
+	for _, in := range tx.Inputs {
+		if prev, ok := output(chain.UTXOKey{TxID: in.TxID, Index: in.Index}); ok && prev.Address == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+	"ai-blockchain/go-node/internal/graphql"
+)
+
+// graphqlServer serves the explorer schema over a chain of n mined blocks.
+func graphqlServer(t *testing.T, n int) *Server {
+	t.Helper()
+	s := &Server{blockchain: chaintest.NewChain(t, strings.Repeat("a", 64), n), mempool: chain.NewMempool()}
+	s.gqlSchema = s.newGraphQLSchema()
+	return s
+}
+
+// graphqlReply keeps the data as sent, so field order can be checked.
+type graphqlReply struct {
+	Data   json.RawMessage  `json:"data"`
+	Errors []*graphql.Error `json:"errors"`
+}
+
+// postGraphQL sends body as a POST and decodes a JSON reply; resp is nil
+// when the handler answered with plain text.
+func postGraphQL(s *Server, body string) (int, *graphqlReply) {
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	s.handleGraphQL(w, r)
+	if w.Header().Get("Content-Type") != "application/json" {
+		return w.Code, nil
+	}
+	var resp graphqlReply
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		return w.Code, nil
+	}
+	return w.Code, &resp
+}
+
+func graphqlBody(query string, variables map[string]interface{}) string {
+	data, _ := json.Marshal(graphql.Request{Query: query, Variables: variables})
+	return string(data)
+}
+
+func TestGraphQLQuery(t *testing.T) {
+	s := graphqlServer(t, 2)
+	tip := s.blockchain.Tip()
+
+	status, resp := postGraphQL(s, graphqlBody(`
+		query Tip($h: Int!) {
+			chain { height tipHash }
+			at: block(height: $h) { ...ids previous { height } }
+		}
+		fragment ids on Block { height hash }`, map[string]interface{}{"h": 2}))
+	if status != http.StatusOK || resp == nil || len(resp.Errors) != 0 {
+		t.Fatalf("status %d, response %+v", status, resp)
+	}
+	want := `{"chain":{"height":3,"tipHash":"` + tip.Hash + `"},"at":{"height":2,"hash":"` + tip.Hash + `","previous":{"height":1}}}`
+	if string(resp.Data) != want {
+		t.Errorf("data %s\nwant %s", resp.Data, want)
+	}
+
+	// Lists page through a connection; an empty page has no cursor.
+	status, resp = postGraphQL(s, graphqlBody(`{
+		newest: blocks(first: 2) { totalCount nodes { height } pageInfo { hasNextPage endCursor } }
+		none: blocks(first: 0) { totalCount pageInfo { hasNextPage endCursor } }
+	}`, nil))
+	if status != http.StatusOK || resp == nil || len(resp.Errors) != 0 {
+		t.Fatalf("status %d, response %+v", status, resp)
+	}
+	want = `{"newest":{"totalCount":3,"nodes":[{"height":2},{"height":1}],"pageInfo":{"hasNextPage":true,"endCursor":"1"}},` +
+		`"none":{"totalCount":3,"pageInfo":{"hasNextPage":false,"endCursor":null}}}`
+	if string(resp.Data) != want {
+		t.Errorf("data %s\nwant %s", resp.Data, want)
+	}
+
+	// A GET carries the same request in the query string.
+	q := url.Values{"query": {`query($h: Int) { block(height: $h) { hash } }`}, "variables": {`{"h":0}`}}
+	w := httptest.NewRecorder()
+	s.handleGraphQL(w, httptest.NewRequest(http.MethodGet, "/graphql?"+q.Encode(), nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), s.blockchain.BlocksFrom(0, 1)[0].Hash) {
+		t.Errorf("GET: status %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestGraphQLRejects(t *testing.T) {
+	s := graphqlServer(t, 0)
+	tests := []struct {
+		name  string
+		body  string
+		error string // empty when the handler answers in plain text
+	}{
+		{"not JSON", "{query", ""},
+		{"no query", `{"query":""}`, ""},
+		{"syntax", graphqlBody(`{ chain { height }`, nil), "syntax error"},
+		{"unknown field", graphqlBody(`{ chain { height owner } }`, nil), `cannot query field "owner" on type "Chain"`},
+		{"unknown argument", graphqlBody(`{ block(number: 1) { hash } }`, nil), `unknown argument "number"`},
+		{"scalar selection", graphqlBody(`{ chain { height { value } } }`, nil), "is a scalar and takes no selection"},
+		{"object without selection", graphqlBody(`{ chain }`, nil), "must have a selection"},
+		{"mutation", graphqlBody(`mutation { chain { height } }`, nil), "mutation operations are not supported"},
+		{"missing variable", graphqlBody(`query($h: Int!) { block(height: $h) { hash } }`, nil), "variable $h of type Int! is required"},
+		{"two operations", graphqlBody(`query A { chain { height } } query B { chain { height } }`, nil), "operationName is required"},
+		{"unknown fragment", graphqlBody(`{ chain { ...missing } }`, nil), `unknown fragment "missing"`},
+		{"fragment cycle", graphqlBody(`{ chain { tip { ...a } } } fragment a on Block { previous { ...a } }`, nil), `fragment "a" spreads itself`},
+	}
+	for _, tt := range tests {
+		status, resp := postGraphQL(s, tt.body)
+		if status != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", tt.name, status, http.StatusBadRequest)
+			continue
+		}
+		if tt.error == "" {
+			if resp != nil {
+				t.Errorf("%s: GraphQL response %+v, want a plain error", tt.name, resp)
+			}
+			continue
+		}
+		if resp == nil || resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, tt.error) {
+			t.Errorf("%s: response %+v, want error %q", tt.name, resp, tt.error)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleGraphQL(w, httptest.NewRequest(http.MethodPut, "/graphql", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT: status %d, want %d", w.Code, http.StatusMethodNotAllowed)
+	}
+	w = httptest.NewRecorder()
+	s.handleGraphQL(w, httptest.NewRequest(http.MethodGet, "/graphql?query={chain{height}}&variables={", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("GET with bad variables: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+// Errors inside a field leave the rest of the data intact.
+func TestGraphQLFieldErrors(t *testing.T) {
+	s := graphqlServer(t, 0)
+	status, resp := postGraphQL(s, graphqlBody(`{ chain { height } blocks(first: 1000) { totalCount } }`, nil))
+	if status != http.StatusOK || resp == nil {
+		t.Fatalf("status %d, response %+v", status, resp)
+	}
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "first must be between 0 and 100" {
+		t.Errorf("errors %+v, want the page size error", resp.Errors)
+	}
+	if string(resp.Data) != `{"chain":{"height":1},"blocks":null}` {
+		t.Errorf("data %s", resp.Data)
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	s := graphqlServer(t, 4)
+
+	deep := "{ chain { tip " + strings.Repeat("{ previous ", graphqlMaxDepth) + "{ height }" + strings.Repeat(" }", graphqlMaxDepth) + " } }"
+	status, resp := postGraphQL(s, graphqlBody(deep, nil))
+	if status != http.StatusBadRequest || resp == nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "maximum depth") {
+		t.Errorf("deep query: status %d, response %+v", status, resp)
+	}
+	shallow := "{ chain { tip " + strings.Repeat("{ previous ", 3) + "{ height }" + strings.Repeat(" }", 3) + " } }"
+	if status, resp := postGraphQL(s, graphqlBody(shallow, nil)); status != http.StatusOK || len(resp.Errors) != 0 {
+		t.Errorf("shallow query: status %d, response %+v", status, resp)
+	}
+
+	// Fragments of ten aliases each multiply a short query into more than
+	// a hundred thousand fields.
+	var fan strings.Builder
+	fan.WriteString("{ block(height: 4) { ...f4 } }\nfragment f0 on Block { height hash prevHash timestamp nonce difficulty merkleRoot minerPubKey txCount confirmations }\n")
+	for level := 1; level <= 4; level++ {
+		fmt.Fprintf(&fan, "fragment f%d on Block {", level)
+		for alias := 0; alias < 10; alias++ {
+			fmt.Fprintf(&fan, " p%d: previous { ...f%d }", alias, level-1)
+		}
+		fan.WriteString(" }\n")
+	}
+	status, resp = postGraphQL(s, graphqlBody(fan.String(), nil))
+	if status != http.StatusBadRequest || resp == nil || resp.Data != nil || len(resp.Errors) != 1 ||
+		!strings.Contains(resp.Errors[0].Message, fmt.Sprintf("more than %d fields", graphqlMaxFields)) {
+		t.Errorf("wide query: status %d, response %+v", status, resp)
+	}
+
+	// Each fragment spreading the next twice is 2^40 spreads when walked
+	// naively; validation must take each fragment once.
+	var twice strings.Builder
+	twice.WriteString("{ chain { ...g0 } }\n")
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&twice, "fragment g%d on Chain { height ...g%d ...g%d }\n", i, i+1, i+1)
+	}
+	twice.WriteString("fragment g40 on Chain { tipHash }\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	gresp := graphql.Execute(ctx, s.gqlSchema, graphql.Request{Query: twice.String()})
+	if len(gresp.Errors) != 0 {
+		t.Errorf("fragments spread twice: %v", gresp.Errors[0].Message)
+	}
+
+	// Validation stops with the request.
+	canceled, stop := context.WithCancel(context.Background())
+	stop()
+	if gresp := graphql.Execute(canceled, s.gqlSchema, graphql.Request{Query: twice.String()}); gresp.Data != nil || len(gresp.Errors) != 1 ||
+		!strings.Contains(gresp.Errors[0].Message, context.Canceled.Error()) {
+		t.Errorf("canceled request: %+v", gresp)
+	}
+
+	huge := graphqlBody("{ chain { height } }"+strings.Repeat(" ", graphqlMaxBody), nil)
+	if status, resp := postGraphQL(s, huge); status != http.StatusBadRequest || resp != nil {
+		t.Errorf("oversized body: status %d, response %+v", status, resp)
+	}
+}
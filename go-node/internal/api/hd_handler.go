@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type hdWalletRequest struct {
+	Label      string `json:"label,omitempty"`
+	Words      int    `json:"words,omitempty"`      // mnemonic length, 24 when zero
+	Mnemonic   string `json:"mnemonic,omitempty"`   // restore this wallet instead of creating one
+	Passphrase string `json:"passphrase,omitempty"` // BIP39 passphrase
+}
+
+type hdWalletCreatedResponse struct {
+	Address  string              `json:"address"` // first receive address
+	HDWallet wallet.HDWalletInfo `json:"hd_wallet"`
+	Note     string              `json:"note,omitempty"`
+	Path     string              `json:"path"`
+}
+
+type hdWalletsResponse struct {
+	Count     int                   `json:"count"`
+	HDWallets []wallet.HDWalletInfo `json:"hd_wallets"`
+}
+
+type hdWalletResponse struct {
+	Addresses []wallet.WalletInfo `json:"addresses"` // in derivation order
+	HDWallet  wallet.HDWalletInfo `json:"hd_wallet"`
+}
+
+type deriveHDAddressRequest struct {
+	Label string `json:"label,omitempty"`
+}
+
+type derivedAddressResponse struct {
+	Address   string `json:"address"`
+	HD        string `json:"hd"`
+	Label     string `json:"label,omitempty"`
+	Path      string `json:"path"`
+	PublicKey string `json:"public_key"`
+}
+
+type mnemonicResponse struct {
+	Mnemonic string `json:"mnemonic"`
+	Warning  string `json:"warning"`
+}
+
+func writeHDError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrHDWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, wallet.ErrHDWalletExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, wallet.ErrMnemonicRevealed):
+		http.Error(w, err.Error(), http.StatusGone)
+	case errors.Is(err, wallet.ErrInvalidMnemonic), errors.Is(err, wallet.ErrMnemonicLength),
+		errors.Is(err, wallet.ErrMnemonicPassphrase), errors.Is(err, wallet.ErrInvalidLabel):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeKeystoreError(w, err)
+	}
+}
+
+// handleHDWallets serves /api/wallet/hd: GET lists the HD wallets, POST
+// creates one, or restores one from {"mnemonic"}.
+func (s *Server) handleHDWallets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		list := s.walletStore.HDWallets()
+		writeJSON(w, &hdWalletsResponse{Count: len(list), HDWallets: list})
+
+	case http.MethodPost:
+		var request hdWalletRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var info wallet.HDWalletInfo
+		var first *wallet.Wallet
+		var err error
+		if request.Mnemonic != "" {
+			info, first, err = s.walletStore.RestoreHDWallet(request.Label, request.Mnemonic, request.Passphrase)
+		} else {
+			if request.Words == 0 {
+				request.Words = wallet.DefaultMnemonicWords
+			}
+			info, first, err = s.walletStore.CreateHDWallet(request.Label, request.Words, request.Passphrase)
+		}
+		if err != nil {
+			writeHDError(w, err)
+			return
+		}
+		requestLogger(r).Info("HD wallet added", "id", info.ID, "restored", request.Mnemonic != "")
+
+		response := hdWalletCreatedResponse{Address: first.Address, HDWallet: info, Path: first.Path}
+		if info.MnemonicPending {
+			response.Note = "Reveal the mnemonic once with POST /api/wallet/hd/" + info.ID + "/mnemonic and keep it offline"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleHDWallet serves /api/wallet/hd/{id}: GET describes the wallet and
+// the addresses derived from it and POST /{id}/derive derives the next
+// receive address.
+func (s *Server) handleHDWallet(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/wallet/hd/"), "/"), "/")
+	if id == "" {
+		http.Error(w, "HD wallet ID required", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		info, addresses, err := s.walletStore.HDWallet(id)
+		if err != nil {
+			writeHDError(w, err)
+			return
+		}
+		writeJSON(w, &hdWalletResponse{Addresses: addresses, HDWallet: info})
+
+	case action == "derive" && r.Method == http.MethodPost:
+		var request deriveHDAddressRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		derived, err := s.walletStore.DeriveReceiveAddress(id, request.Label)
+		if err != nil {
+			writeHDError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&derivedAddressResponse{
+			Address:   derived.Address,
+			HD:        derived.HD,
+			Label:     derived.Label,
+			Path:      derived.Path,
+			PublicKey: wallet.EncodePublicKey(derived.PublicKey),
+		})
+
+	case action == "" || action == "derive":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+
+	default:
+		http.Error(w, "Unknown action: "+action, http.StatusNotFound)
+	}
+}
+
+// isMnemonicPath reports whether r is for /api/wallet/hd/{id}/mnemonic,
+// which is routed apart from the rest of /api/wallet/hd/.
+func isMnemonicPath(r *http.Request) bool {
+	_, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/wallet/hd/"), "/"), "/")
+	return action == "mnemonic"
+}
+
+// handleRevealMnemonic serves POST /api/wallet/hd/{id}/mnemonic, which
+// reveals the mnemonic once. Whoever has it controls every key of the
+// wallet, so like key export it is admin only.
+func (s *Server) handleRevealMnemonic(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	id, _, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/wallet/hd/"), "/"), "/")
+
+	mnemonic, err := s.walletStore.RevealMnemonic(id)
+	if err != nil {
+		writeHDError(w, err)
+		return
+	}
+	requestLogger(r).Warn("HD wallet mnemonic revealed", "id", id, "caller", adminCaller(r))
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, &mnemonicResponse{
+		Mnemonic: mnemonic,
+		Warning:  "The node has forgotten this mnemonic and will not show it again; anyone who has it controls the wallet",
+	})
+}
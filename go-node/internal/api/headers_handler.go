@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	defaultHeadersLimit = 500
+	maxHeadersLimit     = 2000
+)
+
+type headersResponse struct {
+	Count   int                 `json:"count"`
+	Headers []chain.BlockHeader `json:"headers"`
+	Tip     chain.TipInfo       `json:"tip"`
+}
+
+// handleGetHeaders serves main-chain headers from the header index, for
+// clients that need linkage and proof of work but not transactions.
+func (s *Server) handleGetHeaders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, limit := 0, defaultHeadersLimit
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "from must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		from = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxHeadersLimit {
+			http.Error(w, "limit must be an integer between 1 and 2000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	headers := s.blockchain.Headers(from, limit)
+	if headers == nil {
+		headers = []chain.BlockHeader{}
+	}
+	writeJSON(w, &headersResponse{
+		Count:   len(headers),
+		Headers: headers,
+		Tip:     s.blockchain.TipInfo(),
+	})
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// jwtLeeway tolerates clock skew between the node and whoever minted a
+// token.
+const jwtLeeway = 30 * time.Second
+
+var (
+	errMalformedJWT = errors.New("malformed token")
+	errJWTSignature = errors.New("invalid token signature")
+	errJWTExpired   = errors.New("token expired")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// jwtClaims are the registered claims the admin API reads. Exp is required.
+type jwtClaims struct {
+	Subject   string `json:"sub,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// NewAdminJWT mints an HS256 token for the admin API that expires after ttl.
+func NewAdminJWT(secret []byte, subject string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(jwtClaims{Subject: subject, IssuedAt: now.Unix(), ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return "", err
+	}
+	signing := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signing + "." + base64.RawURLEncoding.EncodeToString(jwtMAC(secret, signing)), nil
+}
+
+// verifyJWT checks an HS256 token's signature and validity period. Other
+// algorithms, "none" included, are refused.
+func verifyJWT(token string, secret []byte, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedJWT
+	}
+
+	var header jwtHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm " + header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedJWT
+	}
+	if !hmac.Equal(sig, jwtMAC(secret, parts[0]+"."+parts[1])) {
+		return nil, errJWTSignature
+	}
+
+	var claims jwtClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt == 0 {
+		return nil, errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(jwtLeeway)) {
+		return nil, errJWTExpired
+	}
+	if claims.NotBefore != 0 && now.Add(jwtLeeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errors.New("token not yet valid")
+	}
+	return &claims, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errMalformedJWT
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errMalformedJWT
+	}
+	return nil
+}
+
+func jwtMAC(secret []byte, signing string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signing))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signJWT builds a token from raw header and claims JSON, signed with
+// secret whatever the header says.
+func signJWT(secret []byte, header, claims string) string {
+	signing := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return signing + "." + base64.RawURLEncoding.EncodeToString(jwtMAC(secret, signing))
+}
+
+// swapClaims replaces the claims of token, keeping its signature.
+func swapClaims(token, claims string) string {
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(claims))
+	return strings.Join(parts, ".")
+}
+
+func TestVerifyJWT(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	now := time.Unix(1700000000, 0)
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	valid := signJWT(secret, hs256, `{"sub":"alice","exp":1700003600}`)
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","exp":1700003600}`)) + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		subject string // empty when the token must be refused
+	}{
+		{"valid", valid, "alice"},
+		{"within leeway of exp", signJWT(secret, hs256, `{"sub":"alice","exp":1699999990}`), "alice"},
+		{"alg none", unsigned, ""},
+		{"alg none signed", signJWT(secret, `{"alg":"none"}`, `{"sub":"alice","exp":1700003600}`), ""},
+		{"wrong alg", signJWT(secret, `{"alg":"HS512"}`, `{"sub":"alice","exp":1700003600}`), ""},
+		{"other secret", signJWT([]byte("other"), hs256, `{"sub":"alice","exp":1700003600}`), ""},
+		{"claims changed", swapClaims(valid, `{"sub":"mallory","exp":1700003600}`), ""},
+		{"no exp", signJWT(secret, hs256, `{"sub":"alice"}`), ""},
+		{"expired", signJWT(secret, hs256, `{"sub":"alice","exp":1699999000}`), ""},
+		{"not yet valid", signJWT(secret, hs256, `{"sub":"alice","nbf":1700000600,"exp":1700003600}`), ""},
+		{"two segments", valid[:strings.LastIndex(valid, ".")], ""},
+		{"four segments", valid + ".x", ""},
+		{"header not base64", "!!." + strings.SplitN(valid, ".", 2)[1], ""},
+		{"claims not JSON", signJWT(secret, hs256, `not json`), ""},
+		{"signature not base64", valid[:strings.LastIndex(valid, ".")] + ".!!", ""},
+	}
+	for _, tt := range tests {
+		claims, err := verifyJWT(tt.token, secret, now)
+		switch {
+		case tt.subject == "" && err == nil:
+			t.Errorf("%s: accepted, subject %q", tt.name, claims.Subject)
+		case tt.subject != "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.subject != "" && claims.Subject != tt.subject:
+			t.Errorf("%s: subject %q, want %q", tt.name, claims.Subject, tt.subject)
+		}
+	}
+}
+
+func TestNewAdminJWTVerifies(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	token, err := NewAdminJWT(secret, "alice", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyJWT(token, secret, time.Now()); err != nil {
+		t.Fatalf("fresh token: %v", err)
+	}
+	if _, err := verifyJWT(token, secret, time.Now().Add(2*time.Minute)); err != errJWTExpired {
+		t.Errorf("after ttl and leeway: err %v, want %v", err, errJWTExpired)
+	}
+}
+
+// The admin token is checked before named keys, and both before a JWT, so
+// a key that happens to look like a JWT is still the key.
+func TestAuthorizeAdminPrecedence(t *testing.T) {
+	secret := []byte("jwt-test-secret")
+	jwt, err := NewAdminJWT(secret, "carol", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{}
+	s.SetAdminToken("shared")
+	s.SetAdminKeys(map[string]string{"alice": "shared", "bob": "b.o.b"})
+	s.SetAdminJWTSecret(secret)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		caller string // empty when the request must be refused
+	}{
+		{"token before key", "Authorization", "Bearer shared", "token"},
+		{"key shaped like a JWT", "Authorization", "Bearer b.o.b", "key:bob"},
+		{"jwt", "Authorization", "Bearer " + jwt, "jwt:carol"},
+		{"api key header", apiKeyHeader, jwt, "jwt:carol"},
+		{"unknown", "Authorization", "Bearer nope", ""},
+		{"forged jwt", "Authorization", "Bearer " + jwt + "x", ""},
+		{"not bearer", "Authorization", "Basic shared", ""},
+		{"none", "", "", ""},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+		if tt.header != "" {
+			r.Header.Set(tt.header, tt.value)
+		}
+		caller, err := s.authorizeAdmin(r)
+		switch {
+		case tt.caller == "" && err == nil:
+			t.Errorf("%s: authorized as %q", tt.name, caller)
+		case tt.caller != "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case caller != tt.caller:
+			t.Errorf("%s: caller %q, want %q", tt.name, caller, tt.caller)
+		}
+	}
+
+	// Bearer wins when both headers are sent.
+	r := httptest.NewRequest(http.MethodGet, "/admin/status", nil)
+	r.Header.Set("Authorization", "Bearer b.o.b")
+	r.Header.Set(apiKeyHeader, "shared")
+	if caller, err := s.authorizeAdmin(r); err != nil || caller != "key:bob" {
+		t.Errorf("both headers: caller %q, err %v; want key:bob", caller, err)
+	}
+}
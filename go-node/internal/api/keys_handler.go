@@ -0,0 +1,127 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type importKeyRequest struct {
+	Label      string `json:"label,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	PrivateKey string `json:"private_key"` // hex, wif or pem
+}
+
+type importKeyResponse struct {
+	Address   string `json:"address"`
+	Format    string `json:"format"` // the private key was read as
+	Label     string `json:"label,omitempty"`
+	Message   string `json:"message"`
+	Owner     string `json:"owner,omitempty"`
+	PublicKey string `json:"public_key"`
+}
+
+type exportKeyResponse struct {
+	Address    string `json:"address"`
+	Format     string `json:"format"`
+	PrivateKey string `json:"private_key"`
+	Warning    string `json:"warning"`
+}
+
+func writeKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrWalletNotFound):
+		http.Error(w, "Wallet not found", http.StatusNotFound)
+	case errors.Is(err, wallet.ErrWalletExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, wallet.ErrInvalidPrivateKey), errors.Is(err, wallet.ErrKeyFormat),
+		errors.Is(err, wallet.ErrInvalidLabel), errors.Is(err, wallet.ErrInvalidAddress):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeKeystoreError(w, err)
+	}
+}
+
+// handleImportKey adds a wallet for a private key exported from another
+// node or the Java wallet.
+func (s *Server) handleImportKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request importKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.PrivateKey == "" {
+		http.Error(w, "Invalid request: private_key is required", http.StatusBadRequest)
+		return
+	}
+	_, format, err := wallet.ParsePrivateKey(request.PrivateKey)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+
+	imported, err := s.walletStore.ImportKey(request.PrivateKey, request.Label, request.Owner)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	requestLogger(r).Info("Private key imported", "address", imported.Address, "format", format)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(&importKeyResponse{
+		Address:   imported.Address,
+		Format:    string(format),
+		Label:     imported.Label,
+		Message:   "Private key imported and stored successfully",
+		Owner:     imported.Owner,
+		PublicKey: wallet.EncodePublicKey(imported.PublicKey),
+	})
+}
+
+// handleExportKey serves GET /api/wallet/export/:address, the wallet's
+// private key in ?format=hex (the default), wif or pem.
+func (s *Server) handleExportKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := strings.ToLower(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/wallet/export/"), "/"))
+	if err := wallet.ValidateAddress(address); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format, err := wallet.ParseKeyFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	if !s.requireUnlocked(w, r, address) {
+		return
+	}
+
+	key, err := s.walletStore.ExportKey(address, format)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	requestLogger(r).Warn("Private key exported", "address", address, "caller", adminCaller(r))
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, &exportKeyResponse{
+		Address:    address,
+		Format:     string(format),
+		PrivateKey: key,
+		Warning:    "Anyone who has this key can spend the wallet's coins",
+	})
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultHeavyConcurrency = 4
+	heavyQueueWait          = 2 * time.Second
+)
+
+type semaphore chan struct{}
+
+func newSemaphore(n int) semaphore {
+	if n <= 0 {
+		return nil
+	}
+	return make(semaphore, n)
+}
+
+func (sem semaphore) acquire(r *http.Request, wait time.Duration) bool {
+	if sem == nil {
+		return true
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (sem semaphore) release() {
+	if sem != nil {
+		<-sem
+	}
+}
+
+// ConcurrencyLimits bounds how many expensive handlers may run at once, both
+// overall and per endpoint, so a burst of requests can't pile up goroutines
+// doing heavy chain work.
+type ConcurrencyLimits struct {
+	Global      int
+	PerEndpoint map[string]int
+}
+
+type concurrencyLimiter struct {
+	global   semaphore
+	endpoint map[string]semaphore
+}
+
+func newConcurrencyLimiter(limits ConcurrencyLimits) *concurrencyLimiter {
+	cl := &concurrencyLimiter{
+		global:   newSemaphore(limits.Global),
+		endpoint: make(map[string]semaphore),
+	}
+	for name, n := range limits.PerEndpoint {
+		cl.endpoint[name] = newSemaphore(n)
+	}
+	return cl
+}
+
+func (s *Server) SetConcurrencyLimits(limits ConcurrencyLimits) {
+	s.limiter = newConcurrencyLimiter(limits)
+}
+
+func (s *Server) heavy(name string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.limiter == nil || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		sem := s.limiter.endpoint[name]
+		if !sem.acquire(r, heavyQueueWait) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Too many concurrent "+name+" requests", http.StatusServiceUnavailable)
+			return
+		}
+		defer sem.release()
+
+		if !s.limiter.global.acquire(r, heavyQueueWait) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "Server busy, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		defer s.limiter.global.release()
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// unixSocketMode lets the socket's owner and group, typically a reverse
+// proxy's, connect.
+const unixSocketMode = 0660
+
+// ListenAddresses splits a comma-separated -listen value. An empty value
+// means every interface on port.
+func ListenAddresses(listen, port string) []string {
+	var addrs []string
+	for _, addr := range strings.Split(listen, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		addrs = []string{":" + port}
+	}
+	return addrs
+}
+
+// Listen opens addr for the API. It is "host:port" (an IPv6 host in
+// brackets, as in "[::1]:8080"), ":port" for every interface, or
+// "unix:///path/to/node.sock" for a unix domain socket. A socket file left
+// by a node that is no longer running is replaced.
+func Listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "unix:") {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("listen address %q: %w", addr, err)
+		}
+		return net.Listen("tcp", addr)
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(addr, "unix:"), "//")
+	if path == "" {
+		return nil, fmt.Errorf("listen address %q: missing socket path", addr)
+	}
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, unixSocketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// removeStaleSocket deletes a socket at path that nothing answers on. Any
+// other file is left alone for net.Listen to fail on.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return err
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	return os.Remove(path)
+}
+
+// ListenAll opens every address, closing those already opened if one
+// fails.
+func ListenAll(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		l, err := Listen(addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// ServeAll serves srv on every listener until it is shut down. It returns
+// the first error other than http.ErrServerClosed.
+func ServeAll(srv *http.Server, listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) { errs <- srv.Serve(l) }(l)
+	}
+	var first error
+	for range listeners {
+		if err := <-errs; err != nil && err != http.ErrServerClosed && first == nil {
+			first = err
+			srv.Close()
+		}
+	}
+	return first
+}
+
+// listenerNames formats listeners' addresses for the logs.
+func listenerNames(listeners []net.Listener) []string {
+	names := make([]string, len(listeners))
+	for i, l := range listeners {
+		names[i] = l.Addr().Network() + ":" + l.Addr().String()
+	}
+	return names
+}
+
+// SetListenAddresses serves the API on addrs, in any form Listen accepts,
+// instead of every interface on the port given to NewServer.
+func (s *Server) SetListenAddresses(addrs []string) {
+	s.listen = addrs
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// transferMemo is the memo a transfer carries. With Encrypt set it is
+// encrypted to the recipient, whose public key is RecipientKey or else
+// looked up by recipientPublicKey.
+type transferMemo struct {
+	Text         string
+	Encrypt      bool
+	RecipientKey string
+}
+
+// sealMemo returns the memo as the transaction to address carries it.
+func (s *Server) sealMemo(m *transferMemo, address string) (string, *transferError) {
+	if m == nil || m.Text == "" {
+		return "", nil
+	}
+	if !m.Encrypt {
+		if err := chain.CheckMemo(m.Text); err != nil {
+			return "", &transferError{status: http.StatusBadRequest, message: err.Error()}
+		}
+		return m.Text, nil
+	}
+	pubKey, err := s.recipientPublicKey(address, m.RecipientKey)
+	if err != nil {
+		return "", &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Cannot encrypt memo: %v", err)}
+	}
+	memo, err := wallet.EncryptMemo(pubKey, m.Text)
+	if err != nil {
+		return "", &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Cannot encrypt memo: %v", err)}
+	}
+	if err := chain.CheckMemo(memo); err != nil {
+		return "", &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Memo too long to encrypt: %v", err)}
+	}
+	return memo, nil
+}
+
+// recipientPublicKey finds the public key of address to encrypt a memo to:
+// the one given, a wallet in the store, or the key that signed a spend from
+// the address on chain. An address that has never spent and isn't ours has
+// no known key, and the sender has to ask the recipient for it.
+func (s *Server) recipientPublicKey(address, given string) (string, error) {
+	if given != "" {
+		if !wallet.KeyOwnsAddress(given, address) {
+			return "", errors.New("recipient_pubkey does not match the recipient address")
+		}
+		return given, nil
+	}
+	if w := s.walletStore.GetWallet(address); w != nil && w.PublicKey != nil {
+		return wallet.EncodePublicKey(w.PublicKey), nil
+	}
+	for _, entry := range s.blockchain.AddressHistory(address) {
+		if entry.Sent == 0 {
+			continue
+		}
+		tx, _, _, ok := s.blockchain.FindTransaction(entry.TxID)
+		if ok && wallet.KeyOwnsAddress(tx.PubKey, address) {
+			return tx.PubKey, nil
+		}
+	}
+	return "", errors.New("the recipient's public key is unknown; pass recipient_pubkey")
+}
+
+type walletHistoryEntry struct {
+	addressHistoryEntry
+	MemoEncrypted bool   `json:"memo_encrypted,omitempty"`
+	MemoError     string `json:"memo_error,omitempty"`
+}
+
+type walletHistoryResponse struct {
+	Address      string               `json:"address"`
+	Count        int                  `json:"count"`
+	Offset       int                  `json:"offset"`
+	Total        int                  `json:"total"`
+	Transactions []walletHistoryEntry `json:"transactions"`
+	WatchOnly    bool                 `json:"watch_only,omitempty"`
+}
+
+// handleWalletHistory serves GET /api/wallet/history?address=: the same
+// list as /address/:addr/history for a wallet in the store, with memos
+// encrypted to the wallet decrypted. Memos the wallet sent encrypted to
+// someone else stay unreadable and are reported as such, as do all
+// encrypted memos of a watch-only address.
+func (s *Server) handleWalletHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		http.Error(w, "address is required", http.StatusBadRequest)
+		return
+	}
+	tracked, watchOnly := s.walletStore.Tracks(address)
+	if !tracked {
+		http.Error(w, wallet.ErrWalletNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	// A watch-only address has no key to unlock.
+	if !watchOnly && !s.requireUnlocked(w, r, address) {
+		return
+	}
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history := s.cached("history:"+address, func() interface{} {
+		return s.blockchain.AddressHistory(address)
+	}).([]chain.AddressTx)
+
+	height := s.blockchain.Height()
+	positions := page.indices(len(history))
+	entries := make([]walletHistoryEntry, len(positions))
+	for i, pos := range positions {
+		entry := walletHistoryEntry{addressHistoryEntry: addressHistoryEntry{
+			AddressTx:     history[pos],
+			Confirmations: height - history[pos].Height,
+		}}
+		if chain.IsEncryptedMemo(entry.Memo) {
+			entry.MemoEncrypted = true
+		}
+		if entry.MemoEncrypted && !watchOnly {
+			memo, err := s.walletStore.DecryptMemo(address, entry.Memo)
+			if errors.Is(err, wallet.ErrStoreLocked) {
+				writeKeystoreError(w, err)
+				return
+			}
+			if err != nil {
+				entry.MemoError = err.Error()
+			} else {
+				entry.Memo = memo
+			}
+		}
+		entries[i] = entry
+	}
+
+	writeJSON(w, &walletHistoryResponse{
+		Address:      address,
+		Count:        len(entries),
+		Offset:       page.offset,
+		Total:        len(history),
+		Transactions: entries,
+		WatchOnly:    watchOnly,
+	})
+}
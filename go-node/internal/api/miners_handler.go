@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	defaultMinersWindow = 1000
+	maxMinersWindow     = 100000
+)
+
+// minerStats summarizes one producer key over the window.
+type minerStats struct {
+	PubKey        string  `json:"pubkey"`
+	Blocks        int     `json:"blocks"` // main-chain blocks
+	Share         float64 `json:"share"`  // fraction of the window's blocks
+	Stale         int     `json:"stale"`  // blocks that lost to another branch, from the stale list
+	FirstHeight   int     `json:"first_height"`
+	LastHeight    int     `json:"last_height"`
+	LastTimestamp int64   `json:"last_timestamp"`
+}
+
+type minersResponse struct {
+	Window     int          `json:"window"` // main-chain blocks examined
+	FromHeight int          `json:"from_height"`
+	TipHash    string       `json:"tip_hash"`
+	Anonymous  int          `json:"anonymous"` // blocks that name no producer
+	Miners     []minerStats `json:"miners"`    // most blocks first
+}
+
+// handleMiners attributes the last ?blocks= main-chain blocks (default
+// 1000) to the producer keys that signed them. Stale blocks recorded in that
+// height range count against their producer, which gives explorers and the
+// AI service a rough reliability figure per miner.
+func (s *Server) handleMiners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	window := defaultMinersWindow
+	if v := r.URL.Query().Get("blocks"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxMinersWindow {
+			http.Error(w, "blocks must be an integer between 1 and 100000", http.StatusBadRequest)
+			return
+		}
+		window = n
+	}
+
+	resp := s.cached("miners:"+strconv.Itoa(window), func() interface{} {
+		return s.minerStats(window)
+	}).(*minersResponse)
+	writeJSON(w, resp)
+}
+
+func (s *Server) minerStats(window int) *minersResponse {
+	height := s.blockchain.Height()
+	from := height - window
+	if from < 0 {
+		from = 0
+	}
+	headers := s.blockchain.Headers(from, 0)
+
+	resp := &minersResponse{Window: len(headers), FromHeight: from, Miners: []minerStats{}}
+	if len(headers) > 0 {
+		resp.TipHash = headers[len(headers)-1].Hash
+	}
+	byKey := make(map[string]*minerStats)
+	var keys []string
+	stats := func(key string) *minerStats {
+		m, ok := byKey[key]
+		if !ok {
+			m = &minerStats{PubKey: key, FirstHeight: -1, LastHeight: -1}
+			byKey[key] = m
+			keys = append(keys, key)
+		}
+		return m
+	}
+
+	for _, h := range headers {
+		if h.MinerPubKey == "" {
+			resp.Anonymous++
+			continue
+		}
+		m := stats(h.MinerPubKey)
+		m.Blocks++
+		if m.FirstHeight < 0 {
+			m.FirstHeight = h.Index
+		}
+		m.LastHeight = h.Index
+		m.LastTimestamp = h.Timestamp
+	}
+	for _, stale := range s.blockchain.Stale.List() {
+		if stale.Block.Index >= from && stale.Block.MinerPubKey != "" {
+			stats(stale.Block.MinerPubKey).Stale++
+		}
+	}
+
+	for _, key := range keys {
+		m := byKey[key]
+		if resp.Window > 0 {
+			m.Share = float64(m.Blocks) / float64(resp.Window)
+		}
+		resp.Miners = append(resp.Miners, *m)
+	}
+	sort.SliceStable(resp.Miners, func(i, j int) bool {
+		return resp.Miners[i].Blocks > resp.Miners[j].Blocks
+	})
+	return resp
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"ai-blockchain/go-node/internal/ai"
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// handleMiningProposal validates a candidate block without requiring proof of
+// work. A rejected proposal is still a 200 response; only malformed requests
+// are errors.
+type blockProposalResponse struct {
+	Height    int      `json:"height"`
+	Reason    string   `json:"reason,omitempty"`     // why the block is invalid
+	TotalFees *float64 `json:"total_fees,omitempty"` // for a valid block
+	TxCount   int      `json:"tx_count"`
+	Valid     bool     `json:"valid"`
+}
+
+func (s *Server) handleMiningProposal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var block chain.Block
+	if err := json.NewDecoder(r.Body).Decode(&block); err != nil {
+		http.Error(w, "Invalid block JSON", http.StatusBadRequest)
+		return
+	}
+
+	response := blockProposalResponse{
+		Valid:   true,
+		Height:  block.Index,
+		TxCount: len(block.Transactions),
+	}
+
+	if err := chain.VerifyBlockProposal(&block, s.blockchain); err != nil {
+		response.Valid = false
+		response.Reason = err.Error()
+	} else {
+		fees := proposalFees(&block, s.blockchain.UTXOSnapshot())
+		response.TotalFees = &fees
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// proposalFees sums the block's fees, applying its transactions to view as
+// it goes.
+func proposalFees(block *chain.Block, view *chain.UTXOSet) float64 {
+	var total float64
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if fee, err := chain.ComputeFee(tx, view); err == nil {
+			total += fee
+		}
+		view.ApplyTransaction(tx)
+	}
+	return total
+}
+
+// anomalyRejectThreshold is the AI anomaly score above which the node turns
+// a transaction away.
+const anomalyRejectThreshold = 0.7
+
+// droppedTx is a pending transaction /mine left out of its block because of
+// its AI score.
+type droppedTx struct {
+	TxID         string   `json:"txid"`
+	AnomalyScore float64  `json:"anomaly_score"`
+	Descendants  []string `json:"descendants,omitempty"` // dropped with it
+}
+
+// rememberScore keeps a real AI score with the pending transaction it is
+// for, so /mempool can show it and mining can order by it. Default scores
+// handed out while the service is off are not kept.
+func (s *Server) rememberScore(txID string, score *ai.ScoreResponse) {
+	if score == nil || score.Fallback {
+		return
+	}
+	s.mempool.SetScore(txID, chain.TxScore{
+		AnomalyScore: score.AnomalyScore,
+		FeeAdequacy:  score.FeeAdequacy,
+		ScoredAt:     time.Now().Unix(),
+	})
+}
+
+func (s *Server) mempoolEntry(tx *chain.Transaction) mempoolEntry {
+	entry := mempoolEntry{Transaction: tx, InFlight: s.mempool.InFlight(tx.ID)}
+	entry.FeeInfo, _ = s.mempool.FeeInfo(tx.ID)
+	if score, ok := s.mempool.Score(tx.ID); ok {
+		entry.AIScore = &score
+	}
+	return entry
+}
+
+// dropAnomalous scores the pending transactions that have no score yet, in
+// batches, and removes those flagged as anomalous, with their descendants,
+// so the next template leaves them out. Transactions relayed by peers, or
+// admitted while the AI service was unreachable, are caught here. Scoring
+// failures are logged and leave the mempool untouched.
+func (s *Server) dropAnomalous(r *http.Request) []droppedTx {
+	if s.aiClient == nil || !s.aiClient.Enabled() {
+		return nil
+	}
+	var unscored []*chain.Transaction
+	for _, tx := range s.mempool.GetTransactionsByFee(0) {
+		if _, ok := s.mempool.Score(tx.ID); !ok {
+			unscored = append(unscored, tx)
+		}
+	}
+	if len(unscored) == 0 {
+		return nil
+	}
+	scores, err := s.aiClient.ScoreTransactions(unscored, "/mine")
+	if err != nil {
+		requestLogger(r).Warn("AI batch scoring failed, mining without it", "txs", len(unscored), "err", err)
+		return nil
+	}
+
+	var dropped []droppedTx
+	for i, tx := range unscored {
+		if scores[i].AnomalyScore <= anomalyRejectThreshold {
+			s.rememberScore(tx.ID, scores[i])
+			continue
+		}
+		removed, err := s.blocks.EvictTransaction(tx.ID)
+		if err != nil {
+			break // shutting down
+		}
+		if len(removed) == 0 {
+			continue // already gone with an anomalous ancestor
+		}
+		s.aiClient.RecordRejection("/mine")
+		entry := droppedTx{TxID: tx.ID, AnomalyScore: scores[i].AnomalyScore}
+		for _, id := range removed {
+			if id != tx.ID {
+				entry.Descendants = append(entry.Descendants, id)
+			}
+		}
+		requestLogger(r).Warn("Dropped anomalous transaction before mining", "txid", tx.ID, "anomaly", scores[i].AnomalyScore, "descendants", len(entry.Descendants))
+		dropped = append(dropped, entry)
+	}
+	return dropped
+}
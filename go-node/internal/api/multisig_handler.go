@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type multisigRequest struct {
+	Label    string   `json:"label,omitempty"`
+	PubKeys  []string `json:"pubkeys,omitempty"` // hex X||Y
+	Required int      `json:"required"`
+	Wallets  []string `json:"wallets,omitempty"` // addresses of wallets in this store whose keys join
+}
+
+type multisigAccountsResponse struct {
+	Accounts []wallet.MultisigInfo `json:"accounts"`
+	Count    int                   `json:"count"`
+}
+
+type multisigSignResponse struct {
+	CanonicalHex string             `json:"canonical_hex"`
+	Complete     bool               `json:"complete"` // enough signatures to submit
+	Required     int                `json:"required"`
+	Signatures   int                `json:"signatures"`
+	Transaction  *chain.Transaction `json:"transaction"`
+	TxID         string             `json:"txid"`
+}
+
+func writeMultisigError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrMultisigNotFound), errors.Is(err, wallet.ErrWalletNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, wallet.ErrMultisigExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, wallet.ErrNotCosigner), errors.Is(err, wallet.ErrForeignInput):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, wallet.ErrInvalidMultisig), errors.Is(err, wallet.ErrNoMultisig),
+		errors.Is(err, wallet.ErrInvalidLabel):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeKeystoreError(w, err)
+	}
+}
+
+// handleMultisig serves /api/wallet/multisig: GET lists the multisig
+// accounts, POST registers one over {"pubkeys"} and the keys of {"wallets"}.
+func (s *Server) handleMultisig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		accounts := s.walletStore.MultisigAccounts()
+		writeJSON(w, &multisigAccountsResponse{Accounts: accounts, Count: len(accounts)})
+
+	case http.MethodPost:
+		var request multisigRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		keys := append([]string(nil), request.PubKeys...)
+		for _, address := range request.Wallets {
+			cosigner := s.walletStore.GetWallet(address)
+			if cosigner == nil {
+				http.Error(w, "Wallet not found: "+address, http.StatusNotFound)
+				return
+			}
+			keys = append(keys, wallet.EncodePublicKey(cosigner.PublicKey))
+		}
+
+		info, err := s.walletStore.CreateMultisig(request.Required, keys, request.Label)
+		if err != nil {
+			writeMultisigError(w, err)
+			return
+		}
+		requestLogger(r).Info("Multisig account added", "address", info.Address, "required", info.Required, "keys", len(info.PubKeys))
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&info)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleMultisigSign adds the signature of a node-held key to a multisig
+// spend, as built by POST /api/wallet/build from a multisig account. It does
+// not submit the transaction: once complete, POST it to /transactions.
+func (s *Server) handleMultisigSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request signTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.From == "" || request.Transaction == nil {
+		http.Error(w, "Invalid request: from and transaction are required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireUnlocked(w, r, request.From) {
+		return
+	}
+
+	tx := request.Transaction
+	if err := s.walletStore.SignMultisig(request.From, tx, s.mempool.SpendableUTXO(s.blockchain)); err != nil {
+		writeMultisigError(w, err)
+		return
+	}
+	canonical, err := chain.CanonicalTxBytes(tx)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to canonicalize: %v", err), http.StatusInternalServerError)
+		return
+	}
+	signatures := tx.Multisig.SignatureCount()
+	requestLogger(r).Info("Multisig transaction signed", "txid", tx.ID, "signer", request.From,
+		"signatures", signatures, "required", tx.Multisig.Required)
+
+	writeJSON(w, &multisigSignResponse{
+		CanonicalHex: hex.EncodeToString(canonical),
+		Complete:     signatures >= tx.Multisig.Required,
+		Required:     tx.Multisig.Required,
+		Signatures:   signatures,
+		Transaction:  tx,
+		TxID:         tx.ID,
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/notify"
+)
+
+// SetNotificationQueue exposes the operator alert retry queue under
+// /admin/notifications.
+func (s *Server) SetNotificationQueue(q *notify.DeliveryQueue) {
+	s.notifyQueue = q
+}
+
+type notificationsResponse struct {
+	Affected *int               `json:"affected,omitempty"` // dead letters requeued or discarded
+	Queue    notify.QueueStatus `json:"queue"`
+}
+
+// requeueRequest names one dead letter, or all of them.
+type requeueRequest struct {
+	ID  string `json:"id,omitempty"`
+	All bool   `json:"all,omitempty"`
+}
+
+// handleNotifications serves /admin/notifications: GET lists alerts waiting
+// to be retried and dead letters, POST {"id"} or {"all": true} retries dead
+// letters afresh, and DELETE ?id= or ?all=true discards them.
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	if s.notifyQueue == nil {
+		http.Error(w, "Notifications not configured (start node with -notify-webhook, -notify-slack or -notify-smtp)", http.StatusConflict)
+		return
+	}
+
+	var count int
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request requeueRequest
+		if json.NewDecoder(r.Body).Decode(&request) != nil || (request.ID == "") == !request.All {
+			http.Error(w, `Body must be {"id": "..."} or {"all": true}`, http.StatusBadRequest)
+			return
+		}
+		count, err = s.notifyQueue.Requeue(request.ID)
+		if err == nil {
+			requestLogger(r).Info("Admin requeued dead notifications", "id", request.ID, "count", count)
+		}
+	case http.MethodDelete:
+		id := r.URL.Query().Get("id")
+		if (id == "") == (r.URL.Query().Get("all") != "true") {
+			http.Error(w, "id or all=true is required", http.StatusBadRequest)
+			return
+		}
+		count, err = s.notifyQueue.Discard(id)
+		if err == nil {
+			requestLogger(r).Info("Admin discarded dead notifications", "id", id, "count", count)
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if errors.Is(err, notify.ErrUnknownDelivery) {
+		http.Error(w, "No dead letter with that id", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Failed to save notification queue: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response := notificationsResponse{Queue: s.notifyQueue.Status()}
+	if r.Method != http.MethodGet {
+		response.Affected = &count
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
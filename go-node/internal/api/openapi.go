@@ -0,0 +1,609 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"ai-blockchain/go-node/internal/archive"
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/cluster"
+	"ai-blockchain/go-node/internal/fees"
+	"ai-blockchain/go-node/internal/graphql"
+	"ai-blockchain/go-node/internal/scheduler"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// openAPIVersion is the version of the OpenAPI specification the document
+// follows.
+const openAPIVersion = "3.0.3"
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Tags       []openAPITag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []openAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"` // path, query or header
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+// openAPISchema is the subset of the schema object the generator emits. An
+// empty schema accepts any value.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	OneOf                []*openAPISchema          `json:"oneOf,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+// apiAccess is who may call an operation.
+type apiAccess int
+
+const (
+	accessPublic apiAccess = iota
+	// accessSensitive needs admin credentials once any are configured.
+	accessSensitive
+	accessAdmin
+)
+
+type apiParam struct {
+	name        string
+	kind        string // string, integer, number or boolean
+	description string
+	required    bool
+}
+
+// apiOperation describes one endpoint for the OpenAPI document. Paths are
+// relative to /v1; {name} segments become required path parameters.
+type apiOperation struct {
+	method  string
+	path    string
+	summary string
+	tag     string
+	access  apiAccess
+	query   []apiParam
+
+	request  interface{} // JSON body
+	response interface{} // JSON body on success
+	status   int         // of the success response; 200 when zero
+	// alternative is another shape the success response takes, such as a
+	// projection selected with ?fields=.
+	alternative interface{}
+	// media are other content types of the success response, served as
+	// they are rather than as JSON.
+	media []string
+	// responses are further JSON responses by status.
+	responses map[int]interface{}
+	// session marks wallet operations that need an X-Wallet-Session token
+	// when the wallet is encrypted.
+	session bool
+	// twoPerson marks operations that need a second admin's approval when
+	// -admin-approvals asks for it.
+	twoPerson bool
+}
+
+var (
+	pageParams = []apiParam{
+		{name: "offset", kind: "integer", description: "Items to skip"},
+		{name: "limit", kind: "integer", description: "Items to return"},
+		{name: "order", kind: "string", description: "asc or desc"},
+	}
+	fieldsParam    = apiParam{name: "fields", kind: "string", description: "Comma-separated fields to return"}
+	addressParam   = apiParam{name: "address", kind: "string", required: true}
+	walletParams   = []apiParam{{name: "label", kind: "string"}, {name: "owner", kind: "string"}}
+	deadLetterArgs = []apiParam{{name: "id", kind: "string", description: "Dead letter to discard"}, {name: "all", kind: "boolean", description: "Discard every dead letter"}}
+)
+
+// apiOperations lists every endpoint served under /v1. Keep it in step
+// with Server.routes, which routes_test.go checks.
+var apiOperations = []apiOperation{
+	{method: "GET", path: "/health", summary: "Node health", tag: "node", response: healthResponse{}},
+	{method: "GET", path: "/version", summary: "Build, protocol versions and features", tag: "node", response: versionResponse{}},
+	{method: "GET", path: "/params", summary: "Consensus parameters", tag: "node", response: ChainParams{}},
+	{method: "GET", path: "/stats", summary: "Chain statistics", tag: "node", response: statsResponse{}},
+	{method: "GET", path: "/metrics", summary: "Prometheus metrics", tag: "node", media: []string{"text/plain"}},
+	{method: "GET", path: "/cluster/status", summary: "Health of the configured cluster members", tag: "node", response: cluster.Status{}},
+
+	{method: "GET", path: "/blocks", summary: "List blocks", tag: "chain", query: append(pageParams, fieldsParam),
+		response: blocksResponse{}, alternative: projectedBlocksResponse{}, media: []string{binaryContentType}},
+	{method: "GET", path: "/blocks/stale", summary: "Blocks that lost a fork", tag: "chain", response: staleBlocksResponse{}},
+	{method: "GET", path: "/headers", summary: "Block headers", tag: "chain",
+		query: []apiParam{{name: "from", kind: "integer"}, {name: "limit", kind: "integer"}}, response: headersResponse{}},
+	{method: "GET", path: "/chain", summary: "Chain tip", tag: "chain", response: chainResponse{}},
+	{method: "GET", path: "/richlist", summary: "Largest balances", tag: "chain",
+		query: []apiParam{{name: "limit", kind: "integer"}}, response: richListResponse{}},
+	{method: "GET", path: "/miners", summary: "Blocks mined per address", tag: "chain",
+		query: []apiParam{{name: "blocks", kind: "integer", description: "Window of recent blocks"}}, response: minersResponse{}},
+	{method: "GET", path: "/consensus/simulate", summary: "Simulate block times at a difficulty", tag: "chain",
+		query: []apiParam{{name: "difficulty", kind: "integer"}, {name: "target", kind: "integer", description: "Target block time in seconds"}}, response: simulateResponse{}},
+	{method: "GET", path: "/balance/{address}", summary: "Confirmed balance", tag: "chain", response: balanceResponse{}},
+	{method: "GET", path: "/address/{address}/history", summary: "Confirmed transactions of an address", tag: "chain", query: pageParams, response: addressHistoryResponse{}},
+	{method: "GET", path: "/analytics/cluster/{address}", summary: "Addresses likely owned together", tag: "chain", response: addressClusterResponse{}},
+	{method: "POST", path: "/graphql", summary: "Explorer query", tag: "chain", request: graphql.Request{}, response: graphql.Response{}},
+	{method: "GET", path: "/archive", summary: "Block archive statistics", tag: "chain", response: archive.Stats{}},
+	{method: "GET", path: "/archive/blocks.dat", summary: "Archived block records", tag: "chain", media: []string{binaryContentType}},
+	{method: "GET", path: "/archive/blocks.idx", summary: "Block archive index", tag: "chain", media: []string{binaryContentType}},
+	{method: "GET", path: "/archive/blocks/{hash}", summary: "Where a block sits in blocks.dat", tag: "chain", response: archive.Location{}},
+
+	{method: "GET", path: "/mempool", summary: "Pending transactions", tag: "transactions", query: []apiParam{fieldsParam},
+		response: mempoolResponse{}, alternative: projectedMempoolResponse{}, media: []string{binaryContentType}},
+	{method: "POST", path: "/transactions", summary: "Submit a signed transaction", tag: "transactions", request: chain.Transaction{}, response: txAcceptedResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/transactions/{txid}", summary: "Look up a transaction", tag: "transactions", response: txLookupResponse{}},
+	{method: "GET", path: "/transactions/{txid}/proof", summary: "Merkle proof of a confirmed transaction", tag: "transactions", response: chain.TxProof{}},
+	{method: "GET", path: "/fees/estimate", summary: "Fee estimate", tag: "transactions",
+		query: []apiParam{{name: "target", kind: "integer", description: "Blocks to confirmation"}, {name: "size", kind: "integer", description: "Transaction size in bytes, for the relay fee floor"}}, response: fees.Estimate{}},
+	{method: "POST", path: "/debug/canonicalize", summary: "Canonical bytes and txid of a transaction", tag: "transactions", request: chain.Transaction{}, response: canonicalizeResponse{}},
+
+	{method: "POST", path: "/mine", summary: "Mine a block", tag: "mining", access: accessSensitive, request: mineRequest{}, response: mineResponse{}},
+	{method: "POST", path: "/mine/cancel", summary: "Cancel mining", tag: "mining", access: accessSensitive, response: cancelMiningResponse{}},
+	{method: "POST", path: "/mining/proposal", summary: "Check a block template", tag: "mining", request: chain.Block{}, response: blockProposalResponse{}},
+
+	{method: "GET", path: "/api/address/validate", summary: "Check an address", tag: "wallet", query: []apiParam{addressParam}, response: wallet.AddressCheck{}},
+	{method: "GET", path: "/api/wallet/generate", summary: "Create a wallet", tag: "wallet", access: accessSensitive, query: walletParams, response: generateWalletResponse{}},
+	{method: "GET", path: "/api/wallet/hd", summary: "List HD wallets", tag: "wallet", access: accessSensitive, response: hdWalletsResponse{}},
+	{method: "POST", path: "/api/wallet/hd", summary: "Create an HD wallet, or restore one from a mnemonic", tag: "wallet", access: accessSensitive,
+		request: hdWalletRequest{}, response: hdWalletCreatedResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/api/wallet/hd/{id}", summary: "An HD wallet and its derived addresses", tag: "wallet", access: accessSensitive, response: hdWalletResponse{}},
+	{method: "POST", path: "/api/wallet/hd/{id}/derive", summary: "Derive the next receive address", tag: "wallet", access: accessSensitive,
+		request: deriveHDAddressRequest{}, response: derivedAddressResponse{}, status: http.StatusCreated},
+	{method: "POST", path: "/api/wallet/hd/{id}/mnemonic", summary: "Reveal the mnemonic, once", tag: "wallet", access: accessAdmin, response: mnemonicResponse{}},
+	{method: "POST", path: "/api/wallet/import", summary: "Import a private key", tag: "wallet", access: accessSensitive,
+		request: importKeyRequest{}, response: importKeyResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/api/wallet/export/{address}", summary: "Export a wallet's private key", tag: "wallet", access: accessAdmin, session: true,
+		query: []apiParam{{name: "format", kind: "string", description: "hex (default), wif or pem"}}, response: exportKeyResponse{}},
+	{method: "GET", path: "/api/wallet/list", summary: "List wallets", tag: "wallet", access: accessSensitive, query: append(pageParams, walletParams...), response: walletListResponse{}},
+	{method: "POST", path: "/api/wallet/transfer", summary: "Send coins from a wallet", tag: "wallet", access: accessSensitive, session: true,
+		request: transferRequest{}, response: transferResponse{}, status: http.StatusCreated,
+		responses: map[int]interface{}{http.StatusUnprocessableEntity: feeCapResponse{}}},
+	{method: "GET", path: "/api/wallet/{address}/consolidate-suggestion", summary: "Propose merging an address's small outputs", tag: "wallet", access: accessSensitive,
+		query: []apiParam{{name: "target_confirmations", kind: "integer", description: "Blocks to confirmation the fee aims for"}}, response: consolidationSuggestionResponse{}},
+	{method: "POST", path: "/api/wallet/{address}/consolidate", summary: "Merge an address's small outputs into one", tag: "wallet", access: accessSensitive, session: true,
+		request: consolidateRequest{}, response: consolidateResponse{}, status: http.StatusCreated},
+	{method: "GET", path: "/api/wallet/history", summary: "Wallet history with memos", tag: "wallet", access: accessSensitive, session: true,
+		query: append([]apiParam{addressParam}, pageParams...), response: walletHistoryResponse{}},
+	{method: "GET", path: "/api/wallet/contacts", summary: "List contacts", tag: "wallet", access: accessSensitive,
+		query: []apiParam{{name: "wallet", kind: "string"}}, response: contactsResponse{}},
+	{method: "POST", path: "/api/wallet/contacts", summary: "Save a contact", tag: "wallet", access: accessSensitive, request: contactRequest{}, response: wallet.Contact{}, status: http.StatusCreated},
+	{method: "DELETE", path: "/api/wallet/contacts", summary: "Delete a contact", tag: "wallet", access: accessSensitive,
+		query: []apiParam{{name: "wallet", kind: "string"}, {name: "name", kind: "string", required: true}}, status: http.StatusNoContent},
+	{method: "GET", path: "/api/wallet/schedules", summary: "List scheduled payments", tag: "wallet", access: accessSensitive, response: schedulesResponse{}},
+	{method: "POST", path: "/api/wallet/schedules", summary: "Schedule a recurring payment", tag: "wallet", access: accessSensitive, session: true,
+		request: scheduleRequest{}, response: scheduler.Schedule{}, status: http.StatusCreated},
+	{method: "POST", path: "/api/wallet/schedules/{id}/pause", summary: "Pause a scheduled payment", tag: "wallet", access: accessSensitive, response: scheduler.Schedule{}},
+	{method: "POST", path: "/api/wallet/schedules/{id}/resume", summary: "Resume a scheduled payment", tag: "wallet", access: accessSensitive, response: scheduler.Schedule{}},
+	{method: "POST", path: "/api/wallet/schedules/{id}/cancel", summary: "Cancel a scheduled payment", tag: "wallet", access: accessSensitive, response: scheduler.Schedule{}},
+	{method: "GET", path: "/api/wallet/uri", summary: "Make a payment URI", tag: "wallet",
+		query: []apiParam{addressParam, {name: "amount", kind: "number"}, {name: "label", kind: "string"}, {name: "memo", kind: "string"}}, response: paymentURIResponse{}},
+	{method: "GET", path: "/api/wallet/uri/parse", summary: "Parse a payment URI", tag: "wallet",
+		query: []apiParam{{name: "uri", kind: "string", required: true}}, response: wallet.PaymentURI{}},
+	{method: "POST", path: "/api/wallet/derive", summary: "Address of a public key", tag: "wallet", request: deriveAddressRequest{}, response: deriveAddressResponse{}},
+	{method: "POST", path: "/api/wallet/build", summary: "Build an unsigned transaction", tag: "wallet", request: buildTransactionRequest{}, response: transactionResponse{}},
+	{method: "POST", path: "/api/wallet/sign", summary: "Sign a transaction with a stored key", tag: "wallet", access: accessSensitive, session: true,
+		request: signTransactionRequest{}, response: transactionResponse{}},
+	{method: "GET", path: "/api/wallet/multisig", summary: "List multisig accounts", tag: "wallet", access: accessSensitive, response: multisigAccountsResponse{}},
+	{method: "POST", path: "/api/wallet/multisig", summary: "Register an m-of-n multisig account", tag: "wallet", access: accessSensitive,
+		request: multisigRequest{}, response: wallet.MultisigInfo{}, status: http.StatusCreated},
+	{method: "POST", path: "/api/wallet/multisig/sign", summary: "Add a stored key's signature to a multisig spend", tag: "wallet", access: accessSensitive, session: true,
+		request: signTransactionRequest{}, response: multisigSignResponse{}},
+	{method: "GET", path: "/api/wallet/watch", summary: "List watch-only addresses", tag: "wallet", access: accessSensitive, response: watchListResponse{}},
+	{method: "POST", path: "/api/wallet/watch", summary: "Track an address without its key", tag: "wallet", access: accessSensitive,
+		request: watchRequest{}, response: walletEntry{}, status: http.StatusCreated},
+	{method: "DELETE", path: "/api/wallet/watch", summary: "Stop tracking a watch-only address", tag: "wallet", access: accessSensitive,
+		query: []apiParam{addressParam}, status: http.StatusNoContent},
+	{method: "POST", path: "/api/wallet/unlock", summary: "Open a wallet session", tag: "wallet", access: accessSensitive, request: unlockWalletRequest{}, response: unlockWalletResponse{}},
+	{method: "POST", path: "/api/wallet/lock", summary: "End wallet sessions", tag: "wallet", access: accessSensitive, request: lockWalletRequest{}, response: lockWalletResponse{}},
+	{method: "GET", path: "/api/wallet/store", summary: "Wallet store status", tag: "wallet", access: accessSensitive, response: walletStoreResponse{}},
+	{method: "POST", path: "/api/wallet/store/lock", summary: "Lock the wallet store", tag: "wallet", access: accessSensitive, response: walletStoreResponse{}},
+	{method: "POST", path: "/api/wallet/store/unlock", summary: "Unlock the wallet store", tag: "wallet", access: accessSensitive, request: unlockStoreRequest{}, response: walletStoreResponse{}},
+
+	{method: "GET", path: "/peers", summary: "Connected peers", tag: "network", response: peersResponse{}},
+	{method: "GET", path: "/sync/status", summary: "Initial block download progress", tag: "network", response: syncStatusResponse{}},
+	{method: "GET", path: "/ws", summary: "Stream chain events over a WebSocket", tag: "network", status: http.StatusSwitchingProtocols,
+		query: []apiParam{{name: "events", kind: "string", description: "Comma-separated block, tx, reorg, wallet"}}},
+
+	{method: "POST", path: "/admin/freeze", summary: "Stop accepting blocks and transactions", tag: "admin", access: accessAdmin, twoPerson: true,
+		request: freezeRequest{}, response: freezeResponse{}},
+	{method: "POST", path: "/admin/unfreeze", summary: "Resume after a freeze", tag: "admin", access: accessAdmin, response: freezeResponse{}},
+	{method: "POST", path: "/admin/mempool/clear", summary: "Drop every pending transaction", tag: "admin", access: accessAdmin, twoPerson: true, response: clearMempoolResponse{}},
+	{method: "POST", path: "/admin/reindex", summary: "Rebuild the UTXO set and indexes", tag: "admin", access: accessAdmin, twoPerson: true, response: chain.ReindexResult{}},
+	{method: "GET", path: "/admin/quarantine", summary: "Transaction quarantine", tag: "admin", access: accessAdmin, response: quarantineResponse{}},
+	{method: "POST", path: "/admin/quarantine", summary: "Turn the quarantine on or off", tag: "admin", access: accessAdmin, request: quarantineRequest{}, response: quarantineResponse{}},
+	{method: "GET", path: "/admin/bans", summary: "Banned peers", tag: "admin", access: accessAdmin, response: bansResponse{}},
+	{method: "POST", path: "/admin/bans", summary: "Ban a peer", tag: "admin", access: accessAdmin, request: banRequest{}, response: bansResponse{}},
+	{method: "DELETE", path: "/admin/bans", summary: "Lift a ban", tag: "admin", access: accessAdmin, query: []apiParam{addressParam}, response: bansResponse{}},
+	{method: "GET", path: "/admin/peers", summary: "Peers and dialed addresses", tag: "admin", access: accessAdmin, response: adminPeersResponse{}},
+	{method: "POST", path: "/admin/peers", summary: "Keep an address dialed", tag: "admin", access: accessAdmin, request: peerRequest{}, response: adminPeersResponse{},
+		responses: map[int]interface{}{http.StatusCreated: adminPeersResponse{}}},
+	{method: "DELETE", path: "/admin/peers", summary: "Stop dialing an address", tag: "admin", access: accessAdmin, query: []apiParam{addressParam}, response: adminPeersResponse{}},
+	{method: "GET", path: "/admin/notifications", summary: "Undelivered notifications", tag: "admin", access: accessAdmin, response: notificationsResponse{}},
+	{method: "POST", path: "/admin/notifications", summary: "Retry dead letters", tag: "admin", access: accessAdmin, request: requeueRequest{}, response: notificationsResponse{}},
+	{method: "DELETE", path: "/admin/notifications", summary: "Discard dead letters", tag: "admin", access: accessAdmin, query: deadLetterArgs, response: notificationsResponse{}},
+	{method: "GET", path: "/admin/proposals", summary: "Operations awaiting approval", tag: "admin", access: accessAdmin, response: proposalsResponse{}},
+	{method: "GET", path: "/admin/proposals/{id}", summary: "One proposal", tag: "admin", access: accessAdmin, response: Proposal{}},
+	{method: "POST", path: "/admin/proposals/{id}/approve", summary: "Approve a proposal; the last approval runs it", tag: "admin", access: accessAdmin, response: Proposal{}},
+	{method: "DELETE", path: "/admin/proposals/{id}", summary: "Cancel a proposal", tag: "admin", access: accessAdmin, response: Proposal{}},
+	{method: "GET", path: "/admin/export", summary: "Chain history as CSV or Parquet", tag: "admin", access: accessAdmin,
+		query: []apiParam{{name: "what", kind: "string", description: "blocks, txs or utxos"}, {name: "format", kind: "string", description: "csv or parquet"}},
+		media: []string{"text/csv", "application/vnd.apache.parquet"}},
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// buildOpenAPI generates the document from apiOperations, deriving schemas
+// from the Go types the handlers encode and decode. Components are named
+// after their types; a first pass finds the names more than one package
+// uses, and the second qualifies those with the package name, so the names
+// do not depend on the order types are met in.
+func buildOpenAPI(version string) *openAPIDocument {
+	first := newSchemaGenerator(nil)
+	first.document(version)
+	packages := make(map[string]map[string]bool)
+	for t := range first.names {
+		name := exportedName(t.Name())
+		if packages[name] == nil {
+			packages[name] = make(map[string]bool)
+		}
+		packages[name][t.PkgPath()] = true
+	}
+	qualify := make(map[string]bool)
+	for name, pkgs := range packages {
+		if len(pkgs) > 1 {
+			qualify[name] = true
+		}
+	}
+	return newSchemaGenerator(qualify).document(version)
+}
+
+func (g *schemaGenerator) document(version string) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       "AI blockchain node API",
+			Description: "Errors are plain-text messages with a 4xx or 5xx status.",
+			Version:     version,
+		},
+		Servers: []openAPIServer{{URL: apiPrefix}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: g.components,
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: apiKeyHeader},
+			},
+		},
+	}
+
+	seenTags := make(map[string]bool)
+	for _, op := range apiOperations {
+		if !seenTags[op.tag] {
+			seenTags[op.tag] = true
+			doc.Tags = append(doc.Tags, openAPITag{Name: op.tag})
+		}
+		if doc.Paths[op.path] == nil {
+			doc.Paths[op.path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[op.path][strings.ToLower(op.method)] = g.operation(op)
+	}
+	return doc
+}
+
+func (g *schemaGenerator) operation(op apiOperation) *openAPIOperation {
+	out := &openAPIOperation{
+		OperationID: operationID(op.method, op.path),
+		Summary:     op.summary,
+		Tags:        []string{op.tag},
+		Responses:   make(map[string]*openAPIResponse),
+	}
+
+	for _, match := range pathParamPattern.FindAllStringSubmatch(op.path, -1) {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name: match[1], In: "path", Required: true, Schema: &openAPISchema{Type: "string"},
+		})
+	}
+	for _, p := range op.query {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name: p.name, In: "query", Description: p.description, Required: p.required, Schema: &openAPISchema{Type: p.kind},
+		})
+	}
+	if op.session {
+		out.Parameters = append(out.Parameters, openAPIParameter{
+			Name: sessionHeader, In: "header", Description: "Session token from /api/wallet/unlock; needed for encrypted wallets",
+			Schema: &openAPISchema{Type: "string"},
+		})
+	}
+
+	if op.request != nil {
+		out.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content:  map[string]openAPIMediaType{"application/json": {Schema: g.schema(reflect.TypeOf(op.request))}},
+		}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &openAPIResponse{Description: http.StatusText(status)}
+	if op.response != nil || len(op.media) > 0 {
+		success.Content = make(map[string]openAPIMediaType)
+	}
+	if op.response != nil {
+		schema := g.schema(reflect.TypeOf(op.response))
+		if op.alternative != nil {
+			schema = &openAPISchema{OneOf: []*openAPISchema{schema, g.schema(reflect.TypeOf(op.alternative))}}
+		}
+		success.Content["application/json"] = openAPIMediaType{Schema: schema}
+	}
+	for _, media := range op.media {
+		schema := &openAPISchema{Type: "string", Format: "binary"}
+		if strings.HasPrefix(media, "text/") {
+			schema.Format = ""
+		}
+		success.Content[media] = openAPIMediaType{Schema: schema}
+	}
+	out.Responses[strconv.Itoa(status)] = success
+
+	for code, body := range op.responses {
+		out.Responses[strconv.Itoa(code)] = &openAPIResponse{
+			Description: http.StatusText(code),
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: g.schema(reflect.TypeOf(body))}},
+		}
+	}
+	if op.twoPerson {
+		out.Responses[strconv.Itoa(http.StatusAccepted)] = &openAPIResponse{
+			Description: "Proposed; waiting for another admin to approve",
+			Content:     map[string]openAPIMediaType{"application/json": {Schema: g.schema(reflect.TypeOf(Proposal{}))}},
+		}
+	}
+	out.Responses["default"] = &openAPIResponse{
+		Description: "Error",
+		Content:     map[string]openAPIMediaType{"text/plain": {Schema: &openAPISchema{Type: "string"}}},
+	}
+
+	switch op.access {
+	case accessAdmin:
+		out.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}}
+	case accessSensitive:
+		// Open until admin credentials are configured.
+		out.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}, {}}
+	}
+	return out
+}
+
+// operationID turns "GET /address/{address}/history" into
+// getAddressAddressHistory.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// schemaGenerator derives schemas from Go types the way encoding/json
+// encodes them. Named struct types become components, referenced by name.
+type schemaGenerator struct {
+	components map[string]*openAPISchema
+	names      map[reflect.Type]string
+	qualify    map[string]bool // names to prefix with the package name
+}
+
+func newSchemaGenerator(qualify map[string]bool) *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]*openAPISchema),
+		names:      make(map[reflect.Type]string),
+		qualify:    qualify,
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+func (g *schemaGenerator) schema(t reflect.Type) *openAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &openAPISchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+	// Interfaces, and anything else encoding/json decides at run time.
+	return &openAPISchema{}
+}
+
+// component names t's schema, generating it the first time t is seen.
+func (g *schemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := exportedName(t.Name())
+	if _, taken := g.components[name]; taken || g.qualify[name] {
+		pkg := t.PkgPath()
+		name = exportedName(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+	}
+	g.names[t] = name
+	// Reserve the name before recursing so self-referencing types end.
+	g.components[name] = &openAPISchema{}
+	*g.components[name] = *g.object(t)
+	return name
+}
+
+func (g *schemaGenerator) object(t reflect.Type) *openAPISchema {
+	s := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields adds t's JSON fields to s, promoting those of embedded structs
+// as encoding/json does.
+func (g *schemaGenerator) addFields(s *openAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(s, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := g.schema(field.Type)
+		if strings.Contains(","+options+",", ",string,") {
+			schema = &openAPISchema{Type: "string"}
+		}
+		omitEmpty := strings.Contains(","+options+",", ",omitempty,")
+		if field.Type.Kind() == reflect.Ptr && !omitEmpty {
+			if schema.Ref != "" {
+				schema = &openAPISchema{OneOf: []*openAPISchema{schema}}
+			}
+			schema.Nullable = true
+		}
+		s.Properties[name] = schema
+		if !omitEmpty {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// handleOpenAPI serves GET /v1/openapi.json.
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	version := s.build.Version
+	if version == "" {
+		version = "dev"
+	}
+	writeJSON(w, buildOpenAPI(version))
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// listPage is the slice of a list that ?offset=, ?limit= and ?order= select
+// on /blocks and /mempool. Without offset or limit the whole list is
+// returned, as before pagination existed.
+type listPage struct {
+	offset  int
+	limit   int // 0 = no limit
+	reverse bool
+}
+
+func parseListPage(r *http.Request) (listPage, error) {
+	q := r.URL.Query()
+	var p listPage
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		p.reverse = true
+	default:
+		return p, errors.New("order must be asc or desc")
+	}
+
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return p, errors.New("offset must be a non-negative integer")
+		}
+		p.offset = n
+		p.limit = defaultPageLimit
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return p, errors.New("limit must be an integer between 1 and 1000")
+		}
+		p.limit = n
+	}
+	return p, nil
+}
+
+// indices returns the positions, in list order, of the items the page
+// selects from a list of total items. With reverse the offset counts from
+// the end of the list and the positions run backwards.
+func (p listPage) indices(total int) []int {
+	n := total - p.offset
+	if n < 0 {
+		n = 0
+	}
+	if p.limit > 0 && n > p.limit {
+		n = p.limit
+	}
+
+	out := make([]int, n)
+	for i := range out {
+		if p.reverse {
+			out[i] = total - 1 - p.offset - i
+		} else {
+			out[i] = p.offset + i
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/p2p"
+)
+
+// ChainParams lists the consensus and relay rules this node enforces. Zero
+// limits mean the rule is not enforced.
+type ChainParams struct {
+	NetworkID           string              `json:"network_id"`
+	GenesisHash         string              `json:"genesis_hash"`
+	ProtocolVersion     int                 `json:"protocol_version"`
+	HashAlgorithm       string              `json:"hash_algorithm"`
+	SignatureAlgorithm  string              `json:"signature_algorithm"`
+	DifficultyAlgorithm string              `json:"difficulty_algorithm"`
+	Difficulty          int                 `json:"difficulty"` // required of the next block
+	InitialDifficulty   int                 `json:"initial_difficulty"`
+	RetargetInterval    int                 `json:"retarget_interval"`
+	TargetBlockTime     int64               `json:"target_block_time"` // seconds
+	MaxBlockSize        int                 `json:"max_block_size"`
+	BlockReward         float64             `json:"block_reward"`
+	RewardSchedule      string              `json:"reward_schedule"`
+	AmountDecimals      int                 `json:"amount_decimals"`
+	DustThreshold       float64             `json:"dust_threshold"`
+	RelayPolicy         chain.MempoolPolicy `json:"relay_policy"`
+}
+
+func (s *Server) chainParams() ChainParams {
+	genesis := s.blockchain.Genesis()
+	retarget := s.blockchain.RetargetPolicy()
+	hashAlgorithm, _ := consensus.ParseAlgorithm(s.blockchain.PowAlgorithm())
+	algorithm := "fixed"
+	if retarget.Enabled() {
+		algorithm = "retarget"
+	}
+	return ChainParams{
+		NetworkID:           s.blockchain.NetworkID(),
+		GenesisHash:         genesis.Hash,
+		ProtocolVersion:     p2p.ProtocolVersion,
+		HashAlgorithm:       string(hashAlgorithm),
+		SignatureAlgorithm:  "ecdsa-p256",
+		DifficultyAlgorithm: algorithm,
+		Difficulty:          s.blockchain.NextDifficulty(),
+		InitialDifficulty:   s.blockchain.Difficulty(),
+		RetargetInterval:    retarget.Interval,
+		TargetBlockTime:     int64(retarget.TargetBlockTime / time.Second),
+		MaxBlockSize:        0,
+		BlockReward:         s.blockchain.BlockReward(),
+		RewardSchedule:      "constant",
+		AmountDecimals:      chain.AmountDecimals,
+		DustThreshold:       0,
+		RelayPolicy:         s.mempool.Policy(),
+	}
+}
+
+func (s *Server) handleGetParams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.chainParams())
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/p2p"
+)
+
+type peersResponse struct {
+	Clock      *chain.ClockStatus   `json:"clock,omitempty"`
+	Dandelion  *p2p.DandelionStatus `json:"dandelion,omitempty"`
+	Enabled    bool                 `json:"enabled"`
+	ListenAddr string               `json:"listen_addr,omitempty"`
+	Peers      []p2p.PeerInfo       `json:"peers"`
+	Sync       *p2p.SyncStatus      `json:"sync,omitempty"`
+}
+
+type syncStatusResponse struct {
+	Enabled bool           `json:"enabled"`
+	Sync    p2p.SyncStatus `json:"sync"`
+	Synced  bool           `json:"synced"`
+}
+
+type banRequest struct {
+	Address  string `json:"address"`            // host or host:port
+	Reason   string `json:"reason,omitempty"`   // "banned by admin" when empty
+	Duration string `json:"duration,omitempty"` // Go duration; "0" bans for good
+}
+
+type bansResponse struct {
+	Bans []p2p.Ban `json:"bans"`
+}
+
+type peerRequest struct {
+	Address string `json:"address"` // host:port
+}
+
+type adminPeersResponse struct {
+	Outbound []string       `json:"outbound"` // addresses kept dialed
+	Peers    []p2p.PeerInfo `json:"peers"`
+}
+
+func (s *Server) SetNetwork(network *p2p.Network) {
+	s.network = network
+}
+
+func (s *Server) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := peersResponse{
+		Enabled: s.network != nil,
+		Peers:   []p2p.PeerInfo{},
+	}
+	if s.network != nil {
+		sync := s.network.SyncStatus()
+		response.ListenAddr = s.network.ListenAddr()
+		response.Peers = s.network.Peers()
+		response.Sync = &sync
+		response.Dandelion = s.network.DandelionStatus()
+		clock := s.blockchain.Clock().Status()
+		response.Clock = &clock
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSyncStatus reports initial block download progress: the node's
+// height against the best height any peer has shown, and the headers-first
+// sync in progress, if any.
+func (s *Server) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := p2p.SyncStatus{Height: s.blockchain.Height(), Progress: 1}
+	if s.network != nil {
+		status = s.network.SyncStatus()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&syncStatusResponse{
+		Enabled: s.network != nil,
+		Sync:    status,
+		Synced:  status.Height >= status.BestPeerHeight && !status.Active,
+	})
+}
+
+// handleBans lists (GET), adds (POST {"address", "reason", "duration"}) and
+// lifts (DELETE ?address=) peer bans. Duration is a Go duration string;
+// omitted means the default, "0" means permanent.
+func (s *Server) handleBans(w http.ResponseWriter, r *http.Request) {
+	if s.network == nil || s.network.Bans() == nil {
+		http.Error(w, "P2P networking not enabled", http.StatusConflict)
+		return
+	}
+	bans := s.network.Bans()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request banRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Address == "" {
+			http.Error(w, `Body must be {"address": "host[:port]", "reason": "...", "duration": "24h"}`, http.StatusBadRequest)
+			return
+		}
+		d := p2p.DefaultBanDuration
+		if request.Duration != "" {
+			parsed, err := time.ParseDuration(request.Duration)
+			if err != nil || parsed < 0 {
+				http.Error(w, "Invalid duration", http.StatusBadRequest)
+				return
+			}
+			d = parsed
+		}
+		if request.Reason == "" {
+			request.Reason = "banned by admin"
+		}
+		if _, err := s.network.BanPeer(request.Address, request.Reason, d); err != nil {
+			http.Error(w, "Failed to save ban list: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		requestLogger(r).Info("Admin banned peer", "peer", request.Address, "reason", request.Reason)
+	case http.MethodDelete:
+		address := r.URL.Query().Get("address")
+		if address == "" {
+			http.Error(w, "address is required", http.StatusBadRequest)
+			return
+		}
+		found, err := bans.Unban(address)
+		if err != nil {
+			http.Error(w, "Failed to save ban list: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "Peer is not banned", http.StatusNotFound)
+			return
+		}
+		requestLogger(r).Info("Admin unbanned peer", "peer", address)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&bansResponse{Bans: bans.List()})
+}
+
+// handleAdminPeers serves /admin/peers: GET lists connected peers and the
+// addresses kept dialed, POST {"address"} adds an address to keep dialed and
+// DELETE ?address= stops dialing it and drops connections to its host.
+func (s *Server) handleAdminPeers(w http.ResponseWriter, r *http.Request) {
+	if s.network == nil {
+		http.Error(w, "P2P networking not enabled", http.StatusConflict)
+		return
+	}
+
+	status := http.StatusOK
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request peerRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Address == "" {
+			http.Error(w, `Body must be {"address": "host:port"}`, http.StatusBadRequest)
+			return
+		}
+		if _, _, err := net.SplitHostPort(request.Address); err != nil {
+			http.Error(w, "Invalid address: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		added, err := s.network.AddPeer(request.Address)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if added {
+			status = http.StatusCreated
+			requestLogger(r).Info("Admin added peer", "peer", request.Address)
+		}
+	case http.MethodDelete:
+		address := r.URL.Query().Get("address")
+		if address == "" {
+			http.Error(w, "address is required", http.StatusBadRequest)
+			return
+		}
+		if !s.network.RemovePeer(address) {
+			http.Error(w, "Peer is not connected", http.StatusNotFound)
+			return
+		}
+		requestLogger(r).Info("Admin removed peer", "peer", address)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&adminPeersResponse{
+		Outbound: s.network.OutboundPeers(),
+		Peers:    s.network.Peers(),
+	})
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/quarantine"
+)
+
+// SetQuarantine keeps a copy of every transaction submitted over the API that
+// fails validation. The store can be switched on and off at runtime through
+// /admin/quarantine.
+func (s *Server) SetQuarantine(q *quarantine.Store) {
+	s.quarantine = q
+}
+
+type quarantineRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type quarantineResponse struct {
+	Configured bool              `json:"configured"`
+	Quarantine quarantine.Status `json:"quarantine"`
+}
+
+func (s *Server) handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request quarantineRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Enabled == nil {
+			http.Error(w, `Body must be {"enabled": true|false}`, http.StatusBadRequest)
+			return
+		}
+		if s.quarantine == nil {
+			http.Error(w, "Quarantine not configured (start node with -quarantine-dir)", http.StatusConflict)
+			return
+		}
+		s.quarantine.SetEnabled(*request.Enabled)
+		requestLogger(r).Info("Quarantine of rejected objects toggled by admin", "enabled", *request.Enabled)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := quarantineResponse{
+		Configured: s.quarantine != nil,
+		Quarantine: s.quarantine.Status(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitSweep is how often idle clients' buckets are dropped.
+const rateLimitSweep = time.Minute
+
+// RateLimit allows each client Requests requests per Per. A client that has
+// been quiet may send all of them at once; after that they come back
+// evenly over Per.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) String() string {
+	switch l.Per {
+	case time.Second:
+		return fmt.Sprintf("%d/s", l.Requests)
+	case time.Minute:
+		return fmt.Sprintf("%d/m", l.Requests)
+	case time.Hour:
+		return fmt.Sprintf("%d/h", l.Requests)
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// ParseRateLimit reads limits such as "10/s", "60/m", "500/h" or "20/30s".
+// An empty string or "0" means no limit, returned as the zero RateLimit.
+func ParseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return RateLimit{}, nil
+	}
+	count, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: want requests/unit, e.g. 60/m", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: invalid request count", s)
+	}
+	var per time.Duration
+	switch unit {
+	case "s":
+		per = time.Second
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		if per, err = time.ParseDuration(unit); err != nil || per <= 0 {
+			return RateLimit{}, fmt.Errorf("rate limit %q: invalid unit %q", s, unit)
+		}
+	}
+	return RateLimit{Requests: n, Per: per}, nil
+}
+
+// rateLimiter keeps a token bucket per client IP.
+type rateLimiter struct {
+	limit RateLimit
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	return &rateLimiter{limit: limit, buckets: make(map[string]*tokenBucket), lastSweep: time.Now()}
+}
+
+// allow takes a token from client's bucket. When it is empty it reports how
+// long until the next token.
+func (l *rateLimiter) allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= rateLimitSweep {
+		l.sweepLocked(now)
+	}
+
+	capacity := float64(l.limit.Requests)
+	perToken := l.limit.Per / time.Duration(l.limit.Requests)
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))/float64(perToken))
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) * float64(perToken))
+}
+
+// sweepLocked drops buckets that have refilled completely; a new bucket
+// for the same client would be identical.
+func (l *rateLimiter) sweepLocked(now time.Time) {
+	for client, b := range l.buckets {
+		if now.Sub(b.last) >= l.limit.Per {
+			delete(l.buckets, client)
+		}
+	}
+	l.lastSweep = now
+}
+
+// SetRateLimits limits how often one client IP may call each class of
+// endpoint: "transactions" (POST /transactions), "mine" (/mine) and
+// "wallet" (/api/wallet/...). Classes left out, or given the zero
+// RateLimit, are not limited.
+func (s *Server) SetRateLimits(limits map[string]RateLimit) {
+	s.rateLimits = make(map[string]*rateLimiter)
+	for class, limit := range limits {
+		if limit.Requests > 0 && limit.Per > 0 {
+			s.rateLimits[class] = newRateLimiter(limit)
+		}
+	}
+}
+
+// rateLimited answers 429 with Retry-After once a client has used up its
+// requests for class.
+func (s *Server) rateLimited(class string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limiter := s.rateLimits[class]
+		if limiter == nil || r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		client := s.clientIP(r)
+		if ok, wait := limiter.allow(client, time.Now()); !ok {
+			requestLogger(r).Debug("Rate limited", "class", class, "client", client, "retry_after", wait)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, fmt.Sprintf("Rate limit exceeded for %s requests (%s per client)", class, limiter.limit), http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// SetTrustedProxies makes rate limits tell clients apart by the address a
+// reverse proxy puts in X-Forwarded-For, for requests that arrive from one
+// of the given IPs or CIDR ranges. Other requests, and the header when they
+// carry it, are keyed on the connection's address as before.
+func (s *Server) SetTrustedProxies(entries []string) error {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return fmt.Errorf("trusted proxy %q: want an IP or CIDR range", entry)
+		}
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	s.trustedProxies = nets
+	return nil
+}
+
+func (s *Server) trustedProxy(ip net.IP) bool {
+	for _, n := range s.trustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP is the address r came from, without the port. A request from a
+// trusted proxy came from the last X-Forwarded-For hop that is not itself
+// a trusted proxy; the hops before it were written by the client and
+// prove nothing.
+func (s *Server) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !s.trustedProxy(ip) {
+		return host
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			break
+		}
+		ip = hop
+		if !s.trustedProxy(ip) {
+			break
+		}
+	}
+	return ip.String()
+}
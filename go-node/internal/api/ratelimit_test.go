@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	direct := &Server{}
+	proxied := &Server{}
+	if err := proxied.SetTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		name      string
+		remote    string
+		forwarded []string
+		direct    string // without trusted proxies
+		proxied   string // with them
+	}{
+		{"no header", "203.0.113.7:4000", nil, "203.0.113.7", "203.0.113.7"},
+		{"untrusted sender", "203.0.113.7:4000", []string{"198.51.100.1"}, "203.0.113.7", "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:4000", []string{"198.51.100.1"}, "10.1.2.3", "198.51.100.1"},
+		{"single trusted IP", "192.0.2.1:4000", []string{"198.51.100.1"}, "192.0.2.1", "198.51.100.1"},
+		{"IPv6 proxy", "[2001:db8::1]:4000", []string{"2001:db8::99"}, "2001:db8::1", "2001:db8::99"},
+		{"chain of proxies", "10.1.2.3:4000", []string{"198.51.100.1, 10.9.9.9"}, "10.1.2.3", "198.51.100.1"},
+		{"spoofed hops", "10.1.2.3:4000", []string{"1.1.1.1, 198.51.100.1"}, "10.1.2.3", "198.51.100.1"},
+		{"headers combined", "10.1.2.3:4000", []string{"1.1.1.1", "198.51.100.1"}, "10.1.2.3", "198.51.100.1"},
+		{"garbage hop", "10.1.2.3:4000", []string{"198.51.100.1, bogus"}, "10.1.2.3", "10.1.2.3"},
+		{"all hops trusted", "10.1.2.3:4000", []string{"10.4.4.4, 10.5.5.5"}, "10.1.2.3", "10.4.4.4"},
+		{"empty header", "10.1.2.3:4000", []string{""}, "10.1.2.3", "10.1.2.3"},
+		{"no port", "203.0.113.7", nil, "203.0.113.7", "203.0.113.7"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/wallet/list", nil)
+		r.RemoteAddr = c.remote
+		for _, v := range c.forwarded {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := direct.clientIP(r); got != c.direct {
+			t.Errorf("%s without trusted proxies: client %s, want %s", c.name, got, c.direct)
+		}
+		if got := proxied.clientIP(r); got != c.proxied {
+			t.Errorf("%s with trusted proxies: client %s, want %s", c.name, got, c.proxied)
+		}
+	}
+
+	if err := proxied.SetTrustedProxies([]string{"proxy.example"}); err == nil {
+		t.Error("SetTrustedProxies accepted a host name")
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// RecordedCall is one state-changing API request captured by a Recorder,
+// with its offset from the start of the recording.
+type RecordedCall struct {
+	OffsetMs int64           `json:"offset_ms"`
+	Method   string          `json:"method"`
+	Path     string          `json:"path"`
+	Body     json.RawMessage `json:"body,omitempty"`
+}
+
+type Recorder struct {
+	mu    sync.Mutex
+	file  *os.File
+	enc   *json.Encoder
+	start time.Time
+}
+
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		file:  file,
+		enc:   json.NewEncoder(file),
+		start: time.Now(),
+	}, nil
+}
+
+func (rec *Recorder) Close() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.file.Close()
+}
+
+func (rec *Recorder) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			rec.record(r.Method, r.URL.RequestURI(), body)
+		}
+		next(w, r)
+	}
+}
+
+func (rec *Recorder) record(method, path string, body []byte) {
+	call := RecordedCall{
+		OffsetMs: time.Since(rec.start).Milliseconds(),
+		Method:   method,
+		Path:     path,
+	}
+	if len(body) > 0 && json.Valid(body) {
+		call.Body = body
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.enc.Encode(call)
+}
+
+func ReadRecording(path string) ([]RecordedCall, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var calls []RecordedCall
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var call RecordedCall
+		if err := json.Unmarshal(line, &call); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	return calls, scanner.Err()
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/fees"
+	"ai-blockchain/go-node/internal/follower"
+	"ai-blockchain/go-node/internal/miner"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// Response types for the hot read endpoints. Fields are declared in
+// alphabetical order so the output matches the map-based encoding the
+// endpoints used before.
+
+type blocksResponse struct {
+	Blocks []*chain.Block `json:"blocks"`
+	Count  int            `json:"count"`
+	Offset int            `json:"offset"`
+	Total  int            `json:"total"`
+}
+
+type mempoolResponse struct {
+	Conflicts    []chain.MempoolConflict `json:"conflicts,omitempty"`
+	Count        int                     `json:"count"`
+	Offset       int                     `json:"offset"`
+	Total        int                     `json:"total"`
+	Policy       chain.MempoolPolicy     `json:"policy"`
+	Transactions []mempoolEntry          `json:"transactions"`
+}
+
+// mempoolEntry is a pending transaction with the fee, size and fee rate
+// the pool computed when admitting it.
+type mempoolEntry struct {
+	*chain.Transaction
+	chain.FeeInfo
+	AIScore  *chain.TxScore `json:"ai_score,omitempty"`
+	InFlight bool           `json:"in_flight,omitempty"` // in the block being mined
+}
+
+type chainResponse struct {
+	ChainWork   string              `json:"chain_work"`
+	Difficulty  int                 `json:"difficulty"`
+	Height      int                 `json:"height"`
+	RelayPolicy chain.MempoolPolicy `json:"relay_policy"`
+	StaleBlocks int                 `json:"stale_blocks"`
+	StaleRate   float64             `json:"stale_rate"`
+	Tip         *chain.Block        `json:"tip"`
+}
+
+type balanceResponse struct {
+	Address string  `json:"address"`
+	Balance float64 `json:"balance"`
+}
+
+// The remaining types replace maps the handlers used to build, with the
+// same keys.
+
+type generateWalletResponse struct {
+	Address   string `json:"address"`
+	Label     string `json:"label,omitempty"`
+	Message   string `json:"message"`
+	Note      string `json:"note"`
+	Owner     string `json:"owner,omitempty"`
+	PublicKey string `json:"public_key"`
+}
+
+type walletEntry struct {
+	wallet.WalletInfo
+	Balance float64 `json:"balance"` // confirmed
+}
+
+type walletListResponse struct {
+	Addresses []string      `json:"addresses"`
+	Count     int           `json:"count"`
+	Limit     int           `json:"limit"`
+	Offset    int           `json:"offset"`
+	Total     int           `json:"total"`
+	Wallets   []walletEntry `json:"wallets"`
+}
+
+type transferRequest struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+	URI    string  `json:"uri,omitempty"` // payment URI, replaces to/amount
+
+	TargetConfirmations int     `json:"target_confirmations,omitempty"`
+	MaxFee              float64 `json:"max_fee,omitempty"`
+	FreshChange         *bool   `json:"fresh_change,omitempty"` // default: true for HD keys, else -wallet-fresh-change
+
+	Memo            string `json:"memo,omitempty"`
+	EncryptMemo     bool   `json:"encrypt_memo,omitempty"`
+	RecipientPubKey string `json:"recipient_pubkey,omitempty"` // for encrypt_memo when the node doesn't know the key
+}
+
+type transferResponse struct {
+	ChangeAddress       string   `json:"change_address,omitempty"`
+	Fee                 float64  `json:"fee"`
+	MemoEncrypted       *bool    `json:"memo_encrypted,omitempty"`
+	Message             string   `json:"message"`
+	Status              string   `json:"status"`
+	TargetConfirmations int      `json:"target_confirmations,omitempty"`
+	TxID                string   `json:"txid"`
+	Warnings            []string `json:"warnings,omitempty"` // address reuse, small outputs over quota
+}
+
+// transferErrorResponse is a failed wallet transfer with a hint, the
+// transaction or its anomaly score, for the client to act on.
+type transferErrorResponse struct {
+	Error string   `json:"error"`
+	Hint  string   `json:"hint,omitempty"`
+	Score *float64 `json:"score,omitempty"`
+	TxID  string   `json:"txid,omitempty"`
+}
+
+// feeCapResponse refuses a transfer whose estimated fee is above max_fee.
+type feeCapResponse struct {
+	Amount float64       `json:"amount"`
+	Error  string        `json:"error"`
+	MaxFee float64       `json:"max_fee"`
+	Quote  fees.Estimate `json:"quote"`
+	Total  float64       `json:"total"`
+}
+
+type healthResponse struct {
+	AutoMine     *miner.AutoStatus `json:"auto_mine,omitempty"`
+	FreezeReason string            `json:"freeze_reason,omitempty"`
+	Frozen       bool              `json:"frozen"`
+	Height       int               `json:"height"`
+	Mempool      int               `json:"mempool"`
+	Peers        *int              `json:"peers,omitempty"` // with P2P enabled
+	Replication  *follower.Status  `json:"replication,omitempty"`
+	Role         string            `json:"role,omitempty"` // "replica" on a read replica
+	Status       string            `json:"status"`         // healthy or frozen
+	Timestamp    int64             `json:"timestamp"`
+}
+
+// projectedBlocksResponse is /blocks with ?fields=: each block has only the
+// keys asked for.
+type projectedBlocksResponse struct {
+	Blocks []map[string]interface{} `json:"blocks"`
+	Count  int                      `json:"count"`
+	Offset int                      `json:"offset"`
+	Total  int                      `json:"total"`
+}
+
+type projectedMempoolResponse struct {
+	Conflicts    []chain.MempoolConflict  `json:"conflicts,omitempty"`
+	Count        int                      `json:"count"`
+	Offset       int                      `json:"offset"`
+	Total        int                      `json:"total"`
+	Transactions []map[string]interface{} `json:"transactions"`
+}
+
+type staleBlocksResponse struct {
+	ChainWork   string             `json:"chain_work"`
+	Count       int                `json:"count"`
+	StaleBlocks []chain.StaleBlock `json:"stale_blocks"`
+	StaleRate   float64            `json:"stale_rate"`
+	Total       int                `json:"total"`
+}
+
+type txAcceptedResponse struct {
+	Message string `json:"message"`
+	Status  string `json:"status"`
+	TxID    string `json:"txid"`
+}
+
+type mineRequest struct {
+	MinerAddress string `json:"miner_address"` // coinbase recipient; the node's -miner-address when empty
+}
+
+type mineResponse struct {
+	Block        *chain.Block `json:"block"`
+	Dropped      []droppedTx  `json:"dropped,omitempty"`
+	Message      string       `json:"message"`
+	MinerAddress string       `json:"miner_address,omitempty"`
+	Reward       *float64     `json:"reward,omitempty"`
+	Time         string       `json:"time"`
+}
+
+type cancelMiningResponse struct {
+	Canceled bool   `json:"canceled"`
+	Message  string `json:"message"`
+}
+
+// maxPooledBuffer keeps one huge response (e.g. a full /blocks dump) from
+// pinning its buffer in the pool forever.
+const maxPooledBuffer = 4 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// writeJSON encodes v into a pooled buffer and writes it with an exact
+// Content-Length.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	writeJSONStatus(w, http.StatusOK, v)
+}
+
+// writeJSONStatus is writeJSON for responses other than 200 OK.
+func writeJSONStatus(w http.ResponseWriter, status int, v interface{}) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBuffer {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	defaultRichListLimit = 100
+	maxRichListLimit     = 1000
+)
+
+type richList struct {
+	height  int
+	tipHash string
+	holders []chain.AddressBalance
+}
+
+type richListResponse struct {
+	Height    int                    `json:"height"`
+	TipHash   string                 `json:"tip_hash"`
+	Holders   int                    `json:"holders"`
+	Addresses []chain.AddressBalance `json:"addresses"`
+}
+
+// handleRichList ranks addresses by confirmed balance. Totalling the whole
+// UTXO set is expensive, so the full ranking is cached and every limit is
+// served from it.
+func (s *Server) handleRichList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := defaultRichListLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRichListLimit {
+			http.Error(w, "limit must be an integer between 1 and 1000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	list := s.cached("richlist", func() interface{} {
+		holders, tip := s.blockchain.Holders()
+		return &richList{height: tip.Index + 1, tipHash: tip.Hash, holders: holders}
+	}).(*richList)
+
+	addresses := list.holders
+	if len(addresses) > limit {
+		addresses = addresses[:limit]
+	}
+	if addresses == nil {
+		addresses = []chain.AddressBalance{}
+	}
+	writeJSON(w, &richListResponse{
+		Height:    list.height,
+		TipHash:   list.tipHash,
+		Holders:   len(list.holders),
+		Addresses: addresses,
+	})
+}
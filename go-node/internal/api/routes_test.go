@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Every route the server registers is documented in apiOperations, and
+// every documented operation reaches a route, both under apiPrefix and at
+// the deprecated unversioned path.
+func TestRoutesMatchAPIOperations(t *testing.T) {
+	s := &Server{}
+	mux := s.routes()
+	root := s.versioned(mux).(*http.ServeMux)
+
+	documented := make(map[string]bool)
+	for _, op := range apiOperations {
+		path := pathParamPattern.ReplaceAllString(op.path, "x")
+		for _, prefix := range []string{apiPrefix, ""} {
+			r := httptest.NewRequest(op.method, prefix+path, nil)
+			if _, pattern := root.Handler(r); pattern != prefix+"/" {
+				t.Errorf("%s %s%s is served by %q, not the API", op.method, prefix, op.path, pattern)
+			}
+		}
+		_, pattern := mux.Handler(httptest.NewRequest(op.method, path, nil))
+		switch {
+		case pattern == "":
+			t.Errorf("%s %s has no route", op.method, op.path)
+		case !strings.HasSuffix(pattern, "/") && pattern != op.path:
+			t.Errorf("%s %s is routed to %s", op.method, op.path, pattern)
+		}
+		documented[pattern] = true
+	}
+
+	for _, pattern := range mux.patterns {
+		if !documented[pattern] {
+			t.Errorf("route %s has no entry in apiOperations", pattern)
+		}
+	}
+}
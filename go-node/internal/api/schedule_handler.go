@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/scheduler"
+)
+
+func (s *Server) Scheduler() *scheduler.Scheduler {
+	return s.scheduler
+}
+
+func (s *Server) submitScheduledTransfer(from, to string, amount float64) (string, error) {
+	tx, terr := s.submitTransfer(from, to, amount, 0, from, nil)
+	if terr != nil {
+		return "", terr
+	}
+	return tx.ID, nil
+}
+
+type schedulesResponse struct {
+	Count     int                  `json:"count"`
+	Schedules []scheduler.Schedule `json:"schedules"`
+}
+
+type scheduleRequest struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Amount   float64 `json:"amount"`
+	Interval string  `json:"interval"` // Go duration, e.g. "1h"
+	StartAt  int64   `json:"start_at,omitempty"`
+}
+
+func (s *Server) handleSchedules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		schedules := s.scheduler.List()
+
+		response := schedulesResponse{
+			Count:     len(schedules),
+			Schedules: schedules,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+
+	case http.MethodPost:
+		var request scheduleRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		interval, err := time.ParseDuration(request.Interval)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid interval: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if s.walletStore.GetWallet(request.From) == nil {
+			http.Error(w, "Wallet not found", http.StatusNotFound)
+			return
+		}
+		if !s.requireUnlocked(w, r, request.From) {
+			return
+		}
+		to, err := s.walletStore.ResolveRecipient(request.From, request.To)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid recipient: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var startAt time.Time
+		if request.StartAt > 0 {
+			startAt = time.Unix(request.StartAt, 0)
+		}
+
+		sched, err := s.scheduler.Add(request.From, to, request.Amount, interval, startAt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sched)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleScheduleAction serves POST /api/wallet/schedules/:id/(pause|resume|cancel).
+func (s *Server) handleScheduleAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/wallet/schedules/"), "/")
+	if len(parts) != 2 || parts[0] == "" {
+		http.Error(w, "Expected /api/wallet/schedules/:id/(pause|resume|cancel)", http.StatusNotFound)
+		return
+	}
+
+	var sched scheduler.Schedule
+	var err error
+	switch parts[1] {
+	case "pause":
+		sched, err = s.scheduler.Pause(parts[0])
+	case "resume":
+		// Resuming sends payments from the schedule's wallet again.
+		if sched, err = s.scheduler.Get(parts[0]); err == nil {
+			if !s.requireUnlocked(w, r, sched.From) {
+				return
+			}
+			sched, err = s.scheduler.Resume(parts[0])
+		}
+	case "cancel":
+		sched, err = s.scheduler.Cancel(parts[0])
+	default:
+		http.Error(w, "Unknown action: "+parts[1], http.StatusNotFound)
+		return
+	}
+
+	if errors.Is(err, scheduler.ErrScheduleNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sched)
+}
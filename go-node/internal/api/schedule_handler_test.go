@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-blockchain/go-node/internal/scheduler"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// Resuming a schedule needs an unlock session for its wallet, as creating
+// one does; pausing and cancelling only stop payments and need none.
+func TestResumeScheduleRequiresUnlock(t *testing.T) {
+	s := &Server{scheduler: scheduler.New(nil), sessions: wallet.NewSessions(), requireUnlock: true}
+	from := strings.Repeat("a", 64)
+	sched, err := s.scheduler.Add(from, strings.Repeat("b", 64), 1, time.Hour, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := s.sessions.Unlock(strings.Repeat("c", 64), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := s.sessions.Unlock(from, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action := func(id, name, token string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/wallet/schedules/"+id+"/"+name, nil)
+		if token != "" {
+			r.Header.Set(sessionHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		s.handleScheduleAction(rec, r)
+		return rec.Code
+	}
+
+	if code := action(sched.ID, "pause", ""); code != http.StatusOK {
+		t.Fatalf("pause without a session: status %d, want 200", code)
+	}
+	for _, token := range []string{"", other.Token} {
+		if code := action(sched.ID, "resume", token); code != http.StatusUnauthorized {
+			t.Errorf("resume with session %q: status %d, want 401", token, code)
+		}
+	}
+	if got, _ := s.scheduler.Get(sched.ID); got.Status != scheduler.StatusPaused {
+		t.Fatalf("status %s after refused resumes, want paused", got.Status)
+	}
+	if code := action(sched.ID, "resume", owner.Token); code != http.StatusOK {
+		t.Fatalf("resume with the owner's session: status %d, want 200", code)
+	}
+	if code := action("missing", "resume", ""); code != http.StatusNotFound {
+		t.Errorf("resume of an unknown schedule: status %d, want 404", code)
+	}
+}
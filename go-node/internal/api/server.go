@@ -1,76 +1,315 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"ai-blockchain/go-node/internal/ai"
+	"ai-blockchain/go-node/internal/analytics"
+	"ai-blockchain/go-node/internal/archive"
 	"ai-blockchain/go-node/internal/chain"
-	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/cluster"
+	"ai-blockchain/go-node/internal/fees"
+	"ai-blockchain/go-node/internal/follower"
+	"ai-blockchain/go-node/internal/graphql"
+	"ai-blockchain/go-node/internal/metrics"
+	"ai-blockchain/go-node/internal/miner"
+	"ai-blockchain/go-node/internal/notify"
+	"ai-blockchain/go-node/internal/p2p"
+	"ai-blockchain/go-node/internal/quarantine"
+	"ai-blockchain/go-node/internal/scheduler"
 	"ai-blockchain/go-node/internal/wallet"
 )
 
 type Server struct {
-	blockchain *chain.Blockchain
-	mempool    *chain.Mempool
-	aiClient   *ai.Client
-	difficulty int
-	port       string
-	walletStore *wallet.WalletStore
+	blockchain     *chain.Blockchain
+	mempool        *chain.Mempool
+	blocks         *chain.BlockPipeline
+	aiClient       *ai.Client
+	port           string
+	listen         []string // addresses to serve on; every interface on port when empty
+	walletStore    *wallet.WalletStore
+	recorder       *Recorder
+	adminToken     string
+	adminJWTSecret []byte
+	adminKeys      map[string]string // key name → token
+	approvals      *approvalBook
+	rateLimits     map[string]*rateLimiter // endpoint class → per-client limiter
+	trustedProxies []*net.IPNet            // reverse proxies whose X-Forwarded-For names the client
+	limiter        *concurrencyLimiter
+	miner          *miner.Miner
+	autoMiner      *miner.AutoMiner
+	scheduler      *scheduler.Scheduler
+	cluster        *cluster.Monitor
+	follower       *follower.Follower
+	network        *p2p.Network
+	fees           *fees.Estimator
+	clusters       *analytics.Clusterer
+	quarantine     *quarantine.Store
+	notifyQueue    *notify.DeliveryQueue
+	archive        *archive.Archive
+	wsClients      atomic.Int64
+	sessions       *wallet.Sessions
+	gqlSchema      *graphql.Schema
+	cache          *QueryCache
+	metrics        *metrics.Registry
+
+	lifecycle  sync.Mutex
+	httpServer *http.Server
+	closing    chan struct{} // closed by Shutdown
+	closeOnce  sync.Once
+
+	requireUnlock bool
+	freshChange   bool
+	consolidation wallet.ConsolidationPolicy
+
+	minerAddress string
+
+	build    BuildInfo
+	features NodeFeatures
+	selfTest *SelfTestReport
 }
 
 func NewServer(
 	blockchain *chain.Blockchain,
 	mempool *chain.Mempool,
+	blocks *chain.BlockPipeline,
 	aiClient *ai.Client,
-	difficulty int,
 	port string,
 	walletStore *wallet.WalletStore,
 ) *Server {
-	return &Server{
-		blockchain: blockchain,
-		mempool:    mempool,
-		aiClient:   aiClient,
-		difficulty: difficulty,
-		port:       port,
-		walletStore: walletStore,
+	s := &Server{
+		blockchain:    blockchain,
+		mempool:       mempool,
+		blocks:        blocks,
+		aiClient:      aiClient,
+		port:          port,
+		walletStore:   walletStore,
+		miner:         miner.New(blockchain, mempool, blocks),
+		fees:          fees.NewEstimator(blockchain, mempool),
+		sessions:      wallet.NewSessions(),
+		consolidation: wallet.DefaultConsolidationPolicy(),
+		closing:       make(chan struct{}),
+		limiter: newConcurrencyLimiter(ConcurrencyLimits{
+			Global:      DefaultHeavyConcurrency,
+			PerEndpoint: map[string]int{"mine": 1},
+		}),
 	}
+	s.scheduler = scheduler.New(s.submitScheduledTransfer)
+	s.gqlSchema = s.newGraphQLSchema()
+	return s
 }
 
 func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+apiKeyHeader+", "+sessionHeader)
+
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
-		
+
 		next(w, r)
 	}
 }
 
+// SetMinerAddress sets the default coinbase recipient for /mine requests that
+// don't name one.
+func (s *Server) SetMinerAddress(address string) {
+	s.minerAddress = address
+}
+
+// SetFreshChange makes wallet transfers send their change to a newly
+// generated wallet instead of back to the sender, unless a request says
+// otherwise.
+func (s *Server) SetFreshChange(fresh bool) {
+	s.freshChange = fresh
+}
+
+// SetConsolidationPolicy sets the soft quota on small outputs per address
+// above which the node suggests consolidating them.
+func (s *Server) SetConsolidationPolicy(policy wallet.ConsolidationPolicy) {
+	s.consolidation = policy
+}
+
+// SetMetrics serves reg on GET /metrics for Prometheus to scrape.
+func (s *Server) SetMetrics(reg *metrics.Registry) {
+	s.metrics = reg
+}
+
+func (s *Server) SetMiningRefreshPolicy(policy miner.RefreshPolicy) {
+	s.miner.SetRefreshPolicy(policy)
+}
+
+func (s *Server) Miner() *miner.Miner {
+	return s.miner
+}
+
+// SetAutoMiner reports the background miner's progress in /health.
+func (s *Server) SetAutoMiner(a *miner.AutoMiner) {
+	s.autoMiner = a
+}
+
+func (s *Server) SetRecorder(rec *Recorder) {
+	s.recorder = rec
+}
+
+func (s *Server) recorded(next http.HandlerFunc) http.HandlerFunc {
+	if s.recorder == nil {
+		return next
+	}
+	return s.recorder.Wrap(next)
+}
+
+// routeMux is a ServeMux that remembers its patterns, so tests can hold
+// the routes against apiOperations.
+type routeMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *routeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+// Start serves the API until Shutdown is called, returning nil in that case.
 func (s *Server) Start() error {
-	http.HandleFunc("/health", corsMiddleware(s.handleHealth))
-	http.HandleFunc("/blocks", corsMiddleware(s.handleGetBlocks))
-	http.HandleFunc("/chain", corsMiddleware(s.handleGetChain))
-	http.HandleFunc("/mempool", corsMiddleware(s.handleGetMempool))
-	http.HandleFunc("/transactions", corsMiddleware(s.handlePostTransaction))
-	http.HandleFunc("/mine", corsMiddleware(s.handleMine))
-	http.HandleFunc("/balance/", corsMiddleware(s.handleGetBalance))
-	
-	http.HandleFunc("/api/wallet/generate", corsMiddleware(s.handleGenerateWallet))
-	http.HandleFunc("/api/wallet/list", corsMiddleware(s.handleListWallets))
-	http.HandleFunc("/api/wallet/transfer", corsMiddleware(s.handleTransfer))
-
-	addr := ":" + s.port
-	log.Printf("Starting API server on %s (CORS enabled)", addr)
-	return http.ListenAndServe(addr, nil)
+	mux := s.routes()
+
+	addrs := s.listen
+	if len(addrs) == 0 {
+		addrs = []string{":" + s.port}
+	}
+	srv := &http.Server{Handler: withRequestID(s.versioned(mux))}
+	s.lifecycle.Lock()
+	if s.httpServer != nil {
+		s.lifecycle.Unlock()
+		return errors.New("API server already started")
+	}
+	s.httpServer = srv
+	s.lifecycle.Unlock()
+
+	listeners, err := ListenAll(addrs)
+	if err != nil {
+		return err
+	}
+	slog.Info("Starting API server (CORS enabled)", "addr", strings.Join(listenerNames(listeners), ","))
+	return ServeAll(srv, listeners)
+}
+
+// routes registers every endpoint, unversioned; versioned mounts them under
+// apiPrefix.
+func (s *Server) routes() *routeMux {
+	mux := &routeMux{ServeMux: http.NewServeMux()}
+	mux.HandleFunc("/health", corsMiddleware(s.handleHealth))
+	mux.HandleFunc("/blocks", corsMiddleware(s.heavy("blocks", s.handleGetBlocks)))
+	mux.HandleFunc("/headers", corsMiddleware(s.handleGetHeaders))
+	mux.HandleFunc("/blocks/stale", corsMiddleware(s.handleGetStaleBlocks))
+	mux.HandleFunc("/chain", corsMiddleware(s.handleGetChain))
+	mux.HandleFunc("/stats", corsMiddleware(s.handleStats))
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/richlist", corsMiddleware(s.heavy("richlist", s.handleRichList)))
+	mux.HandleFunc("/miners", corsMiddleware(s.heavy("miners", s.handleMiners)))
+	mux.HandleFunc("/params", corsMiddleware(s.handleGetParams))
+	mux.HandleFunc("/version", corsMiddleware(s.handleVersion))
+	mux.HandleFunc("/consensus/simulate", corsMiddleware(s.heavy("simulate", s.handleSimulateDifficulty)))
+	mux.HandleFunc("/mempool", corsMiddleware(s.handleGetMempool))
+	mux.HandleFunc("/transactions", corsMiddleware(s.rateLimited("transactions", s.whenLive(s.recorded(s.handlePostTransaction)))))
+	mux.HandleFunc("/transactions/", corsMiddleware(s.handleGetTransaction))
+	mux.HandleFunc("/analytics/cluster/", corsMiddleware(s.heavy("analytics", s.handleAddressCluster)))
+	mux.HandleFunc("/graphql", corsMiddleware(s.heavy("graphql", s.handleGraphQL)))
+	mux.HandleFunc("/mine", corsMiddleware(s.rateLimited("mine", s.sensitive(s.whenLive(s.recorded(s.heavy("mine", s.handleMine)))))))
+	mux.HandleFunc("/mine/cancel", corsMiddleware(s.sensitive(s.handleCancelMining)))
+	mux.HandleFunc("/balance/", corsMiddleware(s.handleGetBalance))
+	mux.HandleFunc("/address/", corsMiddleware(s.handleAddressHistory))
+	mux.HandleFunc("/api/address/validate", corsMiddleware(s.handleValidateAddress))
+
+	mux.HandleFunc("/api/wallet/generate", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.whenLive(s.handleGenerateWallet)))))
+	mux.HandleFunc("/api/wallet/hd", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.handleHDWallets)))))
+	hdWallet := s.sensitive(s.writesWhenLive(s.handleHDWallet))
+	revealMnemonic := s.adminOnly(s.whenLive(s.handleRevealMnemonic))
+	mux.HandleFunc("/api/wallet/hd/", corsMiddleware(s.rateLimited("wallet", func(w http.ResponseWriter, r *http.Request) {
+		if isMnemonicPath(r) {
+			revealMnemonic(w, r)
+			return
+		}
+		hdWallet(w, r)
+	})))
+	mux.HandleFunc("/api/wallet/", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.recorded(s.handleWalletAddress))))))
+	mux.HandleFunc("/api/wallet/multisig", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.handleMultisig)))))
+	mux.HandleFunc("/api/wallet/multisig/sign", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleMultisigSign))))
+	mux.HandleFunc("/api/wallet/watch", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.handleWatch)))))
+	mux.HandleFunc("/api/wallet/import", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.whenLive(s.handleImportKey)))))
+	mux.HandleFunc("/api/wallet/export/", corsMiddleware(s.rateLimited("wallet", s.adminOnly(s.handleExportKey))))
+	mux.HandleFunc("/api/wallet/list", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleListWallets))))
+	mux.HandleFunc("/api/wallet/contacts", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.handleContacts)))))
+	mux.HandleFunc("/api/wallet/schedules", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.writesWhenLive(s.handleSchedules)))))
+	mux.HandleFunc("/api/wallet/schedules/", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.whenLive(s.handleScheduleAction)))))
+	mux.HandleFunc("/api/wallet/uri", corsMiddleware(s.rateLimited("wallet", s.handleMakePaymentURI)))
+	mux.HandleFunc("/api/wallet/uri/parse", corsMiddleware(s.rateLimited("wallet", s.handleParsePaymentURI)))
+	mux.HandleFunc("/api/wallet/transfer", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.whenLive(s.recorded(s.handleTransfer))))))
+	mux.HandleFunc("/api/wallet/history", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleWalletHistory))))
+	mux.HandleFunc("/api/wallet/derive", corsMiddleware(s.rateLimited("wallet", s.handleDeriveAddress)))
+	mux.HandleFunc("/api/wallet/build", corsMiddleware(s.rateLimited("wallet", s.handleBuildTransaction)))
+	mux.HandleFunc("/api/wallet/sign", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleSignTransaction))))
+	mux.HandleFunc("/api/wallet/unlock", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleUnlockWallet))))
+	mux.HandleFunc("/api/wallet/lock", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleLockWallet))))
+	mux.HandleFunc("/api/wallet/store", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleWalletStore))))
+	mux.HandleFunc("/api/wallet/store/lock", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleLockWalletStore))))
+	mux.HandleFunc("/api/wallet/store/unlock", corsMiddleware(s.rateLimited("wallet", s.sensitive(s.handleUnlockWalletStore))))
+
+	mux.HandleFunc("/cluster/status", corsMiddleware(s.heavy("cluster", s.handleClusterStatus)))
+	mux.HandleFunc("/fees/estimate", corsMiddleware(s.handleFeeEstimate))
+	mux.HandleFunc("/mining/proposal", corsMiddleware(s.heavy("proposal", s.handleMiningProposal)))
+	mux.HandleFunc("/peers", corsMiddleware(s.handlePeers))
+	mux.HandleFunc("/sync/status", corsMiddleware(s.handleSyncStatus))
+	mux.HandleFunc("/archive", corsMiddleware(s.handleArchive))
+	mux.HandleFunc("/archive/", corsMiddleware(s.handleArchive))
+	mux.HandleFunc("/ws", s.handleWebSocket)
+
+	mux.HandleFunc("/debug/canonicalize", corsMiddleware(s.handleCanonicalize))
+
+	mux.HandleFunc("/admin/freeze", corsMiddleware(s.adminOnly(s.twoPerson(s.handleFreeze))))
+	mux.HandleFunc("/admin/unfreeze", corsMiddleware(s.adminOnly(s.handleUnfreeze)))
+	mux.HandleFunc("/admin/quarantine", corsMiddleware(s.adminOnly(s.handleQuarantine)))
+	mux.HandleFunc("/admin/bans", corsMiddleware(s.adminOnly(s.handleBans)))
+	mux.HandleFunc("/admin/peers", corsMiddleware(s.adminOnly(s.handleAdminPeers)))
+	mux.HandleFunc("/admin/notifications", corsMiddleware(s.adminOnly(s.handleNotifications)))
+	mux.HandleFunc("/admin/mempool/clear", corsMiddleware(s.adminOnly(s.twoPerson(s.handleClearMempool))))
+	mux.HandleFunc("/admin/reindex", corsMiddleware(s.adminOnly(s.twoPerson(s.handleReindex))))
+	mux.HandleFunc("/admin/proposals", corsMiddleware(s.adminOnly(s.handleProposals)))
+	mux.HandleFunc("/admin/proposals/", corsMiddleware(s.adminOnly(s.handleProposals)))
+	mux.HandleFunc("/admin/export", corsMiddleware(s.adminOnly(s.heavy("export", s.handleExport))))
+	return mux
+}
+
+// Shutdown stops the node's API: mining jobs are aborted first so a /mine
+// request does not hold the server open, WebSocket streams are closed, and
+// in-flight requests get until ctx expires to finish.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closing) })
+	s.miner.Stop()
+
+	s.lifecycle.Lock()
+	srv := s.httpServer
+	s.lifecycle.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -79,11 +318,34 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now().Unix(),
-		"height":    s.blockchain.Height(),
-		"mempool":   s.mempool.Size(),
+	status := "healthy"
+	frozen, reason := s.blockchain.Frozen()
+	if frozen {
+		status = "frozen"
+	}
+
+	response := healthResponse{
+		Status:    status,
+		Timestamp: time.Now().Unix(),
+		Height:    s.blockchain.Height(),
+		Mempool:   s.mempool.Size(),
+		Frozen:    frozen,
+	}
+	if frozen {
+		response.FreezeReason = reason
+	}
+	if s.network != nil {
+		peers := s.network.PeerCount()
+		response.Peers = &peers
+	}
+	if s.follower != nil {
+		replication := s.follower.Status()
+		response.Role = "replica"
+		response.Replication = &replication
+	}
+	if s.autoMiner != nil {
+		autoMine := s.autoMiner.Status()
+		response.AutoMine = &autoMine
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -96,11 +358,56 @@ func (s *Server) handleGetBlocks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	blocks := s.blockchain.Blocks
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	all := s.blockchain.BlocksFrom(0, 0)
+	positions := page.indices(len(all))
+	blocks := make([]*chain.Block, len(positions))
+	for i, pos := range positions {
+		blocks[i] = all[pos]
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if wantsBinary(r) {
+		writeBinary(w, chain.EncodeBlocks(blocks))
+		return
+	}
 
-	response := map[string]interface{}{
-		"blocks": blocks,
-		"count":  len(blocks),
+	fields, err := requestedFields(r, blockFieldNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if fields != nil {
+		writeJSON(w, &projectedBlocksResponse{
+			Blocks: projectBlocks(blocks, fields),
+			Count:  len(blocks),
+			Offset: page.offset,
+			Total:  len(all),
+		})
+		return
+	}
+
+	writeJSON(w, &blocksResponse{Blocks: blocks, Count: len(blocks), Offset: page.offset, Total: len(all)})
+}
+
+func (s *Server) handleGetStaleBlocks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stale := s.blockchain.Stale.List()
+
+	response := staleBlocksResponse{
+		StaleBlocks: stale,
+		Count:       len(stale),
+		Total:       s.blockchain.Stale.Total(),
+		StaleRate:   s.blockchain.StaleRate(),
+		ChainWork:   s.blockchain.ChainWork().String(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -115,14 +422,23 @@ func (s *Server) handleGetChain(w http.ResponseWriter, r *http.Request) {
 
 	tip := s.blockchain.Tip()
 
-	response := map[string]interface{}{
-		"height":    s.blockchain.Height(),
-		"tip":       tip,
-		"difficulty": s.difficulty,
-	}
+	writeJSON(w, &chainResponse{
+		ChainWork:   s.blockchain.ChainWork().String(),
+		Difficulty:  s.blockchain.NextDifficulty(),
+		Height:      s.blockchain.Height(),
+		RelayPolicy: s.mempool.Policy(),
+		StaleBlocks: s.blockchain.Stale.Total(),
+		StaleRate:   s.blockchain.StaleRate(),
+		Tip:         tip,
+	})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil {
+		http.NotFound(w, r)
+		return
+	}
+	s.metrics.Handler().ServeHTTP(w, r)
 }
 
 func (s *Server) handleGetMempool(w http.ResponseWriter, r *http.Request) {
@@ -131,15 +447,53 @@ func (s *Server) handleGetMempool(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txs := s.mempool.GetTransactions()
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	all := s.mempool.GetTransactionsByFee(0)
+	positions := page.indices(len(all))
+	txs := make([]*chain.Transaction, len(positions))
+	for i, pos := range positions {
+		txs[i] = all[pos]
+	}
 
-	response := map[string]interface{}{
-		"transactions": txs,
-		"count":        len(txs),
+	w.Header().Add("Vary", "Accept")
+	if wantsBinary(r) {
+		writeBinary(w, chain.EncodeTransactions(txs))
+		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	fields, err := requestedFields(r, mempoolFieldNames)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conflicts := s.mempool.Conflicts()
+	if fields != nil {
+		writeJSON(w, &projectedMempoolResponse{
+			Conflicts:    conflicts,
+			Count:        len(txs),
+			Offset:       page.offset,
+			Total:        len(all),
+			Transactions: s.projectMempool(txs, fields),
+		})
+		return
+	}
+
+	entries := make([]mempoolEntry, len(txs))
+	for i, tx := range txs {
+		entries[i] = s.mempoolEntry(tx)
+	}
+	writeJSON(w, &mempoolResponse{
+		Conflicts:    conflicts,
+		Count:        len(txs),
+		Offset:       page.offset,
+		Total:        len(all),
+		Policy:       s.mempool.Policy(),
+		Transactions: entries,
+	})
 }
 
 func (s *Server) handlePostTransaction(w http.ResponseWriter, r *http.Request) {
@@ -154,35 +508,47 @@ func (s *Server) handlePostTransaction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := chain.VerifyTransaction(&tx, s.blockchain.UTXO); err != nil {
+	if err := chain.VerifyTransaction(&tx, s.inputView(&tx)); err != nil {
+		s.quarantine.Record(quarantine.KindTransaction, tx.ID, &tx, err.Error(), "api:"+r.RemoteAddr)
 		http.Error(w, fmt.Sprintf("Invalid transaction: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	err := s.checkRelayFee(&tx)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Transaction rejected by relay policy: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var score *ai.ScoreResponse
 	if s.aiClient != nil {
-		score, err := s.aiClient.ScoreTransaction(&tx)
+		score, err = s.aiClient.ScoreTransaction(&tx, "/transactions")
 		if err != nil {
-			log.Printf("AI scoring failed: %v (continuing anyway)", err)
+			requestLogger(r).Warn("AI scoring failed, continuing without a score", "txid", tx.ID, "err", err)
 		} else {
-			log.Printf("Transaction %s scored: anomaly=%.2f, fee_adequacy=%.2f",
-				tx.ID, score.AnomalyScore, score.FeeAdequacy)
-			
-			if score.AnomalyScore > 0.7 {
+			requestLogger(r).Info("Transaction scored", "txid", tx.ID, "anomaly", score.AnomalyScore, "fee_adequacy", score.FeeAdequacy)
+
+			if score.AnomalyScore > anomalyRejectThreshold {
+				s.aiClient.RecordRejection("/transactions")
 				http.Error(w, "Transaction flagged as anomalous by AI", http.StatusBadRequest)
 				return
 			}
 		}
 	}
 
-	if err := s.mempool.AddTransaction(&tx); err != nil {
+	if err := s.blocks.AddTransaction(&tx); errors.Is(err, chain.ErrPipelineStopped) {
+		http.Error(w, "Node is shutting down", http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to add transaction: %v", err), http.StatusConflict)
 		return
 	}
+	s.rememberScore(tx.ID, score)
 
-	response := map[string]interface{}{
-		"status":  "accepted",
-		"txid":    tx.ID,
-		"message": "Transaction added to mempool",
+	response := txAcceptedResponse{
+		Status:  "accepted",
+		TxID:    tx.ID,
+		Message: "Transaction added to mempool",
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -196,64 +562,92 @@ func (s *Server) handleMine(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	txs := s.mempool.GetTransactions()
-	if len(txs) == 0 {
-		http.Error(w, "No transactions in mempool", http.StatusBadRequest)
+	var request mineRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	txSlice := make([]chain.Transaction, len(txs))
-	for i, tx := range txs {
-		txSlice[i] = *tx
+	rewardAddress := request.MinerAddress
+	if rewardAddress == "" {
+		rewardAddress = s.minerAddress
+	}
+	if rewardAddress != "" {
+		if err := wallet.ValidateAddress(rewardAddress); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid miner address: %v", err), http.StatusBadRequest)
+			return
+		}
 	}
 
-	tip := s.blockchain.Tip()
-
-	block := chain.NewBlock(
-		tip.Index+1,
-		tip.Hash,
-		txSlice,
-	)
-
-	log.Printf("Mining block %d with difficulty %d...", block.Index, s.difficulty)
 	startTime := time.Now()
-	
-	computeHashFunc := func(nonce int64) string {
-		block.Nonce = nonce
-		return block.ComputeHash()
-	}
-	setNonceFunc := func(nonce int64) {
-		block.Nonce = nonce
-	}
-	
-	hash, nonce := consensus.MineBlock(computeHashFunc, setNonceFunc, s.difficulty)
-	if hash == "" {
+	dropped := s.dropAnomalous(r)
+
+	// Produce queues behind any mining job already running, so concurrent
+	// requests each get their own height instead of colliding on one tip.
+	// A client that disconnects cancels its job.
+	block, err := s.miner.Produce(r.Context(), rewardAddress)
+	if r.Context().Err() != nil {
+		requestLogger(r).Info("Mining request abandoned by client", "err", err)
+		return
+	} else if errors.Is(err, miner.ErrNoTransactions) {
+		http.Error(w, "No transactions in mempool", http.StatusBadRequest)
+		return
+	} else if errors.Is(err, miner.ErrMiningStopped) {
+		http.Error(w, "Node is shutting down", http.StatusServiceUnavailable)
+		return
+	} else if errors.Is(err, miner.ErrMiningCanceled) {
+		http.Error(w, "Mining canceled", http.StatusConflict)
+		return
+	} else if errors.Is(err, miner.ErrStaleBlock) {
+		http.Error(w, "Mined block is stale: chain tip changed during mining", http.StatusConflict)
+		return
+	} else if errors.Is(err, chain.ErrChainFrozen) {
+		http.Error(w, fmt.Sprintf("Failed to add block: %v", err), http.StatusServiceUnavailable)
+		return
+	} else if errors.Is(err, miner.ErrBlockRejected) {
+		requestLogger(r).Error("Mined block failed validation", "height", block.Index, "hash", block.Hash, "err", err)
+		http.Error(w, fmt.Sprintf("Mined block failed validation: %v", err), http.StatusInternalServerError)
+		return
+	} else if err != nil {
 		http.Error(w, "Failed to mine block", http.StatusInternalServerError)
 		return
 	}
-	
-	block.Hash = hash
-	block.Nonce = nonce
 
 	duration := time.Since(startTime)
-	log.Printf("Block %d mined in %v (hash: %s)", block.Index, duration, block.Hash)
-
-	s.blockchain.AddBlock(block)
+	requestLogger(r).Info("Block mined", "height", block.Index, "hash", block.Hash, "txs", len(block.Transactions), "duration", duration)
 
-	for _, tx := range txs {
-		s.mempool.RemoveTransaction(tx.ID)
+	response := mineResponse{
+		Block:   block,
+		Message: "Block mined successfully",
+		Time:    duration.String(),
+		Dropped: dropped,
 	}
-
-	response := map[string]interface{}{
-		"block":   block,
-		"message": "Block mined successfully",
-		"time":    duration.String(),
+	if rewardAddress != "" {
+		reward := block.Transactions[0].Outputs[0].Amount
+		response.MinerAddress = rewardAddress
+		response.Reward = &reward
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleCancelMining aborts the block currently being mined, whether it was
+// started by POST /mine or the auto-miner.
+func (s *Server) handleCancelMining(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	canceled := s.miner.Cancel()
+	message := "No block is being mined"
+	if canceled {
+		message = "Mining canceled"
+	}
+	writeJSON(w, &cancelMiningResponse{Canceled: canceled, Message: message})
+}
+
 func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -266,14 +660,23 @@ func (s *Server) handleGetBalance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	balance := s.blockchain.UTXO.BalanceOf(address)
+	balance := s.confirmedBalance(address)
 
-	response := map[string]interface{}{
-		"address": address,
-		"balance": balance,
-	}
+	writeJSON(w, &balanceResponse{Address: address, Balance: balance})
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+// inputView holds the outputs tx spends, confirmed or created by pending
+// transactions, for checks made before handing it to the pipeline.
+func (s *Server) inputView(tx *chain.Transaction) *chain.UTXOSet {
+	return s.mempool.InputView(tx, s.blockchain.Output)
 }
 
+// checkRelayFee computes the fee tx pays and checks it against the relay
+// policy.
+func (s *Server) checkRelayFee(tx *chain.Transaction) error {
+	fee, err := chain.ComputeFee(tx, s.inputView(tx))
+	if err != nil {
+		return err
+	}
+	return s.mempool.CheckFee(tx, fee)
+}
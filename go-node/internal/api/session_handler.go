@@ -0,0 +1,223 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// sessionHeader carries the token from POST /api/wallet/unlock on signing
+// requests.
+const sessionHeader = "X-Wallet-Session"
+
+// SetRequireUnlock makes signing endpoints refuse to use a wallet without a
+// live unlock session for it.
+func (s *Server) SetRequireUnlock(require bool) {
+	s.requireUnlock = require
+}
+
+func (s *Server) Sessions() *wallet.Sessions {
+	return s.sessions
+}
+
+// requireUnlocked writes an error and returns false when unlock sessions are
+// required and the request has none for address.
+func (s *Server) requireUnlocked(w http.ResponseWriter, r *http.Request, address string) bool {
+	if !s.requireUnlock {
+		return true
+	}
+	if err := s.sessions.Check(r.Header.Get(sessionHeader), address); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+type unlockWalletRequest struct {
+	Address        string `json:"address"`
+	Passphrase     string `json:"passphrase,omitempty"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+}
+
+type unlockWalletResponse struct {
+	Address   string `json:"address"`
+	ExpiresAt int64  `json:"expires_at"`
+	Header    string `json:"header"` // send the token in this header
+	Token     string `json:"token"`
+}
+
+type lockWalletRequest struct {
+	Token   string `json:"token,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+type lockWalletResponse struct {
+	Locked int    `json:"locked"` // sessions ended
+	Status string `json:"status"`
+}
+
+type unlockStoreRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type walletStoreResponse struct {
+	Encrypted bool `json:"encrypted"`
+	Locked    bool `json:"locked"`
+	Wallets   int  `json:"wallets"`
+}
+
+func (s *Server) handleUnlockWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request unlockWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.TimeoutSeconds < 0 {
+		http.Error(w, "timeout_seconds must not be negative", http.StatusBadRequest)
+		return
+	}
+	if s.walletStore.GetWallet(request.Address) == nil {
+		http.Error(w, "Wallet not found", http.StatusNotFound)
+		return
+	}
+	if err := s.walletStore.CheckPassphrase(request.Passphrase); err != nil {
+		writeKeystoreError(w, err)
+		return
+	}
+
+	session, err := s.sessions.Unlock(request.Address, time.Duration(request.TimeoutSeconds)*time.Second)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to unlock wallet: %v", err), http.StatusInternalServerError)
+		return
+	}
+	requestLogger(r).Info("Wallet unlocked", "address", request.Address, "until", time.Unix(session.ExpiresAt, 0).Format(time.RFC3339))
+
+	response := unlockWalletResponse{
+		Address:   session.Address,
+		ExpiresAt: session.ExpiresAt,
+		Header:    sessionHeader,
+		Token:     session.Token,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleLockWallet ends the session in the X-Wallet-Session header or body
+// token, or every session for the body's address.
+func (s *Server) handleLockWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request lockWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.Token == "" {
+		request.Token = r.Header.Get(sessionHeader)
+	}
+
+	locked := 0
+	switch {
+	case request.Token != "":
+		if s.sessions.Lock(request.Token) {
+			locked = 1
+		}
+	case request.Address != "":
+		locked = s.sessions.LockAddress(request.Address)
+	default:
+		http.Error(w, "A session token or address is required", http.StatusBadRequest)
+		return
+	}
+
+	response := lockWalletResponse{Locked: locked, Status: "locked"}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func writeKeystoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrBadPassphrase):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, wallet.ErrNoKeystore):
+		http.Error(w, "No keystore configured (start node with -wallet-file)", http.StatusConflict)
+	case errors.Is(err, wallet.ErrStoreLocked):
+		http.Error(w, "Wallet store is locked: unlock it with POST /api/wallet/store/unlock", http.StatusLocked)
+	default:
+		http.Error(w, fmt.Sprintf("Keystore error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) handleWalletStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.writeWalletStoreStatus(w)
+}
+
+func (s *Server) writeWalletStoreStatus(w http.ResponseWriter) {
+	response := walletStoreResponse{
+		Encrypted: s.walletStore.HasKeystore(),
+		Locked:    s.walletStore.Locked(),
+		Wallets:   len(s.walletStore.GetAllAddresses()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleLockWalletStore removes private keys from memory and ends every
+// unlock session until the store is unlocked with its passphrase.
+func (s *Server) handleLockWalletStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.walletStore.Lock(); err != nil {
+		writeKeystoreError(w, err)
+		return
+	}
+	for _, address := range s.walletStore.GetAllAddresses() {
+		s.sessions.LockAddress(address)
+	}
+	requestLogger(r).Info("Wallet store locked")
+
+	s.writeWalletStoreStatus(w)
+}
+
+func (s *Server) handleUnlockWalletStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request unlockStoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.walletStore.Unlock(request.Passphrase); err != nil {
+		writeKeystoreError(w, err)
+		return
+	}
+	requestLogger(r).Info("Wallet store unlocked")
+
+	s.writeWalletStoreStatus(w)
+}
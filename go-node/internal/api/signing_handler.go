@@ -0,0 +1,173 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// Build, sign and address derivation for external wallets, so they can leave
+// canonical serialization and hashing to the node. Requests and responses
+// mirror the WalletService messages in schemas/wallet.proto.
+
+type transactionResponse struct {
+	CanonicalHex string             `json:"canonical_hex"`
+	Transaction  *chain.Transaction `json:"transaction"`
+	TxID         string             `json:"txid"`
+}
+
+func writeTransactionResponse(w http.ResponseWriter, tx *chain.Transaction) {
+	canonical, err := chain.CanonicalTxBytes(tx)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to canonicalize: %v", err), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, &transactionResponse{
+		CanonicalHex: hex.EncodeToString(canonical),
+		Transaction:  tx,
+		TxID:         tx.ID,
+	})
+}
+
+func writeSigningError(w http.ResponseWriter, err error) {
+	switch err {
+	case wallet.ErrWalletNotFound:
+		http.Error(w, "Wallet not found", http.StatusNotFound)
+	case wallet.ErrStoreLocked:
+		writeKeystoreError(w, err)
+	case wallet.ErrForeignInput:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+type deriveAddressRequest struct {
+	PublicKey string `json:"public_key"` // hex X||Y
+}
+
+type deriveAddressResponse struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+}
+
+type buildTransactionRequest struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+	Fee    float64 `json:"fee"`
+
+	Memo            string `json:"memo,omitempty"`
+	EncryptMemo     bool   `json:"encrypt_memo,omitempty"`
+	RecipientPubKey string `json:"recipient_pubkey,omitempty"`
+}
+
+type signTransactionRequest struct {
+	From        string             `json:"from"`
+	Transaction *chain.Transaction `json:"transaction"`
+}
+
+func (s *Server) handleDeriveAddress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request deriveAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	pub, err := crypto.ParsePublicKey(request.PublicKey)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("public_key must be hex X||Y of a P-256 point: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := deriveAddressResponse{
+		Address:   crypto.AddressFromPublicKey(pub),
+		PublicKey: crypto.EncodePublicKey(pub),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleBuildTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request buildTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.From == "" || request.To == "" || request.Amount <= 0 || request.Fee < 0 {
+		http.Error(w, "Invalid request: from, to, a positive amount and a non-negative fee are required", http.StatusBadRequest)
+		return
+	}
+
+	to, err := s.walletStore.ResolveRecipient(request.From, request.To)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid recipient: %v", err), http.StatusBadRequest)
+		return
+	}
+	memo, terr := s.sealMemo(&transferMemo{Text: request.Memo, Encrypt: request.EncryptMemo, RecipientKey: request.RecipientPubKey}, to)
+	if terr != nil {
+		terr.write(w)
+		return
+	}
+
+	tx, err := s.walletStore.BuildTransaction(request.From, to, request.Amount, request.Fee,
+		s.mempool.SpendableUTXO(s.blockchain))
+	if err != nil {
+		writeSigningError(w, err)
+		return
+	}
+	if memo != "" {
+		tx.Memo = memo
+		if tx.ID, err = chain.ComputeTxID(tx); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to compute txid: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	writeTransactionResponse(w, tx)
+}
+
+// handleSignTransaction signs a transaction with a node-held key without
+// submitting it.
+func (s *Server) handleSignTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request signTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if request.From == "" || request.Transaction == nil {
+		http.Error(w, "Invalid request: from and transaction are required", http.StatusBadRequest)
+		return
+	}
+	if !s.requireUnlocked(w, r, request.From) {
+		return
+	}
+
+	tx := request.Transaction
+	if err := s.walletStore.SignTransaction(request.From, tx, s.mempool.SpendableUTXO(s.blockchain)); err != nil {
+		writeSigningError(w, err)
+		return
+	}
+
+	writeTransactionResponse(w, tx)
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxSimulatedDifficulty = 255
+
+type simulateResponse struct {
+	Difficulty          int     `json:"difficulty"`
+	CurrentDifficulty   int     `json:"current_difficulty"`
+	HashRate            float64 `json:"hash_rate"`        // hashes per second
+	HashRateSource      string  `json:"hash_rate_source"` // "mining" or "benchmark"
+	Threads             int     `json:"threads"`
+	ExpectedHashes      float64 `json:"expected_hashes"`
+	ExpectedSeconds     float64 `json:"expected_seconds"`
+	MedianSeconds       float64 `json:"median_seconds"`
+	P90Seconds          float64 `json:"p90_seconds"`
+	P99Seconds          float64 `json:"p99_seconds"`
+	TargetBlockTime     float64 `json:"target_block_time"` // seconds
+	SuggestedDifficulty int     `json:"suggested_difficulty"`
+}
+
+// handleSimulateDifficulty estimates how long this node would take to mine a
+// block at a given difficulty. A hash meets difficulty d with probability
+// 2^-d, so a block takes 2^d hashes on average and the time to find one is
+// exponentially distributed around that. The hash rate is the one measured
+// while mining, or a one-off one second benchmark if the node has not mined
+// enough to measure it.
+func (s *Server) handleSimulateDifficulty(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	current := s.blockchain.NextDifficulty()
+	difficulty := current
+	if v := r.URL.Query().Get("difficulty"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSimulatedDifficulty {
+			http.Error(w, "difficulty must be an integer between 1 and 255", http.StatusBadRequest)
+			return
+		}
+		difficulty = n
+	}
+
+	target := s.blockchain.RetargetPolicy().TargetBlockTime
+	if v := r.URL.Query().Get("target"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "target must be a positive duration such as 30s", http.StatusBadRequest)
+			return
+		}
+		target = d
+	}
+
+	rate, source := s.miner.EstimateHashRate()
+	if rate <= 0 {
+		http.Error(w, "Could not measure hash rate", http.StatusServiceUnavailable)
+		return
+	}
+
+	hashes := math.Ldexp(1, difficulty)
+	mean := hashes / rate
+	resp := &simulateResponse{
+		Difficulty:        difficulty,
+		CurrentDifficulty: current,
+		HashRate:          math.Round(rate),
+		HashRateSource:    source,
+		Threads:           s.miner.Threads(),
+		ExpectedHashes:    hashes,
+		ExpectedSeconds:   mean,
+		MedianSeconds:     mean * math.Ln2,
+		P90Seconds:        -mean * math.Log(0.1),
+		P99Seconds:        -mean * math.Log(0.01),
+	}
+	if target > 0 {
+		resp.TargetBlockTime = target.Seconds()
+		// Each step doubles the expected time, so the closest difficulty
+		// is the nearest power of two to the hashes done in target.
+		suggested := int(math.Round(math.Log2(rate * target.Seconds())))
+		if suggested < 1 {
+			suggested = 1
+		}
+		if suggested > maxSimulatedDifficulty {
+			suggested = maxSimulatedDifficulty
+		}
+		resp.SuggestedDifficulty = suggested
+	}
+	writeJSON(w, resp)
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+type statsResponse struct {
+	chain.UTXOStats
+	MempoolSize int         `json:"mempool_size"`
+	Cache       *CacheStats `json:"cache,omitempty"`
+}
+
+// handleStats reports ledger state cheaply enough to poll. utxo_hash is
+// maintained incrementally, so replicas and operators can compare it across
+// nodes at the same tip_hash instead of diffing whole UTXO sets. With the
+// query cache on, its hit and miss counters are included.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := &statsResponse{
+		UTXOStats:   s.blockchain.UTXOStats(),
+		MempoolSize: s.mempool.Size(),
+	}
+	if s.cache != nil {
+		stats := s.cache.Stats()
+		resp.Cache = &stats
+	}
+	writeJSON(w, resp)
+}
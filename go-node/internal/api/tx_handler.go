@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	txStatusConfirmed = "confirmed"
+	txStatusPending   = "pending"
+)
+
+type txLookupResponse struct {
+	TxID          string             `json:"txid"`
+	Status        string             `json:"status"`
+	Transaction   *chain.Transaction `json:"transaction"`
+	BlockHash     string             `json:"block_hash,omitempty"`
+	BlockHeight   *int               `json:"block_height,omitempty"`
+	Index         *int               `json:"index,omitempty"`
+	Confirmations int                `json:"confirmations"`
+}
+
+// handleGetTransaction looks a transaction up in the chain's transaction
+// index, falling back to the mempool for unconfirmed ones.
+func (s *Server) handleGetTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/transactions/"))
+	if id, ok := strings.CutSuffix(txID, "/proof"); ok {
+		s.handleTransactionProof(w, id)
+		return
+	}
+	if txID == "" {
+		http.Error(w, "Transaction ID required", http.StatusBadRequest)
+		return
+	}
+
+	if tx, loc, confirmations, ok := s.blockchain.FindTransaction(txID); ok {
+		writeJSON(w, &txLookupResponse{
+			TxID:          txID,
+			Status:        txStatusConfirmed,
+			Transaction:   tx,
+			BlockHash:     loc.BlockHash,
+			BlockHeight:   &loc.Height,
+			Index:         &loc.Index,
+			Confirmations: confirmations,
+		})
+		return
+	}
+	if tx, ok := s.mempool.Get(txID); ok {
+		writeJSON(w, &txLookupResponse{
+			TxID:        txID,
+			Status:      txStatusPending,
+			Transaction: tx,
+		})
+		return
+	}
+
+	http.Error(w, "Transaction not found", http.StatusNotFound)
+}
+
+// handleTransactionProof serves GET /transactions/:txid/proof: the Merkle
+// branch from a confirmed transaction to its block's root, which a light
+// client checks against a header it has validated itself.
+func (s *Server) handleTransactionProof(w http.ResponseWriter, txID string) {
+	if txID == "" {
+		http.Error(w, "Transaction ID required", http.StatusBadRequest)
+		return
+	}
+	proof, ok := s.blockchain.TransactionProof(txID)
+	if !ok {
+		if _, pending := s.mempool.Get(txID); pending {
+			http.Error(w, "Transaction is not confirmed yet", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Transaction not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, proof)
+}
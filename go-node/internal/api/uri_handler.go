@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type paymentURIResponse struct {
+	Request wallet.PaymentURI `json:"request"`
+	URI     string            `json:"uri"`
+}
+
+func (s *Server) handleMakePaymentURI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	uri := wallet.PaymentURI{
+		Address: query.Get("address"),
+		Label:   query.Get("label"),
+		Memo:    query.Get("memo"),
+	}
+	if err := wallet.ValidateAddress(uri.Address); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid address: %v", err), http.StatusBadRequest)
+		return
+	}
+	if amount := query.Get("amount"); amount != "" {
+		value, err := strconv.ParseFloat(amount, 64)
+		if err != nil || value <= 0 {
+			http.Error(w, "Invalid amount", http.StatusBadRequest)
+			return
+		}
+		uri.Amount = value
+	}
+
+	response := paymentURIResponse{Request: uri, URI: uri.String()}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) handleParsePaymentURI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	uri, err := wallet.ParsePaymentURI(r.URL.Query().Get("uri"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid payment URI: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(uri)
+}
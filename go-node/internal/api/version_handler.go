@@ -0,0 +1,94 @@
+package api
+
+import (
+	"net/http"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/p2p"
+)
+
+// BuildInfo identifies the node binary. Commit and CommitTime come from the
+// version control stamp Go embeds at build time and are empty under go run.
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	CommitTime string `json:"commit_time,omitempty"`
+	Modified   bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion  string `json:"go_version"`
+}
+
+// NodeFeatures is how the node was started. The consensus fields are filled
+// in from the chain when GET /version is served.
+type NodeFeatures struct {
+	Mode                string   `json:"mode"` // full or replica
+	Consensus           string   `json:"consensus"`
+	DifficultyAlgorithm string   `json:"difficulty_algorithm"`
+	Indexes             []string `json:"indexes"`
+	Pruning             bool     `json:"pruning"`
+	P2P                 bool     `json:"p2p"`
+	Dandelion           bool     `json:"dandelion"`
+	AIScoring           bool     `json:"ai_scoring"`
+	EncryptedKeystore   bool     `json:"encrypted_keystore"`
+	AutoMine            bool     `json:"auto_mine"`
+	AdminAPI            bool     `json:"admin_api"`
+}
+
+// SelfTestReport records the checks run at startup. The node does not start
+// when one fails, so a served report always passed.
+type SelfTestReport struct {
+	Checks     []string `json:"checks"`
+	DurationMs float64  `json:"duration_ms"`
+	RanAt      int64    `json:"ran_at"`
+}
+
+// ProtocolVersions lists the versions the node speaks on each interface.
+type ProtocolVersions struct {
+	API  []string `json:"api"` // HTTP API path prefixes
+	P2P  []int    `json:"p2p"`
+	Wire []int    `json:"wire"` // binary encoding of /blocks and /mempool
+}
+
+type versionResponse struct {
+	BuildInfo
+	Protocols ProtocolVersions `json:"protocols"`
+	Features  NodeFeatures     `json:"features"`
+	SelfTest  *SelfTestReport  `json:"self_test,omitempty"`
+}
+
+// SetVersionInfo sets what GET /version reports.
+func (s *Server) SetVersionInfo(build BuildInfo, features NodeFeatures, selfTest *SelfTestReport) {
+	s.build = build
+	s.features = features
+	s.selfTest = selfTest
+}
+
+// handleVersion serves GET /version.
+func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	features := s.features
+	algorithm, _ := consensus.ParseAlgorithm(s.blockchain.PowAlgorithm())
+	features.Consensus = "pow-" + string(algorithm)
+	features.DifficultyAlgorithm = "fixed"
+	if s.blockchain.RetargetPolicy().Enabled() {
+		features.DifficultyAlgorithm = "retarget"
+	}
+	if features.Mode == "" {
+		features.Mode = "full"
+	}
+
+	writeJSON(w, versionResponse{
+		BuildInfo: s.build,
+		Protocols: ProtocolVersions{
+			API:  []string{apiPrefix},
+			P2P:  []int{p2p.ProtocolVersion},
+			Wire: []int{chain.WireVersion},
+		},
+		Features: features,
+		SelfTest: s.selfTest,
+	})
+}
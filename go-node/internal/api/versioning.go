@@ -0,0 +1,27 @@
+package api
+
+import "net/http"
+
+// apiPrefix is the path every endpoint is served under. Bump it, and keep
+// serving the old prefix, for changes that break existing clients.
+const apiPrefix = "/v1"
+
+// versioned serves mux under apiPrefix, along with the OpenAPI document.
+// The unversioned paths remain as deprecated aliases for clients written
+// before the API was versioned; their responses carry a Deprecation header
+// and a Link to the versioned path.
+func (s *Server) versioned(mux http.Handler) http.Handler {
+	root := http.NewServeMux()
+	root.Handle(apiPrefix+"/", http.StripPrefix(apiPrefix, mux))
+	root.HandleFunc(apiPrefix+"/openapi.json", corsMiddleware(s.handleOpenAPI))
+	root.Handle("/", legacyAlias(mux))
+	return root
+}
+
+func legacyAlias(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+r.URL.Path+`>; rel="successor-version"`)
+		next.ServeHTTP(w, r)
+	})
+}
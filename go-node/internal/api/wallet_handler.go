@@ -3,10 +3,12 @@ package api
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 
+	"ai-blockchain/go-node/internal/ai"
 	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/fees"
 	"ai-blockchain/go-node/internal/wallet"
 )
 
@@ -16,7 +18,16 @@ func (s *Server) handleGenerateWallet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newWallet, err := s.walletStore.GenerateWallet()
+	q := r.URL.Query()
+	newWallet, err := s.walletStore.GenerateLabeledWallet(q.Get("label"), q.Get("owner"))
+	if err == wallet.ErrStoreLocked {
+		writeKeystoreError(w, err)
+		return
+	}
+	if err == wallet.ErrInvalidLabel || err == wallet.ErrInvalidAddress {
+		http.Error(w, fmt.Sprintf("Invalid wallet metadata: %v", err), http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Failed to generate wallet: %v", err), http.StatusInternalServerError)
 		return
@@ -24,11 +35,13 @@ func (s *Server) handleGenerateWallet(w http.ResponseWriter, r *http.Request) {
 
 	publicKeyHex := wallet.EncodePublicKey(newWallet.PublicKey)
 
-	response := map[string]interface{}{
-		"address":    newWallet.Address,
-		"public_key": publicKeyHex,
-		"message":    "Wallet generated and stored successfully",
-		"note":       "Private key is stored securely in wallet service",
+	response := generateWalletResponse{
+		Address:   newWallet.Address,
+		Label:     newWallet.Label,
+		Message:   "Wallet generated and stored successfully",
+		Note:      "Private key is stored securely in wallet service",
+		Owner:     newWallet.Owner,
+		PublicKey: publicKeyHex,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -41,15 +54,37 @@ func (s *Server) handleListWallets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	addresses := s.walletStore.GetAllAddresses()
+	page, err := parseListPage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if page.limit == 0 {
+		page.limit = defaultPageLimit
+	}
+	q := r.URL.Query()
+	all := s.walletStore.ListWallets(wallet.WalletFilter{Label: q.Get("label"), Owner: q.Get("owner")})
+
+	positions := page.indices(len(all))
+	addresses := make([]string, len(positions))
+	for i, pos := range positions {
+		addresses[i] = all[pos].Address
+	}
+	balances := s.blockchain.AddressBalances(addresses)
 
-	response := map[string]interface{}{
-		"addresses": addresses,
-		"count":    len(addresses),
+	wallets := make([]walletEntry, len(positions))
+	for i, pos := range positions {
+		wallets[i] = walletEntry{WalletInfo: all[pos], Balance: balances[all[pos].Address]}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, &walletListResponse{
+		Addresses: addresses,
+		Count:     len(addresses),
+		Limit:     page.limit,
+		Offset:    page.offset,
+		Total:     len(all),
+		Wallets:   wallets,
+	})
 }
 
 func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
@@ -58,79 +93,296 @@ func (s *Server) handleTransfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var request struct {
-		From   string  `json:"from"`
-		To     string  `json:"to"`
-		Amount float64 `json:"amount"`
-	}
+	var request transferRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
 		return
 	}
 
+	if request.URI != "" {
+		uri, err := wallet.ParsePaymentURI(request.URI)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid payment URI: %v", err), http.StatusBadRequest)
+			return
+		}
+		if request.To != "" && request.To != uri.Address {
+			http.Error(w, "Recipient conflicts with payment URI", http.StatusBadRequest)
+			return
+		}
+		if uri.Amount > 0 {
+			if request.Amount > 0 && request.Amount != uri.Amount {
+				http.Error(w, "Amount conflicts with payment URI", http.StatusBadRequest)
+				return
+			}
+			request.Amount = uri.Amount
+		}
+		if request.Memo == "" {
+			request.Memo = uri.Memo
+		}
+		request.To = uri.Address
+	}
+
 	if request.From == "" || request.To == "" || request.Amount <= 0 {
 		http.Error(w, "Invalid request: from, to, and amount (positive) are required", http.StatusBadRequest)
 		return
 	}
 
-	tx, err := s.walletStore.BuildAndSignTransaction(
-		request.From,
-		request.To,
-		request.Amount,
-		s.blockchain.UTXO,
-	)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to build transaction: %v", err), http.StatusBadRequest)
+	if !s.requireUnlocked(w, r, request.From) {
 		return
 	}
 
-	if err := chain.VerifyTransaction(tx, s.blockchain.UTXO); err != nil {
-		response := map[string]interface{}{
-			"error": fmt.Sprintf("Transaction validation failed: %v", err),
-			"hint":  "Make sure you have coins. Try using genesis address or mine a block first.",
-			"txid":  tx.ID,
+	if request.TargetConfirmations < 0 || request.MaxFee < 0 {
+		http.Error(w, "Invalid request: target_confirmations and max_fee must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	// Change from an HD key is derived on the change chain, so the mnemonic
+	// still recovers it; such wallets get fresh change unless they opt out.
+	fresh := s.freshChange
+	if from := s.walletStore.GetWallet(request.From); from != nil && from.HD != "" {
+		fresh = true
+	}
+	if request.FreshChange != nil {
+		fresh = *request.FreshChange
+	}
+	changeAddress := request.From
+	if fresh {
+		changeWallet, err := s.walletStore.NewChangeWallet(request.From)
+		if err != nil {
+			writeKeystoreError(w, err)
+			return
+		}
+		changeAddress = changeWallet.Address
+	}
+	// The change wallet is saved already; it is kept only if the payment
+	// is admitted.
+	admitted := false
+	defer func() {
+		if changeAddress != request.From && !admitted {
+			s.discardChangeWallet(changeAddress)
+		}
+	}()
+
+	memo := &transferMemo{Text: request.Memo, Encrypt: request.EncryptMemo, RecipientKey: request.RecipientPubKey}
+	var terr *transferError
+	build := func(fee float64) (*chain.Transaction, error) {
+		var built *chain.Transaction
+		if built, terr = s.buildTransfer(request.From, request.To, request.Amount, fee, changeAddress, memo); terr != nil {
+			return nil, terr
+		}
+		return built, nil
+	}
+
+	var tx *chain.Transaction
+	var fee float64
+	if request.TargetConfirmations > 0 {
+		var quote fees.Estimate
+		tx, quote, _ = s.buildWithQuote(request.TargetConfirmations, build)
+		if terr == nil && request.MaxFee > 0 && quote.Fee > request.MaxFee {
+			writeJSONStatus(w, http.StatusUnprocessableEntity, &feeCapResponse{
+				Amount: request.Amount,
+				Error:  "Required fee exceeds max_fee; resubmit with a higher cap to approve",
+				MaxFee: request.MaxFee,
+				Quote:  quote,
+				Total:  request.Amount + quote.Fee,
+			})
+			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(response)
+		fee = quote.Fee
+	} else {
+		tx, _ = build(0)
+	}
+	if terr == nil {
+		terr = s.admitWalletTransaction(tx, "/api/wallet/transfer")
+	}
+	if terr != nil {
+		terr.write(w)
 		return
 	}
+	admitted = true
+
+	response := transferResponse{
+		Fee:                 fee,
+		Message:             "Transaction signed and submitted successfully",
+		Status:              "submitted",
+		TargetConfirmations: request.TargetConfirmations,
+		TxID:                tx.ID,
+		Warnings:            s.reuseWarnings(tx, request.From, changeAddress),
+	}
+	if warning := s.consolidationWarning(request.From); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+	if changeAddress != request.From {
+		response.ChangeAddress = changeAddress
+	}
+	if tx.Memo != "" {
+		encrypted := chain.IsEncryptedMemo(tx.Memo)
+		response.MemoEncrypted = &encrypted
+	}
+
+	writeJSONStatus(w, http.StatusCreated, response)
+}
 
+// discardChangeWallet removes the change wallet created for a transfer that
+// failed, so failed attempts don't fill the store.
+func (s *Server) discardChangeWallet(address string) {
+	if err := s.walletStore.DiscardChangeWallet(address); err != nil {
+		slog.Warn("Failed to discard unused change wallet", "address", address, "err", err)
+	}
+}
+
+// reuseWarnings flags payments to addresses that have been paid before.
+// Every payment to a reused address is linked to the others on the chain,
+// so payees should hand out a new address each time.
+func (s *Server) reuseWarnings(tx *chain.Transaction, from, change string) []string {
+	var warnings []string
+	for _, out := range tx.Outputs {
+		if out.Address == from || out.Address == change {
+			continue
+		}
+		if len(s.blockchain.AddressHistory(out.Address)) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"Address %s has been paid before; ask the payee for a new address to keep payments unlinked", out.Address))
+		}
+	}
+	return warnings
+}
+
+// consolidationWarning suggests consolidating when address holds more small
+// outputs than the soft quota allows. The per-address count in the UTXO set
+// rules out most addresses without scanning it.
+func (s *Server) consolidationWarning(address string) string {
+	policy := s.consolidation
+	if !policy.OverQuota(s.blockchain.UTXOCountOf(address)) {
+		return ""
+	}
+	small := len(policy.SmallOutputs(s.blockchain.UnspentOutputs(address)))
+	if !policy.OverQuota(small) {
+		return ""
+	}
+	return fmt.Sprintf("Address %s holds %d small outputs, over the quota of %d; see GET /api/wallet/%s/consolidate-suggestion",
+		address, small, policy.MaxSmallOutputs, address)
+}
+
+type transferError struct {
+	status  int
+	message string
+	details *transferErrorResponse // sent as JSON when set, else the message as plain text
+}
+
+func (e *transferError) Error() string {
+	return e.message
+}
+
+func (e *transferError) write(w http.ResponseWriter) {
+	if e.details == nil {
+		http.Error(w, e.message, e.status)
+		return
+	}
+
+	response := *e.details
+	response.Error = e.message
+	writeJSONStatus(w, e.status, &response)
+}
+
+// submitTransfer builds, signs and admits a wallet transfer through the same
+// checks as a submitted transaction, sending any change to change and
+// attaching memo if it is not nil. Scheduled payments use it; the transfer
+// endpoint builds and admits separately, to quote the fee for the size of
+// what it built.
+func (s *Server) submitTransfer(from, recipient string, amount, fee float64, change string, memo *transferMemo) (*chain.Transaction, *transferError) {
+	tx, terr := s.buildTransfer(from, recipient, amount, fee, change, memo)
+	if terr != nil {
+		return nil, terr
+	}
+	if terr := s.admitWalletTransaction(tx, "/api/wallet/transfer"); terr != nil {
+		return nil, terr
+	}
+	return tx, nil
+}
+
+// buildTransfer builds and signs the transfer submitTransfer admits.
+func (s *Server) buildTransfer(from, recipient string, amount, fee float64, change string, memo *transferMemo) (*chain.Transaction, *transferError) {
+	to, err := s.walletStore.ResolveRecipient(from, recipient)
+	if err != nil {
+		return nil, &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Invalid recipient: %v", err)}
+	}
+	sealed, terr := s.sealMemo(memo, to)
+	if terr != nil {
+		return nil, terr
+	}
+
+	tx, err := s.walletStore.BuildAndSignTransfer(
+		from,
+		to,
+		amount,
+		fee,
+		change,
+		sealed,
+		s.mempool.SpendableUTXO(s.blockchain),
+	)
+	if err == wallet.ErrInsufficientFunds && s.blockchain.BalanceOf(from) >= amount+fee {
+		return nil, &transferError{
+			status:  http.StatusConflict,
+			message: "Failed to build transaction: funds are held by pending transactions",
+			details: &transferErrorResponse{Hint: "Wait for the pending transactions to be mined, then retry."},
+		}
+	}
+	if err == wallet.ErrStoreLocked {
+		return nil, &transferError{status: http.StatusLocked, message: "Wallet store is locked: unlock it with POST /api/wallet/store/unlock"}
+	}
+	if err != nil {
+		return nil, &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Failed to build transaction: %v", err)}
+	}
+	return tx, nil
+}
+
+// admitWalletTransaction puts a transaction the wallet store built and
+// signed through the checks of a submitted transaction, scoring it as a
+// request to endpoint, and adds it to the mempool.
+func (s *Server) admitWalletTransaction(tx *chain.Transaction, endpoint string) *transferError {
+	if err := chain.VerifyTransaction(tx, s.inputView(tx)); err != nil {
+		return &transferError{
+			status:  http.StatusBadRequest,
+			message: fmt.Sprintf("Transaction validation failed: %v", err),
+			details: &transferErrorResponse{
+				Hint: "Make sure you have coins. Try using genesis address or mine a block first.",
+				TxID: tx.ID,
+			},
+		}
+	}
+
+	if err := s.checkRelayFee(tx); err != nil {
+		return &transferError{status: http.StatusBadRequest, message: fmt.Sprintf("Transaction rejected by relay policy: %v", err)}
+	}
+
+	var score *ai.ScoreResponse
 	if s.aiClient != nil {
-		score, err := s.aiClient.ScoreTransaction(tx)
+		var err error
+		score, err = s.aiClient.ScoreTransaction(tx, endpoint)
 		if err != nil {
-			log.Printf("AI scoring failed: %v (continuing anyway)", err)
+			slog.Warn("AI scoring failed, continuing without a score", "txid", tx.ID, "err", err)
 		} else {
-			log.Printf("Transaction %s scored: anomaly=%.2f, fee_adequacy=%.2f",
-				tx.ID, score.AnomalyScore, score.FeeAdequacy)
+			slog.Info("Transaction scored", "txid", tx.ID, "anomaly", score.AnomalyScore, "fee_adequacy", score.FeeAdequacy)
 
-			if score.AnomalyScore > 0.7 {
-				response := map[string]interface{}{
-					"error": "Transaction flagged as anomalous by AI",
-					"score": score.AnomalyScore,
+			if score.AnomalyScore > anomalyRejectThreshold {
+				s.aiClient.RecordRejection(endpoint)
+				return &transferError{
+					status:  http.StatusBadRequest,
+					message: "Transaction flagged as anomalous by AI",
+					details: &transferErrorResponse{Score: &score.AnomalyScore},
 				}
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusBadRequest)
-				json.NewEncoder(w).Encode(response)
-				return
 			}
 		}
 	}
 
-	if err := s.mempool.AddTransaction(tx); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to add to mempool: %v", err), http.StatusConflict)
-		return
+	if err := s.blocks.AddTransaction(tx); err == chain.ErrPipelineStopped {
+		return &transferError{status: http.StatusServiceUnavailable, message: "Node is shutting down"}
+	} else if err != nil {
+		return &transferError{status: http.StatusConflict, message: fmt.Sprintf("Failed to add to mempool: %v", err)}
 	}
+	s.rememberScore(tx.ID, score)
 
-	response := map[string]interface{}{
-		"status":  "submitted",
-		"txid":    tx.ID,
-		"message": "Transaction signed and submitted successfully",
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(response)
+	return nil
 }
-
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// A transfer from an HD-derived key sends its change to a new address on
+// the HD wallet's change chain unless the request opts out; a plain key
+// keeps the node's default.
+func TestTransferFreshChangeDefaultsOnForHDKeys(t *testing.T) {
+	store := wallet.NewWalletStore()
+	_, hd, err := store.CreateHDWallet("savings", 12, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	optOut, err := store.DeriveReceiveAddress(hd.HD, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := store.GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := chaintest.FromGenesis(chaintest.Genesis(t, hd.Address, optOut.Address, plain.Address))
+	mempool := chain.NewMempool()
+	pipeline := chain.NewBlockPipeline(bc, mempool)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go pipeline.Run(ctx)
+	s := &Server{blockchain: bc, mempool: mempool, blocks: pipeline, walletStore: store}
+
+	to := strings.Repeat("b", 64)
+	for _, tc := range []struct {
+		name     string
+		from     string
+		fresh    string
+		toSender bool
+	}{
+		{"hd default", hd.Address, "", false},
+		{"hd opt-out", optOut.Address, `,"fresh_change":false`, true},
+		{"plain default", plain.Address, "", true},
+	} {
+		body := `{"from":"` + tc.from + `","to":"` + to + `","amount":1` + tc.fresh + `}`
+		rec := httptest.NewRecorder()
+		s.handleTransfer(rec, httptest.NewRequest(http.MethodPost, "/api/wallet/transfer", strings.NewReader(body)))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s: status %d: %s", tc.name, rec.Code, rec.Body)
+		}
+		var reply transferResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if tc.toSender {
+			if reply.ChangeAddress != "" {
+				t.Errorf("%s: change sent to %s, want back to the sender", tc.name, reply.ChangeAddress)
+			}
+			continue
+		}
+		change := store.GetWallet(reply.ChangeAddress)
+		if change == nil || change.HD != hd.HD {
+			t.Errorf("%s: change address %q is not derived from the HD wallet", tc.name, reply.ChangeAddress)
+		}
+	}
+}
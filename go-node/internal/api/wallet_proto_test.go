@@ -0,0 +1,80 @@
+package api
+
+import (
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+// walletProtoTypes maps each message in schemas/wallet.proto to the type the
+// JSON routes encode or decode for it.
+var walletProtoTypes = map[string]interface{}{
+	"TxIn":                    chain.TxIn{},
+	"TxOut":                   chain.TxOut{},
+	"MultisigSpend":           chain.MultisigSpend{},
+	"Transaction":             chain.Transaction{},
+	"DeriveAddressRequest":    deriveAddressRequest{},
+	"DeriveAddressResponse":   deriveAddressResponse{},
+	"BuildTransactionRequest": buildTransactionRequest{},
+	"SignTransactionRequest":  signTransactionRequest{},
+	"CreateMultisigRequest":   multisigRequest{},
+	"MultisigAccount":         wallet.MultisigInfo{},
+	"MultisigSignResponse":    multisigSignResponse{},
+	"TransactionResponse":     transactionResponse{},
+}
+
+var (
+	protoMessage = regexp.MustCompile(`(?s)message (\w+) \{(.*?)\n\}`)
+	protoField   = regexp.MustCompile(`(?m)^\s*(?:repeated )?\w+ (\w+) = \d+;`)
+)
+
+// The proto documents the JSON bodies, so its field names must be theirs.
+func TestWalletProtoMatchesJSON(t *testing.T) {
+	data, err := os.ReadFile("../../../schemas/wallet.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range protoMessage.FindAllStringSubmatch(string(data), -1) {
+		name := m[1]
+		seen[name] = true
+		typ, ok := walletProtoTypes[name]
+		if !ok {
+			t.Errorf("message %s has no JSON type in walletProtoTypes", name)
+			continue
+		}
+		var fields []string
+		for _, f := range protoField.FindAllStringSubmatch(m[2], -1) {
+			fields = append(fields, f[1])
+		}
+		sort.Strings(fields)
+		if tags := jsonFields(reflect.TypeOf(typ)); !reflect.DeepEqual(fields, tags) {
+			t.Errorf("message %s has fields %v, JSON has %v", name, fields, tags)
+		}
+	}
+	for name := range walletProtoTypes {
+		if !seen[name] {
+			t.Errorf("walletProtoTypes names %s, which the proto does not define", name)
+		}
+	}
+}
+
+// jsonFields lists the JSON names of t's fields, sorted.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
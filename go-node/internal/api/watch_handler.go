@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"ai-blockchain/go-node/internal/wallet"
+)
+
+type watchRequest struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+type watchListResponse struct {
+	Addresses []walletEntry `json:"addresses"`
+	Count     int           `json:"count"`
+}
+
+func writeWatchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, wallet.ErrWatchNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, wallet.ErrWatchExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, wallet.ErrInvalidAddress), errors.Is(err, wallet.ErrInvalidLabel):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeKeystoreError(w, err)
+	}
+}
+
+// handleWatch serves /api/wallet/watch: GET lists the watch-only addresses
+// with their confirmed balances, POST adds {"address"}, DELETE ?address=
+// removes one.
+func (s *Server) handleWatch(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		watched := s.walletStore.WatchedAddresses()
+		addresses := make([]string, len(watched))
+		for i, info := range watched {
+			addresses[i] = info.Address
+		}
+		balances := s.blockchain.AddressBalances(addresses)
+		entries := make([]walletEntry, len(watched))
+		for i, info := range watched {
+			entries[i] = walletEntry{WalletInfo: info, Balance: balances[info.Address]}
+		}
+		writeJSON(w, &watchListResponse{Addresses: entries, Count: len(entries)})
+
+	case http.MethodPost:
+		var request watchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+			return
+		}
+		info, err := s.walletStore.WatchAddress(request.Address, request.Label)
+		if err != nil {
+			writeWatchError(w, err)
+			return
+		}
+		requestLogger(r).Info("Watch-only address added", "address", info.Address)
+
+		entry := walletEntry{WalletInfo: info, Balance: s.blockchain.AddressBalances([]string{info.Address})[info.Address]}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(&entry)
+
+	case http.MethodDelete:
+		address := r.URL.Query().Get("address")
+		if err := s.walletStore.UnwatchAddress(address); err != nil {
+			writeWatchError(w, err)
+			return
+		}
+		requestLogger(r).Info("Watch-only address removed", "address", address)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Minimal server side of RFC 6455: enough for pushing JSON text messages to
+// browsers and wallets and answering their control frames. Fragmented and
+// extension frames from clients are not supported.
+
+const (
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+
+	wsMaxClientFrame = 64 << 10
+	wsWriteTimeout   = 10 * time.Second
+)
+
+var errWSProtocol = errors.New("websocket protocol error")
+
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+
+	writeMu sync.Mutex
+}
+
+func wsAcceptKey(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket completes the opening handshake and takes over the
+// connection. On failure it has already written an HTTP error.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errWSProtocol
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContainsToken(r.Header, "Connection", "upgrade") ||
+		!headerContainsToken(r.Header, "Upgrade", "websocket") || key == "" {
+		http.Error(w, "Expected a WebSocket upgrade request", http.StatusBadRequest)
+		return nil, errWSProtocol
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errWSProtocol
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported by this server", http.StatusInternalServerError)
+		return nil, errWSProtocol
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Accept: %s\r\n\r\n", wsAcceptKey(key))
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	var header [10]byte
+	header[0] = 0x80 | opcode // FIN, no fragmentation
+	n := 2
+	switch {
+	case len(payload) < 126:
+		header[1] = byte(len(payload))
+	case len(payload) <= 0xFFFF:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(len(payload)))
+		n = 4
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(len(payload)))
+		n = 10
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := c.rw.Write(header[:n]); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+func (c *wsConn) WriteText(payload []byte) error {
+	return c.writeFrame(wsOpText, payload)
+}
+
+func (c *wsConn) Ping() error {
+	return c.writeFrame(wsOpPing, nil)
+}
+
+// Close sends a close frame with the given status code and drops the
+// connection.
+func (c *wsConn) Close(code uint16) error {
+	var payload [2]byte
+	binary.BigEndian.PutUint16(payload[:], code)
+	c.writeFrame(wsOpClose, payload[:])
+	return c.conn.Close()
+}
+
+// ReadMessage returns the next data frame from the client, answering pings
+// along the way. It returns io.EOF once the client closes the connection.
+func (c *wsConn) ReadMessage() (opcode byte, payload []byte, err error) {
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(c.rw, header[:]); err != nil {
+			return 0, nil, err
+		}
+		fin := header[0]&0x80 != 0
+		opcode = header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7F)
+
+		if header[0]&0x70 != 0 || !masked || !fin || opcode == wsOpContinuation {
+			return 0, nil, errWSProtocol
+		}
+
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+				return 0, nil, err
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+		if length > wsMaxClientFrame {
+			return 0, nil, errWSProtocol
+		}
+
+		var mask [4]byte
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return 0, nil, err
+		}
+		payload = make([]byte, length)
+		if _, err := io.ReadFull(c.rw, payload); err != nil {
+			return 0, nil, err
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return 0, nil, err
+			}
+		case wsOpPong:
+		case wsOpClose:
+			c.writeFrame(wsOpClose, payload)
+			return 0, nil, io.EOF
+		case wsOpText, wsOpBinary:
+			return opcode, payload, nil
+		default:
+			return 0, nil, errWSProtocol
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	DefaultMaxWebSocketClients = 256
+	wsPingInterval             = 30 * time.Second
+)
+
+const (
+	eventBlock = "block"
+	eventTx    = "tx"
+	eventReorg = "reorg"
+	// eventWallet is sent once per wallet or watch-only address of the
+	// store that a transaction pays or spends from, when it enters the
+	// mempool and again when a block confirms it.
+	eventWallet = "wallet"
+)
+
+type wsEvent struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// wsHello is the first message on a connection.
+type wsHello struct {
+	Events []string `json:"events"` // subscribed to
+	Height int      `json:"height"`
+	Tip    string   `json:"tip"`
+}
+
+type walletEvent struct {
+	Address   string  `json:"address"`
+	Height    int     `json:"block_height,omitempty"`
+	Confirmed bool    `json:"confirmed"`
+	Received  float64 `json:"received"`
+	Sent      float64 `json:"sent"`
+	TxID      string  `json:"txid"`
+	WatchOnly bool    `json:"watch_only,omitempty"`
+}
+
+type reorgEvent struct {
+	ForkHeight   int      `json:"fork_height"`
+	OldTip       string   `json:"old_tip"`
+	NewTip       string   `json:"new_tip"`
+	Depth        int      `json:"depth"`
+	Disconnected []string `json:"disconnected"`
+	Connected    []string `json:"connected"`
+}
+
+func blockHashes(blocks []*chain.Block) []string {
+	hashes := make([]string, len(blocks))
+	for i, b := range blocks {
+		hashes[i] = b.Hash
+	}
+	return hashes
+}
+
+// parseEventFilter reads ?events=block,tx,reorg,wallet. No parameter means
+// block, tx and reorg: wallet events name the store's addresses, so they
+// are only sent on request.
+func parseEventFilter(raw string) (map[string]bool, bool) {
+	if raw == "" {
+		return map[string]bool{eventBlock: true, eventTx: true, eventReorg: true}, true
+	}
+	known := map[string]bool{eventBlock: true, eventTx: true, eventReorg: true, eventWallet: true}
+	filter := make(map[string]bool)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if !known[name] {
+			return nil, false
+		}
+		filter[name] = true
+	}
+	return filter, true
+}
+
+// prevout looks up the output in spends, whether it is still unspent,
+// created by a mempool transaction or already spent on the main chain.
+func (s *Server) prevout(in chain.TxIn) (chain.TxOut, bool) {
+	if out, ok := s.blockchain.Output(chain.UTXOKey{TxID: in.TxID, Index: in.Index}); ok {
+		return out, true
+	}
+	tx, ok := s.mempool.Get(in.TxID)
+	if !ok {
+		tx, _, _, ok = s.blockchain.FindTransaction(in.TxID)
+	}
+	if !ok || in.Index < 0 || in.Index >= len(tx.Outputs) {
+		return chain.TxOut{}, false
+	}
+	return tx.Outputs[in.Index], true
+}
+
+// walletEvents lists what tx pays to and spends from the addresses the
+// wallet store tracks, by address. height is that of the confirming block.
+func (s *Server) walletEvents(tx *chain.Transaction, height int, confirmed bool) []walletEvent {
+	received := make(map[string]float64)
+	sent := make(map[string]float64)
+	for _, out := range tx.Outputs {
+		received[out.Address] += out.Amount
+	}
+	if !tx.IsCoinbase() {
+		for _, in := range tx.Inputs {
+			if out, ok := s.prevout(in); ok {
+				sent[out.Address] += out.Amount
+			}
+		}
+	}
+
+	var events []walletEvent
+	add := func(address string) {
+		tracked, watchOnly := s.walletStore.Tracks(address)
+		if !tracked {
+			return
+		}
+		events = append(events, walletEvent{
+			Address:   address,
+			Height:    height,
+			Confirmed: confirmed,
+			Received:  chain.RoundAmount(received[address]),
+			Sent:      chain.RoundAmount(sent[address]),
+			TxID:      tx.ID,
+			WatchOnly: watchOnly,
+		})
+	}
+	for address := range received {
+		add(address)
+	}
+	for address := range sent {
+		if _, ok := received[address]; !ok {
+			add(address)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Address < events[j].Address })
+	return events
+}
+
+// handleWebSocket streams chain events to the client as JSON text messages:
+// {"type":"block"|"tx"|"reorg"|"wallet","data":...}. Blocks connected by a
+// reorg are sent as block events after the reorg event itself. Wallet
+// events, which follow the block or tx event they come from, require admin
+// credentials when the admin API is enabled.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseEventFilter(r.URL.Query().Get("events"))
+	if !ok {
+		http.Error(w, "events must be a comma-separated list of block, tx, reorg, wallet", http.StatusBadRequest)
+		return
+	}
+	if filter[eventWallet] && s.adminEnabled() {
+		if _, err := s.authorizeAdmin(r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	if s.wsClients.Add(1) > DefaultMaxWebSocketClients {
+		s.wsClients.Add(-1)
+		http.Error(w, "Too many WebSocket clients", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.wsClients.Add(-1)
+
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close(1000)
+
+	var (
+		blocks <-chan *chain.Block
+		txs    <-chan *chain.Transaction
+		reorgs <-chan *chain.Reorg
+	)
+	if filter[eventBlock] || filter[eventWallet] {
+		ch, cancel := s.blockchain.SubscribeBlocks()
+		defer cancel()
+		blocks = ch
+	}
+	if filter[eventTx] || filter[eventWallet] {
+		ch, cancel := s.mempool.Subscribe()
+		defer cancel()
+		txs = ch
+	}
+	if filter[eventReorg] {
+		ch, cancel := s.blockchain.SubscribeReorgs()
+		defer cancel()
+		reorgs = ch
+	}
+
+	// Clients only send control frames; reading them is what notices a
+	// closed connection.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(eventType string, data interface{}) bool {
+		payload, err := json.Marshal(wsEvent{Type: eventType, Data: data})
+		if err != nil {
+			requestLogger(r).Error("WebSocket: failed to encode event", "event", eventType, "err", err)
+			return true
+		}
+		return conn.WriteText(payload) == nil
+	}
+	sendWallet := func(tx *chain.Transaction, height int, confirmed bool) bool {
+		for _, event := range s.walletEvents(tx, height, confirmed) {
+			if !send(eventWallet, event) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var events []string
+	for _, name := range []string{eventBlock, eventTx, eventReorg, eventWallet} {
+		if filter[name] {
+			events = append(events, name)
+		}
+	}
+	tip := s.blockchain.TipInfo()
+	if !send("hello", wsHello{Events: events, Height: tip.Height, Tip: tip.Hash}) {
+		return
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var ok bool
+		select {
+		case <-closed:
+			return
+		case <-s.closing:
+			return
+		case <-ping.C:
+			ok = conn.Ping() == nil
+		case b := <-blocks:
+			ok = !filter[eventBlock] || send(eventBlock, b)
+			for i := 0; ok && filter[eventWallet] && i < len(b.Transactions); i++ {
+				ok = sendWallet(&b.Transactions[i], b.Index, true)
+			}
+		case tx := <-txs:
+			ok = !filter[eventTx] || send(eventTx, tx)
+			if ok && filter[eventWallet] {
+				ok = sendWallet(tx, 0, false)
+			}
+		case reorg := <-reorgs:
+			ok = send(eventReorg, reorgEvent{
+				ForkHeight:   reorg.ForkHeight,
+				OldTip:       reorg.OldTip,
+				NewTip:       reorg.NewTip,
+				Depth:        reorg.Depth(),
+				Disconnected: blockHashes(reorg.Disconnected),
+				Connected:    blockHashes(reorg.Connected),
+			})
+		}
+		if !ok {
+			return
+		}
+	}
+}
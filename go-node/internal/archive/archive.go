@@ -0,0 +1,343 @@
+// Package archive keeps the main chain's blocks in flat, append-only files
+// that can be served as they are, so new nodes and analytics jobs can
+// fetch history in bulk with HTTP range requests.
+//
+// blocks.dat is a sequence of records, each a 4-byte big-endian length
+// followed by one block in the chain package's binary wire encoding
+// (chain.DecodeBlocks reads it). blocks.idx has one fixed-size entry per
+// record, in the order they were written:
+//
+//	bytes  0-31  block hash
+//	bytes 32-39  height, big-endian
+//	bytes 40-47  offset of the record in blocks.dat, big-endian
+//	bytes 48-51  length of the encoded block, big-endian
+//
+// Records are addressed by block hash, written once and never rewritten.
+// After a reorg the new branch's blocks get index entries with their
+// heights; an entry for a block already in blocks.dat points at its
+// existing record. An entry at height h supersedes every earlier entry at
+// h or above, so replaying the index in order gives the main chain as the
+// archive last saw it.
+package archive
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	DataFile  = "blocks.dat"
+	IndexFile = "blocks.idx"
+
+	// EntrySize is the size of one blocks.idx entry.
+	EntrySize = 52
+
+	recordHeader = 4
+	syncBatch    = 500
+	syncInterval = 30 * time.Second
+)
+
+var ErrUnknownBlock = errors.New("block not in archive")
+
+// Location is where a block's record sits in blocks.dat. Offset points at
+// the record's length prefix; the encoded block follows it.
+type Location struct {
+	Hash   string `json:"hash"`
+	Height int    `json:"height"`
+	Offset int64  `json:"offset"`
+	Length int    `json:"length"`
+}
+
+// Stats is what GET /archive reports.
+type Stats struct {
+	Blocks    int    `json:"blocks"`     // records in blocks.dat
+	Height    int    `json:"height"`     // main-chain blocks archived
+	DataSize  int64  `json:"data_size"`  // bytes in blocks.dat
+	IndexSize int64  `json:"index_size"` // bytes in blocks.idx
+	Tip       string `json:"tip,omitempty"`
+}
+
+// Archive appends main-chain blocks to blocks.dat and blocks.idx in a
+// directory.
+type Archive struct {
+	dir string
+
+	mu       sync.Mutex
+	data     *os.File
+	index    *os.File
+	dataSize int64
+	entries  int
+	byHash   map[string]Location
+	main     []string // hash by height, as last synced
+
+	onError func(error) // nil = Run only logs sync failures
+}
+
+// Open opens the archive in dir, creating it if needed. A record or index
+// entry cut short by a crash is dropped.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	data, err := os.OpenFile(filepath.Join(dir, DataFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, IndexFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	a := &Archive{dir: dir, data: data, index: index, byHash: make(map[string]Location)}
+	if err := a.load(); err != nil {
+		a.Close()
+		return nil, fmt.Errorf("block archive %s: %w", dir, err)
+	}
+	return a, nil
+}
+
+// load reads the index and truncates both files after the last complete
+// record.
+func (a *Archive) load() error {
+	raw, err := io.ReadAll(a.index)
+	if err != nil {
+		return err
+	}
+	dataInfo, err := a.data.Stat()
+	if err != nil {
+		return err
+	}
+
+	var end int64
+	n := 0
+	for ; (n+1)*EntrySize <= len(raw); n++ {
+		loc := decodeEntry(raw[n*EntrySize : (n+1)*EntrySize])
+		if known, ok := a.byHash[loc.Hash]; ok {
+			if known.Offset != loc.Offset || known.Length != loc.Length {
+				break
+			}
+		} else {
+			if loc.Offset != end || loc.Offset+recordHeader+int64(loc.Length) > dataInfo.Size() {
+				break
+			}
+			end = loc.Offset + recordHeader + int64(loc.Length)
+		}
+		a.byHash[loc.Hash] = loc
+		if loc.Height > len(a.main) {
+			return fmt.Errorf("entry %d skips to height %d", n, loc.Height)
+		}
+		a.main = append(a.main[:loc.Height], loc.Hash)
+	}
+
+	if n*EntrySize != len(raw) || end != dataInfo.Size() {
+		slog.Warn("Block archive has an incomplete tail, truncating", "dir", a.dir, "entries", n, "data_size", end)
+		if err := a.index.Truncate(int64(n * EntrySize)); err != nil {
+			return err
+		}
+		if err := a.data.Truncate(end); err != nil {
+			return err
+		}
+	}
+	a.entries, a.dataSize = n, end
+	return nil
+}
+
+func (a *Archive) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.index.Close()
+	return a.data.Close()
+}
+
+// appendLocked writes block's index entry at height, and its record unless
+// blocks.dat already holds it.
+func (a *Archive) appendLocked(block *chain.Block, height int) error {
+	hash, err := hex.DecodeString(block.Hash)
+	if err != nil || len(hash) != 32 {
+		return fmt.Errorf("block %s: hash is not 32 bytes of hex", block.Hash)
+	}
+
+	loc, stored := a.byHash[block.Hash]
+	if !stored {
+		encoded := chain.EncodeBlocks([]*chain.Block{block})
+		record := make([]byte, recordHeader+len(encoded))
+		binary.BigEndian.PutUint32(record, uint32(len(encoded)))
+		copy(record[recordHeader:], encoded)
+		if _, err := a.data.WriteAt(record, a.dataSize); err != nil {
+			return err
+		}
+		if err := a.data.Sync(); err != nil {
+			return err
+		}
+		loc = Location{Hash: block.Hash, Offset: a.dataSize, Length: len(encoded)}
+	}
+	loc.Height = height
+
+	entry := make([]byte, EntrySize)
+	copy(entry, hash)
+	binary.BigEndian.PutUint64(entry[32:], uint64(height))
+	binary.BigEndian.PutUint64(entry[40:], uint64(loc.Offset))
+	binary.BigEndian.PutUint32(entry[48:], uint32(loc.Length))
+	if _, err := a.index.WriteAt(entry, int64(a.entries*EntrySize)); err != nil {
+		return err
+	}
+	if err := a.index.Sync(); err != nil {
+		return err
+	}
+
+	// Only now is the record part of the archive; until then, a retry
+	// overwrites it.
+	if !stored {
+		a.dataSize += recordHeader + int64(loc.Length)
+	}
+	a.entries++
+	a.byHash[block.Hash] = loc
+	a.main = append(a.main[:height], block.Hash)
+	return nil
+}
+
+func decodeEntry(entry []byte) Location {
+	return Location{
+		Hash:   hex.EncodeToString(entry[:32]),
+		Height: int(binary.BigEndian.Uint64(entry[32:])),
+		Offset: int64(binary.BigEndian.Uint64(entry[40:])),
+		Length: int(binary.BigEndian.Uint32(entry[48:])),
+	}
+}
+
+// Sync indexes the main-chain blocks the archive lacks, writing the
+// records of those blocks.dat does not hold yet. Blocks a reorg replaced
+// stay in the files. It returns how many blocks were indexed.
+func (a *Archive) Sync(bc *chain.Blockchain) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.main) > 0 && a.main[0] != bc.Genesis().Hash {
+		return 0, fmt.Errorf("block archive %s holds a different chain (genesis %s)", a.dir, a.main[0])
+	}
+	// Find the highest height the archive and the chain agree on; the
+	// hashes chain, so they agree on everything below it too.
+	height := len(a.main)
+	for height > 0 {
+		blocks := bc.BlocksFrom(height-1, 1)
+		if len(blocks) == 1 && blocks[0].Hash == a.main[height-1] {
+			break
+		}
+		height--
+	}
+
+	indexed := 0
+	for {
+		blocks := bc.BlocksFrom(height, syncBatch)
+		if len(blocks) == 0 {
+			return indexed, nil
+		}
+		for _, block := range blocks {
+			if err := a.appendLocked(block, height); err != nil {
+				return indexed, err
+			}
+			height++
+			indexed++
+		}
+	}
+}
+
+// SetErrorHandler has Run report sync failures to fn as well as the log.
+func (a *Archive) SetErrorHandler(fn func(error)) {
+	a.onError = fn
+}
+
+// Run keeps the archive in step with bc until ctx is done.
+func (a *Archive) Run(ctx context.Context, bc *chain.Blockchain) {
+	blocks, cancelBlocks := bc.SubscribeBlocks()
+	defer cancelBlocks()
+	reorgs, cancelReorgs := bc.SubscribeReorgs()
+	defer cancelReorgs()
+	// Subscribers may miss notifications; the ticker catches up regardless.
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := a.Sync(bc); err != nil {
+			slog.Error("Block archive sync failed", "dir", a.dir, "err", err)
+			if a.onError != nil {
+				a.onError(fmt.Errorf("block archive %s: %w", a.dir, err))
+			}
+		} else if n > 0 {
+			slog.Debug("Blocks archived", "count", n, "height", a.Stats().Height)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-blocks:
+		case <-reorgs:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Locate finds the record of the block with hash.
+func (a *Archive) Locate(hash string) (Location, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	loc, ok := a.byHash[hash]
+	if !ok {
+		return Location{}, ErrUnknownBlock
+	}
+	return loc, nil
+}
+
+// Block reads and decodes the block with hash.
+func (a *Archive) Block(hash string) (*chain.Block, error) {
+	loc, err := a.Locate(hash)
+	if err != nil {
+		return nil, err
+	}
+	encoded := make([]byte, loc.Length)
+	if _, err := a.data.ReadAt(encoded, loc.Offset+recordHeader); err != nil {
+		return nil, err
+	}
+	blocks, err := chain.DecodeBlocks(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return blocks[0], nil
+}
+
+// Files returns readers over blocks.dat and blocks.idx as far as they had
+// been written when called, and how many records that is. Later appends
+// do not change what the readers return, so they can be served with range
+// requests while the archive grows.
+func (a *Archive) Files() (data, index *io.SectionReader, blocks int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	data = io.NewSectionReader(a.data, 0, a.dataSize)
+	index = io.NewSectionReader(a.index, 0, int64(a.entries*EntrySize))
+	return data, index, a.entries
+}
+
+func (a *Archive) Stats() Stats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := Stats{
+		Blocks:    a.entries,
+		Height:    len(a.main),
+		DataSize:  a.dataSize,
+		IndexSize: int64(a.entries * EntrySize),
+	}
+	if len(a.main) > 0 {
+		stats.Tip = a.main[len(a.main)-1]
+	}
+	return stats
+}
@@ -0,0 +1,120 @@
+package chain
+
+// AddressTx is a main-chain transaction that paid or spent from an address.
+type AddressTx struct {
+	TxID      string  `json:"txid"`
+	BlockHash string  `json:"block_hash"`
+	Height    int     `json:"block_height"`
+	Index     int     `json:"index"` // position within the block
+	Timestamp int64   `json:"timestamp"`
+	Received  float64 `json:"received"` // paid to the address by the transaction's outputs
+	Sent      float64 `json:"sent"`     // spent from the address by its inputs
+	Memo      string  `json:"memo,omitempty"`
+}
+
+// addressIndex lists, for every address, the main-chain transactions that
+// touch it in chain order. It is maintained alongside txIndex as blocks are
+// connected and disconnected.
+type addressIndex map[string][]AddressTx
+
+// addBlock indexes block at height. spent is the block's undo data, which
+// supplies the address and amount of every output its inputs consumed
+// except those created earlier in the block itself.
+func (idx addressIndex) addBlock(block *Block, height int, spent SpentOutputs) {
+	prevouts := make(map[UTXOKey]TxOut, len(spent))
+	for _, s := range spent {
+		prevouts[s.Key] = s.Output
+	}
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		touched := make(map[string]*AddressTx)
+		var order []string
+		entry := func(address string) *AddressTx {
+			e, ok := touched[address]
+			if !ok {
+				e = &AddressTx{TxID: tx.ID, BlockHash: block.Hash, Height: height, Index: i, Timestamp: block.Timestamp, Memo: tx.Memo}
+				touched[address] = e
+				order = append(order, address)
+			}
+			return e
+		}
+
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				if out, ok := prevouts[UTXOKey{TxID: in.TxID, Index: in.Index}]; ok {
+					e := entry(out.Address)
+					e.Sent = RoundAmount(e.Sent + out.Amount)
+				}
+			}
+		}
+		for j, out := range tx.Outputs {
+			e := entry(out.Address)
+			e.Received = RoundAmount(e.Received + out.Amount)
+			prevouts[UTXOKey{TxID: tx.ID, Index: j}] = out
+		}
+
+		for _, address := range order {
+			idx[address] = append(idx[address], *touched[address])
+		}
+	}
+}
+
+// removeBlock drops block's entries. A block's entries are contiguous in
+// each address's list, so they are found by scanning back from the end.
+func (idx addressIndex) removeBlock(block *Block, spent SpentOutputs) {
+	addresses := make(map[string]bool)
+	for _, s := range spent {
+		addresses[s.Output.Address] = true
+	}
+	for i := range block.Transactions {
+		for _, out := range block.Transactions[i].Outputs {
+			addresses[out.Address] = true
+		}
+	}
+
+	for address := range addresses {
+		entries := idx[address]
+		end := len(entries)
+		for end > 0 && entries[end-1].BlockHash != block.Hash {
+			end--
+		}
+		start := end
+		for start > 0 && entries[start-1].BlockHash == block.Hash {
+			start--
+		}
+		entries = append(entries[:start], entries[end:]...)
+		if len(entries) == 0 {
+			delete(idx, address)
+		} else {
+			idx[address] = entries
+		}
+	}
+}
+
+// AddressHistory returns every main-chain transaction that paid or spent
+// from address, oldest first.
+func (bc *Blockchain) AddressHistory(address string) []AddressTx {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return append([]AddressTx(nil), bc.addrIndex[address]...)
+}
+
+// AddressBalances returns the confirmed balance of each address, summed from
+// the address index rather than by scanning the UTXO set. Addresses the
+// chain has never touched have a balance of 0.
+func (bc *Blockchain) AddressBalances(addresses []string) map[string]float64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	balances := make(map[string]float64, len(addresses))
+	for _, address := range addresses {
+		var balance float64
+		for _, e := range bc.addrIndex[address] {
+			balance += e.Received - e.Sent
+		}
+		balances[address] = RoundAmount(balance)
+	}
+	return balances
+}
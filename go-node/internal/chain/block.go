@@ -4,17 +4,22 @@ import (
 	"encoding/json"
 	"time"
 
+	"ai-blockchain/go-node/internal/consensus"
 	"ai-blockchain/go-node/internal/crypto"
 )
 
 type Block struct {
-	Index       int           `json:"index"`        // position in the chain
-	Timestamp   int64         `json:"timestamp"`    // block creation time
-	PrevHash    string        `json:"prevHash"`     // hash of previous block
-	MerkleRoot  string        `json:"merkleRoot"`   // commitment to transactions
-	Transactions []Transaction `json:"transactions"`
-	Hash        string        `json:"hash"`         // hash of this block
-	Nonce       int64         `json:"nonce"`        // used later for PoW / PoA
+	Index          int           `json:"index"`      // position in the chain
+	Timestamp      int64         `json:"timestamp"`  // block creation time
+	PrevHash       string        `json:"prevHash"`   // hash of previous block
+	MerkleRoot     string        `json:"merkleRoot"` // commitment to transactions
+	Transactions   []Transaction `json:"transactions"`
+	Hash           string        `json:"hash"`                      // hash of this block
+	Nonce          int64         `json:"nonce"`                     // used later for PoW / PoA
+	Difficulty     int           `json:"difficulty,omitempty"`      // proof-of-work difficulty the block meets (0 for genesis)
+	PowAlgorithm   string        `json:"pow_algorithm,omitempty"`   // hash the block hash is computed with (empty = sha256)
+	MinerPubKey    string        `json:"miner_pubkey,omitempty"`    // producer's public key, covered by the hash (empty = anonymous)
+	MinerSignature string        `json:"miner_signature,omitempty"` // producer's signature over the hash
 }
 
 func NewBlock(
@@ -54,17 +59,23 @@ func (b *Block) computeMerkleRoot() string {
 
 func (b *Block) computeHash() string {
 	hashData := struct {
-		Index      int    `json:"index"`
-		Timestamp  int64  `json:"timestamp"`
-		PrevHash   string `json:"prevHash"`
-		MerkleRoot string `json:"merkleRoot"`
-		Nonce      int64  `json:"nonce"`
+		Index        int    `json:"index"`
+		Timestamp    int64  `json:"timestamp"`
+		PrevHash     string `json:"prevHash"`
+		MerkleRoot   string `json:"merkleRoot"`
+		Nonce        int64  `json:"nonce"`
+		Difficulty   int    `json:"difficulty,omitempty"`    // omitted when 0 so genesis hashes are unchanged
+		PowAlgorithm string `json:"pow_algorithm,omitempty"` // omitted for sha256 for the same reason
+		MinerPubKey  string `json:"miner_pubkey,omitempty"`  // omitted for anonymous blocks
 	}{
-		Index:      b.Index,
-		Timestamp:  b.Timestamp,
-		PrevHash:   b.PrevHash,
-		MerkleRoot: b.MerkleRoot,
-		Nonce:      b.Nonce,
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		PrevHash:     b.PrevHash,
+		MerkleRoot:   b.MerkleRoot,
+		Nonce:        b.Nonce,
+		Difficulty:   b.Difficulty,
+		PowAlgorithm: b.PowAlgorithm,
+		MinerPubKey:  b.MinerPubKey,
 	}
 
 	data, err := json.Marshal(hashData)
@@ -78,5 +89,5 @@ func (b *Block) computeHash() string {
 		)
 	}
 
-	return crypto.SHA256(data)
+	return consensus.Algorithm(b.PowAlgorithm).Hash(data)
 }
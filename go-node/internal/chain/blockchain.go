@@ -1,8 +1,43 @@
 package chain
 
+import (
+	"errors"
+	"sync"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+var (
+	ErrChainFrozen = errors.New("chain is frozen by operator")
+	ErrNotOnTip    = errors.New("stale tip: block's parent is no longer the chain tip")
+)
+
 type Blockchain struct {
-	Blocks []*Block // ordered list of blocks
-	UTXO   *UTXOSet // current ledger state (derived)
+	mu     sync.RWMutex
+	Blocks []*Block    // ordered list of blocks
+	UTXO   *UTXOSet    // current ledger state (derived)
+	Stale  *StaleStore // blocks that lost the race for the tip
+
+	frozen       bool
+	freezeReason string
+	reward       float64
+	difficulty   int // initial difficulty, and the only one without retargeting
+	retarget     RetargetPolicy
+	powAlgorithm string // from the genesis block, which fixes it for the network
+	clock        *NetworkClock
+
+	nodes      map[string]*blockNode   // every known block by hash, main chain or not
+	index      headerIndex             // main-chain headers
+	txIndex    txIndex                 // main-chain transactions by txid
+	addrIndex  addressIndex            // main-chain transactions by address
+	sideBlocks map[string]*Block       // bodies of blocks not on the main chain
+	undo       map[string]SpentOutputs // outputs each connected block spent, by block hash
+
+	maxReorgDepth int // how far below the tip a side branch may fork off
+	maxSideBlocks int // cap on sideBlocks
+
+	subscribers      []chan *Block
+	reorgSubscribers []chan *Reorg
 }
 
 func NewBlockchain(genesis *Block) *Blockchain {
@@ -15,25 +50,341 @@ func NewBlockchain(genesis *Block) *Blockchain {
 		utxo.ApplyTransaction(&tx)
 	}
 
+	txs := make(txIndex)
+	txs.addBlock(genesis, 0)
+	addrs := make(addressIndex)
+	addrs.addBlock(genesis, 0, nil)
+
 	return &Blockchain{
-		Blocks: []*Block{genesis},
-		UTXO:   utxo,
+		Blocks:       []*Block{genesis},
+		UTXO:         utxo,
+		Stale:        NewStaleStore(DefaultMaxStaleBlocks),
+		reward:       consensus.DefaultBlockReward,
+		difficulty:   consensus.DefaultDifficulty,
+		powAlgorithm: genesis.PowAlgorithm,
+		clock:        NewNetworkClock(),
+		nodes: map[string]*blockNode{
+			genesis.Hash: newBlockNode(genesis, nil),
+		},
+		index:      newHeaderIndex(genesis),
+		txIndex:    txs,
+		addrIndex:  addrs,
+		sideBlocks: make(map[string]*Block),
+		undo:       make(map[string]SpentOutputs),
+
+		maxReorgDepth: DefaultMaxReorgDepth,
+		maxSideBlocks: DefaultMaxSideBlocks,
 	}
 }
 
 func (bc *Blockchain) Tip() *Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
 	return bc.Blocks[len(bc.Blocks)-1]
 }
 
 func (bc *Blockchain) Height() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
 	return len(bc.Blocks)
 }
 
-func (bc *Blockchain) AddBlock(block *Block) {
+// SetBlockReward sets the subsidy a coinbase may claim on top of fees. All
+// nodes on a network must agree on it.
+func (bc *Blockchain) SetBlockReward(reward float64) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.reward = reward
+}
+
+func (bc *Blockchain) BlockReward() float64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.reward
+}
+
+// Freeze stops the chain from accepting new blocks until Unfreeze is called,
+// e.g. while operators investigate a suspected consensus bug.
+func (bc *Blockchain) Freeze(reason string) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.frozen = true
+	bc.freezeReason = reason
+}
+
+func (bc *Blockchain) Unfreeze() {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.frozen = false
+	bc.freezeReason = ""
+}
+
+func (bc *Blockchain) Frozen() (bool, string) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.frozen, bc.freezeReason
+}
+
+// AddBlock connects a locally built block on top of the tip after running
+// every consensus check against the live chain state: hash, merkle root,
+// proof of work, the difficulty the chain requires next, a timestamp no
+// further ahead of network-adjusted time than MaxFutureBlockTime and each
+// transaction against the current UTXO set. A block whose parent is no
+// longer the tip fails with ErrNotOnTip; blocks on other branches go
+// through ProcessBlock.
+func (bc *Blockchain) AddBlock(block *Block) error {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
 
-	for _, tx := range block.Transactions {
-		bc.UTXO.ApplyTransaction(&tx)
+	if bc.frozen {
+		return ErrChainFrozen
+	}
+	if _, ok := bc.nodes[block.Hash]; ok {
+		return ErrDuplicateBlock
+	}
+	tip := bc.tipNode()
+	if block.PrevHash != tip.header.Hash {
+		return ErrNotOnTip
+	}
+	if err := bc.verifyOnTip(block, tip); err != nil {
+		return err
 	}
 
+	bc.nodes[block.Hash] = newBlockNode(block, tip)
+	bc.connect(block)
+	return nil
+}
+
+// verifyStateOnTip checks a block's transactions against the UTXO set, as
+// long as the block still builds on the tip it describes.
+func (bc *Blockchain) verifyStateOnTip(block *Block) error {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if block.PrevHash != bc.index.tip().Hash {
+		return ErrNotOnTip
+	}
+	return VerifyBlockState(block, bc.UTXO, bc.reward)
+}
+
+// verifyOnTip runs every consensus check on a block whose parent is the
+// tip. Callers hold bc.mu.
+func (bc *Blockchain) verifyOnTip(block *Block, tip *blockNode) error {
+	if err := checkDifficulty(block, bc.nextDifficulty(tip)); err != nil {
+		return err
+	}
+	if err := checkBlockHeader(block, bc.powAlgorithm); err != nil {
+		return err
+	}
+	if block.Index != tip.height+1 {
+		return errors.New("block index is not sequential")
+	}
+	if err := checkMedianTimePast(block, medianTimePast(tip)); err != nil {
+		return err
+	}
+	if err := checkBlockTime(block, bc.clock.Now()); err != nil {
+		return err
+	}
+	return VerifyBlockState(block, bc.UTXO, bc.reward)
+}
+
+// connect applies a block on top of the tip. Callers hold bc.mu and have
+// validated the block.
+func (bc *Blockchain) connect(block *Block) {
+	spent := bc.UTXO.ApplyBlock(block)
+	bc.undo[block.Hash] = spent
+
 	bc.Blocks = append(bc.Blocks, block)
+	bc.index.append(block.Header())
+	bc.txIndex.addBlock(block, len(bc.Blocks)-1)
+	bc.addrIndex.addBlock(block, len(bc.Blocks)-1, spent)
+	bc.notifyBlock(block)
+	bc.pruneSideBlocks()
+}
+
+func (bc *Blockchain) notifyBlock(block *Block) {
+	for _, ch := range bc.subscribers {
+		select {
+		case ch <- block:
+		default: // slow subscriber, drop rather than stall the chain
+		}
+	}
+}
+
+// SubscribeBlocks returns a channel receiving every block appended to the
+// chain and a function that unregisters it.
+func (bc *Blockchain) SubscribeBlocks() (<-chan *Block, func()) {
+	ch := make(chan *Block, 16)
+
+	bc.mu.Lock()
+	bc.subscribers = append(bc.subscribers, ch)
+	bc.mu.Unlock()
+
+	cancel := func() {
+		bc.mu.Lock()
+		defer bc.mu.Unlock()
+		for i, sub := range bc.subscribers {
+			if sub == ch {
+				bc.subscribers = append(bc.subscribers[:i], bc.subscribers[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// Clock is the network-adjusted clock new blocks' timestamps are checked
+// against. The P2P network feeds it the peers' clocks.
+func (bc *Blockchain) Clock() *NetworkClock {
+	return bc.clock
+}
+
+// MedianTimePast is the median timestamp of the last MedianTimeSpan blocks;
+// a block extending the current tip must be stamped later.
+func (bc *Blockchain) MedianTimePast() int64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return medianTimePast(bc.tipNode())
+}
+
+// medianTimePastAfter is the median-time-past a block whose parent is
+// prevHash must be stamped after, on any branch.
+func (bc *Blockchain) medianTimePastAfter(prevHash string) (int64, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	parent, ok := bc.nodes[prevHash]
+	if !ok {
+		return 0, false
+	}
+	return medianTimePast(parent), true
+}
+
+// PowAlgorithm is the proof-of-work algorithm every block must name, as the
+// genesis block names it: empty for sha256.
+func (bc *Blockchain) PowAlgorithm() string {
+	return bc.powAlgorithm
+}
+
+func (bc *Blockchain) Genesis() *Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.Blocks[0]
+}
+
+// NetworkID identifies the network a node belongs to. Nodes only share a
+// chain when they share a genesis block, so the ID is derived from its hash.
+func (bc *Blockchain) NetworkID() string {
+	return bc.Genesis().Hash[:16]
+}
+
+// BlocksFrom returns up to limit main-chain blocks starting at height from.
+func (bc *Blockchain) BlocksFrom(from, limit int) []*Block {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if from < 0 || from >= len(bc.Blocks) {
+		return nil
+	}
+	end := len(bc.Blocks)
+	if limit > 0 && from+limit < end {
+		end = from + limit
+	}
+	out := make([]*Block, end-from)
+	copy(out, bc.Blocks[from:end])
+	return out
+}
+
+// UTXOSnapshot returns a private copy of the UTXO set at the current tip.
+// Callers may apply or undo blocks on it freely; the canonical set is never
+// touched.
+func (bc *Blockchain) UTXOSnapshot() *UTXOSet {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.Clone()
+}
+
+// Output looks up an unspent output at the current tip.
+func (bc *Blockchain) Output(key UTXOKey) (TxOut, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.Get(key)
+}
+
+// BalanceOf sums the unspent outputs of address at the current tip.
+func (bc *Blockchain) BalanceOf(address string) float64 {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.BalanceOf(address)
+}
+
+// UnspentOutputs lists the unspent outputs of address at the current tip.
+func (bc *Blockchain) UnspentOutputs(address string) []UTXO {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.UnspentOutputs(address)
+}
+
+// UTXOCountOf is the number of unspent outputs address holds at the
+// current tip.
+func (bc *Blockchain) UTXOCountOf(address string) int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.CountOf(address)
+}
+
+// UTXOStats describes the ledger at the current tip.
+type UTXOStats struct {
+	Height   int    `json:"height"`
+	TipHash  string `json:"tip_hash"`
+	Count    int    `json:"utxo_count"`
+	UTXOHash string `json:"utxo_hash"`
+}
+
+// UTXOStats reads the tip and the UTXO set hash together, so the hash can be
+// compared with another node's at the same tip.
+func (bc *Blockchain) UTXOStats() UTXOStats {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return UTXOStats{
+		Height:   len(bc.Blocks),
+		TipHash:  bc.Blocks[len(bc.Blocks)-1].Hash,
+		Count:    bc.UTXO.Count(),
+		UTXOHash: bc.UTXO.Hash(),
+	}
+}
+
+// Holders totals the UTXO set by address as UTXOSet.Holders does, together
+// with the tip the totals belong to.
+func (bc *Blockchain) Holders() ([]AddressBalance, *Block) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.UTXO.Holders(), bc.Blocks[len(bc.Blocks)-1]
+}
+
+// StaleRate is the fraction of produced blocks (excluding genesis) that ended
+// up stale rather than on the main chain.
+func (bc *Blockchain) StaleRate() float64 {
+	stale := bc.Stale.Total()
+	produced := stale + bc.Height() - 1
+	if produced <= 0 {
+		return 0
+	}
+	return float64(stale) / float64(produced)
 }
@@ -0,0 +1,216 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Peers send their time in the P2P handshake. Like Bitcoin, a node adjusts
+// its clock by the median offset of its peers' clocks from its own and
+// checks block timestamps against that network-adjusted time, so a node
+// whose clock is a little off agrees with the network on which blocks are
+// too far in the future.
+
+const (
+	// MaxFutureBlockTime is how far ahead of network-adjusted time a block
+	// may be stamped.
+	MaxFutureBlockTime = 2 * time.Hour
+	// MaxClockAdjustment bounds the offset the clock applies. A larger
+	// median more likely means the local clock is wrong, or the peers are
+	// lying, than that it should be followed.
+	MaxClockAdjustment = 70 * time.Minute
+	// DefaultClockSkewWarning is the median offset above which the
+	// operator is warned to check the local clock.
+	DefaultClockSkewWarning = 5 * time.Minute
+	// MedianTimeSpan is how many blocks the median-time-past is taken
+	// over. A block must be stamped later than that median, so timestamps
+	// move forward even though any one of them may run behind its parent.
+	MedianTimeSpan = 11
+
+	minClockSamples = 5
+	maxClockSamples = 200
+	// maxSampleOffset bounds a single peer's offset. Anything past
+	// MaxClockAdjustment is ignored anyway; the bound keeps a hostile
+	// timestamp from overflowing a Duration.
+	maxSampleOffset = 24 * time.Hour
+)
+
+// ClockStatus is the state of a NetworkClock. Offsets are peer clocks minus
+// the local clock, in seconds.
+type ClockStatus struct {
+	AdjustedTime  int64   `json:"adjusted_time"`
+	LocalTime     int64   `json:"local_time"`
+	MedianOffset  float64 `json:"median_offset"`
+	Offset        float64 `json:"offset"` // what adjusted_time adds to local_time
+	Samples       int     `json:"samples"`
+	Skewed        bool    `json:"skewed"` // median_offset is beyond warn_threshold
+	WarnThreshold float64 `json:"warn_threshold"`
+}
+
+// NetworkClock is local time corrected by the median offset of peer clocks.
+// It takes one sample per peer host, the first it sees, and keeps at most
+// maxClockSamples of them. With fewer than minClockSamples it reports local
+// time unchanged.
+type NetworkClock struct {
+	mu      sync.Mutex
+	samples map[string]time.Duration // by peer host
+	median  time.Duration
+	offset  time.Duration
+	warnAt  time.Duration
+	warned  bool
+}
+
+func NewNetworkClock() *NetworkClock {
+	return &NetworkClock{
+		samples: make(map[string]time.Duration),
+		warnAt:  DefaultClockSkewWarning,
+	}
+}
+
+// SetWarnThreshold sets the median offset above which the clock logs a
+// warning (0 = never).
+func (c *NetworkClock) SetWarnThreshold(threshold time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnAt = threshold
+}
+
+// PeerClockOffset is how far a peer whose clock reads peerTime, in Unix
+// seconds, is ahead of now, clamped to maxSampleOffset either way.
+func PeerClockOffset(peerTime int64, now time.Time) time.Duration {
+	local := now.Unix()
+	limit := int64(maxSampleOffset / time.Second)
+	switch {
+	case peerTime > local+limit:
+		return maxSampleOffset
+	case peerTime < local-limit:
+		return -maxSampleOffset
+	}
+	return time.Duration(peerTime-local) * time.Second
+}
+
+// AddSample records that the clock of the peer at host reads offset ahead of
+// ours. Offsets beyond maxSampleOffset count as that much.
+func (c *NetworkClock) AddSample(host string, offset time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.samples[host]; ok || len(c.samples) >= maxClockSamples {
+		return
+	}
+	c.samples[host] = max(-maxSampleOffset, min(offset, maxSampleOffset))
+	if len(c.samples) < minClockSamples {
+		return
+	}
+
+	offsets := make([]time.Duration, 0, len(c.samples))
+	for _, o := range c.samples {
+		offsets = append(offsets, o)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	c.median = offsets[len(offsets)/2]
+	if len(offsets)%2 == 0 {
+		c.median = (offsets[len(offsets)/2-1] + c.median) / 2
+	}
+
+	c.offset = c.median
+	if absDuration(c.median) > MaxClockAdjustment {
+		c.offset = 0
+	}
+
+	skewed := c.skewedLocked()
+	if skewed && !c.warned {
+		slog.Warn("Local clock differs from the network's; check the system time",
+			"median_offset", c.median.String(), "samples", len(c.samples), "applied", c.offset != 0)
+	} else if !skewed && c.warned {
+		slog.Info("Local clock agrees with the network again", "median_offset", c.median.String())
+	}
+	c.warned = skewed
+}
+
+func (c *NetworkClock) skewedLocked() bool {
+	return c.warnAt > 0 && absDuration(c.median) > c.warnAt
+}
+
+// Offset is the correction applied to local time.
+func (c *NetworkClock) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.offset
+}
+
+// Skew is the median offset of peer clocks from ours, whether or not it is
+// applied, and whether it exceeds the warning threshold.
+func (c *NetworkClock) Skew() (time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.median, c.skewedLocked()
+}
+
+// Now is the network-adjusted time.
+func (c *NetworkClock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+func (c *NetworkClock) Status() ClockStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	return ClockStatus{
+		AdjustedTime:  now.Add(c.offset).Unix(),
+		LocalTime:     now.Unix(),
+		MedianOffset:  c.median.Seconds(),
+		Offset:        c.offset.Seconds(),
+		Samples:       len(c.samples),
+		Skewed:        c.skewedLocked(),
+		WarnThreshold: c.warnAt.Seconds(),
+	}
+}
+
+// ErrBlockTooNew marks a block stamped too far ahead of network-adjusted
+// time. It depends on the local clock as much as on the block, and the
+// block is not stored, so the same block may be accepted later.
+var ErrBlockTooNew = errors.New("block timestamp too far in the future")
+
+// checkBlockTime rejects blocks stamped more than MaxFutureBlockTime after
+// now. Only blocks arriving now are checked: a block that was acceptable
+// when it was connected stays valid as the clock moves.
+func checkBlockTime(block *Block, now time.Time) error {
+	if limit := now.Add(MaxFutureBlockTime).Unix(); block.Timestamp > limit {
+		return fmt.Errorf("%w: timestamp %d is more than %s ahead of network-adjusted time %d",
+			ErrBlockTooNew, block.Timestamp, MaxFutureBlockTime, now.Unix())
+	}
+	return nil
+}
+
+// medianTimePast is the median timestamp of parent and up to
+// MedianTimeSpan-1 of its ancestors.
+func medianTimePast(parent *blockNode) int64 {
+	timestamps := make([]int64, 0, MedianTimeSpan)
+	for node := parent; node != nil && len(timestamps) < MedianTimeSpan; node = node.parent {
+		timestamps = append(timestamps, node.header.Timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2]
+}
+
+// checkMedianTimePast rejects blocks stamped no later than the
+// median-time-past of their parent's branch, the lower bound to
+// checkBlockTime's upper one.
+func checkMedianTimePast(block *Block, mtp int64) error {
+	if block.Timestamp <= mtp {
+		return fmt.Errorf("block timestamp %d is not after the median time past %d", block.Timestamp, mtp)
+	}
+	return nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,100 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// Peers send their clock as a raw int64; the extremes once overflowed the
+// Duration the offset was converted to.
+func TestPeerClockOffset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		peerTime int64
+		want     time.Duration
+	}{
+		{now.Unix(), 0},
+		{now.Unix() + 90, 90 * time.Second},
+		{now.Unix() - 90, -90 * time.Second},
+		{math.MaxInt64, maxSampleOffset},
+		{math.MinInt64, -maxSampleOffset},
+		{1, -maxSampleOffset},
+	}
+	for _, tt := range tests {
+		if got := PeerClockOffset(tt.peerTime, now); got != tt.want {
+			t.Errorf("PeerClockOffset(%d) = %s, want %s", tt.peerTime, got, tt.want)
+		}
+	}
+
+	clock := NewNetworkClock()
+	for i := 0; i < minClockSamples+1; i++ {
+		offset := time.Duration(math.MaxInt64)
+		if i%2 == 1 {
+			offset = time.Duration(math.MinInt64)
+		}
+		clock.AddSample(fmt.Sprintf("peer%d", i), offset)
+	}
+	if median, _ := clock.Skew(); absDuration(median) > maxSampleOffset {
+		t.Fatalf("median offset %s beyond the sample bound", median)
+	}
+	if offset := clock.Offset(); offset != 0 {
+		t.Fatalf("applied offset %s from out-of-range samples", offset)
+	}
+}
+
+// A block must be stamped after the median of the last MedianTimeSpan
+// timestamps, however far in the past it stays within the future limit.
+func TestMedianTimePast(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	bc := newTestChain(t, address)
+	for i := 0; i < MedianTimeSpan; i++ {
+		if err := bc.AddBlock(mineTestBlock(t, bc, address)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	genesis := bc.Genesis().Timestamp
+	// Timestamps run genesis+0 through genesis+11.
+	if mtp, want := bc.MedianTimePast(), genesis+6; mtp != want {
+		t.Fatalf("median time past %d, want %d", mtp, want)
+	}
+
+	stamped := func(timestamp int64) *Block {
+		t.Helper()
+		block := mineTestBlock(t, bc, address)
+		block.Timestamp = timestamp
+		block.Nonce = 0
+		for block.Hash = block.ComputeHash(); !consensus.ValidateProofOfWork(block.Hash, block.Difficulty); block.Hash = block.ComputeHash() {
+			block.Nonce++
+		}
+		return block
+	}
+	for _, timestamp := range []int64{genesis, genesis + 6} {
+		block := stamped(timestamp)
+		if err := VerifyBlockHeader(block, bc); err == nil || !strings.Contains(err.Error(), "median time past") {
+			t.Errorf("VerifyBlockHeader, timestamp %d: err = %v, want median time past", timestamp, err)
+		}
+		if err := bc.AddBlock(block); err == nil || !strings.Contains(err.Error(), "median time past") {
+			t.Fatalf("AddBlock, timestamp %d: err = %v, want median time past", timestamp, err)
+		}
+	}
+	// Behind its parent but after the median is fine.
+	if err := bc.AddBlock(stamped(genesis + 7)); err != nil {
+		t.Fatalf("timestamp after the median time past: %v", err)
+	}
+
+	// Too far ahead is ErrBlockTooNew, which peers are not banned for, and
+	// leaves nothing behind: the same block is rejected again rather than
+	// reported as known.
+	future := stamped(time.Now().Add(MaxFutureBlockTime + time.Hour).Unix())
+	for i := 0; i < 2; i++ {
+		if _, err := bc.ProcessBlock(future); !errors.Is(err, ErrBlockTooNew) {
+			t.Fatalf("ProcessBlock, attempt %d: err = %v, want %v", i+1, err, ErrBlockTooNew)
+		}
+	}
+}
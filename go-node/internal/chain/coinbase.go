@@ -0,0 +1,83 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CoinbaseInputID is the previous-output txid of a coinbase's single input.
+// The input index carries the block height, which keeps coinbase txids unique.
+var CoinbaseInputID = strings.Repeat("0", 64)
+
+// coinbaseTolerance absorbs float rounding between the fees a miner summed and
+// the 8-decimal amounts that end up serialized in the block.
+const coinbaseTolerance = 1e-8
+
+var (
+	ErrCoinbaseOutsideBlock = errors.New("coinbase transactions are only valid as the first transaction of a block")
+	ErrCoinbaseMisplaced    = errors.New("coinbase must be the first transaction in the block")
+)
+
+// NewCoinbaseTransaction pays amount (block reward plus fees) to address in
+// the block at the given height. The amount is rounded to 8 decimals, since
+// summed fees can carry float noise below the last serialized digit.
+func NewCoinbaseTransaction(height int, address string, amount float64) (*Transaction, error) {
+	tx, err := NewTransaction(
+		[]TxIn{{TxID: CoinbaseInputID, Index: height}},
+		[]TxOut{{Address: address, Amount: RoundAmount(amount)}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	tx.Signature = "coinbase"
+	tx.PubKey = "coinbase"
+	return tx, nil
+}
+
+func (tx *Transaction) IsCoinbase() bool {
+	return len(tx.Inputs) == 1 && tx.Inputs[0].TxID == CoinbaseInputID
+}
+
+func verifyCoinbase(tx *Transaction, height int, maxAmount float64) error {
+	computedID, err := ComputeTxID(tx)
+	if err != nil {
+		return err
+	}
+	if computedID != tx.ID {
+		return errors.New("coinbase transaction ID mismatch")
+	}
+
+	if err := CheckMemo(tx.Memo); err != nil {
+		return err
+	}
+	if tx.Multisig != nil {
+		return errors.New("coinbase transaction must not carry a multisig witness")
+	}
+
+	if tx.Inputs[0].Index != height {
+		return fmt.Errorf("coinbase height %d does not match block height %d", tx.Inputs[0].Index, height)
+	}
+
+	if len(tx.Outputs) == 0 {
+		return errors.New("coinbase must have at least one output")
+	}
+
+	// The txid and wire form round amounts to 8 decimals; anything finer
+	// would enter the UTXO set differently here than on a decoding peer.
+	if err := checkCanonicalOutputs(tx.Outputs); err != nil {
+		return err
+	}
+
+	total, err := checkOutputAmounts(tx.Outputs)
+	if err != nil {
+		return err
+	}
+
+	if total > maxAmount+coinbaseTolerance {
+		return fmt.Errorf("coinbase pays %s, more than reward plus fees %s", FormatAmount(total), FormatAmount(maxAmount))
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package chain
+
+import (
+	"fmt"
+	"time"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// RetargetPolicy controls difficulty adjustment. Every Interval blocks the
+// time taken by the preceding blocks is compared with TargetBlockTime and
+// the difficulty moves one step up or down through
+// consensus.AdjustDifficulty. All nodes on a network must agree on it.
+type RetargetPolicy struct {
+	Interval        int // blocks between adjustments (0 = fixed difficulty)
+	TargetBlockTime time.Duration
+}
+
+func (p RetargetPolicy) Enabled() bool {
+	return p.Interval > 0 && p.TargetBlockTime > 0
+}
+
+func (bc *Blockchain) SetRetargetPolicy(policy RetargetPolicy) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.retarget = policy
+}
+
+func (bc *Blockchain) RetargetPolicy() RetargetPolicy {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.retarget
+}
+
+// NextDifficulty is the difficulty a block extending the current tip must
+// declare and meet.
+func (bc *Blockchain) NextDifficulty() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.nextDifficulty(bc.tipNode())
+}
+
+// ExpectedDifficulty is the difficulty required of a block whose parent is
+// prevHash, on any branch.
+func (bc *Blockchain) ExpectedDifficulty(prevHash string) (int, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	parent, ok := bc.nodes[prevHash]
+	if !ok {
+		return 0, false
+	}
+	return bc.nextDifficulty(parent), true
+}
+
+// nextDifficulty derives the difficulty of parent's child from the headers
+// on parent's branch. Genesis declares none, so the chain's configured
+// difficulty applies until the first adjustment. Callers hold bc.mu.
+func (bc *Blockchain) nextDifficulty(parent *blockNode) int {
+	current := parent.header.Difficulty
+	if current == 0 {
+		current = bc.difficulty
+	}
+
+	height := parent.height + 1
+	if !bc.retarget.Enabled() || height%bc.retarget.Interval != 0 {
+		return current
+	}
+
+	first := parent
+	for i := 0; i < bc.retarget.Interval && first.parent != nil; i++ {
+		first = first.parent
+	}
+	blocks := int64(parent.height - first.height)
+	actual := parent.header.Timestamp - first.header.Timestamp
+	target := blocks * int64(bc.retarget.TargetBlockTime/time.Second)
+	return consensus.AdjustDifficulty(current, target, actual)
+}
+
+// NextHeaderDifficulty is nextDifficulty for callers that keep headers
+// rather than a block index, such as a light client. branch ends with the
+// parent of the header in question and must reach back at least
+// policy.Interval headers (or to genesis) when retargeting is on; initial is
+// the network's starting difficulty.
+func NextHeaderDifficulty(branch []BlockHeader, initial int, policy RetargetPolicy) int {
+	parent := branch[len(branch)-1]
+	current := parent.Difficulty
+	if current == 0 {
+		current = initial
+	}
+
+	height := parent.Index + 1
+	if !policy.Enabled() || height%policy.Interval != 0 {
+		return current
+	}
+
+	first := len(branch) - 1 - policy.Interval
+	if first < 0 {
+		first = 0
+	}
+	blocks := int64(parent.Index - branch[first].Index)
+	actual := parent.Timestamp - branch[first].Timestamp
+	target := blocks * int64(policy.TargetBlockTime/time.Second)
+	return consensus.AdjustDifficulty(current, target, actual)
+}
+
+// checkDifficulty verifies a block declares the difficulty its parent
+// requires.
+func checkDifficulty(block *Block, expected int) error {
+	if block.Difficulty != expected {
+		return fmt.Errorf("block difficulty %d does not match required difficulty %d", block.Difficulty, expected)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package chain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// newTestChain starts a chain whose genesis pays 50 coins to address.
+func newTestChain(t testing.TB, address string) *Blockchain {
+	t.Helper()
+	coinbase, err := NewCoinbaseTransaction(0, address, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := NewBlockchain(NewBlock(0, "0", []Transaction{*coinbase}))
+	bc.SetDifficulty(1)
+	return bc
+}
+
+// Difficulty is a shift count: a block declaring more than 256 bits once
+// made target.Lsh panic and took the node down with it.
+func TestOversizedDifficultyIsRejected(t *testing.T) {
+	bc := newTestChain(t, strings.Repeat("a", 64))
+	tip := bc.Tip()
+	coinbase, err := NewCoinbaseTransaction(1, strings.Repeat("b", 64), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oversized := func(prevHash string) *Block {
+		block := NewBlock(1, prevHash, []Transaction{*coinbase})
+		block.Difficulty = 300
+		block.Hash = block.ComputeHash()
+		return block
+	}
+
+	// On the tip the expected difficulty turns it away; as an orphan the
+	// proof-of-work check must.
+	for _, prevHash := range []string{tip.Hash, strings.Repeat("f", 64)} {
+		block := oversized(prevHash)
+		if _, err := bc.ProcessBlock(block); err == nil || errors.Is(err, ErrOrphanBlock) {
+			t.Errorf("ProcessBlock on parent %.8s: err = %v, want rejection", prevHash, err)
+		}
+		if err := VerifyHeader(block.Header(), bc.PowAlgorithm()); !errors.Is(err, consensus.ErrInvalidDifficulty) {
+			t.Errorf("VerifyHeader: err = %v, want ErrInvalidDifficulty", err)
+		}
+	}
+	if err := bc.AddBlock(oversized(tip.Hash)); err == nil {
+		t.Error("AddBlock accepted a block at difficulty 300")
+	}
+}
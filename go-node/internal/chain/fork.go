@@ -0,0 +1,467 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+var (
+	ErrDuplicateBlock = errors.New("block already known")
+	ErrOrphanBlock    = errors.New("parent block not known")
+	ErrInvalidParent  = errors.New("block builds on an invalid branch")
+	ErrForkTooDeep    = errors.New("block forks off deeper than the maximum reorg depth")
+	ErrSideBranchFull = errors.New("side branch has less work than every side block kept")
+)
+
+const (
+	// DefaultMaxReorgDepth is how many blocks below the tip a side branch
+	// may fork off. Deeper branches are rejected, and side blocks are
+	// pruned once the tip has moved that far past them, since the chain
+	// would never reorganize onto them.
+	DefaultMaxReorgDepth = 100
+	// DefaultMaxSideBlocks caps the side blocks kept. Past it the leaf
+	// with the least work is evicted.
+	DefaultMaxSideBlocks = 1000
+)
+
+// Outcomes of ProcessBlock.
+const (
+	BlockExtendedTip = "extended"
+	BlockSideBranch  = "side_branch"
+	BlockReorganized = "reorganized"
+)
+
+// blockNode is an entry in the block tree. Every block whose ancestry reaches
+// genesis is kept, whether or not it is on the main chain.
+type blockNode struct {
+	header  BlockHeader
+	parent  *blockNode
+	height  int
+	work    *big.Int // cumulative work from genesis
+	invalid bool
+}
+
+// Reorg describes a switch of the main chain to a heavier branch.
+type Reorg struct {
+	ForkHeight   int      `json:"fork_height"` // height of the last common block
+	OldTip       string   `json:"old_tip"`
+	NewTip       string   `json:"new_tip"`
+	Disconnected []*Block `json:"-"`
+	Connected    []*Block `json:"-"`
+	// Restored counts the transactions from disconnected blocks that the
+	// block pipeline returned to the mempool before announcing the reorg.
+	Restored int `json:"-"`
+}
+
+func (r *Reorg) Depth() int {
+	return len(r.Disconnected)
+}
+
+func newBlockNode(block *Block, parent *blockNode) *blockNode {
+	node := &blockNode{header: block.Header(), parent: parent, work: consensus.Work(block.Difficulty)}
+	if parent != nil {
+		node.height = parent.height + 1
+		node.work.Add(node.work, parent.work)
+	}
+	return node
+}
+
+// SetDifficulty sets the difficulty of the first block after genesis. Without
+// a retarget policy every block uses it.
+func (bc *Blockchain) SetDifficulty(difficulty int) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	bc.difficulty = difficulty
+}
+
+func (bc *Blockchain) Difficulty() int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return bc.difficulty
+}
+
+// ChainWork is the cumulative proof of work of the main chain.
+func (bc *Blockchain) ChainWork() *big.Int {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	return new(big.Int).Set(bc.tipNode().work)
+}
+
+// WorkAt is the cumulative proof of work of the chain ending at the known
+// block hash, on the main chain or a side branch.
+func (bc *Blockchain) WorkAt(hash string) (*big.Int, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	node, ok := bc.nodes[hash]
+	if !ok {
+		return nil, false
+	}
+	return new(big.Int).Set(node.work), true
+}
+
+func (bc *Blockchain) tipNode() *blockNode {
+	return bc.nodes[bc.index.tip().Hash]
+}
+
+func (bc *Blockchain) onMainChain(node *blockNode) bool {
+	_, ok := bc.index.heights[node.header.Hash]
+	return ok
+}
+
+// ProcessBlock adds a block received from the network. Blocks extending the
+// tip are validated and connected; blocks on other branches are stored and,
+// once their branch carries more work than the main chain, the chain
+// reorganizes onto it. Blocks whose parent is unknown return ErrOrphanBlock
+// so the caller can fetch the missing ancestors.
+func (bc *Blockchain) ProcessBlock(block *Block) (string, error) {
+	status, reorg, err := bc.processBlock(block)
+	if reorg != nil {
+		bc.publishReorg(reorg)
+	}
+	return status, err
+}
+
+// processBlock does the work of ProcessBlock, returning the reorg it caused
+// without announcing it.
+func (bc *Blockchain) processBlock(block *Block) (string, *Reorg, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.frozen {
+		return "", nil, ErrChainFrozen
+	}
+	if _, ok := bc.nodes[block.Hash]; ok {
+		return "", nil, ErrDuplicateBlock
+	}
+	// With the parent at hand, the difficulty it requires is checked before
+	// any proof of work.
+	parent, ok := bc.nodes[block.PrevHash]
+	if ok {
+		if err := checkDifficulty(block, bc.nextDifficulty(parent)); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := checkBlockHeader(block, bc.powAlgorithm); err != nil {
+		return "", nil, err
+	}
+	if !ok {
+		return "", nil, ErrOrphanBlock
+	}
+	if parent.invalid {
+		return "", nil, ErrInvalidParent
+	}
+	if depth := bc.forkDepth(parent); depth > bc.maxReorgDepth {
+		return "", nil, fmt.Errorf("%w: forks %d blocks below the tip, at most %d allowed", ErrForkTooDeep, depth, bc.maxReorgDepth)
+	}
+	if block.Index != parent.height+1 {
+		return "", nil, errors.New("block index is not sequential")
+	}
+	if err := checkMedianTimePast(block, medianTimePast(parent)); err != nil {
+		return "", nil, err
+	}
+	if err := checkBlockTime(block, bc.clock.Now()); err != nil {
+		return "", nil, err
+	}
+
+	node := newBlockNode(block, parent)
+	tip := bc.tipNode()
+
+	if parent == tip {
+		if err := VerifyBlockState(block, bc.UTXO, bc.reward); err != nil {
+			return "", nil, err
+		}
+		bc.nodes[block.Hash] = node
+		bc.connect(block)
+		return BlockExtendedTip, nil, nil
+	}
+
+	bc.nodes[block.Hash] = node
+	bc.sideBlocks[block.Hash] = block
+	if node.work.Cmp(tip.work) <= 0 {
+		bc.evictSideBlocks()
+		if _, kept := bc.sideBlocks[block.Hash]; !kept {
+			return "", nil, fmt.Errorf("%w: at most %d side blocks kept", ErrSideBranchFull, bc.maxSideBlocks)
+		}
+		return BlockSideBranch, nil, nil
+	}
+
+	reorg, err := bc.reorganize(node)
+	if err != nil {
+		return "", nil, err
+	}
+	bc.pruneSideBlocks()
+
+	for _, b := range reorg.Disconnected {
+		bc.Stale.Record(b, "reorganized out of the main chain", reorg.NewTip)
+	}
+	return BlockReorganized, reorg, nil
+}
+
+// forkDepth is how many blocks the tip is above the main-chain block node's
+// branch forks off from. Callers hold bc.mu.
+func (bc *Blockchain) forkDepth(node *blockNode) int {
+	for !bc.onMainChain(node) {
+		node = node.parent
+	}
+	return bc.tipNode().height - node.height
+}
+
+// pruneSideBlocks forgets side blocks the tip has moved more than the
+// maximum reorg depth past, and every side block built on them: their
+// branches fork off too deep for the chain to ever switch to. Callers hold
+// bc.mu.
+func (bc *Blockchain) pruneSideBlocks() {
+	below := bc.tipNode().height - bc.maxReorgDepth
+	drop := make(map[*blockNode]bool)
+	for hash := range bc.sideBlocks {
+		if node := bc.nodes[hash]; node.height <= below {
+			drop[node] = true
+		}
+	}
+	if len(drop) == 0 {
+		return
+	}
+
+	// A side block goes if an ancestor below it on its branch goes.
+	var doomed func(node *blockNode) bool
+	doomed = func(node *blockNode) bool {
+		if dropped, ok := drop[node]; ok {
+			return dropped
+		}
+		dropped := !bc.onMainChain(node) && doomed(node.parent)
+		drop[node] = dropped
+		return dropped
+	}
+	for hash := range bc.sideBlocks {
+		if node := bc.nodes[hash]; doomed(node) {
+			bc.forgetSideBlock(hash)
+		}
+	}
+}
+
+// evictSideBlocks forgets the side-branch leaves with the least work until
+// at most maxSideBlocks remain. Only leaves go, so every branch still kept
+// has its blocks back to the main chain. Callers hold bc.mu.
+func (bc *Blockchain) evictSideBlocks() {
+	for len(bc.sideBlocks) > bc.maxSideBlocks {
+		parents := make(map[*blockNode]bool, len(bc.sideBlocks))
+		for hash := range bc.sideBlocks {
+			parents[bc.nodes[hash].parent] = true
+		}
+		var victim *blockNode
+		for hash := range bc.sideBlocks {
+			node := bc.nodes[hash]
+			if parents[node] {
+				continue
+			}
+			if victim == nil || node.work.Cmp(victim.work) < 0 ||
+				(node.work.Cmp(victim.work) == 0 && node.header.Hash < victim.header.Hash) {
+				victim = node
+			}
+		}
+		bc.forgetSideBlock(victim.header.Hash)
+	}
+}
+
+func (bc *Blockchain) forgetSideBlock(hash string) {
+	delete(bc.sideBlocks, hash)
+	delete(bc.nodes, hash)
+}
+
+func (bc *Blockchain) publishReorg(reorg *Reorg) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	for _, ch := range bc.reorgSubscribers {
+		select {
+		case ch <- reorg:
+		default:
+		}
+	}
+}
+
+// reorganize switches the main chain to end at node. The new branch is
+// validated on a copy of the ledger; if any block fails, that block and its
+// descendants are marked invalid and the current chain is kept.
+func (bc *Blockchain) reorganize(node *blockNode) (*Reorg, error) {
+	var branch []*blockNode
+	fork := node
+	for !bc.onMainChain(fork) {
+		branch = append([]*blockNode{fork}, branch...)
+		fork = fork.parent
+	}
+
+	oldTip := bc.Blocks[len(bc.Blocks)-1]
+	disconnected := make([]*Block, len(bc.Blocks)-fork.height-1)
+	copy(disconnected, bc.Blocks[fork.height+1:])
+
+	utxo := bc.UTXO.Clone()
+	for i := len(disconnected) - 1; i >= 0; i-- {
+		if err := bc.undoBlock(utxo, disconnected[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	connected := make([]*Block, 0, len(branch))
+	undo := make(map[string]SpentOutputs, len(branch))
+	for i, n := range branch {
+		block := bc.sideBlocks[n.header.Hash]
+		if err := VerifyBlockState(block, utxo, bc.reward); err != nil {
+			for _, bad := range branch[i:] {
+				bad.invalid = true
+			}
+			return nil, fmt.Errorf("reorg to %s aborted, block %d invalid: %w", node.header.Hash, n.height, err)
+		}
+		undo[block.Hash] = utxo.ApplyBlock(block)
+		connected = append(connected, block)
+	}
+	for hash, spent := range undo {
+		bc.undo[hash] = spent
+	}
+
+	blocks := make([]*Block, fork.height+1, fork.height+1+len(connected))
+	copy(blocks, bc.Blocks[:fork.height+1])
+	bc.Blocks = append(blocks, connected...)
+	bc.UTXO = utxo
+
+	bc.index.truncate(fork.height + 1)
+	for _, b := range disconnected {
+		bc.sideBlocks[b.Hash] = b
+		bc.txIndex.removeBlock(b)
+		bc.addrIndex.removeBlock(b, bc.undo[b.Hash])
+	}
+	for i, b := range connected {
+		delete(bc.sideBlocks, b.Hash)
+		bc.index.append(b.Header())
+		bc.txIndex.addBlock(b, fork.height+1+i)
+		bc.addrIndex.addBlock(b, fork.height+1+i, bc.undo[b.Hash])
+	}
+
+	for _, b := range connected {
+		bc.notifyBlock(b)
+	}
+
+	return &Reorg{
+		ForkHeight:   fork.height,
+		OldTip:       oldTip.Hash,
+		NewTip:       node.header.Hash,
+		Disconnected: disconnected,
+		Connected:    connected,
+	}, nil
+}
+
+// undoBlock reverses a block's effect on utxo using the undo data recorded
+// when it was connected. Callers hold bc.mu.
+func (bc *Blockchain) undoBlock(utxo *UTXOSet, block *Block) error {
+	spent, ok := bc.undo[block.Hash]
+	if !ok {
+		return fmt.Errorf("cannot undo block %d: no undo data for %s", block.Index, block.Hash)
+	}
+	return utxo.UndoBlock(block, spent)
+}
+
+// UndoData returns the outputs block hash spent when it was connected, with
+// their addresses and amounts. It is kept for every block that has been on
+// the main chain, including ones since reorganized out.
+func (bc *Blockchain) UndoData(hash string) (SpentOutputs, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	spent, ok := bc.undo[hash]
+	if !ok {
+		return nil, false
+	}
+	return append(SpentOutputs(nil), spent...), true
+}
+
+// DisconnectBlock removes the tip from the main chain, restoring the outputs
+// it spent from its undo data, and returns it. The block stays known as a
+// side block; the mempool is left to the caller. The genesis block cannot be
+// disconnected.
+func (bc *Blockchain) DisconnectBlock() (*Block, error) {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	if bc.frozen {
+		return nil, ErrChainFrozen
+	}
+	if len(bc.Blocks) == 1 {
+		return nil, errors.New("cannot disconnect the genesis block")
+	}
+
+	block := bc.Blocks[len(bc.Blocks)-1]
+	if err := bc.undoBlock(bc.UTXO, block); err != nil {
+		return nil, err
+	}
+
+	bc.Blocks = bc.Blocks[:len(bc.Blocks)-1]
+	bc.index.truncate(len(bc.Blocks))
+	bc.txIndex.removeBlock(block)
+	bc.addrIndex.removeBlock(block, bc.undo[block.Hash])
+	bc.sideBlocks[block.Hash] = block
+	return block, nil
+}
+
+// SubscribeReorgs returns a channel receiving every reorganization of the
+// main chain and a function that unregisters it.
+func (bc *Blockchain) SubscribeReorgs() (<-chan *Reorg, func()) {
+	ch := make(chan *Reorg, 16)
+
+	bc.mu.Lock()
+	bc.reorgSubscribers = append(bc.reorgSubscribers, ch)
+	bc.mu.Unlock()
+
+	cancel := func() {
+		bc.mu.Lock()
+		defer bc.mu.Unlock()
+		for i, sub := range bc.reorgSubscribers {
+			if sub == ch {
+				bc.reorgSubscribers = append(bc.reorgSubscribers[:i], bc.reorgSubscribers[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// Locator returns main-chain block hashes from the tip back to genesis,
+// dense near the tip and exponentially sparser further back, so a peer can
+// find the last block both sides share.
+func (bc *Blockchain) Locator() []string {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	headers := bc.index.headers
+	var locator []string
+	step := 1
+	for h := len(headers) - 1; h > 0; h -= step {
+		locator = append(locator, headers[h].Hash)
+		if len(locator) >= 10 {
+			step *= 2
+		}
+	}
+	return append(locator, headers[0].Hash)
+}
+
+// BlocksAfterLocator returns up to limit main-chain blocks following the
+// first locator hash found on the main chain.
+func (bc *Blockchain) BlocksAfterLocator(locator []string, limit int) []*Block {
+	bc.mu.RLock()
+	start := 0
+	for _, hash := range locator {
+		if height, ok := bc.index.heights[hash]; ok {
+			start = height + 1
+			break
+		}
+	}
+	bc.mu.RUnlock()
+
+	return bc.BlocksFrom(start, limit)
+}
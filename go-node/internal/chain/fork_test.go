@@ -0,0 +1,94 @@
+package chain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mineBranch mines n blocks paying address on a chain of its own that
+// shares bc's genesis block.
+func mineBranch(t *testing.T, bc *Blockchain, address string, n int) []*Block {
+	t.Helper()
+	other := NewBlockchain(bc.Genesis())
+	other.SetDifficulty(bc.Difficulty())
+	blocks := make([]*Block, n)
+	for i := range blocks {
+		blocks[i] = mineTestBlock(t, other, address)
+		if err := other.AddBlock(blocks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return blocks
+}
+
+func extendChain(t *testing.T, bc *Blockchain, address string, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := bc.AddBlock(mineTestBlock(t, bc, address)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func (bc *Blockchain) hasSideBlock(hash string) bool {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+	_, side := bc.sideBlocks[hash]
+	_, node := bc.nodes[hash]
+	return side && node
+}
+
+// Branches are cheap to mine at low difficulty; ones the chain could never
+// switch to are refused or forgotten.
+func TestSideBranchesForkingTooDeep(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	bc := newTestChain(t, address)
+	bc.maxReorgDepth = 3
+	extendChain(t, bc, address, 2)
+
+	side := mineBranch(t, bc, strings.Repeat("b", 64), 2)
+	if status, err := bc.ProcessBlock(side[0]); err != nil || status != BlockSideBranch {
+		t.Fatalf("ProcessBlock: status %q, err %v; want %q", status, err, BlockSideBranch)
+	}
+
+	// Once the tip is three blocks past it, the side block goes, and the
+	// branch cannot be extended or started again.
+	extendChain(t, bc, address, 2)
+	if bc.hasSideBlock(side[0].Hash) {
+		t.Fatalf("side block at height 1 kept with the tip at %d", bc.Height())
+	}
+	if _, err := bc.ProcessBlock(side[1]); !errors.Is(err, ErrOrphanBlock) {
+		t.Errorf("child of a pruned block: err = %v, want %v", err, ErrOrphanBlock)
+	}
+	if _, err := bc.ProcessBlock(side[0]); !errors.Is(err, ErrForkTooDeep) {
+		t.Errorf("fork 4 blocks below the tip: err = %v, want %v", err, ErrForkTooDeep)
+	}
+}
+
+func TestSideBlocksEvictLightestLeaf(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	bc := newTestChain(t, address)
+	bc.maxSideBlocks = 2
+	extendChain(t, bc, address, 3)
+
+	long := mineBranch(t, bc, strings.Repeat("b", 64), 2)
+	short := mineBranch(t, bc, strings.Repeat("c", 64), 1)
+	for _, block := range long {
+		if status, err := bc.ProcessBlock(block); err != nil || status != BlockSideBranch {
+			t.Fatalf("ProcessBlock %d: status %q, err %v; want %q", block.Index, status, err, BlockSideBranch)
+		}
+	}
+	if _, err := bc.ProcessBlock(short[0]); !errors.Is(err, ErrSideBranchFull) {
+		t.Fatalf("lightest block over the cap: err = %v, want %v", err, ErrSideBranchFull)
+	}
+
+	// The root of the longer branch has the least work but is not a
+	// leaf; dropping it would strand its child.
+	if !bc.hasSideBlock(long[0].Hash) || !bc.hasSideBlock(long[1].Hash) {
+		t.Error("evicted part of the heavier branch")
+	}
+	if bc.hasSideBlock(short[0].Hash) {
+		t.Error("lightest leaf kept over the cap")
+	}
+}
@@ -0,0 +1,165 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// BlockHeader is a block without its transactions.
+type BlockHeader struct {
+	Index          int    `json:"index"`
+	Timestamp      int64  `json:"timestamp"`
+	PrevHash       string `json:"prevHash"`
+	MerkleRoot     string `json:"merkleRoot"`
+	Hash           string `json:"hash"`
+	Nonce          int64  `json:"nonce"`
+	Difficulty     int    `json:"difficulty,omitempty"`
+	PowAlgorithm   string `json:"pow_algorithm,omitempty"` // empty for sha256, as in the block
+	MinerPubKey    string `json:"miner_pubkey,omitempty"`
+	MinerSignature string `json:"miner_signature,omitempty"`
+	TxCount        int    `json:"tx_count"`
+}
+
+func (b *Block) Header() BlockHeader {
+	return BlockHeader{
+		Index:          b.Index,
+		Timestamp:      b.Timestamp,
+		PrevHash:       b.PrevHash,
+		MerkleRoot:     b.MerkleRoot,
+		Hash:           b.Hash,
+		Nonce:          b.Nonce,
+		Difficulty:     b.Difficulty,
+		PowAlgorithm:   b.PowAlgorithm,
+		MinerPubKey:    b.MinerPubKey,
+		MinerSignature: b.MinerSignature,
+		TxCount:        len(b.Transactions),
+	}
+}
+
+// VerifyHeader checks what a header commits to without its transactions:
+// that it uses the network's proof-of-work algorithm, that its hash covers
+// its fields, that it meets the difficulty it declares and that its
+// producer signature, if any, verifies. It lets
+// headers-first sync reject a bogus chain before downloading any block
+// bodies; everything else is checked when the full block is connected.
+func VerifyHeader(h BlockHeader, algorithm string) error {
+	if err := checkPowAlgorithm(h.PowAlgorithm, algorithm); err != nil {
+		return err
+	}
+	block := Block{
+		Index:        h.Index,
+		Timestamp:    h.Timestamp,
+		PrevHash:     h.PrevHash,
+		MerkleRoot:   h.MerkleRoot,
+		Nonce:        h.Nonce,
+		Difficulty:   h.Difficulty,
+		PowAlgorithm: h.PowAlgorithm,
+		MinerPubKey:  h.MinerPubKey,
+	}
+	if block.ComputeHash() != h.Hash {
+		return errors.New("header hash does not match header data")
+	}
+	if !consensus.ValidDifficulty(h.Difficulty) {
+		return fmt.Errorf("header declares difficulty %d: %w", h.Difficulty, consensus.ErrInvalidDifficulty)
+	}
+	if !consensus.ValidateProofOfWork(h.Hash, h.Difficulty) {
+		return errors.New("header does not meet proof-of-work requirement")
+	}
+	return checkProducer(h.MinerPubKey, h.MinerSignature, h.Hash)
+}
+
+// TipInfo summarizes the head of the main chain.
+type TipInfo struct {
+	Height    int    `json:"height"` // number of blocks, genesis included
+	Hash      string `json:"hash"`
+	PrevHash  string `json:"prevHash"`
+	Timestamp int64  `json:"timestamp"`
+	ChainWork string `json:"chain_work"`
+}
+
+// headerIndex holds the main chain's headers by height and maps their hashes
+// back to heights, so header-only lookups never touch block bodies.
+type headerIndex struct {
+	headers []BlockHeader
+	heights map[string]int
+}
+
+func newHeaderIndex(genesis *Block) headerIndex {
+	idx := headerIndex{heights: make(map[string]int)}
+	idx.append(genesis.Header())
+	return idx
+}
+
+func (idx *headerIndex) append(h BlockHeader) {
+	idx.heights[h.Hash] = len(idx.headers)
+	idx.headers = append(idx.headers, h)
+}
+
+// truncate drops every header at or above height.
+func (idx *headerIndex) truncate(height int) {
+	for _, h := range idx.headers[height:] {
+		delete(idx.heights, h.Hash)
+	}
+	idx.headers = idx.headers[:height:height]
+}
+
+func (idx *headerIndex) tip() BlockHeader {
+	return idx.headers[len(idx.headers)-1]
+}
+
+func (bc *Blockchain) TipInfo() TipInfo {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	tip := bc.index.tip()
+	return TipInfo{
+		Height:    len(bc.index.headers),
+		Hash:      tip.Hash,
+		PrevHash:  tip.PrevHash,
+		Timestamp: tip.Timestamp,
+		ChainWork: bc.tipNode().work.String(),
+	}
+}
+
+// HeaderAt returns the main-chain header at height.
+func (bc *Blockchain) HeaderAt(height int) (BlockHeader, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if height < 0 || height >= len(bc.index.headers) {
+		return BlockHeader{}, false
+	}
+	return bc.index.headers[height], true
+}
+
+// HeaderByHash returns the header of any known block, on the main chain or a
+// side branch.
+func (bc *Blockchain) HeaderByHash(hash string) (BlockHeader, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	node, ok := bc.nodes[hash]
+	if !ok {
+		return BlockHeader{}, false
+	}
+	return node.header, true
+}
+
+// Headers returns up to limit main-chain headers starting at height from.
+func (bc *Blockchain) Headers(from, limit int) []BlockHeader {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	if from < 0 || from >= len(bc.index.headers) {
+		return nil
+	}
+	end := len(bc.index.headers)
+	if limit > 0 && from+limit < end {
+		end = from + limit
+	}
+	out := make([]BlockHeader, end-from)
+	copy(out, bc.index.headers[from:end])
+	return out
+}
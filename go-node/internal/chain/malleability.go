@@ -0,0 +1,100 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// The txid covers only the canonical form of the inputs, outputs and memo,
+// and the signature covers the same bytes. Anything a relay could change without
+// touching those bytes has to be pinned down by the rules below. Otherwise one
+// txid could stand for several valid transactions, for example with outputs
+// at different indices or with amounts that differ below the eighth decimal.
+// The transaction timestamp is covered by neither and is informational only;
+// nothing in consensus may depend on it.
+
+var ErrNonCanonicalTx = errors.New("transaction is not in canonical form")
+
+func inputLess(a, b TxIn) bool {
+	if a.TxID == b.TxID {
+		return a.Index < b.Index
+	}
+	return a.TxID < b.TxID
+}
+
+func outputLess(a, b TxOut) bool {
+	if a.Address == b.Address {
+		return a.Amount < b.Amount
+	}
+	return a.Address < b.Address
+}
+
+func sortCanonical(inputs []TxIn, outputs []TxOut) {
+	sort.SliceStable(inputs, func(i, j int) bool { return inputLess(inputs[i], inputs[j]) })
+	sort.SliceStable(outputs, func(i, j int) bool { return outputLess(outputs[i], outputs[j]) })
+}
+
+func nonCanonical(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrNonCanonicalTx, fmt.Sprintf(format, args...))
+}
+
+// CheckCanonicalForm rejects every encoding of a transaction except the one
+// its txid and signature were computed from. It does not check the
+// signature or the ledger.
+func CheckCanonicalForm(tx *Transaction) error {
+	if len(tx.ID) != 64 || !crypto.IsLowerHex(tx.ID) {
+		return nonCanonical("id must be 64 lowercase hex characters")
+	}
+
+	for i, in := range tx.Inputs {
+		if len(in.TxID) != 64 || !crypto.IsLowerHex(in.TxID) {
+			return nonCanonical("input %d tx_id must be 64 lowercase hex characters", i)
+		}
+		if in.Index < 0 {
+			return nonCanonical("input %d has a negative index", i)
+		}
+		if i > 0 && !inputLess(tx.Inputs[i-1], in) {
+			return nonCanonical("inputs must be sorted by tx_id and index without duplicates")
+		}
+	}
+
+	if err := checkCanonicalOutputs(tx.Outputs); err != nil {
+		return err
+	}
+
+	// A transaction spending only multisig outputs is signed by its witness
+	// alone.
+	if tx.Multisig != nil {
+		if err := checkMultisigForm(tx.Multisig); err != nil {
+			return err
+		}
+		if tx.Signature == "" && tx.PubKey == "" {
+			return nil
+		}
+	}
+
+	if err := crypto.CheckSignatureEncoding(tx.Signature); err != nil {
+		return nonCanonical("%v", err)
+	}
+	if len(tx.PubKey) != 2*crypto.PublicKeyLength || !crypto.IsLowerHex(tx.PubKey) {
+		return nonCanonical("pubkey must be %d lowercase hex characters", 2*crypto.PublicKeyLength)
+	}
+	return nil
+}
+
+// checkCanonicalOutputs is the output half of CheckCanonicalForm, shared with
+// coinbase validation.
+func checkCanonicalOutputs(outputs []TxOut) error {
+	for i, out := range outputs {
+		if out.Amount != RoundAmount(out.Amount) {
+			return nonCanonical("output %d amount has more than %d decimal places", i, AmountDecimals)
+		}
+		if i > 0 && outputLess(out, outputs[i-1]) {
+			return nonCanonical("outputs must be sorted by address and amount")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// However inputs and outputs are passed in, NewTransaction yields the same
+// txid and the same wire encoding, so their order says nothing about which
+// output is the payment and which the change.
+func TestNewTransactionOrderIsCanonical(t *testing.T) {
+	inputs := []TxIn{
+		{TxID: strings.Repeat("2", 64), Index: 0},
+		{TxID: strings.Repeat("1", 64), Index: 1},
+		{TxID: strings.Repeat("1", 64), Index: 0},
+	}
+	outputs := []TxOut{
+		{Address: strings.Repeat("c", 64), Amount: 1},
+		{Address: strings.Repeat("a", 64), Amount: 2},
+		{Address: strings.Repeat("a", 64), Amount: 1},
+		{Address: strings.Repeat("b", 64), Amount: 3},
+	}
+
+	var want *Transaction
+	var wantWire []byte
+	for shift := 0; shift < len(outputs); shift++ {
+		in := append(append([]TxIn(nil), inputs[shift%len(inputs):]...), inputs[:shift%len(inputs)]...)
+		out := append(append([]TxOut(nil), outputs[shift:]...), outputs[:shift]...)
+		tx, err := NewTransaction(in, out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx.Timestamp = 0
+		wire := EncodeTransactions([]*Transaction{tx})
+		if want == nil {
+			want, wantWire = tx, wire
+			continue
+		}
+		if tx.ID != want.ID {
+			t.Errorf("rotation %d: txid %s, want %s", shift, tx.ID, want.ID)
+		}
+		if !bytes.Equal(wire, wantWire) {
+			t.Errorf("rotation %d: wire encoding differs", shift)
+		}
+	}
+
+	for i := 1; i < len(want.Outputs); i++ {
+		if outputLess(want.Outputs[i], want.Outputs[i-1]) {
+			t.Fatalf("outputs not sorted by address and amount: %+v", want.Outputs)
+		}
+	}
+}
+
+func TestCheckCanonicalFormRejectsReorderedOutputs(t *testing.T) {
+	tx, err := NewTransaction(
+		[]TxIn{{TxID: strings.Repeat("1", 64), Index: 0}},
+		[]TxOut{{Address: strings.Repeat("a", 64), Amount: 1}, {Address: strings.Repeat("b", 64), Amount: 2}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	signTestTx(t, tx, key)
+	if err := CheckCanonicalForm(tx); err != nil {
+		t.Fatalf("as built: %v", err)
+	}
+	tx.Outputs[0], tx.Outputs[1] = tx.Outputs[1], tx.Outputs[0]
+
+	// The txid and signature still hold, since they cover the sorted form,
+	// but the swapped order must not pass as a second encoding of them.
+	if id, _ := ComputeTxID(tx); id != tx.ID {
+		t.Fatalf("txid changed with output order")
+	}
+	if err := CheckCanonicalForm(tx); !errors.Is(err, ErrNonCanonicalTx) {
+		t.Fatalf("CheckCanonicalForm: err = %v, want ErrNonCanonicalTx", err)
+	}
+}
+
+// The txid and signature do not cover the public key, so a key with each
+// coordinate zero-padded by a byte, which still names the same point, must
+// be refused as a second form of the transaction.
+func TestPaddedPubKeyIsRejected(t *testing.T) {
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.AddressFromPublicKey(&key.PublicKey)
+	utxo := NewUTXOSet()
+	utxo.Add(strings.Repeat("1", 64), 0, TxOut{Address: address, Amount: 10})
+
+	tx, err := NewTransaction(
+		[]TxIn{{TxID: strings.Repeat("1", 64), Index: 0}},
+		[]TxOut{{Address: strings.Repeat("b", 64), Amount: 9}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signTestTx(t, tx, key)
+	if err := VerifyTransaction(tx, utxo); err != nil {
+		t.Fatalf("as built: %v", err)
+	}
+
+	half := len(tx.PubKey) / 2
+	tx.PubKey = "00" + tx.PubKey[:half] + "00" + tx.PubKey[half:]
+	if id, _ := ComputeTxID(tx); id != tx.ID {
+		t.Fatalf("txid changed with the public key encoding")
+	}
+	if err := CheckCanonicalForm(tx); !errors.Is(err, ErrNonCanonicalTx) {
+		t.Errorf("CheckCanonicalForm: err = %v, want ErrNonCanonicalTx", err)
+	}
+	if err := VerifyTransaction(tx, utxo); err == nil {
+		t.Error("VerifyTransaction accepted the padded key")
+	}
+}
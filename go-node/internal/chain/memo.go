@@ -0,0 +1,52 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// A transaction may carry a short memo, such as an invoice number. It is
+// covered by the txid and the signature like the inputs and outputs, so it
+// cannot be changed in relay, but it is public like everything else on
+// chain. A memo meant only for the recipient is encrypted to their public
+// key and stored as EncryptedMemoPrefix followed by the ECIES ciphertext in
+// lowercase hex; only the recipient's wallet can read it.
+
+const (
+	MaxMemoLength       = 512
+	EncryptedMemoPrefix = "ecies:"
+
+	// eciesOverhead is the ephemeral key, nonce and tag ECIES adds to the
+	// plaintext.
+	eciesOverhead = 65 + 12 + 16
+)
+
+var ErrInvalidMemo = errors.New("invalid memo")
+
+// IsEncryptedMemo reports whether memo is an ECIES ciphertext rather than
+// plain text.
+func IsEncryptedMemo(memo string) bool {
+	return strings.HasPrefix(memo, EncryptedMemoPrefix)
+}
+
+// CheckMemo checks a memo against the consensus limits: its length, UTF-8
+// text, and the encoding of an encrypted memo.
+func CheckMemo(memo string) error {
+	if len(memo) > MaxMemoLength {
+		return fmt.Errorf("%w: %d bytes, more than the maximum %d", ErrInvalidMemo, len(memo), MaxMemoLength)
+	}
+	if !utf8.ValidString(memo) {
+		return fmt.Errorf("%w: not valid UTF-8", ErrInvalidMemo)
+	}
+	if IsEncryptedMemo(memo) {
+		ct := strings.TrimPrefix(memo, EncryptedMemoPrefix)
+		if len(ct)%2 != 0 || len(ct) < 2*eciesOverhead || !crypto.IsLowerHex(ct) {
+			return fmt.Errorf("%w: encrypted memo must be an ECIES ciphertext in lowercase hex", ErrInvalidMemo)
+		}
+	}
+	return nil
+}
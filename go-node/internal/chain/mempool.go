@@ -2,21 +2,178 @@ package chain
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"sync"
+	"time"
 )
 
+const DefaultMaxMempoolSize = 5000
+
+// Descendant limits and the fee bump keep replacement usable. Without them
+// an attacker could attach enough low-fee children to a payment that
+// replacing it would evict more than maxReplacementEvictions transactions,
+// or cost more than it is worth, and the payment could never be bumped.
+const (
+	DefaultMaxDescendants    = 25
+	DefaultMaxDescendantSize = 101000 // bytes of binary encoding
+	DefaultMinFeeBump        = 0.00001
+)
+
+// DefaultDeprioritizeAnomaly is the AI anomaly score above which accepted
+// transactions are mined after all others.
+const DefaultDeprioritizeAnomaly = 0.5
+
+const (
+	// maxReplacementEvictions bounds how many pending transactions one
+	// replacement may push out, counting descendants.
+	maxReplacementEvictions = 100
+	// maxRecentConflicts is how many conflict events the pool remembers
+	// for /mempool.
+	maxRecentConflicts = 100
+)
+
+// ErrMempoolConflict is returned for a transaction that spends an output a
+// pending transaction already spends, without paying enough to replace it.
+var ErrMempoolConflict = errors.New("transaction conflicts with a pending transaction")
+
+// ErrDescendantLimit is returned for a transaction that would give a
+// pending ancestor more descendants than the policy allows.
+var ErrDescendantLimit = errors.New("too many pending descendants")
+
+// MempoolConflict records a transaction that spent outputs already spent in
+// the pool. Replaced says whether it displaced the earlier transactions by
+// paying more, or was rejected.
+type MempoolConflict struct {
+	TxID          string   `json:"txid"`
+	ConflictsWith []string `json:"conflicts_with"`
+	Outpoints     []string `json:"outpoints"`
+	Replaced      bool     `json:"replaced"`
+	Reason        string   `json:"reason,omitempty"`
+	Time          int64    `json:"time"`
+}
+
+type MempoolPolicy struct {
+	MinRelayFee     float64 `json:"min_relay_fee"`      // Minimum absolute fee accepted for relay
+	MinRelayFeeRate float64 `json:"min_relay_fee_rate"` // Minimum fee per 1000 bytes accepted for relay
+	MaxSize         int     `json:"max_mempool_size"`   // Maximum number of pending transactions
+
+	// MaxDescendants and MaxDescendantSize bound every pending
+	// transaction's in-pool descendants, itself included (0 = no limit).
+	MaxDescendants    int `json:"max_descendants"`
+	MaxDescendantSize int `json:"max_descendant_size"`
+	// MinFeeBump is how much more a replacement must pay than everything
+	// it evicts.
+	MinFeeBump float64 `json:"min_fee_bump"`
+	// DeprioritizeAnomalyAbove ranks transactions whose AI anomaly score
+	// exceeds it after every other transaction (0 = rank by fee rate only).
+	DeprioritizeAnomalyAbove float64 `json:"deprioritize_anomaly_above"`
+}
+
+func DefaultMempoolPolicy() MempoolPolicy {
+	return MempoolPolicy{
+		MinRelayFee:       0,
+		MaxSize:           DefaultMaxMempoolSize,
+		MaxDescendants:    DefaultMaxDescendants,
+		MaxDescendantSize: DefaultMaxDescendantSize,
+		MinFeeBump:        DefaultMinFeeBump,
+
+		DeprioritizeAnomalyAbove: DefaultDeprioritizeAnomaly,
+	}
+}
+
+// FeeInfo is what a pending transaction pays. Size is the length of its
+// binary wire encoding and FeeRate the fee per 1000 bytes of it.
+type FeeInfo struct {
+	Fee     float64 `json:"fee"`
+	Size    int     `json:"size"`
+	FeeRate float64 `json:"fee_rate"`
+}
+
+func newFeeInfo(tx *Transaction, fee float64) FeeInfo {
+	data, _ := tx.MarshalBinary()
+	info := FeeInfo{Fee: fee, Size: len(data)}
+	if info.Size > 0 {
+		info.FeeRate = fee * 1000 / float64(info.Size)
+	}
+	return info
+}
+
 type Mempool struct {
-	mu  sync.Mutex
-	txs map[string]*Transaction // txID → transaction
+	mu          sync.Mutex
+	txs         map[string]*Transaction // txID → transaction
+	fees        map[string]FeeInfo      // txID → fee paid
+	inFlight    map[string]bool         // txID → in a block template being mined
+	scores      map[string]TxScore      // txID → AI score, for scored transactions
+	spent       map[UTXOKey]string      // outpoint → txID of the pending transaction spending it
+	conflicts   []MempoolConflict       // most recent last
+	policy      MempoolPolicy
+	subscribers []chan *Transaction
+	evictions   []chan string
 }
 
 func NewMempool() *Mempool {
+	return NewMempoolWithPolicy(DefaultMempoolPolicy())
+}
+
+func NewMempoolWithPolicy(policy MempoolPolicy) *Mempool {
 	return &Mempool{
-		txs: make(map[string]*Transaction),
+		txs:      make(map[string]*Transaction),
+		fees:     make(map[string]FeeInfo),
+		scores:   make(map[string]TxScore),
+		inFlight: make(map[string]bool),
+		spent:    make(map[UTXOKey]string),
+		policy:   policy,
 	}
 }
 
-func (mp *Mempool) AddTransaction(tx *Transaction) error {
+func (mp *Mempool) Policy() MempoolPolicy {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.policy
+}
+
+// MinFee is the least fee a transaction whose binary encoding is size bytes
+// long must pay to be relayed: the minimum relay fee, or the minimum fee
+// rate applied to size if that is more, rounded up to a whole base unit.
+func (p MempoolPolicy) MinFee(size int) float64 {
+	fee := p.MinRelayFee
+	units := p.MinRelayFeeRate * float64(size) / 1000 * amountScale
+	// Shed float error first, so an exact product is not bumped a unit.
+	if byRate := math.Ceil(math.Round(units*1e4)/1e4) / amountScale; byRate > fee {
+		fee = byRate
+	}
+	return fee
+}
+
+// CheckFee applies the relay policy to tx, which pays fee: both the fee and
+// the fee rate must reach their minimums.
+func (mp *Mempool) CheckFee(tx *Transaction, fee float64) error {
+	policy := mp.Policy()
+	if fee < policy.MinRelayFee {
+		return fmt.Errorf("fee %s below minimum relay fee %s", FormatAmount(fee), FormatAmount(policy.MinRelayFee))
+	}
+	if policy.MinRelayFeeRate > 0 {
+		// Compared in base units: the fee rate itself carries float error.
+		if info := newFeeInfo(tx, fee); RoundAmount(fee) < policy.MinFee(info.Size) {
+			return fmt.Errorf("fee rate %.8f per 1000 bytes (fee %s for %d bytes) below minimum relay fee rate %.8f",
+				info.FeeRate, FormatAmount(fee), info.Size, policy.MinRelayFeeRate)
+		}
+	}
+	return nil
+}
+
+// AddTransaction admits tx, which pays fee. Callers have verified it against
+// the ledger and computed the fee while doing so. A transaction spending an
+// output that a pending one already spends replaces it (and anything
+// spending its outputs) only if it pays a higher fee rate than each
+// transaction it conflicts with and at least MinFeeBump more in total than
+// everything it evicts; otherwise it is rejected with ErrMempoolConflict.
+// A transaction that would give a pending ancestor too many descendants is
+// rejected with ErrDescendantLimit.
+func (mp *Mempool) AddTransaction(tx *Transaction, fee float64) error {
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
@@ -24,15 +181,466 @@ func (mp *Mempool) AddTransaction(tx *Transaction) error {
 		return errors.New("transaction already in mempool")
 	}
 
+	info := newFeeInfo(tx, fee)
+	evict, err := mp.replacementLocked(tx, info)
+	if err != nil {
+		return err
+	}
+
+	if mp.policy.MaxSize > 0 && len(mp.txs)-len(evict) >= mp.policy.MaxSize {
+		return errors.New("mempool is full")
+	}
+	if err := mp.checkDescendantLimitsLocked(tx, info, evict); err != nil {
+		return err
+	}
+
+	for _, id := range evict {
+		mp.evictLocked(id)
+	}
 	mp.txs[tx.ID] = tx
+	mp.fees[tx.ID] = info
+	for _, in := range tx.Inputs {
+		mp.spent[UTXOKey{TxID: in.TxID, Index: in.Index}] = tx.ID
+	}
+
+	for _, ch := range mp.subscribers {
+		select {
+		case ch <- tx:
+		default: // slow subscriber, drop rather than block admission
+		}
+	}
+	return nil
+}
+
+// replacementLocked finds the pending transactions tx conflicts with and
+// decides whether it may replace them. It returns the IDs to evict, which
+// include descendants of the conflicting transactions.
+func (mp *Mempool) replacementLocked(tx *Transaction, info FeeInfo) ([]string, error) {
+	direct := make(map[string]bool)
+	var outpoints []string
+	for _, in := range tx.Inputs {
+		if id, ok := mp.spent[UTXOKey{TxID: in.TxID, Index: in.Index}]; ok {
+			direct[id] = true
+			outpoints = append(outpoints, fmt.Sprintf("%s:%d", in.TxID, in.Index))
+		}
+	}
+	if len(direct) == 0 {
+		return nil, nil
+	}
+
+	conflict := MempoolConflict{TxID: tx.ID, Outpoints: outpoints, Time: time.Now().Unix()}
+	for id := range direct {
+		conflict.ConflictsWith = append(conflict.ConflictsWith, id)
+	}
+	sort.Strings(conflict.ConflictsWith)
+
+	reject := func(reason string) ([]string, error) {
+		conflict.Reason = reason
+		mp.recordConflictLocked(conflict)
+		return nil, fmt.Errorf("%w: %s", ErrMempoolConflict, reason)
+	}
+
+	for id := range direct {
+		if info.FeeRate <= mp.fees[id].FeeRate {
+			return reject(fmt.Sprintf("fee rate %.8f does not exceed %.8f of pending transaction %s", info.FeeRate, mp.fees[id].FeeRate, id))
+		}
+	}
+
+	evict := mp.descendantsLocked(direct)
+	if len(evict) > maxReplacementEvictions {
+		return reject(fmt.Sprintf("would evict %d transactions, more than %d", len(evict), maxReplacementEvictions))
+	}
+	evicted := make(map[string]bool, len(evict))
+	var evictedFees float64
+	for _, id := range evict {
+		evicted[id] = true
+		evictedFees += mp.fees[id].Fee
+	}
+	for _, in := range tx.Inputs {
+		if evicted[in.TxID] {
+			return reject("spends an output of a transaction it replaces")
+		}
+	}
+	if fee := RoundAmount(info.Fee); fee <= RoundAmount(evictedFees) {
+		return reject(fmt.Sprintf("fee %s does not exceed the %s paid by the transactions it replaces", FormatAmount(fee), FormatAmount(evictedFees)))
+	} else if bump := RoundAmount(fee - evictedFees); bump < RoundAmount(mp.policy.MinFeeBump) {
+		return reject(fmt.Sprintf("fee %s exceeds the %s paid by the transactions it replaces by %s, less than the minimum bump %s",
+			FormatAmount(fee), FormatAmount(evictedFees), FormatAmount(bump), FormatAmount(mp.policy.MinFeeBump)))
+	}
+
+	conflict.Replaced = true
+	mp.recordConflictLocked(conflict)
+	return evict, nil
+}
+
+// checkDescendantLimitsLocked rejects tx if admitting it would leave any
+// of its pending ancestors with more descendants, or descendants of a
+// larger total size, than the policy allows. Transactions in evict are
+// about to leave the pool and don't count.
+func (mp *Mempool) checkDescendantLimitsLocked(tx *Transaction, info FeeInfo, evict []string) error {
+	if mp.policy.MaxDescendants <= 0 && mp.policy.MaxDescendantSize <= 0 {
+		return nil
+	}
+	evicted := make(map[string]bool, len(evict))
+	for _, id := range evict {
+		evicted[id] = true
+	}
+
+	ancestors := make(map[string]bool)
+	queue := []*Transaction{tx}
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		for _, in := range child.Inputs {
+			parent, ok := mp.txs[in.TxID]
+			if ok && !evicted[in.TxID] && !ancestors[in.TxID] {
+				ancestors[in.TxID] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(ancestors))
+	for id := range ancestors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		count, size := 1, info.Size
+		for _, d := range mp.descendantsLocked(map[string]bool{id: true}) {
+			if !evicted[d] {
+				count++
+				size += mp.fees[d].Size
+			}
+		}
+		if mp.policy.MaxDescendants > 0 && count > mp.policy.MaxDescendants {
+			return fmt.Errorf("%w: pending transaction %s would have %d descendants, more than %d",
+				ErrDescendantLimit, id, count, mp.policy.MaxDescendants)
+		}
+		if mp.policy.MaxDescendantSize > 0 && size > mp.policy.MaxDescendantSize {
+			return fmt.Errorf("%w: pending transaction %s would have %d bytes of descendants, more than %d",
+				ErrDescendantLimit, id, size, mp.policy.MaxDescendantSize)
+		}
+	}
 	return nil
 }
 
+// descendantsLocked returns roots and every pending transaction that spends
+// an output of one of them, directly or through other pending transactions.
+func (mp *Mempool) descendantsLocked(roots map[string]bool) []string {
+	found := make(map[string]bool, len(roots))
+	queue := make([]string, 0, len(roots))
+	for id := range roots {
+		found[id] = true
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		parent := mp.txs[queue[0]]
+		queue = queue[1:]
+		for idx := range parent.Outputs {
+			child, ok := mp.spent[UTXOKey{TxID: parent.ID, Index: idx}]
+			if ok && !found[child] {
+				found[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(found))
+	for id := range found {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (mp *Mempool) recordConflictLocked(c MempoolConflict) {
+	mp.conflicts = append(mp.conflicts, c)
+	if len(mp.conflicts) > maxRecentConflicts {
+		mp.conflicts = mp.conflicts[len(mp.conflicts)-maxRecentConflicts:]
+	}
+}
+
+// Conflicts returns the most recent conflicting submissions, newest first.
+func (mp *Mempool) Conflicts() []MempoolConflict {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	out := make([]MempoolConflict, len(mp.conflicts))
+	for i, c := range mp.conflicts {
+		out[len(out)-1-i] = c
+	}
+	return out
+}
+
+// SpentBy returns the pending transaction that spends the outpoint, if any.
+func (mp *Mempool) SpentBy(key UTXOKey) (string, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	id, ok := mp.spent[key]
+	return id, ok
+}
+
+// Subscribe returns a channel receiving every newly admitted transaction and
+// a function that unregisters it.
+func (mp *Mempool) Subscribe() (<-chan *Transaction, func()) {
+	ch := make(chan *Transaction, 64)
+
+	mp.mu.Lock()
+	mp.subscribers = append(mp.subscribers, ch)
+	mp.mu.Unlock()
+
+	cancel := func() {
+		mp.mu.Lock()
+		defer mp.mu.Unlock()
+		for i, sub := range mp.subscribers {
+			if sub == ch {
+				mp.subscribers = append(mp.subscribers[:i], mp.subscribers[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// SubscribeEvictions returns a channel receiving the ID of every
+// transaction that leaves the pool without confirming: replaced, evicted,
+// conflicting with a block, invalidated by a reorg or cleared. It also
+// returns a function that unregisters the channel.
+func (mp *Mempool) SubscribeEvictions() (<-chan string, func()) {
+	ch := make(chan string, 256)
+
+	mp.mu.Lock()
+	mp.evictions = append(mp.evictions, ch)
+	mp.mu.Unlock()
+
+	cancel := func() {
+		mp.mu.Lock()
+		defer mp.mu.Unlock()
+		for i, sub := range mp.evictions {
+			if sub == ch {
+				mp.evictions = append(mp.evictions[:i], mp.evictions[i+1:]...)
+				break
+			}
+		}
+	}
+	return ch, cancel
+}
+
+func (mp *Mempool) Has(txID string) bool {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	_, ok := mp.txs[txID]
+	return ok
+}
+
+// Get returns the pending transaction with the given ID.
+func (mp *Mempool) Get(txID string) (*Transaction, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	tx, ok := mp.txs[txID]
+	return tx, ok
+}
+
+// SpendableUTXO returns a snapshot of bc's UTXO set without the outputs
+// that pending transactions already spend, so new transactions don't select
+// them again.
+func (mp *Mempool) SpendableUTXO(bc *Blockchain) *UTXOSet {
+	view := bc.UTXOSnapshot()
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	for key := range mp.spent {
+		view.Spend(key)
+	}
+	return view
+}
+
+// InputView returns a UTXO set holding the outputs tx spends, looked up
+// with confirmed or, failing that, among the outputs of pending
+// transactions, so a transaction spending an unconfirmed output can be
+// verified and its fee computed. Outputs found in neither are left out.
+func (mp *Mempool) InputView(tx *Transaction, confirmed func(UTXOKey) (TxOut, bool)) *UTXOSet {
+	view := NewUTXOSet()
+	var missing []UTXOKey
+	for _, in := range tx.Inputs {
+		key := UTXOKey{TxID: in.TxID, Index: in.Index}
+		if out, ok := confirmed(key); ok {
+			view.Add(key.TxID, key.Index, out)
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return view
+	}
+
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+	for _, key := range missing {
+		if parent, ok := mp.txs[key.TxID]; ok && key.Index >= 0 && key.Index < len(parent.Outputs) {
+			view.Add(key.TxID, key.Index, parent.Outputs[key.Index])
+		}
+	}
+	return view
+}
+
 func (mp *Mempool) RemoveTransaction(txID string) {
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
+	mp.evictLocked(txID)
+}
+
+// RemoveWithDescendants drops a pending transaction and every pending
+// transaction spending its outputs, which could not confirm without it. It
+// returns the IDs dropped.
+func (mp *Mempool) RemoveWithDescendants(txID string) []string {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if _, ok := mp.txs[txID]; !ok {
+		return nil
+	}
+	evict := mp.descendantsLocked(map[string]bool{txID: true})
+	for _, id := range evict {
+		mp.evictLocked(id)
+	}
+	return evict
+}
+
+func (mp *Mempool) removeLocked(txID string) {
+	tx, ok := mp.txs[txID]
+	if !ok {
+		return
+	}
+	for _, in := range tx.Inputs {
+		key := UTXOKey{TxID: in.TxID, Index: in.Index}
+		if mp.spent[key] == txID {
+			delete(mp.spent, key)
+		}
+	}
 	delete(mp.txs, txID)
+	delete(mp.fees, txID)
+	delete(mp.scores, txID)
+	delete(mp.inFlight, txID)
+}
+
+// evictLocked removes a transaction that will not confirm and tells the
+// eviction subscribers.
+func (mp *Mempool) evictLocked(txID string) {
+	if _, ok := mp.txs[txID]; !ok {
+		return
+	}
+	mp.removeLocked(txID)
+	for _, ch := range mp.evictions {
+		select {
+		case ch <- txID:
+		default: // slow subscriber, drop rather than block the pool
+		}
+	}
+}
+
+// RemoveBlockTransactions drops the transactions a newly connected block
+// confirmed, plus pending transactions that spend an output the block spent
+// and everything descending from them: they can never confirm now. It
+// returns how many conflicting transactions were dropped.
+func (mp *Mempool) RemoveBlockTransactions(block *Block) int {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, tx := range block.Transactions {
+		mp.removeLocked(tx.ID)
+	}
+
+	doomed := make(map[string]bool)
+	for _, tx := range block.Transactions {
+		if tx.IsCoinbase() {
+			continue
+		}
+		for _, in := range tx.Inputs {
+			if id, ok := mp.spent[UTXOKey{TxID: in.TxID, Index: in.Index}]; ok {
+				doomed[id] = true
+			}
+		}
+	}
+	if len(doomed) == 0 {
+		return 0
+	}
+	evict := mp.descendantsLocked(doomed)
+	for _, id := range evict {
+		mp.evictLocked(id)
+	}
+	return len(evict)
+}
+
+// FeeInfo returns what the pending transaction with the given ID pays.
+func (mp *Mempool) FeeInfo(txID string) (FeeInfo, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	info, ok := mp.fees[txID]
+	return info, ok
+}
+
+// GetTransactionsByFee returns up to limit pending transactions (0 = all),
+// highest fee rate first. Transactions the AI flagged above the policy's
+// DeprioritizeAnomalyAbove come after all others, so they are confirmed
+// last. A transaction spending the output of another pending transaction
+// is placed after it, so the result can fill a block in order.
+func (mp *Mempool) GetTransactionsByFee(limit int) []*Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.byFeeLocked(limit, nil)
+}
+
+// byFeeLocked ranks the pool as GetTransactionsByFee describes, leaving out
+// the transactions skip reports (nil = none).
+func (mp *Mempool) byFeeLocked(limit int, skip func(tx *Transaction) bool) []*Transaction {
+	ranked := make([]*Transaction, 0, len(mp.txs))
+	for _, tx := range mp.txs {
+		if skip == nil || !skip(tx) {
+			ranked = append(ranked, tx)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if di, dj := mp.deprioritizedLocked(ranked[i].ID), mp.deprioritizedLocked(ranked[j].ID); di != dj {
+			return dj
+		}
+		a, b := mp.fees[ranked[i].ID], mp.fees[ranked[j].ID]
+		if a.FeeRate != b.FeeRate {
+			return a.FeeRate > b.FeeRate
+		}
+		return ranked[i].ID < ranked[j].ID
+	})
+
+	result := make([]*Transaction, 0, len(ranked))
+	placed := make(map[string]bool, len(ranked))
+	var place func(tx *Transaction)
+	place = func(tx *Transaction) {
+		if placed[tx.ID] {
+			return
+		}
+		placed[tx.ID] = true
+		for _, in := range tx.Inputs {
+			if parent, ok := mp.txs[in.TxID]; ok {
+				place(parent)
+			}
+		}
+		result = append(result, tx)
+	}
+	for _, tx := range ranked {
+		place(tx)
+	}
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
 }
 
 func (mp *Mempool) GetTransactions() []*Transaction {
@@ -53,9 +661,91 @@ func (mp *Mempool) Size() int {
 	return len(mp.txs)
 }
 
-func (mp *Mempool) Clear() {
+// Clear empties the pool and reports how many transactions it held.
+func (mp *Mempool) Clear() int {
 	mp.mu.Lock()
 	defer mp.mu.Unlock()
 
+	n := len(mp.txs)
+	for id := range mp.txs {
+		mp.evictLocked(id)
+	}
 	mp.txs = make(map[string]*Transaction)
+	mp.fees = make(map[string]FeeInfo)
+	mp.scores = make(map[string]TxScore)
+	mp.inFlight = make(map[string]bool)
+	mp.spent = make(map[UTXOKey]string)
+	return n
+}
+
+// ApplyReorg updates the pool after the main chain switched branches:
+// transactions confirmed by the new branch are dropped, and transactions
+// from disconnected blocks that are still valid against utxo and the pool
+// return to it. Pending transactions are then checked again, since some
+// may spend outputs only the disconnected blocks created. It reports how
+// many were restored.
+func (mp *Mempool) ApplyReorg(reorg *Reorg, utxo *UTXOSet) int {
+	confirmed := make(map[string]bool)
+	for _, b := range reorg.Connected {
+		for _, tx := range b.Transactions {
+			confirmed[tx.ID] = true
+		}
+		mp.RemoveBlockTransactions(b)
+	}
+
+	restored := 0
+	for _, b := range reorg.Disconnected {
+		for i := range b.Transactions {
+			tx := b.Transactions[i]
+			if tx.IsCoinbase() || confirmed[tx.ID] {
+				continue
+			}
+			view := mp.InputView(&tx, utxo.Get)
+			if VerifyTransaction(&tx, view) != nil {
+				continue
+			}
+			fee, err := ComputeFee(&tx, view)
+			if err != nil {
+				continue
+			}
+			if mp.AddTransaction(&tx, fee) == nil {
+				restored++
+			}
+		}
+	}
+	mp.Revalidate(utxo)
+	return restored
+}
+
+// Revalidate checks every pending transaction again against utxo and the
+// outputs of its pending ancestors, parents before children, and drops
+// those no longer valid together with their descendants. It returns the
+// IDs dropped.
+func (mp *Mempool) Revalidate(utxo *UTXOSet) []string {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	invalid := make(map[string]bool)
+	for _, tx := range mp.byFeeLocked(0, nil) {
+		view := NewUTXOSet()
+		for _, in := range tx.Inputs {
+			key := UTXOKey{TxID: in.TxID, Index: in.Index}
+			if out, ok := utxo.Get(key); ok {
+				view.Add(key.TxID, key.Index, out)
+			} else if parent, ok := mp.txs[key.TxID]; ok && !invalid[key.TxID] && key.Index >= 0 && key.Index < len(parent.Outputs) {
+				view.Add(key.TxID, key.Index, parent.Outputs[key.Index])
+			}
+		}
+		if VerifyTransaction(tx, view) != nil {
+			invalid[tx.ID] = true
+		}
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	evict := mp.descendantsLocked(invalid)
+	for _, id := range evict {
+		mp.evictLocked(id)
+	}
+	return evict
 }
@@ -0,0 +1,57 @@
+package chain
+
+// ReserveTransactions selects up to limit pending transactions (0 = all) for
+// a block template, in GetTransactionsByFee order, and marks them in
+// flight. Transactions already in flight are left out, and so are their
+// pending descendants, which cannot confirm in another block before them.
+// So two templates built at once never include the same transaction.
+//
+// The reservation ends when the transactions leave the pool, normally with
+// the block that confirms them, or when ReleaseTransactions hands them
+// back because mining failed or the block was rejected.
+func (mp *Mempool) ReserveTransactions(limit int) []*Transaction {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	blocked := make(map[string]bool)
+	var isBlocked func(tx *Transaction) bool
+	isBlocked = func(tx *Transaction) bool {
+		if b, ok := blocked[tx.ID]; ok {
+			return b
+		}
+		b := mp.inFlight[tx.ID]
+		for _, in := range tx.Inputs {
+			if parent, ok := mp.txs[in.TxID]; ok && !b {
+				b = isBlocked(parent)
+			}
+		}
+		blocked[tx.ID] = b
+		return b
+	}
+
+	txs := mp.byFeeLocked(limit, isBlocked)
+	for _, tx := range txs {
+		mp.inFlight[tx.ID] = true
+	}
+	return txs
+}
+
+// ReleaseTransactions ends the reservation ReserveTransactions made for
+// txs, making those still pending available to the next template.
+func (mp *Mempool) ReleaseTransactions(txs []*Transaction) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	for _, tx := range txs {
+		delete(mp.inFlight, tx.ID)
+	}
+}
+
+// InFlight reports whether a pending transaction is in a block template
+// being mined.
+func (mp *Mempool) InFlight(txID string) bool {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	return mp.inFlight[txID]
+}
@@ -0,0 +1,37 @@
+package chain
+
+// TxScore is the AI service's verdict on a pending transaction, kept with
+// it so it is not asked twice. Scores are advisory: they order the pool
+// but never decide what is valid.
+type TxScore struct {
+	AnomalyScore float64 `json:"anomaly_score"`
+	FeeAdequacy  float64 `json:"fee_adequacy"`
+	ScoredAt     int64   `json:"scored_at"`
+}
+
+// SetScore records the AI score of a pending transaction. Scores for
+// transactions no longer in the pool are dropped.
+func (mp *Mempool) SetScore(txID string, score TxScore) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	if _, ok := mp.txs[txID]; ok {
+		mp.scores[txID] = score
+	}
+}
+
+// Score returns the AI score of a pending transaction, if it has one.
+// Transactions relayed by peers are unscored until a miner scores them.
+func (mp *Mempool) Score(txID string) (TxScore, bool) {
+	mp.mu.Lock()
+	defer mp.mu.Unlock()
+
+	score, ok := mp.scores[txID]
+	return score, ok
+}
+
+func (mp *Mempool) deprioritizedLocked(txID string) bool {
+	threshold := mp.policy.DeprioritizeAnomalyAbove
+	score, ok := mp.scores[txID]
+	return ok && threshold > 0 && score.AnomalyScore > threshold
+}
@@ -0,0 +1,192 @@
+package chain
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// A child spending an unconfirmed output is admitted through the pipeline,
+// counts towards its parent's descendant limit, and confirms with it.
+func TestPipelineAdmitsUnconfirmedChildren(t *testing.T) {
+	alice, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.AddressFromPublicKey(&alice.PublicKey)
+
+	bc := newTestChain(t, address)
+	policy := DefaultMempoolPolicy()
+	policy.MaxDescendants = 2
+	mempool := NewMempoolWithPolicy(policy)
+	pipeline := NewBlockPipeline(bc, mempool)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+
+	spend := func(in TxIn, amount float64) *Transaction {
+		t.Helper()
+		tx, err := NewTransaction([]TxIn{in}, []TxOut{{Address: address, Amount: amount}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		signTestTx(t, tx, alice)
+		return tx
+	}
+
+	genesis := bc.Genesis().Transactions[0]
+	parent, err := NewTransaction([]TxIn{{TxID: genesis.ID, Index: 0}},
+		[]TxOut{{Address: address, Amount: 20}, {Address: address, Amount: 29}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signTestTx(t, parent, alice)
+	if err := pipeline.AddTransaction(parent); err != nil {
+		t.Fatalf("parent: %v", err)
+	}
+
+	child := spend(TxIn{TxID: parent.ID, Index: 0}, parent.Outputs[0].Amount-1)
+	if err := pipeline.AddTransaction(child); err != nil {
+		t.Fatalf("child of a pending transaction: %v", err)
+	}
+	second := spend(TxIn{TxID: parent.ID, Index: 1}, parent.Outputs[1].Amount-1)
+	if err := pipeline.AddTransaction(second); !errors.Is(err, ErrDescendantLimit) {
+		t.Fatalf("third in the family: err = %v, want ErrDescendantLimit", err)
+	}
+
+	block := mineTestBlock(t, bc, address, mempool.GetTransactionsByFee(0)...)
+	if err := pipeline.ConnectBlock(block); err != nil {
+		t.Fatalf("block with parent and child: %v", err)
+	}
+	if n := mempool.Size(); n != 0 {
+		t.Fatalf("%d transactions left pending after the block", n)
+	}
+	if err := pipeline.AddTransaction(second); err != nil {
+		t.Fatalf("once the parent confirmed: %v", err)
+	}
+}
+
+// A reorg takes away the outputs the abandoned blocks created. Pending
+// spends of them, such as of an abandoned coinbase, must leave the pool
+// with their descendants, or every later block template fails.
+func TestReorgEvictsSpendsOfDisconnectedOutputs(t *testing.T) {
+	alice, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.AddressFromPublicKey(&alice.PublicKey)
+	bob := strings.Repeat("b", 64)
+
+	bc := newTestChain(t, address)
+	mempool := NewMempool()
+	pipeline := NewBlockPipeline(bc, mempool)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+
+	side := mineBranch(t, bc, bob, 2)
+	mined := mineTestBlock(t, bc, address)
+	if err := pipeline.ConnectBlock(mined); err != nil {
+		t.Fatal(err)
+	}
+
+	spend := func(in TxIn, amount float64) *Transaction {
+		t.Helper()
+		tx, err := NewTransaction([]TxIn{in}, []TxOut{{Address: address, Amount: amount}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		signTestTx(t, tx, alice)
+		if err := pipeline.AddTransaction(tx); err != nil {
+			t.Fatalf("AddTransaction: %v", err)
+		}
+		return tx
+	}
+	coinbase := mined.Transactions[0]
+	orphaned := spend(TxIn{TxID: coinbase.ID, Index: 0}, coinbase.Outputs[0].Amount-1)
+	child := spend(TxIn{TxID: orphaned.ID, Index: 0}, orphaned.Outputs[0].Amount-1)
+	genesis := bc.Genesis().Transactions[0]
+	kept := spend(TxIn{TxID: genesis.ID, Index: 0}, genesis.Outputs[0].Amount-1)
+
+	for i, block := range side {
+		status, err := pipeline.ProcessBlock(block)
+		if err != nil {
+			t.Fatalf("side block %d: %v", i, err)
+		}
+		if i == len(side)-1 && status != BlockReorganized {
+			t.Fatalf("status %q, want %q", status, BlockReorganized)
+		}
+	}
+
+	for _, tx := range []*Transaction{orphaned, child} {
+		if mempool.Has(tx.ID) {
+			t.Errorf("%s spends an abandoned output but is still pending", tx.ID)
+		}
+	}
+	if !mempool.Has(kept.ID) {
+		t.Fatalf("spend of the genesis output was dropped")
+	}
+
+	template := mineTestBlock(t, bc, bob, mempool.GetTransactionsByFee(0)...)
+	if err := pipeline.ConnectBlock(template); err != nil {
+		t.Fatalf("template after the reorg: %v", err)
+	}
+}
+
+// Eviction subscribers hear of every transaction leaving the pool without
+// confirming, and of no confirmed one.
+func TestEvictionsAreAnnounced(t *testing.T) {
+	mempool := NewMempool()
+	evicted, cancel := mempool.SubscribeEvictions()
+	defer cancel()
+
+	pay := func(in TxIn, amount float64) *Transaction {
+		t.Helper()
+		tx, err := NewTransaction([]TxIn{in}, []TxOut{{Address: strings.Repeat("b", 64), Amount: amount}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	a := TxIn{TxID: strings.Repeat("a", 64), Index: 0}
+	c := TxIn{TxID: strings.Repeat("c", 64), Index: 0}
+	d := TxIn{TxID: strings.Repeat("d", 64), Index: 0}
+
+	original, replacement := pay(a, 10), pay(a, 9)
+	confirmed, dropped := pay(c, 10), pay(d, 10)
+	for _, add := range []struct {
+		tx  *Transaction
+		fee float64
+	}{{original, 0.001}, {confirmed, 0.001}, {dropped, 0.001}, {replacement, 1}} {
+		if err := mempool.AddTransaction(add.tx, add.fee); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mempool.RemoveBlockTransactions(&Block{Transactions: []Transaction{*confirmed}})
+	mempool.RemoveWithDescendants(dropped.ID)
+	mempool.Clear()
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case id := <-evicted:
+			got = append(got, id)
+		default:
+			t.Fatalf("announced %v, want the replaced, evicted and cleared transactions", got)
+		}
+	}
+	want := []string{original.ID, dropped.ID, replacement.ID}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("announced %v, want %v", got, want)
+		}
+	}
+	select {
+	case id := <-evicted:
+		t.Fatalf("also announced %s", id)
+	default:
+	}
+}
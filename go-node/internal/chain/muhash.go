@@ -0,0 +1,79 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+)
+
+// muHashPrime is 2^3072 - 1103717, the modulus MuHash3072 works in.
+var muHashPrime = func() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), 3072)
+	return p.Sub(p, big.NewInt(1103717))
+}()
+
+const muHashBytes = 3072 / 8
+
+// MuHash is a rolling hash of a set: elements are mapped to numbers modulo a
+// large prime and multiplied together, so adding or removing one costs a
+// single modular multiplication and the result does not depend on order.
+// Removals are multiplied into a separate denominator so no modular inverse
+// is needed until the digest is taken.
+type MuHash struct {
+	num *big.Int
+	den *big.Int
+}
+
+func NewMuHash() *MuHash {
+	return &MuHash{num: big.NewInt(1), den: big.NewInt(1)}
+}
+
+func (m *MuHash) Clone() *MuHash {
+	return &MuHash{num: new(big.Int).Set(m.num), den: new(big.Int).Set(m.den)}
+}
+
+func (m *MuHash) Insert(data []byte) {
+	m.num.Mul(m.num, muHashElement(data))
+	m.num.Mod(m.num, muHashPrime)
+}
+
+func (m *MuHash) Remove(data []byte) {
+	m.den.Mul(m.den, muHashElement(data))
+	m.den.Mod(m.den, muHashPrime)
+}
+
+// Digest is SHA-256 of num/den mod p as a 384-byte big-endian number, hex
+// encoded. Equal sets give equal digests regardless of history.
+func (m *MuHash) Digest() string {
+	inv := new(big.Int).ModInverse(m.den, muHashPrime)
+	v := inv.Mul(inv, m.num)
+	v.Mod(v, muHashPrime)
+
+	buf := make([]byte, muHashBytes)
+	v.FillBytes(buf)
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// muHashElement expands SHA-256(data) to 3072 bits by hashing it with a
+// counter and reduces the result modulo p.
+func muHashElement(data []byte) *big.Int {
+	seed := sha256.Sum256(data)
+	buf := make([]byte, 0, muHashBytes)
+	var block [sha256.Size + 4]byte
+	copy(block[:], seed[:])
+	for i := uint32(0); len(buf) < muHashBytes; i++ {
+		binary.BigEndian.PutUint32(block[sha256.Size:], i)
+		sum := sha256.Sum256(block[:])
+		buf = append(buf, sum[:]...)
+	}
+	e := new(big.Int).SetBytes(buf[:muHashBytes])
+	return e.Mod(e, muHashPrime)
+}
+
+// utxoHashData is the byte string a UTXO contributes to the set hash.
+func utxoHashData(key UTXOKey, out TxOut) []byte {
+	return []byte(key.TxID + ":" + strconv.Itoa(key.Index) + ":" + out.Address + ":" + FormatAmount(out.Amount))
+}
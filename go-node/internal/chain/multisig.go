@@ -0,0 +1,134 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// An m-of-n multisig address is "ms" followed by the SHA-256 of
+//
+//	"multisig:" m ":" pubkey1 "," pubkey2 "," ... pubkeyN
+//
+// with the public keys as lowercase hex X||Y in ascending order. Outputs
+// paid to it can only be spent by a transaction carrying a MultisigSpend
+// that names the same m and keys and holds at least m valid signatures of
+// the transaction's canonical bytes. Like Bitcoin's P2SH, the address
+// commits to the keys without revealing them until the first spend.
+//
+// Unlike P2SH, this is a fixed output type and not a script. The chain has
+// no script engine, opcodes or stack, so there are no locking and unlocking
+// scripts to trace step by step. A failed spend instead returns an error
+// that names the check and the signature slot that failed.
+
+const (
+	MultisigAddressPrefix = "ms"
+	MaxMultisigKeys       = 15
+)
+
+var ErrMultisig = errors.New("invalid multisig spend")
+
+// MultisigSpend is the witness for spending multisig outputs. Signatures
+// has one slot per key in PubKeys, empty until that key signs; the txid
+// covers none of it, so co-signers fill slots in without changing the
+// transaction they sign.
+type MultisigSpend struct {
+	Required   int      `json:"required"`
+	PubKeys    []string `json:"pubkeys"`
+	Signatures []string `json:"signatures"`
+}
+
+// IsMultisigAddress reports whether address is shaped like a multisig
+// address.
+func IsMultisigAddress(address string) bool {
+	hash := strings.TrimPrefix(address, MultisigAddressPrefix)
+	return len(hash) == 64 && len(address) == 66 && crypto.IsLowerHex(hash)
+}
+
+// MultisigAddress is the address of the required-of-len(pubKeys) multisig
+// over pubKeys, which must be distinct P-256 keys in ascending order.
+func MultisigAddress(required int, pubKeys []string) (string, error) {
+	if len(pubKeys) < 1 || len(pubKeys) > MaxMultisigKeys {
+		return "", fmt.Errorf("%w: must have between 1 and %d keys", ErrMultisig, MaxMultisigKeys)
+	}
+	if required < 1 || required > len(pubKeys) {
+		return "", fmt.Errorf("%w: required signatures must be between 1 and %d", ErrMultisig, len(pubKeys))
+	}
+	for i, key := range pubKeys {
+		if len(key) != 2*crypto.PublicKeyLength || !crypto.IsLowerHex(key) {
+			return "", fmt.Errorf("%w: key %d must be %d lowercase hex characters", ErrMultisig, i, 2*crypto.PublicKeyLength)
+		}
+		if _, err := crypto.DecodePublicKey(key); err != nil {
+			return "", fmt.Errorf("%w: key %d: %v", ErrMultisig, i, err)
+		}
+		if i > 0 && key <= pubKeys[i-1] {
+			return "", fmt.Errorf("%w: keys must be distinct and in ascending order", ErrMultisig)
+		}
+	}
+	descriptor := "multisig:" + strconv.Itoa(required) + ":" + strings.Join(pubKeys, ",")
+	return MultisigAddressPrefix + crypto.SHA256([]byte(descriptor)), nil
+}
+
+// Address is the multisig address the spend unlocks.
+func (m *MultisigSpend) Address() (string, error) {
+	return MultisigAddress(m.Required, m.PubKeys)
+}
+
+// SignatureCount is the number of filled signature slots.
+func (m *MultisigSpend) SignatureCount() int {
+	count := 0
+	for _, sig := range m.Signatures {
+		if sig != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// checkMultisigForm is CheckCanonicalForm for the witness: one slot per
+// key, each empty or a canonical signature.
+func checkMultisigForm(m *MultisigSpend) error {
+	if len(m.Signatures) != len(m.PubKeys) {
+		return nonCanonical("multisig must have one signature slot per key")
+	}
+	for i, sig := range m.Signatures {
+		if sig == "" {
+			continue
+		}
+		if err := crypto.CheckSignatureEncoding(sig); err != nil {
+			return nonCanonical("multisig signature %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// verifyMultisig checks that the witness unlocks address: it names the
+// keys address commits to and at least Required of its slots hold valid
+// signatures of canonical. A filled slot that does not verify fails the
+// spend even when enough others do.
+func verifyMultisig(m *MultisigSpend, address string, canonical []byte) error {
+	expected, err := m.Address()
+	if err != nil {
+		return err
+	}
+	if expected != address {
+		return fmt.Errorf("%w: keys do not match multisig address %s", ErrMultisig, address)
+	}
+
+	for i, sig := range m.Signatures {
+		if sig == "" {
+			continue
+		}
+		ok, err := crypto.VerifySignature(canonical, sig, m.PubKeys[i])
+		if err != nil || !ok {
+			return fmt.Errorf("%w: signature %d does not verify", ErrMultisig, i)
+		}
+	}
+	if count := m.SignatureCount(); count < m.Required {
+		return fmt.Errorf("%w: %d of %d required signatures", ErrMultisig, count, m.Required)
+	}
+	return nil
+}
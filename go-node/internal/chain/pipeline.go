@@ -0,0 +1,162 @@
+package chain
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrPipelineStopped = errors.New("block pipeline stopped")
+
+// BlockPipeline is the node's single writer. Mined blocks, blocks from peers
+// and the follower, and transactions entering the mempool are all handed to
+// it, and one goroutine applies them one at a time: a block is connected
+// and the mempool brought in line with it before anything else is applied.
+// So the mempool never sees the chain half-way through a change, and a
+// transaction is checked against the same UTXO set it is admitted over.
+//
+// Blockchain.AddBlock, ProcessBlock and Mempool.AddTransaction stay
+// available for tools that own the chain outright; node components must go
+// through the pipeline.
+type BlockPipeline struct {
+	blockchain *Blockchain
+	mempool    *Mempool
+	jobs       chan pipelineJob
+	stopped    chan struct{}
+}
+
+type pipelineJob struct {
+	apply  func() (string, error)
+	result chan pipelineResult
+}
+
+type pipelineResult struct {
+	status string
+	err    error
+}
+
+func NewBlockPipeline(blockchain *Blockchain, mempool *Mempool) *BlockPipeline {
+	return &BlockPipeline{
+		blockchain: blockchain,
+		mempool:    mempool,
+		jobs:       make(chan pipelineJob),
+		stopped:    make(chan struct{}),
+	}
+}
+
+// Run applies submitted work until ctx is done. Work submitted afterwards
+// fails with ErrPipelineStopped.
+func (p *BlockPipeline) Run(ctx context.Context) {
+	defer close(p.stopped)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-p.jobs:
+			status, err := job.apply()
+			job.result <- pipelineResult{status: status, err: err}
+		}
+	}
+}
+
+// do runs apply on the writer goroutine and waits for it.
+func (p *BlockPipeline) do(apply func() (string, error)) (string, error) {
+	job := pipelineJob{apply: apply, result: make(chan pipelineResult, 1)}
+	select {
+	case p.jobs <- job:
+	case <-p.stopped:
+		return "", ErrPipelineStopped
+	}
+	r := <-job.result
+	return r.status, r.err
+}
+
+// ConnectBlock connects a locally mined block on top of the tip, as
+// Blockchain.AddBlock does, and drops its transactions, and any pending
+// ones conflicting with them, from the mempool.
+func (p *BlockPipeline) ConnectBlock(block *Block) error {
+	_, err := p.do(func() (string, error) {
+		if err := p.blockchain.AddBlock(block); err != nil {
+			return "", err
+		}
+		p.mempool.RemoveBlockTransactions(block)
+		return BlockExtendedTip, nil
+	})
+	return err
+}
+
+// ProcessBlock hands a block from the network to Blockchain.ProcessBlock and
+// updates the mempool for the outcome. After a reorg, transactions from
+// the abandoned blocks return to the pool before subscribers hear of it.
+func (p *BlockPipeline) ProcessBlock(block *Block) (string, error) {
+	return p.do(func() (string, error) {
+		status, reorg, err := p.blockchain.processBlock(block)
+		if err != nil {
+			return "", err
+		}
+		switch status {
+		case BlockExtendedTip:
+			p.mempool.RemoveBlockTransactions(block)
+		case BlockReorganized:
+			reorg.Restored = p.mempool.ApplyReorg(reorg, p.blockchain.UTXO)
+			p.blockchain.publishReorg(reorg)
+		}
+		return status, nil
+	})
+}
+
+// AddTransaction verifies tx against the tip's UTXO set and the outputs of
+// pending transactions, checks the relay policy and admits it to the
+// mempool. Callers may check it beforehand to report problems in detail;
+// this check is the one that counts, since no block can be connected
+// between it and the admission.
+func (p *BlockPipeline) AddTransaction(tx *Transaction) error {
+	_, err := p.do(func() (string, error) {
+		view := p.mempool.InputView(tx, p.blockchain.UTXO.Get)
+		if err := VerifyTransaction(tx, view); err != nil {
+			return "", err
+		}
+		fee, err := ComputeFee(tx, view)
+		if err != nil {
+			return "", err
+		}
+		if err := p.mempool.CheckFee(tx, fee); err != nil {
+			return "", err
+		}
+		return "", p.mempool.AddTransaction(tx, fee)
+	})
+	return err
+}
+
+// EvictTransaction removes a pending transaction and everything spending its
+// outputs from the mempool, returning the IDs removed.
+func (p *BlockPipeline) EvictTransaction(txID string) ([]string, error) {
+	var removed []string
+	_, err := p.do(func() (string, error) {
+		removed = p.mempool.RemoveWithDescendants(txID)
+		return "", nil
+	})
+	return removed, err
+}
+
+// ClearMempool drops every pending transaction, returning how many there
+// were.
+func (p *BlockPipeline) ClearMempool() (int, error) {
+	var cleared int
+	_, err := p.do(func() (string, error) {
+		cleared = p.mempool.Clear()
+		return "", nil
+	})
+	return cleared, err
+}
+
+// Reindex rebuilds the chain's derived state, as Blockchain.Reindex does,
+// with no block or transaction applied while it runs. The mempool is left
+// as it is.
+func (p *BlockPipeline) Reindex() (ReindexResult, error) {
+	var result ReindexResult
+	_, err := p.do(func() (string, error) {
+		result = p.blockchain.Reindex()
+		return "", nil
+	})
+	return result, err
+}
@@ -0,0 +1,179 @@
+package chain
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// mineTestBlock solves a block on bc's tip paying the block reward to
+// address and carrying txs.
+func mineTestBlock(t testing.TB, bc *Blockchain, address string, txs ...*Transaction) *Block {
+	t.Helper()
+	tip := bc.Tip()
+	coinbase, err := NewCoinbaseTransaction(tip.Index+1, address, bc.BlockReward())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []Transaction{*coinbase}
+	for _, tx := range txs {
+		body = append(body, *tx)
+	}
+	block := NewBlock(tip.Index+1, tip.Hash, body)
+	block.Timestamp = tip.Timestamp + 1
+	block.Difficulty = bc.NextDifficulty()
+	hash, nonce, err := consensus.MineBlock(context.Background(),
+		func(nonce int64) string { return block.ComputeHash() },
+		func(nonce int64) { block.Nonce = nonce },
+		block.Difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Hash, block.Nonce = hash, nonce
+	return block
+}
+
+// Readers outside the pipeline go through the chain's locked accessors;
+// run with -race to check they never touch the UTXO set mid-write.
+func TestPipelineConcurrentReads(t *testing.T) {
+	alice, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliceAddress := crypto.AddressFromPublicKey(&alice.PublicKey)
+	bob := strings.Repeat("b", 64)
+
+	bc := newTestChain(t, aliceAddress)
+	mempool := NewMempool()
+	pipeline := NewBlockPipeline(bc, mempool)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+
+	genesis := bc.Genesis().Transactions[0]
+	tx, err := NewTransaction([]TxIn{{TxID: genesis.ID, Index: 0}}, []TxOut{{Address: bob, Amount: 10}, {Address: aliceAddress, Amount: 39}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	signTestTx(t, tx, alice)
+
+	// Mined ahead of time on a scratch chain with the same genesis, so the
+	// writer does nothing but connect them.
+	scratch := NewBlockchain(bc.Genesis())
+	scratch.SetDifficulty(1)
+	blocks := make([]*Block, 10)
+	for i := range blocks {
+		blocks[i] = mineTestBlock(t, scratch, aliceAddress)
+		if err := scratch.AddBlock(blocks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// One goroutine per accessor: the locks each takes would otherwise
+	// order the others' reads after the writer's and hide a missing one.
+	readers := []func(){
+		func() { mempool.InputView(tx, bc.Output) },
+		func() { bc.BalanceOf(aliceAddress) },
+		func() { bc.UnspentOutputs(aliceAddress) },
+		func() { bc.UTXOCountOf(aliceAddress) },
+		func() { mempool.SpendableUTXO(bc) },
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, read := range readers {
+		wg.Add(1)
+		go func(read func()) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					read()
+				}
+			}
+		}(read)
+	}
+
+	if err := pipeline.AddTransaction(tx); err != nil {
+		t.Fatalf("AddTransaction: %v", err)
+	}
+	for _, block := range blocks {
+		if err := pipeline.ConnectBlock(block); err != nil {
+			t.Fatalf("ConnectBlock: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if got := bc.Height(); got != len(blocks)+1 {
+		t.Fatalf("height %d, want %d", got, len(blocks)+1)
+	}
+}
+
+// Proposals are checked from API handlers while the pipeline connects
+// blocks; run with -race to check the state check holds the chain's lock.
+func TestProposalDuringBlockConnect(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	bc := newTestChain(t, address)
+	pipeline := NewBlockPipeline(bc, NewMempool())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+
+	scratch := NewBlockchain(bc.Genesis())
+	scratch.SetDifficulty(1)
+	blocks := make([]*Block, 50)
+	for i := range blocks {
+		blocks[i] = mineTestBlock(t, scratch, address)
+		if err := scratch.AddBlock(blocks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	propose := func() {
+		tip := bc.Tip()
+		coinbase, err := NewCoinbaseTransaction(tip.Index+1, address, bc.BlockReward())
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		block := NewBlock(tip.Index+1, tip.Hash, []Transaction{*coinbase})
+		block.Timestamp = tip.Timestamp + 1
+		block.Difficulty = bc.NextDifficulty()
+		// A block connected since Tip was read makes the proposal stale;
+		// that is the only way it may fail.
+		if err := VerifyBlockProposal(block, bc); err != nil && bc.Tip().Hash == tip.Hash {
+			t.Errorf("proposal on unchanged tip %d: %v", tip.Index, err)
+		}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					propose()
+				}
+			}
+		}()
+	}
+
+	for _, block := range blocks {
+		if err := pipeline.ConnectBlock(block); err != nil {
+			t.Fatalf("ConnectBlock: %v", err)
+		}
+	}
+	close(done)
+	wg.Wait()
+	propose()
+}
@@ -0,0 +1,72 @@
+package chain
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// A block may name the miner that produced it. MinerPubKey is set before
+// mining, so the proof of work covers it and nobody can swap in their own
+// key afterwards; MinerSignature is made over the finished hash. Both are
+// optional, but a block with one must have the other and the signature must
+// verify.
+//
+// The identity is for attribution only. Two signed blocks at the same height
+// are not evidence of misbehaviour under proof of work, since a miner whose
+// block goes stale mines its replacement at the same height. There is also
+// no stake to slash, so signatures carry no penalty.
+
+// producerMessage is what a producer signs: the block hash, prefixed so the
+// signature cannot be replayed as anything else.
+func producerMessage(hash string) []byte {
+	return []byte("block-producer:" + hash)
+}
+
+// SignProducer sets the block's producer signature. priv must be the key
+// MinerPubKey names and the block's hash must be final.
+func (b *Block) SignProducer(priv *ecdsa.PrivateKey) error {
+	if crypto.EncodePublicKey(&priv.PublicKey) != b.MinerPubKey {
+		return errors.New("signing key does not match the block's producer key")
+	}
+	signature, err := crypto.SignMessage(priv, producerMessage(b.Hash))
+	if err != nil {
+		return err
+	}
+	b.MinerSignature = signature
+	return nil
+}
+
+func checkProducerKey(pubKey string) error {
+	if len(pubKey) != 2*crypto.PublicKeyLength || !crypto.IsLowerHex(pubKey) {
+		return errors.New("producer key must be 128 lowercase hex characters")
+	}
+	if _, err := crypto.DecodePublicKey(pubKey); err != nil {
+		return fmt.Errorf("producer key: %w", err)
+	}
+	return nil
+}
+
+func checkProducer(pubKey, signature, hash string) error {
+	if pubKey == "" && signature == "" {
+		return nil
+	}
+	if pubKey == "" {
+		return errors.New("block has a producer signature but no producer key")
+	}
+	if signature == "" {
+		return errors.New("block names a producer but is not signed")
+	}
+	if err := checkProducerKey(pubKey); err != nil {
+		return err
+	}
+	if err := crypto.CheckSignatureEncoding(signature); err != nil {
+		return fmt.Errorf("producer signature: %w", err)
+	}
+	if ok, err := crypto.VerifySignature(producerMessage(hash), signature, pubKey); err != nil || !ok {
+		return errors.New("producer signature does not verify")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package chain
+
+// ReindexResult reports what Reindex rebuilt.
+type ReindexResult struct {
+	Blocks       int    `json:"blocks"`
+	Transactions int    `json:"transactions"`
+	UTXOs        int    `json:"utxos"`
+	UTXOHash     string `json:"utxo_hash"`
+	Changed      bool   `json:"changed"` // the rebuilt UTXO set differs from the one it replaced
+}
+
+// Reindex rebuilds the UTXO set, the undo data and the transaction and
+// address indexes by replaying the main chain from genesis. Blocks are not
+// revalidated; it repairs derived state, not the chain itself.
+func (bc *Blockchain) Reindex() ReindexResult {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	before := bc.UTXO.Hash()
+	utxo := NewUTXOSet()
+	txs := make(txIndex)
+	addrs := make(addressIndex)
+	result := ReindexResult{Blocks: len(bc.Blocks)}
+	for height, block := range bc.Blocks {
+		var spent SpentOutputs
+		if height == 0 {
+			for i := range block.Transactions {
+				utxo.ApplyTransaction(&block.Transactions[i])
+			}
+		} else {
+			spent = utxo.ApplyBlock(block)
+			bc.undo[block.Hash] = spent
+		}
+		txs.addBlock(block, height)
+		addrs.addBlock(block, height, spent)
+		result.Transactions += len(block.Transactions)
+	}
+
+	// Replace the set in place: the mempool and API hold on to bc.UTXO.
+	*bc.UTXO = *utxo
+	bc.txIndex = txs
+	bc.addrIndex = addrs
+
+	result.UTXOs = bc.UTXO.Count()
+	result.UTXOHash = bc.UTXO.Hash()
+	result.Changed = result.UTXOHash != before
+	return result
+}
@@ -11,6 +11,7 @@ import (
 type txForHash struct {
 	Inputs  []TxIn  `json:"inputs"`
 	Outputs []TxOut `json:"outputs"`
+	Memo    string  `json:"memo,omitempty"`
 }
 
 func CanonicalTxBytes(tx *Transaction) ([]byte, error) {
@@ -27,12 +28,13 @@ func CanonicalTxBytes(tx *Transaction) ([]byte, error) {
 	})
 
 	sort.Slice(outputsCopy, func(i, j int) bool {
-		return outputsCopy[i].Address < outputsCopy[j].Address
+		return outputLess(outputsCopy[i], outputsCopy[j])
 	})
 
 	tmp := txForHash{
 		Inputs:  inputsCopy,
 		Outputs: outputsCopy,
+		Memo:    tx.Memo,
 	}
 
 	buf := &bytes.Buffer{}
@@ -58,4 +60,4 @@ func ComputeTxID(tx *Transaction) (string, error) {
 		return "", err
 	}
 	return crypto.SHA256(canonical), nil
-}
\ No newline at end of file
+}
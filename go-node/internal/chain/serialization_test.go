@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// Transactions arrive as JSON from the API and from peers; run with
+// go test -fuzz=FuzzTransactionJSON ./internal/chain.
+func FuzzTransactionJSON(f *testing.F) {
+	address := strings.Repeat("a", 64)
+	tx, err := NewTransaction(
+		[]TxIn{{TxID: strings.Repeat("1", 64), Index: 0}, {TxID: strings.Repeat("2", 64), Index: 3}},
+		[]TxOut{{Address: address, Amount: 1.5}, {Address: "ms" + address, Amount: 0.25}},
+	)
+	if err != nil {
+		f.Fatal(err)
+	}
+	tx.Memo = "fuzz"
+	seed, err := json.Marshal(tx)
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(seed)
+	f.Add([]byte(`{"id":"","inputs":[],"outputs":[{"address":"x","amount":-1}]}`))
+	f.Add([]byte(`{"outputs":[{"address":"x","amount":1e400}]}`))
+	f.Add([]byte(`{"multisig":{"required":2,"pubkeys":["00"],"signatures":[]}}`))
+	f.Add([]byte(`null`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tx Transaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			return
+		}
+
+		canonical, err := CanonicalTxBytes(&tx)
+		if err != nil {
+			t.Fatalf("CanonicalTxBytes: %v", err)
+		}
+		id, err := ComputeTxID(&tx)
+		if err != nil {
+			t.Fatalf("ComputeTxID: %v", err)
+		}
+		if id != crypto.SHA256(canonical) {
+			t.Fatalf("txid %s is not the hash of the canonical bytes", id)
+		}
+
+		// The canonical form, and so the txid, must not depend on the
+		// order inputs and outputs were sent in.
+		reordered := tx
+		reordered.Inputs = append([]TxIn(nil), tx.Inputs...)
+		reordered.Outputs = append([]TxOut(nil), tx.Outputs...)
+		for i, j := 0, len(reordered.Inputs)-1; i < j; i, j = i+1, j-1 {
+			reordered.Inputs[i], reordered.Inputs[j] = reordered.Inputs[j], reordered.Inputs[i]
+		}
+		for i, j := 0, len(reordered.Outputs)-1; i < j; i, j = i+1, j-1 {
+			reordered.Outputs[i], reordered.Outputs[j] = reordered.Outputs[j], reordered.Outputs[i]
+		}
+		again, err := CanonicalTxBytes(&reordered)
+		if err != nil {
+			t.Fatalf("CanonicalTxBytes after reordering: %v", err)
+		}
+		if !bytes.Equal(canonical, again) {
+			t.Fatalf("canonical bytes depend on order:\n%s\n%s", canonical, again)
+		}
+
+		// Validation must fail cleanly, never panic, whatever was decoded.
+		VerifyTransaction(&tx, NewUTXOSet())
+	})
+}
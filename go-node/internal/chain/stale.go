@@ -0,0 +1,62 @@
+package chain
+
+import (
+	"sync"
+	"time"
+)
+
+const DefaultMaxStaleBlocks = 1000
+
+// StaleBlock is a block that was valid when produced but lost the race to
+// extend the tip.
+type StaleBlock struct {
+	Block    *Block `json:"block"`
+	Reason   string `json:"reason"`
+	SeenAt   int64  `json:"seen_at"`
+	TipAtHit string `json:"tip_at_detection"`
+}
+
+type StaleStore struct {
+	mu     sync.RWMutex
+	blocks []StaleBlock
+	max    int
+	total  int
+}
+
+func NewStaleStore(max int) *StaleStore {
+	return &StaleStore{max: max}
+}
+
+func (ss *StaleStore) Record(block *Block, reason, tipHash string) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.blocks = append(ss.blocks, StaleBlock{
+		Block:    block,
+		Reason:   reason,
+		SeenAt:   time.Now().Unix(),
+		TipAtHit: tipHash,
+	})
+	if ss.max > 0 && len(ss.blocks) > ss.max {
+		ss.blocks = ss.blocks[len(ss.blocks)-ss.max:]
+	}
+	ss.total++
+}
+
+func (ss *StaleStore) List() []StaleBlock {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	out := make([]StaleBlock, len(ss.blocks))
+	copy(out, ss.blocks)
+	return out
+}
+
+// Total counts every stale block ever recorded, including ones evicted from
+// the bounded list.
+func (ss *StaleStore) Total() int {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	return ss.total
+}
@@ -5,15 +5,21 @@ import (
 )
 
 type Transaction struct {
-	ID        string   `json:"id"`        // Hash of canonical inputs+outputs
-	Inputs    []TxIn   `json:"inputs"`   // UTXOs being spent
-	Outputs   []TxOut  `json:"outputs"`  // New UTXOs being created
-	Signature string   `json:"signature"` // ECDSA signature (hex-encoded)
-	PubKey    string   `json:"pubkey"`    // Public key of signer (hex-encoded)
-	Timestamp int64    `json:"timestamp"` // Creation time (Unix timestamp)
+	ID        string         `json:"id"`                 // Hash of canonical inputs+outputs
+	Inputs    []TxIn         `json:"inputs"`             // UTXOs being spent
+	Outputs   []TxOut        `json:"outputs"`            // New UTXOs being created
+	Signature string         `json:"signature"`          // ECDSA signature (hex-encoded)
+	PubKey    string         `json:"pubkey"`             // Public key of signer (hex-encoded)
+	Timestamp int64          `json:"timestamp"`          // Creation time (Unix timestamp)
+	Memo      string         `json:"memo,omitempty"`     // Optional note, plain or encrypted to the recipient
+	Multisig  *MultisigSpend `json:"multisig,omitempty"` // Signatures spending multisig outputs
 }
 
+// NewTransaction puts inputs and outputs in canonical order, the only order
+// VerifyTransaction accepts, so output indices are known once it returns.
 func NewTransaction(inputs []TxIn, outputs []TxOut) (*Transaction, error) {
+	sortCanonical(inputs, outputs)
+
 	tx := &Transaction{
 		Inputs:    inputs,
 		Outputs:   outputs,
@@ -27,4 +33,4 @@ func NewTransaction(inputs []TxIn, outputs []TxOut) (*Transaction, error) {
 	tx.ID = id
 
 	return tx, nil
-}
\ No newline at end of file
+}
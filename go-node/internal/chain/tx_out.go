@@ -1,6 +1,122 @@
 package chain
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const AmountDecimals = 8
+
+// MaxAmount bounds every amount and every sum of amounts in a transaction.
+// Up to 2^53 base units float64 represents each 8-decimal value exactly;
+// past that, amounts stop round-tripping through JSON and the wire format.
+const MaxAmount = 90000000.0
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// CheckAmount rejects values no amount may take: NaN, infinities, negative
+// numbers and anything above MaxAmount. Zero passes; callers that need a
+// positive amount check for that themselves.
+func CheckAmount(amount float64) error {
+	switch {
+	case math.IsNaN(amount) || math.IsInf(amount, 0):
+		return fmt.Errorf("%w: %v is not a finite number", ErrInvalidAmount, amount)
+	case amount < 0:
+		return fmt.Errorf("%w: %v is negative", ErrInvalidAmount, amount)
+	case amount > MaxAmount:
+		return fmt.Errorf("%w: %v exceeds the maximum %.0f", ErrInvalidAmount, amount, MaxAmount)
+	}
+	return nil
+}
+
+// checkOutputAmounts is the consensus check on a transaction's outputs:
+// each must be positive and within range, and so must their total.
+func checkOutputAmounts(outputs []TxOut) (float64, error) {
+	var total float64
+	for i, out := range outputs {
+		if err := CheckAmount(out.Amount); err != nil {
+			return 0, fmt.Errorf("output %d: %w", i, err)
+		}
+		if out.Amount <= 0 {
+			return 0, errors.New("output amount must be positive")
+		}
+		total += out.Amount
+	}
+	if total > MaxAmount {
+		return 0, fmt.Errorf("%w: outputs total %s, more than the maximum %s", ErrInvalidAmount, FormatAmount(total), FormatAmount(MaxAmount))
+	}
+	return total, nil
+}
+
 type TxOut struct {
 	Address string  `json:"address"` // Hash of recipient's public key
 	Amount  float64 `json:"amount"`  // Value in coins (using float64 for precision)
-}
\ No newline at end of file
+}
+
+// FormatAmount renders an amount with a fixed number of decimals so 10,
+// 10.0 and 1e1 all serialize (and therefore hash) identically.
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', AmountDecimals, 64)
+}
+
+// RoundAmount rounds to AmountDecimals places, the precision amounts have
+// once serialized.
+func RoundAmount(amount float64) float64 {
+	return math.Round(amount*amountScale) / amountScale
+}
+
+// UnmarshalJSON refuses amounts CheckAmount rejects, so a transaction with
+// a negative or oversized output fails to decode rather than reaching
+// validation with a value that only looks like a number. -0 becomes 0, the
+// only zero the wire format can carry.
+func (o *TxOut) UnmarshalJSON(data []byte) error {
+	type plainTxOut TxOut
+	var out plainTxOut
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+	if err := CheckAmount(out.Amount); err != nil {
+		return err
+	}
+	if out.Amount == 0 {
+		out.Amount = 0
+	}
+	*o = TxOut(out)
+	return nil
+}
+
+// MarshalJSON is on the hot path of every block and mempool response, so
+// plain addresses are appended directly instead of going through reflection.
+func (o TxOut) MarshalJSON() ([]byte, error) {
+	if !plainJSONString(o.Address) {
+		return json.Marshal(struct {
+			Address string      `json:"address"`
+			Amount  json.Number `json:"amount"`
+		}{
+			Address: o.Address,
+			Amount:  json.Number(FormatAmount(o.Amount)),
+		})
+	}
+
+	buf := make([]byte, 0, len(o.Address)+48)
+	buf = append(buf, `{"address":"`...)
+	buf = append(buf, o.Address...)
+	buf = append(buf, `","amount":`...)
+	buf = strconv.AppendFloat(buf, o.Amount, 'f', AmountDecimals, 64)
+	return append(buf, '}'), nil
+}
+
+// plainJSONString reports whether s can be written between quotes without
+// any escaping.
+func plainJSONString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c >= 0x7f || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package chain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/fixtures"
+)
+
+// checkAmounts fails t if a decoder let through an amount CheckAmount
+// rejects.
+func checkAmounts(t *testing.T, decoder string, tx *chain.Transaction) {
+	t.Helper()
+	for i, out := range tx.Outputs {
+		if err := chain.CheckAmount(out.Amount); err != nil {
+			t.Fatalf("%s decoded output %d: %v", decoder, i, err)
+		}
+	}
+}
+
+// FuzzDecode feeds the JSON and the wire transaction decoders, seeded with
+// every transaction in the golden vectors. Run with
+// go test -fuzz=FuzzDecode ./internal/chain.
+func FuzzDecode(f *testing.F) {
+	vectors, err := fixtures.Load()
+	if err != nil {
+		f.Fatal(err)
+	}
+	addSeed := func(tx *chain.Transaction) {
+		data, err := json.Marshal(tx)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+		f.Add(chain.EncodeTransactions([]*chain.Transaction{tx}))
+	}
+	for _, c := range vectors.Chains {
+		for i := range c.Transactions {
+			addSeed(&c.Transactions[i].Transaction)
+		}
+		for _, b := range c.Blocks {
+			for i := range b.Block.Transactions {
+				addSeed(&b.Block.Transactions[i])
+			}
+		}
+	}
+	for _, mv := range vectors.Malleability {
+		f.Add([]byte(mv.Transaction))
+	}
+	f.Add([]byte(`{"outputs":[{"address":"x","amount":-1}]}`))
+	f.Add([]byte(`{"outputs":[{"address":"x","amount":1e400}]}`))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if txs, err := chain.DecodeTransactions(data); err == nil {
+			for _, tx := range txs {
+				checkAmounts(t, "wire", tx)
+			}
+		}
+
+		var tx chain.Transaction
+		if err := json.Unmarshal(data, &tx); err != nil {
+			return
+		}
+		checkAmounts(t, "JSON", &tx)
+
+		// Whatever JSON decodes must survive the wire format with its
+		// txid intact.
+		var again chain.Transaction
+		if err := again.UnmarshalBinary(chain.EncodeTransactions([]*chain.Transaction{&tx})); err != nil {
+			t.Fatalf("wire round trip: %v", err)
+		}
+		want, err := chain.ComputeTxID(&tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := chain.ComputeTxID(&again); err != nil || got != want {
+			t.Fatalf("txid %s after the wire round trip, want %s (err %v)", got, want, err)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package chain
+
+import "ai-blockchain/go-node/internal/crypto"
+
+// TxLocation is where a transaction sits on the main chain.
+type TxLocation struct {
+	BlockHash string `json:"block_hash"`
+	Height    int    `json:"block_height"`
+	Index     int    `json:"index"` // position within the block
+}
+
+// txIndex maps the txid of every main-chain transaction to its location. It
+// is maintained alongside headerIndex as blocks are connected and
+// disconnected.
+type txIndex map[string]TxLocation
+
+func (idx txIndex) addBlock(block *Block, height int) {
+	for i := range block.Transactions {
+		idx[block.Transactions[i].ID] = TxLocation{BlockHash: block.Hash, Height: height, Index: i}
+	}
+}
+
+func (idx txIndex) removeBlock(block *Block) {
+	for i := range block.Transactions {
+		if loc, ok := idx[block.Transactions[i].ID]; ok && loc.BlockHash == block.Hash {
+			delete(idx, block.Transactions[i].ID)
+		}
+	}
+}
+
+// FindTransaction looks up a confirmed transaction by txid and reports its
+// location and how many blocks (its own included) confirm it.
+func (bc *Blockchain) FindTransaction(txID string) (*Transaction, TxLocation, int, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	loc, ok := bc.txIndex[txID]
+	if !ok {
+		return nil, TxLocation{}, 0, false
+	}
+	tx := bc.Blocks[loc.Height].Transactions[loc.Index]
+	return &tx, loc, len(bc.Blocks) - loc.Height, true
+}
+
+// TxProof shows that a main-chain transaction is committed to by its block's
+// Merkle root, for clients that hold headers but not blocks.
+type TxProof struct {
+	TxID        string       `json:"txid"`
+	Transaction *Transaction `json:"transaction"`
+	BlockHash   string       `json:"block_hash"`
+	Height      int          `json:"block_height"`
+	Index       int          `json:"index"` // leaf position, which orders each step of the branch
+	MerkleRoot  string       `json:"merkle_root"`
+	Branch      []string     `json:"branch"`
+}
+
+// TransactionProof builds a Merkle proof for a confirmed transaction.
+func (bc *Blockchain) TransactionProof(txID string) (*TxProof, bool) {
+	bc.mu.RLock()
+	defer bc.mu.RUnlock()
+
+	loc, ok := bc.txIndex[txID]
+	if !ok {
+		return nil, false
+	}
+	block := bc.Blocks[loc.Height]
+	txIDs := make([]string, len(block.Transactions))
+	for i := range block.Transactions {
+		txIDs[i] = block.Transactions[i].ID
+	}
+	tx := block.Transactions[loc.Index]
+	return &TxProof{
+		TxID:        txID,
+		Transaction: &tx,
+		BlockHash:   block.Hash,
+		Height:      loc.Height,
+		Index:       loc.Index,
+		MerkleRoot:  block.MerkleRoot,
+		Branch:      crypto.MerkleBranch(txIDs, loc.Index),
+	}, true
+}
+
+// Verify reports whether the proof's branch leads from its txid to
+// merkleRoot, which the caller takes from a header it has validated itself
+// rather than from the proof.
+func (p *TxProof) Verify(merkleRoot string) bool {
+	return crypto.MerkleRootFromBranch(p.TxID, p.Index, p.Branch) == merkleRoot
+}
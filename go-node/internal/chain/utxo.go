@@ -1,18 +1,48 @@
 package chain
 
+import (
+	"fmt"
+	"sort"
+)
+
 type UTXOKey struct {
 	TxID  string // Transaction hash that created the output
 	Index int    // Index of the output inside that transaction
 }
 
+// UTXO is an unspent output together with the outpoint that identifies it.
+type UTXO struct {
+	Key    UTXOKey
+	Output TxOut
+}
+
 type UTXOSet struct {
-	store map[UTXOKey]TxOut
+	store  map[UTXOKey]TxOut
+	hash   *MuHash        // rolling hash of every entry in store
+	counts map[string]int // unspent outputs per address
 }
 
 func NewUTXOSet() *UTXOSet {
 	return &UTXOSet{
-		store: make(map[UTXOKey]TxOut),
+		store:  make(map[UTXOKey]TxOut),
+		hash:   NewMuHash(),
+		counts: make(map[string]int),
+	}
+}
+
+func (u *UTXOSet) Clone() *UTXOSet {
+	clone := &UTXOSet{
+		store:  make(map[UTXOKey]TxOut, len(u.store)),
+		hash:   u.hash.Clone(),
+		counts: make(map[string]int, len(u.counts)),
 	}
+	for k, v := range u.store {
+		clone.store[k] = v
+	}
+	for a, n := range u.counts {
+		clone.counts[a] = n
+	}
+	return clone
 }
 
 func (u *UTXOSet) Get(key UTXOKey) (TxOut, bool) {
@@ -21,7 +51,15 @@ func (u *UTXOSet) Get(key UTXOKey) (TxOut, bool) {
 }
 
 func (u *UTXOSet) Spend(key UTXOKey) {
+	out, ok := u.store[key]
+	if !ok {
+		return
+	}
+	u.hash.Remove(utxoHashData(key, out))
 	delete(u.store, key)
+	if u.counts[out.Address]--; u.counts[out.Address] == 0 {
+		delete(u.counts, out.Address)
+	}
 }
 
 func (u *UTXOSet) Add(txid string, index int, out TxOut) {
@@ -29,7 +67,28 @@ func (u *UTXOSet) Add(txid string, index int, out TxOut) {
 		TxID:  txid,
 		Index: index,
 	}
+	u.Spend(key)
+	u.hash.Insert(utxoHashData(key, out))
 	u.store[key] = out
+	u.counts[out.Address]++
+}
+
+// Count is the number of unspent outputs.
+func (u *UTXOSet) Count() int {
+	return len(u.store)
+}
+
+// CountOf is the number of unspent outputs paying address. It is kept up to
+// date as outputs come and go, so unlike BalanceOf it does not scan the set.
+func (u *UTXOSet) CountOf(address string) int {
+	return u.counts[address]
+}
+
+// Hash is a digest of the whole set, maintained incrementally as outputs are
+// added and spent. Two nodes with the same unspent outputs report the same
+// hash, so comparing it detects diverged state without dumping the set.
+func (u *UTXOSet) Hash() string {
+	return u.hash.Digest()
 }
 
 func (u *UTXOSet) ApplyTransaction(tx *Transaction) {
@@ -47,6 +106,57 @@ func (u *UTXOSet) ApplyTransaction(tx *Transaction) {
 	}
 }
 
+// SpentOutputs are the outputs a block consumed that existed before it,
+// which is exactly what UndoBlock needs to restore.
+type SpentOutputs []UTXO
+
+// ApplyBlock applies the block's transactions in order and returns the
+// outputs it spent. Outputs created and spent within the block are not
+// recorded, since undoing the block removes them anyway. Like
+// ApplyTransaction it does not validate; callers check the block first,
+// usually against a Clone.
+func (u *UTXOSet) ApplyBlock(block *Block) SpentOutputs {
+	created := make(map[string]bool, len(block.Transactions))
+	var spent SpentOutputs
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if !tx.IsCoinbase() {
+			for _, in := range tx.Inputs {
+				key := UTXOKey{TxID: in.TxID, Index: in.Index}
+				if out, ok := u.store[key]; ok && !created[in.TxID] {
+					spent = append(spent, UTXO{Key: key, Output: out})
+				}
+			}
+		}
+		u.ApplyTransaction(tx)
+		created[tx.ID] = true
+	}
+	return spent
+}
+
+// UndoBlock reverses ApplyBlock: the block's outputs are removed and the
+// outputs it spent are restored. It fails without touching the set if
+// spent does not fit the current state, i.e. block is not the last block
+// applied.
+func (u *UTXOSet) UndoBlock(block *Block, spent SpentOutputs) error {
+	for _, s := range spent {
+		if _, ok := u.store[s.Key]; ok {
+			return fmt.Errorf("cannot undo block %d: output %s:%d is already unspent", block.Index, s.Key.TxID, s.Key.Index)
+		}
+	}
+
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		tx := &block.Transactions[i]
+		for idx := range tx.Outputs {
+			u.Spend(UTXOKey{TxID: tx.ID, Index: idx})
+		}
+	}
+	for _, s := range spent {
+		u.Add(s.Key.TxID, s.Key.Index, s.Output)
+	}
+	return nil
+}
+
 func (u *UTXOSet) BalanceOf(address string) float64 {
 	var balance float64
 	for _, out := range u.store {
@@ -57,6 +167,50 @@ func (u *UTXOSet) BalanceOf(address string) float64 {
 	return balance
 }
 
+func (u *UTXOSet) UnspentOutputs(address string) []UTXO {
+	var outs []UTXO
+	for key, out := range u.store {
+		if out.Address == address {
+			outs = append(outs, UTXO{Key: key, Output: out})
+		}
+	}
+	return outs
+}
+
+// AddressBalance is one address's share of the UTXO set.
+type AddressBalance struct {
+	Address string  `json:"address"`
+	Balance float64 `json:"balance"`
+	Outputs int     `json:"utxos"`
+}
+
+// Holders totals the set by address, largest balance first and ties broken
+// by address so the order is stable.
+func (u *UTXOSet) Holders() []AddressBalance {
+	index := make(map[string]int)
+	var holders []AddressBalance
+	for _, out := range u.store {
+		i, ok := index[out.Address]
+		if !ok {
+			i = len(holders)
+			index[out.Address] = i
+			holders = append(holders, AddressBalance{Address: out.Address})
+		}
+		holders[i].Balance += out.Amount
+		holders[i].Outputs++
+	}
+	for i := range holders {
+		holders[i].Balance = RoundAmount(holders[i].Balance)
+	}
+	sort.Slice(holders, func(i, j int) bool {
+		if holders[i].Balance != holders[j].Balance {
+			return holders[i].Balance > holders[j].Balance
+		}
+		return holders[i].Address < holders[j].Address
+	})
+	return holders
+}
+
 func (u *UTXOSet) FindSpendableOutputs(address string, amount float64) (float64, []UTXOKey) {
 	var total float64
 	var selected []UTXOKey
@@ -73,4 +227,4 @@ func (u *UTXOSet) FindSpendableOutputs(address string, amount float64) (float64,
 	}
 
 	return total, selected
-}
\ No newline at end of file
+}
@@ -8,11 +8,95 @@ import (
 	"ai-blockchain/go-node/internal/crypto"
 )
 
-func VerifyBlock(block *Block, blockchain *Blockchain, difficulty int) error {
+// VerifyBlock runs every consensus check on a block that extends the
+// current tip, validating its transactions in order against a copy of the
+// chain's live UTXO set. A block building on any other block fails with
+// ErrNotOnTip, since the ledger state it spends from is not at hand.
+func VerifyBlock(block *Block, blockchain *Blockchain) error {
+	if err := VerifyBlockHeader(block, blockchain); err != nil {
+		return err
+	}
+
+	return blockchain.verifyStateOnTip(block)
+}
+
+// VerifyBlockState checks every transaction in the block against a copy of
+// the given UTXO set, applying them in order so later transactions may spend
+// outputs created earlier in the same block. An optional leading coinbase may
+// claim at most reward plus the fees of the other transactions. The set
+// itself is not modified.
+func VerifyBlockState(block *Block, utxo *UTXOSet, reward float64) error {
+	view := utxo.Clone()
+	var coinbase *Transaction
+	var fees float64
+
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if tx.IsCoinbase() {
+			if i != 0 {
+				return ErrCoinbaseMisplaced
+			}
+			coinbase = tx
+			continue
+		}
+
+		if err := VerifyTransaction(tx, view); err != nil {
+			return fmt.Errorf("transaction %d invalid: %w", i, err)
+		}
+		fee, err := ComputeFee(tx, view)
+		if err != nil {
+			return fmt.Errorf("transaction %d invalid: %w", i, err)
+		}
+		fees += fee
+		view.ApplyTransaction(tx)
+	}
+
+	if coinbase != nil {
+		if err := verifyCoinbase(coinbase, block.Index, reward+fees); err != nil {
+			return fmt.Errorf("transaction 0 invalid: %w", err)
+		}
+	}
+	return nil
+}
+
+// VerifyBlockHeader runs the checks that don't depend on ledger state: the
+// difficulty and median-time-past the chain requires at that point, hash,
+// merkle root, proof of work and linkage to the previous block.
+func VerifyBlockHeader(block *Block, blockchain *Blockchain) error {
+	expected, ok := blockchain.ExpectedDifficulty(block.PrevHash)
+	if !ok {
+		return errors.New("previous block not found")
+	}
+	if err := checkDifficulty(block, expected); err != nil {
+		return err
+	}
+	mtp, ok := blockchain.medianTimePastAfter(block.PrevHash)
+	if !ok {
+		return errors.New("previous block not found")
+	}
+	if err := checkMedianTimePast(block, mtp); err != nil {
+		return err
+	}
+
+	if err := checkBlockHeader(block, blockchain.PowAlgorithm()); err != nil {
+		return err
+	}
+
+	return verifyBlockLinkage(block, blockchain)
+}
+
+// checkBlockHeader verifies what a block commits to by itself: its
+// proof-of-work algorithm, hash, merkle root, proof of work at the
+// difficulty it declares and its producer signature, if any.
+func checkBlockHeader(block *Block, algorithm string) error {
 	if len(block.Transactions) == 0 {
 		return errors.New("block must contain at least one transaction")
 	}
 
+	if err := checkPowAlgorithm(block.PowAlgorithm, algorithm); err != nil {
+		return err
+	}
+
 	computedHash := block.ComputeHash()
 	if computedHash != block.Hash {
 		return errors.New("block hash does not match block data")
@@ -23,16 +107,87 @@ func VerifyBlock(block *Block, blockchain *Blockchain, difficulty int) error {
 		return errors.New("merkle root does not match transactions")
 	}
 
-	if !consensus.ValidateProofOfWork(block.Hash, difficulty) {
+	if !consensus.ValidDifficulty(block.Difficulty) {
+		return fmt.Errorf("block declares difficulty %d: %w", block.Difficulty, consensus.ErrInvalidDifficulty)
+	}
+	if !consensus.ValidateProofOfWork(block.Hash, block.Difficulty) {
 		return errors.New("block does not meet proof-of-work requirement")
 	}
 
+	return checkProducer(block.MinerPubKey, block.MinerSignature, block.Hash)
+}
+
+// VerifyBlockProposal checks a candidate block built on the current tip with
+// every rule except proof of work, so pools can validate a template before
+// handing it out to miners.
+func VerifyBlockProposal(block *Block, blockchain *Blockchain) error {
+	if len(block.Transactions) == 0 {
+		return errors.New("block must contain at least one transaction")
+	}
+
+	if block.computeMerkleRoot() != block.MerkleRoot {
+		return errors.New("merkle root does not match transactions")
+	}
+
+	if err := checkPowAlgorithm(block.PowAlgorithm, blockchain.PowAlgorithm()); err != nil {
+		return err
+	}
+
+	// The producer signs only once the proof of work is found.
+	if block.MinerPubKey != "" {
+		if err := checkProducerKey(block.MinerPubKey); err != nil {
+			return err
+		}
+	}
+
+	if err := verifyBlockLinkage(block, blockchain); err != nil {
+		return err
+	}
+
+	if block.Index != blockchain.Height() {
+		return fmt.Errorf("block %d does not extend the current tip at height %d", block.Index, blockchain.Height()-1)
+	}
+
+	if err := checkDifficulty(block, blockchain.NextDifficulty()); err != nil {
+		return err
+	}
+
+	if err := checkMedianTimePast(block, blockchain.MedianTimePast()); err != nil {
+		return err
+	}
+	if err := checkBlockTime(block, blockchain.Clock().Now()); err != nil {
+		return err
+	}
+
+	// The pipeline may be connecting a block meanwhile; the state check
+	// reads the UTXO set under the chain's lock.
+	return blockchain.verifyStateOnTip(block)
+}
+
+// checkPowAlgorithm requires a header to name the network's algorithm
+// exactly as the genesis block does.
+func checkPowAlgorithm(declared, algorithm string) error {
+	if declared != algorithm {
+		return fmt.Errorf("block uses proof-of-work algorithm %s, network uses %s",
+			algorithmName(declared), algorithmName(algorithm))
+	}
+	return nil
+}
+
+func algorithmName(name string) string {
+	if name == "" {
+		return string(consensus.SHA256)
+	}
+	return name
+}
+
+func verifyBlockLinkage(block *Block, blockchain *Blockchain) error {
 	if block.Index > 0 {
-		if blockchain.Height() < block.Index {
+		prevBlock, ok := blockchain.HeaderAt(block.Index - 1)
+		if !ok {
 			return errors.New("previous block not found")
 		}
 
-		prevBlock := blockchain.Blocks[block.Index-1]
 		if prevBlock.Hash != block.PrevHash {
 			return errors.New("previous hash mismatch")
 		}
@@ -46,20 +201,17 @@ func VerifyBlock(block *Block, blockchain *Blockchain, difficulty int) error {
 		}
 	}
 
-	tempUTXO := NewUTXOSet()
-
-	for i, tx := range block.Transactions {
-		if err := VerifyTransaction(&tx, tempUTXO); err != nil {
-			return fmt.Errorf("transaction %d invalid: %w", i, err)
-		}
-
-		tempUTXO.ApplyTransaction(&tx)
-	}
-
 	return nil
 }
 
+// ErrForeignInput is returned for a transaction spending a plain output
+// that is not locked to the address of its signing key.
+var ErrForeignInput = errors.New("input not owned by the signing key")
+
 func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
+	if tx.IsCoinbase() {
+		return ErrCoinbaseOutsideBlock
+	}
 
 	computedID, err := ComputeTxID(tx)
 	if err != nil {
@@ -70,6 +222,13 @@ func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
 		return errors.New("transaction ID mismatch")
 	}
 
+	if err := CheckCanonicalForm(tx); err != nil {
+		return err
+	}
+	if err := CheckMemo(tx.Memo); err != nil {
+		return err
+	}
+
 	seenInputs := make(map[UTXOKey]bool)
 
 	for _, in := range tx.Inputs {
@@ -85,6 +244,8 @@ func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
 	}
 
 	var inputSum float64
+	plainInputs := make(map[string]bool)
+	multisigInputs := make(map[string]bool)
 
 	for _, in := range tx.Inputs {
 		key := UTXOKey{
@@ -98,17 +259,24 @@ func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
 		}
 
 		inputSum += out.Amount
+		if IsMultisigAddress(out.Address) {
+			multisigInputs[out.Address] = true
+		} else {
+			plainInputs[out.Address] = true
+		}
+	}
+	if inputSum > MaxAmount {
+		return fmt.Errorf("%w: inputs total %s, more than the maximum %s", ErrInvalidAmount, FormatAmount(inputSum), FormatAmount(MaxAmount))
 	}
 
-	var outputSum float64
-	for _, out := range tx.Outputs {
-		if out.Amount <= 0 {
-			return errors.New("output amount must be positive")
-		}
-		outputSum += out.Amount
+	outputSum, err := checkOutputAmounts(tx.Outputs)
+	if err != nil {
+		return err
 	}
 
-	if outputSum > inputSum {
+	// Amounts carry AmountDecimals of precision on the wire; compare at that
+	// precision so float summation error can't flip the result.
+	if RoundAmount(outputSum) > RoundAmount(inputSum) {
 		return errors.New("output value exceeds input value")
 	}
 
@@ -117,6 +285,21 @@ func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
 		return fmt.Errorf("failed to compute canonical bytes: %w", err)
 	}
 
+	if len(multisigInputs) > 0 && tx.Multisig == nil {
+		return fmt.Errorf("%w: spends multisig outputs without a multisig witness", ErrMultisig)
+	}
+	if len(multisigInputs) == 0 && tx.Multisig != nil {
+		return fmt.Errorf("%w: witness on a transaction that spends no multisig outputs", ErrMultisig)
+	}
+	for address := range multisigInputs {
+		if err := verifyMultisig(tx.Multisig, address, canonicalBytes); err != nil {
+			return err
+		}
+	}
+	if len(plainInputs) == 0 && tx.Signature == "" && tx.PubKey == "" {
+		return nil
+	}
+
 	ok, err := crypto.VerifySignature(canonicalBytes, tx.Signature, tx.PubKey)
 	if err != nil {
 		return fmt.Errorf("signature verification error: %w", err)
@@ -125,5 +308,36 @@ func VerifyTransaction(tx *Transaction, utxo *UTXOSet) error {
 		return errors.New("invalid transaction signature")
 	}
 
+	// A valid signature only proves the signer holds tx.PubKey; every plain
+	// input must also be locked to that key's address.
+	pub, err := crypto.DecodePublicKey(tx.PubKey)
+	if err != nil {
+		return fmt.Errorf("signature verification error: %w", err)
+	}
+	signer := crypto.AddressFromPublicKey(pub)
+	for address := range plainInputs {
+		if address != signer {
+			return fmt.Errorf("%w: input locked to %s, signed by %s", ErrForeignInput, address, signer)
+		}
+	}
+
 	return nil
 }
+
+func ComputeFee(tx *Transaction, utxo *UTXOSet) (float64, error) {
+	var inputSum float64
+	for _, in := range tx.Inputs {
+		out, ok := utxo.Get(UTXOKey{TxID: in.TxID, Index: in.Index})
+		if !ok {
+			return 0, fmt.Errorf("referenced UTXO not found: %s:%d", in.TxID, in.Index)
+		}
+		inputSum += out.Amount
+	}
+
+	var outputSum float64
+	for _, out := range tx.Outputs {
+		outputSum += out.Amount
+	}
+
+	return RoundAmount(inputSum - outputSum), nil
+}
@@ -0,0 +1,385 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Binary wire encoding for blocks and transactions. Integers are varints,
+// amounts are fixed-point with AmountDecimals places, and strings that are
+// lowercase hex (hashes, keys, signatures) are stored as raw bytes, which
+// roughly halves the size compared to JSON. The encoding round-trips exactly
+// to the JSON form.
+
+const WireVersion = 6
+
+var ErrWireFormat = errors.New("malformed binary encoding")
+
+const (
+	wireString byte = 0
+	wireHex    byte = 1
+)
+
+// maxWireItems bounds decoded list lengths so a corrupt length prefix cannot
+// trigger a huge allocation.
+const maxWireItems = 1 << 20
+
+var amountScale = math.Pow10(AmountDecimals)
+
+type wireWriter struct {
+	buf bytes.Buffer
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func (w *wireWriter) uvarint(v uint64) {
+	n := binary.PutUvarint(w.tmp[:], v)
+	w.buf.Write(w.tmp[:n])
+}
+
+func (w *wireWriter) varint(v int64) {
+	n := binary.PutVarint(w.tmp[:], v)
+	w.buf.Write(w.tmp[:n])
+}
+
+func (w *wireWriter) str(s string) {
+	if isLowerHex(s) {
+		raw, _ := hex.DecodeString(s)
+		w.buf.WriteByte(wireHex)
+		w.uvarint(uint64(len(raw)))
+		w.buf.Write(raw)
+		return
+	}
+	w.buf.WriteByte(wireString)
+	w.uvarint(uint64(len(s)))
+	w.buf.WriteString(s)
+}
+
+// amount writes the base units of the digits FormatAmount gives, not a
+// rounded a*amountScale: an amount with more than AmountDecimals places
+// could round one way in the canonical JSON and the other way here, and come
+// out of the wire format with a different txid.
+func (w *wireWriter) amount(a float64) {
+	units, _ := strconv.ParseInt(strings.Replace(FormatAmount(a), ".", "", 1), 10, 64)
+	w.varint(units)
+}
+
+func (w *wireWriter) tx(tx *Transaction) {
+	w.str(tx.ID)
+	w.uvarint(uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		w.str(in.TxID)
+		w.varint(int64(in.Index))
+	}
+	w.uvarint(uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		w.str(out.Address)
+		w.amount(out.Amount)
+	}
+	w.str(tx.Signature)
+	w.str(tx.PubKey)
+	w.str(tx.Memo)
+	w.multisig(tx.Multisig)
+	w.varint(tx.Timestamp)
+}
+
+// multisig writes the number of required signatures, 0 for no witness,
+// then the keys and the signature slots.
+func (w *wireWriter) multisig(m *MultisigSpend) {
+	if m == nil {
+		w.uvarint(0)
+		return
+	}
+	w.uvarint(uint64(m.Required))
+	w.uvarint(uint64(len(m.PubKeys)))
+	for _, key := range m.PubKeys {
+		w.str(key)
+	}
+	w.uvarint(uint64(len(m.Signatures)))
+	for _, sig := range m.Signatures {
+		w.str(sig)
+	}
+}
+
+func (w *wireWriter) block(b *Block) {
+	w.varint(int64(b.Index))
+	w.varint(b.Timestamp)
+	w.str(b.PrevHash)
+	w.str(b.MerkleRoot)
+	w.str(b.Hash)
+	w.varint(b.Nonce)
+	w.varint(int64(b.Difficulty))
+	w.str(b.PowAlgorithm)
+	w.str(b.MinerPubKey)
+	w.str(b.MinerSignature)
+	w.uvarint(uint64(len(b.Transactions)))
+	for i := range b.Transactions {
+		w.tx(&b.Transactions[i])
+	}
+}
+
+func isLowerHex(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+type wireReader struct {
+	data []byte
+	err  error
+}
+
+func (r *wireReader) fail() {
+	if r.err == nil {
+		r.err = ErrWireFormat
+	}
+}
+
+func (r *wireReader) byte() byte {
+	if r.err != nil || len(r.data) == 0 {
+		r.fail()
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *wireReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *wireReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *wireReader) count() int {
+	n := r.uvarint()
+	if n > maxWireItems {
+		r.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (r *wireReader) str() string {
+	kind := r.byte()
+	n := r.uvarint()
+	if r.err != nil || n > uint64(len(r.data)) {
+		r.fail()
+		return ""
+	}
+	raw := r.data[:n]
+	r.data = r.data[n:]
+
+	switch kind {
+	case wireString:
+		return string(raw)
+	case wireHex:
+		return hex.EncodeToString(raw)
+	}
+	r.fail()
+	return ""
+}
+
+func (r *wireReader) amount() float64 {
+	units := r.varint()
+	if units < 0 || float64(units) > MaxAmount*amountScale {
+		r.fail()
+		return 0
+	}
+	return float64(units) / amountScale
+}
+
+func (r *wireReader) tx() Transaction {
+	var tx Transaction
+	tx.ID = r.str()
+	if n := r.count(); n > 0 {
+		tx.Inputs = make([]TxIn, 0, n)
+		for i := 0; i < n && r.err == nil; i++ {
+			tx.Inputs = append(tx.Inputs, TxIn{TxID: r.str(), Index: int(r.varint())})
+		}
+	} else {
+		tx.Inputs = []TxIn{}
+	}
+	n := r.count()
+	tx.Outputs = make([]TxOut, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		tx.Outputs = append(tx.Outputs, TxOut{Address: r.str(), Amount: r.amount()})
+	}
+	tx.Signature = r.str()
+	tx.PubKey = r.str()
+	tx.Memo = r.str()
+	tx.Multisig = r.multisig()
+	tx.Timestamp = r.varint()
+	return tx
+}
+
+func (r *wireReader) multisig() *MultisigSpend {
+	required := r.uvarint()
+	if required == 0 || r.err != nil {
+		return nil
+	}
+	if required > MaxMultisigKeys {
+		r.fail()
+		return nil
+	}
+	m := &MultisigSpend{Required: int(required)}
+	n := r.count()
+	m.PubKeys = make([]string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		m.PubKeys = append(m.PubKeys, r.str())
+	}
+	n = r.count()
+	m.Signatures = make([]string, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		m.Signatures = append(m.Signatures, r.str())
+	}
+	return m
+}
+
+func (r *wireReader) block() *Block {
+	b := &Block{
+		Index:      int(r.varint()),
+		Timestamp:  r.varint(),
+		PrevHash:   r.str(),
+		MerkleRoot: r.str(),
+		Hash:       r.str(),
+		Nonce:      r.varint(),
+		Difficulty: int(r.varint()),
+	}
+	b.PowAlgorithm = r.str()
+	b.MinerPubKey = r.str()
+	b.MinerSignature = r.str()
+	n := r.count()
+	b.Transactions = make([]Transaction, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		b.Transactions = append(b.Transactions, r.tx())
+	}
+	return b
+}
+
+func (r *wireReader) header() {
+	if v := r.byte(); r.err == nil && v != WireVersion {
+		r.fail()
+	}
+}
+
+func (r *wireReader) finish() error {
+	if r.err == nil && len(r.data) != 0 {
+		r.fail()
+	}
+	return r.err
+}
+
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	return EncodeTransactions([]*Transaction{tx}), nil
+}
+
+func (tx *Transaction) UnmarshalBinary(data []byte) error {
+	txs, err := DecodeTransactions(data)
+	if err != nil {
+		return err
+	}
+	if len(txs) != 1 {
+		return ErrWireFormat
+	}
+	*tx = *txs[0]
+	return nil
+}
+
+func (b *Block) MarshalBinary() ([]byte, error) {
+	return EncodeBlocks([]*Block{b}), nil
+}
+
+func (b *Block) UnmarshalBinary(data []byte) error {
+	blocks, err := DecodeBlocks(data)
+	if err != nil {
+		return err
+	}
+	if len(blocks) != 1 {
+		return ErrWireFormat
+	}
+	*b = *blocks[0]
+	return nil
+}
+
+// EncodeTransactions encodes a version byte, a count and the transactions.
+func EncodeTransactions(txs []*Transaction) []byte {
+	w := &wireWriter{}
+	w.buf.WriteByte(WireVersion)
+	w.uvarint(uint64(len(txs)))
+	for _, tx := range txs {
+		w.tx(tx)
+	}
+	return w.buf.Bytes()
+}
+
+func DecodeTransactions(data []byte) ([]*Transaction, error) {
+	r := &wireReader{data: data}
+	r.header()
+	n := r.count()
+	txs := make([]*Transaction, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		tx := r.tx()
+		txs = append(txs, &tx)
+	}
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// EncodeBlocks encodes a version byte, a count and the blocks.
+func EncodeBlocks(blocks []*Block) []byte {
+	w := &wireWriter{}
+	w.buf.WriteByte(WireVersion)
+	w.uvarint(uint64(len(blocks)))
+	for _, b := range blocks {
+		w.block(b)
+	}
+	return w.buf.Bytes()
+}
+
+func DecodeBlocks(data []byte) ([]*Block, error) {
+	r := &wireReader{data: data}
+	r.header()
+	n := r.count()
+	blocks := make([]*Block, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		blocks = append(blocks, r.block())
+	}
+	if err := r.finish(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
@@ -0,0 +1,82 @@
+// Package chaintest builds small chains for tests: a genesis block paying
+// 50 coins to each address given, and blocks solved at difficulty 1.
+// Tests inside package chain cannot import it and keep their own
+// newTestChain and mineTestBlock.
+package chaintest
+
+import (
+	"context"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// Genesis is a genesis block whose coinbase transactions pay 50 coins to
+// each address in turn.
+func Genesis(t testing.TB, addresses ...string) *chain.Block {
+	t.Helper()
+	var txs []chain.Transaction
+	for _, address := range addresses {
+		coinbase, err := chain.NewCoinbaseTransaction(0, address, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		txs = append(txs, *coinbase)
+	}
+	return chain.NewBlock(0, "0", txs)
+}
+
+// FromGenesis starts a chain on genesis at difficulty 1. Chains started on
+// the same genesis block can exchange blocks.
+func FromGenesis(genesis *chain.Block) *chain.Blockchain {
+	bc := chain.NewBlockchain(genesis)
+	bc.SetDifficulty(1)
+	return bc
+}
+
+// NewChain is a genesis block paying address and n blocks on top of it,
+// each with its coinbase, paying address, only.
+func NewChain(t testing.TB, address string, n int) *chain.Blockchain {
+	t.Helper()
+	bc := FromGenesis(Genesis(t, address))
+	for i := 0; i < n; i++ {
+		if err := bc.AddBlock(MineBlock(t, bc, address)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bc
+}
+
+// MineBlock solves a block on bc's tip paying the block reward to address
+// and carrying txs. The block is not added to bc.
+func MineBlock(t testing.TB, bc *chain.Blockchain, address string, txs ...*chain.Transaction) *chain.Block {
+	t.Helper()
+	tip := bc.Tip()
+	coinbase, err := chain.NewCoinbaseTransaction(tip.Index+1, address, bc.BlockReward())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []chain.Transaction{*coinbase}
+	for _, tx := range txs {
+		body = append(body, *tx)
+	}
+	block := chain.NewBlock(tip.Index+1, tip.Hash, body)
+	block.Timestamp = tip.Timestamp + 1
+	block.Difficulty = bc.NextDifficulty()
+	Solve(t, block)
+	return block
+}
+
+// Solve finds a nonce meeting block's declared difficulty and sets its hash.
+func Solve(t testing.TB, block *chain.Block) {
+	t.Helper()
+	hash, nonce, err := consensus.MineBlock(context.Background(),
+		func(nonce int64) string { return block.ComputeHash() },
+		func(nonce int64) { block.Nonce = nonce },
+		block.Difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Hash, block.Nonce = hash, nonce
+}
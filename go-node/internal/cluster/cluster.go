@@ -0,0 +1,183 @@
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultLagThreshold = 3
+
+type NodeStatus struct {
+	URL       string `json:"url"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Height    int    `json:"height"`
+	TipHash   string `json:"tip_hash,omitempty"`
+	Mempool   int    `json:"mempool"`
+	Peers     int    `json:"peers"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+type Divergence struct {
+	Kind    string   `json:"kind"` // "fork" or "lagging"
+	Height  int      `json:"height"`
+	Nodes   []string `json:"nodes"`
+	Details string   `json:"details"`
+}
+
+type Status struct {
+	Nodes       []NodeStatus `json:"nodes"`
+	MaxHeight   int          `json:"max_height"`
+	Reachable   int          `json:"reachable"`
+	Divergences []Divergence `json:"divergences"`
+	Healthy     bool         `json:"healthy"`
+	CheckedAt   int64        `json:"checked_at"`
+}
+
+// Monitor polls a fixed list of sibling nodes over their public REST API.
+type Monitor struct {
+	urls         []string
+	client       *http.Client
+	lagThreshold int
+}
+
+func NewMonitor(urls []string, timeout time.Duration, lagThreshold int) *Monitor {
+	cleaned := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u = strings.TrimRight(strings.TrimSpace(u), "/"); u != "" {
+			cleaned = append(cleaned, u)
+		}
+	}
+	return &Monitor{
+		urls:         cleaned,
+		client:       &http.Client{Timeout: timeout},
+		lagThreshold: lagThreshold,
+	}
+}
+
+func (m *Monitor) Enabled() bool {
+	return m != nil && len(m.urls) > 0
+}
+
+func (m *Monitor) getJSON(url string, out interface{}) error {
+	resp, err := m.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (m *Monitor) probe(base string) NodeStatus {
+	status := NodeStatus{URL: base}
+	start := time.Now()
+
+	var health struct {
+		Status  string `json:"status"`
+		Mempool int    `json:"mempool"`
+		Peers   int    `json:"peers"`
+	}
+	if err := m.getJSON(base+"/health", &health); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.LatencyMs = time.Since(start).Milliseconds()
+
+	var chainInfo struct {
+		Height int `json:"height"`
+		Tip    struct {
+			Hash string `json:"hash"`
+		} `json:"tip"`
+	}
+	if err := m.getJSON(base+"/chain", &chainInfo); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	status.Reachable = true
+	status.Status = health.Status
+	status.Mempool = health.Mempool
+	status.Peers = health.Peers
+	status.Height = chainInfo.Height
+	status.TipHash = chainInfo.Tip.Hash
+	return status
+}
+
+// Check probes every sibling (plus the local node, if given) concurrently and
+// reports forks (same height, different tips) and nodes lagging behind the
+// best height by more than the configured threshold.
+func (m *Monitor) Check(local *NodeStatus) Status {
+	nodes := make([]NodeStatus, len(m.urls))
+	var wg sync.WaitGroup
+	for i, u := range m.urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			nodes[i] = m.probe(u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	if local != nil {
+		nodes = append([]NodeStatus{*local}, nodes...)
+	}
+
+	result := Status{
+		Nodes:       nodes,
+		Divergences: []Divergence{},
+		CheckedAt:   time.Now().Unix(),
+	}
+
+	tipsByHeight := make(map[int]map[string][]string)
+	for _, n := range nodes {
+		if !n.Reachable {
+			continue
+		}
+		result.Reachable++
+		if n.Height > result.MaxHeight {
+			result.MaxHeight = n.Height
+		}
+		if tipsByHeight[n.Height] == nil {
+			tipsByHeight[n.Height] = make(map[string][]string)
+		}
+		tipsByHeight[n.Height][n.TipHash] = append(tipsByHeight[n.Height][n.TipHash], n.URL)
+	}
+
+	for height, tips := range tipsByHeight {
+		if len(tips) > 1 {
+			var urls []string
+			for _, u := range tips {
+				urls = append(urls, u...)
+			}
+			result.Divergences = append(result.Divergences, Divergence{
+				Kind:    "fork",
+				Height:  height,
+				Nodes:   urls,
+				Details: fmt.Sprintf("%d different tips at height %d", len(tips), height),
+			})
+		}
+	}
+
+	for _, n := range nodes {
+		if n.Reachable && result.MaxHeight-n.Height > m.lagThreshold {
+			result.Divergences = append(result.Divergences, Divergence{
+				Kind:    "lagging",
+				Height:  n.Height,
+				Nodes:   []string{n.URL},
+				Details: fmt.Sprintf("%d blocks behind best height %d", result.MaxHeight-n.Height, result.MaxHeight),
+			})
+		}
+	}
+
+	result.Healthy = len(result.Divergences) == 0 && result.Reachable == len(nodes)
+	return result
+}
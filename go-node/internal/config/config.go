@@ -0,0 +1,278 @@
+// Package config loads node settings from a YAML or TOML file. Settings are
+// named after the node's command-line flags, so a file holds the flags an
+// operator would otherwise type, and a flag given on the command line still
+// wins over the file.
+//
+// Both formats are parsed by hand and only the subset a settings file needs
+// is understood: nested tables or mappings, strings, numbers, booleans and
+// lists of those. A table's name is joined to its keys with a hyphen, so
+//
+//	[ai]
+//	url = "http://localhost:5000"
+//
+// sets -ai-url. Underscores in keys are read as hyphens.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Setting is one value from a configuration file, formatted as it would be
+// on the command line. Lists are joined with commas.
+type Setting struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// Settings are the values of a file, in the order they appear.
+type Settings struct {
+	Path  string
+	Items []Setting
+}
+
+// Load reads the settings in path. Files ending in .yaml or .yml are read as
+// YAML and files ending in .toml as TOML. ${VAR} in a value is replaced by
+// the environment variable VAR, and ${VAR:-default} falls back to default
+// when VAR is unset or empty; $$ is a literal $.
+func Load(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var parse func(string) ([]Setting, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		parse = parseTOML
+	case ".yaml", ".yml":
+		parse = parseYAML
+	default:
+		return nil, fmt.Errorf("%s: unknown configuration format (want .toml, .yaml or .yml)", path)
+	}
+
+	items, err := parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &Settings{Path: path, Items: items}, nil
+}
+
+// Apply sets each flag in fs named by a setting, unless it was already given
+// on the command line. It fails on a setting no flag matches, so a typo is
+// not silently ignored. It returns the names of the flags it set.
+func (s *Settings) Apply(fs *flag.FlagSet) ([]string, error) {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	seen := make(map[string]int)
+	var applied []string
+	for _, item := range s.Items {
+		if line, dup := seen[item.Name]; dup {
+			return applied, fmt.Errorf("%s:%d: %s already set on line %d", s.Path, item.Line, item.Name, line)
+		}
+		seen[item.Name] = item.Line
+
+		if fs.Lookup(item.Name) == nil {
+			return applied, fmt.Errorf("%s:%d: unknown setting %q", s.Path, item.Line, item.Name)
+		}
+		if given[item.Name] {
+			continue
+		}
+		if err := fs.Set(item.Name, item.Value); err != nil {
+			return applied, fmt.Errorf("%s:%d: %s: %w", s.Path, item.Line, item.Name, err)
+		}
+		applied = append(applied, item.Name)
+	}
+	return applied, nil
+}
+
+// settingName joins a table path and a key into a flag name.
+func settingName(path []string, key string) string {
+	name := strings.Join(append(append([]string(nil), path...), key), "-")
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+func validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// expandEnv substitutes environment variables in a raw value.
+func expandEnv(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '$' {
+			b.WriteByte('$')
+			i++
+			continue
+		}
+		if i+1 >= len(s) || s[i+1] != '{' {
+			b.WriteByte('$')
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", errors.New("unterminated ${")
+		}
+		ref := s[i+2 : i+end]
+		name, fallback, hasDefault := strings.Cut(ref, ":-")
+		if !validKey(name) {
+			return "", fmt.Errorf("invalid variable reference ${%s}", ref)
+		}
+		value := os.Getenv(name)
+		if value == "" {
+			if !hasDefault {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+			value = fallback
+		}
+		b.WriteString(value)
+		i += end
+	}
+	return b.String(), nil
+}
+
+// stripComment removes a # comment that is not inside a quoted string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseValue reads a scalar or a single-line [a, b] list.
+func parseValue(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "[") {
+		if !strings.HasSuffix(raw, "]") {
+			return "", errors.New("unterminated list")
+		}
+		parts, err := splitList(raw[1 : len(raw)-1])
+		if err != nil {
+			return "", err
+		}
+		values := make([]string, 0, len(parts))
+		for _, part := range parts {
+			v, err := parseScalar(part)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, v)
+		}
+		return strings.Join(values, ","), nil
+	}
+	return parseScalar(raw)
+}
+
+// splitList splits list items on commas outside quotes. A trailing comma is
+// allowed.
+func splitList(s string) ([]string, error) {
+	var parts []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated string")
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	for i, part := range parts {
+		if parts[i] = strings.TrimSpace(part); parts[i] == "" {
+			return nil, errors.New("empty list item")
+		}
+	}
+	return parts, nil
+}
+
+// parseScalar unquotes a "double" or 'single' quoted string and returns
+// anything else as written.
+func parseScalar(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", errors.New("missing value")
+	}
+	switch s[0] {
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", errors.New("unterminated string")
+		}
+		return s[1 : len(s)-1], nil
+	case '"':
+		if len(s) < 2 || s[len(s)-1] != '"' {
+			return "", errors.New("unterminated string")
+		}
+		return unescape(s[1 : len(s)-1])
+	}
+	return s, nil
+}
+
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", errors.New("trailing backslash in string")
+		}
+		switch s[i] {
+		case '\\', '"':
+			b.WriteByte(s[i])
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			return "", fmt.Errorf("unsupported escape \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
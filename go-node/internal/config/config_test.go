@@ -0,0 +1,191 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes text to a file called name in a fresh directory.
+func writeConfig(t *testing.T, name, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(t *testing.T, name, text string) []Setting {
+	t.Helper()
+	settings, err := Load(writeConfig(t, name, text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return settings.Items
+}
+
+func TestLoadTOML(t *testing.T) {
+	got := load(t, "node.toml", `# node settings
+port = "8080" # trailing comment
+difficulty = 4
+
+[ai]
+url = "http://localhost:5000/#score" # the # in the string stays
+timeout = '3s'
+
+[p2p.gossip]
+peers = ["a:9000", "b:9000",]
+seed_only = true
+label = "a \"quoted\" # name"
+`)
+	want := []Setting{
+		{Name: "port", Value: "8080", Line: 2},
+		{Name: "difficulty", Value: "4", Line: 3},
+		{Name: "ai-url", Value: "http://localhost:5000/#score", Line: 6},
+		{Name: "ai-timeout", Value: "3s", Line: 7},
+		{Name: "p2p-gossip-peers", Value: "a:9000,b:9000", Line: 10},
+		{Name: "p2p-gossip-seed-only", Value: "true", Line: 11},
+		{Name: "p2p-gossip-label", Value: `a "quoted" # name`, Line: 12},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settings\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLoadYAML(t *testing.T) {
+	got := load(t, "node.yaml", `---
+# node settings
+port: 8080 # trailing comment
+ai:
+  url: "http://localhost:5000/#score"
+  key: 'a: b # c'
+p2p:
+  gossip:
+    peers:
+      - a:9000
+      - "b:9000"
+    seed_only: true
+difficulty: 4
+`)
+	want := []Setting{
+		{Name: "port", Value: "8080", Line: 3},
+		{Name: "ai-url", Value: "http://localhost:5000/#score", Line: 5},
+		{Name: "ai-key", Value: "a: b # c", Line: 6},
+		{Name: "p2p-gossip-peers", Value: "a:9000,b:9000", Line: 9},
+		{Name: "p2p-gossip-seed-only", Value: "true", Line: 12},
+		{Name: "difficulty", Value: "4", Line: 13},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("settings\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestLoadRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name, file, text, err string
+	}{
+		{"toml header", "a.toml", "[ai\nurl = 1", "invalid table header"},
+		{"toml array of tables", "a.toml", "[[ai]]", "invalid table header"},
+		{"toml no equals", "a.toml", "port 8080", "expected key = value"},
+		{"toml bad key", "a.toml", "my port = 8080", "invalid key"},
+		{"toml open string", "a.toml", `url = "http://x`, "unterminated string"},
+		{"toml open list", "a.toml", "peers = [a, b", "unterminated list"},
+		{"toml empty item", "a.toml", "peers = [a, , b]", "empty list item"},
+		{"toml bad escape", "a.toml", `label = "a\q"`, "unsupported escape"},
+		{"toml missing value", "a.toml", "port =", "missing value"},
+		{"yaml tab indent", "a.yml", "ai:\n\turl: x", "tabs are not allowed"},
+		{"yaml no value", "a.yml", "ai:\nport: 1", "ai has no value"},
+		{"yaml stray item", "a.yml", "- a", "list item outside a list"},
+		{"yaml mixed", "a.yml", "peers:\n  - a\n  b: 1", "mixes list items and keys"},
+		{"yaml not a key", "a.yml", "port=8080", "expected key: value"},
+		{"format", "a.json", "{}", "unknown configuration format"},
+	}
+	for _, tt := range tests {
+		_, err := Load(writeConfig(t, tt.file, tt.text))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestLoadExpandsEnvironment(t *testing.T) {
+	t.Setenv("CONFIG_TEST_PORT", "9090")
+	got := load(t, "node.toml", `port = "${CONFIG_TEST_PORT}"
+url = "${CONFIG_TEST_UNSET:-http://localhost}"
+price = "$$5"
+`)
+	values := []string{got[0].Value, got[1].Value, got[2].Value}
+	if want := []string{"9090", "http://localhost", "$5"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("values %q, want %q", values, want)
+	}
+	if _, err := Load(writeConfig(t, "node.toml", `port = "${CONFIG_TEST_UNSET}"`)); err == nil {
+		t.Error("unset variable without a default accepted")
+	}
+}
+
+func testFlags() (*flag.FlagSet, *int, *string, *bool) {
+	fs := flag.NewFlagSet("node", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	port := fs.Int("port", 8080, "")
+	url := fs.String("ai-url", "", "")
+	mine := fs.Bool("auto-mine", false, "")
+	return fs, port, url, mine
+}
+
+// A flag on the command line wins over the file; the rest come from it.
+func TestApplyFlagsWinOverFile(t *testing.T) {
+	settings := &Settings{Path: "node.toml", Items: []Setting{
+		{Name: "port", Value: "9000", Line: 1},
+		{Name: "ai-url", Value: "http://ai", Line: 2},
+		{Name: "auto-mine", Value: "true", Line: 3},
+	}}
+	fs, port, url, mine := testFlags()
+	if err := fs.Parse([]string{"-port", "7000"}); err != nil {
+		t.Fatal(err)
+	}
+	applied, err := settings.Apply(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 7000 || *url != "http://ai" || !*mine {
+		t.Errorf("port %d, ai-url %q, auto-mine %v; want 7000, http://ai, true", *port, *url, *mine)
+	}
+	if want := []string{"ai-url", "auto-mine"}; !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied %q, want %q", applied, want)
+	}
+}
+
+func TestApplyRejectsBadSettings(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []Setting
+		err   string
+	}{
+		{"unknown key", []Setting{{Name: "prot", Value: "1", Line: 4}}, `node.toml:4: unknown setting "prot"`},
+		{"int mismatch", []Setting{{Name: "port", Value: "eighty", Line: 2}}, "node.toml:2: port:"},
+		{"bool mismatch", []Setting{{Name: "auto-mine", Value: "sometimes", Line: 3}}, "node.toml:3: auto-mine:"},
+		{"duplicate", []Setting{{Name: "port", Value: "1", Line: 1}, {Name: "port", Value: "2", Line: 5}}, "node.toml:5: port already set on line 1"},
+	}
+	for _, tt := range tests {
+		fs, _, _, _ := testFlags()
+		settings := &Settings{Path: "node.toml", Items: tt.items}
+		if _, err := settings.Apply(fs); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: err %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	// The file's value for a flag given on the command line is never used,
+	// so it is not parsed either.
+	fs, _, _, _ := testFlags()
+	if err := fs.Parse([]string{"-port", "1"}); err != nil {
+		t.Fatal(err)
+	}
+	settings := &Settings{Path: "node.toml", Items: []Setting{{Name: "port", Value: "eighty", Line: 1}}}
+	if _, err := settings.Apply(fs); err != nil {
+		t.Errorf("bad value under a command-line flag: %v", err)
+	}
+}
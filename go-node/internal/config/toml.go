@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseTOML reads key = value pairs under [table] headers. Dotted table
+// names and keys nest, and lists must fit on one line.
+func parseTOML(text string) ([]Setting, error) {
+	var items []Setting
+	var table []string
+	for i, line := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid table header %q", lineNo, line)
+			}
+			path, err := dottedKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			table = path
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		path, err := dottedKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		expanded, err := expandEnv(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		value, err := parseValue(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		name := settingName(append(append([]string(nil), table...), path[:len(path)-1]...), path[len(path)-1])
+		items = append(items, Setting{Name: name, Value: value, Line: lineNo})
+	}
+	return items, nil
+}
+
+// dottedKey splits a.b.c into its parts.
+func dottedKey(s string) ([]string, error) {
+	parts := strings.Split(strings.TrimSpace(s), ".")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+		if !validKey(parts[i]) {
+			return nil, fmt.Errorf("invalid key %q", s)
+		}
+	}
+	return parts, nil
+}
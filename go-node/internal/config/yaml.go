@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+type yamlKey struct {
+	indent   int
+	name     string
+	line     int
+	list     []string
+	children bool
+}
+
+// parseYAML reads block mappings nested by indentation, with scalar values,
+// [a, b] flow lists or "- item" block lists. Anchors, multi-line strings and
+// lists of mappings are not understood.
+func parseYAML(text string) ([]Setting, error) {
+	var items []Setting
+	var stack []*yamlKey
+
+	path := func() []string {
+		names := make([]string, len(stack))
+		for i, k := range stack {
+			names[i] = k.name
+		}
+		return names
+	}
+	// closeKey finishes the innermost open key, which had no inline value.
+	closeKey := func() error {
+		k := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch {
+		case k.list != nil:
+			items = append(items, Setting{Name: settingName(path(), k.name), Value: strings.Join(k.list, ","), Line: k.line})
+		case !k.children:
+			return fmt.Errorf("line %d: %s has no value", k.line, k.name)
+		}
+		return nil
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		lineNo := i + 1
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		content := strings.TrimLeft(line, " ")
+		if content == "" || content == "---" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", lineNo)
+		}
+		indent := len(line) - len(content)
+
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			if len(stack) == 0 || indent < stack[len(stack)-1].indent || stack[len(stack)-1].children {
+				return nil, fmt.Errorf("line %d: list item outside a list", lineNo)
+			}
+			expanded, err := expandEnv(strings.TrimPrefix(content, "-"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			value, err := parseScalar(expanded)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			top := stack[len(stack)-1]
+			top.list = append(top.list, value)
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			if err := closeKey(); err != nil {
+				return nil, err
+			}
+		}
+		if len(stack) > 0 {
+			parent := stack[len(stack)-1]
+			if parent.list != nil {
+				return nil, fmt.Errorf("line %d: %s mixes list items and keys", lineNo, parent.name)
+			}
+			parent.children = true
+		}
+
+		key, raw := cutYAMLKey(content)
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNo)
+		}
+		if strings.TrimSpace(raw) == "" {
+			stack = append(stack, &yamlKey{indent: indent, name: key, line: lineNo})
+			continue
+		}
+		expanded, err := expandEnv(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		value, err := parseValue(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		items = append(items, Setting{Name: settingName(path(), key), Value: value, Line: lineNo})
+	}
+
+	for len(stack) > 0 {
+		if err := closeKey(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// cutYAMLKey splits "key: value" at the first colon followed by a space or
+// the end of the line. It returns an empty key when there is none.
+func cutYAMLKey(s string) (key, value string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return strings.TrimSpace(s[:i]), s[i+1:]
+		}
+	}
+	return "", ""
+}
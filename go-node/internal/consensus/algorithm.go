@@ -0,0 +1,72 @@
+// Package consensus holds the rules for producing and accepting blocks:
+// proof-of-work hashing, difficulty and the block reward. Proof of work is
+// the only consensus mode. There is no proof-of-stake or proof-of-authority
+// engine, validator set or staking state, so features such as stake
+// delegation or per-epoch validator snapshots have nothing to extend until
+// one is designed. Light clients track the chain from headers and proof of
+// work alone.
+package consensus
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// Algorithm is the hash a network's proof of work is computed with. Blocks
+// commit to it in their header, and block hashes are computed with it, so a
+// block hash is its proof of work whatever the algorithm.
+type Algorithm string
+
+const (
+	SHA256     Algorithm = "sha256" // the original algorithm; headers omit it
+	SHA256d    Algorithm = "sha256d"
+	Blake3     Algorithm = "blake3"
+	ScryptLite Algorithm = "scrypt-lite" // scrypt with N=1024, r=1, p=1
+)
+
+// Algorithms lists every supported algorithm.
+var Algorithms = []Algorithm{SHA256, SHA256d, Blake3, ScryptLite}
+
+// ParseAlgorithm accepts an algorithm name; the empty name means SHA256, as
+// it does in a block header.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	if name == "" {
+		return SHA256, nil
+	}
+	for _, a := range Algorithms {
+		if Algorithm(name) == a {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("unknown proof-of-work algorithm %q (want sha256, sha256d, blake3 or scrypt-lite)", name)
+}
+
+// HeaderName is the name a block header records: empty for SHA256, so
+// blocks from before algorithms were selectable keep their hashes.
+func (a Algorithm) HeaderName() string {
+	if a == SHA256 {
+		return ""
+	}
+	return string(a)
+}
+
+// Hash returns the hash of data under a as lowercase hex. An unknown
+// algorithm returns "", which never matches a block hash.
+func (a Algorithm) Hash(data []byte) string {
+	switch a {
+	case SHA256, "":
+		return crypto.SHA256(data)
+	case SHA256d:
+		first := sha256.Sum256(data)
+		second := sha256.Sum256(first[:])
+		return hex.EncodeToString(second[:])
+	case Blake3:
+		return crypto.Blake3(data)
+	case ScryptLite:
+		return crypto.ScryptLite(data)
+	}
+	return ""
+}
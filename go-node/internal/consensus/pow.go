@@ -1,44 +1,126 @@
 package consensus
 
 import (
+	"context"
 	"encoding/hex"
+	"errors"
 	"math/big"
 )
 
 const (
-	DefaultDifficulty = 4 // Start with difficulty 4 for learning
+	DefaultDifficulty  = 4 // Start with difficulty 4 for learning
+	DefaultBlockReward = 50.0
+
+	// MaxDifficulty is the most leading zero bits a 256-bit hash can have.
+	// Difficulty is a shift count, so anything beyond it has no target.
+	MaxDifficulty = 256
+)
+
+var (
+	ErrNoSolution        = errors.New("no nonce meets the difficulty")
+	ErrInvalidDifficulty = errors.New("difficulty must be between 1 and 256")
 )
 
-func MineBlock(computeHashFunc func(int64) string, setNonceFunc func(int64), difficulty int) (string, int64) {
+// ValidDifficulty reports whether difficulty is one a block may declare.
+func ValidDifficulty(difficulty int) bool {
+	return difficulty >= 1 && difficulty <= MaxDifficulty
+}
+
+// MineBlock tries nonces until the hash meets difficulty. It gives up once
+// ctx is done and returns context.Cause(ctx), so a caller that cancelled
+// with a cause of its own can tell why mining stopped.
+func MineBlock(ctx context.Context, computeHashFunc func(int64) string, setNonceFunc func(int64), difficulty int) (string, int64, error) {
+	hashWithNonce := func(nonce int64) string {
+		setNonceFunc(nonce)
+		return computeHashFunc(nonce)
+	}
+	return searchNonces(ctx, hashWithNonce, difficulty, 0, 1)
+}
+
+// MineBlockParallel is MineBlock spread over threads goroutines. Worker i
+// tries nonces i, i+threads, i+2*threads and so on, so no nonce is tried
+// twice; the first solution found stops the other workers. newHasher is
+// called once per worker, so each hasher can keep its own copy of the block
+// and need not be safe for concurrent use.
+func MineBlockParallel(ctx context.Context, newHasher func() func(int64) string, difficulty int, threads int) (string, int64, error) {
+	if threads < 1 {
+		threads = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		hash  string
+		nonce int64
+		err   error
+	}
+	results := make(chan result, threads)
+	for i := 0; i < threads; i++ {
+		go func(start int64, computeHashFunc func(int64) string) {
+			hash, nonce, err := searchNonces(ctx, computeHashFunc, difficulty, start, int64(threads))
+			results <- result{hash: hash, nonce: nonce, err: err}
+		}(int64(i), newHasher())
+	}
+
+	// The first worker to return decides: either it found a solution or
+	// the search as a whole was canceled.
+	res := <-results
+	return res.hash, res.nonce, res.err
+}
+
+// searchNonces tries start, start+step, ... until a hash meets difficulty.
+func searchNonces(ctx context.Context, computeHashFunc func(int64) string, difficulty int, start, step int64) (string, int64, error) {
+	if !ValidDifficulty(difficulty) {
+		return "", 0, ErrInvalidDifficulty
+	}
 	target := big.NewInt(1)
 	target.Lsh(target, uint(256-difficulty))
 
-	nonce := int64(0)
 	maxNonce := int64(^uint64(0) >> 1) // Max int64 value (safety limit)
+	done := ctx.Done()
+	hashInt := new(big.Int)
 
-	for nonce < maxNonce {
-		setNonceFunc(nonce)
+	for nonce, tried := start, 0; nonce >= 0 && nonce < maxNonce; nonce, tried = nonce+step, tried+1 {
+		if done != nil && tried%256 == 0 {
+			select {
+			case <-done:
+				return "", 0, context.Cause(ctx)
+			default:
+			}
+		}
 
 		hash := computeHashFunc(nonce)
 
-		hashInt := new(big.Int)
 		hashBytes, err := hex.DecodeString(hash)
 		if err != nil {
-			return "", 0
+			return "", 0, err
 		}
 		hashInt.SetBytes(hashBytes)
 
 		if hashInt.Cmp(target) == -1 {
-			return hash, nonce
+			return hash, nonce, nil
 		}
-
-		nonce++
 	}
 
-	return "", 0
+	return "", 0, ErrNoSolution
+}
+
+// Work is the expected number of hashes needed to meet difficulty, used to
+// compare the cumulative work of competing branches. A difficulty outside
+// 1-256 counts for no work.
+func Work(difficulty int) *big.Int {
+	if !ValidDifficulty(difficulty) {
+		return new(big.Int)
+	}
+	work := big.NewInt(1)
+	return work.Lsh(work, uint(difficulty))
 }
 
 func ValidateProofOfWork(hash string, difficulty int) bool {
+	if !ValidDifficulty(difficulty) {
+		return false
+	}
 	target := big.NewInt(1)
 	target.Lsh(target, uint(256-difficulty))
 
@@ -66,6 +148,9 @@ func GetDifficultyFromHash(hash string) int {
 
 func AdjustDifficulty(currentDifficulty int, targetBlockTime, actualBlockTime int64) int {
 	if actualBlockTime < targetBlockTime/2 {
+		if currentDifficulty >= MaxDifficulty {
+			return MaxDifficulty
+		}
 		return currentDifficulty + 1
 	}
 
@@ -88,4 +173,3 @@ func HashToBigInt(hash string) (*big.Int, error) {
 	hashInt.SetBytes(hashBytes)
 	return hashInt, nil
 }
-
@@ -0,0 +1,89 @@
+package consensus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDifficultyOutOfRange(t *testing.T) {
+	zeroHash := strings.Repeat("0", 64)
+	for _, difficulty := range []int{-1, 0, 257, 300, 1 << 20} {
+		if ValidDifficulty(difficulty) {
+			t.Errorf("ValidDifficulty(%d) = true", difficulty)
+		}
+		if ValidateProofOfWork(zeroHash, difficulty) {
+			t.Errorf("ValidateProofOfWork at difficulty %d accepted a hash", difficulty)
+		}
+		if work := Work(difficulty); work.Sign() != 0 {
+			t.Errorf("Work(%d) = %s, want 0", difficulty, work)
+		}
+	}
+	if !ValidateProofOfWork(zeroHash, MaxDifficulty) {
+		t.Errorf("ValidateProofOfWork rejected the zero hash at difficulty %d", MaxDifficulty)
+	}
+}
+
+func TestAdjustDifficultyStopsAtMax(t *testing.T) {
+	if got := AdjustDifficulty(MaxDifficulty, 600, 1); got != MaxDifficulty {
+		t.Errorf("AdjustDifficulty(%d, fast) = %d", MaxDifficulty, got)
+	}
+}
+
+// benchDifficulty takes tens of thousands of hashes per solution, enough
+// for the parallel speedup to show over the cost of starting workers.
+const benchDifficulty = 16
+
+// benchHasher hashes a header that differs per benchmark iteration with the
+// nonce appended, counting the hashes in hashes.
+func benchHasher(header string, hashes *atomic.Int64) func(int64) string {
+	buf := []byte(header)
+	return func(nonce int64) string {
+		hashes.Add(1)
+		sum := sha256.Sum256(strconv.AppendInt(buf[:len(header):len(header)], nonce, 10))
+		return hex.EncodeToString(sum[:])
+	}
+}
+
+func reportHashRate(b *testing.B, hashes *atomic.Int64) {
+	b.ReportMetric(float64(hashes.Load())/b.Elapsed().Seconds(), "hashes/s")
+}
+
+func BenchmarkMineBlock(b *testing.B) {
+	var hashes atomic.Int64
+	for i := 0; i < b.N; i++ {
+		hash := benchHasher(fmt.Sprintf("block-%d|", i), &hashes)
+		if _, _, err := MineBlock(context.Background(), hash, func(int64) {}, benchDifficulty); err != nil {
+			b.Fatal(err)
+		}
+	}
+	reportHashRate(b, &hashes)
+}
+
+// Run with -bench=Mine to compare with BenchmarkMineBlock; the speedup
+// follows the number of cores.
+func BenchmarkMineBlockParallel(b *testing.B) {
+	counts := []int{2}
+	if n := runtime.NumCPU(); n > 2 {
+		counts = append(counts, n)
+	}
+	for _, threads := range counts {
+		b.Run(fmt.Sprintf("threads=%d", threads), func(b *testing.B) {
+			var hashes atomic.Int64
+			for i := 0; i < b.N; i++ {
+				header := fmt.Sprintf("block-%d|", i)
+				newHasher := func() func(int64) string { return benchHasher(header, &hashes) }
+				if _, _, err := MineBlockParallel(context.Background(), newHasher, benchDifficulty, threads); err != nil {
+					b.Fatal(err)
+				}
+			}
+			reportHashRate(b, &hashes)
+		})
+	}
+}
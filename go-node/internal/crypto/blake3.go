@@ -0,0 +1,164 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+)
+
+// BLAKE3 in its default hash mode with a 32-byte output, written from the
+// specification so the node keeps to the standard library. It is a plain
+// portable implementation: one chunk at a time, no SIMD.
+
+const (
+	blake3BlockLen = 64
+	blake3ChunkLen = 1024
+
+	blake3ChunkStart = 1 << 0
+	blake3ChunkEnd   = 1 << 1
+	blake3Parent     = 1 << 2
+	blake3Root       = 1 << 3
+)
+
+var blake3IV = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+var blake3Permutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func blake3G(s *[16]uint32, a, b, c, d int, mx, my uint32) {
+	s[a] = s[a] + s[b] + mx
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] = s[c] + s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] = s[a] + s[b] + my
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] = s[c] + s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+// blake3Compress returns the full 16-word output of the compression
+// function; the first 8 words are the new chaining value.
+func blake3Compress(cv [8]uint32, block [16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		blake3IV[0], blake3IV[1], blake3IV[2], blake3IV[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := block
+	for round := 0; round < 7; round++ {
+		blake3G(&s, 0, 4, 8, 12, m[0], m[1])
+		blake3G(&s, 1, 5, 9, 13, m[2], m[3])
+		blake3G(&s, 2, 6, 10, 14, m[4], m[5])
+		blake3G(&s, 3, 7, 11, 15, m[6], m[7])
+		blake3G(&s, 0, 5, 10, 15, m[8], m[9])
+		blake3G(&s, 1, 6, 11, 12, m[10], m[11])
+		blake3G(&s, 2, 7, 8, 13, m[12], m[13])
+		blake3G(&s, 3, 4, 9, 14, m[14], m[15])
+
+		var permuted [16]uint32
+		for i, p := range blake3Permutation {
+			permuted[i] = m[p]
+		}
+		m = permuted
+	}
+	for i := 0; i < 8; i++ {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+func blake3Words(block []byte) [16]uint32 {
+	var padded [blake3BlockLen]byte
+	copy(padded[:], block)
+	var words [16]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(padded[4*i:])
+	}
+	return words
+}
+
+// blake3Output is a compression not yet run, kept so the last one can be
+// run with the root flag.
+type blake3Output struct {
+	cv       [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o blake3Output) chainingValue() [8]uint32 {
+	out := blake3Compress(o.cv, o.block, o.counter, o.blockLen, o.flags)
+	var cv [8]uint32
+	copy(cv[:], out[:8])
+	return cv
+}
+
+func (o blake3Output) rootHash() [32]byte {
+	out := blake3Compress(o.cv, o.block, 0, o.blockLen, o.flags|blake3Root)
+	var sum [32]byte
+	for i := 0; i < 8; i++ {
+		binary.LittleEndian.PutUint32(sum[4*i:], out[i])
+	}
+	return sum
+}
+
+// blake3ChunkOutput compresses every block of chunk but the last and returns
+// the last as an output.
+func blake3ChunkOutput(chunk []byte, counter uint64) blake3Output {
+	cv := blake3IV
+	flags := uint32(blake3ChunkStart)
+	for len(chunk) > blake3BlockLen {
+		out := blake3Compress(cv, blake3Words(chunk[:blake3BlockLen]), counter, blake3BlockLen, flags)
+		copy(cv[:], out[:8])
+		chunk = chunk[blake3BlockLen:]
+		flags = 0
+	}
+	return blake3Output{
+		cv:       cv,
+		block:    blake3Words(chunk),
+		counter:  counter,
+		blockLen: uint32(len(chunk)),
+		flags:    flags | blake3ChunkEnd,
+	}
+}
+
+func blake3ParentOutput(left, right [8]uint32) blake3Output {
+	var block [16]uint32
+	copy(block[:8], left[:])
+	copy(block[8:], right[:])
+	return blake3Output{cv: blake3IV, block: block, blockLen: blake3BlockLen, flags: blake3Parent}
+}
+
+// Blake3Sum returns the 32-byte BLAKE3 hash of data.
+func Blake3Sum(data []byte) [32]byte {
+	// Completed chunks are merged pairwise as the tree fills in: after
+	// chunk n, as many merges happen as n has trailing one bits.
+	var stack [][8]uint32
+	var counter uint64
+	for len(data) > blake3ChunkLen {
+		cv := blake3ChunkOutput(data[:blake3ChunkLen], counter).chainingValue()
+		data = data[blake3ChunkLen:]
+		counter++
+		for total := counter; total&1 == 0; total >>= 1 {
+			cv = blake3ParentOutput(stack[len(stack)-1], cv).chainingValue()
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, cv)
+	}
+
+	out := blake3ChunkOutput(data, counter)
+	for i := len(stack) - 1; i >= 0; i-- {
+		out = blake3ParentOutput(stack[i], out.chainingValue())
+	}
+	return out.rootHash()
+}
+
+// Blake3 returns the BLAKE3 hash of data as lowercase hex.
+func Blake3(data []byte) string {
+	sum := Blake3Sum(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
+	"strconv"
+	"sync"
+)
+
+// A development node started with a seed derives its keys from it instead
+// of from crypto/rand, so tutorial scripts, integration tests and the other
+// components see the same addresses on every run. Output i of a purpose is
+//
+//	HKDF-SHA256(secret = seed, salt = devSeedSalt, info = purpose "/" i)
+//
+// with i counting from 0 separately for each purpose. Anyone who knows the
+// seed knows every key, so nothing derived this way may guard real coins.
+
+const devSeedSalt = "ai-blockchain dev seed v1"
+
+// Purposes the node derives from a DevSeed.
+const (
+	DevSeedWallet   = "wallet"   // wallet keys, in the order wallets are generated
+	DevSeedMnemonic = "mnemonic" // entropy of HD wallet mnemonics
+	DevSeedNodeID   = "node-id"  // the P2P node ID, followed by "@" and the P2P listen address
+)
+
+// DevSeed derives a deterministic sequence of secrets for each purpose.
+type DevSeed struct {
+	seed []byte
+	mu   sync.Mutex
+	next map[string]uint64
+}
+
+func NewDevSeed(seed string) *DevSeed {
+	return &DevSeed{seed: []byte(seed), next: make(map[string]uint64)}
+}
+
+// Bytes returns the next n bytes for purpose.
+func (d *DevSeed) Bytes(purpose string, n int) []byte {
+	d.mu.Lock()
+	i := d.next[purpose]
+	d.next[purpose] = i + 1
+	d.mu.Unlock()
+
+	info := purpose + "/" + strconv.FormatUint(i, 10)
+	return hkdfSHA256(d.seed, []byte(devSeedSalt), []byte(info), n)
+}
+
+// PrivateKey returns the next P-256 key for purpose. Its scalar is 40
+// derived bytes reduced mod N-1, plus one, as FIPS 186-4 B.4.1 turns random
+// bits into a key, so the Java and Python components can derive the same
+// keys.
+func (d *DevSeed) PrivateKey(purpose string) *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	nMinusOne := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	k := new(big.Int).SetBytes(d.Bytes(purpose, 40))
+	k.Mod(k, nMinusOne)
+	k.Add(k, big.NewInt(1))
+
+	priv := &ecdsa.PrivateKey{D: k}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return priv
+}
@@ -27,22 +27,100 @@ func SignMessage(priv *ecdsa.PrivateKey, msg []byte) (string, error) {
 		return "", err
 	}
 
-	rBytes := r.Bytes()
-	sBytes := s.Bytes()
+	return EncodeSignature(r, s), nil
+}
+
+// SignatureLength is the byte length of an encoded signature: r and s, each
+// left-padded to 32 bytes.
+const SignatureLength = 64
+
+// EncodeSignature returns the one accepted encoding of (r, s): fixed-width
+// lowercase hex with s in the lower half of the curve order. (r, n-s) verifies
+// just as well, so without this rule anyone relaying a transaction could
+// produce a second valid signature for it.
+func EncodeSignature(r, s *big.Int) string {
+	n := elliptic.P256().Params().N
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
 
-	signature := append(rBytes, sBytes...)
-	return hex.EncodeToString(signature), nil
+	sig := make([]byte, SignatureLength)
+	r.FillBytes(sig[:SignatureLength/2])
+	s.FillBytes(sig[SignatureLength/2:])
+	return hex.EncodeToString(sig)
 }
 
+// CheckSignatureEncoding reports whether signature is in the form
+// EncodeSignature produces. It does not verify the signature.
+func CheckSignatureEncoding(signature string) error {
+	if len(signature) != 2*SignatureLength || !IsLowerHex(signature) {
+		return errors.New("signature must be 128 lowercase hex characters")
+	}
+	sig, _ := hex.DecodeString(signature)
+
+	n := elliptic.P256().Params().N
+	r := new(big.Int).SetBytes(sig[:SignatureLength/2])
+	s := new(big.Int).SetBytes(sig[SignatureLength/2:])
+	if r.Sign() == 0 || r.Cmp(n) >= 0 || s.Sign() == 0 {
+		return errors.New("signature values out of range")
+	}
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		return errors.New("signature is not in low-S form")
+	}
+	return nil
+}
+
+// IsLowerHex reports whether s is non-empty and made only of 0-9 and a-f.
+func IsLowerHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// PublicKeyLength is the byte length of an encoded public key: x and y, each
+// left-padded to 32 bytes. Unpadded coordinates would leave about one key
+// in 128 with an odd length or a misplaced split between x and y.
+const PublicKeyLength = 64
+
 func EncodePublicKey(pub *ecdsa.PublicKey) string {
-	xBytes := pub.X.Bytes()
-	yBytes := pub.Y.Bytes()
+	return hex.EncodeToString(publicKeyBytes(pub))
+}
+
+func publicKeyBytes(pub *ecdsa.PublicKey) []byte {
+	combined := make([]byte, PublicKeyLength)
+	pub.X.FillBytes(combined[:PublicKeyLength/2])
+	pub.Y.FillBytes(combined[PublicKeyLength/2:])
+	return combined
+}
 
-	combined := append(xBytes, yBytes...)
-	return hex.EncodeToString(combined)
+// AddressFromPublicKey is the address of pub: the SHA-256 of the 64-byte
+// encoding EncodePublicKey writes, never of the unpadded coordinates.
+func AddressFromPublicKey(pub *ecdsa.PublicKey) string {
+	return SHA256(publicKeyBytes(pub))
 }
 
+// DecodePublicKey accepts only the encoding EncodePublicKey writes. The txid
+// does not cover the public key, so a second accepted form, say with each
+// coordinate zero-padded by a byte, would let a relay change a transaction
+// without changing its id.
 func DecodePublicKey(hexKey string) (*ecdsa.PublicKey, error) {
+	if len(hexKey) != 2*PublicKeyLength || !IsLowerHex(hexKey) {
+		return nil, errors.New("public key must be 128 lowercase hex characters")
+	}
+	return ParsePublicKey(hexKey)
+}
+
+// ParsePublicKey reads a public key as users paste it: hex X||Y in either
+// case, with the coordinates padded or not, split halfway. Anything taken
+// from a transaction or block goes through DecodePublicKey instead.
+func ParsePublicKey(hexKey string) (*ecdsa.PublicKey, error) {
 	bytes, err := hex.DecodeString(hexKey)
 	if err != nil {
 		return nil, err
@@ -56,6 +134,9 @@ func DecodePublicKey(hexKey string) (*ecdsa.PublicKey, error) {
 
 	x := new(big.Int).SetBytes(bytes[:mid])
 	y := new(big.Int).SetBytes(bytes[mid:])
+	if !elliptic.P256().IsOnCurve(x, y) {
+		return nil, errors.New("public key is not a point on P-256")
+	}
 
 	return &ecdsa.PublicKey{
 		Curve: elliptic.P256(),
@@ -81,7 +162,7 @@ func VerifySignature(data []byte, signature, pubKeyHex string) (bool, error) {
 	r := new(big.Int).SetBytes(sigBytes[:mid])
 	s := new(big.Int).SetBytes(sigBytes[mid:])
 
-	pub, err := DecodePublicKey(pubKeyHex)
+	pub, err := ParsePublicKey(pubKeyHex)
 	if err != nil {
 		return false, err
 	}
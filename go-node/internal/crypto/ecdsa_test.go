@@ -0,0 +1,124 @@
+package crypto
+
+import (
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// Public keys and signatures arrive as hex from the API and from peers; run
+// these with go test -fuzz=FuzzDecodePublicKey ./internal/crypto and so on.
+
+// padPublicKey prefixes each coordinate of an encoded key with a zero byte.
+func padPublicKey(key string) string {
+	half := len(key) / 2
+	return "00" + key[:half] + "00" + key[half:]
+}
+
+func TestDecodePublicKeyRejectsOtherEncodings(t *testing.T) {
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := EncodePublicKey(&priv.PublicKey)
+	if _, err := DecodePublicKey(key); err != nil {
+		t.Fatalf("canonical key: %v", err)
+	}
+	for name, other := range map[string]string{
+		"zero-padded": padPublicKey(key),
+		"uppercase":   strings.ToUpper(key),
+		"truncated":   key[2:],
+	} {
+		if _, err := DecodePublicKey(other); err == nil {
+			t.Errorf("%s key %s decoded", name, other)
+		}
+	}
+
+	// Keys users paste are still read leniently.
+	pub, err := ParsePublicKey(strings.ToUpper(padPublicKey(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if EncodePublicKey(pub) != key {
+		t.Errorf("ParsePublicKey read a different point")
+	}
+}
+
+func FuzzDecodePublicKey(f *testing.F) {
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(EncodePublicKey(&priv.PublicKey))
+	f.Add(strings.ToUpper(EncodePublicKey(&priv.PublicKey)))
+	f.Add("")
+	f.Add("00")
+	f.Add(strings.Repeat("f", 2*PublicKeyLength))
+	f.Add(padPublicKey(EncodePublicKey(&priv.PublicKey)))
+
+	f.Fuzz(func(t *testing.T, hexKey string) {
+		pub, err := DecodePublicKey(hexKey)
+		if err != nil {
+			return
+		}
+		if !elliptic.P256().IsOnCurve(pub.X, pub.Y) {
+			t.Fatalf("decoded %q to a point off the curve", hexKey)
+		}
+		// Only one encoding of a key may decode, or a relay could swap it
+		// for another without changing the txid.
+		encoded := EncodePublicKey(pub)
+		if encoded != hexKey {
+			t.Fatalf("%q decodes but re-encodes as %s", hexKey, encoded)
+		}
+		again, err := DecodePublicKey(encoded)
+		if err != nil {
+			t.Fatalf("re-decoding %s: %v", encoded, err)
+		}
+		if again.X.Cmp(pub.X) != 0 || again.Y.Cmp(pub.Y) != 0 {
+			t.Fatalf("%s does not round-trip", encoded)
+		}
+		if AddressFromPublicKey(again) != AddressFromPublicKey(pub) {
+			t.Fatalf("address of %s changes after re-encoding", encoded)
+		}
+	})
+}
+
+func FuzzSignature(f *testing.F) {
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		f.Fatal(err)
+	}
+	msg := []byte("fuzz")
+	sig, err := SignMessage(priv, msg)
+	if err != nil {
+		f.Fatal(err)
+	}
+	pubKey := EncodePublicKey(&priv.PublicKey)
+	f.Add(sig)
+	f.Add(strings.ToUpper(sig))
+	f.Add(sig[:SignatureLength])
+	f.Add("")
+	f.Add(strings.Repeat("0", 2*SignatureLength))
+
+	f.Fuzz(func(t *testing.T, signature string) {
+		// Neither may panic, whatever the input.
+		valid, _ := VerifySignature(msg, signature, pubKey)
+		if err := CheckSignatureEncoding(signature); err != nil {
+			return
+		}
+
+		// An encoding that passes is the one EncodeSignature gives its
+		// (r, s), so no second form of a signature gets through.
+		raw, _ := hex.DecodeString(signature)
+		r := new(big.Int).SetBytes(raw[:SignatureLength/2])
+		s := new(big.Int).SetBytes(raw[SignatureLength/2:])
+		if EncodeSignature(r, s) != signature {
+			t.Fatalf("%s passes the encoding check but is not canonical", signature)
+		}
+		if valid && signature != sig {
+			t.Fatalf("second valid signature %s for the same message", signature)
+		}
+	})
+}
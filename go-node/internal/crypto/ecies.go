@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+)
+
+// ECIES over P-256, for messages only the holder of a wallet key can read:
+// an ephemeral key agrees a secret with the recipient's key, HKDF-SHA256
+// turns it into an AES-256-GCM key, and the ciphertext travels with the
+// ephemeral public key. The layout is
+//
+//	ephemeral public key (65 bytes, uncompressed) || nonce (12) || ciphertext || tag (16)
+//
+// and the key derivation binds the ephemeral key, so a ciphertext cannot be
+// moved under a different one.
+
+const (
+	eciesKeyLen   = 65
+	eciesNonceLen = 12
+	eciesInfo     = "ai-blockchain ecies v1"
+)
+
+var ErrDecrypt = errors.New("ciphertext cannot be decrypted with this key")
+
+// EncryptECIES encrypts plaintext to the P-256 public key given as hex X||Y,
+// the encoding wallets use, and returns the ciphertext.
+func EncryptECIES(pubKeyHex string, plaintext []byte) ([]byte, error) {
+	pub, err := ParsePublicKey(pubKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := pub.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub := ephemeral.PublicKey().Bytes()
+	aead, err := eciesAEAD(secret, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, eciesKeyLen+eciesNonceLen+len(plaintext)+aead.Overhead())
+	out = append(out, ephemeralPub...)
+	nonce := make([]byte, eciesNonceLen)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, nil), nil
+}
+
+// DecryptECIES reverses EncryptECIES with the recipient's private key.
+func DecryptECIES(priv *ecdsa.PrivateKey, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < eciesKeyLen+eciesNonceLen {
+		return nil, ErrDecrypt
+	}
+	key, err := priv.ECDH()
+	if err != nil {
+		return nil, err
+	}
+	ephemeralPub := ciphertext[:eciesKeyLen]
+	ephemeral, err := ecdh.P256().NewPublicKey(ephemeralPub)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	secret, err := key.ECDH(ephemeral)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	aead, err := eciesAEAD(secret, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	nonce := ciphertext[eciesKeyLen : eciesKeyLen+eciesNonceLen]
+	plaintext, err := aead.Open(nil, nonce, ciphertext[eciesKeyLen+eciesNonceLen:], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func eciesAEAD(secret, ephemeralPub []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(hkdfSHA256(secret, nil, append([]byte(eciesInfo), ephemeralPub...), 32))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// hkdfSHA256 is RFC 5869 HKDF with SHA-256, for up to 255 output blocks.
+func hkdfSHA256(secret, salt, info []byte, length int) []byte {
+	if salt == nil {
+		salt = make([]byte, sha256.Size)
+	}
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	prk := extract.Sum(nil)
+
+	expand := hmac.New(sha256.New, prk)
+	var out, block []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		expand.Reset()
+		expand.Write(block)
+		expand.Write(info)
+		expand.Write([]byte{counter})
+		block = expand.Sum(nil)
+		out = append(out, block...)
+	}
+	return out[:length]
+}
@@ -0,0 +1,78 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestECIESRoundTrip(t *testing.T) {
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := EncodePublicKey(&priv.PublicKey)
+
+	for _, msg := range []string{"", "invoice 42", string(bytes.Repeat([]byte("x"), 4096))} {
+		ct, err := EncryptECIES(pub, []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := eciesKeyLen + eciesNonceLen + len(msg) + 16; len(ct) != want {
+			t.Errorf("%d-byte message: ciphertext is %d bytes, want %d", len(msg), len(ct), want)
+		}
+		got, err := DecryptECIES(priv, ct)
+		if err != nil {
+			t.Fatalf("%d-byte message: %v", len(msg), err)
+		}
+		if string(got) != msg {
+			t.Errorf("%d-byte message came back as %d bytes", len(msg), len(got))
+		}
+	}
+
+	// Each encryption takes a fresh ephemeral key and nonce.
+	a, _ := EncryptECIES(pub, []byte("same"))
+	b, _ := EncryptECIES(pub, []byte("same"))
+	if bytes.Equal(a[:eciesKeyLen], b[:eciesKeyLen]) || bytes.Equal(a, b) {
+		t.Error("two encryptions of one message share an ephemeral key")
+	}
+}
+
+func TestECIESRejects(t *testing.T) {
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct, err := EncryptECIES(EncodePublicKey(&priv.PublicKey), []byte("for the recipient only"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := DecryptECIES(other, ct); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong key: err = %v, want ErrDecrypt", err)
+	}
+
+	flip := func(i int) []byte {
+		c := bytes.Clone(ct)
+		c[i] ^= 1
+		return c
+	}
+	for name, c := range map[string][]byte{
+		"ephemeral key": flip(1),
+		"nonce":         flip(eciesKeyLen),
+		"ciphertext":    flip(eciesKeyLen + eciesNonceLen),
+		"tag":           flip(len(ct) - 1),
+		"truncated":     ct[:len(ct)-1],
+		"no body":       ct[:eciesKeyLen+eciesNonceLen],
+		"short":         ct[:eciesKeyLen],
+		"empty":         nil,
+	} {
+		if _, err := DecryptECIES(priv, c); !errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s: err = %v, want ErrDecrypt", name, err)
+		}
+	}
+}
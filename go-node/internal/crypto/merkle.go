@@ -30,3 +30,47 @@ func MerkleRoot(txIDs []string) string {
 
 	return hashes[0]
 }
+
+// MerkleBranch returns the sibling hashes linking txIDs[index] to the root
+// MerkleRoot computes, leaf level first. With the leaf's position they are
+// enough to recompute the root without the other txids; see
+// MerkleRootFromBranch. It returns nil when index is out of range.
+func MerkleBranch(txIDs []string, index int) []string {
+	if index < 0 || index >= len(txIDs) {
+		return nil
+	}
+
+	hashes := make([]string, len(txIDs))
+	copy(hashes, txIDs)
+
+	branch := []string{}
+	for len(hashes) > 1 {
+		if len(hashes)%2 == 1 {
+			hashes = append(hashes, hashes[len(hashes)-1])
+		}
+		branch = append(branch, hashes[index^1])
+
+		nextLevel := make([]string, len(hashes)/2)
+		for i := range nextLevel {
+			nextLevel[i] = SHA256([]byte(hashes[2*i] + hashes[2*i+1]))
+		}
+		hashes = nextLevel
+		index /= 2
+	}
+	return branch
+}
+
+// MerkleRootFromBranch folds a branch from MerkleBranch back up from the
+// leaf at index and returns the root it leads to.
+func MerkleRootFromBranch(txID string, index int, branch []string) string {
+	hash := txID
+	for _, sibling := range branch {
+		if index%2 == 0 {
+			hash = SHA256([]byte(hash + sibling))
+		} else {
+			hash = SHA256([]byte(sibling + hash))
+		}
+		index /= 2
+	}
+	return hash
+}
@@ -0,0 +1,154 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/bits"
+)
+
+// Scrypt parameters for proof of work, the ones Litecoin uses: 128 KiB of
+// memory per hash, cheap enough to verify a block in well under a
+// millisecond.
+const (
+	ScryptLiteN = 1024
+	ScryptLiteR = 1
+	ScryptLiteP = 1
+)
+
+// ScryptLite returns scrypt(data, data, ScryptLiteN, ScryptLiteR,
+// ScryptLiteP) with a 32-byte output, as lowercase hex.
+func ScryptLite(data []byte) string {
+	return hex.EncodeToString(Scrypt(data, data, ScryptLiteN, ScryptLiteR, ScryptLiteP, 32))
+}
+
+// Scrypt derives keyLen bytes from password and salt as RFC 7914 specifies.
+// n must be a power of two greater than 1; r and p must be positive.
+func Scrypt(password, salt []byte, n, r, p, keyLen int) []byte {
+	blockSize := 128 * r
+	b := pbkdf2SHA256(password, salt, p*blockSize)
+
+	x := make([]uint32, 32*r)
+	v := make([]uint32, 32*r*n)
+	for i := 0; i < p; i++ {
+		scryptROMix(b[i*blockSize:(i+1)*blockSize], r, n, x, v)
+	}
+	return pbkdf2SHA256(password, b, keyLen)
+}
+
+// ScryptKey is Scrypt for parameters read from untrusted input, such as a
+// keystore file: it rejects those RFC 7914 does not allow, and those that
+// would need more than 2 GiB of memory, instead of allocating for them.
+func ScryptKey(password, salt []byte, n, r, p, keyLen int) ([]byte, error) {
+	if n <= 1 || n&(n-1) != 0 {
+		return nil, errors.New("scrypt: N must be a power of two greater than 1")
+	}
+	if r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 || n > 1<<24/r {
+		return nil, errors.New("scrypt: parameters out of range")
+	}
+	return Scrypt(password, salt, n, r, p, keyLen), nil
+}
+
+// pbkdf2SHA256 is PBKDF2 with HMAC-SHA256 and the single iteration scrypt
+// uses.
+func pbkdf2SHA256(password, salt []byte, keyLen int) []byte {
+	mac := hmac.New(sha256.New, password)
+	out := make([]byte, 0, keyLen+sha256.Size)
+	var index [4]byte
+	for i := uint32(1); len(out) < keyLen; i++ {
+		binary.BigEndian.PutUint32(index[:], i)
+		mac.Reset()
+		mac.Write(salt)
+		mac.Write(index[:])
+		out = mac.Sum(out)
+	}
+	return out[:keyLen]
+}
+
+// scryptROMix mixes block in place using x and v as scratch space.
+func scryptROMix(block []byte, r, n int, x, v []uint32) {
+	words := 32 * r
+	for i := range x {
+		x[i] = binary.LittleEndian.Uint32(block[4*i:])
+	}
+	y := make([]uint32, words)
+
+	for i := 0; i < n; i++ {
+		copy(v[i*words:], x)
+		scryptBlockMix(x, y, r)
+	}
+	for i := 0; i < n; i++ {
+		// Integerify: the first word of the last 64-byte block, mod n.
+		j := int(x[words-16] & uint32(n-1))
+		for k := range x {
+			x[k] ^= v[j*words+k]
+		}
+		scryptBlockMix(x, y, r)
+	}
+
+	for i := range x {
+		binary.LittleEndian.PutUint32(block[4*i:], x[i])
+	}
+}
+
+// scryptBlockMix runs BlockMix over the 2r 64-byte blocks in b, using y as
+// scratch space, and leaves the result in b.
+func scryptBlockMix(b, y []uint32, r int) {
+	var t [16]uint32
+	copy(t[:], b[(2*r-1)*16:])
+	for i := 0; i < 2*r; i++ {
+		for k := range t {
+			t[k] ^= b[i*16+k]
+		}
+		salsa208(&t)
+		// Even blocks go to the first half of the output, odd ones to the
+		// second.
+		copy(y[((i&1)*r+i/2)*16:], t[:])
+	}
+	copy(b, y)
+}
+
+// salsa208 applies the Salsa20/8 core to t in place.
+func salsa208(t *[16]uint32) {
+	x := *t
+	for i := 0; i < 8; i += 2 {
+		x[4] ^= bits.RotateLeft32(x[0]+x[12], 7)
+		x[8] ^= bits.RotateLeft32(x[4]+x[0], 9)
+		x[12] ^= bits.RotateLeft32(x[8]+x[4], 13)
+		x[0] ^= bits.RotateLeft32(x[12]+x[8], 18)
+		x[9] ^= bits.RotateLeft32(x[5]+x[1], 7)
+		x[13] ^= bits.RotateLeft32(x[9]+x[5], 9)
+		x[1] ^= bits.RotateLeft32(x[13]+x[9], 13)
+		x[5] ^= bits.RotateLeft32(x[1]+x[13], 18)
+		x[14] ^= bits.RotateLeft32(x[10]+x[6], 7)
+		x[2] ^= bits.RotateLeft32(x[14]+x[10], 9)
+		x[6] ^= bits.RotateLeft32(x[2]+x[14], 13)
+		x[10] ^= bits.RotateLeft32(x[6]+x[2], 18)
+		x[3] ^= bits.RotateLeft32(x[15]+x[11], 7)
+		x[7] ^= bits.RotateLeft32(x[3]+x[15], 9)
+		x[11] ^= bits.RotateLeft32(x[7]+x[3], 13)
+		x[15] ^= bits.RotateLeft32(x[11]+x[7], 18)
+
+		x[1] ^= bits.RotateLeft32(x[0]+x[3], 7)
+		x[2] ^= bits.RotateLeft32(x[1]+x[0], 9)
+		x[3] ^= bits.RotateLeft32(x[2]+x[1], 13)
+		x[0] ^= bits.RotateLeft32(x[3]+x[2], 18)
+		x[6] ^= bits.RotateLeft32(x[5]+x[4], 7)
+		x[7] ^= bits.RotateLeft32(x[6]+x[5], 9)
+		x[4] ^= bits.RotateLeft32(x[7]+x[6], 13)
+		x[5] ^= bits.RotateLeft32(x[4]+x[7], 18)
+		x[11] ^= bits.RotateLeft32(x[10]+x[9], 7)
+		x[8] ^= bits.RotateLeft32(x[11]+x[10], 9)
+		x[9] ^= bits.RotateLeft32(x[8]+x[11], 13)
+		x[10] ^= bits.RotateLeft32(x[9]+x[8], 18)
+		x[12] ^= bits.RotateLeft32(x[15]+x[14], 7)
+		x[13] ^= bits.RotateLeft32(x[12]+x[15], 9)
+		x[14] ^= bits.RotateLeft32(x[13]+x[12], 13)
+		x[15] ^= bits.RotateLeft32(x[14]+x[13], 18)
+	}
+	for i := range t {
+		t[i] += x[i]
+	}
+}
@@ -0,0 +1,26 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestScryptKey(t *testing.T) {
+	for _, v := range scryptVectors {
+		key, err := ScryptKey([]byte(v.password), []byte(v.salt), v.n, v.r, v.p, len(v.want)/2)
+		if err != nil {
+			t.Fatalf("N=%d r=%d p=%d: %v", v.n, v.r, v.p, err)
+		}
+		if got := hex.EncodeToString(key); got != v.want {
+			t.Errorf("scrypt(%q, %q, N=%d) = %s, want %s", v.password, v.salt, v.n, got, v.want)
+		}
+	}
+
+	// Keystore files carry their own parameters; none of these may reach
+	// the allocation.
+	for _, p := range [][3]int{{0, 8, 1}, {1, 8, 1}, {1000, 8, 1}, {1 << 15, 0, 1}, {1 << 15, 8, 0}, {1 << 22, 8, 1}, {2, 1 << 15, 1 << 15}} {
+		if _, err := ScryptKey([]byte("pw"), []byte("salt"), p[0], p[1], p[2], 32); err == nil {
+			t.Errorf("ScryptKey accepted N=%d r=%d p=%d", p[0], p[1], p[2])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+)
+
+// Known-answer vectors from the SHA-256 (FIPS 180-2), BLAKE3 and scrypt
+// (RFC 7914) specifications.
+var (
+	sha256Vectors = []struct{ input, want string }{
+		{"", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+	}
+	blake3Vectors = []struct{ input, want string }{
+		{"", "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{"abc", "6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85"},
+	}
+	scryptVectors = []struct {
+		password, salt string
+		n, r, p        int
+		want           string
+	}{
+		{"", "", 16, 1, 1, "77d6576238657b203b19ca42c18a0497f16b4844e3074ae8dfdffa3fede21442fcd0069ded0948f8326a753a0fc81f17e8d3e0fb2e0d3628cf35e20c38d18906"},
+		{"password", "NaCl", 1024, 8, 16, "fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+	}
+)
+
+// SelfTest checks the hash functions against published vectors, then signs,
+// verifies and encrypts with a throwaway key. It returns the names of the
+// checks that passed, stopping at the first failure.
+func SelfTest() ([]string, error) {
+	var passed []string
+
+	for _, v := range sha256Vectors {
+		if got := SHA256([]byte(v.input)); got != v.want {
+			return passed, fmt.Errorf("sha256(%q): got %s, want %s", v.input, got, v.want)
+		}
+	}
+	passed = append(passed, "sha256")
+
+	for _, v := range blake3Vectors {
+		if got := Blake3([]byte(v.input)); got != v.want {
+			return passed, fmt.Errorf("blake3(%q): got %s, want %s", v.input, got, v.want)
+		}
+	}
+	passed = append(passed, "blake3")
+
+	for _, v := range scryptVectors {
+		got := hex.EncodeToString(Scrypt([]byte(v.password), []byte(v.salt), v.n, v.r, v.p, len(v.want)/2))
+		if got != v.want {
+			return passed, fmt.Errorf("scrypt(%q, %q, N=%d): got %s, want %s", v.password, v.salt, v.n, got, v.want)
+		}
+	}
+	passed = append(passed, "scrypt")
+
+	priv, err := GenerateKeyPair()
+	if err != nil {
+		return passed, fmt.Errorf("ecdsa key generation: %w", err)
+	}
+	pub := EncodePublicKey(&priv.PublicKey)
+	msg := []byte("self-test")
+	sig, err := SignMessage(priv, msg)
+	if err != nil {
+		return passed, fmt.Errorf("ecdsa sign: %w", err)
+	}
+	if err := CheckSignatureEncoding(sig); err != nil {
+		return passed, fmt.Errorf("ecdsa signature encoding: %w", err)
+	}
+	if ok, err := VerifySignature(msg, sig, pub); !ok || err != nil {
+		return passed, fmt.Errorf("ecdsa signature did not verify: %v", err)
+	}
+	if ok, _ := VerifySignature([]byte("self-tesT"), sig, pub); ok {
+		return passed, fmt.Errorf("ecdsa signature verified over a different message")
+	}
+	passed = append(passed, "ecdsa-p256")
+
+	sealed, err := EncryptECIES(pub, msg)
+	if err != nil {
+		return passed, fmt.Errorf("ecies encrypt: %w", err)
+	}
+	opened, err := DecryptECIES(priv, sealed)
+	if err != nil {
+		return passed, fmt.Errorf("ecies decrypt: %w", err)
+	}
+	if !bytes.Equal(opened, msg) {
+		return passed, fmt.Errorf("ecies round trip: got %q, want %q", opened, msg)
+	}
+	passed = append(passed, "ecies")
+
+	return passed, nil
+}
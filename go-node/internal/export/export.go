@@ -0,0 +1,259 @@
+// Package export writes chain history as flat tables for offline analysis,
+// e.g. training the AI scorer's models in pandas or Spark.
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// Formats and tables accepted by Write.
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+
+	Blocks = "blocks"
+	Txs    = "txs"
+	UTXOs  = "utxos"
+)
+
+var ErrReorganized = errors.New("chain reorganized during export, try again")
+
+const scanBatch = 500
+
+type columnType int
+
+const (
+	typeString columnType = iota
+	typeInt64
+	typeFloat64
+	typeBool
+)
+
+type column struct {
+	name string
+	typ  columnType
+}
+
+var schemas = map[string][]column{
+	Blocks: {
+		{"height", typeInt64},
+		{"hash", typeString},
+		{"prev_hash", typeString},
+		{"timestamp", typeInt64},
+		{"difficulty", typeInt64},
+		{"nonce", typeInt64},
+		{"merkle_root", typeString},
+		{"tx_count", typeInt64},
+		{"total_output", typeFloat64},
+		{"fees", typeFloat64},
+	},
+	Txs: {
+		{"txid", typeString},
+		{"block_height", typeInt64},
+		{"block_hash", typeString},
+		{"timestamp", typeInt64},
+		{"position", typeInt64},
+		{"coinbase", typeBool},
+		{"num_inputs", typeInt64},
+		{"num_outputs", typeInt64},
+		{"total_input", typeFloat64},
+		{"total_output", typeFloat64},
+		{"fee", typeFloat64},
+		{"size", typeInt64},
+	},
+	UTXOs: {
+		{"txid", typeString},
+		{"index", typeInt64},
+		{"address", typeString},
+		{"amount", typeFloat64},
+		{"height", typeInt64},
+	},
+}
+
+// rowWriter is implemented by each output format. Values in a row follow
+// the table's columns: string, int64, float64 or bool.
+type rowWriter interface {
+	writeRow(row []interface{}) error
+	close() error
+}
+
+// Check reports whether table and format name a supported export.
+func Check(table, format string) error {
+	if _, ok := schemas[table]; !ok {
+		return fmt.Errorf("unknown table %q (want %s, %s or %s)", table, Blocks, Txs, UTXOs)
+	}
+	if format != FormatCSV && format != FormatParquet {
+		return fmt.Errorf("unknown format %q (want %s or %s)", format, FormatCSV, FormatParquet)
+	}
+	return nil
+}
+
+// Write exports one table of the main chain in the given format. Blocks
+// are read in batches, so a reorganization part way through is detected
+// and reported as ErrReorganized rather than producing a mixed history.
+func Write(w io.Writer, bc *chain.Blockchain, table, format string) error {
+	if err := Check(table, format); err != nil {
+		return err
+	}
+	cols := schemas[table]
+
+	var out rowWriter
+	if format == FormatParquet {
+		out = newParquetWriter(w, cols)
+	} else {
+		cw, err := newCSVWriter(w, cols)
+		if err != nil {
+			return err
+		}
+		out = cw
+	}
+
+	if err := scan(bc, table, out); err != nil {
+		return err
+	}
+	return out.close()
+}
+
+type outpoint struct {
+	amount float64
+	height int
+}
+
+// scan walks the main chain from genesis, tracking spent and unspent
+// outputs itself so input totals, fees and the final UTXO set all match
+// the blocks that were read.
+func scan(bc *chain.Blockchain, table string, out rowWriter) error {
+	outputs := make(map[chain.UTXOKey]outpoint)
+	unspent := make(map[chain.UTXOKey]chain.TxOut)
+	prevHash := ""
+
+	for height := 0; ; {
+		blocks := bc.BlocksFrom(height, scanBatch)
+		if len(blocks) == 0 {
+			break
+		}
+		for _, block := range blocks {
+			if block.Index != height || (height > 0 && block.PrevHash != prevHash) {
+				return ErrReorganized
+			}
+
+			var blockOutput, blockFees float64
+			for i := range block.Transactions {
+				tx := &block.Transactions[i]
+				var in, outSum float64
+				for _, input := range tx.Inputs {
+					key := chain.UTXOKey{TxID: input.TxID, Index: input.Index}
+					in += outputs[key].amount
+					delete(unspent, key)
+				}
+				for j, o := range tx.Outputs {
+					key := chain.UTXOKey{TxID: tx.ID, Index: j}
+					outSum += o.Amount
+					outputs[key] = outpoint{amount: o.Amount, height: block.Index}
+					unspent[key] = o
+				}
+				fee := 0.0
+				if !tx.IsCoinbase() && len(tx.Inputs) > 0 {
+					fee = chain.RoundAmount(in - outSum)
+				}
+				blockOutput += outSum
+				blockFees += fee
+
+				if table == Txs {
+					size := 0
+					if data, err := tx.MarshalBinary(); err == nil {
+						size = len(data)
+					}
+					row := []interface{}{
+						tx.ID, int64(block.Index), block.Hash, block.Timestamp, int64(i),
+						tx.IsCoinbase(), int64(len(tx.Inputs)), int64(len(tx.Outputs)),
+						chain.RoundAmount(in), chain.RoundAmount(outSum), fee, int64(size),
+					}
+					if err := out.writeRow(row); err != nil {
+						return err
+					}
+				}
+			}
+
+			if table == Blocks {
+				row := []interface{}{
+					int64(block.Index), block.Hash, block.PrevHash, block.Timestamp,
+					int64(block.Difficulty), block.Nonce, block.MerkleRoot,
+					int64(len(block.Transactions)), chain.RoundAmount(blockOutput), chain.RoundAmount(blockFees),
+				}
+				if err := out.writeRow(row); err != nil {
+					return err
+				}
+			}
+			prevHash = block.Hash
+			height++
+		}
+	}
+
+	if table != UTXOs {
+		return nil
+	}
+	keys := make([]chain.UTXOKey, 0, len(unspent))
+	for key := range unspent {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := outputs[keys[i]].height, outputs[keys[j]].height
+		if a != b {
+			return a < b
+		}
+		if keys[i].TxID != keys[j].TxID {
+			return keys[i].TxID < keys[j].TxID
+		}
+		return keys[i].Index < keys[j].Index
+	})
+	for _, key := range keys {
+		o := unspent[key]
+		row := []interface{}{key.TxID, int64(key.Index), o.Address, o.Amount, int64(outputs[key].height)}
+		if err := out.writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer, cols []column) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), record: make([]string, len(cols))}
+	for i, c := range cols {
+		cw.record[i] = c.name
+	}
+	return cw, cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) writeRow(row []interface{}) error {
+	for i, v := range row {
+		switch v := v.(type) {
+		case string:
+			cw.record[i] = v
+		case int64:
+			cw.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			cw.record[i] = chain.FormatAmount(v)
+		case bool:
+			cw.record[i] = strconv.FormatBool(v)
+		}
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,227 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+func columnNames(table string) []string {
+	var names []string
+	for _, c := range schemas[table] {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+func TestWriteCSV(t *testing.T) {
+	bc := chaintest.NewChain(t, strings.Repeat("a", 64), 3)
+	for table, rows := range map[string]int{Blocks: 4, Txs: 4, UTXOs: 4} {
+		var buf bytes.Buffer
+		if err := Write(&buf, bc, table, FormatCSV); err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", table, err)
+		}
+		if got, want := strings.Join(records[0], ","), strings.Join(columnNames(table), ","); got != want {
+			t.Errorf("%s header %s, want %s", table, got, want)
+		}
+		if len(records)-1 != rows {
+			t.Errorf("%s: %d rows, want %d", table, len(records)-1, rows)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, bc, Blocks, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	records, _ := csv.NewReader(&buf).ReadAll()
+	tip := bc.Tip()
+	if last := records[len(records)-1]; last[0] != "3" || last[1] != tip.Hash {
+		t.Errorf("last block row %v, want height 3 and hash %s", last[:2], tip.Hash)
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	bc := chaintest.NewChain(t, strings.Repeat("a", 64), 3)
+	var buf bytes.Buffer
+	if err := Write(&buf, bc, Txs, FormatParquet); err != nil {
+		t.Fatal(err)
+	}
+	meta := parquetFooter(t, buf.Bytes())
+	if got, want := strings.Join(meta.columns, ","), strings.Join(columnNames(Txs), ","); got != want {
+		t.Errorf("columns %s, want %s", got, want)
+	}
+	if meta.rows != 4 || len(meta.groupRows) != 1 || meta.groupRows[0] != 4 {
+		t.Errorf("%d rows in row groups %v, want 4 in one", meta.rows, meta.groupRows)
+	}
+}
+
+// With no rows both formats still write a readable file: a header line, or
+// a Parquet schema with no row groups.
+func TestWriteEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	cw, err := newCSVWriter(&buf, schemas[UTXOs])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), strings.Join(columnNames(UTXOs), ",")+"\n"; got != want {
+		t.Errorf("empty CSV %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	if err := newParquetWriter(&buf, schemas[UTXOs]).close(); err != nil {
+		t.Fatal(err)
+	}
+	meta := parquetFooter(t, buf.Bytes())
+	if got, want := strings.Join(meta.columns, ","), strings.Join(columnNames(UTXOs), ","); got != want {
+		t.Errorf("columns %s, want %s", got, want)
+	}
+	if meta.rows != 0 || len(meta.groupRows) != 0 {
+		t.Errorf("%d rows in row groups %v, want none", meta.rows, meta.groupRows)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	if err := Check("wallets", FormatCSV); err == nil {
+		t.Error("unknown table accepted")
+	}
+	if err := Check(Blocks, "json"); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if err := Write(new(bytes.Buffer), chaintest.NewChain(t, strings.Repeat("a", 64), 0), Blocks, "json"); err == nil {
+		t.Error("Write took an unknown format")
+	}
+}
+
+type parquetMeta struct {
+	columns   []string
+	rows      int64
+	groupRows []int64
+}
+
+// parquetFooter checks the magic at both ends of a Parquet file and reads
+// the column names and row counts from its footer.
+func parquetFooter(t *testing.T, data []byte) parquetMeta {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatal("missing Parquet magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if size > len(data)-12 {
+		t.Fatalf("footer of %d bytes in a %d byte file", size, len(data))
+	}
+	r := &thriftReader{data: data[len(data)-8-size : len(data)-8]}
+	fields := r.readStruct()
+	if r.err != nil {
+		t.Fatalf("footer: %v", r.err)
+	}
+
+	var meta parquetMeta
+	schema, _ := fields[2].([]interface{})
+	for i, element := range schema {
+		name, _ := element.(map[int16]interface{})[4].([]byte)
+		if i > 0 { // the first element is the root
+			meta.columns = append(meta.columns, string(name))
+		}
+	}
+	meta.rows, _ = fields[3].(int64)
+	groups, _ := fields[4].([]interface{})
+	for _, g := range groups {
+		rows, _ := g.(map[int16]interface{})[3].(int64)
+		meta.groupRows = append(meta.groupRows, rows)
+	}
+	return meta
+}
+
+// thriftReader decodes the Thrift compact protocol as thriftWriter writes
+// it: structs as maps by field id, lists as slices, integers as int64 and
+// binary as []byte.
+type thriftReader struct {
+	data []byte
+	err  error
+}
+
+func (r *thriftReader) byte() byte {
+	if len(r.data) == 0 {
+		r.err = errors.New("truncated")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	u := r.uvarint()
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		if n > len(r.data) {
+			r.err = errors.New("truncated")
+			return nil
+		}
+		b := r.data[:n]
+		r.data = r.data[n:]
+		return b
+	case thriftList:
+		head := r.byte()
+		n := int(head >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, 0, n)
+		for i := 0; i < n && r.err == nil; i++ {
+			list = append(list, r.value(head&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.err = errors.New("unsupported thrift type")
+	return nil
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for r.err == nil {
+		head := r.byte()
+		if head == 0 {
+			break
+		}
+		id := last + int16(head>>4)
+		if head>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(head & 0x0f)
+		last = id
+	}
+	return fields
+}
@@ -0,0 +1,310 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// A minimal Apache Parquet writer: every column is REQUIRED, PLAIN encoded
+// and uncompressed, with one data page per column chunk. That is enough for
+// pandas, pyarrow, DuckDB and Spark to read the files without pulling a
+// Parquet library into the node.
+
+const (
+	parquetMagic        = "PAR1"
+	parquetRowGroupRows = 65536
+)
+
+// Parquet physical types, encodings and page types.
+const (
+	ptBoolean   = 0
+	ptInt64     = 2
+	ptDouble    = 5
+	ptByteArray = 6
+
+	encPlain = 0
+	encRLE   = 3
+
+	pageData = 0
+
+	repRequired   = 0
+	convertedUTF8 = 0
+)
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type parquetRowGroup struct {
+	columns []parquetColumnChunk
+	size    int64
+	rows    int64
+}
+
+type parquetWriter struct {
+	w      io.Writer
+	cols   []column
+	offset int64
+	err    error
+
+	// Buffered values of the row group being built, one buffer per column.
+	pages []bytes.Buffer
+	rows  int
+
+	groups    []parquetRowGroup
+	totalRows int64
+}
+
+func newParquetWriter(w io.Writer, cols []column) *parquetWriter {
+	pw := &parquetWriter{
+		w:     w,
+		cols:  cols,
+		pages: make([]bytes.Buffer, len(cols)),
+	}
+	pw.write([]byte(parquetMagic))
+	return pw
+}
+
+func (pw *parquetWriter) write(p []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	pw.err = err
+}
+
+func (pw *parquetWriter) writeRow(row []interface{}) error {
+	var scratch [8]byte
+	for i, v := range row {
+		page := &pw.pages[i]
+		switch v := v.(type) {
+		case string:
+			binary.LittleEndian.PutUint32(scratch[:4], uint32(len(v)))
+			page.Write(scratch[:4])
+			page.WriteString(v)
+		case int64:
+			binary.LittleEndian.PutUint64(scratch[:], uint64(v))
+			page.Write(scratch[:])
+		case float64:
+			binary.LittleEndian.PutUint64(scratch[:], math.Float64bits(v))
+			page.Write(scratch[:])
+		case bool:
+			// Booleans are bit-packed, least significant bit first.
+			if pw.rows%8 == 0 {
+				page.WriteByte(0)
+			}
+			if v {
+				page.Bytes()[page.Len()-1] |= 1 << (pw.rows % 8)
+			}
+		}
+	}
+	pw.rows++
+	if pw.rows == parquetRowGroupRows {
+		pw.flushRowGroup()
+	}
+	return pw.err
+}
+
+// flushRowGroup writes the buffered rows as one row group.
+func (pw *parquetWriter) flushRowGroup() {
+	if pw.rows == 0 {
+		return
+	}
+	group := parquetRowGroup{rows: int64(pw.rows)}
+	for i := range pw.cols {
+		data := pw.pages[i].Bytes()
+
+		var header thriftWriter
+		header.structBegin()
+		header.i32(1, pageData)
+		header.i32(2, int32(len(data)))
+		header.i32(3, int32(len(data)))
+		header.fieldStruct(5)
+		header.i32(1, int32(pw.rows))
+		header.i32(2, encPlain)
+		header.i32(3, encRLE)
+		header.i32(4, encRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := parquetColumnChunk{
+			offset: pw.offset,
+			size:   int64(header.buf.Len() + len(data)),
+			values: int64(pw.rows),
+		}
+		pw.write(header.buf.Bytes())
+		pw.write(data)
+		pw.pages[i].Reset()
+
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.size
+	}
+	pw.groups = append(pw.groups, group)
+	pw.totalRows += int64(pw.rows)
+	pw.rows = 0
+}
+
+func (pw *parquetWriter) close() error {
+	pw.flushRowGroup()
+
+	var meta thriftWriter
+	meta.structBegin()
+	meta.i32(1, 1)
+
+	meta.listBegin(2, thriftStruct, len(pw.cols)+1)
+	meta.structBegin()
+	meta.str(4, "schema")
+	meta.i32(5, int32(len(pw.cols)))
+	meta.structEnd()
+	for _, c := range pw.cols {
+		meta.structBegin()
+		meta.i32(1, parquetType(c.typ))
+		meta.i32(3, repRequired)
+		meta.str(4, c.name)
+		if c.typ == typeString {
+			meta.i32(6, convertedUTF8)
+		}
+		meta.structEnd()
+	}
+
+	meta.i64(3, pw.totalRows)
+
+	meta.listBegin(4, thriftStruct, len(pw.groups))
+	for _, g := range pw.groups {
+		meta.structBegin()
+		meta.listBegin(1, thriftStruct, len(g.columns))
+		for i, chunk := range g.columns {
+			meta.structBegin()
+			meta.i64(2, chunk.offset)
+			meta.fieldStruct(3)
+			meta.i32(1, parquetType(pw.cols[i].typ))
+			meta.listBegin(2, thriftI32, 2)
+			meta.varint(encPlain)
+			meta.varint(encRLE)
+			meta.listBegin(3, thriftBinary, 1)
+			meta.binary(pw.cols[i].name)
+			meta.i32(4, 0) // uncompressed
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64(2, g.size)
+		meta.i64(3, g.rows)
+		meta.structEnd()
+	}
+	meta.str(6, "ai-blockchain go-node")
+	meta.structEnd()
+
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(meta.buf.Len()))
+	pw.write(meta.buf.Bytes())
+	pw.write(length[:])
+	pw.write([]byte(parquetMagic))
+	return pw.err
+}
+
+func parquetType(t columnType) int32 {
+	switch t {
+	case typeInt64:
+		return ptInt64
+	case typeFloat64:
+		return ptDouble
+	case typeBool:
+		return ptBoolean
+	default:
+		return ptByteArray
+	}
+}
+
+// Thrift compact protocol type ids.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol, which Parquet uses for
+// its page headers and footer. Only what those need is supported.
+type thriftWriter struct {
+	buf  bytes.Buffer
+	last []int16 // last field id written, per open struct
+}
+
+func (t *thriftWriter) structBegin() {
+	t.last = append(t.last, 0)
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf.WriteByte(0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	top := len(t.last) - 1
+	if delta := id - t.last[top]; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(int64(id))
+	}
+	t.last[top] = id
+}
+
+// fieldStruct opens a struct-valued field; close it with structEnd.
+func (t *thriftWriter) fieldStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.structBegin()
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) str(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.binary(s)
+}
+
+// listBegin opens a list field of n elements, which follow directly:
+// structBegin/structEnd pairs for structs, varint or binary otherwise.
+func (t *thriftWriter) listBegin(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elem)
+	} else {
+		t.buf.WriteByte(0xf0 | elem)
+		t.uvarint(uint64(n))
+	}
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+// varint writes a zigzag-encoded integer.
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+func (t *thriftWriter) uvarint(v uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], v)
+	t.buf.Write(scratch[:n])
+}
@@ -0,0 +1,181 @@
+package fees
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	// MaxSamples bounds how many confirmed transactions the estimator
+	// remembers.
+	MaxSamples = 1000
+	// MaxTarget is the furthest confirmation target that can be requested.
+	MaxTarget = 100
+	// SuccessRatio is the share of transactions paying at least the
+	// estimated fee that must have confirmed within the target.
+	SuccessRatio = 0.9
+)
+
+type sample struct {
+	fee    float64
+	blocks int
+}
+
+type pending struct {
+	fee    float64
+	height int
+}
+
+// staleAfter is how many blocks a pending entry outlives its transaction
+// leaving the mempool when the eviction notice was missed.
+const staleAfter = MaxTarget
+
+// Estimator learns how long transactions paying a given fee wait in the
+// mempool and uses that to quote a fee for a confirmation target. It tracks
+// each transaction from admission until a block confirms it or the
+// mempool drops it.
+type Estimator struct {
+	blockchain *chain.Blockchain
+	mempool    *chain.Mempool
+
+	mu      sync.Mutex
+	pending map[string]pending
+	samples []sample
+}
+
+type Estimate struct {
+	Target  int     `json:"target_confirmations"`
+	Fee     float64 `json:"fee"`
+	Samples int     `json:"samples"`
+	Size    int     `json:"size,omitempty"` // bytes of binary encoding the floor was computed for
+	Floor   bool    `json:"floor"`          // true when the fee is just the relay minimum
+}
+
+func NewEstimator(blockchain *chain.Blockchain, mempool *chain.Mempool) *Estimator {
+	return &Estimator{
+		blockchain: blockchain,
+		mempool:    mempool,
+		pending:    make(map[string]pending),
+	}
+}
+
+func (e *Estimator) Run(ctx context.Context) {
+	txs, cancelTxs := e.mempool.Subscribe()
+	defer cancelTxs()
+	evicted, cancelEvicted := e.mempool.SubscribeEvictions()
+	defer cancelEvicted()
+	blocks, cancelBlocks := e.blockchain.SubscribeBlocks()
+	defer cancelBlocks()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tx := <-txs:
+			e.observeTransaction(tx)
+		case txID := <-evicted:
+			e.forget(txID)
+		case block := <-blocks:
+			e.observeBlock(block)
+		}
+	}
+}
+
+func (e *Estimator) observeTransaction(tx *chain.Transaction) {
+	fee, err := chain.ComputeFee(tx, e.mempool.InputView(tx, e.blockchain.Output))
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.pending[tx.ID] = pending{fee: fee, height: e.blockchain.Height()}
+}
+
+// forget drops a transaction that left the mempool without confirming; how
+// long it waited says nothing about its fee.
+func (e *Estimator) forget(txID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.pending, txID)
+}
+
+func (e *Estimator) observeBlock(block *chain.Block) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, tx := range block.Transactions {
+		p, ok := e.pending[tx.ID]
+		if !ok {
+			continue
+		}
+		delete(e.pending, tx.ID)
+		e.samples = append(e.samples, sample{fee: p.fee, blocks: block.Index - p.height + 1})
+	}
+	if len(e.samples) > MaxSamples {
+		e.samples = e.samples[len(e.samples)-MaxSamples:]
+	}
+
+	// Eviction notices are dropped when the estimator falls behind; long
+	// pending entries whose transaction is gone are swept up here.
+	for id, p := range e.pending {
+		if block.Index-p.height > staleAfter && !e.mempool.Has(id) {
+			delete(e.pending, id)
+		}
+	}
+}
+
+// EstimateFee returns the lowest fee for which at least SuccessRatio of past
+// transactions paying that much or more confirmed within target blocks. It
+// never quotes below the mempool's minimum relay fee for a transaction of
+// size bytes of binary encoding (0 = size unknown, only the absolute
+// minimum applies).
+func (e *Estimator) EstimateFee(target, size int) Estimate {
+	if target < 1 {
+		target = 1
+	}
+	if target > MaxTarget {
+		target = MaxTarget
+	}
+
+	floor := e.mempool.Policy().MinFee(size)
+	result := Estimate{Target: target, Fee: floor, Size: size, Floor: true}
+
+	e.mu.Lock()
+	samples := make([]sample, len(e.samples))
+	copy(samples, e.samples)
+	e.mu.Unlock()
+
+	result.Samples = len(samples)
+	if len(samples) == 0 {
+		return result
+	}
+
+	// Walk from the highest fee down, keeping the running success ratio of
+	// everything paying at least the current fee.
+	sort.Slice(samples, func(i, j int) bool { return samples[i].fee > samples[j].fee })
+
+	best := -1.0
+	confirmed := 0
+	for i, s := range samples {
+		if s.blocks <= target {
+			confirmed++
+		}
+		if float64(confirmed)/float64(i+1) >= SuccessRatio {
+			best = s.fee
+		}
+	}
+
+	if best < 0 {
+		// Nothing in the history confirmed quickly enough; outbid all of it.
+		best = samples[0].fee
+	}
+	if best > floor {
+		result.Fee = best
+		result.Floor = false
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package fees
+
+import (
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+func (e *Estimator) pendingCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.pending)
+}
+
+// An entry whose eviction notice was missed is swept once it is old enough
+// and its transaction is gone from the pool; one still pending stays.
+func TestStalePendingEntriesAreSwept(t *testing.T) {
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.AddressFromPublicKey(&key.PublicKey)
+	bc := chaintest.NewChain(t, address, 0)
+	coinbase := bc.Genesis().Transactions[0]
+	mempool := chain.NewMempool()
+	estimator := NewEstimator(bc, mempool)
+
+	spend := func(amount float64) *chain.Transaction {
+		t.Helper()
+		tx, err := chain.NewTransaction([]chain.TxIn{{TxID: coinbase.ID, Index: 0}},
+			[]chain.TxOut{{Address: strings.Repeat("b", 64), Amount: amount}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tx
+	}
+	gone, kept := spend(49), spend(48)
+	for _, tx := range []*chain.Transaction{gone, kept} {
+		estimator.observeTransaction(tx)
+	}
+	if err := mempool.AddTransaction(kept, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	estimator.observeBlock(&chain.Block{Index: staleAfter})
+	if n := estimator.pendingCount(); n != 2 {
+		t.Fatalf("%d entries tracked before they went stale, want 2", n)
+	}
+	estimator.observeBlock(&chain.Block{Index: staleAfter + 2})
+	if n := estimator.pendingCount(); n != 1 {
+		t.Fatalf("%d entries tracked after the sweep, want 1", n)
+	}
+}
+
+// With no history the estimate is the relay floor, which grows with the
+// transaction under a minimum fee rate.
+func TestEstimateCoversRelayFeeRate(t *testing.T) {
+	policy := chain.DefaultMempoolPolicy()
+	policy.MinRelayFee = 0.0001
+	policy.MinRelayFeeRate = 0.001
+	estimator := NewEstimator(chaintest.NewChain(t, strings.Repeat("a", 64), 0),
+		chain.NewMempoolWithPolicy(policy))
+
+	for _, tc := range []struct {
+		size int
+		fee  float64
+	}{
+		{0, 0.0001},
+		{50, 0.0001},
+		{250, 0.00025},
+		{333, 0.00033300},
+		{1001, 0.001001},
+	} {
+		got := estimator.EstimateFee(1, tc.size)
+		if got.Fee != tc.fee || !got.Floor {
+			t.Errorf("size %d: fee %s (floor %v), want the floor %s",
+				tc.size, chain.FormatAmount(got.Fee), got.Floor, chain.FormatAmount(tc.fee))
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package fixtures
+
+import (
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+//go:generate go run gen.go
+
+//go:embed golden.json
+var goldenJSON []byte
+
+// GoldenPath is the location of the vector file relative to the repository
+// root, for the Java wallet and Python scorer to read directly.
+const GoldenPath = "go-node/internal/fixtures/golden.json"
+
+type Vectors struct {
+	Version     int            `json:"version"`
+	Chains      []ChainVectors `json:"chains"`
+	Hashes      []HashVector   `json:"hashes"`
+	MerkleRoots []MerkleVector `json:"merkle_roots"`
+
+	Malleability []MalleabilityVector `json:"malleability"`
+}
+
+type ChainVectors struct {
+	Name         string        `json:"name"`
+	Transactions []TxVector    `json:"transactions"`
+	Blocks       []BlockVector `json:"blocks"`
+}
+
+type TxVector struct {
+	Name           string            `json:"name"`
+	Transaction    chain.Transaction `json:"transaction"`
+	CanonicalHex   string            `json:"canonical_hex"`
+	TxID           string            `json:"txid"`
+	SignatureValid bool              `json:"signature_valid"`
+}
+
+type BlockVector struct {
+	Name       string      `json:"name"`
+	Block      chain.Block `json:"block"`
+	MerkleRoot string      `json:"merkle_root"`
+	Hash       string      `json:"hash"`
+}
+
+type HashVector struct {
+	InputHex string `json:"input_hex"`
+	SHA256   string `json:"sha256"`
+}
+
+type MerkleVector struct {
+	Leaves []string `json:"leaves"`
+	Root   string   `json:"root"`
+}
+
+// MalleabilityVector holds, as a string, the exact JSON of a signed
+// transaction as a third party might relay it after altering it. Every vector keeps the original txid and a
+// valid signature; Rejected says whether nodes must refuse it. Whitespace
+// and key order are harmless because nodes never hash the JSON they receive.
+type MalleabilityVector struct {
+	Name        string `json:"name"`
+	TxID        string `json:"txid"`
+	Transaction string `json:"transaction"`
+	Rejected    bool   `json:"rejected"`
+}
+
+func Raw() []byte {
+	out := make([]byte, len(goldenJSON))
+	copy(out, goldenJSON)
+	return out
+}
+
+func Load() (*Vectors, error) {
+	var v Vectors
+	if err := json.Unmarshal(goldenJSON, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode golden vectors: %w", err)
+	}
+	return &v, nil
+}
+
+func MustLoad() *Vectors {
+	v, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (v *Vectors) Chain(name string) (*ChainVectors, error) {
+	for i := range v.Chains {
+		if v.Chains[i].Name == name {
+			return &v.Chains[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no vectors for chain %q", name)
+}
+
+func (c *ChainVectors) Transaction(name string) (*TxVector, error) {
+	for i := range c.Transactions {
+		if c.Transactions[i].Name == name {
+			return &c.Transactions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no transaction vector %q", name)
+}
+
+func (c *ChainVectors) Block(name string) (*BlockVector, error) {
+	for i := range c.Blocks {
+		if c.Blocks[i].Name == name {
+			return &c.Blocks[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no block vector %q", name)
+}
+
+// Check recomputes every vector with the current node code and reports the
+// first mismatch, so a serialization change can't silently drift from the
+// vectors other implementations are tested against.
+func (v *Vectors) Check() error {
+	for _, h := range v.Hashes {
+		data, err := hex.DecodeString(h.InputHex)
+		if err != nil {
+			return err
+		}
+		if got := crypto.SHA256(data); got != h.SHA256 {
+			return fmt.Errorf("sha256(%s): got %s, want %s", h.InputHex, got, h.SHA256)
+		}
+	}
+
+	for _, m := range v.MerkleRoots {
+		if got := crypto.MerkleRoot(m.Leaves); got != m.Root {
+			return fmt.Errorf("merkle root of %d leaves: got %s, want %s", len(m.Leaves), got, m.Root)
+		}
+	}
+
+	for _, mv := range v.Malleability {
+		if err := mv.Check(); err != nil {
+			return fmt.Errorf("malleability/%s: %w", mv.Name, err)
+		}
+	}
+
+	for _, c := range v.Chains {
+		for _, tv := range c.Transactions {
+			if err := tv.Check(); err != nil {
+				return fmt.Errorf("%s/%s: %w", c.Name, tv.Name, err)
+			}
+		}
+		for _, bv := range c.Blocks {
+			if err := bv.Check(); err != nil {
+				return fmt.Errorf("%s/%s: %w", c.Name, bv.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (tv *TxVector) Check() error {
+	canonical, err := chain.CanonicalTxBytes(&tv.Transaction)
+	if err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(canonical); got != tv.CanonicalHex {
+		return fmt.Errorf("canonical bytes mismatch: got %s, want %s", got, tv.CanonicalHex)
+	}
+
+	txid, err := chain.ComputeTxID(&tv.Transaction)
+	if err != nil {
+		return err
+	}
+	if txid != tv.TxID {
+		return fmt.Errorf("txid mismatch: got %s, want %s", txid, tv.TxID)
+	}
+
+	if tv.Transaction.Signature == "" {
+		return nil
+	}
+	ok, err := crypto.VerifySignature(canonical, tv.Transaction.Signature, tv.Transaction.PubKey)
+	if err != nil && tv.SignatureValid {
+		return fmt.Errorf("signature check failed: %w", err)
+	}
+	if ok != tv.SignatureValid {
+		return errors.New("signature validity does not match vector")
+	}
+	return nil
+}
+
+func (mv *MalleabilityVector) Check() error {
+	var tx chain.Transaction
+	if err := json.Unmarshal([]byte(mv.Transaction), &tx); err != nil {
+		return err
+	}
+
+	txid, err := chain.ComputeTxID(&tx)
+	if err != nil {
+		return err
+	}
+	if txid != mv.TxID {
+		return fmt.Errorf("txid changed: got %s, want %s", txid, mv.TxID)
+	}
+
+	canonical, err := chain.CanonicalTxBytes(&tx)
+	if err != nil {
+		return err
+	}
+	if ok, _ := crypto.VerifySignature(canonical, tx.Signature, tx.PubKey); !ok {
+		return errors.New("mutation no longer carries a valid signature")
+	}
+
+	err = chain.CheckCanonicalForm(&tx)
+	if mv.Rejected && err == nil {
+		return errors.New("malleated transaction accepted")
+	}
+	if !mv.Rejected && err != nil {
+		return fmt.Errorf("harmless mutation rejected: %w", err)
+	}
+	return nil
+}
+
+func (bv *BlockVector) Check() error {
+	ids := make([]string, len(bv.Block.Transactions))
+	for i, tx := range bv.Block.Transactions {
+		ids[i] = tx.ID
+	}
+	if got := crypto.MerkleRoot(ids); got != bv.MerkleRoot {
+		return fmt.Errorf("merkle root mismatch: got %s, want %s", got, bv.MerkleRoot)
+	}
+	if got := bv.Block.ComputeHash(); got != bv.Hash {
+		return fmt.Errorf("block hash mismatch: got %s, want %s", got, bv.Hash)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package fixtures
+
+import "testing"
+
+// TestGoldenVectors fails when node code stops reproducing golden.json. If
+// the change is intended, regenerate the file with go generate and update
+// the Java and Python implementations to match.
+func TestGoldenVectors(t *testing.T) {
+	v, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckReportsDrift(t *testing.T) {
+	v, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &v.Chains[len(v.Chains)-1]
+	c.Transactions[0].TxID = c.Transactions[1].TxID
+	if err := v.Check(); err == nil {
+		t.Fatal("Check passed a vector with the wrong txid")
+	}
+}
@@ -0,0 +1,270 @@
+//go:build ignore
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/fixtures"
+)
+
+func fixedKey(label string) *ecdsa.PrivateKey {
+	seed := sha256.Sum256([]byte(label))
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(seed[:])
+	d.Mod(d, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	d.Add(d, big.NewInt(1))
+
+	priv := &ecdsa.PrivateKey{D: d}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+	return priv
+}
+
+// previousSignatures maps the canonical hex and public key of every signed
+// transaction in the golden.json being replaced to its signature. ECDSA
+// signatures are randomized; reusing them while the signed bytes stay the
+// same keeps go generate from rewriting every vector.
+func previousSignatures() map[string]string {
+	vectors, err := fixtures.Load()
+	if err != nil {
+		return nil
+	}
+	signatures := make(map[string]string)
+	for _, c := range vectors.Chains {
+		for _, tv := range c.Transactions {
+			if tv.SignatureValid {
+				signatures[tv.CanonicalHex+"/"+tv.Transaction.PubKey] = tv.Transaction.Signature
+			}
+		}
+	}
+	return signatures
+}
+
+var previous = previousSignatures()
+
+func address(pub *ecdsa.PublicKey) string {
+	return crypto.AddressFromPublicKey(pub)
+}
+
+func txVector(name string, tx chain.Transaction, key *ecdsa.PrivateKey) fixtures.TxVector {
+	id, err := chain.ComputeTxID(&tx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tx.ID = id
+
+	canonical, err := chain.CanonicalTxBytes(&tx)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	valid := false
+	if key != nil {
+		tx.PubKey = crypto.EncodePublicKey(&key.PublicKey)
+		if sig, ok := previous[hex.EncodeToString(canonical)+"/"+tx.PubKey]; ok {
+			tx.Signature = sig
+		} else if tx.Signature, err = crypto.SignMessage(key, canonical); err != nil {
+			log.Fatal(err)
+		}
+		valid = true
+	} else {
+		tx.Signature = "genesis"
+		tx.PubKey = "genesis"
+	}
+
+	return fixtures.TxVector{
+		Name:           name,
+		Transaction:    tx,
+		CanonicalHex:   hex.EncodeToString(canonical),
+		TxID:           id,
+		SignatureValid: valid,
+	}
+}
+
+func blockVector(name string, index int, timestamp int64, prevHash string, txs []chain.Transaction) fixtures.BlockVector {
+	ids := make([]string, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID
+	}
+	block := chain.Block{
+		Index:        index,
+		Timestamp:    timestamp,
+		PrevHash:     prevHash,
+		MerkleRoot:   crypto.MerkleRoot(ids),
+		Transactions: txs,
+	}
+	block.Hash = block.ComputeHash()
+
+	return fixtures.BlockVector{
+		Name:       name,
+		Block:      block,
+		MerkleRoot: block.MerkleRoot,
+		Hash:       block.Hash,
+	}
+}
+
+func canonicalOutputs(outputs ...chain.TxOut) []chain.TxOut {
+	sort.Slice(outputs, func(i, j int) bool { return outputs[i].Address < outputs[j].Address })
+	return outputs
+}
+
+// malleabilityVectors alters the relayed JSON of canonical signed
+// transactions in ways that keep their txid and signature valid.
+func malleabilityVectors(spend, unsortedInputs chain.Transaction) []fixtures.MalleabilityVector {
+	vector := func(name string, tx chain.Transaction, rejected bool, change func(tx *chain.Transaction), edit func(raw string) string) fixtures.MalleabilityVector {
+		tx.Inputs = append([]chain.TxIn(nil), tx.Inputs...)
+		tx.Outputs = append([]chain.TxOut(nil), tx.Outputs...)
+		if change != nil {
+			change(&tx)
+		}
+		raw, err := json.Marshal(tx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if edit != nil {
+			raw = []byte(edit(string(raw)))
+		}
+		return fixtures.MalleabilityVector{Name: name, TxID: tx.ID, Transaction: string(raw), Rejected: rejected}
+	}
+
+	sig, _ := hex.DecodeString(spend.Signature)
+	n := elliptic.P256().Params().N
+	r := sig[:32]
+	s := new(big.Int).SetBytes(sig[32:])
+	firstAmount := chain.FormatAmount(spend.Outputs[0].Amount)
+
+	return []fixtures.MalleabilityVector{
+		vector("whitespace", spend, false, nil, func(raw string) string {
+			return strings.NewReplacer(`{`, "{\n  ", `,"`, ",\n  \"", `:`, ": ").Replace(raw)
+		}),
+		vector("keys-reordered", spend, false, nil, func(raw string) string {
+			var fields map[string]json.RawMessage
+			json.Unmarshal([]byte(raw), &fields)
+			keys := []string{"timestamp", "pubkey", "signature", "outputs", "inputs", "id"}
+			parts := make([]string, len(keys))
+			for i, k := range keys {
+				parts[i] = fmt.Sprintf("%q:%s", k, fields[k])
+			}
+			return "{" + strings.Join(parts, ",") + "}"
+		}),
+		vector("timestamp-changed", spend, false, func(tx *chain.Transaction) {
+			tx.Timestamp++
+		}, nil),
+		vector("outputs-reordered", spend, true, func(tx *chain.Transaction) {
+			tx.Outputs[0], tx.Outputs[1] = tx.Outputs[1], tx.Outputs[0]
+		}, nil),
+		vector("inputs-reordered", unsortedInputs, true, nil, nil),
+		vector("amount-below-precision", spend, true, nil, func(raw string) string {
+			return strings.Replace(raw, firstAmount, firstAmount+"1", 1)
+		}),
+		vector("high-s-signature", spend, true, func(tx *chain.Transaction) {
+			highS := new(big.Int).Sub(n, s).FillBytes(make([]byte, 32))
+			tx.Signature = hex.EncodeToString(append(append([]byte(nil), r...), highS...))
+		}, nil),
+		vector("zero-padded-signature", spend, true, func(tx *chain.Transaction) {
+			padded := append(append([]byte{0}, r...), append([]byte{0}, sig[32:]...)...)
+			tx.Signature = hex.EncodeToString(padded)
+		}, nil),
+		vector("uppercase-signature", spend, true, func(tx *chain.Transaction) {
+			tx.Signature = strings.ToUpper(tx.Signature)
+		}, nil),
+		vector("uppercase-pubkey", spend, true, func(tx *chain.Transaction) {
+			tx.PubKey = strings.ToUpper(tx.PubKey)
+		}, nil),
+		vector("zero-padded-pubkey", spend, true, func(tx *chain.Transaction) {
+			half := len(tx.PubKey) / 2
+			tx.PubKey = "00" + tx.PubKey[:half] + "00" + tx.PubKey[half:]
+		}, nil),
+	}
+}
+
+func main() {
+	alice := fixedKey("golden/alice")
+	bob := fixedKey("golden/bob")
+	aliceAddr := address(&alice.PublicKey)
+	bobAddr := address(&bob.PublicKey)
+
+	genesisTx := txVector("genesis", chain.Transaction{
+		Inputs:    []chain.TxIn{},
+		Outputs:   []chain.TxOut{{Address: aliceAddr, Amount: 1000}},
+		Timestamp: 1700000000,
+	}, nil)
+
+	spendTx := txVector("spend-with-change", chain.Transaction{
+		Inputs: []chain.TxIn{{TxID: genesisTx.TxID, Index: 0}},
+		Outputs: canonicalOutputs(
+			chain.TxOut{Address: bobAddr, Amount: 250.5},
+			chain.TxOut{Address: aliceAddr, Amount: 749.25},
+		),
+		Timestamp: 1700000060,
+	}, alice)
+
+	multiInputTx := txVector("multi-input-unsorted", chain.Transaction{
+		Inputs: []chain.TxIn{
+			{TxID: spendTx.TxID, Index: 1},
+			{TxID: spendTx.TxID, Index: 0},
+		},
+		Outputs:   []chain.TxOut{{Address: bobAddr, Amount: 0.1}},
+		Timestamp: 1700000120,
+	}, alice)
+
+	genesisBlock := blockVector("genesis", 0, 1700000000, "0", []chain.Transaction{genesisTx.Transaction})
+	spendBlock := blockVector("spend", 1, 1700000100, genesisBlock.Hash, []chain.Transaction{spendTx.Transaction})
+
+	vectors := fixtures.Vectors{
+		Version: 1,
+		Chains: []fixtures.ChainVectors{
+			{
+				Name:         "genesis-only",
+				Transactions: []fixtures.TxVector{genesisTx},
+				Blocks:       []fixtures.BlockVector{genesisBlock},
+			},
+			{
+				Name:         "two-block",
+				Transactions: []fixtures.TxVector{genesisTx, spendTx, multiInputTx},
+				Blocks:       []fixtures.BlockVector{genesisBlock, spendBlock},
+			},
+		},
+	}
+
+	for _, input := range [][]byte{{}, []byte("abc"), []byte(`{"inputs":[],"outputs":[]}`)} {
+		vectors.Hashes = append(vectors.Hashes, fixtures.HashVector{
+			InputHex: hex.EncodeToString(input),
+			SHA256:   crypto.SHA256(input),
+		})
+	}
+
+	for _, leaves := range [][]string{
+		{genesisTx.TxID},
+		{genesisTx.TxID, spendTx.TxID},
+		{genesisTx.TxID, spendTx.TxID, multiInputTx.TxID},
+	} {
+		vectors.MerkleRoots = append(vectors.MerkleRoots, fixtures.MerkleVector{
+			Leaves: leaves,
+			Root:   crypto.MerkleRoot(leaves),
+		})
+	}
+
+	vectors.Malleability = malleabilityVectors(spendTx.Transaction, multiInputTx.Transaction)
+
+	data, err := json.MarshalIndent(vectors, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("golden.json", append(data, '\n'), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,234 @@
+package follower
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/quarantine"
+)
+
+// Follower keeps a read replica in step with a primary node by polling its
+// REST API and applying any blocks past the local tip.
+type Follower struct {
+	primary    string
+	blockchain *chain.Blockchain
+	blocks     *chain.BlockPipeline
+	client     *http.Client
+	interval   time.Duration
+	quarantine *quarantine.Store
+
+	mu         sync.RWMutex
+	difficulty int
+	lastSync   time.Time
+	lastError  string
+	diverged   bool
+}
+
+type Status struct {
+	Primary    string `json:"primary"`
+	Difficulty int    `json:"difficulty"`
+	LastSync   int64  `json:"last_sync"`
+	LastError  string `json:"last_error,omitempty"`
+	Diverged   bool   `json:"utxo_diverged"` // UTXO set hash differed from the primary's at the same tip
+}
+
+type blocksResponse struct {
+	Blocks []*chain.Block `json:"blocks"`
+}
+
+func newClient() *http.Client {
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+func getJSON(client *http.Client, url string, out interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// FetchGenesis returns the primary's genesis block so the replica starts from
+// the same chain rather than minting its own.
+func FetchGenesis(primary string) (*chain.Block, error) {
+	var resp blocksResponse
+	if err := getJSON(newClient(), strings.TrimRight(primary, "/")+"/blocks?limit=1", &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Blocks) == 0 || resp.Blocks[0].Index != 0 {
+		return nil, errors.New("primary returned no genesis block")
+	}
+	genesis := resp.Blocks[0]
+	if genesis.ComputeHash() != genesis.Hash {
+		return nil, errors.New("primary genesis block hash does not match its contents")
+	}
+	return genesis, nil
+}
+
+// Params are the consensus parameters a replica adopts from its primary. The
+// difficulty of each block is then derived and checked locally.
+type Params struct {
+	InitialDifficulty int     `json:"initial_difficulty"`
+	RetargetInterval  int     `json:"retarget_interval"`
+	TargetBlockTime   int64   `json:"target_block_time"`
+	BlockReward       float64 `json:"block_reward"`
+}
+
+func FetchParams(primary string) (*Params, error) {
+	var params Params
+	if err := getJSON(newClient(), strings.TrimRight(primary, "/")+"/params", &params); err != nil {
+		return nil, err
+	}
+	if params.InitialDifficulty < 1 {
+		return nil, errors.New("primary did not report its initial difficulty")
+	}
+	return &params, nil
+}
+
+func New(primary string, blockchain *chain.Blockchain, blocks *chain.BlockPipeline, interval time.Duration) *Follower {
+	return &Follower{
+		primary:    strings.TrimRight(primary, "/"),
+		blockchain: blockchain,
+		blocks:     blocks,
+		client:     newClient(),
+		interval:   interval,
+	}
+}
+
+// SetQuarantine keeps a copy of every block from the primary that fails
+// validation.
+func (f *Follower) SetQuarantine(q *quarantine.Store) {
+	f.quarantine = q
+}
+
+func (f *Follower) Primary() string {
+	return f.primary
+}
+
+func (f *Follower) Status() Status {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return Status{
+		Primary:    f.primary,
+		Difficulty: f.difficulty,
+		LastSync:   f.lastSync.Unix(),
+		LastError:  f.lastError,
+		Diverged:   f.diverged,
+	}
+}
+
+func (f *Follower) Run(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	for {
+		applied, err := f.SyncOnce()
+
+		f.mu.Lock()
+		if err != nil {
+			f.lastError = err.Error()
+			slog.Warn("Follower sync failed", "primary", f.primary, "err", err)
+		} else {
+			f.lastError = ""
+			f.lastSync = time.Now()
+			if applied > 0 {
+				slog.Info("Follower applied blocks", "blocks", applied, "primary", f.primary, "height", f.blockchain.Height())
+			}
+		}
+		f.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SyncOnce fetches the primary's chain and processes every block the replica
+// hasn't seen, following the primary through reorganizations. It stops at the
+// first block that fails validation.
+func (f *Follower) SyncOnce() (int, error) {
+	var chainInfo struct {
+		Height     int `json:"height"`
+		Difficulty int `json:"difficulty"`
+		Tip        struct {
+			Hash string `json:"hash"`
+		} `json:"tip"`
+	}
+	if err := getJSON(f.client, f.primary+"/chain", &chainInfo); err != nil {
+		return 0, err
+	}
+
+	f.mu.Lock()
+	f.difficulty = chainInfo.Difficulty
+	f.mu.Unlock()
+
+	if chainInfo.Tip.Hash == f.blockchain.Tip().Hash {
+		f.compareState()
+		return 0, nil
+	}
+
+	var resp blocksResponse
+	if err := getJSON(f.client, f.primary+"/blocks", &resp); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, block := range resp.Blocks {
+		_, err := f.blocks.ProcessBlock(block)
+		if errors.Is(err, chain.ErrDuplicateBlock) {
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, chain.ErrOrphanBlock) {
+				f.quarantine.Record(quarantine.KindBlock, block.Hash, block, err.Error(), "follower:"+f.primary)
+			}
+			return applied, fmt.Errorf("block %d rejected: %w", block.Index, err)
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// compareState checks the replica's UTXO set hash against the primary's.
+// Both are read together with their tip, so a primary that moved on in
+// between is skipped rather than reported. Primaries without /stats are
+// ignored.
+func (f *Follower) compareState() {
+	var primary chain.UTXOStats
+	if err := getJSON(f.client, f.primary+"/stats", &primary); err != nil || primary.UTXOHash == "" {
+		return
+	}
+	local := f.blockchain.UTXOStats()
+	if primary.TipHash != local.TipHash {
+		return
+	}
+
+	diverged := primary.UTXOHash != local.UTXOHash
+	f.mu.Lock()
+	changed := diverged != f.diverged
+	f.diverged = diverged
+	f.mu.Unlock()
+
+	if changed && diverged {
+		slog.Warn("Follower UTXO set diverged from primary", "primary", f.primary, "tip", local.TipHash,
+			"utxo_hash", local.UTXOHash, "primary_utxo_hash", primary.UTXOHash)
+	} else if changed {
+		slog.Info("Follower UTXO set matches primary again", "primary", f.primary)
+	}
+}
@@ -0,0 +1,490 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// Schema is a read-only GraphQL schema. Only the query root is supported;
+// mutations and subscriptions are rejected at execution time.
+type Schema struct {
+	Query *Object
+	// MaxDepth bounds selection-set nesting; 0 means unlimited.
+	MaxDepth int
+	// MaxFields bounds the number of fields a single request may resolve,
+	// which keeps nested list queries from fanning out without limit.
+	MaxFields int
+}
+
+// Object is a GraphQL object type.
+type Object struct {
+	Name   string
+	Fields map[string]*FieldDef
+}
+
+// FieldDef describes one field of an object type. Type is the object type
+// of the result (or of each element when the resolver returns a slice);
+// it is nil for scalar fields.
+type FieldDef struct {
+	Type    *Object
+	Args    []string
+	Resolve func(p Params) (interface{}, error)
+}
+
+// Params is passed to resolvers.
+type Params struct {
+	Context context.Context
+	Source  interface{}
+	Args    map[string]interface{}
+}
+
+// Int returns an integer argument, or def when it is absent or null.
+func (p Params) Int(name string, def int) (int, error) {
+	switch v := p.Args[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt32 {
+			return 0, fmt.Errorf("argument %q must be an integer", name)
+		}
+		return int(v), nil
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// String returns a string argument and whether it was supplied.
+func (p Params) String(name string) (string, bool, error) {
+	switch v := p.Args[name].(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	}
+	return "", false, fmt.Errorf("argument %q must be a string", name)
+}
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response. Data is absent when the request failed
+// before execution started.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error with the response path it occurred at.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Execute parses and runs req against the schema.
+func Execute(ctx context.Context, schema *Schema, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	if op.Type != "query" {
+		return &Response{Errors: []*Error{{Message: fmt.Sprintf("%s operations are not supported", op.Type)}}}
+	}
+	vars, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	ex := &executor{ctx: ctx, schema: schema, doc: doc, vars: vars, types: schemaTypes(schema.Query), validated: make(map[string]int)}
+	if err := ex.validate(schema.Query, op.Selections, 1, make(map[string]bool)); err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	data := ex.selectionSet(schema.Query, nil, op.Selections, nil)
+	if ex.aborted {
+		data = nil
+	}
+	return &Response{Data: data, Errors: ex.errors}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func coerceVariables(op *Operation, given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(op.Variables))
+	for _, def := range op.Variables {
+		v, ok := given[def.Name]
+		if !ok || v == nil {
+			if def.Default != nil {
+				vars[def.Name] = literal(def.Default, nil)
+				continue
+			}
+			if def.Required {
+				return nil, fmt.Errorf("variable $%s of type %s is required", def.Name, def.Type)
+			}
+			continue
+		}
+		vars[def.Name] = v
+	}
+	return vars, nil
+}
+
+// literal converts a parsed Value into the plain Go value resolvers see,
+// substituting variables.
+func literal(v Value, vars map[string]interface{}) interface{} {
+	switch v := v.(type) {
+	case Variable:
+		return vars[string(v)]
+	case Enum:
+		return string(v)
+	case []Value:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = literal(e, vars)
+		}
+		return out
+	case map[string]Value:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = literal(e, vars)
+		}
+		return out
+	}
+	return v
+}
+
+type executor struct {
+	ctx     context.Context
+	schema  *Schema
+	doc     *Document
+	vars    map[string]interface{}
+	types   map[string]*Object
+	errors  []*Error
+	fields  int
+	aborted bool
+
+	// validated is the deepest depth each fragment has passed validation
+	// at; a spread no deeper passes too, so it is not walked again.
+	validated map[string]int
+}
+
+func (ex *executor) fail(path []interface{}, format string, args ...interface{}) {
+	p := make([]interface{}, len(path))
+	copy(p, path)
+	ex.errors = append(ex.errors, &Error{Message: fmt.Sprintf(format, args...), Path: p})
+}
+
+// validate checks the selections against the schema before anything is
+// resolved, so a bad field is reported once rather than for every element
+// of a list, and the depth limit holds however fragments are nested. Each
+// fragment is walked once per depth it is spread at, not once per spread.
+func (ex *executor) validate(obj *Object, sels []Selection, depth int, spreading map[string]bool) error {
+	if err := ex.ctx.Err(); err != nil {
+		return err
+	}
+	if ex.schema.MaxDepth > 0 && depth > ex.schema.MaxDepth {
+		return fmt.Errorf("query exceeds the maximum depth of %d", ex.schema.MaxDepth)
+	}
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *Field:
+			if sel.Name == "__typename" {
+				if len(sel.Selections) > 0 {
+					return fmt.Errorf("field \"__typename\" is a scalar and takes no selection")
+				}
+				continue
+			}
+			def, ok := obj.Fields[sel.Name]
+			if !ok {
+				return fmt.Errorf("cannot query field %q on type %q (line %d)", sel.Name, obj.Name, sel.Line)
+			}
+			for name := range sel.Arguments {
+				if !contains(def.Args, name) {
+					return fmt.Errorf("unknown argument %q on field %q (line %d)", name, sel.Name, sel.Line)
+				}
+			}
+			if def.Type == nil && len(sel.Selections) > 0 {
+				return fmt.Errorf("field %q is a scalar and takes no selection (line %d)", sel.Name, sel.Line)
+			}
+			if def.Type != nil {
+				if len(sel.Selections) == 0 {
+					return fmt.Errorf("field %q of type %q must have a selection (line %d)", sel.Name, def.Type.Name, sel.Line)
+				}
+				if err := ex.validate(def.Type, sel.Selections, depth+1, spreading); err != nil {
+					return err
+				}
+			}
+		case *FragmentSpread:
+			frag, ok := ex.doc.Fragments[sel.Name]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", sel.Name)
+			}
+			if spreading[sel.Name] {
+				return fmt.Errorf("fragment %q spreads itself", sel.Name)
+			}
+			target, ok := ex.types[frag.TypeCondition]
+			if !ok {
+				return fmt.Errorf("fragment %q is on unknown type %q", frag.Name, frag.TypeCondition)
+			}
+			if target != obj {
+				return fmt.Errorf("fragment %q on %q cannot be spread within %q", frag.Name, frag.TypeCondition, obj.Name)
+			}
+			// Only the depth limit depends on where a fragment is spread.
+			if deepest, ok := ex.validated[sel.Name]; ok && (depth <= deepest || ex.schema.MaxDepth == 0) {
+				continue
+			}
+			spreading[sel.Name] = true
+			err := ex.validate(obj, frag.Selections, depth, spreading)
+			delete(spreading, sel.Name)
+			if err != nil {
+				return err
+			}
+			ex.validated[sel.Name] = depth
+		case *InlineFragment:
+			if sel.TypeCondition != "" && ex.types[sel.TypeCondition] != obj {
+				return fmt.Errorf("inline fragment on %q cannot be used within %q", sel.TypeCondition, obj.Name)
+			}
+			if err := ex.validate(obj, sel.Selections, depth, spreading); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectionSet resolves the selections of one object value.
+func (ex *executor) selectionSet(obj *Object, source interface{}, sels []Selection, path []interface{}) interface{} {
+	groups := &orderedMap{}
+	if err := ex.collect(obj, sels, groups, make(map[string]bool)); err != nil {
+		ex.fail(path, "%v", err)
+		return nil
+	}
+
+	result := &orderedMap{}
+	for _, key := range groups.keys {
+		if ex.aborted {
+			return nil
+		}
+		if err := ex.ctx.Err(); err != nil {
+			ex.fail(path, "%v", err)
+			ex.aborted = true
+			return nil
+		}
+		fields := groups.values[key].([]*Field)
+		result.set(key, ex.field(obj, source, fields, append(path, key)))
+	}
+	return result
+}
+
+// collect gathers the fields selected on obj, flattening fragments and
+// merging fields that share a response key.
+func (ex *executor) collect(obj *Object, sels []Selection, groups *orderedMap, visited map[string]bool) error {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *Field:
+			include, err := ex.included(sel.Directives)
+			if err != nil {
+				return err
+			}
+			if !include {
+				continue
+			}
+			key := sel.ResponseKey()
+			prev, _ := groups.values[key].([]*Field)
+			groups.set(key, append(prev, sel))
+		case *FragmentSpread:
+			include, err := ex.included(sel.Directives)
+			if err != nil {
+				return err
+			}
+			if !include || visited[sel.Name] {
+				continue
+			}
+			frag := ex.doc.Fragments[sel.Name]
+			visited[sel.Name] = true
+			if err := ex.collect(obj, frag.Selections, groups, visited); err != nil {
+				return err
+			}
+		case *InlineFragment:
+			include, err := ex.included(sel.Directives)
+			if err != nil {
+				return err
+			}
+			if !include {
+				continue
+			}
+			if err := ex.collect(obj, sel.Selections, groups, visited); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ex *executor) included(dirs []Directive) (bool, error) {
+	for _, d := range dirs {
+		if d.Name != "include" && d.Name != "skip" {
+			return false, fmt.Errorf("unknown directive @%s", d.Name)
+		}
+		cond, ok := literal(d.Arguments["if"], ex.vars).(bool)
+		if !ok {
+			return false, fmt.Errorf("directive @%s requires a boolean \"if\" argument", d.Name)
+		}
+		if (d.Name == "include") != cond {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// schemaTypes lists every object type reachable from the query root.
+func schemaTypes(root *Object) map[string]*Object {
+	types := make(map[string]*Object)
+	var walk func(o *Object)
+	walk = func(o *Object) {
+		if o == nil || types[o.Name] != nil {
+			return
+		}
+		types[o.Name] = o
+		for _, f := range o.Fields {
+			walk(f.Type)
+		}
+	}
+	walk(root)
+	return types
+}
+
+// field resolves one response key; fields has already been validated.
+func (ex *executor) field(obj *Object, source interface{}, fields []*Field, path []interface{}) interface{} {
+	f := fields[0]
+	if f.Name == "__typename" {
+		return obj.Name
+	}
+	def := obj.Fields[f.Name]
+	ex.fields++
+	if ex.schema.MaxFields > 0 && ex.fields > ex.schema.MaxFields {
+		ex.fail(path, "query resolves more than %d fields", ex.schema.MaxFields)
+		ex.aborted = true
+		return nil
+	}
+
+	args := make(map[string]interface{}, len(f.Arguments))
+	for name, v := range f.Arguments {
+		args[name] = literal(v, ex.vars)
+	}
+
+	var sels []Selection
+	for _, sf := range fields {
+		sels = append(sels, sf.Selections...)
+	}
+
+	var value interface{}
+	if def.Resolve != nil {
+		var err error
+		value, err = def.Resolve(Params{Context: ex.ctx, Source: source, Args: args})
+		if err != nil {
+			ex.fail(path, "%v", err)
+			return nil
+		}
+	} else if m, ok := source.(map[string]interface{}); ok {
+		value = m[f.Name]
+	}
+	if def.Type == nil {
+		return value
+	}
+	return ex.complete(def.Type, value, sels, path)
+}
+
+// complete resolves the sub-selection for an object value or for every
+// element of a slice of them.
+func (ex *executor) complete(obj *Object, value interface{}, sels []Selection, path []interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	if rv.Kind() != reflect.Slice {
+		return ex.selectionSet(obj, value, sels, path)
+	}
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		if ex.aborted {
+			return nil
+		}
+		out[i] = ex.selectionSet(obj, rv.Index(i).Interface(), sels, append(path, i))
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// orderedMap keeps keys in insertion order so responses follow the order
+// of the query's selections.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
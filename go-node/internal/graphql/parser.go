@@ -0,0 +1,498 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed GraphQL request document.
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+type Operation struct {
+	Type       string // "query", "mutation" or "subscription"
+	Name       string
+	Variables  []VariableDefinition
+	Selections []Selection
+}
+
+type VariableDefinition struct {
+	Name     string
+	Type     string // as written, e.g. "Int!" or "[String]"
+	Default  Value
+	Required bool
+}
+
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	Selections    []Selection
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment.
+type Selection interface{}
+
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]Value
+	Directives []Directive
+	Selections []Selection
+	Line       int
+}
+
+// ResponseKey is the name the field's result appears under.
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []Directive
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []Directive
+	Selections    []Selection
+}
+
+type Directive struct {
+	Name      string
+	Arguments map[string]Value
+}
+
+// Value is an argument literal: nil, bool, int, float64, string, Enum,
+// Variable, []Value or map[string]Value.
+type Value interface{}
+
+type Variable string
+
+type Enum string
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// SyntaxError reports where a document failed to parse.
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error on line %d: %s", e.Line, e.Message)
+}
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return l.token()
+		}
+	}
+	return token{kind: tokEOF, line: l.line}, nil
+}
+
+func (l *lexer) token() (token, error) {
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$()=:@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, value: string(c), line: l.line}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokPunct, value: "...", line: l.line}, nil
+		}
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], line: l.line}, nil
+	case c == '-' || isDigit(c):
+		l.pos++
+		kind := tokInt
+		for l.pos < len(l.src) {
+			d := l.src[l.pos]
+			if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (l.src[l.pos-1] == 'e' || l.src[l.pos-1] == 'E')) {
+				kind = tokFloat
+			} else if !isDigit(d) {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: kind, value: l.src[start:l.pos], line: l.line}, nil
+	case c == '"':
+		return l.string()
+	}
+	return token{}, &SyntaxError{Line: l.line, Message: fmt.Sprintf("unexpected character %q", c)}
+}
+
+func (l *lexer) string() (token, error) {
+	var sb strings.Builder
+	l.pos++ // opening quote
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch c {
+		case '"':
+			l.pos++
+			return token{kind: tokString, value: sb.String(), line: l.line}, nil
+		case '\n':
+			return token{}, &SyntaxError{Line: l.line, Message: "unterminated string"}
+		case '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, &SyntaxError{Line: l.line, Message: "unterminated string"}
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				sb.WriteByte(esc)
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case 'u':
+				if l.pos+4 > len(l.src) {
+					return token{}, &SyntaxError{Line: l.line, Message: "bad unicode escape"}
+				}
+				r, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+				if err != nil {
+					return token{}, &SyntaxError{Line: l.line, Message: "bad unicode escape"}
+				}
+				sb.WriteRune(rune(r))
+				l.pos += 4
+			default:
+				return token{}, &SyntaxError{Line: l.line, Message: fmt.Sprintf("bad escape \\%c", esc)}
+			}
+		default:
+			sb.WriteByte(c)
+			l.pos++
+		}
+	}
+	return token{}, &SyntaxError{Line: l.line, Message: "unterminated string"}
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+type parser struct {
+	lex lexer
+	tok token
+}
+
+// Parse parses a GraphQL document. Type system definitions are not
+// supported; a request document holds only operations and fragments.
+func Parse(src string) (doc *Document, err error) {
+	p := &parser{lex: lexer{src: src, line: 1}}
+	defer func() {
+		if r := recover(); r != nil {
+			syntaxErr, ok := r.(*SyntaxError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, syntaxErr
+		}
+	}()
+
+	p.advance()
+	doc = &Document{Fragments: make(map[string]*Fragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", Selections: p.selectionSet()})
+		case p.peek(tokName, "fragment"):
+			f := p.fragment()
+			if _, dup := doc.Fragments[f.Name]; dup {
+				p.fail(fmt.Sprintf("fragment %q defined twice", f.Name))
+			}
+			doc.Fragments[f.Name] = f
+		case p.tok.kind == tokName:
+			doc.Operations = append(doc.Operations, p.operation())
+		default:
+			p.fail(fmt.Sprintf("unexpected %q", p.tok.value))
+		}
+	}
+	if len(doc.Operations) == 0 {
+		p.fail("document contains no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) fail(msg string) {
+	panic(&SyntaxError{Line: p.tok.line, Message: msg})
+}
+
+func (p *parser) advance() {
+	tok, err := p.lex.next()
+	if err != nil {
+		panic(err)
+	}
+	p.tok = tok
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+func (p *parser) expect(value string) {
+	if p.tok.kind != tokPunct || p.tok.value != value {
+		p.fail(fmt.Sprintf("expected %q, found %q", value, p.tok.value))
+	}
+	p.advance()
+}
+
+func (p *parser) name() string {
+	if p.tok.kind != tokName {
+		p.fail(fmt.Sprintf("expected a name, found %q", p.tok.value))
+	}
+	name := p.tok.value
+	p.advance()
+	return name
+}
+
+func (p *parser) operation() *Operation {
+	op := &Operation{Type: p.name()}
+	if op.Type != "query" && op.Type != "mutation" && op.Type != "subscription" {
+		p.fail(fmt.Sprintf("unknown operation type %q", op.Type))
+	}
+	if p.tok.kind == tokName {
+		op.Name = p.name()
+	}
+	if p.peek(tokPunct, "(") {
+		p.advance()
+		for !p.peek(tokPunct, ")") {
+			op.Variables = append(op.Variables, p.variableDefinition())
+		}
+		p.advance()
+	}
+	p.directives()
+	op.Selections = p.selectionSet()
+	return op
+}
+
+func (p *parser) variableDefinition() VariableDefinition {
+	p.expect("$")
+	def := VariableDefinition{Name: p.name()}
+	p.expect(":")
+	def.Type = p.typeRef()
+	def.Required = strings.HasSuffix(def.Type, "!")
+	if p.peek(tokPunct, "=") {
+		p.advance()
+		def.Default = p.value(true)
+	}
+	return def
+}
+
+func (p *parser) typeRef() string {
+	var t string
+	if p.peek(tokPunct, "[") {
+		p.advance()
+		t = "[" + p.typeRef() + "]"
+		p.expect("]")
+	} else {
+		t = p.name()
+	}
+	if p.peek(tokPunct, "!") {
+		p.advance()
+		t += "!"
+	}
+	return t
+}
+
+func (p *parser) fragment() *Fragment {
+	p.advance() // "fragment"
+	f := &Fragment{Name: p.name()}
+	if f.Name == "on" {
+		p.fail("a fragment cannot be named \"on\"")
+	}
+	if p.name() != "on" {
+		p.fail("expected \"on\" after fragment name")
+	}
+	f.TypeCondition = p.name()
+	p.directives()
+	f.Selections = p.selectionSet()
+	return f
+}
+
+func (p *parser) selectionSet() []Selection {
+	p.expect("{")
+	var sels []Selection
+	for !p.peek(tokPunct, "}") {
+		if p.tok.kind == tokEOF {
+			p.fail("unterminated selection set")
+		}
+		sels = append(sels, p.selection())
+	}
+	p.advance()
+	if len(sels) == 0 {
+		p.fail("empty selection set")
+	}
+	return sels
+}
+
+func (p *parser) selection() Selection {
+	if !p.peek(tokPunct, "...") {
+		return p.field()
+	}
+	p.advance()
+	if p.tok.kind == tokName && p.tok.value != "on" {
+		return &FragmentSpread{Name: p.name(), Directives: p.directives()}
+	}
+	inline := &InlineFragment{}
+	if p.peek(tokName, "on") {
+		p.advance()
+		inline.TypeCondition = p.name()
+	}
+	inline.Directives = p.directives()
+	inline.Selections = p.selectionSet()
+	return inline
+}
+
+func (p *parser) field() *Field {
+	f := &Field{Line: p.tok.line, Name: p.name()}
+	if p.peek(tokPunct, ":") {
+		p.advance()
+		f.Alias, f.Name = f.Name, p.name()
+	}
+	f.Arguments = p.arguments()
+	f.Directives = p.directives()
+	if p.peek(tokPunct, "{") {
+		f.Selections = p.selectionSet()
+	}
+	return f
+}
+
+func (p *parser) arguments() map[string]Value {
+	if !p.peek(tokPunct, "(") {
+		return nil
+	}
+	p.advance()
+	args := make(map[string]Value)
+	for !p.peek(tokPunct, ")") {
+		name := p.name()
+		if _, dup := args[name]; dup {
+			p.fail(fmt.Sprintf("argument %q given twice", name))
+		}
+		p.expect(":")
+		args[name] = p.value(false)
+	}
+	p.advance()
+	return args
+}
+
+func (p *parser) directives() []Directive {
+	var dirs []Directive
+	for p.peek(tokPunct, "@") {
+		p.advance()
+		dirs = append(dirs, Directive{Name: p.name(), Arguments: p.arguments()})
+	}
+	return dirs
+}
+
+// value parses a literal; const forbids variables, as in defaults.
+func (p *parser) value(constant bool) Value {
+	tok := p.tok
+	switch tok.kind {
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if constant {
+				p.fail("variables are not allowed here")
+			}
+			p.advance()
+			return Variable(p.name())
+		case "[":
+			p.advance()
+			list := []Value{}
+			for !p.peek(tokPunct, "]") {
+				list = append(list, p.value(constant))
+			}
+			p.advance()
+			return list
+		case "{":
+			p.advance()
+			obj := make(map[string]Value)
+			for !p.peek(tokPunct, "}") {
+				name := p.name()
+				p.expect(":")
+				obj[name] = p.value(constant)
+			}
+			p.advance()
+			return obj
+		}
+	case tokInt:
+		p.advance()
+		n, err := strconv.Atoi(tok.value)
+		if err != nil {
+			p.fail(fmt.Sprintf("invalid integer %s", tok.value))
+		}
+		return n
+	case tokFloat:
+		p.advance()
+		f, err := strconv.ParseFloat(tok.value, 64)
+		if err != nil {
+			p.fail(fmt.Sprintf("invalid number %s", tok.value))
+		}
+		return f
+	case tokString:
+		p.advance()
+		return tok.value
+	case tokName:
+		p.advance()
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return Enum(tok.value)
+	}
+	p.fail(fmt.Sprintf("unexpected %q", tok.value))
+	return nil
+}
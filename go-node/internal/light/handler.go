@@ -0,0 +1,99 @@
+package light
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	defaultHeadersLimit = 500
+	maxHeadersLimit     = 2000
+)
+
+// Handler serves the light client's API: its sync status, its header chain
+// in the same form full nodes serve it, and transaction verification.
+func (c *Client) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/light/status", c.handleStatus)
+	mux.HandleFunc("/headers", c.handleHeaders)
+	mux.HandleFunc("/light/tx/", c.handleVerify)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("Failed to write response", "err", err)
+	}
+}
+
+func (c *Client) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, c.Status())
+}
+
+func (c *Client) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from, limit := 0, defaultHeadersLimit
+	if v := r.URL.Query().Get("from"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "from must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		from = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxHeadersLimit {
+			http.Error(w, "limit must be an integer between 1 and 2000", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	headers := c.Headers(from, limit)
+	if headers == nil {
+		headers = []chain.BlockHeader{}
+	}
+	writeJSON(w, &headersResponse{Count: len(headers), Headers: headers})
+}
+
+// handleVerify serves GET /light/tx/:txid. A transaction is reported only
+// once a peer's Merkle proof checks out against the local headers.
+func (c *Client) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	txID := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/light/tx/"))
+	if txID == "" {
+		http.Error(w, "Transaction ID required", http.StatusBadRequest)
+		return
+	}
+
+	verified, err := c.VerifyTransaction(txID)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, "Transaction not found or not confirmed", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Could not verify transaction: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, verified)
+}
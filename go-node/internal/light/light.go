@@ -0,0 +1,462 @@
+// Package light implements a header-only client. It keeps the chain of block
+// headers, checking linkage, proof of work and the required difficulty of
+// each one itself, and trusts full-node peers only for data it can check
+// against those headers: Merkle proofs that a transaction is in a block.
+package light
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/follower"
+)
+
+// headerBatch is the most headers requested from a peer at once, the limit
+// full nodes serve on GET /headers.
+const headerBatch = 2000
+
+var (
+	// ErrNotFound means no peer had a valid proof and at least one reported
+	// the transaction unknown or unconfirmed.
+	ErrNotFound = errors.New("transaction not found or not confirmed")
+
+	errPeerNotFound = errors.New("not found")
+)
+
+// Client syncs headers from a list of full-node API URLs, moving on to the
+// next peer when one fails or serves headers that don't validate.
+type Client struct {
+	peers     []string
+	client    *http.Client
+	interval  time.Duration
+	params    follower.Params
+	retarget  chain.RetargetPolicy
+	algorithm string // as the genesis block names it: empty for sha256
+
+	mu        sync.RWMutex
+	headers   []chain.BlockHeader // main chain, genesis first
+	heights   map[string]int
+	work      *big.Int
+	peer      string // peer of the last successful sync
+	lastSync  time.Time
+	lastError string
+	reorgs    int
+}
+
+type Status struct {
+	Peers        []string `json:"peers"`
+	Peer         string   `json:"peer,omitempty"`
+	Height       int      `json:"height"` // number of headers, genesis included
+	TipHash      string   `json:"tip_hash"`
+	ChainWork    string   `json:"chain_work"`
+	PowAlgorithm string   `json:"pow_algorithm"`
+	Reorgs       int      `json:"reorgs"`
+	LastSync     int64    `json:"last_sync"`
+	LastError    string   `json:"last_error,omitempty"`
+}
+
+// Verified is a transaction whose inclusion in a main-chain block was
+// checked against the client's own headers.
+type Verified struct {
+	TxID          string             `json:"txid"`
+	Transaction   *chain.Transaction `json:"transaction"`
+	BlockHash     string             `json:"block_hash"`
+	BlockHeight   int                `json:"block_height"`
+	Index         int                `json:"index"`
+	Confirmations int                `json:"confirmations"`
+	Peer          string             `json:"peer"` // where the proof came from
+}
+
+// New adopts the genesis block and consensus parameters of the first
+// reachable peer, as a read replica does, and starts with just the genesis
+// header.
+func New(peers []string, interval time.Duration) (*Client, error) {
+	c := &Client{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		interval: interval,
+		heights:  make(map[string]int),
+	}
+	for _, peer := range peers {
+		if peer = strings.TrimRight(strings.TrimSpace(peer), "/"); peer != "" {
+			c.peers = append(c.peers, peer)
+		}
+	}
+	if len(c.peers) == 0 {
+		return nil, errors.New("no peers given")
+	}
+
+	var lastErr error
+	for _, peer := range c.peers {
+		genesis, err := follower.FetchGenesis(peer)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", peer, err)
+			continue
+		}
+		params, err := follower.FetchParams(peer)
+		if err != nil {
+			lastErr = fmt.Errorf("%s: %w", peer, err)
+			continue
+		}
+		if _, err := consensus.ParseAlgorithm(genesis.PowAlgorithm); err != nil {
+			return nil, fmt.Errorf("%s: genesis block: %w", peer, err)
+		}
+
+		c.params = *params
+		c.retarget = chain.RetargetPolicy{
+			Interval:        params.RetargetInterval,
+			TargetBlockTime: time.Duration(params.TargetBlockTime) * time.Second,
+		}
+		c.algorithm = genesis.PowAlgorithm
+		header := genesis.Header()
+		c.headers = []chain.BlockHeader{header}
+		c.heights[header.Hash] = 0
+		c.work = consensus.Work(header.Difficulty)
+		c.peer = peer
+		return c, nil
+	}
+	return nil, fmt.Errorf("no peer reachable: %w", lastErr)
+}
+
+func (c *Client) Params() follower.Params {
+	return c.params
+}
+
+// PowAlgorithm is the network's proof-of-work algorithm.
+func (c *Client) PowAlgorithm() string {
+	if c.algorithm == "" {
+		return string(consensus.SHA256)
+	}
+	return c.algorithm
+}
+
+func (c *Client) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Status{
+		Peers:        c.peers,
+		Peer:         c.peer,
+		Height:       len(c.headers),
+		TipHash:      c.headers[len(c.headers)-1].Hash,
+		ChainWork:    c.work.String(),
+		PowAlgorithm: c.PowAlgorithm(),
+		Reorgs:       c.reorgs,
+		LastSync:     c.lastSync.Unix(),
+		LastError:    c.lastError,
+	}
+}
+
+// Headers returns up to limit headers of the client's chain starting at
+// height from.
+func (c *Client) Headers(from, limit int) []chain.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if from < 0 || from >= len(c.headers) {
+		return nil
+	}
+	end := len(c.headers)
+	if limit > 0 && from+limit < end {
+		end = from + limit
+	}
+	out := make([]chain.BlockHeader, end-from)
+	copy(out, c.headers[from:end])
+	return out
+}
+
+func (c *Client) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		added, err := c.Sync()
+		if err != nil {
+			slog.Warn("Light client sync failed", "err", err)
+		} else if added > 0 {
+			status := c.Status()
+			slog.Info("Light client synced headers", "headers", added, "peer", status.Peer,
+				"height", status.Height, "tip", status.TipHash)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync brings the header chain up to date from the first peer that answers,
+// starting with the one that last succeeded, and returns how many headers
+// were added.
+func (c *Client) Sync() (int, error) {
+	c.mu.RLock()
+	order := []string{c.peer}
+	c.mu.RUnlock()
+	for _, peer := range c.peers {
+		if peer != order[0] {
+			order = append(order, peer)
+		}
+	}
+
+	var errs []string
+	for _, peer := range order {
+		added, err := c.syncFrom(peer)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+			continue
+		}
+		c.mu.Lock()
+		c.peer = peer
+		c.lastSync = time.Now()
+		c.lastError = ""
+		c.mu.Unlock()
+		return added, nil
+	}
+
+	err := errors.New(strings.Join(errs, "; "))
+	c.mu.Lock()
+	c.lastError = err.Error()
+	c.mu.Unlock()
+	return 0, err
+}
+
+// headersResponse is the body of GET /headers, on full nodes (which also
+// report their tip) and on the light client.
+type headersResponse struct {
+	Count   int                 `json:"count"`
+	Headers []chain.BlockHeader `json:"headers"`
+}
+
+func (c *Client) fetchHeaders(peer string, from, limit int) ([]chain.BlockHeader, error) {
+	var resp headersResponse
+	url := fmt.Sprintf("%s/headers?from=%d&limit=%d", peer, from, limit)
+	if err := c.getJSON(url, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Headers, nil
+}
+
+// syncFrom fetches headers past the local tip from peer in batches. A batch
+// that doesn't build on the local tip means the peer is on another branch:
+// the fork point is located and the peer's branch adopted if it carries more
+// work.
+func (c *Client) syncFrom(peer string) (int, error) {
+	added := 0
+	for {
+		tip := c.tip()
+		headers, err := c.fetchHeaders(peer, tip.Index+1, headerBatch)
+		if err != nil {
+			return added, err
+		}
+		if len(headers) == 0 {
+			return added, nil
+		}
+
+		fork := tip.Index
+		if headers[0].PrevHash != tip.Hash {
+			if fork, err = c.findFork(peer, tip.Index); err != nil {
+				return added, err
+			}
+			if headers, err = c.fetchHeaders(peer, fork+1, headerBatch); err != nil {
+				return added, err
+			}
+		}
+
+		n, err := c.connect(fork, headers)
+		added += n
+		if err != nil {
+			return added, err
+		}
+		if n == 0 || len(headers) < headerBatch {
+			return added, nil
+		}
+	}
+}
+
+// findFork returns a height at or below the point where peer's chain leaves
+// the local one, stepping back exponentially from height.
+func (c *Client) findFork(peer string, height int) (int, error) {
+	for back := 1; ; back *= 2 {
+		h := height - back
+		if h < 0 {
+			h = 0
+		}
+		headers, err := c.fetchHeaders(peer, h, 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(headers) == 1 && headers[0].Hash == c.headerAt(h).Hash {
+			return h, nil
+		}
+		if h == 0 {
+			return 0, errors.New("peer is on a different genesis block")
+		}
+	}
+}
+
+// connect validates headers as a branch from the local header at fork and
+// makes it the main chain if it carries more work than the local headers
+// past fork. It returns how many headers were added.
+func (c *Client) connect(fork int, headers []chain.BlockHeader) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The fork search may land below the real fork point; skip what is
+	// already in place.
+	for len(headers) > 0 && fork+1 < len(c.headers) && c.headers[fork+1].Hash == headers[0].Hash {
+		fork++
+		headers = headers[1:]
+	}
+	if len(headers) == 0 {
+		return 0, nil
+	}
+
+	// The difficulty rule looks back one retarget interval.
+	start := fork - c.retarget.Interval
+	if start < 0 {
+		start = 0
+	}
+	branch := make([]chain.BlockHeader, fork+1-start, fork+1-start+len(headers))
+	copy(branch, c.headers[start:fork+1])
+
+	branchWork := new(big.Int)
+	for _, h := range headers {
+		parent := branch[len(branch)-1]
+		if h.PrevHash != parent.Hash || h.Index != parent.Index+1 {
+			return 0, fmt.Errorf("header %d does not link to header %d", h.Index, parent.Index)
+		}
+		if expected := chain.NextHeaderDifficulty(branch, c.params.InitialDifficulty, c.retarget); h.Difficulty != expected {
+			return 0, fmt.Errorf("header %d declares difficulty %d, expected %d", h.Index, h.Difficulty, expected)
+		}
+		if err := chain.VerifyHeader(h, c.algorithm); err != nil {
+			return 0, fmt.Errorf("header %d: %w", h.Index, err)
+		}
+		branch = append(branch, h)
+		branchWork.Add(branchWork, consensus.Work(h.Difficulty))
+	}
+
+	replaced := c.headers[fork+1:]
+	replacedWork := new(big.Int)
+	for _, h := range replaced {
+		replacedWork.Add(replacedWork, consensus.Work(h.Difficulty))
+	}
+	if branchWork.Cmp(replacedWork) <= 0 {
+		return 0, nil
+	}
+
+	if len(replaced) > 0 {
+		c.reorgs++
+		slog.Warn("Light client switched to a branch with more work", "fork_height", fork,
+			"replaced", len(replaced), "new_headers", len(headers))
+	}
+	for _, h := range replaced {
+		delete(c.heights, h.Hash)
+	}
+	c.headers = append(c.headers[:fork+1], headers...)
+	for _, h := range headers {
+		c.heights[h.Hash] = h.Index
+	}
+	c.work.Sub(c.work, replacedWork)
+	c.work.Add(c.work, branchWork)
+	return len(headers), nil
+}
+
+func (c *Client) tip() chain.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[len(c.headers)-1]
+}
+
+func (c *Client) headerAt(height int) chain.BlockHeader {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.headers[height]
+}
+
+// VerifyTransaction fetches a Merkle proof for txID from the peers in turn
+// and checks it against the local header of the block it names.
+func (c *Client) VerifyTransaction(txID string) (*Verified, error) {
+	var errs []string
+	notFound := 0
+	for _, peer := range c.peers {
+		var proof chain.TxProof
+		err := c.getJSON(peer+"/transactions/"+txID+"/proof", &proof)
+		if errors.Is(err, errPeerNotFound) {
+			notFound++
+			continue
+		}
+		if err == nil {
+			var v *Verified
+			if v, err = c.checkProof(txID, &proof); err == nil {
+				v.Peer = peer
+				return v, nil
+			}
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", peer, err))
+	}
+	if notFound > 0 {
+		// An answer outweighs peers that could not be reached.
+		return nil, ErrNotFound
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+func (c *Client) checkProof(txID string, proof *chain.TxProof) (*Verified, error) {
+	if proof.TxID != txID || proof.Transaction == nil {
+		return nil, errors.New("proof is for a different transaction")
+	}
+	if id, err := chain.ComputeTxID(proof.Transaction); err != nil || id != txID {
+		return nil, errors.New("transaction in proof does not hash to its txid")
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	height, ok := c.heights[proof.BlockHash]
+	if !ok || height != proof.Height {
+		return nil, fmt.Errorf("block %s is not on the local header chain", proof.BlockHash)
+	}
+	// Each branch step reads one bit of the index, so higher bits would let
+	// one proof claim many positions.
+	if proof.Index < 0 || (len(proof.Branch) < 63 && proof.Index >= 1<<len(proof.Branch)) {
+		return nil, fmt.Errorf("index %d is out of range for a branch of %d steps", proof.Index, len(proof.Branch))
+	}
+	if !proof.Verify(c.headers[height].MerkleRoot) {
+		return nil, errors.New("merkle proof does not lead to the block header's root")
+	}
+	return &Verified{
+		TxID:          txID,
+		Transaction:   proof.Transaction,
+		BlockHash:     proof.BlockHash,
+		BlockHeight:   height,
+		Index:         proof.Index,
+		Confirmations: len(c.headers) - height,
+	}, nil
+}
+
+func (c *Client) getJSON(url string, out interface{}) error {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errPeerNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
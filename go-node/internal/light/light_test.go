@@ -0,0 +1,75 @@
+package light
+
+import (
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+// proofClient returns a client holding only the genesis header of a chain
+// whose genesis block pays each of n addresses, and that chain.
+func proofClient(t *testing.T, n int) (*Client, *chain.Blockchain) {
+	t.Helper()
+	var addresses []string
+	for i := 0; i < n; i++ {
+		addresses = append(addresses, strings.Repeat(string(rune('a'+i)), 64))
+	}
+	bc := chain.NewBlockchain(chaintest.Genesis(t, addresses...))
+	header := bc.Genesis().Header()
+	return &Client{
+		headers: []chain.BlockHeader{header},
+		heights: map[string]int{header.Hash: 0},
+	}, bc
+}
+
+func TestCheckProof(t *testing.T) {
+	c, bc := proofClient(t, 3)
+	genesis := bc.Genesis()
+
+	for i, tx := range genesis.Transactions {
+		proof, ok := bc.TransactionProof(tx.ID)
+		if !ok {
+			t.Fatalf("no proof for transaction %d", i)
+		}
+		v, err := c.checkProof(tx.ID, proof)
+		if err != nil {
+			t.Fatalf("transaction %d: %v", i, err)
+		}
+		if v.Index != i || v.BlockHash != genesis.Hash || v.Confirmations != 1 {
+			t.Errorf("transaction %d verified as %+v", i, v)
+		}
+	}
+
+	txID := genesis.Transactions[0].ID
+	valid, _ := bc.TransactionProof(txID)
+	for name, tamper := range map[string]func(p *chain.TxProof){
+		"wrong index":       func(p *chain.TxProof) { p.Index = 1 },
+		"index past branch": func(p *chain.TxProof) { p.Index += 1 << len(p.Branch) },
+		"negative index":    func(p *chain.TxProof) { p.Index = -1 },
+		"wrong branch":      func(p *chain.TxProof) { p.Branch[0] = genesis.Transactions[2].ID },
+		"short branch":      func(p *chain.TxProof) { p.Branch = p.Branch[:1] },
+		"unknown block":     func(p *chain.TxProof) { p.BlockHash = strings.Repeat("0", 64) },
+		"wrong height":      func(p *chain.TxProof) { p.Height = 1 },
+		"other txid":        func(p *chain.TxProof) { p.TxID = genesis.Transactions[1].ID },
+	} {
+		proof := *valid
+		proof.Branch = append([]string(nil), valid.Branch...)
+		tamper(&proof)
+		if _, err := c.checkProof(txID, &proof); err == nil {
+			t.Errorf("%s: proof accepted", name)
+		}
+	}
+
+	// The root comes from the client's own header, not the proof.
+	forged := *valid
+	forged.MerkleRoot = strings.Repeat("0", 64)
+	if _, err := c.checkProof(txID, &forged); err != nil {
+		t.Errorf("proof rejected over its own merkle_root field: %v", err)
+	}
+	c.headers[0].MerkleRoot = strings.Repeat("0", 64)
+	if _, err := c.checkProof(txID, valid); err == nil {
+		t.Error("proof accepted against a header with a different root")
+	}
+}
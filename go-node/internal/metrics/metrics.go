@@ -0,0 +1,241 @@
+// Package metrics keeps counters and histograms and serves them in the
+// Prometheus text exposition format, so a Prometheus server can scrape the
+// node without the node depending on a client library.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds the metrics a node exposes on GET /metrics.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic("metrics: duplicate metric " + m.name())
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText writes every metric in the Prometheus text format, sorted by
+// name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name() < metrics[j].name() })
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry for scraping.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// series is the set of label values one metric has been recorded with.
+type series struct {
+	labels []string
+	mu     sync.Mutex
+	byKey  map[string][]string // key -> label values
+}
+
+func newSeries(labels []string) series {
+	return series{labels: labels, byKey: make(map[string][]string)}
+}
+
+// key returns the map key for values, remembering them. It must be called
+// with mu held.
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values for %d labels", len(values), len(s.labels)))
+	}
+	k := strings.Join(values, "\xff")
+	if _, ok := s.byKey[k]; !ok {
+		s.byKey[k] = append([]string(nil), values...)
+	}
+	return k
+}
+
+// sortedKeys must be called with mu held.
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.byKey))
+	for k := range s.byKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// labelString renders the label set for values, with extra appended as a
+// final name and value when non-empty.
+func (s *series) labelString(values []string, extraName, extraValue string) string {
+	var parts []string
+	for i, name := range s.labels {
+		parts = append(parts, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter is a monotonically increasing count per label set.
+type Counter struct {
+	metricName string
+	help       string
+	series
+	values map[string]float64
+}
+
+// NewCounter registers a counter with the given label names.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{metricName: name, help: help, series: newSeries(labels), values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+func (c *Counter) name() string { return c.metricName }
+
+// Inc adds one to the count for the label values, given in the order the
+// labels were declared.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) Add(v float64, values ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[c.key(values)] += v
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.metricName, c.help, c.metricName)
+	for _, k := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(c.byKey[k], "", ""), formatFloat(c.values[k]))
+	}
+}
+
+// Histogram counts observations into cumulative buckets per label set.
+type Histogram struct {
+	metricName string
+	help       string
+	buckets    []float64 // upper bounds, ascending, without +Inf
+	series
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	counts []uint64 // per bucket, not cumulative; the last is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds and
+// label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{metricName: name, help: help, buckets: sorted, series: newSeries(labels), values: make(map[string]*histogramValue)}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) name() string { return h.metricName }
+
+// Observe records v for the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	k := h.key(values)
+	hv, ok := h.values[k]
+	if !ok {
+		hv = &histogramValue{counts: make([]uint64, len(h.buckets)+1)}
+		h.values[k] = hv
+	}
+	i := sort.SearchFloat64s(h.buckets, v)
+	hv.counts[i]++
+	hv.sum += v
+	hv.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.metricName, h.help, h.metricName)
+	for _, k := range h.sortedKeys() {
+		values, hv := h.byKey[k], h.values[k]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hv.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(values, "le", formatFloat(bound)), cumulative)
+		}
+		cumulative += hv.counts[len(h.buckets)]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(values, "le", "+Inf"), cumulative)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(values, "", ""), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(values, "", ""), hv.count)
+	}
+}
@@ -0,0 +1,120 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// AutoMiner mines blocks in the background. With an interval it mines once
+// per interval; with no interval it mines as soon as the mempool holds
+// transactions.
+type AutoMiner struct {
+	miner         *Miner
+	interval      time.Duration
+	rewardAddress string
+
+	mu        sync.Mutex
+	mined     int
+	lastBlock int64
+	lastError string
+}
+
+type AutoStatus struct {
+	Interval      string `json:"interval"`
+	RewardAddress string `json:"reward_address,omitempty"`
+	BlocksMined   int    `json:"blocks_mined"`
+	LastBlock     int64  `json:"last_block,omitempty"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+func NewAutoMiner(m *Miner, interval time.Duration, rewardAddress string) *AutoMiner {
+	return &AutoMiner{miner: m, interval: interval, rewardAddress: rewardAddress}
+}
+
+func (a *AutoMiner) Status() AutoStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	interval := "on demand"
+	if a.interval > 0 {
+		interval = a.interval.String()
+	}
+	return AutoStatus{
+		Interval:      interval,
+		RewardAddress: a.rewardAddress,
+		BlocksMined:   a.mined,
+		LastBlock:     a.lastBlock,
+		LastError:     a.lastError,
+	}
+}
+
+func (a *AutoMiner) Run(ctx context.Context) {
+	arrivals, cancel := a.miner.mempool.Subscribe()
+	defer cancel()
+
+	var tick <-chan time.Time
+	if a.interval > 0 {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if a.interval == 0 && a.miner.mempool.Size() > 0 {
+			a.mineOnce(ctx)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			a.mineOnce(ctx)
+		case <-arrivals:
+			// In interval mode arrivals wait for the next tick; otherwise the
+			// loop picks them up straight away.
+		}
+	}
+}
+
+func (a *AutoMiner) mineOnce(ctx context.Context) {
+	if frozen, _ := a.miner.blockchain.Frozen(); frozen {
+		// Back off so a frozen chain with a full mempool does not spin.
+		time.Sleep(time.Second)
+		return
+	}
+
+	start := time.Now()
+	block, err := a.miner.Produce(ctx, a.rewardAddress)
+	if errors.Is(err, ErrNoTransactions) || errors.Is(err, ErrMiningStopped) || ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		a.setError(err)
+		if !errors.Is(err, ErrStaleBlock) {
+			slog.Error("Auto-miner failed", "err", err)
+			time.Sleep(time.Second)
+		}
+		return
+	}
+
+	a.mu.Lock()
+	a.mined++
+	a.lastBlock = time.Now().Unix()
+	a.lastError = ""
+	a.mu.Unlock()
+	slog.Info("Auto-mined block", "height", block.Index, "hash", block.Hash,
+		"txs", len(block.Transactions), "duration", time.Since(start))
+}
+
+func (a *AutoMiner) setError(err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lastError = err.Error()
+}
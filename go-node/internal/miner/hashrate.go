@@ -0,0 +1,100 @@
+package miner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+// minRateSample is how long the miner must have spent hashing before its own
+// count is trusted as a rate; a handful of short jobs at a low difficulty
+// mostly measure setup time.
+const minRateSample = time.Second
+
+// rateSampleDuration is how long EstimateHashRate hashes when the miner has
+// no measurement of its own.
+const rateSampleDuration = time.Second
+
+// hashMeter counts the hashes tried while mining and the time spent on them.
+type hashMeter struct {
+	hashes atomic.Int64
+	nanos  atomic.Int64
+
+	sampleOnce sync.Once
+	sampled    float64
+}
+
+// count wraps computeHash so every call is counted.
+func (hm *hashMeter) count(computeHash func(int64) string) func(int64) string {
+	return func(nonce int64) string {
+		hm.hashes.Add(1)
+		return computeHash(nonce)
+	}
+}
+
+// HashRate is the rate, in hashes per second, this node has achieved while
+// mining. It reports false until mining has run for long enough to measure.
+func (m *Miner) HashRate() (float64, bool) {
+	nanos := m.meter.nanos.Load()
+	if nanos < int64(minRateSample) {
+		return 0, false
+	}
+	return float64(m.meter.hashes.Load()) / time.Duration(nanos).Seconds(), true
+}
+
+// EstimateHashRate returns HashRate when there is one. Otherwise it hashes a
+// throwaway block with the configured threads for a second, once, and
+// returns that rate. source is "mining" or "benchmark" accordingly. The
+// benchmark hashes with the network's proof-of-work algorithm.
+func (m *Miner) EstimateHashRate() (rate float64, source string) {
+	if rate, ok := m.HashRate(); ok {
+		return rate, "mining"
+	}
+	m.meter.sampleOnce.Do(func() {
+		m.meter.sampled = MeasureHashRate(m.lifetime, m.blockchain.PowAlgorithm(), m.Threads(), rateSampleDuration)
+	})
+	return m.meter.sampled, "benchmark"
+}
+
+// Threads is the number of goroutines a block's nonce search uses.
+func (m *Miner) Threads() int {
+	if m.threads < 1 {
+		return 1
+	}
+	return m.threads
+}
+
+// MeasureHashRate hashes a throwaway block under the given proof-of-work
+// algorithm (a header name; empty for sha256) with threads goroutines for d,
+// or until ctx is done, and returns the hashes per second achieved.
+func MeasureHashRate(ctx context.Context, algorithm string, threads int, d time.Duration) float64 {
+	coinbase, err := chain.NewCoinbaseTransaction(1, "bench", consensus.DefaultBlockReward)
+	if err != nil {
+		return 0
+	}
+	block := chain.NewBlock(1, chain.CoinbaseInputID, []chain.Transaction{*coinbase})
+	// The largest difficulty has no practical solution, so the search runs
+	// for the full duration.
+	block.Difficulty = 256
+	block.PowAlgorithm = algorithm
+
+	var hashes atomic.Int64
+	newHasher := func() func(int64) string {
+		candidate := *block
+		return func(nonce int64) string {
+			hashes.Add(1)
+			candidate.Nonce = nonce
+			return candidate.ComputeHash()
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	start := time.Now()
+	consensus.MineBlockParallel(ctx, newHasher, block.Difficulty, threads)
+	return float64(hashes.Load()) / time.Since(start).Seconds()
+}
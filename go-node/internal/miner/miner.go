@@ -0,0 +1,417 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+var (
+	ErrNoTransactions = errors.New("no transactions in mempool")
+	ErrMiningFailed   = errors.New("failed to mine block")
+	ErrStaleBlock     = errors.New("mined block is stale: chain tip changed during mining")
+	ErrBlockRejected  = errors.New("mined block rejected")
+	ErrMiningStopped  = errors.New("miner stopped")
+	ErrMiningCanceled = errors.New("mining canceled")
+)
+
+// Causes for abandoning a template without giving up on the block.
+var (
+	errRefreshTemplate = errors.New("high-fee transaction arrived")
+	errTipMoved        = errors.New("chain tip moved")
+)
+
+// RefreshPolicy controls when an in-progress mining job is restarted with a
+// fresh template because a sufficiently valuable transaction arrived.
+type RefreshPolicy struct {
+	MinFee       float64       // arrivals paying at least this fee trigger a refresh (0 = disabled)
+	MinInterval  time.Duration // minimum time between two refreshes
+	MaxRefreshes int           // upper bound on refreshes per block
+}
+
+func (p RefreshPolicy) Enabled() bool {
+	return p.MinFee > 0 && p.MaxRefreshes > 0
+}
+
+type Miner struct {
+	blockchain *chain.Blockchain
+	mempool    *chain.Mempool
+	blocks     *chain.BlockPipeline
+	refresh    RefreshPolicy
+	maxTxs     int
+	threads    int
+	meter      hashMeter
+	identity   *ecdsa.PrivateKey // signs mined blocks as their producer (nil = anonymous)
+
+	// producing is held by Produce from template to submit, so two jobs on
+	// this node never build on the same tip.
+	producing sync.Mutex
+
+	// lifetime is canceled by Stop; every mining job derives from it.
+	lifetime context.Context
+	stop     context.CancelCauseFunc
+
+	jobMu     sync.Mutex
+	cancelJob context.CancelCauseFunc // nil when no block is being mined
+}
+
+func New(blockchain *chain.Blockchain, mempool *chain.Mempool, blocks *chain.BlockPipeline) *Miner {
+	lifetime, stop := context.WithCancelCause(context.Background())
+	return &Miner{
+		blockchain: blockchain,
+		mempool:    mempool,
+		blocks:     blocks,
+		lifetime:   lifetime,
+		stop:       stop,
+	}
+}
+
+// Stop aborts any block being mined and makes later mining calls fail with
+// ErrMiningStopped. It is called once the node starts shutting down.
+func (m *Miner) Stop() {
+	m.stop(ErrMiningStopped)
+}
+
+// Cancel aborts the block currently being mined, which fails with
+// ErrMiningCanceled. It reports whether there was one.
+func (m *Miner) Cancel() bool {
+	m.jobMu.Lock()
+	defer m.jobMu.Unlock()
+	if m.cancelJob == nil {
+		return false
+	}
+	m.cancelJob(ErrMiningCanceled)
+	return true
+}
+
+func (m *Miner) SetRefreshPolicy(policy RefreshPolicy) {
+	m.refresh = policy
+}
+
+// SetMaxBlockTxs caps how many mempool transactions a template includes
+// (0 = all). The highest fee rates are taken first.
+func (m *Miner) SetMaxBlockTxs(n int) {
+	m.maxTxs = n
+}
+
+// SetThreads sets how many goroutines search nonces in parallel (values
+// below 2 mine on the calling goroutine).
+func (m *Miner) SetThreads(n int) {
+	m.threads = n
+}
+
+// SetIdentity makes the miner name itself in every block it mines and sign
+// the result with key, so explorers can attribute blocks to it.
+func (m *Miner) SetIdentity(key *ecdsa.PrivateKey) {
+	m.identity = key
+}
+
+// Difficulty is the difficulty the next block must meet.
+func (m *Miner) Difficulty() int {
+	return m.blockchain.NextDifficulty()
+}
+
+// template builds the next block from the mempool. When rewardAddress is set
+// a coinbase paying the block reward plus fees is prepended, and the block
+// may be mined even with an empty mempool. The block holds copies of the
+// transactions it includes, which are reserved in the mempool until the
+// caller releases them or the block confirms them.
+//
+// The template is checked against the tip's UTXO set before any work is
+// spent on it. Transactions that fail are evicted from the mempool with
+// their descendants and the template is built again without them.
+func (m *Miner) template(rewardAddress string) (*chain.Block, []*chain.Transaction, error) {
+	for {
+		txs := m.mempool.ReserveTransactions(m.maxTxs)
+		if len(txs) == 0 && rewardAddress == "" {
+			return nil, nil, ErrNoTransactions
+		}
+
+		txSlice := make([]chain.Transaction, 0, len(txs)+1)
+		tip := m.blockchain.Tip()
+		utxo := m.blockchain.UTXOSnapshot()
+		reward := m.blockchain.BlockReward()
+
+		if rewardAddress != "" {
+			view := utxo.Clone()
+			var fees float64
+			for _, tx := range txs {
+				if fee, err := chain.ComputeFee(tx, view); err == nil {
+					fees += fee
+				}
+				view.ApplyTransaction(tx)
+			}
+
+			coinbase, err := chain.NewCoinbaseTransaction(tip.Index+1, rewardAddress, reward+fees)
+			if err != nil {
+				m.mempool.ReleaseTransactions(txs)
+				return nil, nil, err
+			}
+			txSlice = append(txSlice, *coinbase)
+		}
+
+		for _, tx := range txs {
+			txSlice = append(txSlice, *tx)
+		}
+
+		block := chain.NewBlock(tip.Index+1, tip.Hash, txSlice)
+		block.Timestamp = max(m.blockchain.Clock().Now().Unix(), m.blockchain.MedianTimePast()+1)
+		block.Difficulty = m.blockchain.NextDifficulty()
+		block.PowAlgorithm = m.blockchain.PowAlgorithm()
+		if m.identity != nil {
+			block.MinerPubKey = crypto.EncodePublicKey(&m.identity.PublicKey)
+		}
+
+		if err := chain.VerifyBlockState(block, utxo, reward); err != nil {
+			m.mempool.ReleaseTransactions(txs)
+			// A block connected since the tip was read makes the snapshot
+			// disagree with the template; build on the new tip instead.
+			if len(m.evictInvalid(txs)) > 0 || m.blockchain.Tip().Hash != tip.Hash {
+				continue
+			}
+			return nil, nil, fmt.Errorf("%w: invalid template: %w", ErrMiningFailed, err)
+		}
+		return block, txs, nil
+	}
+}
+
+// evictInvalid checks txs in order against the tip's UTXO set, as a block
+// would apply them, and evicts every one that fails from the mempool with
+// its descendants, so no later template includes it again. It returns the
+// IDs evicted.
+func (m *Miner) evictInvalid(txs []*chain.Transaction) []string {
+	view := m.blockchain.UTXOSnapshot()
+	var evicted []string
+	for _, tx := range txs {
+		if err := chain.VerifyTransaction(tx, view); err != nil {
+			removed, _ := m.blocks.EvictTransaction(tx.ID)
+			if len(removed) > 0 {
+				slog.Warn("Evicted invalid transaction from the mempool", "txid", tx.ID, "evicted", len(removed), "err", err)
+			}
+			evicted = append(evicted, removed...)
+			continue
+		}
+		view.ApplyTransaction(tx)
+	}
+	return evicted
+}
+
+// MineBlock builds a block from the mempool and solves its proof of work,
+// paying the reward to rewardAddress if set. It does not add the block to the
+// chain; callers decide what to do with it.
+//
+// Mining stops with ctx's error when ctx is done, with ErrMiningStopped on
+// Stop and with ErrMiningCanceled on Cancel. A block from a peer that moves
+// the tip, or a high-fee arrival under the refresh policy, restarts the job
+// on a fresh template instead.
+//
+// While a template is mined its transactions are in flight: no other
+// template includes them. On failure they are released. On success the
+// returned transactions stay reserved until the caller submits the block,
+// which removes them from the mempool, or hands them back with
+// Mempool.ReleaseTransactions.
+func (m *Miner) MineBlock(ctx context.Context, rewardAddress string) (*chain.Block, []*chain.Transaction, error) {
+	if m.lifetime.Err() != nil {
+		return nil, nil, ErrMiningStopped
+	}
+	job, cancelJob := context.WithCancelCause(ctx)
+	defer cancelJob(nil)
+	defer context.AfterFunc(m.lifetime, func() { cancelJob(ErrMiningStopped) })()
+
+	m.jobMu.Lock()
+	m.cancelJob = cancelJob
+	m.jobMu.Unlock()
+	defer func() {
+		m.jobMu.Lock()
+		m.cancelJob = nil
+		m.jobMu.Unlock()
+	}()
+
+	var arrivals <-chan *chain.Transaction
+	if m.refresh.Enabled() {
+		ch, cancel := m.mempool.Subscribe()
+		defer cancel()
+		arrivals = ch
+	}
+	tips, cancelTips := m.blockchain.SubscribeBlocks()
+	defer cancelTips()
+
+	refreshes := 0
+	lastRefresh := time.Now()
+
+	for {
+		if job.Err() != nil {
+			return nil, nil, context.Cause(job)
+		}
+		block, txs, err := m.template(rewardAddress)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		slog.Info("Mining block", "height", block.Index, "difficulty", block.Difficulty, "txs", len(block.Transactions))
+
+		// The watchers share the subscriptions across rounds, so each round
+		// waits for its own to exit before the next one starts reading.
+		round, cancelRound := context.WithCancelCause(job)
+		var watchers sync.WaitGroup
+		if arrivals != nil && refreshes < m.refresh.MaxRefreshes {
+			watchers.Add(1)
+			go func(lastRefresh time.Time) {
+				defer watchers.Done()
+				m.watchArrivals(round, arrivals, lastRefresh, cancelRound)
+			}(lastRefresh)
+		}
+		watchers.Add(1)
+		go func(parent string) {
+			defer watchers.Done()
+			m.watchTip(round, tips, parent, cancelRound)
+		}(block.PrevHash)
+
+		hash, nonce, err := m.solve(round, block)
+		cancelRound(nil)
+		watchers.Wait()
+		if err != nil {
+			m.mempool.ReleaseTransactions(txs)
+		}
+
+		switch {
+		case err == nil:
+			block.Hash = hash
+			block.Nonce = nonce
+			if m.identity != nil {
+				if err := block.SignProducer(m.identity); err != nil {
+					m.mempool.ReleaseTransactions(txs)
+					return nil, nil, fmt.Errorf("%w: %w", ErrMiningFailed, err)
+				}
+			}
+			return block, txs, nil
+		case errors.Is(err, errRefreshTemplate):
+			refreshes++
+			lastRefresh = time.Now()
+			slog.Info("High-fee transaction arrived, refreshing template",
+				"height", block.Index, "refresh", refreshes, "max_refreshes", m.refresh.MaxRefreshes)
+		case errors.Is(err, errTipMoved):
+			slog.Info("Chain tip moved while mining, restarting on the new tip", "height", block.Index)
+		case job.Err() != nil:
+			return nil, nil, err
+		default:
+			return nil, nil, fmt.Errorf("%w: %w", ErrMiningFailed, err)
+		}
+	}
+}
+
+// solve searches for block's proof of work, over m.threads goroutines when
+// more than one is configured.
+func (m *Miner) solve(ctx context.Context, block *chain.Block) (string, int64, error) {
+	start := time.Now()
+	defer func() { m.meter.nanos.Add(int64(time.Since(start))) }()
+
+	if m.threads > 1 {
+		// Each worker hashes its own copy; only the nonce differs.
+		newHasher := func() func(int64) string {
+			candidate := *block
+			return m.meter.count(func(nonce int64) string {
+				candidate.Nonce = nonce
+				return candidate.ComputeHash()
+			})
+		}
+		return consensus.MineBlockParallel(ctx, newHasher, block.Difficulty, m.threads)
+	}
+
+	computeHashFunc := m.meter.count(func(nonce int64) string {
+		block.Nonce = nonce
+		return block.ComputeHash()
+	})
+	setNonceFunc := func(nonce int64) {
+		block.Nonce = nonce
+	}
+	return consensus.MineBlock(ctx, computeHashFunc, setNonceFunc, block.Difficulty)
+}
+
+// Produce mines a block on the current tip and submits it. Concurrent calls
+// run one at a time: a second caller waits for the first block to land and
+// then builds on top of it instead of racing it for the same parent. A
+// block can still go stale if a peer's block arrives while mining. Submit
+// failures are wrapped in ErrBlockRejected and returned with the block;
+// transactions that made it invalid are evicted from the mempool, and the
+// rest are released for the next template.
+func (m *Miner) Produce(ctx context.Context, rewardAddress string) (*chain.Block, error) {
+	m.producing.Lock()
+	defer m.producing.Unlock()
+
+	block, txs, err := m.MineBlock(ctx, rewardAddress)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Submit(block); err != nil {
+		m.mempool.ReleaseTransactions(txs)
+		if !errors.Is(err, ErrStaleBlock) {
+			m.evictInvalid(txs)
+		}
+		return block, fmt.Errorf("%w: %w", ErrBlockRejected, err)
+	}
+	return block, nil
+}
+
+// Submit hands a freshly mined block to the block pipeline, which validates
+// and connects it, then drops its transactions, and any pending ones
+// conflicting with them, from the mempool. A block whose parent is no
+// longer the tip is recorded in the stale store and rejected with
+// ErrStaleBlock.
+func (m *Miner) Submit(block *chain.Block) error {
+	err := m.blocks.ConnectBlock(block)
+	if errors.Is(err, chain.ErrNotOnTip) {
+		tip := m.blockchain.Tip()
+		m.blockchain.Stale.Record(block, "tip advanced while mining", tip.Hash)
+		slog.Warn("Mined block is stale: tip moved during mining", "height", block.Index, "hash", block.Hash, "tip", tip.Hash)
+		return ErrStaleBlock
+	}
+	return err
+}
+
+func (m *Miner) watchArrivals(round context.Context, arrivals <-chan *chain.Transaction, lastRefresh time.Time, cancel context.CancelCauseFunc) {
+	for {
+		select {
+		case <-round.Done():
+			return
+		case tx := <-arrivals:
+			fee, err := chain.ComputeFee(tx, m.mempool.InputView(tx, m.blockchain.Output))
+			if err != nil || fee < m.refresh.MinFee {
+				continue
+			}
+			if wait := m.refresh.MinInterval - time.Since(lastRefresh); wait > 0 {
+				select {
+				case <-round.Done():
+					return
+				case <-time.After(wait):
+				}
+			}
+			cancel(errRefreshTemplate)
+			return
+		}
+	}
+}
+
+// watchTip cancels the round once a block connects that the template was
+// not built on, typically one relayed by a peer.
+func (m *Miner) watchTip(round context.Context, tips <-chan *chain.Block, parent string, cancel context.CancelCauseFunc) {
+	for {
+		select {
+		case <-round.Done():
+			return
+		case <-tips:
+			if m.blockchain.Tip().Hash != parent {
+				cancel(errTipMoved)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package miner
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// unsolvable is a difficulty no test block will meet, so mining runs until
+// the job is canceled or restarted.
+const unsolvable = 64
+
+type testNode struct {
+	key      *ecdsa.PrivateKey
+	address  string
+	chain    *chain.Blockchain
+	mempool  *chain.Mempool
+	pipeline *chain.BlockPipeline
+	miner    *Miner
+}
+
+// newTestNode starts a node at difficulty 1 whose genesis pays 50 coins to
+// a fresh key.
+func newTestNode(t *testing.T) *testNode {
+	t.Helper()
+	key, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.AddressFromPublicKey(&key.PublicKey)
+	bc := chaintest.NewChain(t, address, 0)
+	mempool := chain.NewMempool()
+	pipeline := chain.NewBlockPipeline(bc, mempool)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go pipeline.Run(ctx)
+
+	return &testNode{
+		key:      key,
+		address:  address,
+		chain:    bc,
+		mempool:  mempool,
+		pipeline: pipeline,
+		miner:    New(bc, mempool, pipeline),
+	}
+}
+
+// spend builds a transaction from the node's key moving in to the node's
+// own address, leaving fee unclaimed.
+func (n *testNode) spend(t *testing.T, in chain.TxIn, amount, fee float64) *chain.Transaction {
+	t.Helper()
+	tx, err := chain.NewTransaction([]chain.TxIn{in}, []chain.TxOut{{Address: n.address, Amount: amount - fee}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.ID, err = chain.ComputeTxID(tx); err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := chain.CanonicalTxBytes(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tx.Signature, err = crypto.SignMessage(n.key, canonical); err != nil {
+		t.Fatal(err)
+	}
+	tx.PubKey = crypto.EncodePublicKey(&n.key.PublicKey)
+	return tx
+}
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type mineResult struct {
+	block *chain.Block
+	txs   []*chain.Transaction
+	err   error
+}
+
+// mineAsync runs MineBlock on its own goroutine.
+func (n *testNode) mineAsync() <-chan mineResult {
+	done := make(chan mineResult, 1)
+	go func() {
+		block, txs, err := n.miner.MineBlock(context.Background(), n.address)
+		done <- mineResult{block, txs, err}
+	}()
+	return done
+}
+
+// A transaction that got into the pool without being valid on the tip must
+// not wedge the miner: the template drops it, and its descendants, from
+// the pool before any work is spent.
+func TestTemplateEvictsInvalidTransactions(t *testing.T) {
+	n := newTestNode(t)
+
+	missing := chain.TxIn{TxID: strings.Repeat("f", 64), Index: 0}
+	invalid := n.spend(t, missing, 10, 1)
+	if err := n.mempool.AddTransaction(invalid, 1); err != nil {
+		t.Fatal(err)
+	}
+	child := n.spend(t, chain.TxIn{TxID: invalid.ID, Index: 0}, invalid.Outputs[0].Amount, 1)
+	if err := n.mempool.AddTransaction(child, 1); err != nil {
+		t.Fatal(err)
+	}
+	genesis := n.chain.Genesis().Transactions[0]
+	valid := n.spend(t, chain.TxIn{TxID: genesis.ID, Index: 0}, genesis.Outputs[0].Amount, 1)
+	if err := n.pipeline.AddTransaction(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := n.miner.Produce(context.Background(), n.address)
+	if err != nil {
+		t.Fatalf("Produce: %v", err)
+	}
+	if len(block.Transactions) != 2 || block.Transactions[1].ID != valid.ID {
+		t.Fatalf("block holds %d transactions, want the coinbase and %s", len(block.Transactions), valid.ID)
+	}
+	if size := n.mempool.Size(); size != 0 {
+		t.Fatalf("%d transactions left pending, want the invalid ones evicted", size)
+	}
+}
+
+// A block the chain turns down for a reason of its own hands its
+// transactions back to the pool rather than evicting them.
+func TestRejectedBlockReleasesTransactions(t *testing.T) {
+	n := newTestNode(t)
+	genesis := n.chain.Genesis().Transactions[0]
+	tx := n.spend(t, chain.TxIn{TxID: genesis.ID, Index: 0}, genesis.Outputs[0].Amount, 1)
+	if err := n.pipeline.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	n.chain.Freeze("test")
+	if _, err := n.miner.Produce(context.Background(), n.address); !errors.Is(err, ErrBlockRejected) || !errors.Is(err, chain.ErrChainFrozen) {
+		t.Fatalf("Produce on a frozen chain: err = %v, want ErrBlockRejected wrapping ErrChainFrozen", err)
+	}
+	if !n.mempool.Has(tx.ID) || n.mempool.InFlight(tx.ID) {
+		t.Fatalf("valid transaction evicted or still reserved after the rejection")
+	}
+
+	n.chain.Unfreeze()
+	block, err := n.miner.Produce(context.Background(), n.address)
+	if err != nil {
+		t.Fatalf("Produce after unfreezing: %v", err)
+	}
+	if len(block.Transactions) != 2 || block.Transactions[1].ID != tx.ID {
+		t.Fatalf("block holds %d transactions, want the coinbase and %s", len(block.Transactions), tx.ID)
+	}
+}
+
+// A high-fee arrival restarts the job on a template that includes it, and
+// canceling the job releases what that template reserved.
+func TestArrivalRefreshesTemplate(t *testing.T) {
+	n := newTestNode(t)
+	n.chain.SetDifficulty(unsolvable)
+	n.miner.SetRefreshPolicy(RefreshPolicy{MinFee: 1, MaxRefreshes: 1})
+
+	done := n.mineAsync()
+	// The first template, with only a coinbase, is being hashed.
+	waitFor(t, "mining to start", func() bool { return n.miner.meter.hashes.Load() > 0 })
+
+	genesis := n.chain.Genesis().Transactions[0]
+	tx := n.spend(t, chain.TxIn{TxID: genesis.ID, Index: 0}, genesis.Outputs[0].Amount, 2)
+	if err := n.pipeline.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "a refreshed template to reserve the arrival", func() bool { return n.mempool.InFlight(tx.ID) })
+
+	if !n.miner.Cancel() {
+		t.Fatal("Cancel found no job running")
+	}
+	if r := <-done; !errors.Is(r.err, ErrMiningCanceled) {
+		t.Fatalf("MineBlock: err = %v, want ErrMiningCanceled", r.err)
+	}
+	if n.mempool.InFlight(tx.ID) {
+		t.Fatal("transaction still reserved after the job was canceled")
+	}
+}
+
+// A peer's block moving the tip restarts the job on the new tip, and the
+// transactions the old template reserved are released so the new one can
+// take them.
+func TestTipChangeReleasesReservedTransactions(t *testing.T) {
+	n := newTestNode(t)
+	genesis := n.chain.Genesis()
+
+	// Mined before the chain's difficulty goes up, on a chain of its own.
+	peer := chaintest.MineBlock(t, chaintest.FromGenesis(genesis), strings.Repeat("b", 64))
+
+	tx := n.spend(t, chain.TxIn{TxID: genesis.Transactions[0].ID, Index: 0}, genesis.Transactions[0].Outputs[0].Amount, 1)
+	if err := n.pipeline.AddTransaction(tx); err != nil {
+		t.Fatal(err)
+	}
+
+	n.chain.SetDifficulty(unsolvable)
+	done := n.mineAsync()
+	waitFor(t, "the first template to reserve the transaction", func() bool { return n.mempool.InFlight(tx.ID) })
+
+	// Block 2 takes its difficulty from the peer's block and is solved.
+	n.chain.SetDifficulty(1)
+	if err := n.pipeline.ConnectBlock(peer); err != nil {
+		t.Fatalf("ConnectBlock: %v", err)
+	}
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("MineBlock: %v", r.err)
+	}
+	if r.block.PrevHash != peer.Hash {
+		t.Fatalf("block built on %s, want the peer's block %s", r.block.PrevHash, peer.Hash)
+	}
+	if len(r.txs) != 1 || r.txs[0].ID != tx.ID {
+		t.Fatalf("new template reserved %d transactions, want %s", len(r.txs), tx.ID)
+	}
+	if err := n.miner.Submit(r.block); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+}
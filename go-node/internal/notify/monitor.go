@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type Thresholds struct {
+	ChainStall  time.Duration // no new block for this long (0 = disabled)
+	AIDown      time.Duration // AI service unreachable for this long (0 = disabled)
+	MempoolSize int           // pending transactions above this count (0 = disabled)
+	ReorgDepth  int           // reorgs deeper than this are reported (0 = disabled)
+}
+
+type Probes struct {
+	TipTime     func() time.Time
+	MempoolSize func() int
+	AIHealthy   func() bool // nil when AI scoring is disabled
+	// ClockSkew reports the median offset of peer clocks from ours and
+	// whether it is beyond the clock's warning threshold. Nil without P2P.
+	ClockSkew func() (time.Duration, bool)
+}
+
+type Monitor struct {
+	notifier   *Notifier
+	thresholds Thresholds
+	probes     Probes
+	interval   time.Duration
+
+	aiDownSince time.Time
+}
+
+func NewMonitor(notifier *Notifier, thresholds Thresholds, probes Probes, interval time.Duration) *Monitor {
+	return &Monitor{
+		notifier:   notifier,
+		thresholds: thresholds,
+		probes:     probes,
+		interval:   interval,
+	}
+}
+
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.check(time.Now())
+		}
+	}
+}
+
+// ObserveReorg is called by the chain whenever the tip switches branches.
+func (m *Monitor) ObserveReorg(depth int, oldTip, newTip string) {
+	if m.thresholds.ReorgDepth > 0 && depth > m.thresholds.ReorgDepth {
+		m.notifier.DeepReorg(depth, oldTip, newTip)
+	}
+}
+
+func (m *Monitor) check(now time.Time) {
+	if m.thresholds.ChainStall > 0 && m.probes.TipTime != nil {
+		since := now.Sub(m.probes.TipTime())
+		if since > m.thresholds.ChainStall {
+			m.notifier.Notify(Event{
+				Kind:     EventChainStalled,
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("no new block for %s", since.Round(time.Second)),
+			})
+		} else {
+			m.notifier.Reset(EventChainStalled)
+		}
+	}
+
+	if m.thresholds.MempoolSize > 0 && m.probes.MempoolSize != nil {
+		size := m.probes.MempoolSize()
+		if size > m.thresholds.MempoolSize {
+			m.notifier.Notify(Event{
+				Kind:     EventMempoolFull,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("mempool holds %d transactions (threshold %d)", size, m.thresholds.MempoolSize),
+				Fields:   map[string]interface{}{"size": size},
+			})
+		} else {
+			m.notifier.Reset(EventMempoolFull)
+		}
+	}
+
+	if m.probes.ClockSkew != nil {
+		if skew, skewed := m.probes.ClockSkew(); skewed {
+			m.notifier.Notify(Event{
+				Kind:     EventClockSkew,
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("local clock is %s off the median of peer clocks; check the system time", skew.Round(time.Second)),
+				Fields:   map[string]interface{}{"offset_seconds": skew.Seconds()},
+			})
+		} else {
+			m.notifier.Reset(EventClockSkew)
+		}
+	}
+
+	if m.thresholds.AIDown > 0 && m.probes.AIHealthy != nil {
+		if m.probes.AIHealthy() {
+			m.aiDownSince = time.Time{}
+			m.notifier.Reset(EventAIDown)
+		} else {
+			if m.aiDownSince.IsZero() {
+				m.aiDownSince = now
+			}
+			if down := now.Sub(m.aiDownSince); down > m.thresholds.AIDown {
+				m.notifier.Notify(Event{
+					Kind:     EventAIDown,
+					Severity: SeverityWarning,
+					Message:  fmt.Sprintf("AI service unreachable for %s", down.Round(time.Second)),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+const (
+	EventChainStalled = "chain_stalled"
+	EventDeepReorg    = "deep_reorg"
+	EventStorageError = "storage_error"
+	EventAIDown       = "ai_down"
+	EventMempoolFull  = "mempool_above_threshold"
+	EventClockSkew    = "clock_skew"
+)
+
+type Event struct {
+	Kind     string                 `json:"kind"`
+	Severity Severity               `json:"severity"`
+	Message  string                 `json:"message"`
+	Time     int64                  `json:"time"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+}
+
+type Sink interface {
+	Name() string
+	Send(event Event) error
+}
+
+// Notifier fans events out to every configured sink. Repeated events of the
+// same kind are suppressed for the cooldown period so a stuck condition
+// doesn't page the operator on every monitor tick.
+type Notifier struct {
+	mu       sync.Mutex
+	sinks    []Sink
+	cooldown time.Duration
+	lastSent map[string]time.Time
+	queue    *DeliveryQueue // nil = failed sends are only logged
+}
+
+func NewNotifier(cooldown time.Duration, sinks ...Sink) *Notifier {
+	return &Notifier{
+		sinks:    sinks,
+		cooldown: cooldown,
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.sinks) > 0
+}
+
+func (n *Notifier) Notify(event Event) {
+	if !n.Enabled() {
+		return
+	}
+
+	n.mu.Lock()
+	now := time.Now()
+	if last, ok := n.lastSent[event.Kind]; ok && now.Sub(last) < n.cooldown {
+		n.mu.Unlock()
+		return
+	}
+	n.lastSent[event.Kind] = now
+	n.mu.Unlock()
+
+	if event.Time == 0 {
+		event.Time = now.Unix()
+	}
+
+	for _, sink := range n.sinks {
+		go func(s Sink) {
+			if err := s.Send(event); err != nil {
+				slog.Warn("Notification failed", "sink", s.Name(), "err", err)
+				if n.queue != nil {
+					n.queue.enqueue(s, event, err)
+				}
+			}
+		}(sink)
+	}
+}
+
+// SetQueue has failed sends retried by q instead of dropped.
+func (n *Notifier) SetQueue(q *DeliveryQueue) {
+	for _, sink := range n.sinks {
+		q.register(sink)
+	}
+	n.queue = q
+}
+
+// Reset clears the cooldown for a kind once its condition has recovered, so
+// the next occurrence is reported immediately.
+func (n *Notifier) Reset(kind string) {
+	if !n.Enabled() {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.lastSent, kind)
+}
+
+func (n *Notifier) DeepReorg(depth int, oldTip, newTip string) {
+	n.Notify(Event{
+		Kind:     EventDeepReorg,
+		Severity: SeverityCritical,
+		Message:  "chain reorganization deeper than threshold",
+		Fields: map[string]interface{}{
+			"depth":   depth,
+			"old_tip": oldTip,
+			"new_tip": newTip,
+		},
+	})
+}
+
+func (n *Notifier) StorageError(err error) {
+	n.Notify(Event{
+		Kind:     EventStorageError,
+		Severity: SeverityCritical,
+		Message:  "storage error: " + err.Error(),
+	})
+}
@@ -0,0 +1,341 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultInitialBackoff = 10 * time.Second
+	DefaultMaxBackoff     = time.Hour
+	DefaultMaxAttempts    = 12
+	DefaultMaxDead        = 1000
+)
+
+var ErrUnknownDelivery = errors.New("no such dead letter")
+
+// RetryPolicy says how a DeliveryQueue retries. The wait before attempt n+1
+// is InitialBackoff doubled n-1 times, capped at MaxBackoff, with up to 10%
+// jitter. After MaxAttempts failed attempts the delivery becomes a dead
+// letter; at most MaxDead are kept, oldest dropped first.
+type RetryPolicy struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxAttempts    int
+	MaxDead        int
+}
+
+// Delivery is one event that a sink has not accepted yet.
+type Delivery struct {
+	ID          string `json:"id"`
+	Sink        string `json:"sink"`
+	Event       Event  `json:"event"`
+	Attempts    int    `json:"attempts"`
+	LastError   string `json:"last_error,omitempty"`
+	CreatedAt   int64  `json:"created_at"`
+	NextAttempt int64  `json:"next_attempt_at,omitempty"`
+	DeadAt      int64  `json:"dead_at,omitempty"`
+}
+
+// QueueStatus is what GET /admin/notifications reports.
+type QueueStatus struct {
+	Pending   []Delivery `json:"pending"`
+	Dead      []Delivery `json:"dead"`
+	Delivered int        `json:"delivered"` // retries that succeeded since start
+}
+
+type queueFile struct {
+	NextID  uint64      `json:"next_id"`
+	Pending []*Delivery `json:"pending"`
+	Dead    []*Delivery `json:"dead"`
+}
+
+// DeliveryQueue holds notifications a sink failed to deliver and retries
+// them with exponential backoff. With a path, the queue is written to disk
+// on every change, so undelivered notifications survive restarts.
+type DeliveryQueue struct {
+	path   string
+	policy RetryPolicy
+	wake   chan struct{}
+
+	mu        sync.Mutex
+	sinks     map[string]Sink
+	nextID    uint64
+	pending   []*Delivery
+	dead      []*Delivery // oldest first
+	delivered int
+}
+
+// LoadDeliveryQueue reads path if it exists. An empty path keeps the queue
+// in memory.
+func LoadDeliveryQueue(path string, policy RetryPolicy) (*DeliveryQueue, error) {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = DefaultInitialBackoff
+	}
+	if policy.MaxBackoff < policy.InitialBackoff {
+		policy.MaxBackoff = policy.InitialBackoff
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.MaxDead <= 0 {
+		policy.MaxDead = DefaultMaxDead
+	}
+	q := &DeliveryQueue{
+		path:   path,
+		policy: policy,
+		wake:   make(chan struct{}, 1),
+		sinks:  make(map[string]Sink),
+	}
+	if path == "" {
+		return q, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f queueFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("notification queue %s: %w", path, err)
+	}
+	q.nextID, q.pending, q.dead = f.NextID, f.Pending, f.Dead
+	return q, nil
+}
+
+// enqueue records that sink failed to deliver event on the first attempt.
+func (q *DeliveryQueue) enqueue(sink Sink, event Event, sendErr error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sinks[sink.Name()] = sink
+	q.nextID++
+	now := time.Now()
+	d := &Delivery{
+		ID:        strconv.FormatUint(q.nextID, 10),
+		Sink:      sink.Name(),
+		Event:     event,
+		Attempts:  1,
+		LastError: sendErr.Error(),
+		CreatedAt: now.Unix(),
+	}
+	q.pending = append(q.pending, d)
+	q.scheduleLocked(d, now)
+	q.saveLocked()
+	q.signal()
+}
+
+// register makes sink available to retry deliveries loaded from disk.
+func (q *DeliveryQueue) register(sink Sink) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.sinks[sink.Name()] = sink
+}
+
+// scheduleLocked sets when d, a pending delivery, is next attempted, or
+// moves it to the dead letters once it has used all its attempts.
+func (q *DeliveryQueue) scheduleLocked(d *Delivery, now time.Time) {
+	if d.Attempts >= q.policy.MaxAttempts {
+		q.removePendingLocked(d)
+		d.NextAttempt = 0
+		d.DeadAt = now.Unix()
+		q.dead = append(q.dead, d)
+		if over := len(q.dead) - q.policy.MaxDead; over > 0 {
+			q.dead = q.dead[over:]
+		}
+		slog.Error("Notification undeliverable, moved to dead letters", "id", d.ID, "sink", d.Sink, "kind", d.Event.Kind, "attempts", d.Attempts, "err", d.LastError)
+		return
+	}
+
+	backoff := q.policy.InitialBackoff
+	for i := 1; i < d.Attempts && backoff < q.policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > q.policy.MaxBackoff {
+		backoff = q.policy.MaxBackoff
+	}
+	backoff += time.Duration(rand.Int63n(int64(backoff)/10 + 1))
+	d.NextAttempt = now.Add(backoff).Unix()
+}
+
+func (q *DeliveryQueue) removePendingLocked(d *Delivery) {
+	for i, p := range q.pending {
+		if p == d {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *DeliveryQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run retries due deliveries until ctx is done.
+func (q *DeliveryQueue) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-q.wake:
+		}
+		q.retryDue(time.Now())
+
+		timer.Stop()
+		timer.Reset(q.untilNext(time.Now()))
+	}
+}
+
+// untilNext is the time until the next delivery is due, or a minute when
+// none are pending. Deliveries for sinks no longer configured wait until
+// the sink comes back.
+func (q *DeliveryQueue) untilNext(now time.Time) time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	wait := time.Minute
+	for _, d := range q.pending {
+		if _, ok := q.sinks[d.Sink]; !ok {
+			continue
+		}
+		if until := time.Unix(d.NextAttempt, 0).Sub(now); until < wait {
+			wait = until
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// retryDue attempts every due delivery once. Deliveries stay in pending,
+// and so on disk, until they succeed or die.
+func (q *DeliveryQueue) retryDue(now time.Time) {
+	q.mu.Lock()
+	var due []*Delivery
+	for _, d := range q.pending {
+		if _, ok := q.sinks[d.Sink]; ok && d.NextAttempt <= now.Unix() {
+			due = append(due, d)
+		}
+	}
+	q.mu.Unlock()
+
+	for _, d := range due {
+		q.mu.Lock()
+		sink := q.sinks[d.Sink]
+		q.mu.Unlock()
+
+		err := sink.Send(d.Event)
+
+		q.mu.Lock()
+		d.Attempts++
+		if err == nil {
+			q.removePendingLocked(d)
+			q.delivered++
+			slog.Info("Notification delivered on retry", "id", d.ID, "sink", d.Sink, "kind", d.Event.Kind, "attempts", d.Attempts)
+		} else {
+			d.LastError = err.Error()
+			q.scheduleLocked(d, time.Now())
+		}
+		q.saveLocked()
+		q.mu.Unlock()
+	}
+}
+
+// Status lists pending deliveries, soonest first, and dead letters, newest
+// first.
+func (q *DeliveryQueue) Status() QueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	status := QueueStatus{
+		Pending:   make([]Delivery, 0, len(q.pending)),
+		Dead:      make([]Delivery, 0, len(q.dead)),
+		Delivered: q.delivered,
+	}
+	for _, d := range q.pending {
+		status.Pending = append(status.Pending, *d)
+	}
+	sort.Slice(status.Pending, func(i, j int) bool { return status.Pending[i].NextAttempt < status.Pending[j].NextAttempt })
+	for i := len(q.dead) - 1; i >= 0; i-- {
+		status.Dead = append(status.Dead, *q.dead[i])
+	}
+	return status
+}
+
+// Requeue gives a dead letter, or every one when id is empty, a fresh set
+// of attempts starting now. It returns how many were requeued.
+func (q *DeliveryQueue) Requeue(id string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	moved := 0
+	kept := q.dead[:0]
+	for _, d := range q.dead {
+		if id != "" && d.ID != id {
+			kept = append(kept, d)
+			continue
+		}
+		d.Attempts, d.DeadAt, d.NextAttempt = 0, 0, time.Now().Unix()
+		q.pending = append(q.pending, d)
+		moved++
+	}
+	q.dead = kept
+	if id != "" && moved == 0 {
+		return 0, ErrUnknownDelivery
+	}
+	q.signal()
+	return moved, q.saveLocked()
+}
+
+// Discard drops a dead letter, or every one when id is empty. It returns
+// how many were dropped.
+func (q *DeliveryQueue) Discard(id string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dropped := 0
+	kept := q.dead[:0]
+	for _, d := range q.dead {
+		if id != "" && d.ID != id {
+			kept = append(kept, d)
+			continue
+		}
+		dropped++
+	}
+	q.dead = kept
+	if id != "" && dropped == 0 {
+		return 0, ErrUnknownDelivery
+	}
+	return dropped, q.saveLocked()
+}
+
+func (q *DeliveryQueue) saveLocked() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(queueFile{NextID: q.nextID, Pending: q.pending, Dead: q.dead}, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := q.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		slog.Error("Failed to save notification queue", "path", q.path, "err", err)
+		return err
+	}
+	return os.Rename(tmp, q.path)
+}
@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// flakySink fails its first failures sends, then accepts. The queue's own
+// first attempt is the enqueue, which does not send.
+type flakySink struct {
+	failures int
+	sent     int
+}
+
+func (s *flakySink) Name() string { return "flaky" }
+
+func (s *flakySink) Send(event Event) error {
+	s.sent++
+	if s.sent <= s.failures {
+		return errors.New("sink down")
+	}
+	return nil
+}
+
+var testPolicy = RetryPolicy{InitialBackoff: 10 * time.Second, MaxBackoff: 40 * time.Second, MaxAttempts: 4, MaxDead: 2}
+
+// later is far enough ahead that every pending delivery is due.
+func later() time.Time { return time.Now().Add(time.Hour) }
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	q, err := LoadDeliveryQueue("", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &flakySink{failures: 1}
+	start := time.Now()
+	q.enqueue(sink, Event{Kind: EventDeepReorg}, errors.New("sink down"))
+
+	// The wait doubles from InitialBackoff, with at most 10% jitter.
+	d := q.Status().Pending[0]
+	if wait := time.Unix(d.NextAttempt, 0).Sub(start.Truncate(time.Second)); wait < 10*time.Second || wait > 12*time.Second {
+		t.Errorf("first retry after %s, want 10s to 11s", wait)
+	}
+	q.retryDue(time.Now())
+	if sink.sent != 0 {
+		t.Fatal("delivery retried before it was due")
+	}
+
+	retried := time.Now()
+	q.retryDue(later())
+	d = q.Status().Pending[0]
+	if d.Attempts != 2 || d.LastError != "sink down" {
+		t.Fatalf("after a failed retry: %+v", d)
+	}
+	if wait := time.Unix(d.NextAttempt, 0).Sub(retried.Truncate(time.Second)); wait < 20*time.Second || wait > 23*time.Second {
+		t.Errorf("second retry after %s, want 20s to 22s", wait)
+	}
+
+	q.retryDue(later())
+	status := q.Status()
+	if len(status.Pending) != 0 || len(status.Dead) != 0 || status.Delivered != 1 {
+		t.Fatalf("after a successful retry: %+v", status)
+	}
+	if sink.sent != 2 {
+		t.Errorf("%d sends, want a failed retry and the success", sink.sent)
+	}
+}
+
+func TestQueueDeadLetters(t *testing.T) {
+	q, err := LoadDeliveryQueue("", testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &flakySink{failures: 1 << 30}
+	for i := 0; i < 3; i++ {
+		q.enqueue(sink, Event{Kind: EventStorageError}, errors.New("sink down"))
+	}
+	for i := 1; i < testPolicy.MaxAttempts; i++ {
+		q.retryDue(later())
+	}
+	status := q.Status()
+	if len(status.Pending) != 0 {
+		t.Fatalf("%d deliveries still pending after %d attempts", len(status.Pending), testPolicy.MaxAttempts)
+	}
+	// MaxDead keeps the newest two, listed newest first.
+	if len(status.Dead) != 2 || status.Dead[0].ID != "3" || status.Dead[1].ID != "2" {
+		t.Fatalf("dead letters %+v, want 3 and 2", status.Dead)
+	}
+	if d := status.Dead[0]; d.Attempts != testPolicy.MaxAttempts || d.DeadAt == 0 || d.NextAttempt != 0 {
+		t.Errorf("dead letter %+v", d)
+	}
+
+	if _, err := q.Requeue("1"); !errors.Is(err, ErrUnknownDelivery) {
+		t.Errorf("Requeue of a dropped letter: err = %v, want ErrUnknownDelivery", err)
+	}
+	if n, err := q.Requeue("3"); n != 1 || err != nil {
+		t.Fatalf("Requeue: %d, %v", n, err)
+	}
+	sink.failures = 0
+	q.retryDue(time.Now())
+	if status := q.Status(); status.Delivered != 1 || len(status.Pending) != 0 || len(status.Dead) != 1 {
+		t.Fatalf("after requeueing: %+v", status)
+	}
+
+	if n, err := q.Discard(""); n != 1 || err != nil {
+		t.Fatalf("Discard: %d, %v", n, err)
+	}
+	if _, err := q.Discard("2"); !errors.Is(err, ErrUnknownDelivery) {
+		t.Errorf("Discard of a discarded letter: err = %v, want ErrUnknownDelivery", err)
+	}
+}
+
+// Undelivered notifications survive a restart, and wait for their sink to
+// be configured again.
+func TestQueuePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.json")
+	q, err := LoadDeliveryQueue(path, testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.enqueue(&flakySink{failures: 1}, Event{Kind: EventAIDown, Message: "down"}, errors.New("sink down"))
+
+	q, err = LoadDeliveryQueue(path, testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := q.Status().Pending
+	if len(pending) != 1 || pending[0].Event.Message != "down" || pending[0].Attempts != 1 {
+		t.Fatalf("reloaded pending %+v", pending)
+	}
+
+	q.retryDue(later())
+	if wait := q.untilNext(time.Now()); wait != time.Minute {
+		t.Errorf("delivery for an unconfigured sink due in %s, want it left waiting", wait)
+	}
+	sink := &flakySink{}
+	q.register(sink)
+	q.retryDue(later())
+	if sink.sent != 1 || len(q.Status().Pending) != 0 {
+		t.Fatalf("registered sink got %d sends, %d still pending", sink.sent, len(q.Status().Pending))
+	}
+
+	// A new ID after a restart does not reuse one already handed out.
+	q, err = LoadDeliveryQueue(path, testPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.enqueue(sink, Event{Kind: EventAIDown}, errors.New("sink down"))
+	if id := q.Status().Pending[0].ID; id != "2" {
+		t.Errorf("new delivery got ID %s, want 2", id)
+	}
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func postJSON(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type WebhookSink struct {
+	URL string
+}
+
+func (s *WebhookSink) Name() string { return "webhook" }
+
+func (s *WebhookSink) Send(event Event) error {
+	return postJSON(s.URL, event)
+}
+
+type SlackSink struct {
+	WebhookURL string
+}
+
+func (s *SlackSink) Name() string { return "slack" }
+
+func (s *SlackSink) Send(event Event) error {
+	text := fmt.Sprintf("[%s] %s: %s", strings.ToUpper(string(event.Severity)), event.Kind, event.Message)
+	for k, v := range event.Fields {
+		text += fmt.Sprintf("\n• %s: %v", k, v)
+	}
+	return postJSON(s.WebhookURL, map[string]string{"text": text})
+}
+
+type EmailSink struct {
+	SMTPAddr string // host:port
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+func (s *EmailSink) Name() string { return "email" }
+
+func (s *EmailSink) Send(event Event) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.From)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&body, "Subject: [node %s] %s\r\n\r\n", event.Severity, event.Kind)
+	fmt.Fprintf(&body, "%s\r\n", event.Message)
+	for k, v := range event.Fields {
+		fmt.Fprintf(&body, "%s: %v\r\n", k, v)
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host := s.SMTPAddr
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	return smtp.SendMail(s.SMTPAddr, auth, s.From, s.To, []byte(body.String()))
+}
@@ -0,0 +1,227 @@
+package p2p
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultBanDuration = 24 * time.Hour
+
+type Ban struct {
+	Host      string `json:"host"`
+	Reason    string `json:"reason"`
+	CreatedAt int64  `json:"created_at"`
+	Until     int64  `json:"until"` // unix seconds, 0 = permanent
+}
+
+func (b *Ban) expired(now time.Time) bool {
+	return b.Until != 0 && now.Unix() >= b.Until
+}
+
+// BanList tracks banned peer hosts. Bans are keyed by IP rather than
+// host:port because outbound connections come from ephemeral ports. With a
+// path the list is written to disk on every change and survives restarts.
+type BanList struct {
+	path string
+
+	mu   sync.Mutex
+	bans map[string]*Ban
+}
+
+// LoadBanList reads path if it exists. An empty path keeps bans in memory.
+func LoadBanList(path string) (*BanList, error) {
+	bl := &BanList{path: path, bans: make(map[string]*Ban)}
+	if path == "" {
+		return bl, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return bl, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var bans []*Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return nil, fmt.Errorf("ban list %s: %w", path, err)
+	}
+	now := time.Now()
+	for _, b := range bans {
+		if !b.expired(now) {
+			bl.bans[b.Host] = b
+		}
+	}
+	return bl, nil
+}
+
+// hostOf strips the port from addr, accepting bare hosts too.
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Trim(addr, "[]")
+}
+
+// Ban bans the host of addr for d (0 = permanent).
+func (bl *BanList) Ban(addr, reason string, d time.Duration) (Ban, error) {
+	host := hostOf(addr)
+	if host == "" {
+		return Ban{}, errors.New("address required")
+	}
+
+	now := time.Now()
+	b := &Ban{Host: host, Reason: reason, CreatedAt: now.Unix()}
+	if d > 0 {
+		b.Until = now.Add(d).Unix()
+	}
+
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	bl.bans[host] = b
+	return *b, bl.saveLocked()
+}
+
+// Unban lifts the ban on the host of addr, reporting whether there was one.
+func (bl *BanList) Unban(addr string) (bool, error) {
+	host := hostOf(addr)
+
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if _, ok := bl.bans[host]; !ok {
+		return false, nil
+	}
+	delete(bl.bans, host)
+	return true, bl.saveLocked()
+}
+
+func (bl *BanList) IsBanned(addr string) bool {
+	host := hostOf(addr)
+
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	b, ok := bl.bans[host]
+	if !ok {
+		return false
+	}
+	if b.expired(time.Now()) {
+		delete(bl.bans, host)
+		if err := bl.saveLocked(); err != nil {
+			slog.Error("P2P failed to save ban list", "err", err)
+		}
+		return false
+	}
+	return true
+}
+
+func (bl *BanList) List() []Ban {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+
+	now := time.Now()
+	out := make([]Ban, 0, len(bl.bans))
+	for _, b := range bl.bans {
+		if !b.expired(now) {
+			out = append(out, *b)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+func (bl *BanList) saveLocked() error {
+	if bl.path == "" {
+		return nil
+	}
+	bans := make([]*Ban, 0, len(bl.bans))
+	for _, b := range bl.bans {
+		bans = append(bans, b)
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Host < bans[j].Host })
+
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := bl.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, bl.path)
+}
+
+// AllowList admits only peers matching one of its entries: an IP, a CIDR
+// range or a hostname (resolved on each check). A nil AllowList admits
+// everyone.
+type AllowList struct {
+	ips   map[string]bool
+	nets  []*net.IPNet
+	names []string
+}
+
+func ParseAllowList(entries []string) (*AllowList, error) {
+	al := &AllowList{ips: make(map[string]bool)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			al.nets = append(al.nets, ipNet)
+			continue
+		}
+		host := hostOf(entry)
+		if ip := net.ParseIP(host); ip != nil {
+			al.ips[ip.String()] = true
+			continue
+		}
+		al.names = append(al.names, host)
+	}
+	if len(al.ips) == 0 && len(al.nets) == 0 && len(al.names) == 0 {
+		return nil, errors.New("allow-list has no entries")
+	}
+	return al, nil
+}
+
+func (al *AllowList) Enabled() bool {
+	return al != nil
+}
+
+func (al *AllowList) Allows(addr string) bool {
+	if al == nil {
+		return true
+	}
+	ip := net.ParseIP(hostOf(addr))
+	if ip == nil {
+		return false
+	}
+	if al.ips[ip.String()] {
+		return true
+	}
+	for _, n := range al.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	for _, name := range al.names {
+		addrs, err := net.LookupHost(name)
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if resolved := net.ParseIP(a); resolved != nil && resolved.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
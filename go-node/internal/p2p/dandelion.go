@@ -0,0 +1,251 @@
+package p2p
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	DefaultFluffProbability = 0.1
+	DefaultStemEmbargo      = 30 * time.Second
+	stemEpoch               = 10 * time.Minute
+	fluffMarkTTL            = time.Minute
+)
+
+// DandelionPolicy enables stem/fluff relay. A transaction created on this
+// node is not announced to every peer; it is passed along a stem, one
+// relay at a time, and each relay diffuses it normally ("fluffs") with
+// probability FluffProbability. An observer who sees the broadcast start
+// learns only where the stem ended, not where it began. Every node that
+// stems a transaction holds an embargo on it: if the transaction is not
+// seen being fluffed within Embargo (plus jitter), the node fluffs it
+// itself, so a relay that drops stem transactions cannot make them vanish.
+type DandelionPolicy struct {
+	Enabled          bool
+	FluffProbability float64
+	Embargo          time.Duration
+}
+
+func (p DandelionPolicy) withDefaults() DandelionPolicy {
+	if p.FluffProbability <= 0 || p.FluffProbability > 1 {
+		p.FluffProbability = DefaultFluffProbability
+	}
+	if p.Embargo <= 0 {
+		p.Embargo = DefaultStemEmbargo
+	}
+	return p
+}
+
+// DandelionStatus is reported under "dandelion" in GET /peers.
+type DandelionStatus struct {
+	StemPeer   string `json:"stem_peer,omitempty"`
+	Embargoed  int    `json:"embargoed"`
+	EpochEndAt int64  `json:"epoch_end_at,omitempty"`
+}
+
+type embargo struct {
+	tx    *chain.Transaction
+	local bool // already in our mempool; only the broadcast is held back
+	until time.Time
+}
+
+type dandelion struct {
+	n      *Network
+	policy DandelionPolicy
+
+	mu       sync.Mutex
+	stemPeer *Peer
+	epochEnd time.Time
+	embargo  map[string]*embargo
+	fluffed  map[string]time.Time // txids entering the mempool in the fluff phase
+}
+
+func newDandelion(n *Network, policy DandelionPolicy) *dandelion {
+	return &dandelion{
+		n:       n,
+		policy:  policy.withDefaults(),
+		embargo: make(map[string]*embargo),
+		fluffed: make(map[string]time.Time),
+	}
+}
+
+// stemPeerLocked returns the current stem relay, picking a new one at
+// random when the epoch has ended or the relay went away. Outbound peers are
+// preferred since inbound connections are cheap for an observer to open.
+// Keeping the relay fixed for an epoch stops an observer from collecting
+// many stems from one origin through different relays.
+func (d *dandelion) stemPeerLocked() *Peer {
+	if p := d.stemPeer; p != nil && time.Now().Before(d.epochEnd) {
+		select {
+		case <-p.Done():
+		default:
+			return p
+		}
+	}
+
+	var outbound, inbound []*Peer
+	d.n.mu.RLock()
+	for p := range d.n.peers {
+		if p.Version() == nil {
+			continue
+		}
+		if p.inbound {
+			inbound = append(inbound, p)
+		} else {
+			outbound = append(outbound, p)
+		}
+	}
+	d.n.mu.RUnlock()
+
+	candidates := outbound
+	if len(candidates) == 0 {
+		candidates = inbound
+	}
+	d.stemPeer = nil
+	if len(candidates) > 0 {
+		d.stemPeer = candidates[rand.Intn(len(candidates))]
+		d.epochEnd = time.Now().Add(stemEpoch)
+	}
+	return d.stemPeer
+}
+
+// markFluffed records that tx is entering the mempool in the fluff phase,
+// so relayTx broadcasts it rather than starting a stem, and lifts any
+// embargo on it.
+func (d *dandelion) markFluffed(txID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fluffed[txID] = time.Now()
+	delete(d.embargo, txID)
+}
+
+// stemLocal starts a stem for a transaction that entered the mempool
+// without having been fluffed, i.e. one submitted to this node. It reports
+// false when tx should be broadcast normally instead.
+func (d *dandelion) stemLocal(tx *chain.Transaction) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.fluffed[tx.ID]; ok {
+		delete(d.fluffed, tx.ID)
+		return false
+	}
+	return d.stemLocked(tx, true, nil)
+}
+
+// stemLocked forwards tx to the stem relay unless that is from, and
+// embargoes it. It reports false when there is nowhere to send it.
+func (d *dandelion) stemLocked(tx *chain.Transaction, local bool, from *Peer) bool {
+	p := d.stemPeerLocked()
+	if p == nil || p == from {
+		return false
+	}
+	if err := p.SendPayload(MsgStemTx, tx); err != nil {
+		return false
+	}
+	// Jitter keeps the nodes along a stem from all timing out together,
+	// which would point back at the origin.
+	jitter := time.Duration(rand.Int63n(int64(d.policy.Embargo)/2 + 1))
+	d.embargo[tx.ID] = &embargo{tx: tx, local: local, until: time.Now().Add(d.policy.Embargo + jitter)}
+	return true
+}
+
+// handleStemTx validates a stem transaction from p and either extends the
+// stem or fluffs it.
+func (n *Network) handleStemTx(p *Peer, tx *chain.Transaction) {
+	d := n.dandelion
+	if d == nil {
+		// Without Dandelion there is no stem to extend.
+		n.handleTx(p, tx)
+		return
+	}
+	if n.mempool.Has(tx.ID) {
+		return
+	}
+	d.mu.Lock()
+	_, held := d.embargo[tx.ID]
+	d.mu.Unlock()
+	if held {
+		return // the stem looped back
+	}
+
+	if !n.checkTx(p, tx) {
+		return
+	}
+
+	if rand.Float64() >= d.policy.FluffProbability {
+		d.mu.Lock()
+		stemmed := d.stemLocked(tx, false, p)
+		d.mu.Unlock()
+		if stemmed {
+			return
+		}
+	}
+	n.fluff(tx, p.Addr())
+}
+
+// fluff adds a transaction that was on a stem to the mempool, which
+// broadcasts it.
+func (n *Network) fluff(tx *chain.Transaction, source string) {
+	n.dandelion.markFluffed(tx.ID)
+	if err := n.blocks.AddTransaction(tx); err == nil {
+		slog.Debug("P2P fluffed transaction", "txid", tx.ID, "stem_from", source)
+	}
+}
+
+// embargoLoop fluffs stem transactions whose embargo ran out and forgets
+// stale fluff marks.
+func (d *dandelion) embargoLoop(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		var expired []*embargo
+		d.mu.Lock()
+		for id, e := range d.embargo {
+			if now.After(e.until) {
+				expired = append(expired, e)
+				delete(d.embargo, id)
+			}
+		}
+		for id, at := range d.fluffed {
+			if now.Sub(at) > fluffMarkTTL {
+				delete(d.fluffed, id)
+			}
+		}
+		d.mu.Unlock()
+
+		for _, e := range expired {
+			slog.Info("P2P embargo on transaction expired, fluffing it", "txid", e.tx.ID)
+			if !e.local {
+				d.n.fluff(e.tx, "embargo")
+			} else if d.n.mempool.Has(e.tx.ID) {
+				d.n.fluffTx(e.tx)
+			}
+		}
+	}
+}
+
+func (d *dandelion) Status() DandelionStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := DandelionStatus{Embargoed: len(d.embargo)}
+	if d.stemPeer != nil {
+		status.StemPeer = d.stemPeer.Addr()
+		status.EpochEndAt = d.epochEnd.Unix()
+	}
+	return status
+}
@@ -0,0 +1,752 @@
+package p2p
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+	"ai-blockchain/go-node/internal/quarantine"
+)
+
+const (
+	DefaultMaxPeers = 32
+	dialTimeout     = 5 * time.Second
+	redialInterval  = 10 * time.Second
+)
+
+type Config struct {
+	ListenAddr string   // TCP address to accept peers on ("" = outbound only)
+	Peers      []string // bootstrap peers to keep connected to
+	MaxPeers   int
+
+	// AllowList, when set, restricts inbound peers to matching hosts.
+	// Bootstrap peers are always dialed.
+	AllowList *AllowList
+
+	// SyncWindow is how many block batches may be requested at once while
+	// catching up with a peer far ahead (0 = DefaultSyncWindow).
+	SyncWindow int
+
+	TxRelay   TxRelayPolicy
+	Dandelion DandelionPolicy
+
+	// DevSeed, when set, derives the node ID from the seed and ListenAddr
+	// instead of drawing it at random, so a development node keeps its ID
+	// across runs while nodes sharing a seed still tell each other apart.
+	DevSeed *crypto.DevSeed
+}
+
+// Network gossips transactions and blocks with connected peers and feeds what
+// it receives into the local mempool and blockchain.
+type Network struct {
+	cfg        Config
+	blockchain *chain.Blockchain
+	mempool    *chain.Mempool
+	blocks     *chain.BlockPipeline
+	nodeID     string
+	quarantine *quarantine.Store
+	bans       *BanList
+	sync       *syncer
+	dandelion  *dandelion // nil unless Dandelion relay is enabled
+
+	reliability *reliabilityBook
+	scorer      PeerScorer // nil unless peers are scored
+	scoring     PeerScoringPolicy
+
+	mu       sync.RWMutex
+	peers    map[*Peer]struct{}
+	listener net.Listener
+	ctx      context.Context               // set by Start
+	outbound map[string]context.CancelFunc // peers kept dialed, by address
+}
+
+func New(cfg Config, blockchain *chain.Blockchain, mempool *chain.Mempool, blocks *chain.BlockPipeline) *Network {
+	if cfg.MaxPeers <= 0 {
+		cfg.MaxPeers = DefaultMaxPeers
+	}
+	id := make([]byte, 8)
+	if cfg.DevSeed != nil {
+		id = cfg.DevSeed.Bytes(crypto.DevSeedNodeID+"@"+cfg.ListenAddr, len(id))
+	} else {
+		rand.Read(id)
+	}
+
+	n := &Network{
+		cfg:        cfg,
+		blockchain: blockchain,
+		mempool:    mempool,
+		blocks:     blocks,
+		nodeID:     hex.EncodeToString(id),
+		peers:      make(map[*Peer]struct{}),
+		outbound:   make(map[string]context.CancelFunc),
+
+		reliability: newReliabilityBook(),
+	}
+	n.sync = newSyncer(n, cfg.SyncWindow)
+	if cfg.Dandelion.Enabled {
+		n.dandelion = newDandelion(n, cfg.Dandelion)
+	}
+	return n
+}
+
+// SetQuarantine keeps a copy of every block and transaction peers send that
+// fails validation.
+func (n *Network) SetQuarantine(q *quarantine.Store) {
+	n.quarantine = q
+}
+
+// SetBanList refuses connections from banned hosts and bans peers that send
+// invalid blocks.
+func (n *Network) SetBanList(bans *BanList) {
+	n.bans = bans
+}
+
+func (n *Network) Bans() *BanList {
+	return n.bans
+}
+
+// admits reports whether a connection to or from addr may proceed.
+func (n *Network) admits(addr string, inbound bool) bool {
+	if n.bans != nil && n.bans.IsBanned(addr) {
+		return false
+	}
+	if inbound && !n.cfg.AllowList.Allows(addr) {
+		return false
+	}
+	return true
+}
+
+// disconnect closes every connection to the host of addr.
+func (n *Network) disconnect(addr string) {
+	host := hostOf(addr)
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for p := range n.peers {
+		if hostOf(p.Addr()) == host {
+			p.Close()
+		}
+	}
+}
+
+// BanPeer bans the host of addr and drops any connections to it.
+func (n *Network) BanPeer(addr, reason string, d time.Duration) (Ban, error) {
+	if n.bans == nil {
+		return Ban{}, errors.New("ban list not configured")
+	}
+	b, err := n.bans.Ban(addr, reason, d)
+	n.disconnect(addr)
+	return b, err
+}
+
+// ErrNotStarted is returned for peers added before Start.
+var ErrNotStarted = errors.New("p2p network not started")
+
+// AddPeer keeps addr dialed, reconnecting whenever the connection drops,
+// until RemovePeer is called or the network stops. It reports false when
+// addr was already being kept connected.
+func (n *Network) AddPeer(addr string) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ctx == nil {
+		return false, ErrNotStarted
+	}
+	if _, ok := n.outbound[addr]; ok {
+		return false, nil
+	}
+	ctx, cancel := context.WithCancel(n.ctx)
+	n.outbound[addr] = cancel
+	go n.maintainOutbound(ctx, addr)
+	return true, nil
+}
+
+// RemovePeer stops redialing addr and drops every connection to its host,
+// inbound ones included. It reports whether there was anything to remove.
+func (n *Network) RemovePeer(addr string) bool {
+	n.mu.Lock()
+	cancel, kept := n.outbound[addr]
+	delete(n.outbound, addr)
+	connected := false
+	for p := range n.peers {
+		if hostOf(p.Addr()) == hostOf(addr) {
+			connected = true
+		}
+	}
+	n.mu.Unlock()
+
+	if kept {
+		cancel()
+	}
+	n.disconnect(addr)
+	return kept || connected
+}
+
+// OutboundPeers lists the addresses kept dialed, sorted.
+func (n *Network) OutboundPeers() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	addrs := make([]string, 0, len(n.outbound))
+	for addr := range n.outbound {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func (n *Network) Start(ctx context.Context) error {
+	if n.cfg.ListenAddr != "" {
+		ln, err := net.Listen("tcp", n.cfg.ListenAddr)
+		if err != nil {
+			return err
+		}
+		n.listener = ln
+		slog.Info("P2P listening", "addr", ln.Addr().String())
+		go n.acceptLoop()
+		go func() {
+			<-ctx.Done()
+			ln.Close()
+		}()
+	}
+
+	n.mu.Lock()
+	n.ctx = ctx
+	n.mu.Unlock()
+	for _, addr := range n.cfg.Peers {
+		n.AddPeer(addr)
+	}
+
+	go n.relayTransactions(ctx)
+	if n.dandelion != nil {
+		go n.dandelion.embargoLoop(ctx)
+	}
+	go n.relayBlocks(ctx)
+	if n.scorer != nil {
+		go n.scoreLoop(ctx)
+	}
+	return nil
+}
+
+func (n *Network) Peers() []PeerInfo {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	out := make([]PeerInfo, 0, len(n.peers))
+	for p := range n.peers {
+		info := p.Info()
+		r := n.reliability.get(p.Addr())
+		info.Reliability = &r
+		out = append(out, info)
+	}
+	return out
+}
+
+// SyncStatus reports the sync in progress, if any, and how far the chain is
+// behind the best connected peer.
+func (n *Network) SyncStatus() SyncStatus {
+	status := n.sync.Status()
+	status.Height = n.blockchain.Height()
+	status.BestPeerHeight = n.BestPeerHeight()
+	status.Progress = 1
+	if status.BestPeerHeight > status.Height {
+		status.Progress = float64(status.Height) / float64(status.BestPeerHeight)
+	}
+	return status
+}
+
+// DandelionStatus reports the current stem relay, or nil when Dandelion
+// relay is off.
+func (n *Network) DandelionStatus() *DandelionStatus {
+	if n.dandelion == nil {
+		return nil
+	}
+	status := n.dandelion.Status()
+	return &status
+}
+
+// BestPeerHeight is the highest chain height any handshaked peer has shown.
+func (n *Network) BestPeerHeight() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	best := 0
+	for p := range n.peers {
+		if p.Version() != nil && p.Height() > best {
+			best = p.Height()
+		}
+	}
+	return best
+}
+
+func (n *Network) PeerCount() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.peers)
+}
+
+func (n *Network) ListenAddr() string {
+	if n.listener == nil {
+		return ""
+	}
+	return n.listener.Addr().String()
+}
+
+func (n *Network) acceptLoop() {
+	for {
+		conn, err := n.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("P2P accept error", "err", err)
+			continue
+		}
+
+		if n.PeerCount() >= n.cfg.MaxPeers {
+			conn.Close()
+			continue
+		}
+		if addr := conn.RemoteAddr().String(); !n.admits(addr, true) {
+			slog.Info("P2P refused connection", "peer", addr)
+			conn.Close()
+			continue
+		}
+		go n.runPeer(newPeer(conn, true))
+	}
+}
+
+func (n *Network) maintainOutbound(ctx context.Context, addr string) {
+	for {
+		if !n.admits(addr, false) {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(redialInterval):
+			}
+			continue
+		}
+
+		conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+		if err != nil {
+			slog.Warn("P2P dial failed", "peer", addr, "err", err)
+		} else if ctx.Err() != nil {
+			conn.Close() // removed while dialing
+			return
+		} else {
+			n.runPeer(newPeer(conn, false))
+			slog.Info("P2P peer disconnected", "peer", addr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(redialInterval):
+		}
+	}
+}
+
+func (n *Network) runPeer(p *Peer) {
+	n.mu.Lock()
+	n.peers[p] = struct{}{}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.peers, p)
+		n.mu.Unlock()
+		p.Close()
+		n.noteDisconnect(p)
+	}()
+
+	go p.writeLoop()
+	p.SendPayload(MsgVersion, n.localVersion())
+	go p.pingLoop()
+	if n.cfg.TxRelay.trickle() {
+		go n.trickleLoop(p)
+	}
+
+	if err := p.readLoop(n.handleMessage); err != nil {
+		slog.Warn("P2P peer read error", "peer", p.Addr(), "err", err)
+	}
+}
+
+func (n *Network) localVersion() *VersionPayload {
+	state := n.blockchain.UTXOStats()
+	return &VersionPayload{
+		ProtocolVersion: ProtocolVersion,
+		NodeID:          n.nodeID,
+		GenesisHash:     n.blockchain.Genesis().Hash,
+		Height:          state.Height,
+		TipHash:         state.TipHash,
+		Difficulty:      n.blockchain.NextDifficulty(),
+		ListenAddr:      n.ListenAddr(),
+		RelayPolicy:     n.mempool.Policy(),
+		Consensus:       ChainConsensusParams(n.blockchain),
+		UTXOHash:        state.UTXOHash,
+		Timestamp:       time.Now().Unix(),
+	}
+}
+
+func (n *Network) broadcast(msgType string, payload interface{}) {
+	msg, err := newMessage(msgType, payload)
+	if err != nil {
+		slog.Error("P2P failed to encode message", "msg_type", msgType, "err", err)
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for p := range n.peers {
+		if p.Version() == nil {
+			continue
+		}
+		if err := p.Send(msg); err != nil {
+			slog.Warn("P2P send failed", "msg_type", msgType, "peer", p.Addr(), "err", err)
+		}
+	}
+}
+
+func (n *Network) relayTransactions(ctx context.Context) {
+	txs, cancel := n.mempool.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tx := <-txs:
+			n.relayTx(tx)
+		}
+	}
+}
+
+func (n *Network) relayBlocks(ctx context.Context) {
+	blocks, cancel := n.blockchain.SubscribeBlocks()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case block := <-blocks:
+			n.broadcast(MsgBlock, block)
+		}
+	}
+}
+
+func (n *Network) handleMessage(p *Peer, msg *Message) {
+	switch msg.Type {
+	case MsgVersion:
+		var v VersionPayload
+		if err := json.Unmarshal(msg.Payload, &v); err != nil {
+			p.Close()
+			return
+		}
+		n.handleVersion(p, &v)
+		return
+	case MsgGetBlocks:
+		var req GetBlocksPayload
+		if err := json.Unmarshal(msg.Payload, &req); err == nil {
+			n.handleGetBlocks(p, &req)
+		}
+		return
+	case MsgGetHeaders:
+		var req GetBlocksPayload
+		if err := json.Unmarshal(msg.Payload, &req); err == nil {
+			n.handleGetHeaders(p, &req)
+		}
+		return
+	case MsgPing:
+		var ping PingPayload
+		json.Unmarshal(msg.Payload, &ping)
+		p.SendPayload(MsgPong, &ping)
+		return
+	case MsgPong:
+		var pong PingPayload
+		if err := json.Unmarshal(msg.Payload, &pong); err == nil {
+			p.stats.pong(pong.Nonce)
+		}
+		return
+	case MsgReject:
+		var rej RejectPayload
+		json.Unmarshal(msg.Payload, &rej)
+		slog.Warn("P2P peer rejected us", "peer", p.Addr(), "reason", rej.Reason)
+		p.Close()
+		return
+	}
+
+	if p.Version() == nil {
+		return // ignore gossip until the handshake completes
+	}
+
+	switch msg.Type {
+	case MsgTx:
+		var tx chain.Transaction
+		if err := json.Unmarshal(msg.Payload, &tx); err == nil {
+			n.handleTx(p, &tx)
+		}
+	case MsgStemTx:
+		var tx chain.Transaction
+		if err := json.Unmarshal(msg.Payload, &tx); err == nil {
+			n.handleStemTx(p, &tx)
+		}
+	case MsgBlock:
+		var block chain.Block
+		if err := json.Unmarshal(msg.Payload, &block); err == nil {
+			n.acceptBlock(p, &block)
+		}
+	case MsgHeaders:
+		var payload HeadersPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err == nil {
+			n.sync.deliverHeaders(p, payload.Headers)
+		}
+	case MsgBlocks:
+		var payload BlocksPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err == nil {
+			p.stats.blocksDelivered(len(payload.Blocks))
+			n.handleBlocks(p, &payload)
+		}
+	}
+}
+
+func (n *Network) reject(p *Peer, reason string) {
+	p.SendPayload(MsgReject, &RejectPayload{Reason: reason})
+	slog.Warn("P2P dropping peer", "peer", p.Addr(), "reason", reason)
+	time.AfterFunc(time.Second, p.Close)
+}
+
+func (n *Network) handleVersion(p *Peer, v *VersionPayload) {
+	if v.NodeID == n.nodeID {
+		p.Close()
+		return
+	}
+	if v.ProtocolVersion != ProtocolVersion {
+		n.reject(p, fmt.Sprintf("unsupported protocol version %d", v.ProtocolVersion))
+		return
+	}
+	if v.GenesisHash != n.blockchain.Genesis().Hash {
+		n.reject(p, "different genesis block")
+		return
+	}
+	// Blocks from a peer on other parameters would fail validation here and
+	// get it banned; turning it away is enough.
+	if local := ChainConsensusParams(n.blockchain); v.Consensus != local {
+		n.reject(p, fmt.Sprintf("different consensus parameters: peer %+v, ours %+v", v.Consensus, local))
+		return
+	}
+
+	p.setVersion(v)
+	if v.Timestamp != 0 {
+		offset := chain.PeerClockOffset(v.Timestamp, time.Now())
+		p.setClockOffset(offset)
+		host, _, err := net.SplitHostPort(p.Addr())
+		if err != nil {
+			host = p.Addr()
+		}
+		n.blockchain.Clock().AddSample(host, offset)
+	}
+	if v.UTXOHash != "" {
+		if state := n.blockchain.UTXOStats(); state.TipHash == v.TipHash && state.UTXOHash != v.UTXOHash {
+			slog.Warn("P2P peer is at our tip but its UTXO set hash differs from ours",
+				"peer", p.Addr(), "tip", v.TipHash, "peer_utxo_hash", v.UTXOHash, "utxo_hash", state.UTXOHash)
+		}
+	}
+	slog.Info("P2P handshake complete", "peer", p.Addr(), "height", v.Height,
+		"min_relay_fee", chain.FormatAmount(v.RelayPolicy.MinRelayFee))
+
+	// Peers far ahead are downloaded from by height through the sync
+	// pipeline; short gaps and forks go through the locator. The sync starts
+	// after a short delay so the first pings have measured which of the
+	// peers that connected together is fastest.
+	if height := n.blockchain.Height(); v.Height > height+syncBatchSize {
+		time.AfterFunc(syncStartDelay, func() { n.startSync(nil) })
+	} else if v.Height > height {
+		n.requestBlocks(p)
+	}
+}
+
+func (n *Network) requestBlocks(p *Peer) {
+	p.stats.blocksRequested()
+	p.SendPayload(MsgGetBlocks, &GetBlocksPayload{
+		Locator: n.blockchain.Locator(),
+		Limit:   maxBlocksPerMessage,
+	})
+}
+
+func (n *Network) handleGetBlocks(p *Peer, req *GetBlocksPayload) {
+	limit := req.Limit
+	if limit <= 0 || limit > maxBlocksPerMessage {
+		limit = maxBlocksPerMessage
+	}
+	blocks := n.blockchain.BlocksFrom(req.FromHeight, limit)
+	if len(req.Locator) > 0 {
+		blocks = n.blockchain.BlocksAfterLocator(req.Locator, limit)
+	}
+	p.SendPayload(MsgBlocks, &BlocksPayload{Blocks: blocks})
+}
+
+func (n *Network) handleGetHeaders(p *Peer, req *GetBlocksPayload) {
+	limit := req.Limit
+	if limit <= 0 || limit > maxHeadersPerMessage {
+		limit = maxHeadersPerMessage
+	}
+	headers := n.blockchain.Headers(req.FromHeight, limit)
+	if headers == nil {
+		headers = []chain.BlockHeader{}
+	}
+	p.SendPayload(MsgHeaders, &HeadersPayload{Headers: headers})
+}
+
+func (n *Network) handleBlocks(p *Peer, payload *BlocksPayload) {
+	if n.sync.deliver(p, payload.Blocks) {
+		return
+	}
+	for _, block := range payload.Blocks {
+		if !n.acceptBlock(p, block) {
+			return
+		}
+	}
+	if len(payload.Blocks) == maxBlocksPerMessage {
+		n.requestBlocks(p)
+	}
+}
+
+func (n *Network) handleTx(p *Peer, tx *chain.Transaction) {
+	if n.dandelion != nil {
+		// It is being broadcast: lift any embargo we hold and relay it
+		// normally rather than starting a stem.
+		n.dandelion.markFluffed(tx.ID)
+	}
+	if n.mempool.Has(tx.ID) {
+		return
+	}
+	if !n.checkTx(p, tx) {
+		return
+	}
+	if err := n.blocks.AddTransaction(tx); err == nil {
+		slog.Debug("P2P accepted transaction", "txid", tx.ID, "peer", p.Addr())
+	}
+}
+
+// checkTx validates a transaction from p against the chain, the outputs
+// of pending transactions and the relay fee.
+func (n *Network) checkTx(p *Peer, tx *chain.Transaction) bool {
+	view := n.mempool.InputView(tx, n.blockchain.Output)
+	if err := chain.VerifyTransaction(tx, view); err != nil {
+		n.quarantine.Record(quarantine.KindTransaction, tx.ID, tx, err.Error(), "p2p:"+p.Addr())
+		n.noteInvalidTx(p)
+		return false
+	}
+	fee, err := chain.ComputeFee(tx, view)
+	return err == nil && n.mempool.CheckFee(tx, fee) == nil
+}
+
+// acceptBlock hands a block received from a peer to the chain, which
+// connects it, stores it on a side branch or reorganizes onto it. It reports
+// whether processing of a batch should continue.
+func (n *Network) acceptBlock(p *Peer, block *chain.Block) bool {
+	status, err := n.blocks.ProcessBlock(block)
+	switch {
+	case errors.Is(err, chain.ErrDuplicateBlock):
+		return true
+	case errors.Is(err, chain.ErrPipelineStopped):
+		return false
+	case errors.Is(err, chain.ErrOrphanBlock):
+		n.requestBlocks(p)
+		return false
+	case errors.Is(err, chain.ErrBlockTooNew):
+		// Our adjusted clock may be the one that is off; the block was not
+		// stored and will be taken once it is no longer ahead of us.
+		slog.Info("P2P deferred block from the future", "height", block.Index, "hash", block.Hash, "peer", p.Addr(), "err", err)
+		return false
+	case errors.Is(err, chain.ErrForkTooDeep), errors.Is(err, chain.ErrSideBranchFull):
+		// A valid block on a branch we will not follow, as an honest peer
+		// on the other side of a long partition sends.
+		slog.Info("P2P ignored block on a branch we will not follow", "height", block.Index, "hash", block.Hash, "peer", p.Addr(), "err", err)
+		return false
+	case err != nil:
+		slog.Warn("P2P rejected block", "height", block.Index, "hash", block.Hash, "peer", p.Addr(), "err", err)
+		n.quarantine.Record(quarantine.KindBlock, block.Hash, block, err.Error(), "p2p:"+p.Addr())
+		if !errors.Is(err, chain.ErrChainFrozen) {
+			n.noteInvalidBlock(p)
+		}
+		if n.bans != nil && !errors.Is(err, chain.ErrChainFrozen) {
+			if _, banErr := n.BanPeer(p.Addr(), "invalid block: "+err.Error(), DefaultBanDuration); banErr != nil {
+				slog.Error("P2P failed to save ban list", "err", banErr)
+			}
+		}
+		return false
+	}
+
+	p.noteHeight(block.Index + 1)
+	slog.Info("P2P accepted block", "height", block.Index, "hash", block.Hash, "peer", p.Addr(), "status", status)
+	return true
+}
+
+// FetchGenesis connects to a peer just long enough to download its genesis
+// block and learn its consensus parameters, so a fresh node can join an
+// existing network.
+func FetchGenesis(addr string) (*chain.Block, *ConsensusParams, error) {
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := newPeer(conn, false)
+	defer p.Close()
+	go p.writeLoop()
+
+	conn.SetReadDeadline(time.Now().Add(30 * time.Second))
+
+	var version *VersionPayload
+	result := make(chan *chain.Block, 1)
+	p.SendPayload(MsgGetBlocks, &GetBlocksPayload{FromHeight: 0, Limit: 1})
+
+	err = p.readLoop(func(p *Peer, msg *Message) {
+		switch msg.Type {
+		case MsgVersion:
+			version = &VersionPayload{}
+			json.Unmarshal(msg.Payload, version)
+		case MsgBlocks:
+			var payload BlocksPayload
+			if json.Unmarshal(msg.Payload, &payload) == nil && len(payload.Blocks) > 0 {
+				// Only the first reply counts; another already
+				// queued before Close must not block the read loop.
+				select {
+				case result <- payload.Blocks[0]:
+				default:
+				}
+			}
+			p.Close()
+		}
+	})
+
+	select {
+	case genesis := <-result:
+		if genesis.Index != 0 || genesis.ComputeHash() != genesis.Hash {
+			return nil, nil, errors.New("peer returned an invalid genesis block")
+		}
+		if version == nil || version.ProtocolVersion != ProtocolVersion {
+			return nil, nil, errors.New("peer did not complete a compatible handshake")
+		}
+		if version.GenesisHash != genesis.Hash {
+			return nil, nil, errors.New("peer genesis does not match its handshake")
+		}
+		if version.Consensus.InitialDifficulty < 1 {
+			return nil, nil, errors.New("peer did not report its initial difficulty")
+		}
+		return genesis, &version.Consensus, nil
+	default:
+		if err == nil {
+			err = errors.New("peer closed connection before sending genesis")
+		}
+		return nil, nil, err
+	}
+}
@@ -0,0 +1,60 @@
+package p2p
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+// A peer still on a branch that forked off before our reorg limit, as after
+// a long partition, sends valid blocks; they are ignored, not punished.
+func TestDeepForkBlockDoesNotBanPeer(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	genesis := chaintest.Genesis(t, address)
+	bc := chaintest.FromGenesis(genesis)
+	other := chaintest.FromGenesis(genesis)
+	fork := chaintest.MineBlock(t, other, strings.Repeat("b", 64))
+	for i := 0; i <= chain.DefaultMaxReorgDepth; i++ {
+		if err := bc.AddBlock(chaintest.MineBlock(t, bc, address)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pipeline := chain.NewBlockPipeline(bc, chain.NewMempool())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go pipeline.Run(ctx)
+	n := New(Config{}, bc, chain.NewMempool(), pipeline)
+	bans, err := LoadBanList("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.SetBanList(bans)
+	conn, _ := net.Pipe()
+	p := newPeer(conn, false)
+	defer p.Close()
+
+	if n.acceptBlock(p, fork) {
+		t.Fatal("block forking below the reorg limit accepted")
+	}
+	if bans.IsBanned(p.Addr()) {
+		t.Error("peer banned for a deep fork")
+	}
+	if got := n.reliability.get(p.Addr()).InvalidBlocks; got != 0 {
+		t.Errorf("%d invalid blocks held against the peer, want 0", got)
+	}
+
+	// An invalid block still gets the peer banned.
+	bad := chaintest.MineBlock(t, bc, address)
+	bad.Nonce++
+	if n.acceptBlock(p, bad) {
+		t.Fatal("block with a wrong hash accepted")
+	}
+	if !bans.IsBanned(p.Addr()) {
+		t.Error("peer not banned for an invalid block")
+	}
+}
@@ -0,0 +1,110 @@
+package p2p
+
+import (
+	"encoding/json"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// ProtocolVersion 2 added the consensus parameters to the handshake.
+const ProtocolVersion = 2
+
+const (
+	MsgVersion    = "version"
+	MsgTx         = "tx"
+	MsgStemTx     = "stemtx"
+	MsgBlock      = "block"
+	MsgGetBlocks  = "getblocks"
+	MsgBlocks     = "blocks"
+	MsgGetHeaders = "getheaders"
+	MsgHeaders    = "headers"
+	MsgPing       = "ping"
+	MsgPong       = "pong"
+	MsgReject     = "reject"
+)
+
+const (
+	maxBlocksPerMessage  = 500
+	maxHeadersPerMessage = 2000
+)
+
+// Message is the wire envelope: one JSON object per line.
+type Message struct {
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// VersionPayload is exchanged by both sides immediately after connecting.
+type VersionPayload struct {
+	ProtocolVersion int                 `json:"protocol_version"`
+	NodeID          string              `json:"node_id"`
+	GenesisHash     string              `json:"genesis_hash"`
+	Height          int                 `json:"height"`
+	TipHash         string              `json:"tip_hash"`
+	Difficulty      int                 `json:"difficulty"`
+	ListenAddr      string              `json:"listen_addr,omitempty"`
+	RelayPolicy     chain.MempoolPolicy `json:"relay_policy"`
+	Consensus       ConsensusParams     `json:"consensus"`
+	UTXOHash        string              `json:"utxo_hash,omitempty"` // UTXO set hash at TipHash
+	Timestamp       int64               `json:"timestamp"`
+}
+
+// ConsensusParams are the settings besides the genesis block that every
+// block is validated against. Nodes that differ in any of them reject each
+// other's blocks, so the handshake refuses such peers.
+type ConsensusParams struct {
+	InitialDifficulty int     `json:"initial_difficulty"`
+	RetargetInterval  int     `json:"retarget_interval"`
+	TargetBlockTime   int64   `json:"target_block_time"` // seconds
+	BlockReward       float64 `json:"block_reward"`
+}
+
+// ChainConsensusParams reads the consensus parameters bc validates with.
+func ChainConsensusParams(bc *chain.Blockchain) ConsensusParams {
+	retarget := bc.RetargetPolicy()
+	return ConsensusParams{
+		InitialDifficulty: bc.Difficulty(),
+		RetargetInterval:  retarget.Interval,
+		TargetBlockTime:   int64(retarget.TargetBlockTime / time.Second),
+		BlockReward:       bc.BlockReward(),
+	}
+}
+
+// GetBlocksPayload asks for main-chain blocks after the first locator hash
+// the peer recognizes, or from FromHeight when no locator is given.
+type GetBlocksPayload struct {
+	Locator    []string `json:"locator,omitempty"`
+	FromHeight int      `json:"from_height"`
+	Limit      int      `json:"limit"`
+}
+
+type BlocksPayload struct {
+	Blocks []*chain.Block `json:"blocks"`
+}
+
+// HeadersPayload answers a getheaders request, which reuses
+// GetBlocksPayload.
+type HeadersPayload struct {
+	Headers []chain.BlockHeader `json:"headers"`
+}
+
+type PingPayload struct {
+	Nonce int64 `json:"nonce"`
+}
+
+type RejectPayload struct {
+	Reason string `json:"reason"`
+}
+
+func newMessage(msgType string, payload interface{}) (*Message, error) {
+	msg := &Message{Type: msgType}
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		msg.Payload = data
+	}
+	return msg, nil
+}
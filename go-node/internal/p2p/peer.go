@@ -0,0 +1,202 @@
+package p2p
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	maxMessageSize = 32 * 1024 * 1024
+	sendQueueSize  = 256
+	writeTimeout   = 30 * time.Second
+)
+
+var errSendQueueFull = errors.New("peer send queue full")
+
+type Peer struct {
+	conn      net.Conn
+	addr      string
+	inbound   bool
+	connected time.Time
+
+	send      chan *Message
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu      sync.RWMutex
+	version *VersionPayload
+	height  int           // highest chain height seen from the peer since the handshake
+	offset  time.Duration // how far the peer's clock was ahead of ours at the handshake
+
+	stats peerStats
+
+	relayMu    sync.Mutex
+	relayQueue []*chain.Transaction // transactions waiting for the next trickle
+}
+
+// PeerInfo is the public view of a peer for the API.
+type PeerInfo struct {
+	Addr        string           `json:"addr"`
+	Inbound     bool             `json:"inbound"`
+	ConnectedAt int64            `json:"connected_at"`
+	Handshaked  bool             `json:"handshaked"`
+	Version     *VersionPayload  `json:"version,omitempty"`
+	Height      int              `json:"height"`
+	ClockOffset int64            `json:"clock_offset"` // seconds the peer's clock is ahead of ours
+	Stats       PeerStats        `json:"stats"`
+	Reliability *PeerReliability `json:"reliability,omitempty"`
+}
+
+func newPeer(conn net.Conn, inbound bool) *Peer {
+	return &Peer{
+		conn:      conn,
+		addr:      conn.RemoteAddr().String(),
+		inbound:   inbound,
+		connected: time.Now(),
+		send:      make(chan *Message, sendQueueSize),
+		closed:    make(chan struct{}),
+	}
+}
+
+func (p *Peer) Addr() string {
+	return p.addr
+}
+
+func (p *Peer) Version() *VersionPayload {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.version
+}
+
+func (p *Peer) setVersion(v *VersionPayload) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.version = v
+}
+
+func (p *Peer) setClockOffset(offset time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.offset = offset
+}
+
+// ClockOffset is how far the peer's clock was ahead of ours when it sent
+// its version message, to the second.
+func (p *Peer) ClockOffset() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.offset
+}
+
+// Height is the peer's best known chain height: what it announced in its
+// version message, raised by every block or header it has sent us since.
+func (p *Peer) Height() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.version != nil && p.version.Height > p.height {
+		return p.version.Height
+	}
+	return p.height
+}
+
+func (p *Peer) noteHeight(height int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if height > p.height {
+		p.height = height
+	}
+}
+
+func (p *Peer) Info() PeerInfo {
+	v := p.Version()
+	return PeerInfo{
+		Addr:        p.addr,
+		Inbound:     p.inbound,
+		ConnectedAt: p.connected.Unix(),
+		Handshaked:  v != nil,
+		Version:     v,
+		Height:      p.Height(),
+		ClockOffset: int64(p.ClockOffset() / time.Second),
+		Stats:       p.stats.snapshot(p.connected),
+	}
+}
+
+func (p *Peer) Send(msg *Message) error {
+	select {
+	case <-p.closed:
+		return net.ErrClosed
+	case p.send <- msg:
+		return nil
+	default:
+		return errSendQueueFull
+	}
+}
+
+func (p *Peer) SendPayload(msgType string, payload interface{}) error {
+	msg, err := newMessage(msgType, payload)
+	if err != nil {
+		return err
+	}
+	return p.Send(msg)
+}
+
+func (p *Peer) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		p.conn.Close()
+	})
+}
+
+func (p *Peer) Done() <-chan struct{} {
+	return p.closed
+}
+
+func (p *Peer) writeLoop() {
+	enc := json.NewEncoder(&countingWriter{w: p.conn, n: &p.stats.bytesSent})
+	for {
+		select {
+		case <-p.closed:
+			return
+		case msg := <-p.send:
+			p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if err := enc.Encode(msg); err != nil {
+				p.Close()
+				return
+			}
+		}
+	}
+}
+
+func (p *Peer) readLoop(handle func(*Peer, *Message)) error {
+	scanner := bufio.NewScanner(p.conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		p.stats.bytesReceived.Add(uint64(len(scanner.Bytes()) + 1))
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return err
+		}
+		handle(p, &msg)
+	}
+	return scanner.Err()
+}
+
+// countingWriter adds the number of bytes written to n.
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Uint64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	written, err := c.w.Write(b)
+	c.n.Add(uint64(written))
+	return written, err
+}
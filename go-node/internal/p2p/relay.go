@@ -0,0 +1,110 @@
+package p2p
+
+import (
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	DefaultTxRelayBatch = 100
+	maxTrickleQueue     = chain.DefaultMaxMempoolSize
+)
+
+// TxRelayPolicy controls how quickly transactions are passed on. With a
+// zero MeanDelay each transaction goes to every peer as soon as it enters
+// the mempool, so the first peer to hear of it can guess the sender's node
+// from timing alone. With trickling each peer has its own queue, flushed
+// after independent, exponentially distributed delays averaging MeanDelay,
+// in shuffled order and at most MaxBatch at a time. Inbound peers wait
+// twice as long on average, since anyone can open a connection to observe.
+type TxRelayPolicy struct {
+	MeanDelay time.Duration
+	MaxBatch  int
+}
+
+func (p TxRelayPolicy) trickle() bool {
+	return p.MeanDelay > 0
+}
+
+// relayTx passes a new mempool transaction on: along a Dandelion stem if it
+// originated here and stemming is on, otherwise to every peer according to
+// the relay policy.
+func (n *Network) relayTx(tx *chain.Transaction) {
+	if n.dandelion != nil && n.dandelion.stemLocal(tx) {
+		return
+	}
+	n.fluffTx(tx)
+}
+
+func (n *Network) fluffTx(tx *chain.Transaction) {
+	if !n.cfg.TxRelay.trickle() {
+		n.broadcast(MsgTx, tx)
+		return
+	}
+
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	for p := range n.peers {
+		if p.Version() != nil {
+			p.queueTx(tx)
+		}
+	}
+}
+
+func (p *Peer) queueTx(tx *chain.Transaction) {
+	p.relayMu.Lock()
+	defer p.relayMu.Unlock()
+
+	if len(p.relayQueue) >= maxTrickleQueue {
+		return // the peer will learn of it from someone else
+	}
+	p.relayQueue = append(p.relayQueue, tx)
+}
+
+// takeQueued removes up to max queued transactions in random order.
+func (p *Peer) takeQueued(max int) []*chain.Transaction {
+	p.relayMu.Lock()
+	defer p.relayMu.Unlock()
+
+	rand.Shuffle(len(p.relayQueue), func(i, j int) {
+		p.relayQueue[i], p.relayQueue[j] = p.relayQueue[j], p.relayQueue[i]
+	})
+	if max <= 0 || max > len(p.relayQueue) {
+		max = len(p.relayQueue)
+	}
+	batch := p.relayQueue[:max:max]
+	p.relayQueue = append([]*chain.Transaction(nil), p.relayQueue[max:]...)
+	return batch
+}
+
+// trickleLoop flushes p's relay queue at random intervals until it
+// disconnects.
+func (n *Network) trickleLoop(p *Peer) {
+	policy := n.cfg.TxRelay
+	mean := policy.MeanDelay
+	if p.inbound {
+		mean *= 2
+	}
+
+	for {
+		delay := time.Duration(rand.ExpFloat64() * float64(mean))
+		if delay > 10*mean {
+			delay = 10 * mean
+		}
+		select {
+		case <-p.closed:
+			return
+		case <-time.After(delay):
+		}
+
+		for _, tx := range p.takeQueued(policy.MaxBatch) {
+			if err := p.SendPayload(MsgTx, tx); err != nil {
+				slog.Warn("P2P send failed", "msg_type", MsgTx, "peer", p.Addr(), "err", err)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/ai"
+)
+
+const DefaultPeerScoreInterval = time.Minute
+
+// PeerScorer rates how reliable a peer has been, from 0 (unreliable) to 1.
+// The AI client implements it.
+type PeerScorer interface {
+	ScorePeer(stats ai.PeerStats) (*ai.PeerScore, error)
+}
+
+// PeerScoringPolicy says what the node does with reliability scores. Every
+// Interval each handshaked peer is scored. Peers scoring below
+// DeprioritizeBelow are the last choice for block download, and peers below
+// BanBelow are banned for DefaultBanDuration (0 = never). Scores are
+// advisory: a peer is never treated differently for lack of one.
+type PeerScoringPolicy struct {
+	Interval          time.Duration
+	DeprioritizeBelow float64
+	BanBelow          float64
+}
+
+// PeerReliability is what the node holds against a peer's host, reported
+// under "reliability" in GET /peers. Like bans, it is kept per host, so it
+// survives reconnections.
+type PeerReliability struct {
+	InvalidBlocks int      `json:"invalid_blocks"`
+	InvalidTxs    int      `json:"invalid_txs"`
+	Disconnects   int      `json:"disconnects"`
+	Score         *float64 `json:"score,omitempty"` // last AI reliability score
+	ScoredAt      int64    `json:"scored_at,omitempty"`
+}
+
+type reliabilityBook struct {
+	mu    sync.Mutex
+	hosts map[string]*PeerReliability
+}
+
+func newReliabilityBook() *reliabilityBook {
+	return &reliabilityBook{hosts: make(map[string]*PeerReliability)}
+}
+
+// update applies f to the record for addr's host, creating it if needed.
+func (b *reliabilityBook) update(addr string, f func(r *PeerReliability)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	host := hostOf(addr)
+	r, ok := b.hosts[host]
+	if !ok {
+		r = &PeerReliability{}
+		b.hosts[host] = r
+	}
+	f(r)
+}
+
+func (b *reliabilityBook) get(addr string) PeerReliability {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if r, ok := b.hosts[hostOf(addr)]; ok {
+		return *r
+	}
+	return PeerReliability{}
+}
+
+func (n *Network) noteInvalidBlock(p *Peer) {
+	n.reliability.update(p.Addr(), func(r *PeerReliability) { r.InvalidBlocks++ })
+}
+
+func (n *Network) noteInvalidTx(p *Peer) {
+	n.reliability.update(p.Addr(), func(r *PeerReliability) { r.InvalidTxs++ })
+}
+
+func (n *Network) noteDisconnect(p *Peer) {
+	if p.Version() == nil {
+		return // never got past the handshake
+	}
+	n.reliability.update(p.Addr(), func(r *PeerReliability) { r.Disconnects++ })
+}
+
+// SetPeerScorer has peers scored for reliability under policy.
+func (n *Network) SetPeerScorer(scorer PeerScorer, policy PeerScoringPolicy) {
+	if policy.Interval <= 0 {
+		policy.Interval = DefaultPeerScoreInterval
+	}
+	n.scorer = scorer
+	n.scoring = policy
+}
+
+// deprioritized reports whether p's last score puts it behind other peers.
+func (n *Network) deprioritized(p *Peer) bool {
+	r := n.reliability.get(p.Addr())
+	return r.Score != nil && *r.Score < n.scoring.DeprioritizeBelow
+}
+
+func (n *Network) scoreLoop(ctx context.Context) {
+	ticker := time.NewTicker(n.scoring.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		n.mu.RLock()
+		peers := make([]*Peer, 0, len(n.peers))
+		for p := range n.peers {
+			if p.Version() != nil {
+				peers = append(peers, p)
+			}
+		}
+		n.mu.RUnlock()
+
+		for _, p := range peers {
+			n.scorePeer(p)
+		}
+	}
+}
+
+func (n *Network) scorePeer(p *Peer) {
+	info := p.Info()
+	r := n.reliability.get(p.Addr())
+	score, err := n.scorer.ScorePeer(ai.PeerStats{
+		Inbound:          info.Inbound,
+		ConnectedSeconds: time.Since(p.connected).Seconds(),
+		PingMs:           info.Stats.PingMs,
+		BlockDeliveryMs:  info.Stats.BlockDeliveryMs,
+		BlocksReceived:   info.Stats.BlocksReceived,
+		InvalidBlocks:    r.InvalidBlocks,
+		InvalidTxs:       r.InvalidTxs,
+		Disconnects:      r.Disconnects,
+	})
+	if err != nil {
+		slog.Debug("P2P peer scoring failed", "peer", p.Addr(), "err", err)
+		return
+	}
+
+	value := score.ReliabilityScore
+	n.reliability.update(p.Addr(), func(r *PeerReliability) {
+		r.Score = &value
+		r.ScoredAt = time.Now().Unix()
+	})
+	slog.Debug("P2P peer scored", "peer", p.Addr(), "reliability", value)
+
+	if n.scoring.BanBelow > 0 && value < n.scoring.BanBelow && n.bans != nil {
+		reason := fmt.Sprintf("AI reliability score %.2f below %.2f", value, n.scoring.BanBelow)
+		slog.Warn("P2P banning unreliable peer", "peer", p.Addr(), "reliability", value)
+		if _, err := n.BanPeer(p.Addr(), reason, DefaultBanDuration); err != nil {
+			slog.Error("P2P failed to save ban list", "err", err)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package p2p
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	pingInterval       = 30 * time.Second
+	maxPendingRequests = 64
+	deliveryWeight     = 0.3 // weight of the newest sample in the moving average
+)
+
+// PeerStats is what we have measured about a peer's connection.
+type PeerStats struct {
+	PingMs          float64 `json:"ping_ms,omitempty"`           // last ping round trip
+	BlockDeliveryMs float64 `json:"block_delivery_ms,omitempty"` // moving average from getblocks to blocks
+	BlocksReceived  int     `json:"blocks_received"`
+	BytesSent       uint64  `json:"bytes_sent"`
+	BytesReceived   uint64  `json:"bytes_received"`
+	SendRate        float64 `json:"send_bytes_per_sec"`
+	ReceiveRate     float64 `json:"receive_bytes_per_sec"`
+}
+
+// peerStats tracks latency and traffic for one peer. Byte counters are
+// updated by the read and write loops without taking the lock.
+type peerStats struct {
+	bytesSent     atomic.Uint64
+	bytesReceived atomic.Uint64
+
+	mu             sync.Mutex
+	pingNonce      int64
+	pingSent       time.Time
+	ping           time.Duration
+	requests       []time.Time // getblocks sent and not yet answered, oldest first
+	delivery       time.Duration
+	blocksReceived int
+}
+
+// nextPing records an outgoing ping and returns its nonce.
+func (s *peerStats) nextPing() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pingNonce = rand.Int63()
+	s.pingSent = time.Now()
+	return s.pingNonce
+}
+
+func (s *peerStats) pong(nonce int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if nonce != s.pingNonce || s.pingSent.IsZero() {
+		return
+	}
+	s.ping = time.Since(s.pingSent)
+	s.pingSent = time.Time{}
+}
+
+// blocksRequested notes a getblocks sent to the peer. Peers answer requests
+// in order, so each blocks message is matched with the oldest request.
+func (s *peerStats) blocksRequested() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.requests) == maxPendingRequests {
+		s.requests = s.requests[1:]
+	}
+	s.requests = append(s.requests, time.Now())
+}
+
+func (s *peerStats) blocksDelivered(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blocksReceived += count
+	if len(s.requests) == 0 {
+		return // unsolicited (gossip) or the request was dropped
+	}
+	s.recordDeliveryLocked(time.Since(s.requests[0]))
+	s.requests = s.requests[1:]
+}
+
+// deliveryTimedOut penalizes a peer that never answered a block request.
+func (s *peerStats) deliveryTimedOut(waited time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+	s.recordDeliveryLocked(waited)
+}
+
+func (s *peerStats) recordDeliveryLocked(sample time.Duration) {
+	if s.delivery == 0 {
+		s.delivery = sample
+		return
+	}
+	s.delivery = time.Duration(deliveryWeight*float64(sample) + (1-deliveryWeight)*float64(s.delivery))
+}
+
+func (s *peerStats) snapshot(connected time.Time) PeerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := PeerStats{
+		PingMs:          durationMs(s.ping),
+		BlockDeliveryMs: durationMs(s.delivery),
+		BlocksReceived:  s.blocksReceived,
+		BytesSent:       s.bytesSent.Load(),
+		BytesReceived:   s.bytesReceived.Load(),
+	}
+	if elapsed := time.Since(connected).Seconds(); elapsed > 0 {
+		stats.SendRate = math.Round(float64(stats.BytesSent) / elapsed)
+		stats.ReceiveRate = math.Round(float64(stats.BytesReceived) / elapsed)
+	}
+	return stats
+}
+
+// syncCost ranks peers for block download, lower first: measured block
+// delivery time, else ping time, else unknown peers last.
+func (s *peerStats) syncCost() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.delivery > 0:
+		return s.delivery
+	case s.ping > 0:
+		return s.ping
+	default:
+		return time.Duration(math.MaxInt64)
+	}
+}
+
+func durationMs(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+// pingLoop measures round-trip time right after the handshake and then every
+// pingInterval until the peer disconnects.
+func (p *Peer) pingLoop() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		p.SendPayload(MsgPing, &PingPayload{Nonce: p.stats.nextPing()})
+		select {
+		case <-p.closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fastestPeers returns handshaked peers at least minHeight high, cheapest
+// to download from first, with peers deprioritized for a low reliability
+// score after all the others.
+func (n *Network) fastestPeers(minHeight int) []*Peer {
+	n.mu.RLock()
+	var candidates []*Peer
+	for p := range n.peers {
+		select {
+		case <-p.closed:
+			continue
+		default:
+		}
+		if p.Version() != nil && p.Height() >= minHeight {
+			candidates = append(candidates, p)
+		}
+	}
+	n.mu.RUnlock()
+
+	cost := make(map[*Peer]time.Duration, len(candidates))
+	demoted := make(map[*Peer]bool, len(candidates))
+	for _, p := range candidates {
+		cost[p] = p.stats.syncCost()
+		demoted[p] = n.deprioritized(p)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if demoted[a] != demoted[b] {
+			return !demoted[a]
+		}
+		return cost[a] < cost[b]
+	})
+	return candidates
+}
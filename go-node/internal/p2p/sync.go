@@ -0,0 +1,453 @@
+package p2p
+
+import (
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/consensus"
+)
+
+const (
+	DefaultSyncWindow  = 4
+	syncBatchSize      = 100
+	syncRequestTimeout = 30 * time.Second
+	syncStartDelay     = time.Second
+)
+
+// Sync phases.
+const (
+	SyncHeaders = "headers"
+	SyncBlocks  = "blocks"
+)
+
+// SyncStatus describes an initial block download in progress, alongside how
+// far the node is behind its best peer.
+type SyncStatus struct {
+	Active         bool    `json:"active"`
+	Phase          string  `json:"phase,omitempty"`
+	Peer           string  `json:"peer,omitempty"`
+	Height         int     `json:"height"`
+	BestPeerHeight int     `json:"best_peer_height"`
+	Progress       float64 `json:"progress"`
+	HeaderHeight   int     `json:"header_height,omitempty"`
+	Target         int     `json:"target_height,omitempty"`
+	Validated      int     `json:"validated_height,omitempty"`
+	InFlight       int     `json:"in_flight"`
+	Buffered       int     `json:"buffered"`
+}
+
+// syncer downloads a long run of blocks from one peer, headers first. The
+// peer's header chain above our tip, up to the height it announced, is
+// fetched and checked for linkage, the difficulty our retarget schedule
+// requires and proof of work. That fixes exactly which blocks will be
+// downloaded, and a peer serving a worthless chain, or one with no more
+// work than ours, is dropped before any bodies are transferred. The bodies
+// are then fetched by height with up to window batch requests outstanding at
+// once while a single worker validates completed batches strictly in order.
+// A new batch is only requested once one has been validated, so at most
+// window batches are ever held in memory no matter how slowly blocks are
+// connected.
+type syncer struct {
+	n      *Network
+	window int
+
+	mu           sync.Mutex
+	active       bool
+	phase        string
+	peer         *Peer
+	from         int                    // our height when the sync started
+	base         chain.BlockHeader      // our tip when the sync started
+	baseWork     *big.Int               // cumulative work at base
+	context      []chain.BlockHeader    // our headers ending at base, one retarget interval long
+	initial      int                    // the network's initial difficulty
+	retarget     chain.RetargetPolicy   // the network's retarget schedule
+	announced    int                    // height the peer claimed; headers stop there
+	headers      []chain.BlockHeader    // verified headers from height from on
+	headersAsked time.Time              // zero when no header request is outstanding
+	next         int                    // next height to request
+	target       int                    // height the sync downloads up to
+	done         int                    // heights below this are validated
+	inflight     map[int]time.Time      // batch start height → requested at
+	ready        map[int][]*chain.Block // downloaded batches awaiting validation
+	stop         string                 // set when the sync must end early
+	banPeer      bool                   // whether stop warrants banning the peer
+	wake         chan struct{}
+}
+
+func newSyncer(n *Network, window int) *syncer {
+	if window <= 0 {
+		window = DefaultSyncWindow
+	}
+	return &syncer{n: n, window: window}
+}
+
+// startSync begins a pipelined download from the fastest peer other than
+// exclude that is more than a batch ahead. It reports whether a sync is
+// running.
+func (n *Network) startSync(exclude *Peer) bool {
+	for _, p := range n.fastestPeers(n.blockchain.Height() + syncBatchSize + 1) {
+		if p != exclude {
+			n.sync.start(p, p.Height())
+			return true
+		}
+	}
+	return n.sync.Status().Active
+}
+
+// start begins a headers-first download from p, which claims to be at
+// height, unless one is already running.
+func (s *syncer) start(p *Peer, height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active {
+		return
+	}
+	bc := s.n.blockchain
+	retarget := bc.RetargetPolicy()
+	// Our headers back one retarget interval, so the difficulty of the
+	// peer's first headers can be derived; read in one go so they end at
+	// our tip.
+	start := bc.Height() - 1 - retarget.Interval
+	if start < 0 {
+		start = 0
+	}
+	context := bc.Headers(start, 0)
+	base := context[len(context)-1]
+	baseWork, _ := bc.WorkAt(base.Hash)
+	from := base.Index + 1
+	s.active = true
+	s.phase = SyncHeaders
+	s.peer = p
+	s.from = from
+	s.base = base
+	s.baseWork = baseWork
+	s.context = context
+	s.initial = bc.Difficulty()
+	s.retarget = retarget
+	s.announced = height
+	s.headers = nil
+	s.next = from
+	s.done = from
+	s.target = height
+	s.inflight = make(map[int]time.Time)
+	s.ready = make(map[int][]*chain.Block)
+	s.stop = ""
+	s.banPeer = false
+	s.wake = make(chan struct{}, 1)
+	s.requestHeadersLocked()
+
+	slog.Info("P2P syncing headers", "from", from, "to", height-1, "peer", p.Addr())
+	go s.run(p)
+}
+
+func (s *syncer) requestHeadersLocked() {
+	s.peer.SendPayload(MsgGetHeaders, &GetBlocksPayload{
+		FromHeight: s.from + len(s.headers),
+		Limit:      maxHeadersPerMessage,
+	})
+	s.headersAsked = time.Now()
+}
+
+// deliverHeaders extends the sync's header chain with headers from p. It
+// reports false when they were not requested by the sync.
+func (s *syncer) deliverHeaders(p *Peer, headers []chain.BlockHeader) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active || p != s.peer || s.phase != SyncHeaders || s.headersAsked.IsZero() {
+		return false
+	}
+	s.headersAsked = time.Time{}
+	defer s.signal()
+
+	prev := s.base
+	if len(s.headers) > 0 {
+		prev = s.headers[len(s.headers)-1]
+	}
+	more := len(headers) == maxHeadersPerMessage
+	for _, h := range headers {
+		if h.Index >= s.announced {
+			// Anything newer arrives through the locator once the sync
+			// ends; a peer cannot keep the header phase going forever.
+			more = false
+			break
+		}
+		if h.Index != prev.Index+1 || h.PrevHash != prev.Hash {
+			// Not necessarily misbehaviour: the peer may have reorganized
+			// below our tip. The locator fallback sorts that out.
+			s.stop = fmt.Sprintf("header %d does not extend %s", h.Index, prev.Hash)
+			return true
+		}
+		if expected := s.nextDifficultyLocked(); h.Difficulty != expected {
+			s.stop = fmt.Sprintf("invalid header %d: declares difficulty %d, expected %d", h.Index, h.Difficulty, expected)
+			s.banPeer = true
+			return true
+		}
+		if err := chain.VerifyHeader(h, s.n.blockchain.PowAlgorithm()); err != nil {
+			s.stop = fmt.Sprintf("invalid header %d: %v", h.Index, err)
+			s.banPeer = true
+			return true
+		}
+		s.headers = append(s.headers, h)
+		prev = h
+	}
+	if len(s.headers) > 0 {
+		p.noteHeight(s.from + len(s.headers))
+	}
+
+	if more {
+		s.requestHeadersLocked()
+		return true
+	}
+	if len(s.headers) > 0 {
+		// The headers extend our tip as it was; blocks connected since
+		// may already carry more work.
+		work := new(big.Int).Set(s.baseWork)
+		for _, h := range s.headers {
+			work.Add(work, consensus.Work(h.Difficulty))
+		}
+		if work.Cmp(s.n.blockchain.ChainWork()) <= 0 {
+			s.stop = "peer's headers carry no more work than our chain"
+			return true
+		}
+	}
+	s.phase = SyncBlocks
+	s.target = s.from + len(s.headers)
+	slog.Info("P2P verified headers, downloading blocks", "headers", len(s.headers), "peer", p.Addr(),
+		"from", s.from, "to", s.target-1, "window", s.window, "batch", syncBatchSize)
+	s.fillLocked()
+	return true
+}
+
+// nextDifficultyLocked is the difficulty the header after the last one held
+// must declare under our retarget schedule.
+func (s *syncer) nextDifficultyLocked() int {
+	n := 1
+	if s.retarget.Enabled() {
+		n = s.retarget.Interval + 1
+	}
+	if len(s.headers) >= n {
+		return chain.NextHeaderDifficulty(s.headers[len(s.headers)-n:], s.initial, s.retarget)
+	}
+	keep := n - len(s.headers)
+	if keep > len(s.context) {
+		keep = len(s.context)
+	}
+	branch := make([]chain.BlockHeader, 0, keep+len(s.headers))
+	branch = append(branch, s.context[len(s.context)-keep:]...)
+	return chain.NextHeaderDifficulty(append(branch, s.headers...), s.initial, s.retarget)
+}
+
+// batchSize is the number of blocks requested for the batch at start.
+func (s *syncer) batchSize(start int) int {
+	if s.target-start < syncBatchSize {
+		return s.target - start
+	}
+	return syncBatchSize
+}
+
+// fillLocked requests batches until the window is full.
+func (s *syncer) fillLocked() {
+	for s.phase == SyncBlocks && len(s.inflight)+len(s.ready) < s.window && s.next < s.target {
+		size := s.batchSize(s.next)
+		s.peer.stats.blocksRequested()
+		s.peer.SendPayload(MsgGetBlocks, &GetBlocksPayload{FromHeight: s.next, Limit: size})
+		s.inflight[s.next] = time.Now()
+		s.next += size
+	}
+}
+
+// deliver hands a batch from p to the pipeline. It reports false when the
+// batch was not requested by the sync, so the caller processes it normally.
+func (s *syncer) deliver(p *Peer, blocks []*chain.Block) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active || p != s.peer || len(blocks) == 0 {
+		return false
+	}
+	start := blocks[0].Index
+	if _, ok := s.inflight[start]; !ok {
+		return false
+	}
+	delete(s.inflight, start)
+	s.ready[start] = blocks
+	s.signal()
+	return true
+}
+
+func (s *syncer) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *syncer) Status() SyncStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active {
+		return SyncStatus{}
+	}
+	return SyncStatus{
+		Active:       true,
+		Phase:        s.phase,
+		Peer:         s.peer.Addr(),
+		HeaderHeight: s.from + len(s.headers),
+		Target:       s.target,
+		Validated:    s.done,
+		InFlight:     len(s.inflight),
+		Buffered:     len(s.ready),
+	}
+}
+
+// run validates batches in height order until the target is reached, the
+// peer goes away, a request times out or a header or block is rejected.
+// Afterwards the peer is asked for anything newer with a locator, which also
+// recovers from the peer having switched branches mid-sync. If the peer was
+// too slow or went away, the sync moves to the next fastest peer instead. A
+// rejected block needs no follow-up: acceptBlock already re-requests after an
+// orphan and drops the batch after an invalid block.
+func (s *syncer) run(p *Peer) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	reason := "complete"
+	for {
+		batch, stop, finished, timedOut := s.take()
+		if finished {
+			break
+		}
+		if stop != "" {
+			reason = stop
+			break
+		}
+		if timedOut {
+			reason = "request timed out"
+			p.stats.deliveryTimedOut(syncRequestTimeout)
+			break
+		}
+		if batch != nil {
+			if reason = s.validate(p, batch); reason != "" {
+				break
+			}
+			reason = "complete"
+			continue
+		}
+
+		select {
+		case <-p.Done():
+			reason = "peer disconnected"
+		case <-s.wake:
+			continue
+		case <-ticker.C:
+			continue
+		}
+		break
+	}
+
+	s.mu.Lock()
+	validated := s.done
+	ban := s.banPeer
+	s.active = false
+	s.peer = nil
+	s.context = nil
+	s.headers = nil
+	s.inflight = nil
+	s.ready = nil
+	s.mu.Unlock()
+
+	slog.Warn("P2P sync stopped", "peer", p.Addr(), "height", validated, "reason", reason)
+	if ban {
+		s.n.noteInvalidBlock(p)
+	}
+	if ban && s.n.bans != nil {
+		if _, err := s.n.BanPeer(p.Addr(), reason, DefaultBanDuration); err != nil {
+			slog.Error("P2P failed to save ban list", "err", err)
+		}
+	}
+	switch reason {
+	case "batch rejected":
+		return
+	case "request timed out", "peer disconnected":
+		if s.n.startSync(p) {
+			return
+		}
+	}
+	select {
+	case <-p.Done():
+	default:
+		s.n.requestBlocks(p)
+	}
+}
+
+// take returns the next batch in order if it has arrived.
+func (s *syncer) take() (batch []*chain.Block, stop string, finished, timedOut bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != "" {
+		return nil, s.stop, false, false
+	}
+	if s.phase == SyncHeaders {
+		return nil, "", false, time.Since(s.headersAsked) > syncRequestTimeout
+	}
+	if s.done >= s.target && len(s.inflight) == 0 {
+		return nil, "", true, false
+	}
+	if batch, ok := s.ready[s.done]; ok {
+		delete(s.ready, s.done)
+		return batch, "", false, false
+	}
+	for _, requested := range s.inflight {
+		if time.Since(requested) > syncRequestTimeout {
+			return nil, "", false, true
+		}
+	}
+	return nil, "", false, false
+}
+
+// validate connects a batch, returning why it was refused if it was.
+func (s *syncer) validate(p *Peer, batch []*chain.Block) string {
+	s.mu.Lock()
+	start, expected := s.done, s.batchSize(s.done)
+	s.mu.Unlock()
+
+	for i, block := range batch {
+		// Blocks must be the ones whose headers were verified; anything
+		// else means the peer switched branches since sending them.
+		if i >= expected || block.Index != start+i || block.Hash != s.headers[block.Index-s.from].Hash {
+			return fmt.Sprintf("block %d does not match its verified header", block.Index)
+		}
+		if !s.n.acceptBlock(p, block) {
+			return "batch rejected"
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.done += len(batch)
+	if len(batch) < expected {
+		// The peer's chain ended early (it reorganized onto a shorter
+		// branch); drop requests past its end.
+		s.target = s.done
+		for start := range s.inflight {
+			if start >= s.done {
+				delete(s.inflight, start)
+			}
+		}
+		for start := range s.ready {
+			if start >= s.done {
+				delete(s.ready, start)
+			}
+		}
+	}
+	s.fillLocked()
+	return ""
+}
@@ -0,0 +1,100 @@
+package p2p
+
+import (
+	"encoding/json"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/chaintest"
+)
+
+// getBlocks asks n for blocks as p and returns the heights it answers with.
+func getBlocks(t *testing.T, n *Network, p *Peer, req *GetBlocksPayload) []int {
+	t.Helper()
+	n.handleGetBlocks(p, req)
+	msg := <-p.send
+	if msg.Type != MsgBlocks {
+		t.Fatalf("answered with %s, want %s", msg.Type, MsgBlocks)
+	}
+	var payload BlocksPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatal(err)
+	}
+	heights := []int{}
+	for _, b := range payload.Blocks {
+		heights = append(heights, b.Index)
+	}
+	return heights
+}
+
+// A peer syncing with a locator gets the blocks after the last hash both
+// sides share. Hashes we don't have on the main chain, whether from the
+// peer's own branch, a branch we saw lose or not hashes at all, are passed
+// over; a locator with nothing we know falls back to genesis.
+func TestGetBlocksLocator(t *testing.T) {
+	address := strings.Repeat("a", 64)
+	genesis := chaintest.Genesis(t, address)
+	bc := chaintest.FromGenesis(genesis)
+
+	// The peer shares our first two blocks, then mines two of its own.
+	// A scratch copy of our chain mines a block that loses to our fifth.
+	peer := chaintest.FromGenesis(genesis)
+	side := chaintest.FromGenesis(genesis)
+	for i := 1; i <= 5; i++ {
+		block := chaintest.MineBlock(t, bc, address)
+		if err := bc.AddBlock(block); err != nil {
+			t.Fatal(err)
+		}
+		if i <= 2 {
+			if err := peer.AddBlock(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if i <= 4 {
+			if err := side.AddBlock(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := peer.AddBlock(chaintest.MineBlock(t, peer, strings.Repeat("b", 64))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stale := chaintest.MineBlock(t, side, strings.Repeat("c", 64))
+	if _, err := bc.ProcessBlock(stale); err != nil {
+		t.Fatal(err)
+	}
+	if bc.Tip().Index != 5 || bc.Tip().Hash == stale.Hash {
+		t.Fatal("side block took the tip")
+	}
+
+	n := New(Config{}, bc, chain.NewMempool(), chain.NewBlockPipeline(bc, chain.NewMempool()))
+	conn, _ := net.Pipe()
+	p := newPeer(conn, false)
+	defer p.Close()
+
+	tip := bc.Tip().Hash
+	unknown := strings.Repeat("f", 64)
+	for _, c := range []struct {
+		name    string
+		locator []string
+		limit   int
+		want    []int
+	}{
+		{"fork", peer.Locator(), 0, []int{3, 4, 5}},
+		{"limit", peer.Locator(), 2, []int{3, 4}},
+		{"garbage first", append([]string{"", "not a hash", unknown}, peer.Locator()...), 0, []int{3, 4, 5}},
+		{"at our tip", []string{tip}, 0, []int{}},
+		{"side branch", []string{stale.Hash, bc.BlocksFrom(3, 1)[0].Hash}, 0, []int{4, 5}},
+		{"nothing known", []string{unknown, "zz"}, 0, []int{0, 1, 2, 3, 4, 5}},
+	} {
+		got := getBlocks(t, n, p, &GetBlocksPayload{Locator: c.locator, Limit: c.limit})
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: blocks %v, want %v", c.name, got, c.want)
+		}
+	}
+}
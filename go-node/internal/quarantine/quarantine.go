@@ -0,0 +1,181 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultMaxBytes = 64 << 20
+
+const (
+	KindBlock       = "block"
+	KindTransaction = "transaction"
+)
+
+// Entry is one rejected object as written to disk.
+type Entry struct {
+	Kind       string      `json:"kind"`
+	ID         string      `json:"id"`
+	Reason     string      `json:"reason"`
+	Source     string      `json:"source"`
+	RejectedAt int64       `json:"rejected_at"`
+	Object     interface{} `json:"object"`
+}
+
+type Status struct {
+	Enabled  bool   `json:"enabled"`
+	Dir      string `json:"dir"`
+	Files    int    `json:"files"`
+	Bytes    int64  `json:"bytes"`
+	MaxBytes int64  `json:"max_bytes"`
+	Recorded int    `json:"recorded"`
+	Evicted  int    `json:"evicted"`
+}
+
+type file struct {
+	name string
+	size int64
+}
+
+// Store keeps rejected blocks and transactions as JSON files in a directory,
+// deleting the oldest files once the total size exceeds maxBytes. A nil
+// Store records nothing.
+type Store struct {
+	dir      string
+	maxBytes int64
+
+	mu       sync.Mutex
+	enabled  bool
+	files    []file // oldest first
+	bytes    int64
+	recorded int
+	evicted  int
+}
+
+func Open(dir string, maxBytes int64, enabled bool) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+
+	s := &Store{dir: dir, maxBytes: maxBytes, enabled: enabled}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		s.files = append(s.files, file{name: e.Name(), size: info.Size()})
+		s.bytes += info.Size()
+	}
+	// File names start with a nanosecond timestamp, so name order is age order.
+	sort.Slice(s.files, func(i, j int) bool { return s.files[i].name < s.files[j].name })
+	s.evict()
+
+	return s, nil
+}
+
+func (s *Store) SetEnabled(enabled bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = enabled
+}
+
+func (s *Store) Status() Status {
+	if s == nil {
+		return Status{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		Enabled:  s.enabled,
+		Dir:      s.dir,
+		Files:    len(s.files),
+		Bytes:    s.bytes,
+		MaxBytes: s.maxBytes,
+		Recorded: s.recorded,
+		Evicted:  s.evicted,
+	}
+}
+
+// Record writes a rejected object with the reason it was rejected and where
+// it came from (e.g. "api", "p2p:1.2.3.4:9000").
+func (s *Store) Record(kind, id string, object interface{}, reason, source string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return
+	}
+
+	now := time.Now()
+	data, err := json.MarshalIndent(Entry{
+		Kind:       kind,
+		ID:         id,
+		Reason:     reason,
+		Source:     source,
+		RejectedAt: now.Unix(),
+		Object:     object,
+	}, "", "  ")
+	if err != nil {
+		slog.Error("Quarantine: failed to encode object", "kind", kind, "id", id, "err", err)
+		return
+	}
+
+	name := fmt.Sprintf("%019d-%s-%s.json", now.UnixNano(), kind, safeName(id))
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0644); err != nil {
+		slog.Error("Quarantine: failed to write file", "file", name, "err", err)
+		return
+	}
+
+	s.files = append(s.files, file{name: name, size: int64(len(data))})
+	s.bytes += int64(len(data))
+	s.recorded++
+	s.evict()
+}
+
+func (s *Store) evict() {
+	for s.bytes > s.maxBytes && len(s.files) > 0 {
+		oldest := s.files[0]
+		s.files = s.files[1:]
+		s.bytes -= oldest.size
+		s.evicted++
+		if err := os.Remove(filepath.Join(s.dir, oldest.name)); err != nil && !os.IsNotExist(err) {
+			slog.Error("Quarantine: failed to remove file", "file", oldest.name, "err", err)
+		}
+	}
+}
+
+// safeName keeps ids usable as file name components.
+func safeName(id string) string {
+	if len(id) > 64 {
+		id = id[:64]
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, id)
+}
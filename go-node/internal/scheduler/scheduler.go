@@ -0,0 +1,193 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+const MinInterval = 10 * time.Second
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusPaused    Status = "paused"
+	StatusCancelled Status = "cancelled"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("schedule not found")
+	ErrScheduleInvalid  = errors.New("schedule requires from, to, a positive amount and an interval of at least 10s")
+	ErrScheduleClosed   = errors.New("schedule is cancelled")
+)
+
+// SubmitFunc builds, signs and submits one payment, returning its txid.
+type SubmitFunc func(from, to string, amount float64) (string, error)
+
+type Schedule struct {
+	ID        string  `json:"id"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Amount    float64 `json:"amount"`
+	Interval  string  `json:"interval"`
+	Status    Status  `json:"status"`
+	NextRun   int64   `json:"next_run"`
+	Runs      int     `json:"runs"`
+	Failures  int     `json:"failures"`
+	LastTxID  string  `json:"last_txid,omitempty"`
+	LastError string  `json:"last_error,omitempty"`
+	CreatedAt int64   `json:"created_at"`
+
+	interval time.Duration
+}
+
+type Scheduler struct {
+	mu        sync.Mutex
+	schedules map[string]*Schedule
+	submit    SubmitFunc
+}
+
+func New(submit SubmitFunc) *Scheduler {
+	return &Scheduler{
+		schedules: make(map[string]*Schedule),
+		submit:    submit,
+	}
+}
+
+func newID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func (s *Scheduler) Add(from, to string, amount float64, interval time.Duration, firstRun time.Time) (Schedule, error) {
+	if from == "" || to == "" || amount <= 0 || interval < MinInterval {
+		return Schedule{}, ErrScheduleInvalid
+	}
+	if firstRun.IsZero() {
+		firstRun = time.Now().Add(interval)
+	}
+
+	sched := &Schedule{
+		ID:        newID(),
+		From:      from,
+		To:        to,
+		Amount:    amount,
+		Interval:  interval.String(),
+		Status:    StatusActive,
+		NextRun:   firstRun.Unix(),
+		CreatedAt: time.Now().Unix(),
+		interval:  interval,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules[sched.ID] = sched
+	return *sched, nil
+}
+
+func (s *Scheduler) List() []Schedule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]Schedule, 0, len(s.schedules))
+	for _, sched := range s.schedules {
+		out = append(out, *sched)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out
+}
+
+func (s *Scheduler) Get(id string) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	return *sched, nil
+}
+
+func (s *Scheduler) setStatus(id string, status Status) (Schedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sched, ok := s.schedules[id]
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	if sched.Status == StatusCancelled {
+		return Schedule{}, ErrScheduleClosed
+	}
+
+	if status == StatusActive && sched.Status == StatusPaused {
+		next := time.Now().Add(sched.interval).Unix()
+		if sched.NextRun < next {
+			sched.NextRun = next
+		}
+	}
+	sched.Status = status
+	return *sched, nil
+}
+
+func (s *Scheduler) Pause(id string) (Schedule, error) {
+	return s.setStatus(id, StatusPaused)
+}
+
+func (s *Scheduler) Resume(id string) (Schedule, error) {
+	return s.setStatus(id, StatusActive)
+}
+
+func (s *Scheduler) Cancel(id string) (Schedule, error) {
+	return s.setStatus(id, StatusCancelled)
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDue(now)
+		}
+	}
+}
+
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	var due []*Schedule
+	for _, sched := range s.schedules {
+		if sched.Status == StatusActive && sched.NextRun <= now.Unix() {
+			sched.NextRun = now.Add(sched.interval).Unix()
+			due = append(due, sched)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, sched := range due {
+		txid, err := s.submit(sched.From, sched.To, sched.Amount)
+
+		s.mu.Lock()
+		sched.Runs++
+		if err != nil {
+			sched.Failures++
+			sched.LastError = err.Error()
+			slog.Warn("Scheduled payment failed", "schedule", sched.ID, "err", err)
+		} else {
+			sched.LastTxID = txid
+			sched.LastError = ""
+			slog.Info("Scheduled payment submitted", "schedule", sched.ID, "txid", txid)
+		}
+		s.mu.Unlock()
+	}
+}
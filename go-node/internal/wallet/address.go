@@ -0,0 +1,109 @@
+package wallet
+
+import (
+	"fmt"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+const (
+	// AddressTypePubKeyHash is the SHA-256 of a public key's 64-byte X||Y
+	// encoding, the address of a single key.
+	AddressTypePubKeyHash = "pubkey_hash"
+	// AddressTypeMultisig is "ms" and the SHA-256 of an m-of-n key set (see
+	// chain.MultisigAddress).
+	AddressTypeMultisig = "multisig"
+)
+
+// AddressCheck describes what is wrong, if anything, with an address a
+// client typed or pasted. Valid reports whether Normalized is an address;
+// Canonical whether the input already was, byte for byte. Addresses are
+// compared as strings, so a non-canonical one must be normalized before it
+// is used, or it names nobody.
+type AddressCheck struct {
+	Input      string   `json:"input"`
+	Valid      bool     `json:"valid"`
+	Canonical  bool     `json:"canonical"`
+	Normalized string   `json:"normalized,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Encoding   string   `json:"encoding"`
+	Checksum   bool     `json:"checksum"` // addresses carry none, so typos go unnoticed
+	Problems   []string `json:"problems,omitempty"`
+}
+
+// CheckAddress inspects input as an address for this network. It trims
+// whitespace, drops a 0x prefix and lowercases hex, reporting each as a
+// problem, and explains the common ways an input fails to be an address.
+func CheckAddress(input string) AddressCheck {
+	check := AddressCheck{Input: input, Encoding: "hex"}
+
+	s := strings.TrimSpace(input)
+	if s != input {
+		check.Problems = append(check.Problems, "surrounding whitespace removed")
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		s = s[2:]
+		check.Problems = append(check.Problems, "0x prefix removed: addresses are bare hex")
+	}
+	if lower := strings.ToLower(s); lower != s {
+		s = lower
+		check.Problems = append(check.Problems, "uppercase hex lowercased")
+	}
+
+	if chain.IsMultisigAddress(s) {
+		check.Valid = true
+		check.Canonical = s == input
+		check.Normalized = s
+		check.Type = AddressTypeMultisig
+		return check
+	}
+
+	for i, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			if looksBech32(s) {
+				check.Problems = append(check.Problems, "bech32 addresses are not used on this network")
+			} else {
+				check.Problems = append(check.Problems, fmt.Sprintf("non-hex character %q at position %d", r, i))
+			}
+			return check
+		}
+	}
+
+	switch {
+	case len(s) == AddressLength:
+	case len(s) == 2*AddressLength:
+		check.Problems = append(check.Problems, "this is a public key: its address is the SHA-256 of it (POST /api/wallet/derive)")
+		return check
+	default:
+		check.Problems = append(check.Problems, fmt.Sprintf("must be %d hex characters, got %d", AddressLength, len(s)))
+		return check
+	}
+
+	check.Valid = true
+	check.Canonical = s == input
+	check.Normalized = s
+	check.Type = AddressTypePubKeyHash
+	return check
+}
+
+// looksBech32 reports whether s is shaped like a Bitcoin-style bech32
+// address: a lowercase human-readable part, the separator 1, and at least
+// six characters from the bech32 alphabet.
+func looksBech32(s string) bool {
+	sep := strings.LastIndex(s, "1")
+	if sep < 1 || sep+7 > len(s) || len(s) > 90 {
+		return false
+	}
+	for _, r := range s[:sep] {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	for _, r := range s[sep+1:] {
+		if !strings.ContainsRune("qpzry9x8gf2tvdw0s3jn54khce6mua7l", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"strings"
+)
+
+// BIP39 mnemonics: entropy plus a checksum, written as words from the
+// standard English list, so a wallet's seed can be written down and
+// restored in other wallets.
+
+//go:embed bip39_english.txt
+var bip39English string
+
+const (
+	DefaultMnemonicWords = 24
+	mnemonicIterations   = 2048
+)
+
+var (
+	ErrInvalidMnemonic     = &WalletError{Message: "invalid mnemonic"}
+	ErrMnemonicLength      = &WalletError{Message: "mnemonic must have 12, 15, 18, 21 or 24 words"}
+	ErrMnemonicPassphrase  = &WalletError{Message: "mnemonic passphrase must be ASCII"}
+	bip39Words, bip39Index = loadWordlist(bip39English)
+)
+
+func loadWordlist(text string) ([]string, map[string]int) {
+	words := strings.Fields(text)
+	if len(words) != 2048 {
+		panic("wallet: BIP39 wordlist must have 2048 words")
+	}
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[w] = i
+	}
+	return words, index
+}
+
+// NewMnemonic returns a mnemonic of words words over fresh random entropy.
+func NewMnemonic(words int) (string, error) {
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", ErrMnemonicLength
+	}
+	entropy := make([]byte, words*11*32/33/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return mnemonicFromEntropy(entropy), nil
+}
+
+// mnemonicFromEntropy appends the first len(entropy)/4 bits of its SHA-256
+// to the entropy and writes each 11 bits as a word.
+func mnemonicFromEntropy(entropy []byte) string {
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte(nil), entropy...), checksum[0])
+	words := make([]string, len(entropy)*8*33/32/11)
+	for i := range words {
+		index := 0
+		for bit := i * 11; bit < (i+1)*11; bit++ {
+			index = index<<1 | int(data[bit/8]>>(7-bit%8)&1)
+		}
+		words[i] = bip39Words[index]
+	}
+	return strings.Join(words, " ")
+}
+
+// NormalizeMnemonic lowercases mnemonic, collapses its whitespace and
+// checks its words and checksum.
+func NormalizeMnemonic(mnemonic string) (string, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return "", ErrMnemonicLength
+	}
+
+	bits := len(words) * 11
+	data := make([]byte, (bits+7)/8)
+	for i, w := range words {
+		index, ok := bip39Index[w]
+		if !ok {
+			return "", ErrInvalidMnemonic
+		}
+		for b := 0; b < 11; b++ {
+			if index>>(10-b)&1 == 1 {
+				bit := i*11 + b
+				data[bit/8] |= 1 << (7 - bit%8)
+			}
+		}
+	}
+	entropy := data[:bits*32/33/8]
+	if mnemonicFromEntropy(entropy) != strings.Join(words, " ") {
+		return "", ErrInvalidMnemonic
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicSeed is the 64-byte BIP39 seed of mnemonic under passphrase,
+// which may be empty. BIP39 normalizes both to NFKD first; the English
+// words are ASCII already, and passphrases are restricted to ASCII, for
+// which NFKD changes nothing.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(passphrase); i++ {
+		if passphrase[i] >= 0x80 {
+			return nil, ErrMnemonicPassphrase
+		}
+	}
+	return pbkdf2Key(sha512.New, []byte(mnemonic), []byte("mnemonic"+passphrase), mnemonicIterations, 64), nil
+}
@@ -0,0 +1,42 @@
+package wallet
+
+import (
+	"sort"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// SelectCoins picks outputs covering target. It prefers the smallest single
+// output that covers the target, which avoids change where possible;
+// otherwise it takes the largest outputs first to keep the input count low.
+// Ties are broken by outpoint so the choice is deterministic.
+func SelectCoins(available []chain.UTXO, target float64) ([]chain.UTXO, float64, error) {
+	coins := make([]chain.UTXO, len(available))
+	copy(coins, available)
+	sort.Slice(coins, func(i, j int) bool {
+		if coins[i].Output.Amount != coins[j].Output.Amount {
+			return coins[i].Output.Amount < coins[j].Output.Amount
+		}
+		if coins[i].Key.TxID != coins[j].Key.TxID {
+			return coins[i].Key.TxID < coins[j].Key.TxID
+		}
+		return coins[i].Key.Index < coins[j].Key.Index
+	})
+
+	for _, c := range coins {
+		if c.Output.Amount >= target {
+			return []chain.UTXO{c}, c.Output.Amount, nil
+		}
+	}
+
+	var selected []chain.UTXO
+	var total float64
+	for i := len(coins) - 1; i >= 0 && chain.RoundAmount(total) < target; i-- {
+		selected = append(selected, coins[i])
+		total += coins[i].Output.Amount
+	}
+	if chain.RoundAmount(total) < target {
+		return nil, total, ErrInsufficientFunds
+	}
+	return selected, total, nil
+}
@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// Every unspent output is an entry every node keeps in memory, and an
+// address paid many small amounts holds many of them. Consolidating spends
+// the small outputs back to the address in one transaction, leaving a
+// single output and a smaller UTXO set.
+
+// MaxConsolidationInputs bounds the inputs of one consolidation
+// transaction; an address with more small outputs consolidates in rounds.
+const MaxConsolidationInputs = 200
+
+var ErrNothingToConsolidate = &WalletError{Message: "fewer than two small outputs to consolidate"}
+
+// ConsolidationPolicy is a soft quota on how much of the UTXO set an address
+// holds in small outputs. Exceeding it is never an error; it only makes the
+// node suggest consolidating.
+type ConsolidationPolicy struct {
+	MaxSmallOutputs int     // suggest consolidating above this many small outputs
+	SmallBelow      float64 // outputs worth less than this are small
+}
+
+func DefaultConsolidationPolicy() ConsolidationPolicy {
+	return ConsolidationPolicy{MaxSmallOutputs: 20, SmallBelow: 1}
+}
+
+// SmallOutputs returns the small outputs in available, smallest first with
+// ties broken by outpoint so the choice is deterministic.
+func (p ConsolidationPolicy) SmallOutputs(available []chain.UTXO) []chain.UTXO {
+	var small []chain.UTXO
+	for _, c := range available {
+		if c.Output.Amount < p.SmallBelow {
+			small = append(small, c)
+		}
+	}
+	sort.Slice(small, func(i, j int) bool {
+		if small[i].Output.Amount != small[j].Output.Amount {
+			return small[i].Output.Amount < small[j].Output.Amount
+		}
+		if small[i].Key.TxID != small[j].Key.TxID {
+			return small[i].Key.TxID < small[j].Key.TxID
+		}
+		return small[i].Key.Index < small[j].Key.Index
+	})
+	return small
+}
+
+// OverQuota reports whether small outputs are more than the policy allows.
+func (p ConsolidationPolicy) OverQuota(small int) bool {
+	return small > p.MaxSmallOutputs
+}
+
+// BuildConsolidation returns an unsigned transaction spending the small
+// outputs of address in utxo, at most MaxConsolidationInputs of them, to a
+// single output back to address less fee.
+func (p ConsolidationPolicy) BuildConsolidation(address string, fee float64, utxo *chain.UTXOSet) (*chain.Transaction, error) {
+	if err := chain.CheckAmount(fee); err != nil {
+		return nil, fmt.Errorf("fee: %w", err)
+	}
+	fee = chain.RoundAmount(fee)
+
+	coins := p.SmallOutputs(utxo.UnspentOutputs(address))
+	if len(coins) < 2 {
+		return nil, ErrNothingToConsolidate
+	}
+	if len(coins) > MaxConsolidationInputs {
+		coins = coins[:MaxConsolidationInputs]
+	}
+
+	inputs := make([]chain.TxIn, len(coins))
+	var total float64
+	for i, c := range coins {
+		inputs[i] = chain.TxIn{TxID: c.Key.TxID, Index: c.Key.Index}
+		total += c.Output.Amount
+	}
+	amount := chain.RoundAmount(total - fee)
+	if amount <= 0 {
+		return nil, ErrInsufficientFunds
+	}
+	return chain.NewTransaction(inputs, []chain.TxOut{{Address: address, Amount: amount}})
+}
+
+// BuildAndSignConsolidation is BuildConsolidation signed with the wallet at
+// address.
+func (ws *WalletStore) BuildAndSignConsolidation(address string, policy ConsolidationPolicy, fee float64, utxo *chain.UTXOSet) (*chain.Transaction, error) {
+	if _, err := ws.signingKey(address); err != nil {
+		return nil, err
+	}
+	tx, err := policy.BuildConsolidation(address, fee, utxo)
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.SignTransaction(address, tx, utxo); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
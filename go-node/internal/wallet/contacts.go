@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// AddressLength is the length of an address: the hex SHA-256 of a public
+// key. Addresses carry no checksum and no network prefix, so nothing in an
+// address distinguishes one network's from another's; a per-network prefix
+// needs a checksummed encoding to hang off first.
+const AddressLength = 64
+
+var (
+	ErrInvalidAddress   = &WalletError{Message: "invalid address"}
+	ErrInvalidContact   = &WalletError{Message: "contact name must be 1-64 characters"}
+	ErrContactNotFound  = &WalletError{Message: "contact not found"}
+	ErrUnknownRecipient = &WalletError{Message: "recipient is neither a valid address nor a known contact"}
+)
+
+type Contact struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+func ValidateAddress(address string) error {
+	if chain.IsMultisigAddress(address) {
+		return nil
+	}
+	if len(address) != AddressLength {
+		return ErrInvalidAddress
+	}
+	if _, err := hex.DecodeString(address); err != nil {
+		return ErrInvalidAddress
+	}
+	return nil
+}
+
+func normalizeContactName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return "", ErrInvalidContact
+	}
+	return strings.ToLower(name), nil
+}
+
+// SaveContact adds or replaces a named contact in the address book owned by
+// the given wallet.
+func (ws *WalletStore) SaveContact(owner, name, address string) (Contact, error) {
+	key, err := normalizeContactName(name)
+	if err != nil {
+		return Contact{}, err
+	}
+	if err := ValidateAddress(address); err != nil {
+		return Contact{}, err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.wallets[owner]; !ok {
+		return Contact{}, ErrWalletNotFound
+	}
+	if ws.contacts[owner] == nil {
+		ws.contacts[owner] = make(map[string]Contact)
+	}
+
+	contact := Contact{Name: strings.TrimSpace(name), Address: address}
+	ws.contacts[owner][key] = contact
+	return contact, nil
+}
+
+func (ws *WalletStore) DeleteContact(owner, name string) error {
+	key, err := normalizeContactName(name)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.contacts[owner][key]; !ok {
+		return ErrContactNotFound
+	}
+	delete(ws.contacts[owner], key)
+	return nil
+}
+
+func (ws *WalletStore) Contacts(owner string) []Contact {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	contacts := make([]Contact, 0, len(ws.contacts[owner]))
+	for _, c := range ws.contacts[owner] {
+		contacts = append(contacts, c)
+	}
+	sort.Slice(contacts, func(i, j int) bool { return contacts[i].Name < contacts[j].Name })
+	return contacts
+}
+
+// ResolveRecipient returns recipient unchanged when it is already an address,
+// otherwise looks it up as a contact name in the owner's address book.
+func (ws *WalletStore) ResolveRecipient(owner, recipient string) (string, error) {
+	if ValidateAddress(recipient) == nil {
+		return recipient, nil
+	}
+
+	key, err := normalizeContactName(recipient)
+	if err != nil {
+		return "", ErrUnknownRecipient
+	}
+
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	contact, ok := ws.contacts[owner][key]
+	if !ok {
+		return "", ErrUnknownRecipient
+	}
+	return contact.Address, nil
+}
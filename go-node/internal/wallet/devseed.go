@@ -0,0 +1,69 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// SetDevSeed makes the store derive new wallet keys and HD mnemonics from
+// seed rather than crypto/rand, for development networks only.
+func (ws *WalletStore) SetDevSeed(seed *crypto.DevSeed) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.devSeed = seed
+}
+
+func (ws *WalletStore) seed() *crypto.DevSeed {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.devSeed
+}
+
+// newKey returns the key of a new wallet. A dev seed's keys are skipped
+// while they are already in the store, as they are when a keystore written
+// by an earlier run is loaded, so each run continues the same sequence.
+func (ws *WalletStore) newKey() (*ecdsa.PrivateKey, error) {
+	seed := ws.seed()
+	if seed == nil {
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+	for {
+		priv := seed.PrivateKey(crypto.DevSeedWallet)
+		address := crypto.AddressFromPublicKey(&priv.PublicKey)
+		if ws.GetWallet(address) == nil {
+			return priv, nil
+		}
+	}
+}
+
+// newMnemonic returns the mnemonic of a new HD wallet, skipping a dev
+// seed's mnemonics whose wallets the store already has.
+func (ws *WalletStore) newMnemonic(words int) (string, error) {
+	seed := ws.seed()
+	if seed == nil {
+		return NewMnemonic(words)
+	}
+	if words < 12 || words > 24 || words%3 != 0 {
+		return "", ErrMnemonicLength
+	}
+	for {
+		mnemonic := mnemonicFromEntropy(seed.Bytes(crypto.DevSeedMnemonic, words*11*32/33/8))
+		bip39Seed, err := MnemonicSeed(mnemonic, "")
+		if err != nil {
+			return "", err
+		}
+		if !ws.hasHDWallet(hdWalletID(bip39Seed)) {
+			return mnemonic, nil
+		}
+	}
+}
+
+func (ws *WalletStore) hasHDWallet(id string) bool {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	_, ok := ws.hd[id]
+	return ok
+}
@@ -0,0 +1,296 @@
+package wallet
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// HD wallets are BIP39 mnemonics from which the store derives ordinary
+// wallets along m/44'/1'/0'/chain/index: chain 0 for receive addresses,
+// chain 1 for change. Coin type 1 is SLIP-0044's "testnet for all coins";
+// the chain has no registered type of its own.
+
+const (
+	hdReceiveChain = 0
+	hdChangeChain  = 1
+)
+
+var hdAccountPath = []uint32{hardened + 44, hardened + 1, hardened + 0}
+
+var (
+	ErrHDWalletNotFound = &WalletError{Message: "HD wallet not found"}
+	ErrHDWalletExists   = &WalletError{Message: "an HD wallet with this seed is already in the store"}
+	ErrMnemonicRevealed = &WalletError{Message: "mnemonic was already revealed or never stored"}
+)
+
+type hdWallet struct {
+	id        string
+	label     string
+	seed      []byte // nil while the store is locked
+	mnemonic  string // held until revealed; empty for restored wallets
+	next      [2]uint32
+	createdAt int64
+}
+
+// HDWalletInfo is what listings show of an HD wallet: never its seed.
+type HDWalletInfo struct {
+	ID              string `json:"id"`
+	Label           string `json:"label,omitempty"`
+	Account         string `json:"account"` // derivation path of the account
+	NextReceive     uint32 `json:"next_receive"`
+	NextChange      uint32 `json:"next_change"`
+	MnemonicPending bool   `json:"mnemonic_pending"` // created here and not revealed yet
+	CreatedAt       int64  `json:"created_at"`
+}
+
+func (h *hdWallet) info() HDWalletInfo {
+	return HDWalletInfo{
+		ID:              h.id,
+		Label:           h.label,
+		Account:         FormatHDPath(hdAccountPath),
+		NextReceive:     h.next[hdReceiveChain],
+		NextChange:      h.next[hdChangeChain],
+		MnemonicPending: h.mnemonic != "",
+		CreatedAt:       h.createdAt,
+	}
+}
+
+// hdWalletIDLength is the bytes of the fingerprint an HD wallet is named by.
+// The store tells seeds apart by it, so it must be far too long to collide
+// by chance or to be matched on purpose.
+const hdWalletIDLength = 16
+
+// hdWalletID fingerprints a seed by its master public key.
+func hdWalletID(seed []byte) string {
+	master := hdMaster(seed).privateKey()
+	sum := sha256.Sum256(elliptic.MarshalCompressed(master.Curve, master.X, master.Y))
+	return hex.EncodeToString(sum[:hdWalletIDLength])
+}
+
+// CreateHDWallet generates a mnemonic of words words, which the store keeps
+// until RevealMnemonic hands it out, and derives the wallet's first receive
+// address.
+func (ws *WalletStore) CreateHDWallet(label string, words int, passphrase string) (HDWalletInfo, *Wallet, error) {
+	mnemonic, err := ws.newMnemonic(words)
+	if err != nil {
+		return HDWalletInfo{}, nil, err
+	}
+	return ws.addHDWallet(label, mnemonic, passphrase, true)
+}
+
+// RestoreHDWallet adds the HD wallet of an existing mnemonic and derives
+// its first receive address. Addresses derived elsewhere beyond that are
+// found again by deriving more.
+func (ws *WalletStore) RestoreHDWallet(label, mnemonic, passphrase string) (HDWalletInfo, *Wallet, error) {
+	return ws.addHDWallet(label, mnemonic, passphrase, false)
+}
+
+func (ws *WalletStore) addHDWallet(label, mnemonic, passphrase string, keepMnemonic bool) (HDWalletInfo, *Wallet, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return HDWalletInfo{}, nil, err
+	}
+	mnemonic, err = NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return HDWalletInfo{}, nil, err
+	}
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return HDWalletInfo{}, nil, err
+	}
+
+	h := &hdWallet{id: hdWalletID(seed), label: label, seed: seed, createdAt: time.Now().Unix()}
+	if keepMnemonic {
+		h.mnemonic = mnemonic
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return HDWalletInfo{}, nil, ErrStoreLocked
+	}
+	if _, ok := ws.hd[h.id]; ok {
+		return HDWalletInfo{}, nil, ErrHDWalletExists
+	}
+	ws.hd[h.id] = h
+	w, err := ws.deriveLocked(h, hdReceiveChain, label, "")
+	if err != nil {
+		delete(ws.hd, h.id)
+		return HDWalletInfo{}, nil, err
+	}
+	return h.info(), w, nil
+}
+
+// deriveLocked adds the next wallet on chain of h and saves the store;
+// callers hold ws.mu for writing.
+func (ws *WalletStore) deriveLocked(h *hdWallet, chain uint32, label, owner string) (*Wallet, error) {
+	if h.seed == nil {
+		return nil, ErrStoreLocked
+	}
+	path := append(append([]uint32(nil), hdAccountPath...), chain, h.next[chain])
+	priv := hdMaster(h.seed).derive(path).privateKey()
+	address := crypto.AddressFromPublicKey(&priv.PublicKey)
+
+	w := &Wallet{
+		Address:    address,
+		PrivateKey: priv,
+		PublicKey:  &priv.PublicKey,
+		Label:      label,
+		Owner:      owner,
+		HD:         h.id,
+		Path:       FormatHDPath(path),
+	}
+	ws.wallets[address] = w
+	h.next[chain]++
+	if err := ws.saveLocked(); err != nil {
+		delete(ws.wallets, address)
+		h.next[chain]--
+		return nil, err
+	}
+	return w, nil
+}
+
+// DeriveReceiveAddress derives the next receive address of HD wallet id.
+func (ws *WalletStore) DeriveReceiveAddress(id, label string) (*Wallet, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	h, ok := ws.hd[id]
+	if !ok {
+		return nil, ErrHDWalletNotFound
+	}
+	return ws.deriveLocked(h, hdReceiveChain, label, "")
+}
+
+// NewChangeWallet returns a fresh wallet for the change of a payment from
+// owner, labeled "change". When owner was derived from an HD wallet, so is
+// the change wallet, on the change chain, and the mnemonic recovers it.
+func (ws *WalletStore) NewChangeWallet(owner string) (*Wallet, error) {
+	ws.mu.Lock()
+	var h *hdWallet
+	if w := ws.wallets[owner]; w != nil && w.HD != "" {
+		h = ws.hd[w.HD]
+	}
+	if h == nil {
+		ws.mu.Unlock()
+		return ws.GenerateLabeledWallet("change", owner)
+	}
+	defer ws.mu.Unlock()
+	return ws.deriveLocked(h, hdChangeChain, "change", owner)
+}
+
+// DiscardChangeWallet removes a change wallet NewChangeWallet returned for
+// a payment that was never made. An HD change wallet that is still the last
+// one derived hands its index back, so the next payment derives it again.
+func (ws *WalletStore) DiscardChangeWallet(address string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	w := ws.wallets[address]
+	if w == nil || w.Label != "change" {
+		return ErrWalletNotFound
+	}
+
+	var h *hdWallet
+	if w.HD != "" {
+		if last := ws.hd[w.HD]; last != nil && last.next[hdChangeChain] > 0 {
+			path := append(append([]uint32(nil), hdAccountPath...), hdChangeChain, last.next[hdChangeChain]-1)
+			if w.Path == FormatHDPath(path) {
+				h = last
+			}
+		}
+	}
+	delete(ws.wallets, address)
+	if h != nil {
+		h.next[hdChangeChain]--
+	}
+	if err := ws.saveLocked(); err != nil {
+		ws.wallets[address] = w
+		if h != nil {
+			h.next[hdChangeChain]++
+		}
+		return err
+	}
+	return nil
+}
+
+// RevealMnemonic returns the mnemonic of an HD wallet created by the store
+// and forgets it, so it is shown once. Back it up: it is the only way to
+// restore the wallet's keys elsewhere.
+func (ws *WalletStore) RevealMnemonic(id string) (string, error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	h, ok := ws.hd[id]
+	if !ok {
+		return "", ErrHDWalletNotFound
+	}
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return "", ErrStoreLocked
+	}
+	if h.mnemonic == "" {
+		return "", ErrMnemonicRevealed
+	}
+	mnemonic := h.mnemonic
+	h.mnemonic = ""
+	if err := ws.saveLocked(); err != nil {
+		h.mnemonic = mnemonic
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// HDWallets lists the store's HD wallets by ID.
+func (ws *WalletStore) HDWallets() []HDWalletInfo {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	infos := make([]HDWalletInfo, 0, len(ws.hd))
+	for _, h := range ws.hd {
+		infos = append(infos, h.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// HDWallet describes HD wallet id and lists the wallets derived from it in
+// derivation order.
+func (ws *WalletStore) HDWallet(id string) (HDWalletInfo, []WalletInfo, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	h, ok := ws.hd[id]
+	if !ok {
+		return HDWalletInfo{}, nil, ErrHDWalletNotFound
+	}
+	var derived []*Wallet
+	for _, w := range ws.wallets {
+		if w.HD == id {
+			derived = append(derived, w)
+		}
+	}
+	sort.Slice(derived, func(i, j int) bool { return hdPathLess(derived[i].Path, derived[j].Path) })
+	infos := make([]WalletInfo, len(derived))
+	for i, w := range derived {
+		infos[i] = w.info()
+	}
+	return h.info(), infos, nil
+}
+
+func hdPathLess(a, b string) bool {
+	pa, errA := ParseHDPath(a)
+	pb, errB := ParseHDPath(b)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		if pa[i] != pb[i] {
+			return pa[i] < pb[i]
+		}
+	}
+	return len(pa) < len(pb)
+}
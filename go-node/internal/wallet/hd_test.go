@@ -0,0 +1,167 @@
+package wallet
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// RFC 6070 vectors. PBKDF2 is the same for every hash, so SHA-1's published
+// answers pin the construction that mnemonic seeds run with SHA-512.
+var pbkdf2Vectors = []struct {
+	password, salt string
+	iterations     int
+	want           string
+}{
+	{"password", "salt", 1, "0c60c80f961f0e71f3a9b524af6012062fe037a6"},
+	{"password", "salt", 2, "ea6c014dc72d6f8ccd1ed92ace1d41f0d8de8957"},
+	{"password", "salt", 4096, "4b007901b765489abead49d926f721d065a429c1"},
+	{"passwordPASSWORDpassword", "saltSALTsaltSALTsaltSALTsaltSALTsalt", 4096, "3d2eec4fe41c849b80c8d83662c0e44a8b291a964cf2f07038"},
+}
+
+// From the reference BIP39 test vectors, all with passphrase "TREZOR".
+var bip39Vectors = []struct {
+	entropy, mnemonic, seed string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"80808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage above",
+		"d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"9e885d952ad362caeb4efe34a8e91bd2",
+		"ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic",
+		"274ddc525802f7c828d8ef7ddbcdc5304e87ac3535913611fbbfa986d0c9e5476c91689f9c8a54fd55bd38606aa6a8595ad213d4c9c9f9aca3fb217069a41028",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+}
+
+// SLIP-0010 nist256p1 test vector 1 and its derivation-retry vector, both
+// from seed 000102030405060708090a0b0c0d0e0f.
+var slip10Vectors = []struct {
+	path, chainCode, key string
+}{
+	{"m", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+	{"m/0'", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+	{"m/0'/1", "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129"},
+	{"m/0'/1/2'", "98c7514f562e64e74170cc3cf304ee1ce54d6b6da4f880f313e8204c2a185318", "694596e8a54f252c960eb771a3c41e7e32496d03b954aeb90f61635b8e092aa7"},
+	{"m/28578'", "e94c8ebe30c2250a14713212f6449b20f3329105ea15b652ca5bdfc68f6c65c2", "06f0db126f023755d0b8d86d4591718a5210dd8d024e3e14b6159d63f53aa669"},
+	{"m/28578'/33941", "9e87fe95031f14736774cd82f25fd885065cb7c358c1edf813c72af535e83071", "092154eed4af83e078ff9b84322015aefe5769e31270f62c3f66c33888335f3a"},
+}
+
+func TestPBKDF2Vectors(t *testing.T) {
+	for _, v := range pbkdf2Vectors {
+		key := pbkdf2Key(sha1.New, []byte(v.password), []byte(v.salt), v.iterations, len(v.want)/2)
+		if got := hex.EncodeToString(key); got != v.want {
+			t.Errorf("pbkdf2(%q, %q, %d) = %s, want %s", v.password, v.salt, v.iterations, got, v.want)
+		}
+	}
+
+	// The BIP39 seed of the all-zero 12-word mnemonic, straight through
+	// pbkdf2Key rather than MnemonicSeed.
+	seed := pbkdf2Key(sha512.New, []byte(bip39Vectors[0].mnemonic), []byte("mnemonicTREZOR"), mnemonicIterations, 64)
+	if got := hex.EncodeToString(seed); got != bip39Vectors[0].seed {
+		t.Errorf("pbkdf2-sha512 = %s, want %s", got, bip39Vectors[0].seed)
+	}
+}
+
+func TestBIP39Vectors(t *testing.T) {
+	for _, v := range bip39Vectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		if got := mnemonicFromEntropy(entropy); got != v.mnemonic {
+			t.Errorf("mnemonic of %s = %q, want %q", v.entropy, got, v.mnemonic)
+		}
+		if _, err := NormalizeMnemonic(v.mnemonic); err != nil {
+			t.Errorf("NormalizeMnemonic(%q): %v", v.mnemonic, err)
+		}
+		seed, err := MnemonicSeed(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("MnemonicSeed(%q): %v", v.mnemonic, err)
+		}
+		if got := hex.EncodeToString(seed); got != v.seed {
+			t.Errorf("seed of %q = %s, want %s", v.mnemonic, got, v.seed)
+		}
+	}
+
+	// Ending in abandon rather than about breaks the checksum.
+	if _, err := NormalizeMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err != ErrInvalidMnemonic {
+		t.Errorf("bad checksum: err = %v, want %v", err, ErrInvalidMnemonic)
+	}
+}
+
+func TestSLIP10Vectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master := hdMaster(seed)
+	for _, v := range slip10Vectors {
+		path, err := ParseHDPath(v.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		k := master.derive(path)
+		if got := hex.EncodeToString(k.chainCode); got != v.chainCode {
+			t.Errorf("%s chain code = %s, want %s", v.path, got, v.chainCode)
+		}
+		if got := hex.EncodeToString(k.key.FillBytes(make([]byte, 32))); got != v.key {
+			t.Errorf("%s key = %s, want %s", v.path, got, v.key)
+		}
+	}
+}
+
+// A change wallet for a transfer that failed goes away again; from an HD
+// wallet its index is handed back, so the next change address is the same.
+func TestDiscardChangeWallet(t *testing.T) {
+	ws := NewWalletStore()
+	_, owner, err := ws.CreateHDWallet("", 12, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	change, err := ws.NewChangeWallet(owner.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.DiscardChangeWallet(owner.Address); err != ErrWalletNotFound {
+		t.Fatalf("discarding a receive wallet: err = %v, want ErrWalletNotFound", err)
+	}
+	if err := ws.DiscardChangeWallet(change.Address); err != nil {
+		t.Fatalf("DiscardChangeWallet: %v", err)
+	}
+	if ws.GetWallet(change.Address) != nil {
+		t.Fatal("change wallet still in the store")
+	}
+	again, err := ws.NewChangeWallet(owner.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Address != change.Address || again.Path != change.Path {
+		t.Fatalf("next change wallet %s at %s, want the discarded %s at %s", again.Address, again.Path, change.Address, change.Path)
+	}
+
+	plain, err := ws.NewChangeWallet(strings.Repeat("a", 64))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.DiscardChangeWallet(plain.Address); err != nil || ws.GetWallet(plain.Address) != nil {
+		t.Fatalf("discarding a generated change wallet: err = %v", err)
+	}
+}
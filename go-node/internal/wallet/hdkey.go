@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Hierarchical deterministic keys. BIP32 is defined for secp256k1; the
+// chain signs with P-256, so keys are derived as SLIP-0010 specifies for
+// that curve ("nist256p1"), which follows BIP32 with a curve-specific
+// master key and a retry when a derived key is out of range.
+
+const hardened = 1 << 31
+
+var hdMasterKey = []byte("Nist256p1 seed")
+
+// hdKey is an extended private key.
+type hdKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+func hdMaster(seed []byte) *hdKey {
+	n := elliptic.P256().Params().N
+	mac := hmac.New(sha512.New, hdMasterKey)
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	for {
+		key := new(big.Int).SetBytes(sum[:32])
+		if key.Sign() != 0 && key.Cmp(n) < 0 {
+			return &hdKey{key: key, chainCode: sum[32:]}
+		}
+		mac = hmac.New(sha512.New, hdMasterKey)
+		mac.Write(sum)
+		sum = mac.Sum(nil)
+	}
+}
+
+// child derives child index i; indexes from 2^31 up are hardened.
+func (k *hdKey) child(i uint32) *hdKey {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	data := make([]byte, 0, 37)
+	if i >= hardened {
+		data = append(data, 0)
+		data = append(data, k.key.FillBytes(make([]byte, 32))...)
+	} else {
+		x, y := curve.ScalarBaseMult(k.key.FillBytes(make([]byte, 32)))
+		data = append(data, elliptic.MarshalCompressed(curve, x, y)...)
+	}
+	data = binary.BigEndian.AppendUint32(data, i)
+
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) < 0 {
+			key := tweak.Add(tweak, k.key)
+			key.Mod(key, n)
+			if key.Sign() != 0 {
+				return &hdKey{key: key, chainCode: sum[32:]}
+			}
+		}
+		// SLIP-0010: retry with 0x01 || IR || ser32(i).
+		data = append(append([]byte{1}, sum[32:]...), data[len(data)-4:]...)
+	}
+}
+
+func (k *hdKey) derive(path []uint32) *hdKey {
+	for _, i := range path {
+		k = k.child(i)
+	}
+	return k
+}
+
+func (k *hdKey) privateKey() *ecdsa.PrivateKey {
+	curve := elliptic.P256()
+	priv := &ecdsa.PrivateKey{D: new(big.Int).Set(k.key)}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(k.key.FillBytes(make([]byte, 32)))
+	return priv
+}
+
+// ParseHDPath reads a path such as m/44'/1'/0'/0/5; ' or h marks a
+// hardened index.
+func ParseHDPath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, errors.New("HD path must start with m")
+	}
+	indexes := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		offset := uint32(0)
+		if trimmed := strings.TrimRight(part, "'h"); trimmed != part {
+			offset, part = hardened, trimmed
+		}
+		i, err := strconv.ParseUint(part, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("HD path %s: bad index %q", path, part)
+		}
+		indexes = append(indexes, uint32(i)+offset)
+	}
+	return indexes, nil
+}
+
+// FormatHDPath writes path the way ParseHDPath reads it.
+func FormatHDPath(path []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, i := range path {
+		b.WriteString("/")
+		if i >= hardened {
+			b.WriteString(strconv.FormatUint(uint64(i-hardened), 10))
+			b.WriteString("'")
+		} else {
+			b.WriteString(strconv.FormatUint(uint64(i), 10))
+		}
+	}
+	return b.String()
+}
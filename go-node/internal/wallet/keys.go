@@ -0,0 +1,251 @@
+package wallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"strings"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// KeyFormat is how a private key is written to move it between nodes, or
+// to and from the Java wallet. All three encode the P-256 scalar:
+//
+//   - hex: the 32-byte big-endian scalar, which is what BigInteger D holds
+//   - wif: Base58Check like Bitcoin's wallet import format, under a version
+//     byte of its own so Bitcoin wallets do not take it for a secp256k1 key
+//   - pem: PKCS#8, what Java's PrivateKey.getEncoded() returns; SEC 1
+//     "EC PRIVATE KEY" blocks are read too
+type KeyFormat string
+
+const (
+	KeyFormatHex KeyFormat = "hex"
+	KeyFormatWIF KeyFormat = "wif"
+	KeyFormatPEM KeyFormat = "pem"
+)
+
+const wifVersion = 0xa0
+
+var (
+	ErrInvalidPrivateKey = &WalletError{Message: "invalid private key: expected 64 hex characters, a WIF string or a PEM block of a P-256 key"}
+	ErrKeyFormat         = &WalletError{Message: "key format must be hex, wif or pem"}
+	ErrWalletExists      = &WalletError{Message: "a wallet with this key is already in the store"}
+)
+
+// ParseKeyFormat reads a format name; empty means hex.
+func ParseKeyFormat(name string) (KeyFormat, error) {
+	switch f := KeyFormat(strings.ToLower(name)); f {
+	case "":
+		return KeyFormatHex, nil
+	case KeyFormatHex, KeyFormatWIF, KeyFormatPEM:
+		return f, nil
+	default:
+		return "", ErrKeyFormat
+	}
+}
+
+// ParsePrivateKey reads a private key in any of the key formats, telling
+// them apart by their shape, and returns the format it was in.
+func ParsePrivateKey(input string) (*ecdsa.PrivateKey, KeyFormat, error) {
+	s := strings.TrimSpace(input)
+	switch {
+	case strings.HasPrefix(s, "-----BEGIN"):
+		priv, err := parsePEMKey(s)
+		return priv, KeyFormatPEM, err
+	case len(strings.TrimPrefix(s, "0x")) == 64:
+		d, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+		if err != nil {
+			return nil, "", ErrInvalidPrivateKey
+		}
+		priv, err := privateKeyFromScalar(d)
+		return priv, KeyFormatHex, err
+	default:
+		data, err := base58CheckDecode(s)
+		if err != nil || len(data) != 33 || data[0] != wifVersion {
+			return nil, "", ErrInvalidPrivateKey
+		}
+		priv, err := privateKeyFromScalar(data[1:])
+		return priv, KeyFormatWIF, err
+	}
+}
+
+func parsePEMKey(s string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, ErrInvalidPrivateKey
+	}
+	var priv *ecdsa.PrivateKey
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, ErrInvalidPrivateKey
+		}
+		ec, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidPrivateKey
+		}
+		priv = ec
+	case "EC PRIVATE KEY":
+		ec, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, ErrInvalidPrivateKey
+		}
+		priv = ec
+	default:
+		return nil, ErrInvalidPrivateKey
+	}
+	if priv.Curve != elliptic.P256() {
+		return nil, ErrInvalidPrivateKey
+	}
+	return priv, nil
+}
+
+func privateKeyFromScalar(d []byte) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	k := new(big.Int).SetBytes(d)
+	if k.Sign() == 0 || k.Cmp(curve.Params().N) >= 0 {
+		return nil, ErrInvalidPrivateKey
+	}
+	priv := &ecdsa.PrivateKey{D: k}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(k.FillBytes(make([]byte, 32)))
+	return priv, nil
+}
+
+// EncodePrivateKey writes priv in format.
+func EncodePrivateKey(priv *ecdsa.PrivateKey, format KeyFormat) (string, error) {
+	d := priv.D.FillBytes(make([]byte, 32))
+	switch format {
+	case KeyFormatHex:
+		return hex.EncodeToString(d), nil
+	case KeyFormatWIF:
+		return base58CheckEncode(append([]byte{wifVersion}, d...)), nil
+	case KeyFormatPEM:
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return "", err
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	default:
+		return "", ErrKeyFormat
+	}
+}
+
+// ImportKey adds the wallet of a private key in any of the key formats,
+// with a label and owner as GenerateLabeledWallet takes them. A watch-only
+// address of the key becomes a wallet, keeping its label unless another is
+// given.
+func (ws *WalletStore) ImportKey(key, label, owner string) (*Wallet, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" {
+		if err := ValidateAddress(owner); err != nil {
+			return nil, err
+		}
+	}
+	priv, _, err := ParsePrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	address := crypto.AddressFromPublicKey(&priv.PublicKey)
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return nil, ErrStoreLocked
+	}
+	if _, ok := ws.wallets[address]; ok {
+		return nil, ErrWalletExists
+	}
+	watched := ws.watch[address]
+	if watched != nil && label == "" {
+		label = watched.label
+	}
+	w := &Wallet{Address: address, PrivateKey: priv, PublicKey: &priv.PublicKey, Label: label, Owner: owner}
+	ws.wallets[address] = w
+	delete(ws.watch, address)
+	if err := ws.saveLocked(); err != nil {
+		delete(ws.wallets, address)
+		if watched != nil {
+			ws.watch[address] = watched
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// ExportKey returns the private key of the wallet at address in format.
+// Whoever has it can spend the wallet's coins.
+func (ws *WalletStore) ExportKey(address string, format KeyFormat) (string, error) {
+	priv, err := ws.signingKey(address)
+	if err != nil {
+		return "", err
+	}
+	return EncodePrivateKey(priv, format)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58CheckEncode appends the first four bytes of data's double SHA-256
+// and writes the result in Bitcoin's base58 alphabet, one leading 1 per
+// leading zero byte.
+func base58CheckEncode(data []byte) string {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	payload := append(append([]byte(nil), data...), second[:4]...)
+
+	n := new(big.Int).SetBytes(payload)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range payload {
+		if b != 0 {
+			break
+		}
+		out = append(out, '1')
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	for i := 0; i < len(s); i++ {
+		digit := strings.IndexByte(base58Alphabet, s[i])
+		if digit < 0 {
+			return nil, ErrInvalidPrivateKey
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+	payload := append(make([]byte, zeros), n.Bytes()...)
+	if len(payload) < 4 {
+		return nil, ErrInvalidPrivateKey
+	}
+	data, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	if string(second[:4]) != string(checksum) {
+		return nil, ErrInvalidPrivateKey
+	}
+	return data, nil
+}
@@ -0,0 +1,504 @@
+package wallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+const (
+	// Version 1 files seal a list of wallets; version 2 adds HD wallets and
+	// version 3 multisig accounts and watch-only addresses. Each new section
+	// bumps the version so that older binaries refuse the file instead of
+	// loading it and dropping the section on their next save.
+	keystoreVersion = 3
+
+	// legacyHDWalletIDLength is the bytes of HD wallet ids written before
+	// they grew to hdWalletIDLength.
+	legacyHDWalletIDLength = 4
+
+	// scrypt cost: about 32 MB and a few hundred milliseconds per derivation.
+	keystoreN      = 1 << 15
+	keystoreR      = 8
+	keystoreP      = 1
+	keystoreKeyLen = 32
+)
+
+var (
+	ErrStoreLocked   = &WalletError{Message: "wallet store is locked"}
+	ErrBadPassphrase = &WalletError{Message: "incorrect keystore passphrase"}
+	ErrNoKeystore    = &WalletError{Message: "wallet store has no keystore file"}
+)
+
+// keystoreFile is the on-disk format: keystoreContents as JSON, sealed with AES-256-GCM under a key derived from the passphrase by scrypt.
+type keystoreFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+type keystoreContents struct {
+	Wallets   []keystoreEntry `json:"wallets"`
+	HDWallets []hdEntry       `json:"hd_wallets,omitempty"`
+	Multisig  []multisigEntry `json:"multisig,omitempty"`
+	WatchOnly []watchEntry    `json:"watch_only,omitempty"`
+}
+
+type keystoreEntry struct {
+	Address    string `json:"address"`
+	PrivateKey string `json:"private_key"`
+	Label      string `json:"label,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	HD         string `json:"hd,omitempty"`
+	Path       string `json:"path,omitempty"`
+}
+
+type hdEntry struct {
+	ID          string `json:"id"`
+	Label       string `json:"label,omitempty"`
+	Seed        string `json:"seed"`
+	Mnemonic    string `json:"mnemonic,omitempty"` // until revealed
+	NextReceive uint32 `json:"next_receive"`
+	NextChange  uint32 `json:"next_change"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+type multisigEntry struct {
+	Address  string   `json:"address"`
+	Label    string   `json:"label,omitempty"`
+	Required int      `json:"required"`
+	PubKeys  []string `json:"pubkeys"`
+}
+
+type watchEntry struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+}
+
+type keystore struct {
+	path string
+	salt []byte
+	n    int
+	r    int
+	p    int
+	key  []byte // derived key while unlocked, nil while locked
+}
+
+func (ks *keystore) derive(passphrase string) ([]byte, error) {
+	return crypto.ScryptKey([]byte(passphrase), ks.salt, ks.n, ks.r, ks.p, keystoreKeyLen)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func readKeystoreFile(path string) (*keystoreFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("keystore %s: %w", path, err)
+	}
+	if file.Version < 1 || file.Version > keystoreVersion || file.KDF != "scrypt" {
+		return nil, fmt.Errorf("keystore %s: unsupported version %d (%s)", path, file.Version, file.KDF)
+	}
+	return &file, nil
+}
+
+func (ks *keystore) open(file *keystoreFile, key []byte) (*keystoreContents, error) {
+	nonce, err := hex.DecodeString(file.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(file.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != gcm.NonceSize() {
+		return nil, errors.New("keystore: bad nonce length")
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrBadPassphrase
+	}
+
+	var contents keystoreContents
+	if file.Version == 1 {
+		err = json.Unmarshal(plaintext, &contents.Wallets)
+	} else {
+		err = json.Unmarshal(plaintext, &contents)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("keystore: %w", err)
+	}
+	return &contents, nil
+}
+
+func (ks *keystore) save(contents *keystoreContents) error {
+	plaintext, err := json.Marshal(contents)
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(ks.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(keystoreFile{
+		Version:    keystoreVersion,
+		KDF:        "scrypt",
+		N:          ks.n,
+		R:          ks.r,
+		P:          ks.p,
+		Salt:       hex.EncodeToString(ks.salt),
+		Nonce:      hex.EncodeToString(nonce),
+		Ciphertext: hex.EncodeToString(gcm.Seal(nil, nonce, plaintext, nil)),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write then rename so a crash never leaves a truncated keystore.
+	tmp := ks.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ks.path)
+}
+
+func walletFromEntry(entry keystoreEntry) (*Wallet, error) {
+	d, ok := new(big.Int).SetString(entry.PrivateKey, 16)
+	if !ok {
+		return nil, fmt.Errorf("keystore: bad private key for %s", entry.Address)
+	}
+	curve := elliptic.P256()
+	priv := &ecdsa.PrivateKey{D: d}
+	priv.PublicKey.Curve = curve
+	priv.PublicKey.X, priv.PublicKey.Y = curve.ScalarBaseMult(d.Bytes())
+
+	// Older keystores list about one key in 128 under the hash of its
+	// unpadded coordinates. Such a wallet loads under its canonical
+	// address, which the next save records.
+	address := crypto.AddressFromPublicKey(&priv.PublicKey)
+	legacy := crypto.SHA256(append(priv.PublicKey.X.Bytes(), priv.PublicKey.Y.Bytes()...))
+	if entry.Address != address && entry.Address != legacy {
+		return nil, fmt.Errorf("keystore: key does not match address %s", entry.Address)
+	}
+	return &Wallet{Address: address, PrivateKey: priv, PublicKey: &priv.PublicKey, Label: entry.Label, Owner: entry.Owner, HD: entry.HD, Path: entry.Path}, nil
+}
+
+func hdFromEntry(entry hdEntry) (*hdWallet, error) {
+	seed, err := hex.DecodeString(entry.Seed)
+	if err != nil || len(seed) == 0 {
+		return nil, fmt.Errorf("keystore: bad seed for HD wallet %s", entry.ID)
+	}
+	// Files from before ids grew to hdWalletIDLength bytes name the wallet
+	// by the first 4; it takes its full id as it loads.
+	id := hdWalletID(seed)
+	if id != entry.ID && (len(entry.ID) != legacyHDWalletIDLength*2 || !strings.HasPrefix(id, entry.ID)) {
+		return nil, fmt.Errorf("keystore: seed does not match HD wallet %s", entry.ID)
+	}
+	return &hdWallet{
+		id:        id,
+		label:     entry.Label,
+		seed:      seed,
+		mnemonic:  entry.Mnemonic,
+		next:      [2]uint32{entry.NextReceive, entry.NextChange},
+		createdAt: entry.CreatedAt,
+	}, nil
+}
+
+func multisigFromEntry(entry multisigEntry) (*multisigAccount, error) {
+	address, err := chain.MultisigAddress(entry.Required, entry.PubKeys)
+	if err != nil || address != entry.Address {
+		return nil, fmt.Errorf("keystore: keys do not match multisig address %s", entry.Address)
+	}
+	return &multisigAccount{address: address, label: entry.Label, required: entry.Required, pubKeys: entry.PubKeys}, nil
+}
+
+// loadLocked adds contents to the store, or restores the keys and seeds of
+// wallets it already lists; callers hold ws.mu for writing. It returns the
+// number of wallets in contents.
+func (ws *WalletStore) loadLocked(contents *keystoreContents) (int, error) {
+	for _, entry := range contents.Wallets {
+		w, err := walletFromEntry(entry)
+		if err != nil {
+			return 0, err
+		}
+		if existing, ok := ws.wallets[w.Address]; ok {
+			existing.PrivateKey = w.PrivateKey
+		} else {
+			ws.wallets[w.Address] = w
+		}
+	}
+	for _, entry := range contents.HDWallets {
+		h, err := hdFromEntry(entry)
+		if err != nil {
+			return 0, err
+		}
+		if existing, ok := ws.hd[h.id]; ok {
+			existing.seed, existing.mnemonic = h.seed, h.mnemonic
+		} else {
+			ws.hd[h.id] = h
+		}
+		if h.id != entry.ID {
+			for _, w := range ws.wallets {
+				if w.HD == entry.ID {
+					w.HD = h.id
+				}
+			}
+		}
+	}
+	for _, entry := range contents.Multisig {
+		account, err := multisigFromEntry(entry)
+		if err != nil {
+			return 0, err
+		}
+		ws.multisig[account.address] = account
+	}
+	for _, entry := range contents.WatchOnly {
+		if err := ValidateAddress(entry.Address); err != nil {
+			return 0, fmt.Errorf("keystore: watch-only address %q: %v", entry.Address, err)
+		}
+		if _, ok := ws.wallets[entry.Address]; !ok {
+			ws.watch[entry.Address] = &watchAddress{address: entry.Address, label: entry.Label}
+		}
+	}
+	return len(contents.Wallets), nil
+}
+
+// OpenKeystore attaches an encrypted keystore file to the store. An existing
+// file is decrypted with passphrase and its wallets are loaded; otherwise a
+// new file is created holding the wallets already in memory. Every wallet
+// generated afterwards is saved to it. It returns the number of wallets
+// loaded from disk.
+func (ws *WalletStore) OpenKeystore(path, passphrase string) (int, error) {
+	if passphrase == "" {
+		return 0, errors.New("keystore passphrase must not be empty")
+	}
+
+	ks := &keystore{path: path}
+	file, err := readKeystoreFile(path)
+	switch {
+	case err == nil:
+		ks.n, ks.r, ks.p = file.N, file.R, file.P
+		if ks.salt, err = hex.DecodeString(file.Salt); err != nil {
+			return 0, fmt.Errorf("keystore %s: bad salt", path)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return 0, err
+		}
+		ks.n, ks.r, ks.p = keystoreN, keystoreR, keystoreP
+		ks.salt = make([]byte, 16)
+		if _, err := rand.Read(ks.salt); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, err
+	}
+
+	key, err := ks.derive(passphrase)
+	if err != nil {
+		return 0, err
+	}
+	ks.key = key
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	loaded := 0
+	if file != nil {
+		contents, err := ks.open(file, key)
+		if err != nil {
+			return 0, err
+		}
+		if loaded, err = ws.loadLocked(contents); err != nil {
+			return 0, err
+		}
+	}
+
+	ws.keystore = ks
+	return loaded, ws.saveLocked()
+}
+
+// saveLocked rewrites the keystore with every wallet; callers hold ws.mu.
+func (ws *WalletStore) saveLocked() error {
+	if ws.keystore == nil {
+		return nil
+	}
+	if ws.keystore.key == nil {
+		return ErrStoreLocked
+	}
+	contents := &keystoreContents{Wallets: make([]keystoreEntry, 0, len(ws.wallets))}
+	for _, w := range ws.wallets {
+		contents.Wallets = append(contents.Wallets, keystoreEntry{
+			Address:    w.Address,
+			PrivateKey: hex.EncodeToString(w.PrivateKey.D.Bytes()),
+			Label:      w.Label,
+			Owner:      w.Owner,
+			HD:         w.HD,
+			Path:       w.Path,
+		})
+	}
+	for _, h := range ws.hd {
+		contents.HDWallets = append(contents.HDWallets, hdEntry{
+			ID:          h.id,
+			Label:       h.label,
+			Seed:        hex.EncodeToString(h.seed),
+			Mnemonic:    h.mnemonic,
+			NextReceive: h.next[hdReceiveChain],
+			NextChange:  h.next[hdChangeChain],
+			CreatedAt:   h.createdAt,
+		})
+	}
+	for _, m := range ws.multisig {
+		contents.Multisig = append(contents.Multisig, multisigEntry{
+			Address:  m.address,
+			Label:    m.label,
+			Required: m.required,
+			PubKeys:  m.pubKeys,
+		})
+	}
+	for _, a := range ws.watch {
+		contents.WatchOnly = append(contents.WatchOnly, watchEntry{Address: a.address, Label: a.label})
+	}
+	if err := ws.keystore.save(contents); err != nil {
+		if ws.onSaveError != nil {
+			ws.onSaveError(fmt.Errorf("keystore %s: %w", ws.keystore.path, err))
+		}
+		return err
+	}
+	return nil
+}
+
+// SetSaveErrorHandler has keystore write failures reported to fn as well as
+// returned to the caller whose change could not be saved.
+func (ws *WalletStore) SetSaveErrorHandler(fn func(error)) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.onSaveError = fn
+}
+
+func (ws *WalletStore) HasKeystore() bool {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.keystore != nil
+}
+
+func (ws *WalletStore) Locked() bool {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.keystore != nil && ws.keystore.key == nil
+}
+
+// Lock drops private keys, HD seeds and the derived key from memory; addresses stay
+// listed. Go cannot guarantee the old key material is overwritten, so this
+// limits exposure rather than erasing it.
+func (ws *WalletStore) Lock() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.keystore == nil {
+		return ErrNoKeystore
+	}
+	for i := range ws.keystore.key {
+		ws.keystore.key[i] = 0
+	}
+	ws.keystore.key = nil
+	for _, w := range ws.wallets {
+		w.PrivateKey = nil
+	}
+	for _, h := range ws.hd {
+		for i := range h.seed {
+			h.seed[i] = 0
+		}
+		h.seed, h.mnemonic = nil, ""
+	}
+	return nil
+}
+
+// Unlock decrypts the keystore with passphrase and restores the private keys.
+func (ws *WalletStore) Unlock(passphrase string) error {
+	ws.mu.RLock()
+	ks := ws.keystore
+	ws.mu.RUnlock()
+	if ks == nil {
+		return ErrNoKeystore
+	}
+
+	file, err := readKeystoreFile(ks.path)
+	if err != nil {
+		return err
+	}
+	key, err := ks.derive(passphrase)
+	if err != nil {
+		return err
+	}
+	contents, err := ks.open(file, key)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.loadLocked(contents); err != nil {
+		return err
+	}
+	ks.key = key
+	return nil
+}
+
+// CheckPassphrase verifies passphrase against the keystore without changing
+// the lock state. Stores without a keystore accept any passphrase.
+func (ws *WalletStore) CheckPassphrase(passphrase string) error {
+	ws.mu.RLock()
+	ks := ws.keystore
+	ws.mu.RUnlock()
+	if ks == nil {
+		return nil
+	}
+
+	file, err := readKeystoreFile(ks.path)
+	if err != nil {
+		return err
+	}
+	key, err := ks.derive(passphrase)
+	if err != nil {
+		return err
+	}
+	_, err = ks.open(file, key)
+	return err
+}
@@ -0,0 +1,141 @@
+package wallet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// Multisig accounts and watch-only addresses live in sections of their own;
+// they must come back from a load and survive the save that follows it.
+func TestKeystoreKeepsMultisigAndWatchOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	keys := make([]string, 2)
+	for i := range keys {
+		priv, err := crypto.GenerateKeyPair()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = crypto.EncodePublicKey(&priv.PublicKey)
+	}
+	watched := strings.Repeat("c", 64)
+
+	ws := NewWalletStore()
+	if _, err := ws.OpenKeystore(path, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	account, err := ws.CreateMultisig(2, keys, "joint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.WatchAddress(watched, "cold"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each open loads the file and saves it again.
+	for round := 1; round <= 2; round++ {
+		ws = NewWalletStore()
+		if _, err := ws.OpenKeystore(path, "passphrase"); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		got, err := ws.Multisig(account.Address)
+		if err != nil {
+			t.Fatalf("round %d: multisig account lost: %v", round, err)
+		}
+		if got.Label != "joint" || got.Required != 2 || len(got.PubKeys) != 2 {
+			t.Errorf("round %d: multisig account %+v", round, got)
+		}
+		if list := ws.WatchedAddresses(); len(list) != 1 || list[0].Address != watched || list[0].Label != "cold" {
+			t.Errorf("round %d: watch-only addresses %+v", round, list)
+		}
+	}
+
+	file, err := readKeystoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != keystoreVersion {
+		t.Errorf("saved as version %d, want %d", file.Version, keystoreVersion)
+	}
+}
+
+// A binary that predates a section must not open a file carrying it.
+func TestKeystoreRefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	ws := NewWalletStore()
+	if _, err := ws.OpenKeystore(path, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := readKeystoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Version = keystoreVersion + 1
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWalletStore().OpenKeystore(path, "passphrase"); err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Errorf("opening version %d: err %v, want unsupported version", file.Version, err)
+	}
+}
+
+// HD wallets saved with the old 4-byte ids load under their full id, and so
+// do the wallets derived from them.
+func TestKeystoreWidensLegacyHDWalletIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.json")
+	ws := NewWalletStore()
+	if _, err := ws.OpenKeystore(path, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	info, first, err := ws.CreateHDWallet("savings", 12, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.ID) != 2*hdWalletIDLength {
+		t.Fatalf("id %s is %d hex characters, want %d", info.ID, len(info.ID), 2*hdWalletIDLength)
+	}
+
+	// Rewrite the file as an older node would have saved it.
+	file, err := readKeystoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents, err := ws.keystore.open(file, ws.keystore.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := info.ID[:2*legacyHDWalletIDLength]
+	contents.HDWallets[0].ID = legacy
+	for i := range contents.Wallets {
+		if contents.Wallets[i].HD == info.ID {
+			contents.Wallets[i].HD = legacy
+		}
+	}
+	if err := ws.keystore.save(contents); err != nil {
+		t.Fatal(err)
+	}
+
+	ws = NewWalletStore()
+	if _, err := ws.OpenKeystore(path, "passphrase"); err != nil {
+		t.Fatal(err)
+	}
+	_, derived, err := ws.HDWallet(info.ID)
+	if err != nil {
+		t.Fatalf("HD wallet under its full id: %v", err)
+	}
+	if len(derived) != 1 || derived[0].Address != first.Address {
+		t.Errorf("derived wallets %+v, want %s", derived, first.Address)
+	}
+	if _, _, err := ws.HDWallet(legacy); err == nil {
+		t.Error("HD wallet still listed under its legacy id")
+	}
+}
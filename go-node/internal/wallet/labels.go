@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const maxLabelLength = 64
+
+var ErrInvalidLabel = &WalletError{Message: "wallet label must be at most 64 printable characters"}
+
+// WalletInfo is what a listing shows of a wallet: never its key.
+type WalletInfo struct {
+	Address string `json:"address"`
+	Label   string `json:"label,omitempty"`
+	Owner   string `json:"owner,omitempty"`
+	HD      string `json:"hd,omitempty"`   // HD wallet the key was derived from
+	Path    string `json:"path,omitempty"` // derivation path in it
+
+	WatchOnly bool `json:"watch_only,omitempty"` // tracked without a key
+}
+
+func (w *Wallet) info() WalletInfo {
+	return WalletInfo{Address: w.Address, Label: w.Label, Owner: w.Owner, HD: w.HD, Path: w.Path}
+}
+
+// WalletFilter selects wallets in ListWallets. Empty fields match every
+// wallet.
+type WalletFilter struct {
+	Label string // case-insensitive substring of the label
+	Owner string // exact owner address
+}
+
+func (f WalletFilter) matches(w WalletInfo) bool {
+	if f.Owner != "" && w.Owner != f.Owner {
+		return false
+	}
+	if f.Label != "" && !strings.Contains(strings.ToLower(w.Label), strings.ToLower(f.Label)) {
+		return false
+	}
+	return true
+}
+
+func normalizeLabel(label string) (string, error) {
+	label = strings.TrimSpace(label)
+	if len(label) > maxLabelLength {
+		return "", ErrInvalidLabel
+	}
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return "", ErrInvalidLabel
+		}
+	}
+	return label, nil
+}
+
+// ListWallets returns the wallets and watch-only addresses matching filter,
+// sorted by address so a listing can be paged through while wallets are
+// being added.
+func (ws *WalletStore) ListWallets(filter WalletFilter) []WalletInfo {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	infos := make([]WalletInfo, 0, len(ws.wallets)+len(ws.watch))
+	for _, w := range ws.wallets {
+		if info := w.info(); filter.matches(info) {
+			infos = append(infos, info)
+		}
+	}
+	for _, watched := range ws.watch {
+		if info := watched.info(); filter.matches(info) {
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Address < infos[j].Address })
+	return infos
+}
@@ -0,0 +1,53 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+var ErrMemoNotReadable = &WalletError{Message: "memo is encrypted to a different key"}
+
+// EncryptMemo encrypts memo to the holder of the public key given as hex
+// X||Y and returns it in the form a transaction carries.
+func EncryptMemo(pubKeyHex, memo string) (string, error) {
+	ciphertext, err := crypto.EncryptECIES(pubKeyHex, []byte(memo))
+	if err != nil {
+		return "", err
+	}
+	return chain.EncryptedMemoPrefix + hex.EncodeToString(ciphertext), nil
+}
+
+// DecryptMemo returns memo as the wallet at address reads it. Plain memos
+// come back unchanged; encrypted ones need the wallet's key, so the store
+// must be unlocked.
+func (ws *WalletStore) DecryptMemo(address, memo string) (string, error) {
+	if !chain.IsEncryptedMemo(memo) {
+		return memo, nil
+	}
+	privateKey, err := ws.signingKey(address)
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := hex.DecodeString(strings.TrimPrefix(memo, chain.EncryptedMemoPrefix))
+	if err != nil {
+		return "", ErrMemoNotReadable
+	}
+	plaintext, err := crypto.DecryptECIES(privateKey, ciphertext)
+	if err != nil {
+		return "", ErrMemoNotReadable
+	}
+	return string(plaintext), nil
+}
+
+// KeyOwnsAddress reports whether address belongs to the public key given as
+// hex X||Y.
+func KeyOwnsAddress(pubKeyHex, address string) bool {
+	pub, err := crypto.ParsePublicKey(pubKeyHex)
+	if err != nil {
+		return false
+	}
+	return crypto.AddressFromPublicKey(pub) == address
+}
@@ -0,0 +1,56 @@
+package wallet
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+func TestDecryptMemo(t *testing.T) {
+	ws := NewWalletStore()
+	recipient, err := ws.GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ws.GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	memo, err := EncryptMemo(crypto.EncodePublicKey(recipient.PublicKey), "invoice 42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(memo, chain.EncryptedMemoPrefix) {
+		t.Fatalf("encrypted memo %q lacks the %q prefix", memo, chain.EncryptedMemoPrefix)
+	}
+	if err := chain.CheckMemo(memo); err != nil {
+		t.Fatalf("CheckMemo: %v", err)
+	}
+
+	if got, err := ws.DecryptMemo(recipient.Address, memo); err != nil || got != "invoice 42" {
+		t.Errorf("recipient reads %q, %v; want the plaintext", got, err)
+	}
+	if _, err := ws.DecryptMemo(other.Address, memo); !errors.Is(err, ErrMemoNotReadable) {
+		t.Errorf("other wallet: err = %v, want ErrMemoNotReadable", err)
+	}
+
+	// Without the prefix the memo is plain text, even if it looks like hex.
+	hexOnly := strings.TrimPrefix(memo, chain.EncryptedMemoPrefix)
+	if got, err := ws.DecryptMemo(recipient.Address, hexOnly); err != nil || got != hexOnly {
+		t.Errorf("unprefixed memo read as %q, %v; want it unchanged", got, err)
+	}
+	for _, bad := range []string{
+		chain.EncryptedMemoPrefix,
+		chain.EncryptedMemoPrefix + "zz" + hexOnly[2:],
+		chain.EncryptedMemoPrefix + hexOnly[1:],
+		chain.EncryptedMemoPrefix + hexOnly[:len(hexOnly)-2],
+	} {
+		if _, err := ws.DecryptMemo(recipient.Address, bad); !errors.Is(err, ErrMemoNotReadable) {
+			t.Errorf("DecryptMemo(%.20q...): err = %v, want ErrMemoNotReadable", bad, err)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package wallet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"ai-blockchain/go-node/internal/chain"
+	"ai-blockchain/go-node/internal/crypto"
+)
+
+// Multisig accounts are m-of-n key sets registered with the store so it can
+// build spends from their address. The store holds no private key for the
+// account itself: each co-signer signs with a wallet of its own, on this
+// node or another, through SignMultisig.
+
+var (
+	ErrMultisigNotFound = &WalletError{Message: "multisig account not found"}
+	ErrMultisigExists   = &WalletError{Message: "this multisig account is already in the store"}
+	ErrInvalidMultisig  = &WalletError{Message: "invalid multisig account"}
+	ErrNoMultisig       = &WalletError{Message: "transaction carries no multisig witness"}
+	ErrNotCosigner      = &WalletError{Message: "wallet's key is not one of the multisig keys"}
+)
+
+type multisigAccount struct {
+	address  string
+	label    string
+	required int
+	pubKeys  []string // ascending
+}
+
+// MultisigInfo describes a multisig account. Cosigners lists the wallets in
+// this store whose keys are among PubKeys.
+type MultisigInfo struct {
+	Address   string   `json:"address"`
+	Label     string   `json:"label,omitempty"`
+	Required  int      `json:"required"`
+	PubKeys   []string `json:"pubkeys"`
+	Cosigners []string `json:"cosigners,omitempty"`
+}
+
+// NormalizeMultisigKeys decodes public keys given as hex X||Y, padded or
+// not, and returns them in the form and order chain.MultisigAddress takes.
+func NormalizeMultisigKeys(pubKeys []string) ([]string, error) {
+	keys := make([]string, len(pubKeys))
+	for i, key := range pubKeys {
+		pub, err := crypto.ParsePublicKey(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("%w: public key %d: %v", ErrInvalidMultisig, i, err)
+		}
+		keys[i] = EncodePublicKey(pub)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// CreateMultisig registers the required-of-n account over pubKeys and
+// returns it, with its address.
+func (ws *WalletStore) CreateMultisig(required int, pubKeys []string, label string) (MultisigInfo, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return MultisigInfo{}, err
+	}
+	keys, err := NormalizeMultisigKeys(pubKeys)
+	if err != nil {
+		return MultisigInfo{}, err
+	}
+	address, err := chain.MultisigAddress(required, keys)
+	if err != nil {
+		return MultisigInfo{}, fmt.Errorf("%w: %v", ErrInvalidMultisig, err)
+	}
+	account := &multisigAccount{address: address, label: label, required: required, pubKeys: keys}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return MultisigInfo{}, ErrStoreLocked
+	}
+	if _, ok := ws.multisig[address]; ok {
+		return MultisigInfo{}, ErrMultisigExists
+	}
+	ws.multisig[address] = account
+	if err := ws.saveLocked(); err != nil {
+		delete(ws.multisig, address)
+		return MultisigInfo{}, err
+	}
+	return ws.multisigInfoLocked(account), nil
+}
+
+func (ws *WalletStore) multisigInfoLocked(account *multisigAccount) MultisigInfo {
+	info := MultisigInfo{
+		Address:  account.address,
+		Label:    account.label,
+		Required: account.required,
+		PubKeys:  account.pubKeys,
+	}
+	for _, key := range account.pubKeys {
+		pub, _ := crypto.DecodePublicKey(key)
+		address := crypto.AddressFromPublicKey(pub)
+		if _, ok := ws.wallets[address]; ok {
+			info.Cosigners = append(info.Cosigners, address)
+		}
+	}
+	return info
+}
+
+// Multisig returns the account at address.
+func (ws *WalletStore) Multisig(address string) (MultisigInfo, error) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	account, ok := ws.multisig[address]
+	if !ok {
+		return MultisigInfo{}, ErrMultisigNotFound
+	}
+	return ws.multisigInfoLocked(account), nil
+}
+
+// MultisigAccounts lists the store's multisig accounts by address.
+func (ws *WalletStore) MultisigAccounts() []MultisigInfo {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	accounts := make([]MultisigInfo, 0, len(ws.multisig))
+	for _, account := range ws.multisig {
+		accounts = append(accounts, ws.multisigInfoLocked(account))
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Address < accounts[j].Address })
+	return accounts
+}
+
+// multisigSpend returns an unsigned witness for the account at address, or
+// nil when the store has no such account.
+func (ws *WalletStore) multisigSpend(address string) *chain.MultisigSpend {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	account, ok := ws.multisig[address]
+	if !ok {
+		return nil
+	}
+	return &chain.MultisigSpend{
+		Required:   account.required,
+		PubKeys:    append([]string(nil), account.pubKeys...),
+		Signatures: make([]string, len(account.pubKeys)),
+	}
+}
+
+// SignMultisig signs tx with the wallet at from as one of the keys of its
+// multisig witness, filling that key's slot. Every input must spend an
+// output of the witness's address. The account need not be registered
+// here, since the witness names its keys, so co-signers on other nodes can
+// pass a transaction along and each add a signature.
+func (ws *WalletStore) SignMultisig(from string, tx *chain.Transaction, utxo *chain.UTXOSet) error {
+	privateKey, err := ws.signingKey(from)
+	if err != nil {
+		return err
+	}
+	witness := tx.Multisig
+	if witness == nil {
+		return ErrNoMultisig
+	}
+	address, err := witness.Address()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMultisig, err)
+	}
+
+	slot := -1
+	key := EncodePublicKey(&privateKey.PublicKey)
+	for i, k := range witness.PubKeys {
+		if k == key {
+			slot = i
+		}
+	}
+	if slot < 0 {
+		return ErrNotCosigner
+	}
+
+	if len(tx.Inputs) == 0 {
+		return ErrForeignInput
+	}
+	for _, in := range tx.Inputs {
+		out, ok := utxo.Get(chain.UTXOKey{TxID: in.TxID, Index: in.Index})
+		if !ok || out.Address != address {
+			return ErrForeignInput
+		}
+	}
+
+	id, err := chain.ComputeTxID(tx)
+	if err != nil {
+		return err
+	}
+	tx.ID = id
+	canonical, err := chain.CanonicalTxBytes(tx)
+	if err != nil {
+		return err
+	}
+	signature, err := crypto.SignMessage(privateKey, canonical)
+	if err != nil {
+		return err
+	}
+
+	if len(witness.Signatures) != len(witness.PubKeys) {
+		slots := make([]string, len(witness.PubKeys))
+		copy(slots, witness.Signatures)
+		witness.Signatures = slots
+	}
+	witness.Signatures[slot] = signature
+	return nil
+}
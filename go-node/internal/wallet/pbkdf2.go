@@ -0,0 +1,36 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+// pbkdf2Key is PBKDF2 (RFC 8018) with HMAC over newHash. Mnemonic seeds
+// use it with SHA-512.
+func pbkdf2Key(newHash func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(newHash, password)
+	out := make([]byte, 0, keyLen+prf.Size())
+	var counter [4]byte
+	u := make([]byte, prf.Size())
+	t := make([]byte, prf.Size())
+
+	for block := uint32(1); len(out) < keyLen; block++ {
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
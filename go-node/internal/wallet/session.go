@@ -0,0 +1,136 @@
+package wallet
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultUnlockTimeout = 5 * time.Minute
+	MaxUnlockTimeout     = time.Hour
+)
+
+var (
+	ErrWalletLocked   = errors.New("wallet is locked: unlock it with POST /api/wallet/unlock")
+	ErrInvalidSession = errors.New("unlock session is invalid or expired")
+)
+
+// Session is a time-limited permission to sign with one wallet.
+type Session struct {
+	Token     string `json:"token"`
+	Address   string `json:"address"`
+	ExpiresAt int64  `json:"expires_at"`
+
+	expires time.Time
+}
+
+// Sessions tracks unlock sessions. Expired sessions are refused as soon as
+// they expire and swept from memory by Run.
+type Sessions struct {
+	mu       sync.Mutex
+	sessions map[string]*Session // token -> session
+}
+
+func NewSessions() *Sessions {
+	return &Sessions{sessions: make(map[string]*Session)}
+}
+
+// Unlock opens a session for address lasting timeout, clamped to
+// MaxUnlockTimeout (0 = DefaultUnlockTimeout).
+func (s *Sessions) Unlock(address string, timeout time.Duration) (*Session, error) {
+	if timeout <= 0 {
+		timeout = DefaultUnlockTimeout
+	}
+	if timeout > MaxUnlockTimeout {
+		timeout = MaxUnlockTimeout
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	expires := time.Now().Add(timeout)
+	session := &Session{
+		Token:     hex.EncodeToString(raw),
+		Address:   address,
+		ExpiresAt: expires.Unix(),
+		expires:   expires,
+	}
+
+	s.mu.Lock()
+	s.sessions[session.Token] = session
+	s.mu.Unlock()
+
+	return session, nil
+}
+
+// Check reports whether token is a live session for address.
+func (s *Sessions) Check(token, address string) error {
+	if token == "" {
+		return ErrWalletLocked
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[token]
+	if !ok || session.Address != address {
+		return ErrInvalidSession
+	}
+	if time.Now().After(session.expires) {
+		delete(s.sessions, token)
+		return ErrInvalidSession
+	}
+	return nil
+}
+
+// Lock ends one session, returning false if it did not exist.
+func (s *Sessions) Lock(token string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.sessions[token]
+	delete(s.sessions, token)
+	return ok
+}
+
+// LockAddress ends every session for address and returns how many there were.
+func (s *Sessions) LockAddress(address string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for token, session := range s.sessions {
+		if session.Address == address {
+			delete(s.sessions, token)
+			n++
+		}
+	}
+	return n
+}
+
+// Run removes expired sessions until ctx is cancelled.
+func (s *Sessions) Run(ctx context.Context) {
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for token, session := range s.sessions {
+				if now.After(session.expires) {
+					delete(s.sessions, token)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package wallet
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const URIScheme = "coin"
+
+var ErrInvalidURI = &WalletError{Message: "invalid payment URI"}
+
+// PaymentURI is a BIP21-style payment request:
+// coin:<address>?amount=<coins>&label=<text>&memo=<text>
+type PaymentURI struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount,omitempty"`
+	Label   string  `json:"label,omitempty"`
+	Memo    string  `json:"memo,omitempty"`
+}
+
+func (p PaymentURI) String() string {
+	params := url.Values{}
+	if p.Amount > 0 {
+		params.Set("amount", strconv.FormatFloat(p.Amount, 'f', -1, 64))
+	}
+	if p.Label != "" {
+		params.Set("label", p.Label)
+	}
+	if p.Memo != "" {
+		params.Set("memo", p.Memo)
+	}
+
+	uri := URIScheme + ":" + p.Address
+	if encoded := params.Encode(); encoded != "" {
+		uri += "?" + encoded
+	}
+	return uri
+}
+
+func ParsePaymentURI(raw string) (*PaymentURI, error) {
+	raw = strings.TrimSpace(raw)
+	prefix := URIScheme + ":"
+	if len(raw) < len(prefix) || !strings.EqualFold(raw[:len(prefix)], prefix) {
+		return nil, ErrInvalidURI
+	}
+
+	rest := strings.TrimPrefix(raw[len(prefix):], "//")
+	address, query, _ := strings.Cut(rest, "?")
+	if err := ValidateAddress(address); err != nil {
+		return nil, err
+	}
+
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, ErrInvalidURI
+	}
+
+	uri := &PaymentURI{
+		Address: address,
+		Label:   params.Get("label"),
+		Memo:    params.Get("memo"),
+	}
+
+	for key := range params {
+		if strings.HasPrefix(key, "req-") {
+			// BIP21: unknown required parameters make the URI unusable.
+			return nil, &WalletError{Message: "unsupported required URI parameter: " + key}
+		}
+	}
+
+	if amount := params.Get("amount"); amount != "" {
+		uri.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil || uri.Amount <= 0 {
+			return nil, &WalletError{Message: "invalid amount in payment URI"}
+		}
+	}
+
+	return uri, nil
+}
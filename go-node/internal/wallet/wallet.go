@@ -2,10 +2,9 @@ package wallet
 
 import (
 	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
-	"encoding/hex"
+	"fmt"
 	"sync"
 
 	"ai-blockchain/go-node/internal/chain"
@@ -13,43 +12,81 @@ import (
 )
 
 type Wallet struct {
-	Address    string           // Derived from public key
+	Address    string            // Derived from public key
 	PrivateKey *ecdsa.PrivateKey // Private key (NEVER expose!)
 	PublicKey  *ecdsa.PublicKey  // Public key (can be shared)
+	Label      string            // Free-form name shown in listings
+	Owner      string            // Address of the wallet this one belongs to, e.g. its change
+	HD         string            // ID of the HD wallet the key was derived from, if any
+	Path       string            // and its derivation path
 }
 
 type WalletStore struct {
-	mu      sync.RWMutex
-	wallets map[string]*Wallet // address -> wallet
+	mu       sync.RWMutex
+	wallets  map[string]*Wallet            // address -> wallet
+	contacts map[string]map[string]Contact // owner address -> lowercase name -> contact
+	hd       map[string]*hdWallet          // ID -> HD wallet
+	multisig map[string]*multisigAccount   // address -> m-of-n key set
+	watch    map[string]*watchAddress      // address -> watch-only address
+	keystore *keystore                     // nil = keys live in memory only
+	devSeed  *crypto.DevSeed               // nil = keys come from crypto/rand
+
+	onSaveError func(error) // nil = keystore write failures only reach the caller
 }
 
 func NewWalletStore() *WalletStore {
 	return &WalletStore{
-		wallets: make(map[string]*Wallet),
+		wallets:  make(map[string]*Wallet),
+		contacts: make(map[string]map[string]Contact),
+		hd:       make(map[string]*hdWallet),
+		multisig: make(map[string]*multisigAccount),
+		watch:    make(map[string]*watchAddress),
 	}
 }
 
 func (ws *WalletStore) GenerateWallet() (*Wallet, error) {
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	return ws.GenerateLabeledWallet("", "")
+}
+
+// GenerateLabeledWallet generates a wallet carrying a label and the address
+// of the wallet that owns it, either of which may be empty. Listings can be
+// filtered by both.
+func (ws *WalletStore) GenerateLabeledWallet(label, owner string) (*Wallet, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return nil, err
+	}
+	if owner != "" {
+		if err := ValidateAddress(owner); err != nil {
+			return nil, err
+		}
+	}
+	if ws.Locked() {
+		return nil, ErrStoreLocked
+	}
+
+	privateKey, err := ws.newKey()
 	if err != nil {
 		return nil, err
 	}
 
-	publicKeyBytes := append(
-		privateKey.PublicKey.X.Bytes(),
-		privateKey.PublicKey.Y.Bytes()...,
-	)
-	address := crypto.SHA256(publicKeyBytes)
+	address := crypto.AddressFromPublicKey(&privateKey.PublicKey)
 
 	wallet := &Wallet{
 		Address:    address,
 		PrivateKey: privateKey,
 		PublicKey:  &privateKey.PublicKey,
+		Label:      label,
+		Owner:      owner,
 	}
 
 	ws.mu.Lock()
+	defer ws.mu.Unlock()
 	ws.wallets[address] = wallet
-	ws.mu.Unlock()
+	if err := ws.saveLocked(); err != nil {
+		delete(ws.wallets, address)
+		return nil, err
+	}
 
 	return wallet, nil
 }
@@ -77,21 +114,117 @@ func (ws *WalletStore) BuildAndSignTransaction(
 	amount float64,
 	utxo *chain.UTXOSet,
 ) (*chain.Transaction, error) {
-	wallet := ws.GetWallet(fromAddress)
+	return ws.BuildAndSignTransactionWithFee(fromAddress, toAddress, amount, 0, utxo)
+}
+
+// BuildAndSignTransactionWithFee is BuildAndSignTransaction leaving fee
+// unclaimed for the miner; inputs are selected to cover amount plus fee.
+func (ws *WalletStore) BuildAndSignTransactionWithFee(
+	fromAddress string,
+	toAddress string,
+	amount float64,
+	fee float64,
+	utxo *chain.UTXOSet,
+) (*chain.Transaction, error) {
+	return ws.BuildAndSignTransfer(fromAddress, toAddress, amount, fee, fromAddress, "", utxo)
+}
+
+// BuildAndSignTransfer is BuildAndSignTransactionWithFee returning the
+// change to changeAddress (see BuildTransactionWithChange) and carrying
+// memo, which the signature covers. An encrypted memo comes from EncryptMemo.
+func (ws *WalletStore) BuildAndSignTransfer(
+	fromAddress string,
+	toAddress string,
+	amount float64,
+	fee float64,
+	changeAddress string,
+	memo string,
+	utxo *chain.UTXOSet,
+) (*chain.Transaction, error) {
+	if _, err := ws.signingKey(fromAddress); err != nil {
+		return nil, err
+	}
+
+	tx, err := ws.BuildTransactionWithChange(fromAddress, toAddress, amount, fee, changeAddress, utxo)
+	if err != nil {
+		return nil, err
+	}
+	tx.Memo = memo
+
+	if err := ws.SignTransaction(fromAddress, tx, utxo); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (ws *WalletStore) signingKey(address string) (*ecdsa.PrivateKey, error) {
+	wallet := ws.GetWallet(address)
 	if wallet == nil {
 		return nil, ErrWalletNotFound
 	}
+	ws.mu.RLock()
+	privateKey := wallet.PrivateKey
+	ws.mu.RUnlock()
+	if privateKey == nil {
+		return nil, ErrStoreLocked
+	}
+	return privateKey, nil
+}
+
+// BuildTransaction selects inputs owned by fromAddress and returns an
+// unsigned transaction paying amount to toAddress, leaving fee for the miner
+// and returning the rest as change. Its ID is already the canonical txid.
+func (ws *WalletStore) BuildTransaction(
+	fromAddress string,
+	toAddress string,
+	amount float64,
+	fee float64,
+	utxo *chain.UTXOSet,
+) (*chain.Transaction, error) {
+	return ws.BuildTransactionWithChange(fromAddress, toAddress, amount, fee, fromAddress, utxo)
+}
+
+// BuildTransactionWithChange is BuildTransaction paying the change to
+// changeAddress. Outputs end up in canonical order, sorted by address, so
+// the change output's position says nothing by itself; what gives it away is
+// going back to the address the inputs came from. Change sent to a fresh
+// address sorts before or after the payment at random and looks like any
+// other payment. Spending from a multisig account of the store, the
+// transaction carries the account's witness with every signature slot
+// empty, ready for SignMultisig.
+func (ws *WalletStore) BuildTransactionWithChange(
+	fromAddress string,
+	toAddress string,
+	amount float64,
+	fee float64,
+	changeAddress string,
+	utxo *chain.UTXOSet,
+) (*chain.Transaction, error) {
+	witness := ws.multisigSpend(fromAddress)
+	if ws.GetWallet(fromAddress) == nil && witness == nil {
+		return nil, ErrWalletNotFound
+	}
+
+	if err := chain.CheckAmount(amount); err != nil {
+		return nil, err
+	}
+	if err := chain.CheckAmount(fee); err != nil {
+		return nil, fmt.Errorf("fee: %w", err)
+	}
 
-	total, selected := utxo.FindSpendableOutputs(fromAddress, amount)
-	if total < amount {
-		return nil, ErrInsufficientFunds
+	amount = chain.RoundAmount(amount)
+	fee = chain.RoundAmount(fee)
+
+	selected, total, err := SelectCoins(utxo.UnspentOutputs(fromAddress), chain.RoundAmount(amount+fee))
+	if err != nil {
+		return nil, err
 	}
 
 	inputs := make([]chain.TxIn, 0, len(selected))
-	for _, key := range selected {
+	for _, coin := range selected {
 		inputs = append(inputs, chain.TxIn{
-			TxID:  key.TxID,
-			Index: key.Index,
+			TxID:  coin.Key.TxID,
+			Index: coin.Key.Index,
 		})
 	}
 
@@ -102,10 +235,10 @@ func (ws *WalletStore) BuildAndSignTransaction(
 		},
 	}
 
-	change := total - amount
+	change := chain.RoundAmount(total - amount - fee)
 	if change > 0 {
 		outputs = append(outputs, chain.TxOut{
-			Address: fromAddress,
+			Address: changeAddress,
 			Amount:  change,
 		})
 	}
@@ -114,42 +247,61 @@ func (ws *WalletStore) BuildAndSignTransaction(
 	if err != nil {
 		return nil, err
 	}
+	tx.Multisig = witness
+	return tx, nil
+}
+
+// SignTransaction signs tx with the wallet at fromAddress. Every input must
+// spend an output of that wallet in utxo, and tx.ID is set to the canonical
+// txid so callers in other languages never have to compute it themselves.
+func (ws *WalletStore) SignTransaction(fromAddress string, tx *chain.Transaction, utxo *chain.UTXOSet) error {
+	privateKey, err := ws.signingKey(fromAddress)
+	if err != nil {
+		return err
+	}
+
+	if len(tx.Inputs) == 0 {
+		return ErrForeignInput
+	}
+	for _, in := range tx.Inputs {
+		out, ok := utxo.Get(chain.UTXOKey{TxID: in.TxID, Index: in.Index})
+		if !ok || out.Address != fromAddress {
+			return ErrForeignInput
+		}
+	}
+
+	id, err := chain.ComputeTxID(tx)
+	if err != nil {
+		return err
+	}
+	tx.ID = id
 
 	canonicalBytes, err := chain.CanonicalTxBytes(tx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	hash := sha256.Sum256(canonicalBytes)
 
-	r, s, err := ecdsa.Sign(rand.Reader, wallet.PrivateKey, hash[:])
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, hash[:])
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	rBytes := r.Bytes()
-	sBytes := s.Bytes()
-	signatureBytes := append(rBytes, sBytes...)
-	tx.Signature = hex.EncodeToString(signatureBytes)
-
-	xBytes := wallet.PublicKey.X.Bytes()
-	yBytes := wallet.PublicKey.Y.Bytes()
-	pubKeyBytes := append(xBytes, yBytes...)
-	tx.PubKey = hex.EncodeToString(pubKeyBytes)
+	tx.Signature = crypto.EncodeSignature(r, s)
+	tx.PubKey = EncodePublicKey(&privateKey.PublicKey)
 
-	return tx, nil
+	return nil
 }
 
 func EncodePublicKey(pub *ecdsa.PublicKey) string {
-	xBytes := pub.X.Bytes()
-	yBytes := pub.Y.Bytes()
-	combined := append(xBytes, yBytes...)
-	return hex.EncodeToString(combined)
+	return crypto.EncodePublicKey(pub)
 }
 
 var (
-	ErrWalletNotFound = &WalletError{Message: "wallet not found"}
+	ErrWalletNotFound    = &WalletError{Message: "wallet not found"}
 	ErrInsufficientFunds = &WalletError{Message: "insufficient funds"}
+	ErrForeignInput      = &WalletError{Message: "transaction spends outputs the wallet does not own"}
 )
 
 type WalletError struct {
@@ -159,7 +311,3 @@ type WalletError struct {
 func (e *WalletError) Error() string {
 	return e.Message
 }
-
-
-
-
@@ -0,0 +1,66 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"ai-blockchain/go-node/internal/chain"
+)
+
+// changeIndex builds a transfer from sender to payee with change to change
+// and returns the position the change output ended up at.
+func changeIndex(t *testing.T, ws *WalletStore, sender, payee, change string, utxo *chain.UTXOSet) int {
+	t.Helper()
+	tx, err := ws.BuildTransactionWithChange(sender, payee, 1, 0.1, change, utxo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tx.Outputs) != 2 {
+		t.Fatalf("%d outputs, want payment and change", len(tx.Outputs))
+	}
+	if tx.Outputs[0].Address > tx.Outputs[1].Address {
+		t.Fatalf("outputs not in canonical order: %+v", tx.Outputs)
+	}
+	for i, out := range tx.Outputs {
+		if out.Address == change {
+			return i
+		}
+	}
+	t.Fatalf("no output pays the change address %s", change)
+	return -1
+}
+
+func TestChangeOutputPosition(t *testing.T) {
+	ws := NewWalletStore()
+	sender, err := ws.GenerateWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	utxo := chain.NewUTXOSet()
+	utxo.Add(strings.Repeat("1", 64), 0, chain.TxOut{Address: sender.Address, Amount: 10})
+	payee := strings.Repeat("8", 64)
+
+	// Change back to the sender lands wherever its address sorts: the
+	// position is fixed, which is why the address gives it away.
+	want := 1
+	if sender.Address < payee {
+		want = 0
+	}
+	if got := changeIndex(t, ws, sender.Address, payee, sender.Address, utxo); got != want {
+		t.Errorf("change to the sender at %d, want %d", got, want)
+	}
+
+	// Change to fresh addresses sorts before or after the payment at
+	// random; 64 draws all landing on one side has odds of 2^-63.
+	var seen [2]int
+	for i := 0; i < 64; i++ {
+		change, err := ws.GenerateWallet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[changeIndex(t, ws, sender.Address, payee, change.Address, utxo)]++
+	}
+	if seen[0] == 0 || seen[1] == 0 {
+		t.Errorf("fresh change always at the same position: %v", seen)
+	}
+}
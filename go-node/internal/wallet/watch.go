@@ -0,0 +1,108 @@
+package wallet
+
+import (
+	"sort"
+	"strings"
+)
+
+// Watch-only addresses are tracked like wallets, in listings, history and
+// wallet events, but the store holds no key for them: they are for keeping
+// an eye on cold storage and other addresses whose keys live elsewhere.
+// Importing the key of a watched address turns it into a wallet.
+
+var (
+	ErrWatchNotFound = &WalletError{Message: "watch-only address not found"}
+	ErrWatchExists   = &WalletError{Message: "this address is already in the store"}
+)
+
+type watchAddress struct {
+	address string
+	label   string
+}
+
+func (a *watchAddress) info() WalletInfo {
+	return WalletInfo{Address: a.address, Label: a.label, WatchOnly: true}
+}
+
+// WatchAddress adds address, which may be a multisig address, to the store
+// as watch-only.
+func (ws *WalletStore) WatchAddress(address, label string) (WalletInfo, error) {
+	label, err := normalizeLabel(label)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	address = strings.TrimSpace(address)
+	if err := ValidateAddress(address); err != nil {
+		return WalletInfo{}, err
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return WalletInfo{}, ErrStoreLocked
+	}
+	if _, ok := ws.wallets[address]; ok {
+		return WalletInfo{}, ErrWatchExists
+	}
+	if _, ok := ws.watch[address]; ok {
+		return WalletInfo{}, ErrWatchExists
+	}
+	watched := &watchAddress{address: address, label: label}
+	ws.watch[address] = watched
+	if err := ws.saveLocked(); err != nil {
+		delete(ws.watch, address)
+		return WalletInfo{}, err
+	}
+	return watched.info(), nil
+}
+
+// UnwatchAddress removes a watch-only address from the store.
+func (ws *WalletStore) UnwatchAddress(address string) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.keystore != nil && ws.keystore.key == nil {
+		return ErrStoreLocked
+	}
+	watched, ok := ws.watch[address]
+	if !ok {
+		return ErrWatchNotFound
+	}
+	delete(ws.watch, address)
+	if err := ws.saveLocked(); err != nil {
+		ws.watch[address] = watched
+		return err
+	}
+	return nil
+}
+
+// WatchedAddresses lists the watch-only addresses by address.
+func (ws *WalletStore) WatchedAddresses() []WalletInfo {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	infos := make([]WalletInfo, 0, len(ws.watch))
+	for _, watched := range ws.watch {
+		infos = append(infos, watched.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Address < infos[j].Address })
+	return infos
+}
+
+// IsWatchOnly reports whether address is a watch-only address of the store.
+func (ws *WalletStore) IsWatchOnly(address string) bool {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	_, ok := ws.watch[address]
+	return ok
+}
+
+// Tracks reports whether the store follows address, as a wallet or as a
+// watch-only address, and which.
+func (ws *WalletStore) Tracks(address string) (tracked, watchOnly bool) {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	if _, ok := ws.wallets[address]; ok {
+		return true, false
+	}
+	_, ok := ws.watch[address]
+	return ok, ok
+}